@@ -64,21 +64,7 @@ func initializeEmailNotification(opts backupModeOptions, orch *orchestrator.Orch
 	}
 
 	logging.DebugStep(logger, "notifications init", "email enabled")
-	emailConfig := notify.EmailConfig{
-		Enabled:          true,
-		DeliveryMethod:   notify.EmailDeliveryMethod(cfg.EmailDeliveryMethod),
-		FallbackSendmail: cfg.EmailFallbackSendmail,
-		Recipient:        cfg.EmailRecipient,
-		From:             cfg.EmailFrom,
-		CloudRelayConfig: notify.CloudRelayConfig{
-			WorkerURL:   cfg.CloudflareWorkerURL,
-			WorkerToken: cfg.CloudflareWorkerToken,
-			HMACSecret:  cfg.CloudflareHMACSecret,
-			Timeout:     cfg.WorkerTimeout,
-			MaxRetries:  cfg.WorkerMaxRetries,
-			RetryDelay:  cfg.WorkerRetryDelay,
-		},
-	}
+	emailConfig := emailNotifierConfig(cfg)
 	emailNotifier, err := notify.NewEmailNotifier(emailConfig, opts.envInfo.Type, logger)
 	if err != nil {
 		logging.Warning("Failed to initialize Email notifier: %v", err)
@@ -218,20 +204,7 @@ func initializeTelegramNotification(opts backupModeOptions, orch *orchestrator.O
 	}
 
 	logging.DebugStep(logger, "notifications init", "telegram enabled (mode=%s)", cfg.TelegramBotType)
-	telegramConfig := notify.TelegramConfig{
-		Enabled:       true,
-		Mode:          notify.TelegramMode(cfg.TelegramBotType),
-		BotToken:      cfg.TelegramBotToken,
-		ChatID:        cfg.TelegramChatID,
-		ServerAPIHost: cfg.ServerAPIHost,
-		ServerID:      cfg.ServerID,
-		NotifySecret:  cfg.TelegramNotifySecret,
-		BaseDir:       cfg.BaseDir,
-
-		ConfirmDelivery: cfg.TelegramConfirmDelivery,
-		ConfirmTimeout:  time.Duration(cfg.TelegramConfirmTimeoutS) * time.Second,
-		ConfirmInterval: time.Duration(cfg.TelegramConfirmIntervalS) * time.Second,
-	}
+	telegramConfig := telegramNotifierConfig(cfg)
 	telegramNotifier, err := notify.NewTelegramNotifier(telegramConfig, logger)
 	if err != nil {
 		logging.Warning("Failed to initialize Telegram notifier: %v", err)
@@ -252,14 +225,7 @@ func initializeGotifyNotification(opts backupModeOptions, orch *orchestrator.Orc
 	}
 
 	logging.DebugStep(logger, "notifications init", "gotify enabled")
-	gotifyConfig := notify.GotifyConfig{
-		Enabled:         true,
-		ServerURL:       cfg.GotifyServerURL,
-		Token:           cfg.GotifyToken,
-		PrioritySuccess: cfg.GotifyPrioritySuccess,
-		PriorityWarning: cfg.GotifyPriorityWarning,
-		PriorityFailure: cfg.GotifyPriorityFailure,
-	}
+	gotifyConfig := gotifyNotifierConfig(cfg)
 	gotifyNotifier, err := notify.NewGotifyNotifier(gotifyConfig, logger)
 	if err != nil {
 		logging.Warning("Failed to initialize Gotify notifier: %v", err)
@@ -297,6 +263,55 @@ func initializeWebhookNotification(opts backupModeOptions, orch *orchestrator.Or
 	logging.Info("✓ Webhook initialized (%d endpoint(s))", len(webhookConfig.Endpoints))
 }
 
+// emailNotifierConfig maps the EMAIL_* settings onto the notifier config.
+func emailNotifierConfig(cfg *config.Config) notify.EmailConfig {
+	return notify.EmailConfig{
+		Enabled:          true,
+		DeliveryMethod:   notify.EmailDeliveryMethod(cfg.EmailDeliveryMethod),
+		FallbackSendmail: cfg.EmailFallbackSendmail,
+		Recipient:        cfg.EmailRecipient,
+		From:             cfg.EmailFrom,
+		CloudRelayConfig: notify.CloudRelayConfig{
+			WorkerURL:   cfg.CloudflareWorkerURL,
+			WorkerToken: cfg.CloudflareWorkerToken,
+			HMACSecret:  cfg.CloudflareHMACSecret,
+			Timeout:     cfg.WorkerTimeout,
+			MaxRetries:  cfg.WorkerMaxRetries,
+			RetryDelay:  cfg.WorkerRetryDelay,
+		},
+	}
+}
+
+// telegramNotifierConfig maps the TELEGRAM_* settings onto the notifier config.
+func telegramNotifierConfig(cfg *config.Config) notify.TelegramConfig {
+	return notify.TelegramConfig{
+		Enabled:       true,
+		Mode:          notify.TelegramMode(cfg.TelegramBotType),
+		BotToken:      cfg.TelegramBotToken,
+		ChatID:        cfg.TelegramChatID,
+		ServerAPIHost: cfg.ServerAPIHost,
+		ServerID:      cfg.ServerID,
+		NotifySecret:  cfg.TelegramNotifySecret,
+		BaseDir:       cfg.BaseDir,
+
+		ConfirmDelivery: cfg.TelegramConfirmDelivery,
+		ConfirmTimeout:  time.Duration(cfg.TelegramConfirmTimeoutS) * time.Second,
+		ConfirmInterval: time.Duration(cfg.TelegramConfirmIntervalS) * time.Second,
+	}
+}
+
+// gotifyNotifierConfig maps the GOTIFY_* settings onto the notifier config.
+func gotifyNotifierConfig(cfg *config.Config) notify.GotifyConfig {
+	return notify.GotifyConfig{
+		Enabled:         true,
+		ServerURL:       cfg.GotifyServerURL,
+		Token:           cfg.GotifyToken,
+		PrioritySuccess: cfg.GotifyPrioritySuccess,
+		PriorityWarning: cfg.GotifyPriorityWarning,
+		PriorityFailure: cfg.GotifyPriorityFailure,
+	}
+}
+
 func logBackupRuntimeSummary(cfg *config.Config, logger *logging.Logger, storageState backupStorageState) {
 	logBackupStorageSummary(cfg, storageState)
 	logBackupLogSummary(cfg)
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/tis24dev/proxsave/internal/checks"
+	"github.com/tis24dev/proxsave/internal/config"
 	"github.com/tis24dev/proxsave/internal/logging"
 	"github.com/tis24dev/proxsave/internal/orchestrator"
 	"github.com/tis24dev/proxsave/internal/storage"
@@ -42,12 +43,29 @@ func initializeBackupStorage(opts backupModeOptions, orch *orchestrator.Orchestr
 
 	state.secondaryFS = initializeSecondaryStorage(opts, orch)
 	state.cloudFS = initializeCloudStorage(opts, orch, checker)
+	resolveArchiveVolumeSize(cfg, state)
 	storageDone(nil)
 
 	fmt.Println()
 	return state, nil, types.ExitSuccess.Int()
 }
 
+// resolveArchiveVolumeSize applies ARCHIVE_VOLUME_SIZE=auto: archives are split
+// below the 4 GiB file size limit when the primary or secondary path is on FAT.
+func resolveArchiveVolumeSize(cfg *config.Config, state backupStorageState) {
+	if !cfg.ArchiveVolumeAuto {
+		return
+	}
+	cfg.ArchiveVolumeSize = 0
+	for _, fs := range []*storage.FilesystemInfo{state.localFS, state.secondaryFS} {
+		if fs != nil && (fs.Type == storage.FilesystemFAT32 || fs.Type == storage.FilesystemFAT) {
+			cfg.ArchiveVolumeSize = config.FATArchiveVolumeSize
+			logging.Info("Archive volumes: %s is on %s, archives larger than 4 GiB are split into volumes", fs.Path, fs.Type)
+			return
+		}
+	}
+}
+
 func initializePrimaryStorage(opts backupModeOptions) (storage.Storage, *storage.FilesystemInfo, string, error) {
 	cfg := opts.cfg
 	logger := opts.logger
@@ -86,6 +86,9 @@ type daemon struct {
 	provisionRetryAt time.Time // next relay-secret self-heal attempt; guarded by mu
 	// newBackupCmd builds the child backup command; overridable in tests.
 	newBackupCmd func(ctx context.Context) *exec.Cmd
+	// control is the live scheduler/run state served on the control socket (daemon_control.go);
+	// nil in tests that drive the loops directly.
+	control *daemonControl
 
 	// statusMu serializes writes to the shared healthcheck status file: the
 	// heartbeat loop and runOnce record ping outcomes concurrently, and
@@ -103,6 +106,7 @@ func runDaemon(rt *appRuntime) int {
 		configPath: rt.args.ConfigPath,
 		now:        time.Now,
 	}
+	d.control = newDaemonControl(d.now().Unix())
 	// The status file is written only by this daemon; surface a corrupt-file
 	// self-heal (health quarantines the unreadable bytes and resets) as a debug
 	// line, since the health package is deliberately logger-free.
@@ -172,6 +176,15 @@ func (d *daemon) run(ctx context.Context) int {
 	}
 
 	var wg sync.WaitGroup
+	// The control socket lets the dashboard and scripts query and drive the scheduler directly
+	// instead of reading the pid/info/status files. It stops with ctx and joins the waitgroup.
+	if d.control != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.serveControl(ctx)
+		}()
+	}
 	// The manual-outcome waker runs regardless of the heartbeat/update loops: it must receive
 	// SIGUSR1 (so the default terminate action never fires) even when a piece of the healthcheck
 	// wiring is off. processManualOutcome is itself a no-op when healthchecks are disabled or
@@ -191,6 +204,13 @@ func (d *daemon) run(ctx context.Context) int {
 		}
 	}()
 
+	// Cloud uploads deferred outside CLOUD_UPLOAD_WINDOW are drained here once the window opens.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.uploadQueueLoop(ctx)
+	}()
+
 	if d.cfg.HealthcheckEnabled {
 		wg.Add(1)
 		go func() {
@@ -272,8 +292,8 @@ func (d *daemon) processManualOutcome(ctx context.Context) {
 	}
 }
 
-// scheduleLoop waits for the next daily run time and supervises a backup, until
-// the context is cancelled.
+// scheduleLoop waits for the next daily run time (or a manual run queued on the
+// control socket) and supervises a backup, until the context is cancelled.
 func (d *daemon) scheduleLoop(ctx context.Context) {
 	for {
 		next, err := cron.NextDaily(d.now(), d.cfg.SchedulerTime)
@@ -286,6 +306,7 @@ func (d *daemon) scheduleLoop(ctx context.Context) {
 			wait = 0
 		}
 		logging.Info("daemon: next backup at %s (in %s)", next.Format("2006-01-02 15:04"), wait.Round(time.Second))
+		d.setNextRun(next)
 
 		timer := time.NewTimer(wait)
 		select {
@@ -294,14 +315,23 @@ func (d *daemon) scheduleLoop(ctx context.Context) {
 			return
 		case <-timer.C:
 			d.runOnce(ctx)
+		case <-d.manualTrigger():
+			timer.Stop()
+			d.runOnceAs(ctx, health.RunTriggerManual)
 		}
 	}
 }
 
-// runOnce launches ONE supervised backup as a child process under a hard timeout
-// and reports the outcome. A child that exceeds the budget is SIGTERM'd, then
-// SIGKILL'd, and reported as a hang.
+// runOnce launches ONE scheduled supervised backup (see runOnceAs).
 func (d *daemon) runOnce(parentCtx context.Context) {
+	d.runOnceAs(parentCtx, health.RunTriggerScheduled)
+}
+
+// runOnceAs launches ONE supervised backup as a child process under a hard timeout
+// and reports the outcome. A child that exceeds the budget is SIGTERM'd, then
+// SIGKILL'd, and reported as a hang. A run cancelled on the control socket is
+// SIGTERM'd the same way but, like a shutdown, pings no outcome.
+func (d *daemon) runOnceAs(parentCtx context.Context, trigger string) {
 	if parentCtx.Err() != nil { // shutting down: do not start a run
 		return
 	}
@@ -317,8 +347,13 @@ func (d *daemon) runOnce(parentCtx context.Context) {
 	rid := health.NewRunID()
 	d.reportBestEffort("start", false, func() error { return d.startPing(parentCtx, r, rid) })
 
-	runCtx, cancel := context.WithTimeout(parentCtx, d.maxRunDuration())
+	opCtx, opCancel := context.WithCancel(parentCtx)
+	defer opCancel()
+	runCtx, cancel := context.WithTimeout(opCtx, d.maxRunDuration())
 	defer cancel()
+	result, exitCode := health.RunResultCanceled, -1
+	d.beginRun(rid, trigger, opCancel)
+	defer func() { d.endRun(result, exitCode) }()
 
 	// Capture the child's combined output (bounded) so a non-success outcome can
 	// POST a real log tail; the output still streams to journald via os.Std*.
@@ -329,6 +364,14 @@ func (d *daemon) runOnce(parentCtx context.Context) {
 	cmd := d.buildBackupCmd(runCtx, tail, rid)
 	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
 	cmd.WaitDelay = daemonKillGrace
+	if d.control != nil {
+		stdout := &runLogWriter{hub: &d.control.logs, rid: rid, now: d.now}
+		stderr := &runLogWriter{hub: &d.control.logs, rid: rid, now: d.now}
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, stdout)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+		defer stdout.flush()
+		defer stderr.flush()
+	}
 
 	logging.Info("daemon: launching backup (rid=%s timeout=%s)", rid, d.maxRunDuration())
 	runErr := cmd.Run()
@@ -345,20 +388,34 @@ func (d *daemon) runOnce(parentCtx context.Context) {
 	}
 
 	if runCtx.Err() == context.DeadlineExceeded {
+		result = health.RunResultHang
 		logging.Error("daemon: backup exceeded %s and was killed (hang)", d.maxRunDuration())
 		d.reportBestEffort("hang", true, func() error { return d.hangPing(parentCtx, r, rid, logBody) })
 		return
 	}
 
+	// Cancelled on the control socket: an operator decision, not an outcome. Stay silent
+	// like the shutdown path above; the run is reported as canceled on the socket.
+	if opCtx.Err() != nil {
+		logging.Info("daemon: backup cancelled (rid=%s, no outcome ping)", rid)
+		return
+	}
+
 	code := exitCodeFromErr(runErr)
+	exitCode = code
 	// A child that exits ExitBackupSkipped did NOT back up (another backup was already running,
 	// or BACKUP_ENABLED was re-read as false): there is no outcome to ping. Stay silent, exactly
 	// like the honest disabled skip above, so the backup-outcome check does not go a false green
 	// (F09-03). The real backup that holds the lock reports its own outcome.
 	if code == types.ExitBackupSkipped.Int() {
+		result = health.RunResultSkipped
 		logging.Info("daemon: scheduled run skipped, no backup performed (rid=%s, no outcome ping)", rid)
 		return
 	}
+	result = health.RunResultSuccess
+	if code != 0 {
+		result = health.RunResultFailed
+	}
 	logging.Info("daemon: backup finished (rid=%s exit=%d)", rid, code)
 	d.reportBestEffort("finish", code != 0, func() error { return d.finishPing(parentCtx, r, rid, code, logBody) })
 
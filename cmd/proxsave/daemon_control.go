@@ -0,0 +1,270 @@
+// Package main contains the proxsave command entrypoint.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/health"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/version"
+)
+
+const (
+	// runLogSubscriberBuffer is how many lines a slow subscriber may lag before lines are dropped
+	// for it; the child's output is never blocked by a reader.
+	runLogSubscriberBuffer = 256
+	// runLogMaxLine truncates a single output line forwarded to subscribers.
+	runLogMaxLine = 4 * 1024
+)
+
+// daemonControl is the live state the daemon exposes on the control socket (health.ControlServer).
+// It is guarded by its own mutex so a status query never waits on reporter resolution or a status
+// file write.
+type daemonControl struct {
+	mu        sync.Mutex
+	startTS   int64
+	nextRun   time.Time
+	running   *health.RunInfo
+	runCancel context.CancelFunc
+	lastRun   *health.RunOutcome
+	// trigger queues one manual run for scheduleLoop (buffered 1: a second request while one is
+	// queued is refused, not stacked).
+	trigger chan struct{}
+	logs    runLogHub
+}
+
+func newDaemonControl(startTS int64) *daemonControl {
+	return &daemonControl{startTS: startTS, trigger: make(chan struct{}, 1)}
+}
+
+// serveControl runs the control socket until ctx is cancelled. Best-effort like the pid/info files:
+// a daemon that cannot bind the socket keeps scheduling backups.
+func (d *daemon) serveControl(ctx context.Context) {
+	path := health.ControlSocketPath(d.cfg.BaseDir)
+	srv, err := health.ListenControl(path, d)
+	if err != nil {
+		logging.Warning("daemon: control socket disabled: %v", err)
+		return
+	}
+	logging.Debug("daemon: control socket listening on %s", path)
+	if err := srv.Serve(ctx); err != nil {
+		logging.Warning("daemon: control socket stopped: %v", err)
+	}
+}
+
+// RuntimeStatus implements health.ControlHandler.
+func (d *daemon) RuntimeStatus() health.DaemonRuntime {
+	st := health.DaemonRuntime{PID: os.Getpid(), Version: version.String()}
+	c := d.control
+	if c == nil {
+		return st
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st.StartTS = c.startTS
+	if !c.nextRun.IsZero() {
+		st.NextRunTS = c.nextRun.Unix()
+	}
+	st.Queued = len(c.trigger) > 0
+	if c.running != nil {
+		running := *c.running
+		st.Running = &running
+	}
+	if c.lastRun != nil {
+		last := *c.lastRun
+		st.LastRun = &last
+	}
+	return st
+}
+
+// TriggerRun implements health.ControlHandler: queue a manual run for scheduleLoop.
+func (d *daemon) TriggerRun() error {
+	c := d.control
+	if c == nil {
+		return errors.New("the daemon scheduler is not running")
+	}
+	if !d.cfg.BackupEnabled {
+		return errors.New("backups are disabled (BACKUP_ENABLED=false)")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running != nil {
+		return fmt.Errorf("a backup is already running (rid=%s)", c.running.RID)
+	}
+	select {
+	case c.trigger <- struct{}{}:
+		logging.Info("daemon: manual backup requested via the control socket")
+		return nil
+	default:
+		return errors.New("a manual backup is already queued")
+	}
+}
+
+// CancelRun implements health.ControlHandler: stop the supervised run in progress.
+func (d *daemon) CancelRun() error {
+	c := d.control
+	if c == nil {
+		return errors.New("no backup is running")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running == nil || c.runCancel == nil {
+		return errors.New("no backup is running")
+	}
+	logging.Info("daemon: cancelling backup rid=%s via the control socket", c.running.RID)
+	c.runCancel()
+	return nil
+}
+
+// PingRecords implements health.ControlHandler with the daemon's own status file, read under
+// statusMu so it never observes a half-applied read-modify-write.
+func (d *daemon) PingRecords() (health.Status, error) {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	return health.LoadStatus(d.cfg.BaseDir)
+}
+
+// SubscribeLog implements health.ControlHandler.
+func (d *daemon) SubscribeLog() (<-chan health.RunLogLine, func()) {
+	if d.control == nil {
+		ch := make(chan health.RunLogLine)
+		close(ch)
+		return ch, func() {}
+	}
+	return d.control.logs.subscribe()
+}
+
+func (d *daemon) setNextRun(next time.Time) {
+	if c := d.control; c != nil {
+		c.mu.Lock()
+		c.nextRun = next
+		c.mu.Unlock()
+	}
+}
+
+// manualTrigger is the scheduleLoop channel for control-socket run requests (nil, never ready,
+// without a control surface).
+func (d *daemon) manualTrigger() <-chan struct{} {
+	if d.control == nil {
+		return nil
+	}
+	return d.control.trigger
+}
+
+func (d *daemon) beginRun(rid, trigger string, cancel context.CancelFunc) {
+	if c := d.control; c != nil {
+		c.mu.Lock()
+		c.running = &health.RunInfo{RID: rid, Trigger: trigger, StartTS: d.now().Unix()}
+		c.runCancel = cancel
+		c.mu.Unlock()
+	}
+}
+
+func (d *daemon) endRun(result string, exitCode int) {
+	c := d.control
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running == nil {
+		return
+	}
+	c.lastRun = &health.RunOutcome{
+		RID:      c.running.RID,
+		Trigger:  c.running.Trigger,
+		StartTS:  c.running.StartTS,
+		EndTS:    d.now().Unix(),
+		Result:   result,
+		ExitCode: exitCode,
+	}
+	c.running = nil
+	c.runCancel = nil
+}
+
+// runLogHub fans the supervised child's output lines out to control-socket subscribers. Sends never
+// block: a subscriber whose buffer is full misses lines rather than stalling the backup.
+type runLogHub struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]chan health.RunLogLine
+}
+
+func (h *runLogHub) subscribe() (<-chan health.RunLogLine, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[int]chan health.RunLogLine)
+	}
+	id := h.next
+	h.next++
+	ch := make(chan health.RunLogLine, runLogSubscriberBuffer)
+	h.subs[id] = ch
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs, id)
+		})
+	}
+}
+
+func (h *runLogHub) publish(line health.RunLogLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// runLogWriter splits one output stream of the child into lines for the hub. exec copies stdout and
+// stderr on separate goroutines, so each stream gets its own writer.
+type runLogWriter struct {
+	hub *runLogHub
+	rid string
+	now func() time.Time
+	buf []byte
+}
+
+func (w *runLogWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > runLogMaxLine {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// flush publishes a trailing line that had no newline.
+func (w *runLogWriter) flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *runLogWriter) emit(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) > runLogMaxLine {
+		line = line[:runLogMaxLine]
+	}
+	w.hub.publish(health.RunLogLine{RID: w.rid, TS: w.now().Unix(), Text: string(line)})
+}
@@ -0,0 +1,177 @@
+// Package main contains the proxsave command entrypoint.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/cron"
+	"github.com/tis24dev/proxsave/internal/health"
+)
+
+func waitForDaemon(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemonControlTriggerRunsManualBackupAndStreamsLog(t *testing.T) {
+	rep := &fakeReporter{backupURL: true}
+	d := newTestDaemon(t, rep, shCmd("echo LINE_ONE; echo LINE_TWO >&2; printf TRAILING; exit 3"), time.Hour)
+	d.cfg.SchedulerTime = cron.DefaultTime
+	d.control = newDaemonControl(d.now().Unix())
+	lines, release := d.SubscribeLog()
+	defer release()
+
+	if err := d.TriggerRun(); err != nil {
+		t.Fatalf("TriggerRun: %v", err)
+	}
+	if !d.RuntimeStatus().Queued {
+		t.Fatalf("a triggered run should be reported as queued until the scheduler picks it up")
+	}
+	if err := d.TriggerRun(); err == nil || !strings.Contains(err.Error(), "already queued") {
+		t.Fatalf("second trigger error = %v, want already queued", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.scheduleLoop(ctx)
+	}()
+	waitForDaemon(t, "the manual run to finish", func() bool { return d.RuntimeStatus().LastRun != nil })
+	st := d.RuntimeStatus()
+	cancel()
+	<-done
+
+	last := st.LastRun
+	if last.Trigger != health.RunTriggerManual || last.Result != health.RunResultFailed || last.ExitCode != 3 || last.RID == "" {
+		t.Fatalf("last run = %+v, want a failed manual run with exit 3", last)
+	}
+	if st.NextRunTS == 0 || st.Running != nil {
+		t.Fatalf("status = %+v, want the next fire time and no run in progress", st)
+	}
+	if s := rep.snapshot(); s.finished != 1 || s.lastCode != 3 {
+		t.Fatalf("a manual run pings its outcome like a scheduled one: finished=%d code=%d", s.finished, s.lastCode)
+	}
+
+	got := map[string]bool{}
+	for len(lines) > 0 {
+		line := <-lines
+		if line.RID != last.RID {
+			t.Fatalf("log line rid = %q, want %q", line.RID, last.RID)
+		}
+		got[line.Text] = true
+	}
+	for _, want := range []string{"LINE_ONE", "LINE_TWO", "TRAILING"} {
+		if !got[want] {
+			t.Fatalf("streamed lines %v missing %q", got, want)
+		}
+	}
+}
+
+func TestDaemonControlCancelStopsRunWithoutOutcomePing(t *testing.T) {
+	rep := &fakeReporter{backupURL: true}
+	d := newTestDaemon(t, rep, shCmd("exec sleep 30"), time.Hour)
+	d.control = newDaemonControl(d.now().Unix())
+	if err := d.CancelRun(); err == nil {
+		t.Fatal("CancelRun with nothing running should fail")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.runOnceAs(context.Background(), health.RunTriggerManual)
+	}()
+	waitForDaemon(t, "the run to start", func() bool { return d.RuntimeStatus().Running != nil })
+	if err := d.TriggerRun(); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("trigger during a run error = %v, want already running", err)
+	}
+	if err := d.CancelRun(); err != nil {
+		t.Fatalf("CancelRun: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("cancelled run did not stop")
+	}
+
+	if last := d.RuntimeStatus().LastRun; last == nil || last.Result != health.RunResultCanceled {
+		t.Fatalf("last run = %+v, want canceled", last)
+	}
+	if s := rep.snapshot(); s.started != 1 || s.finished != 0 || s.hung != 0 {
+		t.Fatalf("cancelled run pings: started=%d finished=%d hung=%d, want 1/0/0", s.started, s.finished, s.hung)
+	}
+}
+
+func TestDaemonControlRefusesRunWhenBackupsDisabled(t *testing.T) {
+	d := newTestDaemon(t, &fakeReporter{}, shCmd("exit 0"), time.Hour)
+	d.control = newDaemonControl(d.now().Unix())
+	d.cfg.BackupEnabled = false
+	if err := d.TriggerRun(); err == nil || !strings.Contains(err.Error(), "BACKUP_ENABLED=false") {
+		t.Fatalf("TriggerRun error = %v, want BACKUP_ENABLED refusal", err)
+	}
+}
+
+func TestRunLogWriterSplitsAndTruncatesLines(t *testing.T) {
+	var hub runLogHub
+	lines, release := hub.subscribe()
+	defer release()
+	w := &runLogWriter{hub: &hub, rid: "r", now: time.Now}
+	_, _ = w.Write([]byte("par"))
+	_, _ = w.Write([]byte("tial\r\nnext\n"))
+	_, _ = w.Write([]byte(strings.Repeat("x", runLogMaxLine+10)))
+	w.flush()
+
+	var got []string
+	for len(lines) > 0 {
+		got = append(got, (<-lines).Text)
+	}
+	if len(got) != 3 || got[0] != "partial" || got[1] != "next" || len(got[2]) != runLogMaxLine {
+		t.Fatalf("lines = %q", got)
+	}
+}
+
+func TestRunDaemonCtlPrintsStatusJSON(t *testing.T) {
+	d := newTestDaemon(t, &fakeReporter{}, shCmd("exit 0"), time.Hour)
+	d.control = newDaemonControl(1700000000)
+	next := time.Unix(1700003600, 0)
+	d.setNextRun(next)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.serveControl(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	socket := health.ControlSocketPath(d.cfg.BaseDir)
+	var out bytes.Buffer
+	waitForDaemon(t, "the control socket", func() bool {
+		out.Reset()
+		return runDaemonCtl(ctx, &out, socket, "status") == nil
+	})
+	var st health.DaemonRuntime
+	if err := json.Unmarshal(out.Bytes(), &st); err != nil {
+		t.Fatalf("status output is not JSON: %v\n%s", err, out.String())
+	}
+	if st.StartTS != 1700000000 || st.NextRunTS != next.Unix() {
+		t.Fatalf("status = %+v", st)
+	}
+	if err := runDaemonCtl(ctx, &out, socket, "cancel"); err == nil || !strings.Contains(err.Error(), "no backup is running") {
+		t.Fatalf("cancel error = %v, want the daemon's refusal", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package main contains the proxsave command entrypoint.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/cli"
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/health"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// daemonCtlActions maps the --daemon-ctl values to control-socket commands.
+var daemonCtlActions = map[string]string{
+	"status": health.ControlCmdStatus,
+	"run":    health.ControlCmdRun,
+	"cancel": health.ControlCmdCancel,
+	"pings":  health.ControlCmdPings,
+	"logs":   health.ControlCmdSubscribe,
+}
+
+// runDaemonCtlMode talks to the running daemon over its control socket and exits. Like
+// --upgrade-config-json it runs before the runtime logger: the answer is machine-readable JSON on
+// stdout (logs streams plain lines until interrupted) and errors go to stderr.
+func runDaemonCtlMode(ctx context.Context, args *cli.Args) (int, bool) {
+	if args.DaemonCtl == "" {
+		return types.ExitSuccess.Int(), false
+	}
+	socket := health.ControlSocketPath(daemonCtlBaseDir(args.ConfigPath))
+	if err := runDaemonCtl(ctx, os.Stdout, socket, args.DaemonCtl); err != nil {
+		if errors.Is(err, health.ErrControlUnavailable) {
+			err = fmt.Errorf("%w: the daemon is not running or predates the control API (see --daemon-status)", err)
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return types.ExitGenericError.Int(), true
+	}
+	return types.ExitSuccess.Int(), true
+}
+
+func runDaemonCtl(ctx context.Context, w io.Writer, socket, action string) error {
+	cmd, ok := daemonCtlActions[action]
+	if !ok {
+		return fmt.Errorf("unknown --daemon-ctl action %q", action)
+	}
+	if cmd == health.ControlCmdSubscribe {
+		return health.FollowControlLog(ctx, socket, func(line health.RunLogLine) {
+			fmt.Fprintf(w, "%s [%s] %s\n", time.Unix(line.TS, 0).Format("15:04:05"), line.RID, line.Text)
+		})
+	}
+	resp, err := health.CallControl(ctx, socket, cmd)
+	if err != nil {
+		return err
+	}
+	var payload any = resp.Status
+	if cmd == health.ControlCmdPings {
+		payload = resp.Pings
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
+
+// daemonCtlBaseDir resolves the BASE_DIR the daemon uses (its control socket lives under it),
+// falling back to the detected install dir when the config cannot be read.
+func daemonCtlBaseDir(configPath string) string {
+	detected, _ := detectedBaseDirOrFallback()
+	if cfg, err := config.LoadConfigWithBaseDir(configPath, detected); err == nil && cfg != nil && strings.TrimSpace(cfg.BaseDir) != "" {
+		return cfg.BaseDir
+	}
+	return detected
+}
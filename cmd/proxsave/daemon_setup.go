@@ -124,6 +124,9 @@ func runDaemonStatus(rt *appRuntime) int {
 		}
 		logging.Info("Binary alignment: %s", align)
 	}
+	if queue := formatUploadQueueStatus(rt.cfg, time.Now()); queue != "" {
+		logging.Info("Cloud upload queue: %s", queue)
+	}
 	if level == orchestrator.HealthcheckSetupLevelOk {
 		return types.ExitSuccess.Int()
 	}
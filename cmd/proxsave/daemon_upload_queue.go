@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/cron"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/storage"
+)
+
+// daemonUploadQueuePoll is how often the daemon checks the cloud upload queue while
+// the upload window is open (it also wakes when the window opens).
+var daemonUploadQueuePoll = 10 * time.Minute
+
+// daemonDrainUploadQueue is the drain seam; tests stub it to avoid rclone.
+var daemonDrainUploadQueue = drainCloudUploadQueue
+
+// uploadQueueLoop drains the cloud uploads that backup runs deferred because they
+// finished outside CLOUD_UPLOAD_WINDOW. It is a no-op without cloud storage.
+func (d *daemon) uploadQueueLoop(ctx context.Context) {
+	if !d.cfg.CloudEnabled || strings.TrimSpace(d.cfg.CloudUploadQueueFile) == "" {
+		return
+	}
+	window, err := cron.ParseWindow(d.cfg.CloudUploadWindow)
+	if err != nil {
+		logging.Warning("daemon: cloud upload queue disabled: CLOUD_UPLOAD_WINDOW invalid: %v", err)
+		return
+	}
+	for {
+		wait := daemonUploadQueuePoll
+		if now := d.now(); !window.Contains(now) {
+			if untilOpen := window.NextOpen(now).Sub(now); untilOpen < wait {
+				wait = untilOpen
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !window.Contains(d.now()) || d.backupRunning() {
+			// A running backup drains the queue itself after its own upload.
+			continue
+		}
+		entries, err := storage.NewUploadQueue(d.cfg.CloudUploadQueueFile).Load()
+		if err != nil {
+			logging.Warning("daemon: cloud upload queue unreadable: %v", err)
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		logging.Info("daemon: upload window open; draining %d queued cloud upload(s)", len(entries))
+		if err := daemonDrainUploadQueue(ctx, d.cfg, d.logger); err != nil {
+			logging.Warning("daemon: cloud upload queue: %v", err)
+		}
+	}
+}
+
+// backupRunning reports whether a supervised backup is in progress.
+func (d *daemon) backupRunning() bool {
+	c := d.control
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running != nil
+}
+
+// drainCloudUploadQueue uploads the queued archives and, when any went out,
+// applies the cloud retention policy the skipped backup run would have applied.
+func drainCloudUploadQueue(ctx context.Context, cfg *config.Config, logger *logging.Logger) error {
+	cloud, err := storage.NewCloudStorage(cfg, logger)
+	if err != nil {
+		return err
+	}
+	uploaded, drainErr := cloud.DrainUploadQueue(ctx)
+	if uploaded > 0 {
+		logging.Info("daemon: %d queued cloud upload(s) completed", uploaded)
+		retention := storage.NewRetentionConfigFromConfig(cfg, storage.LocationCloud)
+		if retention.Policy == "gfs" {
+			retention = storage.NormalizeGFSRetentionConfig(logger, cloud.Name(), retention)
+		}
+		if retention.MaxBackups > 0 || retention.IsTimeBased() {
+			if _, err := cloud.ApplyRetention(ctx, retention); err != nil {
+				logging.Warning("daemon: cloud retention after queued uploads failed: %v", err)
+			}
+		}
+	}
+	return drainErr
+}
+
+// formatUploadQueueStatus renders the queue for --daemon-status ("" when unused).
+func formatUploadQueueStatus(cfg *config.Config, now time.Time) string {
+	if cfg == nil || !cfg.CloudEnabled || strings.TrimSpace(cfg.CloudUploadQueueFile) == "" {
+		return ""
+	}
+	window, err := cron.ParseWindow(cfg.CloudUploadWindow)
+	if err != nil {
+		return fmt.Sprintf("CLOUD_UPLOAD_WINDOW invalid: %v", err)
+	}
+	var next time.Time
+	if !window.Contains(now) {
+		next = window.NextOpen(now)
+	}
+	status, err := storage.NewUploadQueue(cfg.CloudUploadQueueFile).Status(window.String(), next)
+	if err != nil {
+		return fmt.Sprintf("unreadable (%v)", err)
+	}
+	if status.Pending == 0 && window.IsZero() {
+		return ""
+	}
+	line := fmt.Sprintf("%d pending, window %s", status.Pending, status.Window)
+	if !status.Oldest.IsZero() {
+		line += ", oldest queued " + status.Oldest.Format("2006-01-02 15:04")
+	}
+	if !status.NextWindow.IsZero() {
+		line += ", opens " + status.NextWindow.Format("2006-01-02 15:04")
+	}
+	if status.LastError != "" {
+		line += ", last error: " + status.LastError
+	}
+	return line
+}
@@ -607,8 +607,31 @@ type daemonStatusAction int
 const (
 	daemonStatusActionCheck daemonStatusAction = iota
 	daemonStatusActionBack
+	daemonStatusActionRun
+	daemonStatusActionCancel
 )
 
+// daemonControlCall is the control-socket seam used by the daemon-status screen (stubbed in tests).
+var daemonControlCall = health.CallControl
+
+// daemonControlTimeout bounds each dashboard round-trip to the daemon's control socket.
+const daemonControlTimeout = 3 * time.Second
+
+// queryDaemonRuntime sends cmd to the running daemon and returns its live scheduler state. It fails
+// when the daemon does not answer (not running, or a build without the control socket) or refuses.
+func queryDaemonRuntime(ctx context.Context, baseDir, cmd string) (health.DaemonRuntime, error) {
+	callCtx, cancel := context.WithTimeout(ctx, daemonControlTimeout)
+	defer cancel()
+	resp, err := daemonControlCall(callCtx, health.ControlSocketPath(baseDir), cmd)
+	if err != nil {
+		return health.DaemonRuntime{}, err
+	}
+	if resp.Status == nil {
+		return health.DaemonRuntime{}, health.ErrControlUnavailable
+	}
+	return *resp.Status, nil
+}
+
 // runDashboardDaemonStatus shows the daemon-status screen with the SAME look as the Telegram
 // and Healthchecks check screens: a styled prompt (a colored "Status:" keyword + explanation +
 // a Details block) presented ABOVE a Check/Back selector. Check re-computes the state (so after
@@ -664,8 +687,18 @@ func runDashboardDaemonStatus(ctx context.Context, session *shell.Session, confi
 
 		items := []components.SelectorItem[daemonStatusAction]{
 			{Label: "Re-check", Description: "re-run the daemon state check", Value: daemonStatusActionCheck},
-			{Label: "Back", Description: "return to the dashboard menu", Value: daemonStatusActionBack},
 		}
+		// The live block and the run/cancel actions come straight from the daemon's control
+		// socket; a daemon that does not answer simply leaves the file-based verdict above.
+		if live, err := queryDaemonRuntime(ctx, baseDir, health.ControlCmdStatus); err == nil {
+			prompt += buildDaemonLivePrompt(live, time.Now())
+			if live.Running != nil {
+				items = append(items, components.SelectorItem[daemonStatusAction]{Label: "Cancel backup", Description: "stop the backup the daemon is running", Value: daemonStatusActionCancel})
+			} else if !live.Queued {
+				items = append(items, components.SelectorItem[daemonStatusAction]{Label: "Run backup now", Description: "ask the daemon to start a backup immediately", Value: daemonStatusActionRun})
+			}
+		}
+		items = append(items, components.SelectorItem[daemonStatusAction]{Label: "Back", Description: "return to the dashboard menu", Value: daemonStatusActionBack})
 
 		action, err := shell.Ask(ctx, session, components.NewSelector(
 			"Daemon status", items,
@@ -681,6 +714,15 @@ func runDashboardDaemonStatus(ctx context.Context, session *shell.Session, confi
 			return
 		case daemonStatusActionCheck:
 			// Loop: recompute the state so a restart done elsewhere shows up on the next render.
+		case daemonStatusActionRun, daemonStatusActionCancel:
+			cmd := health.ControlCmdRun
+			if action == daemonStatusActionCancel {
+				cmd = health.ControlCmdCancel
+			}
+			if _, err := queryDaemonRuntime(ctx, baseDir, cmd); err != nil {
+				logging.Warning("Daemon %s request failed: %v", cmd, err)
+			}
+			// Loop: the next render shows the queued/running/cancelled state.
 		}
 	}
 }
@@ -748,6 +790,41 @@ func buildDaemonStatusPrompt(level orchestrator.HealthcheckSetupLevel, keyword,
 	return b.String()
 }
 
+// buildDaemonLivePrompt renders the "Live" block of the daemon-status screen from the control
+// socket: the next scheduled fire time, the run in progress, and how the last run ended.
+func buildDaemonLivePrompt(live health.DaemonRuntime, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("\n\n")
+	b.WriteString(theme.Text.Render("Live (control socket):"))
+	if live.NextRunTS > 0 {
+		next := time.Unix(live.NextRunTS, 0)
+		b.WriteString("\n")
+		b.WriteString(theme.Text.Render("Next backup: " + next.Format("2006-01-02 15:04") + " (in " + next.Sub(now).Round(time.Minute).String() + ")"))
+	}
+	switch {
+	case live.Running != nil:
+		started := time.Unix(live.Running.StartTS, 0)
+		b.WriteString("\n")
+		b.WriteString(theme.Text.Render("Backup running: " + components.SanitizeText(live.Running.RID) + " (" + live.Running.Trigger + ", for " + now.Sub(started).Round(time.Second).String() + ")"))
+	case live.Queued:
+		b.WriteString("\n")
+		b.WriteString(theme.Text.Render("Backup running: queued"))
+	default:
+		b.WriteString("\n")
+		b.WriteString(theme.Text.Render("Backup running: no"))
+	}
+	if last := live.LastRun; last != nil {
+		b.WriteString("\n")
+		result := last.Result
+		if last.Result != health.RunResultHang && last.Result != health.RunResultCanceled {
+			result += fmt.Sprintf(" (exit %d)", last.ExitCode)
+		}
+		b.WriteString(theme.Text.Render(fmt.Sprintf("Last run: %s, %s, finished %s",
+			result, last.Trigger, time.Unix(last.EndTS, 0).Format("2006-01-02 15:04"))))
+	}
+	return b.String()
+}
+
 // daemonStatusStyle maps a composed daemon state to a shared HealthcheckSetupLevel (the SAME
 // palette the Telegram/Healthchecks screens use), a short outcome keyword, and a one-line
 // explanation. Green (Ok) only when the daemon is actually alive and beating; every gap is a
@@ -0,0 +1,239 @@
+// Package main contains the proxsave command entrypoint.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/cron"
+	"github.com/tis24dev/proxsave/internal/fleet"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/notify"
+	"github.com/tis24dev/proxsave/internal/types"
+	"github.com/tis24dev/proxsave/internal/version"
+)
+
+const (
+	// fleetShutdownGrace bounds in-flight requests when the collector stops.
+	fleetShutdownGrace = 10 * time.Second
+	// fleetDigestSendTimeout bounds delivery of one digest to every channel.
+	fleetDigestSendTimeout = 2 * time.Minute
+)
+
+// dispatchFleetCollectorMode runs the fleet collector when --fleet-collector is set. Like
+// --daemon it blocks until the run context is cancelled.
+func dispatchFleetCollectorMode(rt *appRuntime) modeResult {
+	if !rt.args.FleetCollector {
+		return modeResult{exitCode: types.ExitSuccess.Int()}
+	}
+	return modeResult{exitCode: runFleetCollector(rt), handled: true}
+}
+
+// fleetCollector owns the collector HTTP surface and the daily digest loop.
+type fleetCollector struct {
+	cfg      *config.Config
+	store    *fleet.Store
+	server   *fleet.Server
+	hostname string
+	now      func() time.Time
+	// notifiers deliver the digest through every enabled notification channel.
+	notifiers []notify.Notifier
+}
+
+func runFleetCollector(rt *appRuntime) int {
+	cfg := rt.cfg
+	if problem := fleetCollectorConfigProblem(cfg); problem != "" {
+		logging.Error("Fleet collector: %s", problem)
+		return types.ExitConfigError.Int()
+	}
+	secrets, err := fleet.LoadAgentSecrets(cfg.FleetAgentSecretsFile)
+	if err != nil {
+		logging.Error("Fleet collector: %v", err)
+		return types.ExitConfigError.Int()
+	}
+	for _, secret := range secrets {
+		rt.logger.RegisterSecret(secret)
+	}
+	rt.logger.RegisterSecret(cfg.FleetReadToken)
+	registerNotificationSecrets(rt.logger, cfg)
+	if cfg.FleetReadToken == "" {
+		logging.Warning("Fleet collector: FLEET_READ_TOKEN is empty; %s and %s are disabled", fleet.DigestPath, fleet.MetricsPath)
+	}
+
+	store := fleet.NewStore(cfg.FleetDataDir)
+	c := &fleetCollector{
+		cfg:       cfg,
+		store:     store,
+		server:    fleet.NewServer(store, secrets, cfg.FleetReadToken, fleetPolicy(cfg), rt.logger),
+		hostname:  rt.hostname,
+		now:       time.Now,
+		notifiers: fleetDigestNotifiers(cfg, fleetProxmoxType(rt), rt.logger),
+	}
+	httpSrv := &http.Server{
+		Addr:              cfg.FleetListen,
+		Handler:           c.server.Handler(),
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpSrv.ListenAndServeTLS(cfg.FleetTLSCert, cfg.FleetTLSKey)
+	}()
+	logging.Info("Fleet collector listening on %s (store=%s stale-after=%s digest-at=%s)",
+		cfg.FleetListen, cfg.FleetDataDir, cfg.FleetStaleAfter, cfg.FleetDigestTime)
+
+	ctx, cancel := context.WithCancel(rt.ctx)
+	defer cancel()
+	digestDone := make(chan struct{})
+	go func() {
+		defer close(digestDone)
+		c.digestLoop(ctx)
+	}()
+
+	exitCode := types.ExitSuccess.Int()
+	select {
+	case <-ctx.Done():
+		logging.Info("Fleet collector stopping")
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logging.Error("Fleet collector: %v", err)
+			exitCode = types.ExitNetworkError.Int()
+		}
+	}
+	cancel()
+	shutdownCtx, stop := context.WithTimeout(context.Background(), fleetShutdownGrace)
+	defer stop()
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		logging.Debug("Fleet collector shutdown: %v", err)
+	}
+	<-digestDone
+	return exitCode
+}
+
+// fleetCollectorConfigProblem returns why the collector cannot start, or "".
+func fleetCollectorConfigProblem(cfg *config.Config) string {
+	switch {
+	case cfg == nil:
+		return "configuration not loaded"
+	case cfg.FleetAgentSecretsFile == "":
+		return "FLEET_AGENT_SECRETS_FILE is empty (the collector needs the secret of every agent)"
+	case cfg.FleetTLSCert == "" || cfg.FleetTLSKey == "":
+		return "FLEET_TLS_CERT and FLEET_TLS_KEY are required (reports are only accepted over HTTPS)"
+	case strings.TrimSpace(cfg.FleetListen) == "":
+		return "FLEET_LISTEN is empty"
+	case strings.TrimSpace(cfg.FleetDataDir) == "":
+		return "FLEET_DATA_DIR is empty"
+	}
+	if _, err := cron.NextDaily(time.Now(), cfg.FleetDigestTime); err != nil {
+		return "invalid FLEET_DIGEST_TIME: " + err.Error()
+	}
+	return ""
+}
+
+func fleetPolicy(cfg *config.Config) fleet.Policy {
+	return fleet.Policy{StaleAfter: cfg.FleetStaleAfter, SpaceWarnPercent: cfg.FleetSpaceWarnPercent}
+}
+
+// fleetDigestNotifiers builds the channels the digest is sent through: the same email,
+// Telegram, Gotify and webhook settings a backup run notifies with.
+func fleetDigestNotifiers(cfg *config.Config, proxmoxType types.ProxmoxType, logger *logging.Logger) []notify.Notifier {
+	var notifiers []notify.Notifier
+	add := func(name string, n notify.Notifier, err error) {
+		if err != nil {
+			logging.Warning("Fleet digest: %s not initialized: %v", name, err)
+			return
+		}
+		notifiers = append(notifiers, n)
+	}
+	if cfg.EmailEnabled {
+		n, err := notify.NewEmailNotifier(emailNotifierConfig(cfg), proxmoxType, logger)
+		add("email", n, err)
+	}
+	if cfg.TelegramEnabled {
+		n, err := notify.NewTelegramNotifier(telegramNotifierConfig(cfg), logger)
+		add("Telegram", n, err)
+	}
+	if cfg.GotifyEnabled {
+		n, err := notify.NewGotifyNotifier(gotifyNotifierConfig(cfg), logger)
+		add("Gotify", n, err)
+	}
+	if cfg.WebhookEnabled {
+		n, err := notify.NewWebhookNotifier(cfg.BuildWebhookConfig(), logger)
+		add("webhook", n, err)
+	}
+	if len(notifiers) == 0 {
+		logging.Info("Fleet digest: no notification channel enabled, the digest is only written to %s/digest", cfg.FleetDataDir)
+	}
+	return notifiers
+}
+
+// fleetProxmoxType is the collector host type, used by email recipient auto-detection.
+func fleetProxmoxType(rt *appRuntime) types.ProxmoxType {
+	if rt.envInfo == nil {
+		return types.ProxmoxUnknown
+	}
+	return rt.envInfo.Type
+}
+
+// digestLoop produces one digest per day at FLEET_DIGEST_TIME until ctx is cancelled.
+func (c *fleetCollector) digestLoop(ctx context.Context) {
+	for {
+		next, err := cron.NextDaily(c.now(), c.cfg.FleetDigestTime)
+		if err != nil {
+			next, _ = cron.NextDaily(c.now(), cron.DefaultTime)
+		}
+		wait := next.Sub(c.now())
+		if wait < 0 {
+			wait = 0
+		}
+		logging.Debug("Fleet collector: next digest at %s", next.Format("2006-01-02 15:04"))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			c.sendDigest(ctx)
+		}
+	}
+}
+
+// sendDigest writes today's digest, prunes the report history and sends the digest to
+// every configured channel. Channel failures are logged, never fatal.
+func (c *fleetCollector) sendDigest(ctx context.Context) {
+	now := c.now()
+	digest := fleet.BuildDigest(c.server.States(), now)
+	if path, err := c.store.WriteDigest(digest); err != nil {
+		logging.Warning("Fleet digest: cannot write: %v", err)
+	} else {
+		logging.Info("%s (written to %s)", digest.Summary(), path)
+	}
+	if err := c.store.PruneHistory(c.cfg.FleetHistoryDays, now); err != nil {
+		logging.Warning("Fleet collector: history prune failed: %v", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, fleetDigestSendTimeout)
+	defer cancel()
+	data := digest.NotificationData(c.hostname, version.String())
+	for _, n := range c.notifiers {
+		result, err := n.Send(sendCtx, data)
+		if err == nil && result != nil && !result.Success {
+			err = result.Error
+			if err == nil {
+				err = errors.New("not delivered")
+			}
+		}
+		if err != nil {
+			logging.Warning("Fleet digest: %s delivery failed: %v", n.Name(), err)
+			continue
+		}
+		logging.Info("✓ Fleet digest sent via %s", n.Name())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/fleet"
+	"github.com/tis24dev/proxsave/internal/notify"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+type recordingNotifier struct {
+	sent []*notify.NotificationData
+}
+
+func (r *recordingNotifier) Name() string     { return "Recorder" }
+func (r *recordingNotifier) IsEnabled() bool  { return true }
+func (r *recordingNotifier) IsCritical() bool { return false }
+func (r *recordingNotifier) Send(_ context.Context, data *notify.NotificationData) (*notify.NotificationResult, error) {
+	r.sent = append(r.sent, data)
+	return &notify.NotificationResult{Success: true}, nil
+}
+
+func validFleetCollectorConfig() *config.Config {
+	return &config.Config{
+		FleetAgentSecretsFile: "/etc/proxsave/fleet-agents.secrets",
+		FleetTLSCert:          "/etc/proxsave/fleet.crt",
+		FleetTLSKey:           "/etc/proxsave/fleet.key",
+		FleetListen:           ":8443",
+		FleetDataDir:          "/opt/proxsave/fleet",
+		FleetDigestTime:       "07:00",
+	}
+}
+
+func TestFleetCollectorConfigProblem(t *testing.T) {
+	if problem := fleetCollectorConfigProblem(validFleetCollectorConfig()); problem != "" {
+		t.Fatalf("valid config reported %q", problem)
+	}
+	cases := []struct {
+		name   string
+		mutate func(*config.Config)
+		want   string
+	}{
+		{"no agent secrets", func(c *config.Config) { c.FleetAgentSecretsFile = "" }, "FLEET_AGENT_SECRETS_FILE"},
+		{"no cert", func(c *config.Config) { c.FleetTLSCert = "" }, "FLEET_TLS_CERT"},
+		{"no key", func(c *config.Config) { c.FleetTLSKey = "" }, "FLEET_TLS_KEY"},
+		{"no listen", func(c *config.Config) { c.FleetListen = "" }, "FLEET_LISTEN"},
+		{"bad digest time", func(c *config.Config) { c.FleetDigestTime = "7am" }, "FLEET_DIGEST_TIME"},
+	}
+	for _, tc := range cases {
+		cfg := validFleetCollectorConfig()
+		tc.mutate(cfg)
+		if problem := fleetCollectorConfigProblem(cfg); !strings.Contains(problem, tc.want) {
+			t.Errorf("%s: problem = %q, want it to mention %s", tc.name, problem, tc.want)
+		}
+	}
+}
+
+func TestFleetCollectorSendDigest(t *testing.T) {
+	now := time.Now()
+	cfg := validFleetCollectorConfig()
+	cfg.FleetDataDir = t.TempDir()
+	cfg.FleetStaleAfter = 26 * time.Hour
+	cfg.FleetHistoryDays = 30
+
+	store := fleet.NewStore(cfg.FleetDataDir)
+	stale := fleet.NewReport(&notify.NotificationData{
+		Status:   notify.StatusSuccess,
+		Hostname: "pve-old",
+	}, nil, now.Add(-72*time.Hour))
+	if err := store.Save(stale); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	rec := &recordingNotifier{}
+	c := &fleetCollector{
+		cfg:       cfg,
+		store:     store,
+		server:    fleet.NewServer(store, map[string]string{"pve-old": "s3cret"}, "", fleetPolicy(cfg), nil),
+		hostname:  "collector",
+		now:       func() time.Time { return now },
+		notifiers: []notify.Notifier{rec},
+	}
+	c.sendDigest(context.Background())
+
+	if len(rec.sent) != 1 {
+		t.Fatalf("digest sent %d times, want 1", len(rec.sent))
+	}
+	got := rec.sent[0]
+	if got.Status != notify.StatusWarning || got.Hostname != "collector" || len(got.LogCategories) != 1 || got.LogCategories[0].Label != "pve-old" {
+		t.Fatalf("digest notification = %+v", got)
+	}
+	if got.FleetDigest == nil || !strings.Contains(got.FleetDigest.Text, "pve-old") {
+		t.Fatalf("digest notification carries no digest text: %+v", got.FleetDigest)
+	}
+	text, err := os.ReadFile(filepath.Join(cfg.FleetDataDir, "digest", now.Format("2006-01-02")+".txt"))
+	if err != nil {
+		t.Fatalf("digest file: %v", err)
+	}
+	if !strings.Contains(string(text), "pve-old") || !strings.Contains(string(text), "1 stale") {
+		t.Fatalf("digest text:\n%s", text)
+	}
+}
+
+func TestFleetDigestNotifiersUseConfiguredChannels(t *testing.T) {
+	cfg := validFleetCollectorConfig()
+	cfg.EmailEnabled = true
+	cfg.EmailDeliveryMethod = "sendmail"
+	cfg.EmailRecipient = "ops@example.com"
+	cfg.TelegramEnabled = true
+	cfg.TelegramBotType = "personal"
+	cfg.TelegramBotToken = "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghi_"
+	cfg.TelegramChatID = "42"
+
+	var names []string
+	for _, n := range fleetDigestNotifiers(cfg, types.ProxmoxVE, nil) {
+		names = append(names, n.Name())
+	}
+	if got := strings.Join(names, ","); got != "Email,Telegram" {
+		t.Fatalf("digest channels = %q, want Email,Telegram", got)
+	}
+
+	if n := fleetDigestNotifiers(validFleetCollectorConfig(), types.ProxmoxVE, nil); len(n) != 0 {
+		t.Fatalf("no channel enabled, got %d notifiers", len(n))
+	}
+}
@@ -112,6 +112,9 @@ func preparePreRuntimeArgs(ctx context.Context, bootstrap *logging.BootstrapLogg
 	if exitCode, handled := runUpgradeConfigJSONMode(args); handled {
 		return args, exitCode, true
 	}
+	if exitCode, handled := runDaemonCtlMode(ctx, args); handled {
+		return args, exitCode, true
+	}
 	if exitCode, handled := dispatchPreRuntimeModes(ctx, args, bootstrap, toolVersion); handled {
 		return args, exitCode, true
 	}
@@ -199,9 +202,26 @@ func runRuntime(rt *appRuntime, state *appRunState) int {
 	if result := dispatchDaemonMode(rt); result.handled {
 		return finalizeModeResult(state, result)
 	}
+	// The fleet collector only receives reports and serves HTTPS; it never runs a backup.
+	if result := dispatchFleetCollectorMode(rt); result.handled {
+		return finalizeModeResult(state, result)
+	}
+	// The retention plan only lists backups; it never deletes anything.
+	if result := dispatchRetentionPlanMode(rt); result.handled {
+		return finalizeModeResult(state, result)
+	}
 	if exitCode, ok := runSecurityPreflight(rt); !ok {
 		return state.finalize(exitCode)
 	}
+	if result := dispatchRestoreRollbackMode(rt); result.handled {
+		return finalizeModeResult(state, result)
+	}
+	if result := dispatchRestoreGuestsMode(rt); result.handled {
+		return finalizeModeResult(state, result)
+	}
+	if result := dispatchClusterRebuildMode(rt); result.handled {
+		return finalizeModeResult(state, result)
+	}
 	if result := dispatchRestoreMode(rt); result.handled {
 		return finalizeModeResult(state, result)
 	}
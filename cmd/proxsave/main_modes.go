@@ -52,6 +52,11 @@ func validateModeCompatibility(args *cli.Args) []string {
 		validateInstallCompatibility,
 		validateUpgradeCompatibility,
 		validateDaemonCompatibility,
+		validateFleetCollectorCompatibility,
+		validateRestoreRollbackCompatibility,
+		validateRestoreGuestsCompatibility,
+		validateClusterRebuildCompatibility,
+		validateRetentionPlanCompatibility,
 	} {
 		if messages := rule(args); len(messages) > 0 {
 			allMessages = append(allMessages, messages...)
@@ -110,6 +115,7 @@ func validateDaemonCompatibility(args *cli.Args) []string {
 		{args.DaemonSetup, "--daemon-setup"},
 		{args.DaemonRemove, "--daemon-remove"},
 		{args.DaemonStatus, "--daemon-status"},
+		{args.DaemonCtl != "", "--daemon-ctl"},
 	} {
 		if f.on {
 			daemonFlags++
@@ -120,7 +126,12 @@ func validateDaemonCompatibility(args *cli.Args) []string {
 		return nil
 	}
 	if daemonFlags > 1 {
-		return []string{"Only one of --daemon, --daemon-setup, --daemon-remove, --daemon-status may be used at a time."}
+		return []string{"Only one of --daemon, --daemon-setup, --daemon-remove, --daemon-status, --daemon-ctl may be used at a time."}
+	}
+	if args.DaemonCtl != "" {
+		if _, ok := daemonCtlActions[args.DaemonCtl]; !ok {
+			return []string{fmt.Sprintf("Unknown --daemon-ctl action %q (use status, run, cancel, pings or logs).", args.DaemonCtl)}
+		}
 	}
 	if args.DryRun && (args.Daemon || args.DaemonSetup || args.DaemonRemove) {
 		return []string{"--dry-run is not supported with --daemon, --daemon-setup, or --daemon-remove."}
@@ -143,6 +154,151 @@ func validateDaemonCompatibility(args *cli.Args) []string {
 	return nil
 }
 
+func validateFleetCollectorCompatibility(args *cli.Args) []string {
+	if !args.FleetCollector {
+		return nil
+	}
+	if args.DryRun {
+		return []string{"--dry-run is not supported with --fleet-collector."}
+	}
+	incompatible := enabledModes([]incompatibleMode{
+		{enabled: args.Backup, label: "--backup"},
+		{enabled: args.Restore, label: "--restore"},
+		{enabled: args.RestoreRollback, label: "--restore-rollback"},
+		{enabled: args.RestoreGuests, label: "--restore-guests"},
+		{enabled: args.ClusterRebuild, label: "--cluster-rebuild"},
+		{enabled: args.Decrypt, label: "--decrypt"},
+		{enabled: args.Install, label: "--install"},
+		{enabled: args.NewInstall, label: "--new-install"},
+		{enabled: args.Upgrade, label: "--upgrade"},
+		{enabled: args.ForceNewKey, label: "--newkey"},
+		{enabled: args.Support, label: "--support"},
+		{enabled: args.Daemon || args.DaemonSetup || args.DaemonRemove || args.DaemonStatus || args.DaemonCtl != "", label: "--daemon/--daemon-setup/--daemon-remove/--daemon-status/--daemon-ctl"},
+		{enabled: args.UpgradeConfig || args.UpgradeConfigDry || args.UpgradeConfigJSON, label: "--upgrade-config"},
+		{enabled: args.CleanupGuards, label: "--cleanup-guards"},
+	})
+	if len(incompatible) > 0 {
+		return []string{fmt.Sprintf("--fleet-collector cannot be combined with: %s", strings.Join(incompatible, ", "))}
+	}
+	return nil
+}
+
+func validateRetentionPlanCompatibility(args *cli.Args) []string {
+	if !args.RetentionPlan {
+		if args.RetentionLocation != "" || len(args.RetentionOverrides) > 0 || args.RetentionSimulateRuns != 0 {
+			return []string{"--location, --override and --simulate-runs only apply to --retention-plan."}
+		}
+		return nil
+	}
+	var messages []string
+	switch args.RetentionLocation {
+	case "", "primary", "secondary", "cloud":
+	default:
+		messages = append(messages, fmt.Sprintf("Unknown --location %q (use primary, secondary or cloud).", args.RetentionLocation))
+	}
+	if args.RetentionSimulateRuns < 0 || args.RetentionSimulateRuns > maxRetentionSimulateRuns {
+		messages = append(messages, fmt.Sprintf("--simulate-runs must be between 0 and %d.", maxRetentionSimulateRuns))
+	}
+	incompatible := enabledModes([]incompatibleMode{
+		{enabled: args.Backup, label: "--backup"},
+		{enabled: args.Restore, label: "--restore"},
+		{enabled: args.RestoreRollback, label: "--restore-rollback"},
+		{enabled: args.RestoreGuests, label: "--restore-guests"},
+		{enabled: args.ClusterRebuild, label: "--cluster-rebuild"},
+		{enabled: args.Decrypt, label: "--decrypt"},
+		{enabled: args.Install, label: "--install"},
+		{enabled: args.NewInstall, label: "--new-install"},
+		{enabled: args.Upgrade, label: "--upgrade"},
+		{enabled: args.ForceNewKey, label: "--newkey"},
+		{enabled: args.Support, label: "--support"},
+		{enabled: args.FleetCollector, label: "--fleet-collector"},
+		{enabled: args.Daemon || args.DaemonSetup || args.DaemonRemove || args.DaemonStatus || args.DaemonCtl != "", label: "--daemon/--daemon-setup/--daemon-remove/--daemon-status/--daemon-ctl"},
+		{enabled: args.UpgradeConfig || args.UpgradeConfigDry || args.UpgradeConfigJSON, label: "--upgrade-config"},
+		{enabled: args.CleanupGuards, label: "--cleanup-guards"},
+	})
+	if len(incompatible) > 0 {
+		messages = append(messages, fmt.Sprintf("--retention-plan cannot be combined with: %s", strings.Join(incompatible, ", ")))
+	}
+	return messages
+}
+
+func validateRestoreRollbackCompatibility(args *cli.Args) []string {
+	if !args.RestoreRollback {
+		return nil
+	}
+	incompatible := enabledModes([]incompatibleMode{
+		{enabled: args.Restore, label: "--restore"},
+		{enabled: args.ClusterRebuild, label: "--cluster-rebuild"},
+		{enabled: args.Decrypt, label: "--decrypt"},
+		{enabled: args.Backup, label: "--backup"},
+		{enabled: args.Install, label: "--install"},
+		{enabled: args.NewInstall, label: "--new-install"},
+		{enabled: args.Upgrade, label: "--upgrade"},
+		{enabled: args.ForceNewKey, label: "--newkey"},
+		{enabled: args.Support, label: "--support"},
+		{enabled: args.Daemon || args.DaemonSetup || args.DaemonRemove || args.DaemonStatus, label: "--daemon/--daemon-setup/--daemon-remove/--daemon-status"},
+		{enabled: args.UpgradeConfig || args.UpgradeConfigDry || args.UpgradeConfigJSON, label: "--upgrade-config"},
+		{enabled: args.CleanupGuards, label: "--cleanup-guards"},
+	})
+	if len(incompatible) > 0 {
+		return []string{fmt.Sprintf("--restore-rollback cannot be combined with: %s", strings.Join(incompatible, ", "))}
+	}
+	return nil
+}
+
+func validateRestoreGuestsCompatibility(args *cli.Args) []string {
+	if !args.RestoreGuests {
+		return nil
+	}
+	incompatible := enabledModes([]incompatibleMode{
+		{enabled: args.Restore, label: "--restore"},
+		{enabled: args.RestoreRollback, label: "--restore-rollback"},
+		{enabled: args.ClusterRebuild, label: "--cluster-rebuild"},
+		{enabled: args.Decrypt, label: "--decrypt"},
+		{enabled: args.Backup, label: "--backup"},
+		{enabled: args.Install, label: "--install"},
+		{enabled: args.NewInstall, label: "--new-install"},
+		{enabled: args.Upgrade, label: "--upgrade"},
+		{enabled: args.ForceNewKey, label: "--newkey"},
+		{enabled: args.Support, label: "--support"},
+		{enabled: args.Daemon || args.DaemonSetup || args.DaemonRemove || args.DaemonStatus, label: "--daemon/--daemon-setup/--daemon-remove/--daemon-status"},
+		{enabled: args.UpgradeConfig || args.UpgradeConfigDry || args.UpgradeConfigJSON, label: "--upgrade-config"},
+		{enabled: args.CleanupGuards, label: "--cleanup-guards"},
+	})
+	if len(incompatible) > 0 {
+		return []string{fmt.Sprintf("--restore-guests cannot be combined with: %s", strings.Join(incompatible, ", "))}
+	}
+	return nil
+}
+
+func validateClusterRebuildCompatibility(args *cli.Args) []string {
+	if !args.ClusterRebuild {
+		if args.ClusterRebuildProfile != "" {
+			return []string{"--cluster-rebuild-profile only applies to --cluster-rebuild."}
+		}
+		return nil
+	}
+	incompatible := enabledModes([]incompatibleMode{
+		{enabled: args.Restore, label: "--restore"},
+		{enabled: args.RestoreRollback, label: "--restore-rollback"},
+		{enabled: args.RestoreGuests, label: "--restore-guests"},
+		{enabled: args.Decrypt, label: "--decrypt"},
+		{enabled: args.Backup, label: "--backup"},
+		{enabled: args.Install, label: "--install"},
+		{enabled: args.NewInstall, label: "--new-install"},
+		{enabled: args.Upgrade, label: "--upgrade"},
+		{enabled: args.ForceNewKey, label: "--newkey"},
+		{enabled: args.Support, label: "--support"},
+		{enabled: args.Daemon || args.DaemonSetup || args.DaemonRemove || args.DaemonStatus, label: "--daemon/--daemon-setup/--daemon-remove/--daemon-status"},
+		{enabled: args.UpgradeConfig || args.UpgradeConfigDry || args.UpgradeConfigJSON, label: "--upgrade-config"},
+		{enabled: args.CleanupGuards, label: "--cleanup-guards"},
+	})
+	if len(incompatible) > 0 {
+		return []string{fmt.Sprintf("--cluster-rebuild cannot be combined with: %s", strings.Join(incompatible, ", "))}
+	}
+	return nil
+}
+
 func cleanupGuardsIncompatibleModes(args *cli.Args) []string {
 	return enabledModes([]incompatibleMode{
 		{enabled: args.Support, label: "--support"},
@@ -61,6 +61,86 @@ func TestValidateModeCompatibility(t *testing.T) {
 			args: &cli.Args{LocalFile: true},
 			want: []string{"The --localfile flag only applies to --upgrade (use: --upgrade --localfile)."},
 		},
+		{
+			name: "daemon ctl alone allowed",
+			args: &cli.Args{DaemonCtl: "run"},
+		},
+		{
+			name: "daemon ctl rejects unknown action",
+			args: &cli.Args{DaemonCtl: "reboot"},
+			want: []string{`Unknown --daemon-ctl action "reboot" (use status, run, cancel, pings or logs).`},
+		},
+		{
+			name: "daemon ctl is exclusive with other daemon flags",
+			args: &cli.Args{DaemonCtl: "status", DaemonStatus: true},
+			want: []string{"Only one of --daemon, --daemon-setup, --daemon-remove, --daemon-status, --daemon-ctl may be used at a time."},
+		},
+		{
+			name: "fleet collector alone allowed",
+			args: &cli.Args{FleetCollector: true},
+		},
+		{
+			name: "fleet collector rejects other workflows",
+			args: &cli.Args{FleetCollector: true, Backup: true, Decrypt: true},
+			want: []string{"--fleet-collector cannot be combined with: --backup, --decrypt"},
+		},
+		{
+			name: "fleet collector rejects dry run",
+			args: &cli.Args{FleetCollector: true, DryRun: true},
+			want: []string{"--dry-run is not supported with --fleet-collector."},
+		},
+		{
+			name: "restore rollback alone allowed",
+			args: &cli.Args{RestoreRollback: true, DryRun: true},
+		},
+		{
+			name: "restore rollback rejects other workflows",
+			args: &cli.Args{RestoreRollback: true, Restore: true, Backup: true},
+			want: []string{"--restore-rollback cannot be combined with: --restore, --backup"},
+		},
+		{
+			name: "restore guests alone allowed",
+			args: &cli.Args{RestoreGuests: true, DryRun: true},
+		},
+		{
+			name: "restore guests rejects other workflows",
+			args: &cli.Args{RestoreGuests: true, Restore: true, Decrypt: true},
+			want: []string{"--restore-guests cannot be combined with: --restore, --decrypt"},
+		},
+		{
+			name: "cluster rebuild with profile allowed",
+			args: &cli.Args{ClusterRebuild: true, ClusterRebuildProfile: "/root/rebuild.env", DryRun: true},
+		},
+		{
+			name: "cluster rebuild profile requires cluster rebuild",
+			args: &cli.Args{ClusterRebuildProfile: "/root/rebuild.env"},
+			want: []string{"--cluster-rebuild-profile only applies to --cluster-rebuild."},
+		},
+		{
+			name: "cluster rebuild rejects other workflows",
+			args: &cli.Args{ClusterRebuild: true, RestoreGuests: true, Backup: true},
+			want: []string{
+				"--restore-guests cannot be combined with: --cluster-rebuild, --backup",
+				"--cluster-rebuild cannot be combined with: --restore-guests, --backup",
+			},
+		},
+		{
+			name: "retention plan with options allowed",
+			args: &cli.Args{RetentionPlan: true, RetentionLocation: "cloud", RetentionOverrides: []string{"RETENTION_WEEKLY=8"}, RetentionSimulateRuns: 30},
+		},
+		{
+			name: "retention plan options require retention plan",
+			args: &cli.Args{RetentionSimulateRuns: 3},
+			want: []string{"--location, --override and --simulate-runs only apply to --retention-plan."},
+		},
+		{
+			name: "retention plan rejects unknown location and other workflows",
+			args: &cli.Args{RetentionPlan: true, RetentionLocation: "tape", Backup: true},
+			want: []string{
+				"Unknown --location \"tape\" (use primary, secondary or cloud).",
+				"--retention-plan cannot be combined with: --backup",
+			},
+		},
 		{
 			name: "accumulates all compatibility violations",
 			args: &cli.Args{CleanupGuards: true, Support: true, Decrypt: true, Install: true, NewInstall: true, Upgrade: true},
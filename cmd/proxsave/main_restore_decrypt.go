@@ -17,8 +17,105 @@ var (
 	restoreIsInteractive = isTerminalInteractive
 	runRestoreCLIFn      = runRestoreCLI
 	runRestoreTUIFn      = runRestoreTUI
+	runRestoreRollbackFn = runRestoreRollback
+	runRestoreGuestsFn   = runRestoreGuests
+	runClusterRebuildFn  = runClusterRebuild
 )
 
+func dispatchRestoreRollbackMode(rt *appRuntime) modeResult {
+	if !rt.args.RestoreRollback {
+		return modeResult{exitCode: types.ExitSuccess.Int()}
+	}
+	logging.DebugStep(rt.logger, "main", "mode=restore-rollback")
+	return runRestoreRollbackFn(rt)
+}
+
+func runRestoreRollback(rt *appRuntime) modeResult {
+	logging.Info("Restore rollback mode enabled - listing safety backups...")
+	err := orchestrator.RunRestoreRollbackWorkflow(rt.ctx, rt.cfg, rt.logger)
+	switch {
+	case err == nil:
+		if rt.logger.HasWarnings() {
+			logging.Warning("Restore rollback completed with warnings (see log above)")
+		} else {
+			logging.Info("Restore rollback completed successfully")
+		}
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	case errors.Is(err, orchestrator.ErrRestoreAborted):
+		logging.Warning("Restore rollback aborted by user")
+		return modeResult{exitCode: exitCodeInterrupted, handled: true}
+	case errors.Is(err, orchestrator.ErrNoSafetyBackups):
+		// Nothing to roll back is not a failure: report it and exit cleanly.
+		logging.Warning("Nothing to roll back: %v", err)
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	default:
+		logging.Error("Restore rollback failed: %v", err)
+		return modeResult{exitCode: types.ExitGenericError.Int(), handled: true}
+	}
+}
+
+func dispatchRestoreGuestsMode(rt *appRuntime) modeResult {
+	if !rt.args.RestoreGuests {
+		return modeResult{exitCode: types.ExitSuccess.Int()}
+	}
+	logging.DebugStep(rt.logger, "main", "mode=restore-guests")
+	return runRestoreGuestsFn(rt)
+}
+
+func runRestoreGuests(rt *appRuntime) modeResult {
+	logging.Info("Guest restore mode enabled - listing guests in the backup...")
+	err := orchestrator.RunGuestRestoreWorkflow(rt.ctx, rt.cfg, rt.logger, rt.toolVersion)
+	switch {
+	case err == nil:
+		if rt.logger.HasWarnings() {
+			logging.Warning("Guest restore completed with warnings (see log above)")
+		} else {
+			logging.Info("Guest restore completed successfully")
+		}
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	case errors.Is(err, orchestrator.ErrRestoreAborted):
+		logging.Warning("Guest restore aborted by user")
+		return modeResult{exitCode: exitCodeInterrupted, handled: true}
+	case errors.Is(err, orchestrator.ErrNoArchivedGuests):
+		logging.Warning("Nothing to restore: %v", err)
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	default:
+		logging.Error("Guest restore failed: %v", err)
+		return modeResult{exitCode: types.ExitGenericError.Int(), handled: true}
+	}
+}
+
+func dispatchClusterRebuildMode(rt *appRuntime) modeResult {
+	if !rt.args.ClusterRebuild {
+		return modeResult{exitCode: types.ExitSuccess.Int()}
+	}
+	logging.DebugStep(rt.logger, "main", "mode=cluster-rebuild profile=%q", rt.args.ClusterRebuildProfile)
+	return runClusterRebuildFn(rt)
+}
+
+func runClusterRebuild(rt *appRuntime) modeResult {
+	logging.Info("Cluster rebuild mode enabled - reading the archived corosync configuration...")
+	err := orchestrator.RunClusterRebuildWorkflow(rt.ctx, rt.cfg, rt.logger, rt.toolVersion, rt.args.ClusterRebuildProfile)
+	switch {
+	case err == nil:
+		if rt.logger.HasWarnings() {
+			logging.Warning("Cluster rebuild completed with warnings (see log above)")
+		} else {
+			logging.Info("Cluster rebuild completed successfully")
+		}
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	case errors.Is(err, orchestrator.ErrRestoreAborted):
+		logging.Warning("Cluster rebuild aborted by user")
+		return modeResult{exitCode: exitCodeInterrupted, handled: true}
+	case errors.Is(err, orchestrator.ErrNoArchivedCorosyncConfig):
+		logging.Warning("Nothing to rebuild: %v", err)
+		return modeResult{exitCode: types.ExitSuccess.Int(), handled: true}
+	default:
+		logging.Error("Cluster rebuild failed: %v", err)
+		return modeResult{exitCode: types.ExitGenericError.Int(), handled: true}
+	}
+}
+
 func dispatchRestoreMode(rt *appRuntime) modeResult {
 	if !rt.args.Restore {
 		return modeResult{exitCode: types.ExitSuccess.Int()}
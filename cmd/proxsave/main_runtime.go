@@ -270,7 +270,7 @@ func resolveRunLogLevel(args *cli.Args, cfg *config.Config, bootstrap *logging.B
 
 func initializeRunLogger(rt *appRuntime) *logging.Logger {
 	logger := logging.New(rt.logLevel, rt.cfg.UseColor)
-	if rt.args.Restore {
+	if rt.args.Restore || rt.args.RestoreRollback || rt.args.RestoreGuests || rt.args.ClusterRebuild {
 		logger = initializeRestoreSessionLogger(rt, logger)
 	}
 	if dashboardHandoffPending() {
@@ -309,7 +309,7 @@ func initializeRunLogFile(rt *appRuntime) {
 	rt.hostname = resolveHostname()
 	rt.startTime = rt.deps.now()
 	rt.timestampStr = rt.startTime.Format("20060102-150405")
-	if rt.args.Restore {
+	if rt.args.Restore || rt.args.RestoreRollback || rt.args.RestoreGuests || rt.args.ClusterRebuild {
 		return
 	}
 
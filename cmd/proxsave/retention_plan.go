@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/storage"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// maxRetentionSimulateRuns bounds --simulate-runs (ten years of daily runs).
+const maxRetentionSimulateRuns = 3650
+
+// retentionPlanTarget is one storage location the plan covers.
+type retentionPlanTarget struct {
+	label   string
+	backend interface {
+		Name() string
+		Location() storage.BackupLocation
+		List(context.Context) ([]*types.BackupMetadata, error)
+	}
+}
+
+// dispatchRetentionPlanMode prints the retention plan when --retention-plan is set.
+// It only lists backups: nothing is deleted, so it runs before the backup preflight.
+func dispatchRetentionPlanMode(rt *appRuntime) modeResult {
+	if !rt.args.RetentionPlan {
+		return modeResult{exitCode: types.ExitSuccess.Int()}
+	}
+	logging.DebugStep(rt.logger, "main", "mode=retention-plan")
+	return modeResult{exitCode: runRetentionPlan(rt, os.Stdout), handled: true}
+}
+
+func runRetentionPlan(rt *appRuntime, w io.Writer) int {
+	cfg := rt.cfg
+	// RETENTION_RULES first, so that RETENTION_POLICY=thinning finds its rules
+	// whatever the order of the --override flags.
+	overrides := append([]string(nil), rt.args.RetentionOverrides...)
+	sort.SliceStable(overrides, func(i, j int) bool {
+		return isRetentionRulesOverride(overrides[i]) && !isRetentionRulesOverride(overrides[j])
+	})
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			logging.Error("Invalid --override %q: expected KEY=VALUE", override)
+			return types.ExitConfigError.Int()
+		}
+		if err := cfg.ApplyRetentionOverride(key, value); err != nil {
+			logging.Error("Invalid --override: %v", err)
+			return types.ExitConfigError.Int()
+		}
+	}
+
+	targets, exitCode := retentionPlanTargets(cfg, rt.logger, rt.args.RetentionLocation)
+	if exitCode != types.ExitSuccess.Int() {
+		return exitCode
+	}
+
+	now := time.Now()
+	for i, target := range targets {
+		backups, err := target.backend.List(rt.ctx)
+		if err != nil {
+			logging.Error("%s: unable to list backups: %v", target.label, err)
+			return types.ExitStorageError.Int()
+		}
+		retention := storage.NewRetentionConfigFromConfig(cfg, target.backend.Location())
+		if retention.Policy == "gfs" {
+			retention = storage.NormalizeGFSRetentionConfig(rt.logger, target.backend.Name(), retention)
+		}
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		printRetentionPlan(w, target.label, storage.PlanRetention(backups, retention, now, rt.args.RetentionSimulateRuns))
+	}
+	return types.ExitSuccess.Int()
+}
+
+func isRetentionRulesOverride(override string) bool {
+	key, _, _ := strings.Cut(override, "=")
+	return strings.EqualFold(strings.TrimSpace(key), "RETENTION_RULES")
+}
+
+// retentionPlanTargets returns the enabled storage locations (or only the one
+// requested with --location).
+func retentionPlanTargets(cfg *config.Config, logger *logging.Logger, only string) ([]retentionPlanTarget, int) {
+	var targets []retentionPlanTarget
+	if only == "" || only == "primary" {
+		local, err := storage.NewLocalStorage(cfg, logger)
+		if err != nil {
+			logging.Error("Failed to initialize local storage: %v", err)
+			return nil, types.ExitStorageError.Int()
+		}
+		targets = append(targets, retentionPlanTarget{label: "Local storage (" + cfg.BackupPath + ")", backend: local})
+	}
+	if only == "" || only == "secondary" {
+		switch {
+		case cfg.SecondaryEnabled:
+			secondary, err := storage.NewSecondaryStorage(cfg, logger)
+			if err != nil {
+				logging.Error("Failed to initialize secondary storage: %v", err)
+				return nil, types.ExitStorageError.Int()
+			}
+			targets = append(targets, retentionPlanTarget{label: "Secondary storage (" + cfg.SecondaryPath + ")", backend: secondary})
+		case only == "secondary":
+			logging.Error("Secondary storage is disabled (SECONDARY_ENABLED=false)")
+			return nil, types.ExitConfigError.Int()
+		}
+	}
+	if only == "" || only == "cloud" {
+		switch {
+		case cfg.CloudEnabled:
+			cloud, err := storage.NewCloudStorage(cfg, logger)
+			if err != nil {
+				logging.Error("Failed to initialize cloud storage: %v", err)
+				return nil, types.ExitStorageError.Int()
+			}
+			targets = append(targets, retentionPlanTarget{label: "Cloud storage (" + cfg.CloudRemote + ")", backend: cloud})
+		case only == "cloud":
+			logging.Error("Cloud storage is disabled (CLOUD_ENABLED=false)")
+			return nil, types.ExitConfigError.Int()
+		}
+	}
+	return targets, types.ExitSuccess.Int()
+}
+
+func printRetentionPlan(w io.Writer, label string, plan storage.RetentionPlan) {
+	_, _ = fmt.Fprintf(w, "Retention plan: %s\n", label)
+	cfg := plan.Config
+	_, _ = fmt.Fprintf(w, "Policy: %s\n", cfg.Describe())
+	if plan.SimulatedRuns > 0 {
+		_, _ = fmt.Fprintf(w, "Simulated: %d daily run(s), plan as of %s\n", plan.SimulatedRuns, plan.At.Format("2006-01-02 15:04"))
+	}
+	_, _ = fmt.Fprintln(w)
+
+	if len(plan.Entries) == 0 {
+		_, _ = fmt.Fprintln(w, "  No backups subject to retention.")
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  ACTION\tCREATED\tSIZE\tBACKUP")
+		for _, e := range plan.Entries {
+			action := string(e.Category)
+			if e.Category == storage.CategoryDelete {
+				action = "DELETE"
+			}
+			name := filepath.Base(e.Backup.BackupFile)
+			if e.Simulated {
+				name = "(simulated run)"
+			}
+			if e.DeletedByRun > 0 {
+				name += fmt.Sprintf("  [deleted by run %d]", e.DeletedByRun)
+			}
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", action, e.Backup.Timestamp.Format("2006-01-02 15:04"), formatBytes(e.Backup.Size), name)
+		}
+		_ = tw.Flush()
+	}
+
+	if len(plan.Inert) > 0 {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "  Ignored by retention (never kept as a slot, never deleted):")
+		for _, in := range plan.Inert {
+			_, _ = fmt.Fprintf(w, "    %s (%s)\n", filepath.Base(in.Backup.BackupFile), in.Reason)
+		}
+	}
+
+	counts := plan.Counts()
+	kept := len(plan.Entries) - counts[storage.CategoryDelete]
+	summary := fmt.Sprintf("keep %d", kept)
+	if cfg.IsTimeBased() {
+		tiers := storage.RetentionTiers(cfg, counts)
+		parts := make([]string, 0, len(tiers))
+		for _, t := range tiers {
+			parts = append(parts, fmt.Sprintf("%s %d", t.Category, t.Kept))
+		}
+		summary += " (" + strings.Join(parts, ", ") + ")"
+	}
+	_, _ = fmt.Fprintf(w, "\nSummary: %s, delete %d, ignored %d\n", summary, counts[storage.CategoryDelete], len(plan.Inert))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/storage"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func TestPrintRetentionPlan(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 0, 0, 0, time.UTC)
+	var backups []*types.BackupMetadata
+	for i := 0; i < 3; i++ {
+		ts := now.AddDate(0, 0, -i)
+		backups = append(backups, &types.BackupMetadata{
+			BackupFile: "/backup/pve1-backup-" + ts.Format("20060102") + ".tar.zst",
+			Timestamp:  ts,
+			Size:       1 << 20,
+			Verified:   true,
+		})
+	}
+	backups = append(backups, &types.BackupMetadata{BackupFile: "/backup/pve1-backup-partial.tar.zst", Timestamp: now})
+
+	plan := storage.PlanRetention(backups, storage.RetentionConfig{Policy: "simple", MaxBackups: 2}, now, 1)
+	var out bytes.Buffer
+	printRetentionPlan(&out, "Local storage (/backup)", plan)
+	got := out.String()
+
+	for _, want := range []string{
+		"Retention plan: Local storage (/backup)",
+		"Policy: simple (keep 2 newest)",
+		"Simulated: 1 daily run(s), plan as of 2026-07-07 12:00",
+		"(simulated run)",
+		"pve1-backup-20260705.tar.zst  [deleted by run 1]",
+		"pve1-backup-20260704.tar.zst  [deleted by run 1]",
+		"pve1-backup-partial.tar.zst (no manifest/checksum)",
+		"Summary: keep 2, delete 2, ignored 1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("plan output missing %q\n%s", want, got)
+		}
+	}
+	if !strings.Contains(got, "DELETE") {
+		t.Fatalf("deleted backups must be flagged\n%s", got)
+	}
+}
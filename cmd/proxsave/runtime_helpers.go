@@ -410,39 +410,42 @@ func fetchStorageStats(ctx context.Context, backend storage.Storage, logger *log
 
 func formatStorageInitSummary(name string, cfg *config.Config, location storage.BackupLocation, stats *storage.StorageStats, backups []*types.BackupMetadata) string {
 	retentionConfig := storage.NewRetentionConfigFromConfig(cfg, location)
-	if retentionConfig.Policy == "gfs" {
+	if retentionConfig.IsTimeBased() {
 		retentionConfig = storage.EffectiveGFSRetentionConfig(retentionConfig)
 	}
 
 	if stats == nil {
 		reason := "unable to gather stats"
-		if retentionConfig.Policy == "gfs" {
+		switch retentionConfig.Policy {
+		case "gfs":
 			return fmt.Sprintf("⚠ %s initialized with warnings (%s; GFS retention: daily=%d, weekly=%d, monthly=%d, yearly=%d)",
 				name, reason, retentionConfig.Daily, retentionConfig.Weekly,
 				retentionConfig.Monthly, retentionConfig.Yearly)
+		case "thinning":
+			return fmt.Sprintf("⚠ %s initialized with warnings (%s; retention %s)", name, reason, retentionConfig.Describe())
 		}
 		return fmt.Sprintf("⚠ %s initialized with warnings (%s; retention %s)", name, reason, formatBackupNoun(retentionConfig.MaxBackups))
 	}
 
-	if retentionConfig.Policy == "gfs" {
+	if retentionConfig.IsTimeBased() {
 		result := fmt.Sprintf("✓ %s initialized (present %s)", name, formatBackupNoun(stats.TotalBackups))
-		if stats.TotalBackups > 0 && backups != nil && len(backups) > 0 {
-			classification := storage.ClassifyBackupsGFS(backups, retentionConfig)
-			gfsStats := storage.GetRetentionStats(classification)
-
-			total := stats.TotalBackups
-			kept := total - gfsStats[storage.CategoryDelete]
-
-			result += fmt.Sprintf("\n  Total: %d/-", total)
-			result += fmt.Sprintf("\n  Daily: %d/%d", gfsStats[storage.CategoryDaily], retentionConfig.Daily)
-			result += fmt.Sprintf("\n  Weekly: %d/%d", gfsStats[storage.CategoryWeekly], retentionConfig.Weekly)
-			result += fmt.Sprintf("\n  Monthly: %d/%d", gfsStats[storage.CategoryMonthly], retentionConfig.Monthly)
-			result += fmt.Sprintf("\n  Yearly: %d/%d", gfsStats[storage.CategoryYearly], retentionConfig.Yearly)
-			result += fmt.Sprintf("\n  Kept (est.): %d, To delete (est.): %d", kept, gfsStats[storage.CategoryDelete])
+		var retentionStats map[storage.RetentionCategory]int
+		if stats.TotalBackups > 0 && len(backups) > 0 {
+			classification := storage.ClassifyBackups(backups, retentionConfig)
+			retentionStats = storage.GetRetentionStats(classification)
+			result += fmt.Sprintf("\n  Total: %d/-", stats.TotalBackups)
+			for _, tier := range storage.RetentionTiers(retentionConfig, retentionStats) {
+				result += "\n  " + formatRetentionTier(tier)
+			}
+			kept := stats.TotalBackups - retentionStats[storage.CategoryDelete]
+			result += fmt.Sprintf("\n  Kept (est.): %d, To delete (est.): %d", kept, retentionStats[storage.CategoryDelete])
 		} else {
-			result += fmt.Sprintf("\n  Daily: 0/%d, Weekly: 0/%d, Monthly: 0/%d, Yearly: 0/%d",
-				retentionConfig.Daily, retentionConfig.Weekly,
-				retentionConfig.Monthly, retentionConfig.Yearly)
+			tiers := storage.RetentionTiers(retentionConfig, nil)
+			parts := make([]string, 0, len(tiers))
+			for _, tier := range tiers {
+				parts = append(parts, formatRetentionTier(tier))
+			}
+			result += "\n  " + strings.Join(parts, ", ")
 		}
 		return result
 	}
@@ -452,6 +455,18 @@ func formatStorageInitSummary(name string, cfg *config.Config, location storage.
 	return result
 }
 
+// formatRetentionTier renders one tier as "Daily: 3/7" (GFS) or "7d:6h: 12"
+// (thinning rules have no fixed limit).
+func formatRetentionTier(tier storage.RetentionTierStat) string {
+	label := string(tier.Category)
+	switch tier.Category {
+	case storage.CategoryHourly, storage.CategoryDaily, storage.CategoryWeekly, storage.CategoryMonthly, storage.CategoryYearly, storage.CategoryKeep:
+		label = strings.ToUpper(label[:1]) + label[1:]
+		return fmt.Sprintf("%s: %d/%d", label, tier.Kept, tier.Limit)
+	}
+	return fmt.Sprintf("%s: %d", label, tier.Kept)
+}
+
 func logStorageInitSummary(summary string) {
 	if summary == "" {
 		return
@@ -86,15 +86,27 @@ type Archiver struct {
 	encryptArchive       bool
 	ageRecipients        []age.Recipient
 	excludePatterns      []string
+	volumeSize           int64
 	deps                 ArchiverDeps
 
+	// compressionEngine is the configured engine; native is resolved by
+	// ResolveCompression and routes the archive through the built-in Go codecs.
+	compressionEngine types.CompressionEngine
+	native            bool
+
 	// State for the current CreateArchive run, reset at its start. Written only by
 	// the (single) walk goroutine and read by CreateArchive/VerifyArchive after the
 	// walk has finished (happens-before via the compressor errChan), so no locking
 	// is needed.
-	skipped        []skippedEntry // source entries that could not be archived
-	entriesWritten int            // tar headers successfully written, for verify reconciliation
-	contentVerify  bool           // true once this instance produced the archive (enables entry reconciliation)
+	skipped        []skippedEntry  // source entries that could not be archived
+	entriesWritten int             // tar headers successfully written, for verify reconciliation
+	contentVerify  bool            // true once this instance produced the archive (enables entry reconciliation)
+	volumes        []ArchiveVolume // volumes written when the output was split, in order
+
+	// verifyVolumes holds the volumes of the split archive VerifyArchive is
+	// checking; verifyInputs the readers handed to the verifier commands.
+	verifyVolumes []string
+	verifyInputs  []io.Closer
 }
 
 // ArchiverConfig holds configuration for archive creation
@@ -107,6 +119,8 @@ type ArchiverConfig struct {
 	EncryptArchive     bool
 	AgeRecipients      []age.Recipient
 	ExcludePatterns    []string
+	VolumeSize         int64 // Write the archive as volumes of at most this size (0 = off)
+	CompressionEngine  types.CompressionEngine
 }
 
 // CompressionError represents an external compression error (xz/zstd).
@@ -172,6 +186,14 @@ func (a *ArchiverConfig) Validate() error {
 	if a.CompressionThreads < 0 {
 		return fmt.Errorf("compression threads must be >= 0")
 	}
+	if a.VolumeSize < 0 {
+		return fmt.Errorf("volume size must be >= 0")
+	}
+	switch a.CompressionEngine {
+	case "", types.CompressionEngineAuto, types.CompressionEngineNative, types.CompressionEngineExternal:
+	default:
+		return fmt.Errorf("invalid compression engine: %s", a.CompressionEngine)
+	}
 
 	return nil
 }
@@ -190,7 +212,9 @@ func NewArchiver(logger *logging.Logger, config *ArchiverConfig) *Archiver {
 		encryptArchive:       config.EncryptArchive,
 		ageRecipients:        append([]age.Recipient(nil), config.AgeRecipients...),
 		excludePatterns:      append([]string(nil), config.ExcludePatterns...),
+		volumeSize:           config.VolumeSize,
 		deps:                 defaultArchiverDeps(),
+		compressionEngine:    config.CompressionEngine,
 	}
 }
 
@@ -236,20 +260,55 @@ func (a *Archiver) CompressionThreads() int {
 	return a.compressionThreads
 }
 
+// NativeCompression reports whether the resolved compression runs through the
+// built-in Go codecs instead of an external tool.
+func (a *Archiver) NativeCompression() bool {
+	return a.native
+}
+
+// useNativeCompression decides whether the resolved algorithm runs in-process.
+// binary is the external tool it would otherwise need ("" for gzip, which never
+// needed one). Returns false when the external tool must be used or is missing
+// and no Go codec may replace it.
+func (a *Archiver) useNativeCompression(binary string) bool {
+	switch a.compressionEngine {
+	case types.CompressionEngineNative:
+		return true
+	case types.CompressionEngineAuto:
+		if binary == "" {
+			return true
+		}
+		if _, err := a.findPath(binary); err != nil {
+			a.logger.Info("%s command not available (%v), using the built-in %s encoder", binary, err, a.compression)
+			return true
+		}
+		return false
+	default:
+		return false
+	}
+}
+
 // ResolveCompression ensures the configured compression is available and normalizes
 // the compression level. If the requested algorithm is unavailable it falls back
 // to gzip, keeping the caller informed via logs.
 func (a *Archiver) ResolveCompression() types.CompressionType {
-	a.logger.Debug("Resolving compression (requested=%s level=%d mode=%s)", a.requestedCompression, a.compressionLevel, a.CompressionMode())
+	a.logger.Debug("Resolving compression (requested=%s level=%d mode=%s engine=%s)", a.requestedCompression, a.compressionLevel, a.CompressionMode(), a.compressionEngine)
+	a.native = false
 	switch a.compression {
 	case types.CompressionXZ:
-		if _, err := a.findPath("xz"); err != nil {
+		if a.useNativeCompression("xz") {
+			a.native = true
+			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
+		} else if _, err := a.findPath("xz"); err != nil {
 			a.logger.Warning("xz command not available: %v", err)
 			a.compression = types.CompressionGzip
 			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
 		}
 	case types.CompressionZstd:
-		if _, err := a.findPath("zstd"); err != nil {
+		if a.useNativeCompression("zstd") {
+			a.native = true
+			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
+		} else if _, err := a.findPath("zstd"); err != nil {
 			a.logger.Warning("zstd command not available: %v", err)
 			a.compression = types.CompressionGzip
 			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
@@ -257,7 +316,10 @@ func (a *Archiver) ResolveCompression() types.CompressionType {
 			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
 		}
 	case types.CompressionPigz:
-		if _, err := a.findPath("pigz"); err != nil {
+		if a.useNativeCompression("pigz") {
+			a.native = true
+			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
+		} else if _, err := a.findPath("pigz"); err != nil {
 			a.logger.Warning("pigz command not available: %v", err)
 			a.compression = types.CompressionGzip
 			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
@@ -277,6 +339,7 @@ func (a *Archiver) ResolveCompression() types.CompressionType {
 			a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
 		}
 	case types.CompressionGzip:
+		a.native = a.useNativeCompression("")
 		a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
 	case types.CompressionNone:
 		a.compressionLevel = 0
@@ -285,7 +348,7 @@ func (a *Archiver) ResolveCompression() types.CompressionType {
 		a.compression = types.CompressionGzip
 		a.compressionLevel = normalizeLevelForCompression(a.compression, a.compressionLevel)
 	}
-	a.logger.Debug("Compression resolved to %s (level %d, threads %d)", a.compression, a.compressionLevel, a.compressionThreads)
+	a.logger.Debug("Compression resolved to %s (level %d, threads %d, native %v)", a.compression, a.compressionLevel, a.compressionThreads, a.native)
 	return a.compression
 }
 
@@ -434,6 +497,9 @@ func (a *Archiver) CreateArchive(ctx context.Context, sourceDir, outputPath stri
 	if a.compressionThreads > 0 {
 		threadInfo = fmt.Sprintf("%d", a.compressionThreads)
 	}
+	if a.native {
+		threadInfo += ", built-in encoder"
+	}
 	a.logger.Info("Creating compressed archive with %s (level %d, mode %s, threads %s)",
 		actualCompression, a.compressionLevel, a.CompressionMode(), threadInfo)
 
@@ -454,33 +520,43 @@ func (a *Archiver) CreateArchive(ctx context.Context, sourceDir, outputPath stri
 
 	// Choose compression method
 	var archiveErr error
+	if a.native {
+		archiveErr = a.createNativeArchive(ctx, sourceDir, outputPath)
+	} else {
+		archiveErr = a.createExternalArchive(ctx, actualCompression, sourceDir, outputPath)
+	}
+	if archiveErr != nil {
+		return archiveErr
+	}
+
+	// The compressor finished cleanly; the walk goroutine has joined, so reading
+	// the walk's accumulated state here is race-free. This instance produced the
+	// archive, so VerifyArchive may reconcile its entry count.
+	a.contentVerify = true
+	return a.incompleteArchiveError()
+}
+
+// createExternalArchive creates the archive with the per-algorithm writer: the
+// external tools, the stdlib gzip writer or plain tar.
+func (a *Archiver) createExternalArchive(ctx context.Context, actualCompression types.CompressionType, sourceDir, outputPath string) error {
 	switch actualCompression {
 	case types.CompressionGzip:
-		archiveErr = a.createGzipArchive(ctx, sourceDir, outputPath)
+		return a.createGzipArchive(ctx, sourceDir, outputPath)
 	case types.CompressionPigz:
-		archiveErr = a.createPigzArchive(ctx, sourceDir, outputPath)
+		return a.createPigzArchive(ctx, sourceDir, outputPath)
 	case types.CompressionXZ:
-		archiveErr = a.createXZArchive(ctx, sourceDir, outputPath)
+		return a.createXZArchive(ctx, sourceDir, outputPath)
 	case types.CompressionBzip2:
-		archiveErr = a.createBzip2Archive(ctx, sourceDir, outputPath)
+		return a.createBzip2Archive(ctx, sourceDir, outputPath)
 	case types.CompressionLZMA:
-		archiveErr = a.createLzmaArchive(ctx, sourceDir, outputPath)
+		return a.createLzmaArchive(ctx, sourceDir, outputPath)
 	case types.CompressionZstd:
-		archiveErr = a.createZstdArchive(ctx, sourceDir, outputPath)
+		return a.createZstdArchive(ctx, sourceDir, outputPath)
 	case types.CompressionNone:
-		archiveErr = a.createTarArchive(ctx, sourceDir, outputPath)
+		return a.createTarArchive(ctx, sourceDir, outputPath)
 	default:
 		return fmt.Errorf("unsupported compression type: %s", actualCompression)
 	}
-	if archiveErr != nil {
-		return archiveErr
-	}
-
-	// The compressor finished cleanly; the walk goroutine has joined, so reading
-	// the walk's accumulated state here is race-free. This instance produced the
-	// archive, so VerifyArchive may reconcile its entry count.
-	a.contentVerify = true
-	return a.incompleteArchiveError()
 }
 
 // resetArchiveState clears the per-run accounting before a new CreateArchive walk.
@@ -488,6 +564,7 @@ func (a *Archiver) resetArchiveState() {
 	a.skipped = nil
 	a.entriesWritten = 0
 	a.contentVerify = false
+	a.volumes = nil
 }
 
 // recordSkipped notes a source path that could not be added to the archive.
@@ -556,7 +633,7 @@ func (a *Archiver) createGzipArchive(ctx context.Context, sourceDir, outputPath
 	a.logger.Debug("Creating gzip archive with level %d (mode %s)", a.compressionLevel, a.CompressionMode())
 
 	// Create output file
-	outFile, err := createBackupOutputFile(outputPath)
+	outFile, err := a.openArchiveOutput(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
@@ -598,7 +675,7 @@ func (a *Archiver) createTarArchive(ctx context.Context, sourceDir, outputPath s
 	a.logger.Debug("Creating uncompressed tar archive")
 
 	// Create output file
-	outFile, err := createBackupOutputFile(outputPath)
+	outFile, err := a.openArchiveOutput(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
@@ -669,7 +746,7 @@ func (a *Archiver) createXZArchive(ctx context.Context, sourceDir, outputPath st
 	if err != nil {
 		return err
 	}
-	outFile, err := createBackupOutputFile(outputPath)
+	outFile, err := a.openArchiveOutput(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
@@ -731,7 +808,7 @@ func (a *Archiver) createZstdArchive(ctx context.Context, sourceDir, outputPath
 	if err != nil {
 		return err
 	}
-	outFile, err := createBackupOutputFile(outputPath)
+	outFile, err := a.openArchiveOutput(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
@@ -829,7 +906,7 @@ func createBackupOutputFile(outputPath string) (*os.File, error) {
 }
 
 func (a *Archiver) pipeTarThroughCommand(ctx context.Context, sourceDir, outputPath string, cmd *exec.Cmd, algo string) (err error) {
-	outFile, err := createBackupOutputFile(outputPath)
+	outFile, err := a.openArchiveOutput(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
@@ -1089,24 +1166,39 @@ func (a *Archiver) VerifyArchive(ctx context.Context, archivePath string) error
 		// Basic existence/size checks still apply below
 	}
 
-	// Check if file exists
-	info, err := os.Stat(archivePath)
+	size, volumes, err := archiveSizeOnDisk(archivePath)
 	if err != nil {
 		return fmt.Errorf("archive not found: %w", err)
 	}
 
 	// Check file size
-	if info.Size() == 0 {
+	if size == 0 {
 		return fmt.Errorf("archive is empty")
 	}
+	if len(volumes) > 0 {
+		// A split archive is checked as one stream, volume after volume.
+		a.verifyVolumes = volumes
+		defer func() {
+			for _, input := range a.verifyInputs {
+				_ = input.Close()
+			}
+			a.verifyVolumes, a.verifyInputs = nil, nil
+		}()
+	}
 
-	a.logger.Debug("Archive size: %d bytes", info.Size())
+	a.logger.Debug("Archive size: %d bytes (%d volume(s))", size, max(len(volumes), 1))
 
 	if a.encryptArchive {
 		// Encrypted: skip detailed verification
 		return nil
 	}
 
+	// An archive written by the built-in encoder is checked with the built-in
+	// decoder: the host may have no xz/zstd binary at all.
+	if a.native {
+		return a.verifyNativeArchive(ctx, archivePath)
+	}
+
 	// Test archive integrity based on compression type
 	switch a.compression {
 	case types.CompressionXZ:
@@ -1128,12 +1220,39 @@ func (a *Archiver) VerifyArchive(ctx context.Context, archivePath string) error
 	}
 }
 
+// verifyCommand builds a verifier command that reads archivePath, given as its
+// last argument. For a split archive the volumes are fed on stdin instead.
+func (a *Archiver) verifyCommand(ctx context.Context, archivePath, name string, args ...string) (*exec.Cmd, error) {
+	if len(a.verifyVolumes) == 0 {
+		return a.cmd(ctx, name, append(args, archivePath)...)
+	}
+	cmd, err := a.cmd(ctx, name, append(args, "-")...)
+	if err != nil {
+		return nil, err
+	}
+	input, err := OpenVolumes(a.verifyVolumes)
+	if err != nil {
+		return nil, err
+	}
+	a.verifyInputs = append(a.verifyInputs, input)
+	cmd.Stdin = input
+	return cmd, nil
+}
+
+// openVerifyInput opens the archive stream for the built-in verifier.
+func (a *Archiver) openVerifyInput(archivePath string) (io.ReadCloser, error) {
+	if len(a.verifyVolumes) > 0 {
+		return OpenVolumes(a.verifyVolumes)
+	}
+	return os.Open(archivePath)
+}
+
 // verifyXZArchive tests XZ compression and tar integrity
 func (a *Archiver) verifyXZArchive(ctx context.Context, archivePath string) error {
 	a.logger.Debug("Testing XZ compression integrity")
 
 	// Test XZ compression integrity
-	cmd, err := a.cmd(ctx, "xz", "--test", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "xz", "--test")
 	if err != nil {
 		return err
 	}
@@ -1144,7 +1263,7 @@ func (a *Archiver) verifyXZArchive(ctx context.Context, archivePath string) erro
 	a.logger.Debug("XZ compression test passed")
 
 	// Test tar listing (decompress and list without extracting)
-	cmd, err = a.cmd(ctx, "tar", "-tJf", archivePath)
+	cmd, err = a.verifyCommand(ctx, archivePath, "tar", "-tJf")
 	if err != nil {
 		return err
 	}
@@ -1165,7 +1284,7 @@ func (a *Archiver) verifyZstdArchive(ctx context.Context, archivePath string) er
 	a.logger.Debug("Testing Zstd compression integrity")
 
 	// Test Zstd compression integrity
-	cmd, err := a.cmd(ctx, "zstd", "--test", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "zstd", "--test")
 	if err != nil {
 		return err
 	}
@@ -1176,7 +1295,7 @@ func (a *Archiver) verifyZstdArchive(ctx context.Context, archivePath string) er
 	a.logger.Debug("Zstd compression test passed")
 
 	// Test tar listing (decompress and list without extracting)
-	cmd, err = a.cmd(ctx, "tar", "--use-compress-program=zstd", "-tf", archivePath)
+	cmd, err = a.verifyCommand(ctx, archivePath, "tar", "--use-compress-program=zstd", "-tf")
 	if err != nil {
 		return err
 	}
@@ -1197,7 +1316,7 @@ func (a *Archiver) verifyGzipArchive(ctx context.Context, archivePath string) er
 	a.logger.Debug("Testing Gzip compression integrity")
 
 	// Test tar listing (tar will test gzip integrity automatically)
-	cmd, err := a.cmd(ctx, "tar", "-tzf", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "tar", "-tzf")
 	if err != nil {
 		return err
 	}
@@ -1218,7 +1337,7 @@ func (a *Archiver) verifyBzip2Archive(ctx context.Context, archivePath string) e
 	a.logger.Debug("Testing Bzip2 compression integrity")
 
 	// tar -tjf decompresses through bzip2 and lists, exercising both layers.
-	cmd, err := a.cmd(ctx, "tar", "-tjf", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "tar", "-tjf")
 	if err != nil {
 		return err
 	}
@@ -1239,7 +1358,7 @@ func (a *Archiver) verifyLzmaArchive(ctx context.Context, archivePath string) er
 	a.logger.Debug("Testing LZMA compression integrity")
 
 	// tar --lzma -tf decompresses through lzma and lists, exercising both layers.
-	cmd, err := a.cmd(ctx, "tar", "--lzma", "-tf", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "tar", "--lzma", "-tf")
 	if err != nil {
 		return err
 	}
@@ -1260,7 +1379,7 @@ func (a *Archiver) verifyTarArchive(ctx context.Context, archivePath string) err
 	a.logger.Debug("Testing uncompressed tar integrity")
 
 	// Test tar listing
-	cmd, err := a.cmd(ctx, "tar", "-tf", archivePath)
+	cmd, err := a.verifyCommand(ctx, archivePath, "tar", "-tf")
 	if err != nil {
 		return err
 	}
@@ -1334,13 +1453,32 @@ func runTarListVerification(cmd *exec.Cmd) (int, error) {
 	return counter.lines, nil
 }
 
-// GetArchiveSize returns the size of the archive in bytes
+// GetArchiveSize returns the size of the archive in bytes; for an archive
+// written as volumes it is the size of all volumes together.
 func (a *Archiver) GetArchiveSize(archivePath string) (int64, error) {
+	size, _, err := archiveSizeOnDisk(archivePath)
+	return size, err
+}
+
+// archiveSizeOnDisk stats archivePath, falling back to its volumes when the
+// archive was written split. volumes is empty for a single-file archive.
+func archiveSizeOnDisk(archivePath string) (size int64, volumes []string, err error) {
 	info, err := os.Stat(archivePath)
-	if err != nil {
-		return 0, err
+	if err == nil {
+		return info.Size(), nil, nil
+	}
+	volumes = ExistingVolumePaths(archivePath)
+	if len(volumes) == 0 {
+		return 0, nil, err
+	}
+	for _, path := range volumes {
+		info, verr := os.Stat(path)
+		if verr != nil {
+			return 0, nil, verr
+		}
+		size += info.Size()
 	}
-	return info.Size(), nil
+	return size, volumes, nil
 }
 
 // FormatDuration formats a duration in human-readable format
@@ -37,11 +37,21 @@ type Manifest struct {
 	ScriptVersion    string    `json:"script_version,omitempty"`
 	EncryptionMode   string    `json:"encryption_mode,omitempty"`
 	ClusterMode      string    `json:"cluster_mode,omitempty"`
+	// ClusterScope, ClusterCaptureNode and ClusterSlot are set by coordinated cluster
+	// backups (CLUSTER_BACKUP_MODE=coordinated). A "node" scope archive holds only the
+	// node-local files; the cluster-wide payload of the slot is in the "full" archive
+	// of ClusterCaptureNode.
+	ClusterScope       string `json:"cluster_scope,omitempty"`
+	ClusterCaptureNode string `json:"cluster_capture_node,omitempty"`
+	ClusterSlot        string `json:"cluster_slot,omitempty"`
 	// PassphraseSalt is the per-installation random salt used to derive a
 	// passphrase-based AGE recipient. It is a public value embedded so the
 	// archive stays decryptable from the passphrase alone on any host. Empty
 	// for X25519/SSH recipients and for legacy archives (which used a fixed salt).
 	PassphraseSalt string `json:"passphrase_salt,omitempty"`
+	// Volumes lists, in order, the .partNNN volumes of an archive split by
+	// ARCHIVE_VOLUME_SIZE. Empty for a single-file archive.
+	Volumes []ArchiveVolume `json:"volumes,omitempty"`
 }
 
 // NormalizeChecksum validates and normalizes a SHA256 checksum string.
@@ -91,18 +101,46 @@ func GenerateChecksum(ctx context.Context, logger *logging.Logger, filePath stri
 // On a stalled read the safefs.CopyBounded worker is abandoned while still
 // holding the fd, so the close is skipped and the *os.File finalizer reclaims the
 // fd once the wedged kernel call returns.
-func GenerateChecksumBounded(ctx context.Context, logger *logging.Logger, filePath string, timeout time.Duration) (checksum string, err error) {
+func GenerateChecksumBounded(ctx context.Context, logger *logging.Logger, filePath string, timeout time.Duration) (string, error) {
 	logger.Debug("Generating SHA256 checksum for: %s", filePath)
+	return generateChecksumBounded(ctx, logger, []string{filePath}, timeout)
+}
 
+// GenerateVolumesChecksumBounded returns the SHA256 of the given volumes read
+// back to back: the checksum of the archive they were written from.
+func GenerateVolumesChecksumBounded(ctx context.Context, logger *logging.Logger, paths []string, timeout time.Duration) (string, error) {
+	logger.Debug("Generating SHA256 checksum for %d volume(s) of: %s", len(paths), strings.Join(paths, ", "))
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no volumes to checksum")
+	}
+	return generateChecksumBounded(ctx, logger, paths, timeout)
+}
+
+func generateChecksumBounded(ctx context.Context, logger *logging.Logger, paths []string, timeout time.Duration) (string, error) {
 	// Never open on an already-cancelled context (nothing to leak), and preserve
 	// the unwrapped context error identity callers assert on.
 	if cerr := ctx.Err(); cerr != nil {
 		return "", cerr
 	}
 
+	hash := sha256.New() // fresh per call; never pooled/shared (abandon-safe as CopyBounded dst)
+	for _, filePath := range paths {
+		if err := hashFileBounded(ctx, hash, filePath, timeout); err != nil {
+			return "", err
+		}
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	logger.Debug("Generated checksum: %s", checksum)
+	return checksum, nil
+}
+
+// hashFileBounded streams filePath into hash under the FS I/O stall budget. An
+// abandoned read ends the whole checksum, so the hash is never written again.
+func hashFileBounded(ctx context.Context, hash io.Writer, filePath string, timeout time.Duration) (err error) {
 	file, err := checksumOpen(ctx, filePath, timeout)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() {
 		// A worker abandoned mid-read may still hold this fd: do not close it
@@ -121,20 +159,16 @@ func GenerateChecksumBounded(ctx context.Context, logger *logging.Logger, filePa
 		}
 	}()
 
-	hash := sha256.New() // fresh per call; never pooled/shared (abandon-safe as CopyBounded dst)
 	// bufSize 0 inherits safefs.CopyBounded's 1 MiB default (the stall floor is
 	// then a few tens of KB/s, harmless for a healthy mount). crypto/sha256.Write
 	// is in-memory and never blocks, so only the file read side can wedge.
 	if _, err = safefs.CopyBounded(ctx, hash, file, 0, timeout, "read checksum source file", filePath); err != nil {
 		if safefs.IsAbandoned(err) {
-			return "", err // ErrTimeout / context.Canceled verbatim (close skipped above)
+			return err // ErrTimeout / context.Canceled verbatim (close skipped above)
 		}
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to read file: %w", err)
 	}
-
-	checksum = hex.EncodeToString(hash.Sum(nil))
-	logger.Debug("Generated checksum: %s", checksum)
-	return checksum, nil
+	return nil
 }
 
 // CreateManifest creates a manifest file with archive metadata and checksum
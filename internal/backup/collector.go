@@ -56,6 +56,18 @@ type Collector struct {
 
 	// clusteredPVE records whether cluster mode was detected during PVE collection.
 	clusteredPVE bool
+	// clusterCapture is the coordinated cluster capture decision (nil outside
+	// CLUSTER_BACKUP_MODE=coordinated or on a standalone node).
+	clusterCapture *ClusterCapture
+
+	// pbsSnapshotInventory holds the datastore content report built during PBS collection.
+	pbsSnapshotInventory *PBSSnapshotInventory
+
+	// pveBackupCoverage holds the guest backup coverage audit built during PVE collection.
+	pveBackupCoverage *PVEBackupCoverage
+
+	// certificateExpiry holds the web UI/API certificate expiry report.
+	certificateExpiry *CertificateExpiryReport
 
 	// Manifest tracking for backup contents
 	pbsManifest    map[string]ManifestEntry
@@ -67,6 +79,15 @@ type Collector struct {
 	// (issue #59).
 	recordSystemManifest bool
 	systemManifestDepth  int
+	// customBrickManifest records the targets of declarative custom bricks.
+	customBrickManifest map[string]CustomBrickManifest
+	// customBrickMu guards customBrickManifest and serializes custom brick runs,
+	// which toggle collectingCustomPaths and systemManifestDepth while copying.
+	customBrickMu sync.Mutex
+
+	// brickRuns holds per-brick outcome and timing, in recipe order.
+	brickRuns   []BrickRun
+	brickRunsMu sync.Mutex
 	// collectingCustomPaths is set while copying operator-supplied CUSTOM_BACKUP_PATHS,
 	// during which the source walk prunes the staging workspace to avoid self-copy (#56).
 	collectingCustomPaths bool
@@ -120,6 +141,13 @@ func (c *Collector) depRunCommand(ctx context.Context, name string, args ...stri
 	return runCommand(ctx, name, args...)
 }
 
+func (c *Collector) depRunTrustedCommand(ctx context.Context, execPath string, args ...string) ([]byte, error) {
+	if c.deps.RunTrustedCommand != nil {
+		return c.deps.RunTrustedCommand(ctx, execPath, args...)
+	}
+	return runTrustedCommand(ctx, execPath, args...)
+}
+
 func (c *Collector) depRunCommandWithEnv(ctx context.Context, extraEnv []string, name string, args ...string) ([]byte, error) {
 	if c.deps.RunCommandWithEnv != nil {
 		return c.deps.RunCommandWithEnv(ctx, extraEnv, name, args...)
@@ -130,6 +158,9 @@ func (c *Collector) depRunCommandWithEnv(ctx context.Context, extraEnv []string,
 type CommandSpec struct {
 	Name string
 	Args []string
+	// Timeout bounds a single run. Zero keeps the default (PVESH_TIMEOUT for
+	// pvesh, unbounded otherwise).
+	Timeout time.Duration
 }
 
 func commandSpec(name string, args ...string) CommandSpec {
@@ -195,8 +226,17 @@ type CollectorConfig struct {
 	PVEBackupIncludePattern string
 	BackupCephConfig        bool
 	CephConfigPath          string
-	PveshTimeoutSeconds     int
-	FsIoTimeoutSeconds      int
+
+	// PVE guest backup coverage audit (MaxAgeDays 0 disables the staleness check)
+	PVEBackupCoverageAudit   bool
+	PVEGuestBackupMaxAgeDays int
+	PveshTimeoutSeconds      int
+	FsIoTimeoutSeconds       int
+
+	// Coordinated cluster backups: one node per ClusterCaptureInterval slot captures
+	// the cluster-wide payload, the others only their node-local files.
+	ClusterCoordination    bool
+	ClusterCaptureInterval time.Duration
 
 	// PBS-specific collection options
 	BackupDatastoreConfigs     bool
@@ -217,12 +257,18 @@ type CollectorConfig struct {
 	BackupPruneSchedules       bool
 	BackupPxarFiles            bool
 
+	// PBS snapshot inventory (per-namespace group/verification health report)
+	PBSSnapshotInventory        bool
+	PBSSnapshotVerifyMaxAgeDays int
+	PBSSnapshotStaleDays        int
+
 	// System collection options
 	BackupNetworkConfigs    bool
 	BackupAptSources        bool
 	BackupCronJobs          bool
 	BackupSystemdServices   bool
 	BackupSSLCerts          bool
+	CertExpiryWarnDays      int // 0 disables the certificate expiry audit
 	BackupSysctlConfig      bool
 	BackupKernelModules     bool
 	BackupFirewallRules     bool
@@ -247,6 +293,10 @@ type CollectorConfig struct {
 
 	CustomBackupPaths []string
 	BackupBlacklist   []string
+	CustomBricksFile  string
+	// CollectorWorkers bounds how many independent bricks run at once; values
+	// below 2 keep collection sequential.
+	CollectorWorkers int
 
 	// Paths and overrides
 	ScriptRepositoryPath string
@@ -367,6 +417,9 @@ func GetDefaultCollectorConfig() *CollectorConfig {
 		PveshTimeoutSeconds:     15,
 		FsIoTimeoutSeconds:      30,
 
+		PVEBackupCoverageAudit:   true,
+		PVEGuestBackupMaxAgeDays: 2,
+
 		// PBS-specific (all enabled by default)
 		BackupDatastoreConfigs:     true,
 		BackupPBSS3Endpoints:       true,
@@ -386,12 +439,17 @@ func GetDefaultCollectorConfig() *CollectorConfig {
 		BackupPruneSchedules:       true,
 		BackupPxarFiles:            true,
 
+		PBSSnapshotInventory:        true,
+		PBSSnapshotVerifyMaxAgeDays: 30,
+		PBSSnapshotStaleDays:        2,
+
 		// System collection (all enabled by default)
 		BackupNetworkConfigs:    true,
 		BackupAptSources:        true,
 		BackupCronJobs:          true,
 		BackupSystemdServices:   true,
 		BackupSSLCerts:          true,
+		CertExpiryWarnDays:      30,
 		BackupSysctlConfig:      true,
 		BackupKernelModules:     true,
 		BackupFirewallRules:     true,
@@ -406,6 +464,7 @@ func GetDefaultCollectorConfig() *CollectorConfig {
 		SystemRootPrefix:        "",
 
 		PxarDatastoreConcurrency: 3,
+		CollectorWorkers:         1,
 		PxarFileIncludePatterns:  nil,
 		PxarFileExcludePatterns:  nil,
 
@@ -1071,6 +1130,9 @@ type commandRunOptions struct {
 	logCollection         bool
 	handleSystemctlStatus bool
 	debugNonCritical      bool
+	// execPath, when set, is the validated absolute executable run through
+	// safeexec.TrustedCommandContext instead of the allowlisted spec.Name.
+	execPath string
 }
 
 type commandRunResult struct {
@@ -1117,25 +1179,27 @@ func (c *Collector) runAndClassifyCommand(ctx context.Context, spec CommandSpec,
 	}
 
 	runCtx := ctx
-	var cancel context.CancelFunc
-	if spec.Name == "pvesh" && c.config != nil && c.config.PveshTimeoutSeconds > 0 {
-		runCtx, cancel = context.WithTimeout(ctx, time.Duration(c.config.PveshTimeoutSeconds)*time.Second)
-	}
-	if cancel != nil {
+	timeout := c.commandTimeout(spec)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
-	out, err := c.depRunCommand(runCtx, spec.Name, spec.Args...)
+	var out []byte
+	var err error
+	if opts.execPath != "" {
+		out, err = c.depRunTrustedCommand(runCtx, opts.execPath, spec.Args...)
+	} else {
+		out, err = c.depRunCommand(runCtx, spec.Name, spec.Args...)
+	}
 	result.output = out
 	if err != nil {
 		if isContextCancellationError(runCtx, err) {
-			if isNonCriticalPveshDeadline(ctx, runCtx, spec, opts.critical) {
+			if timeout > 0 && isNonCriticalCommandDeadline(ctx, runCtx, opts.critical) {
 				result.classification = commandRunNonCriticalFailure
 				result.outputSummary = summarizeCommandOutputText(string(out))
-				timeoutSeconds := 0
-				if c.config != nil {
-					timeoutSeconds = c.config.PveshTimeoutSeconds
-				}
+				timeoutSeconds := int(timeout / time.Second)
 				if opts.debugNonCritical {
 					c.logger.Debug("Skipping %s: command `%s` timed out after %d seconds. Non-critical; backup continues. Output: %s",
 						opts.description, cmdString, timeoutSeconds, result.outputSummary)
@@ -1245,12 +1309,23 @@ func (c *Collector) runAndClassifyCommand(ctx context.Context, spec CommandSpec,
 	return result, nil
 }
 
-func isNonCriticalPveshDeadline(parentCtx, runCtx context.Context, spec CommandSpec, critical bool) bool {
+// commandTimeout returns the per-run deadline for spec: its own Timeout when
+// set, PVESH_TIMEOUT for pvesh, and zero (no deadline) otherwise.
+func (c *Collector) commandTimeout(spec CommandSpec) time.Duration {
+	if spec.Timeout > 0 {
+		return spec.Timeout
+	}
+	if spec.Name == "pvesh" && c.config != nil && c.config.PveshTimeoutSeconds > 0 {
+		return time.Duration(c.config.PveshTimeoutSeconds) * time.Second
+	}
+	return 0
+}
+
+func isNonCriticalCommandDeadline(parentCtx, runCtx context.Context, critical bool) bool {
 	if parentCtx == nil || runCtx == nil {
 		return false
 	}
-	return spec.Name == "pvesh" &&
-		!critical &&
+	return !critical &&
 		parentCtx.Err() == nil &&
 		errors.Is(runCtx.Err(), context.DeadlineExceeded)
 }
@@ -1548,6 +1623,24 @@ func (c *Collector) IsClusteredPVE() bool {
 	return c.clusteredPVE
 }
 
+// PBSSnapshotInventory returns the datastore content inventory built during PBS
+// collection, or nil when it was disabled or not collected.
+func (c *Collector) PBSSnapshotInventory() *PBSSnapshotInventory {
+	return c.pbsSnapshotInventory
+}
+
+// PVEBackupCoverage returns the guest backup coverage audit built during PVE
+// collection, or nil when the audit did not run.
+func (c *Collector) PVEBackupCoverage() *PVEBackupCoverage {
+	return c.pveBackupCoverage
+}
+
+// CertificateExpiry returns the certificate expiry report built during system
+// collection, or nil when the audit did not run.
+func (c *Collector) CertificateExpiry() *CertificateExpiryReport {
+	return c.certificateExpiry
+}
+
 func (c *Collector) writeReportFile(path string, data []byte) error {
 	if c.shouldExclude(path) {
 		c.logger.Debug("Skipping report file %s due to exclusion pattern", path)
@@ -4,7 +4,7 @@ package backup
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sync"
 )
 
 // BrickID identifies one behavior-preserving collection step within a backup recipe.
@@ -37,6 +37,7 @@ const (
 	brickPVEStorageMetadataText        BrickID = "pve_storage_metadata_text"
 	brickPVEStorageBackupAnalysis      BrickID = "pve_storage_backup_analysis"
 	brickPVEStorageSummary             BrickID = "pve_storage_summary"
+	brickPVEGuestBackupCoverage        BrickID = "pve_guest_backup_coverage"
 	brickPVECephConfigSnapshot         BrickID = "pve_ceph_config_snapshot"
 	brickPVECephRuntime                BrickID = "pve_ceph_runtime"
 	brickPVEAliasCore                  BrickID = "pve_alias_core"
@@ -140,6 +141,7 @@ const (
 	brickPBSInventoryWrite                      BrickID = "pbs_inventory_write"
 	brickPBSDatastoreCLIConfigs                 BrickID = "pbs_datastore_cli_configs"
 	brickPBSDatastoreNamespaces                 BrickID = "pbs_datastore_namespaces"
+	brickPBSDatastoreSnapshotInventory          BrickID = "pbs_datastore_snapshot_inventory"
 	brickPBSPXARPrepare                         BrickID = "pbs_pxar_prepare"
 	brickPBSPXARMetadata                        BrickID = "pbs_pxar_metadata"
 	brickPBSPXARSubdirReports                   BrickID = "pbs_pxar_subdir_reports"
@@ -188,6 +190,7 @@ const (
 	brickSystemNetworkReport            BrickID = "system_network_report"
 	brickSystemKernel                   BrickID = "system_kernel"
 	brickSystemHardware                 BrickID = "system_hardware"
+	brickSystemCertExpiry               BrickID = "system_cert_expiry"
 	brickSystemCriticalFiles            BrickID = "system_critical_files"
 	brickSystemConfigFile               BrickID = "system_config_file"
 	brickSystemCustomPaths              BrickID = "system_custom_paths"
@@ -196,12 +199,26 @@ const (
 	brickSystemSSHKeys                  BrickID = "system_ssh_keys"
 	brickSystemRootHome                 BrickID = "system_root_home"
 	brickSystemUserHomes                BrickID = "system_user_homes"
+
+	brickCustomBricksIndex BrickID = "custom_bricks_index"
 )
 
 type collectionBrick struct {
 	ID          BrickID
 	Description string
 	Run         func(context.Context, *collectionState) error
+	// DependsOn lists the bricks that must complete first. Nil keeps the
+	// sequential contract: the brick waits for every earlier brick of the recipe.
+	// A non-nil list lets the brick run as soon as those bricks are done; IDs
+	// that are not part of the recipe are ignored.
+	DependsOn []BrickID
+}
+
+// after returns a copy of b that depends only on the given bricks (none means
+// it can start immediately).
+func (b collectionBrick) after(ids ...BrickID) collectionBrick {
+	b.DependsOn = append([]BrickID{}, ids...)
+	return b
 }
 
 func brick(id BrickID, description string, run func(context.Context, *collectionState) error) collectionBrick {
@@ -247,9 +264,12 @@ type recipe struct {
 
 type collectionState struct {
 	collector *Collector
-	pve       pveContext
-	pbs       pbsContext
-	system    systemContext
+	// mu guards the lazy ensure* initializers, which bricks running in
+	// parallel may call concurrently.
+	mu     sync.Mutex
+	pve    pveContext
+	pbs    pbsContext
+	system systemContext
 }
 
 type pveContext struct {
@@ -290,24 +310,6 @@ func newCollectionState(c *Collector) *collectionState {
 	return &collectionState{collector: c}
 }
 
-func runRecipe(ctx context.Context, r recipe, state *collectionState) error {
-	if state == nil {
-		return fmt.Errorf("collection state is required")
-	}
-	for _, brick := range r.Bricks {
-		if err := ctx.Err(); err != nil {
-			return err
-		}
-		if brick.Run == nil {
-			return fmt.Errorf("recipe %s brick %s has no runner", r.Name, brick.ID)
-		}
-		if err := brick.Run(ctx, state); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func isContextCancellationError(ctx context.Context, err error) bool {
 	if err == nil {
 		return false
@@ -331,6 +333,8 @@ func isParentContextError(ctx context.Context, err error) bool {
 }
 
 func (s *collectionState) ensurePVECommandsDir() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pve.commandsDir != "" {
 		return s.pve.commandsDir, nil
 	}
@@ -343,6 +347,8 @@ func (s *collectionState) ensurePVECommandsDir() (string, error) {
 }
 
 func (s *collectionState) ensurePBSCommandsDir() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pbs.commandsDir != "" {
 		return s.pbs.commandsDir, nil
 	}
@@ -355,6 +361,8 @@ func (s *collectionState) ensurePBSCommandsDir() (string, error) {
 }
 
 func (s *collectionState) ensureSystemCommandsDir() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.system.commandsDir != "" {
 		return s.system.commandsDir, nil
 	}
@@ -367,6 +375,8 @@ func (s *collectionState) ensureSystemCommandsDir() (string, error) {
 }
 
 func (s *collectionState) ensurePBSInventoryState() *pbsInventoryState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pbs.inventory == nil {
 		s.pbs.inventory = &pbsInventoryState{}
 	}
@@ -374,6 +384,8 @@ func (s *collectionState) ensurePBSInventoryState() *pbsInventoryState {
 }
 
 func (s *collectionState) ensurePBSDatastoreConfigState() (*pbsDatastoreConfigState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pbs.datastoreConfig != nil {
 		return s.pbs.datastoreConfig, nil
 	}
@@ -386,6 +398,8 @@ func (s *collectionState) ensurePBSDatastoreConfigState() (*pbsDatastoreConfigSt
 }
 
 func (s *collectionState) ensurePBSPXARState(ctx context.Context) (*pbsPxarState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pbs.pxar != nil {
 		return s.pbs.pxar, nil
 	}
@@ -398,6 +412,8 @@ func (s *collectionState) ensurePBSPXARState(ctx context.Context) (*pbsPxarState
 }
 
 func (s *collectionState) ensurePVERuntimeInfo() *pveRuntimeInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.pve.runtimeInfo == nil {
 		s.pve.runtimeInfo = &pveRuntimeInfo{
 			Nodes:    make([]string, 0),
@@ -0,0 +1,202 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BrickRunStatus is the outcome of a single brick within a recipe run.
+type BrickRunStatus string
+
+const (
+	BrickRunOK       BrickRunStatus = "ok"
+	BrickRunFailed   BrickRunStatus = "failed"
+	BrickRunSkipped  BrickRunStatus = "skipped"
+	BrickRunCanceled BrickRunStatus = "canceled"
+)
+
+// BrickRun records the outcome and wall-clock time of one brick. Runs are kept
+// in recipe order regardless of completion order, so the manifest is stable.
+type BrickRun struct {
+	Recipe     string         `json:"recipe"`
+	ID         BrickID        `json:"id"`
+	Status     BrickRunStatus `json:"status"`
+	DurationMS int64          `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type brickResult struct {
+	index    int
+	err      error
+	duration time.Duration
+}
+
+// runRecipe executes the recipe as a dependency graph on a bounded worker pool
+// (COLLECTOR_WORKERS). Ready bricks are started in recipe order, so a single
+// worker reproduces the sequential behavior exactly. A failed brick only skips
+// the bricks that depend on it; the returned error lists every failure in
+// recipe order. Cancellation stops scheduling and returns ctx.Err().
+func runRecipe(ctx context.Context, r recipe, state *collectionState) error {
+	if state == nil {
+		return fmt.Errorf("collection state is required")
+	}
+	for _, b := range r.Bricks {
+		if b.Run == nil {
+			return fmt.Errorf("recipe %s brick %s has no runner", r.Name, b.ID)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	n := len(r.Bricks)
+	deps := make([][]int, n)
+	for i := range r.Bricks {
+		deps[i] = resolveBrickDependencies(r.Bricks, i)
+	}
+
+	workers := state.brickWorkers()
+	runs := make([]BrickRun, n)
+	errs := make([]error, n)
+	started := make([]bool, n)
+	done := make([]bool, n)
+	results := make(chan brickResult)
+	running, finished := 0, 0
+
+	settle := func(i int, status BrickRunStatus, err error, duration time.Duration) {
+		done[i] = true
+		finished++
+		errs[i] = err
+		runs[i] = BrickRun{Recipe: r.Name, ID: r.Bricks[i].ID, Status: status, DurationMS: duration.Milliseconds()}
+		if err != nil {
+			runs[i].Error = err.Error()
+		}
+	}
+
+	for finished < n {
+		for i := 0; i < n && running < workers; i++ {
+			if started[i] {
+				continue
+			}
+			ready, blockedBy := true, -1
+			for _, d := range deps[i] {
+				if !done[d] {
+					ready = false
+					break
+				}
+				if runs[d].Status != BrickRunOK && blockedBy < 0 {
+					blockedBy = d
+				}
+			}
+			if !ready {
+				continue
+			}
+			started[i] = true
+			switch {
+			case ctx.Err() != nil:
+				settle(i, BrickRunCanceled, nil, 0)
+			case blockedBy >= 0:
+				settle(i, BrickRunSkipped, nil, 0)
+				runs[i].Error = fmt.Sprintf("dependency %s did not complete", r.Bricks[blockedBy].ID)
+			default:
+				running++
+				go func(i int) {
+					results <- runBrick(ctx, r.Bricks[i], i, state)
+				}(i)
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		res := <-results
+		running--
+		status := BrickRunOK
+		if res.err != nil {
+			status = BrickRunFailed
+		}
+		settle(res.index, status, res.err, res.duration)
+		if c := state.collector; c != nil && c.logger != nil {
+			c.logger.Debug("Brick %s/%s %s in %s", r.Name, r.Bricks[res.index].ID, status, res.duration.Round(time.Millisecond))
+		}
+	}
+
+	if c := state.collector; c != nil {
+		c.recordBrickRuns(runs)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var failures []error
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err)
+		}
+	}
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return failures[0]
+	default:
+		return errors.Join(failures...)
+	}
+}
+
+// resolveBrickDependencies returns the indexes brick i waits for. Explicit IDs
+// resolve to the closest earlier brick with that ID.
+func resolveBrickDependencies(bricks []collectionBrick, i int) []int {
+	if bricks[i].DependsOn == nil {
+		deps := make([]int, i)
+		for j := range deps {
+			deps[j] = j
+		}
+		return deps
+	}
+	var deps []int
+	for _, id := range bricks[i].DependsOn {
+		for j := i - 1; j >= 0; j-- {
+			if bricks[j].ID == id {
+				deps = append(deps, j)
+				break
+			}
+		}
+	}
+	return deps
+}
+
+func runBrick(ctx context.Context, b collectionBrick, index int, state *collectionState) (res brickResult) {
+	res.index = index
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			res.err = fmt.Errorf("brick %s panicked: %v", b.ID, p)
+		}
+		res.duration = time.Since(start)
+	}()
+	res.err = b.Run(ctx, state)
+	return res
+}
+
+func (s *collectionState) brickWorkers() int {
+	if s.collector == nil || s.collector.config == nil || s.collector.config.CollectorWorkers < 1 {
+		return 1
+	}
+	return s.collector.config.CollectorWorkers
+}
+
+func (c *Collector) recordBrickRuns(runs []BrickRun) {
+	c.brickRunsMu.Lock()
+	defer c.brickRunsMu.Unlock()
+	c.brickRuns = append(c.brickRuns, runs...)
+}
+
+// BrickRuns returns the per-brick outcome and timing of every recipe run so far.
+func (c *Collector) BrickRuns() []BrickRun {
+	c.brickRunsMu.Lock()
+	defer c.brickRunsMu.Unlock()
+	return append([]BrickRun(nil), c.brickRuns...)
+}
@@ -0,0 +1,160 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newDAGTestState(t *testing.T, workers int) (*Collector, *collectionState) {
+	t.Helper()
+	collector := newTestCollector(t)
+	collector.config.CollectorWorkers = workers
+	return collector, newCollectionState(collector)
+}
+
+func TestRunRecipeRunsIndependentBricksConcurrently(t *testing.T) {
+	collector, state := newDAGTestState(t, 2)
+
+	var active, peak int32
+	aStarted := make(chan struct{})
+	bStarted := make(chan struct{})
+	track := func(started chan struct{}, other chan struct{}) func(context.Context, *collectionState) error {
+		return func(context.Context, *collectionState) error {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			close(started)
+			select {
+			case <-other:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("sibling brick never started")
+			}
+		}
+	}
+	var finalSawBoth bool
+	r := recipe{
+		Name: "dag",
+		Bricks: []collectionBrick{
+			brick(brickPBSRuntimeCore, "a", track(aStarted, bStarted)).after(),
+			brick(brickPBSRuntimeNode, "b", track(bStarted, aStarted)).after(),
+			brick(brickPBSRuntimeDatastoreList, "c", func(context.Context, *collectionState) error {
+				return nil
+			}).after(),
+			brick(brickPBSRuntimeDatastoreStatus, "d", func(context.Context, *collectionState) error {
+				select {
+				case <-aStarted:
+				default:
+					return nil
+				}
+				select {
+				case <-bStarted:
+					finalSawBoth = true
+				default:
+				}
+				return nil
+			}).after(brickPBSRuntimeCore, brickPBSRuntimeNode),
+		},
+	}
+
+	if err := runRecipe(context.Background(), r, state); err != nil {
+		t.Fatalf("runRecipe failed: %v", err)
+	}
+	if !finalSawBoth {
+		t.Fatalf("dependent brick ran before its dependencies")
+	}
+	if peak > 2 {
+		t.Fatalf("peak concurrency = %d, want <= 2", peak)
+	}
+
+	var ids []BrickID
+	for _, run := range collector.BrickRuns() {
+		if run.Status != BrickRunOK || run.Recipe != "dag" {
+			t.Fatalf("unexpected run %+v", run)
+		}
+		ids = append(ids, run.ID)
+	}
+	want := []BrickID{brickPBSRuntimeCore, brickPBSRuntimeNode, brickPBSRuntimeDatastoreList, brickPBSRuntimeDatastoreStatus}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("brick runs = %v, want recipe order %v", ids, want)
+	}
+}
+
+func TestRunRecipeIsolatesBrickFailures(t *testing.T) {
+	collector, state := newDAGTestState(t, 1)
+
+	errList := errors.New("list failed")
+	var independentRan, dependentRan bool
+	r := recipe{
+		Name: "isolation",
+		Bricks: []collectionBrick{
+			brick(brickPBSRuntimeACMEAccountsList, "list", func(context.Context, *collectionState) error {
+				return errList
+			}).after(),
+			brick(brickPBSRuntimeACMEAccountInfo, "info", func(context.Context, *collectionState) error {
+				dependentRan = true
+				return nil
+			}).after(brickPBSRuntimeACMEAccountsList),
+			brick(brickPBSRuntimeACMEPluginsList, "plugins", func(context.Context, *collectionState) error {
+				panic("boom")
+			}).after(),
+			brick(brickPBSRuntimeNetwork, "network", func(context.Context, *collectionState) error {
+				independentRan = true
+				return nil
+			}).after(),
+		},
+	}
+
+	err := runRecipe(context.Background(), r, state)
+	if !errors.Is(err, errList) {
+		t.Fatalf("runRecipe error = %v, want %v", err, errList)
+	}
+	if err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+		t.Fatalf("runRecipe error = %v, want the recovered panic as well", err)
+	}
+	if dependentRan {
+		t.Fatalf("brick depending on a failed brick should be skipped")
+	}
+	if !independentRan {
+		t.Fatalf("independent brick should still run after a failure")
+	}
+
+	got := map[BrickID]BrickRunStatus{}
+	for _, run := range collector.BrickRuns() {
+		got[run.ID] = run.Status
+	}
+	want := map[BrickID]BrickRunStatus{
+		brickPBSRuntimeACMEAccountsList: BrickRunFailed,
+		brickPBSRuntimeACMEAccountInfo:  BrickRunSkipped,
+		brickPBSRuntimeACMEPluginsList:  BrickRunFailed,
+		brickPBSRuntimeNetwork:          BrickRunOK,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("brick statuses = %v, want %v", got, want)
+	}
+}
+
+func TestResolveBrickDependencies(t *testing.T) {
+	bricks := []collectionBrick{
+		{ID: brickPBSDatastoreDiscovery},
+		{ID: brickPBSRuntimeCore},
+		brick(brickPBSRuntimeNode, "", nil).after(brickPBSDatastoreDiscovery, brickPBSRuntimeTapeDetect),
+		{ID: brickPBSRuntimeDatastoreList},
+	}
+	if got := resolveBrickDependencies(bricks, 2); !reflect.DeepEqual(got, []int{0}) {
+		t.Fatalf("explicit deps = %v, want [0] (unknown IDs ignored)", got)
+	}
+	if got := resolveBrickDependencies(bricks, 3); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("implicit deps = %v, want every earlier brick", got)
+	}
+}
@@ -44,6 +44,29 @@ func newPBSDatastoreConfigBricks() []collectionBrick {
 				return nil
 			},
 		},
+		{
+			ID:          brickPBSDatastoreSnapshotInventory,
+			Description: "Build PBS datastore snapshot inventory report",
+			Run: func(ctx context.Context, state *collectionState) error {
+				c := state.collector
+				if !c.config.PBSSnapshotInventory {
+					c.logger.Skip("PBS snapshot inventory disabled.")
+					return nil
+				}
+				cfgState, err := state.ensurePBSDatastoreConfigState()
+				if err != nil {
+					c.logger.Warning("Failed to prepare datastore config state: %v", err)
+					return nil
+				}
+				if err := c.collectPBSSnapshotInventory(ctx, cfgState); err != nil {
+					if isParentContextError(ctx, err) {
+						return err
+					}
+					c.logger.Warning("Failed to build PBS snapshot inventory: %v", err)
+				}
+				return nil
+			},
+		},
 	}
 }
 
@@ -3,12 +3,25 @@ package backup
 
 import "context"
 
+// newPBSInventoryBricks returns the datastore inventory bricks. They share the
+// inventory report, so they run as one chain that only waits for datastore
+// discovery and overlaps the runtime bricks; the finalize bricks read the
+// collected command files and wait for everything before them.
 func newPBSInventoryBricks() []collectionBrick {
 	bricks := []collectionBrick{}
 	bricks = append(bricks, newPBSInventoryInitBricks()...)
 	bricks = append(bricks, newPBSInventoryFileBricks()...)
 	bricks = append(bricks, newPBSInventoryHostCommandBricks()...)
-	bricks = append(bricks, newPBSInventoryFinalizeBricks()...)
+	return append(chainBricks(brickPBSDatastoreDiscovery, bricks), newPBSInventoryFinalizeBricks()...)
+}
+
+// chainBricks makes each brick depend on the previous one, the first on root.
+func chainBricks(root BrickID, bricks []collectionBrick) []collectionBrick {
+	prev := root
+	for i := range bricks {
+		bricks[i] = bricks[i].after(prev)
+		prev = bricks[i].ID
+	}
 	return bricks
 }
 
@@ -3,6 +3,9 @@ package backup
 
 import "context"
 
+// newPBSRuntimeBricks returns the PBS runtime command bricks. Each one only
+// waits for datastore discovery (or the listing it expands), so independent
+// proxmox-backup-manager calls overlap when COLLECTOR_WORKERS > 1.
 func newPBSRuntimeBricks() []collectionBrick {
 	bricks := []collectionBrick{}
 	bricks = append(bricks, newPBSRuntimeCoreBricks()...)
@@ -18,16 +21,16 @@ func newPBSRuntimeBricks() []collectionBrick {
 
 func newPBSRuntimeCoreBricks() []collectionBrick {
 	return []collectionBrick{
-		pbsCommandBrick(brickPBSRuntimeCore, "Collect core PBS runtime information", (*Collector).collectPBSCoreRuntime),
-		pbsCommandBrick(brickPBSRuntimeNode, "Collect PBS node runtime information", (*Collector).collectPBSNodeRuntime),
-		pbsCommandBrick(brickPBSRuntimeDatastoreList, "Collect PBS datastore list", (*Collector).collectPBSDatastoreListRuntime),
+		pbsCommandBrick(brickPBSRuntimeCore, "Collect core PBS runtime information", (*Collector).collectPBSCoreRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNode, "Collect PBS node runtime information", (*Collector).collectPBSNodeRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeDatastoreList, "Collect PBS datastore list", (*Collector).collectPBSDatastoreListRuntime).after(brickPBSDatastoreDiscovery),
 		brick(brickPBSRuntimeDatastoreStatus, "Collect PBS datastore status details", func(ctx context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
 				return err
 			}
 			return state.collector.collectPBSDatastoreStatusRuntime(ctx, commandsDir, state.pbs.datastores)
-		}),
+		}).after(brickPBSDatastoreDiscovery),
 	}
 }
 
@@ -44,14 +47,14 @@ func newPBSRuntimeACMEBricks() []collectionBrick {
 			}
 			state.pbs.acmeAccountNames = ids
 			return nil
-		}),
+		}).after(brickPBSDatastoreDiscovery),
 		brick(brickPBSRuntimeACMEAccountInfo, "Collect PBS ACME account details", func(ctx context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
 				return err
 			}
 			return state.collector.collectPBSAcmeAccountInfoRuntime(ctx, commandsDir, state.pbs.acmeAccountNames)
-		}),
+		}).after(brickPBSRuntimeACMEAccountsList),
 		brick(brickPBSRuntimeACMEPluginsList, "Collect the PBS ACME plugin list", func(ctx context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
@@ -63,25 +66,27 @@ func newPBSRuntimeACMEBricks() []collectionBrick {
 			}
 			state.pbs.acmePluginIDs = ids
 			return nil
-		}),
+		}).after(brickPBSDatastoreDiscovery),
 		brick(brickPBSRuntimeACMEPluginConfig, "Collect PBS ACME plugin configuration", func(ctx context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
 				return err
 			}
 			return state.collector.collectPBSAcmePluginConfigRuntime(ctx, commandsDir, state.pbs.acmePluginIDs)
-		}),
+		}).after(brickPBSRuntimeACMEPluginsList),
 	}
 }
 
 func newPBSRuntimeNotificationBricks() []collectionBrick {
 	return []collectionBrick{
-		pbsCommandBrick(brickPBSRuntimeNotificationTargets, "Collect PBS notification targets", (*Collector).collectPBSNotificationTargetsRuntime),
-		pbsCommandBrick(brickPBSRuntimeNotificationMatchers, "Collect PBS notification matchers", (*Collector).collectPBSNotificationMatchersRuntime),
-		pbsCommandBrick(brickPBSRuntimeNotificationEndpointSMTP, "Collect PBS SMTP notification endpoints", (*Collector).collectPBSNotificationEndpointSMTPRuntime),
-		pbsCommandBrick(brickPBSRuntimeNotificationEndpointSendmail, "Collect PBS sendmail notification endpoints", (*Collector).collectPBSNotificationEndpointSendmailRuntime),
-		pbsCommandBrick(brickPBSRuntimeNotificationEndpointGotify, "Collect PBS gotify notification endpoints", (*Collector).collectPBSNotificationEndpointGotifyRuntime),
-		pbsCommandBrick(brickPBSRuntimeNotificationEndpointWebhook, "Collect PBS webhook notification endpoints", (*Collector).collectPBSNotificationEndpointWebhookRuntime),
+		pbsCommandBrick(brickPBSRuntimeNotificationTargets, "Collect PBS notification targets", (*Collector).collectPBSNotificationTargetsRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNotificationMatchers, "Collect PBS notification matchers", (*Collector).collectPBSNotificationMatchersRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNotificationEndpointSMTP, "Collect PBS SMTP notification endpoints", (*Collector).collectPBSNotificationEndpointSMTPRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNotificationEndpointSendmail, "Collect PBS sendmail notification endpoints", (*Collector).collectPBSNotificationEndpointSendmailRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNotificationEndpointGotify, "Collect PBS gotify notification endpoints", (*Collector).collectPBSNotificationEndpointGotifyRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeNotificationEndpointWebhook, "Collect PBS webhook notification endpoints", (*Collector).collectPBSNotificationEndpointWebhookRuntime).after(brickPBSDatastoreDiscovery),
+		// The summary reads the PBS manifest, so it keeps the sequential contract
+		// and waits for every earlier brick.
 		brick(brickPBSRuntimeNotificationSummary, "Write the PBS notification summary", func(_ context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
@@ -106,11 +111,11 @@ func newPBSRuntimeAccessBricks() []collectionBrick {
 			}
 			state.pbs.userIDs = ids
 			return nil
-		}),
-		pbsCommandBrick(brickPBSRuntimeAccessRealmsLDAP, "Collect PBS LDAP realm definitions", (*Collector).collectPBSAccessRealmLDAPRuntime),
-		pbsCommandBrick(brickPBSRuntimeAccessRealmsAD, "Collect PBS Active Directory realm definitions", (*Collector).collectPBSAccessRealmADRuntime),
-		pbsCommandBrick(brickPBSRuntimeAccessRealmsOpenID, "Collect PBS OpenID realm definitions", (*Collector).collectPBSAccessRealmOpenIDRuntime),
-		pbsCommandBrick(brickPBSRuntimeAccessACL, "Collect PBS ACL definitions", (*Collector).collectPBSAccessACLRuntime),
+		}).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeAccessRealmsLDAP, "Collect PBS LDAP realm definitions", (*Collector).collectPBSAccessRealmLDAPRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeAccessRealmsAD, "Collect PBS Active Directory realm definitions", (*Collector).collectPBSAccessRealmADRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeAccessRealmsOpenID, "Collect PBS OpenID realm definitions", (*Collector).collectPBSAccessRealmOpenIDRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeAccessACL, "Collect PBS ACL definitions", (*Collector).collectPBSAccessACLRuntime).after(brickPBSDatastoreDiscovery),
 		brick(brickPBSRuntimeAccessUserTokens, "Collect PBS API token snapshots", func(ctx context.Context, state *collectionState) error {
 			if !state.collector.config.BackupUserConfigs || len(state.pbs.userIDs) == 0 {
 				return nil
@@ -120,7 +125,7 @@ func newPBSRuntimeAccessBricks() []collectionBrick {
 				return err
 			}
 			return state.collector.collectPBSAccessUserTokensRuntime(ctx, usersDir, state.pbs.userIDs)
-		}),
+		}).after(brickPBSRuntimeAccessUsers),
 		brick(brickPBSRuntimeAccessTokensAggregate, "Aggregate PBS API token snapshots", func(_ context.Context, state *collectionState) error {
 			if !state.collector.config.BackupUserConfigs || len(state.pbs.userIDs) == 0 {
 				return nil
@@ -130,17 +135,17 @@ func newPBSRuntimeAccessBricks() []collectionBrick {
 				return err
 			}
 			return state.collector.collectPBSAccessTokensAggregateRuntime(usersDir, state.pbs.userIDs)
-		}),
+		}).after(brickPBSRuntimeAccessUserTokens),
 	}
 }
 
 func newPBSRuntimeJobBricks() []collectionBrick {
 	return []collectionBrick{
-		pbsCommandBrick(brickPBSRuntimeRemotes, "Collect PBS remote definitions", (*Collector).collectPBSRemotesRuntime),
-		pbsCommandBrick(brickPBSRuntimeSyncJobs, "Collect PBS sync jobs", (*Collector).collectPBSSyncJobsRuntime),
-		pbsCommandBrick(brickPBSRuntimeVerificationJobs, "Collect PBS verification jobs", (*Collector).collectPBSVerificationJobsRuntime),
-		pbsCommandBrick(brickPBSRuntimePruneJobs, "Collect PBS prune jobs", (*Collector).collectPBSPruneJobsRuntime),
-		pbsCommandBrick(brickPBSRuntimeGCJobs, "Collect PBS garbage collection jobs", (*Collector).collectPBSGCJobsRuntime),
+		pbsCommandBrick(brickPBSRuntimeRemotes, "Collect PBS remote definitions", (*Collector).collectPBSRemotesRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeSyncJobs, "Collect PBS sync jobs", (*Collector).collectPBSSyncJobsRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeVerificationJobs, "Collect PBS verification jobs", (*Collector).collectPBSVerificationJobsRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimePruneJobs, "Collect PBS prune jobs", (*Collector).collectPBSPruneJobsRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeGCJobs, "Collect PBS garbage collection jobs", (*Collector).collectPBSGCJobsRuntime).after(brickPBSDatastoreDiscovery),
 	}
 }
 
@@ -165,10 +170,10 @@ func newPBSRuntimeTapeBricks() []collectionBrick {
 			state.pbs.tapeSupportKnown = true
 			state.pbs.tapeSupported = supported
 			return nil
-		}),
-		pbsTapeCommandBrick(brickPBSRuntimeTapeDrives, "Collect PBS tape drive inventory", (*Collector).collectPBSTapeDrivesRuntime),
-		pbsTapeCommandBrick(brickPBSRuntimeTapeChangers, "Collect PBS tape changer inventory", (*Collector).collectPBSTapeChangersRuntime),
-		pbsTapeCommandBrick(brickPBSRuntimeTapePools, "Collect PBS tape pool inventory", (*Collector).collectPBSTapePoolsRuntime),
+		}).after(brickPBSDatastoreDiscovery),
+		pbsTapeCommandBrick(brickPBSRuntimeTapeDrives, "Collect PBS tape drive inventory", (*Collector).collectPBSTapeDrivesRuntime).after(brickPBSRuntimeTapeDetect),
+		pbsTapeCommandBrick(brickPBSRuntimeTapeChangers, "Collect PBS tape changer inventory", (*Collector).collectPBSTapeChangersRuntime).after(brickPBSRuntimeTapeDetect),
+		pbsTapeCommandBrick(brickPBSRuntimeTapePools, "Collect PBS tape pool inventory", (*Collector).collectPBSTapePoolsRuntime).after(brickPBSRuntimeTapeDetect),
 	}
 }
 
@@ -184,11 +189,11 @@ func pbsTapeCommandBrick(id BrickID, description string, run func(*Collector, co
 
 func newPBSRuntimeSystemBricks() []collectionBrick {
 	return []collectionBrick{
-		pbsCommandBrick(brickPBSRuntimeNetwork, "Collect PBS network runtime information", (*Collector).collectPBSNetworkRuntime),
-		pbsCommandBrick(brickPBSRuntimeDisks, "Collect the PBS disk inventory", (*Collector).collectPBSDisksRuntime),
-		pbsCommandBrick(brickPBSRuntimeCertInfo, "Collect the PBS certificate summary", (*Collector).collectPBSCertInfoRuntime),
-		pbsCommandBrick(brickPBSRuntimeTrafficControl, "Collect PBS traffic control runtime information", (*Collector).collectPBSTrafficControlRuntime),
-		pbsCommandBrick(brickPBSRuntimeRecentTasks, "Collect recent PBS tasks", (*Collector).collectPBSRecentTasksRuntime),
+		pbsCommandBrick(brickPBSRuntimeNetwork, "Collect PBS network runtime information", (*Collector).collectPBSNetworkRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeDisks, "Collect the PBS disk inventory", (*Collector).collectPBSDisksRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeCertInfo, "Collect the PBS certificate summary", (*Collector).collectPBSCertInfoRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeTrafficControl, "Collect PBS traffic control runtime information", (*Collector).collectPBSTrafficControlRuntime).after(brickPBSDatastoreDiscovery),
+		pbsCommandBrick(brickPBSRuntimeRecentTasks, "Collect recent PBS tasks", (*Collector).collectPBSRecentTasksRuntime).after(brickPBSDatastoreDiscovery),
 	}
 }
 
@@ -205,13 +210,13 @@ func newPBSRuntimeS3Bricks() []collectionBrick {
 			}
 			state.pbs.s3EndpointIDs = ids
 			return nil
-		}),
+		}).after(brickPBSDatastoreDiscovery),
 		brick(brickPBSRuntimeS3EndpointBuckets, "Collect PBS S3 endpoint bucket inventories", func(ctx context.Context, state *collectionState) error {
 			commandsDir, err := state.ensurePBSCommandsDir()
 			if err != nil {
 				return err
 			}
 			return state.collector.collectPBSS3EndpointBucketsRuntime(ctx, commandsDir, state.pbs.s3EndpointIDs)
-		}),
+		}).after(brickPBSRuntimeS3Endpoints),
 	}
 }
@@ -23,6 +23,7 @@ func newPVERecipe() recipe {
 	bricks = append(bricks, newPVEStorageMetadataTextBricks()...)
 	bricks = append(bricks, newPVEStorageAnalysisBricks()...)
 	bricks = append(bricks, newPVEStorageSummaryBricks()...)
+	bricks = append(bricks, newPVEGuestBackupCoverageBricks()...)
 	bricks = append(bricks, newPVECephBricks()...)
 	bricks = append(bricks, newPVEAliasBricks()...)
 	bricks = append(bricks, newPVEAggregateBricks()...)
@@ -63,6 +64,7 @@ func newPVEValidationBricks() []collectionBrick {
 
 				state.pve.clustered = clustered
 				c.clusteredPVE = clustered
+				c.resolvePVEClusterCapture(ctx, clustered)
 				return nil
 			},
 		},
@@ -163,3 +163,30 @@ func newPVEReplicationBricks() []collectionBrick {
 		},
 	}
 }
+
+// newPVEGuestBackupCoverageBricks runs after the storage bricks so the audit can
+// rely on the guest inventory, the job files and the node storage status.
+func newPVEGuestBackupCoverageBricks() []collectionBrick {
+	return []collectionBrick{
+		{
+			ID:          brickPVEGuestBackupCoverage,
+			Description: "Audit PVE guest backup coverage",
+			Run: func(ctx context.Context, state *collectionState) error {
+				c := state.collector
+				if !c.config.PVEBackupCoverageAudit || !c.config.BackupVMConfigs || !c.config.BackupPVEJobs {
+					return nil
+				}
+				if state.pve.guestCollectionAborted || state.pve.jobCollectionAborted {
+					return nil
+				}
+				if err := c.collectPVEBackupCoverage(ctx, state.pve.runtimeStorages()); err != nil {
+					if isContextCancellationError(ctx, err) {
+						return err
+					}
+					c.logger.Warning("Failed to audit PVE backup coverage: %v", err)
+				}
+				return nil
+			},
+		},
+	}
+}
@@ -102,6 +102,16 @@ func newSystemReportBricks() []collectionBrick {
 			}
 			return nil
 		}),
+		brick(brickSystemCertExpiry, "Audit web UI/API certificate expiry", func(ctx context.Context, state *collectionState) error {
+			c := state.collector
+			if err := c.collectCertificateExpiry(ctx); err != nil {
+				if isContextCancellationError(ctx, err) {
+					return err
+				}
+				c.logger.Warning("Failed to audit certificate expiry: %v", err)
+			}
+			return nil
+		}),
 	}
 }
 
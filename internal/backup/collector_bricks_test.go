@@ -222,8 +222,17 @@ func TestRealRecipesHaveCompleteUniqueBricks(t *testing.T) {
 				t.Fatalf("recipe %s has no bricks", r.Name)
 			}
 
+			inRecipe := make(map[BrickID]bool, len(r.Bricks))
+			for _, brick := range r.Bricks {
+				inRecipe[brick.ID] = true
+			}
 			seen := make(map[BrickID]int, len(r.Bricks))
 			for i, brick := range r.Bricks {
+				for _, dep := range brick.DependsOn {
+					if _, earlier := seen[dep]; inRecipe[dep] && !earlier {
+						t.Fatalf("recipe %s brick %s depends on later brick %s", r.Name, brick.ID, dep)
+					}
+				}
 				if brick.ID == "" {
 					t.Fatalf("recipe %s brick %d has empty ID", r.Name, i)
 				}
@@ -271,6 +280,7 @@ func TestNewPVERecipeOrder(t *testing.T) {
 		brickPVEStorageMetadataText,
 		brickPVEStorageBackupAnalysis,
 		brickPVEStorageSummary,
+		brickPVEGuestBackupCoverage,
 		brickPVECephConfigSnapshot,
 		brickPVECephRuntime,
 		brickPVEAliasCore,
@@ -373,6 +383,7 @@ func TestNewPBSRecipeOrder(t *testing.T) {
 		brickPBSInventoryWrite,
 		brickPBSDatastoreCLIConfigs,
 		brickPBSDatastoreNamespaces,
+		brickPBSDatastoreSnapshotInventory,
 		brickPBSPXARPrepare,
 		brickPBSPXARMetadata,
 		brickPBSPXARSubdirReports,
@@ -438,6 +449,7 @@ func TestNewSystemRecipeOrder(t *testing.T) {
 		brickSystemNetworkReport,
 		brickSystemKernel,
 		brickSystemHardware,
+		brickSystemCertExpiry,
 		brickSystemCriticalFiles,
 		brickSystemConfigFile,
 		brickSystemCustomPaths,
@@ -0,0 +1,372 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var certExpiryNow = time.Now
+
+const (
+	certExpiryJSON = "cert_expiry.json"
+	certExpiryText = "cert_expiry.txt"
+)
+
+// CertificateExpiryReport lists the TLS certificates served by the Proxmox web
+// UI/API of the node together with their remaining lifetime and the ACME renewal
+// configuration of the node.
+type CertificateExpiryReport struct {
+	GeneratedAt  time.Time            `json:"generated_at"`
+	WarnDays     int                  `json:"warn_days"`
+	Certificates []CertificateExpiry  `json:"certificates"`
+	ACME         []ACMERenewalSetting `json:"acme,omitempty"`
+}
+
+// CertificateExpiry describes one certificate file. ExpiringSoon is set when the
+// certificate expires within WarnDays; Error is set when the file could not be parsed.
+type CertificateExpiry struct {
+	Name          string    `json:"name"`
+	Product       string    `json:"product"`
+	Path          string    `json:"path"`
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	DNSNames      []string  `json:"dns_names,omitempty"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	Expired       bool      `json:"expired"`
+	ExpiringSoon  bool      `json:"expiring_soon"`
+	ACMEManaged   bool      `json:"acme_managed"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// ACMERenewalSetting is the ACME configuration read from the node config
+// (/etc/pve/local/config on PVE, node.cfg on PBS).
+type ACMERenewalSetting struct {
+	Product string   `json:"product"`
+	Account string   `json:"account,omitempty"`
+	Domains []string `json:"domains,omitempty"`
+}
+
+// CertificateExpirySummary aggregates a certificate expiry report into the counters
+// used by notifications and metrics.
+type CertificateExpirySummary struct {
+	Certificates     int
+	Expired          int
+	ExpiringSoon     int
+	WarnDays         int
+	ExpiredNames     []string
+	ExpiringNames    []string
+	UnreadableNames  []string
+	ACMEConfigured   bool
+	ACMEDescriptions []string
+}
+
+// Summary returns the aggregated counters of the report.
+func (r *CertificateExpiryReport) Summary() CertificateExpirySummary {
+	if r == nil {
+		return CertificateExpirySummary{}
+	}
+	sum := CertificateExpirySummary{WarnDays: r.WarnDays}
+	for _, cert := range r.Certificates {
+		if cert.Error != "" {
+			sum.UnreadableNames = append(sum.UnreadableNames, cert.Name)
+			continue
+		}
+		sum.Certificates++
+		switch {
+		case cert.Expired:
+			sum.Expired++
+			sum.ExpiredNames = append(sum.ExpiredNames, cert.Name)
+		case cert.ExpiringSoon:
+			sum.ExpiringSoon++
+			sum.ExpiringNames = append(sum.ExpiringNames, fmt.Sprintf("%s (%d day(s))", cert.Name, cert.DaysRemaining))
+		}
+	}
+	for _, acme := range r.ACME {
+		sum.ACMEConfigured = true
+		sum.ACMEDescriptions = append(sum.ACMEDescriptions, acme.describe())
+	}
+	return sum
+}
+
+func (a ACMERenewalSetting) describe() string {
+	account := a.Account
+	if account == "" {
+		account = "default"
+	}
+	if len(a.Domains) == 0 {
+		return fmt.Sprintf("%s: account %s", strings.ToUpper(a.Product), account)
+	}
+	return fmt.Sprintf("%s: account %s (%s)", strings.ToUpper(a.Product), account, strings.Join(a.Domains, ", "))
+}
+
+// certExpiryTarget is a certificate file checked by the audit. acmeManaged marks
+// the file that an ACME order replaces on renewal.
+type certExpiryTarget struct {
+	name        string
+	product     string
+	path        string
+	optional    bool
+	acmeManaged bool
+}
+
+func (c *Collector) certExpiryTargets() []certExpiryTarget {
+	var targets []certExpiryTarget
+	if c.proxType.SupportsPVE() {
+		pveRoot := c.effectivePVEConfigPath()
+		targets = append(targets,
+			certExpiryTarget{name: "pve-root-ca.pem", product: "pve", path: filepath.Join(pveRoot, "pve-root-ca.pem"), optional: true},
+			certExpiryTarget{name: "pve-ssl.pem", product: "pve", path: filepath.Join(pveRoot, "local", "pve-ssl.pem")},
+			// pveproxy-ssl.pem only exists once a custom or ACME certificate is installed.
+			certExpiryTarget{name: "pveproxy-ssl.pem", product: "pve", path: filepath.Join(pveRoot, "local", "pveproxy-ssl.pem"), optional: true, acmeManaged: true},
+		)
+	}
+	if c.proxType.SupportsPBS() {
+		targets = append(targets,
+			certExpiryTarget{name: "proxy.pem", product: "pbs", path: filepath.Join(c.pbsConfigPath(), "proxy.pem"), acmeManaged: true},
+		)
+	}
+	return targets
+}
+
+func (c *Collector) acmeNodeConfigs() map[string]string {
+	configs := map[string]string{}
+	if c.proxType.SupportsPVE() {
+		configs["pve"] = filepath.Join(c.effectivePVEConfigPath(), "local", "config")
+	}
+	if c.proxType.SupportsPBS() {
+		configs["pbs"] = filepath.Join(c.pbsConfigPath(), "node.cfg")
+	}
+	return configs
+}
+
+// collectCertificateExpiry parses the web UI/API certificates of the node, records
+// their remaining lifetime and the ACME renewal settings, and writes the report
+// under proxsave_info/system.
+func (c *Collector) collectCertificateExpiry(ctx context.Context) error {
+	warnDays := c.config.CertExpiryWarnDays
+	if warnDays <= 0 {
+		return nil
+	}
+	jsonPath := c.proxsaveInfoDir("system", certExpiryJSON)
+	if c.shouldExclude(jsonPath) {
+		c.incFilesSkipped()
+		return nil
+	}
+	targets := c.certExpiryTargets()
+	if len(targets) == 0 {
+		c.logger.Debug("Certificate expiry audit skipped: no Proxmox certificate locations for %s", c.proxType)
+		return nil
+	}
+
+	now := certExpiryNow()
+	report := &CertificateExpiryReport{GeneratedAt: now, WarnDays: warnDays}
+	acmeByProduct := map[string]ACMERenewalSetting{}
+	nodeConfigs := c.acmeNodeConfigs()
+	for _, product := range []string{"pve", "pbs"} {
+		path, ok := nodeConfigs[product]
+		if !ok {
+			continue
+		}
+		setting, found, err := readACMERenewalSetting(path)
+		if err != nil {
+			c.logger.Debug("Certificate expiry audit: cannot read ACME settings from %s: %v", path, err)
+			continue
+		}
+		if found {
+			setting.Product = product
+			acmeByProduct[product] = setting
+			report.ACME = append(report.ACME, setting)
+		}
+	}
+
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry := CertificateExpiry{Name: target.name, Product: target.product, Path: target.path}
+		cert, err := readFirstCertificate(target.path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && target.optional {
+				continue
+			}
+			entry.Error = err.Error()
+			report.Certificates = append(report.Certificates, entry)
+			continue
+		}
+		_, acme := acmeByProduct[target.product]
+		entry.ACMEManaged = target.acmeManaged && acme
+		entry.Subject = cert.Subject.String()
+		entry.Issuer = cert.Issuer.String()
+		entry.DNSNames = cert.DNSNames
+		entry.NotBefore = cert.NotBefore.UTC()
+		entry.NotAfter = cert.NotAfter.UTC()
+		entry.DaysRemaining = int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+		entry.Expired = !now.Before(cert.NotAfter)
+		entry.ExpiringSoon = !entry.Expired && entry.DaysRemaining < warnDays
+		report.Certificates = append(report.Certificates, entry)
+	}
+	if len(report.Certificates) == 0 {
+		c.logger.Debug("Certificate expiry audit skipped: no certificate found")
+		return nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal certificate expiry report: %w", err)
+	}
+	if err := c.writeReportFile(jsonPath, data); err != nil {
+		return fmt.Errorf("failed to write certificate expiry report: %w", err)
+	}
+	if err := c.writeReportFile(c.proxsaveInfoDir("system", certExpiryText), []byte(formatCertificateExpiry(report))); err != nil {
+		return fmt.Errorf("failed to write certificate expiry summary: %w", err)
+	}
+	c.certificateExpiry = report
+
+	sum := report.Summary()
+	c.logger.Info("Certificate expiry: %d certificate(s) checked", sum.Certificates)
+	if sum.Expired > 0 {
+		c.logger.Warning("Certificate expiry: %d certificate(s) expired: %s",
+			sum.Expired, strings.Join(sum.ExpiredNames, ", "))
+	}
+	if sum.ExpiringSoon > 0 {
+		c.logger.Warning("Certificate expiry: %d certificate(s) expire within %d day(s): %s",
+			sum.ExpiringSoon, sum.WarnDays, strings.Join(sum.ExpiringNames, ", "))
+	}
+	if len(sum.UnreadableNames) > 0 {
+		c.logger.Warning("Certificate expiry: cannot read %s", strings.Join(sum.UnreadableNames, ", "))
+	}
+	return nil
+}
+
+// readFirstCertificate returns the first CERTIFICATE block of a PEM file (the leaf
+// certificate when the file carries a chain).
+func readFirstCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM certificate in %s", path)
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate %s: %w", path, err)
+		}
+		return cert, nil
+	}
+}
+
+// readACMERenewalSetting parses the acme/acmedomainN properties of a PVE or PBS
+// node config. found is false when the node has no ACME configuration.
+func readACMERenewalSetting(path string) (setting ACMERenewalSetting, found bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return setting, false, nil
+		}
+		return setting, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		switch {
+		case key == "acme":
+			found = true
+			props := parseACMEPropertyString(value, "account")
+			if props["account"] != "" {
+				setting.Account = props["account"]
+			}
+			// Legacy single-line form: acme: domains=a.example;b.example
+			for _, d := range strings.Split(props["domains"], ";") {
+				if d = strings.TrimSpace(d); d != "" {
+					setting.Domains = append(setting.Domains, d)
+				}
+			}
+		case strings.HasPrefix(key, "acmedomain"):
+			found = true
+			if d := parseACMEPropertyString(value, "domain")["domain"]; d != "" {
+				setting.Domains = append(setting.Domains, d)
+			}
+		}
+	}
+	return setting, found, scanner.Err()
+}
+
+// parseACMEPropertyString splits a Proxmox property string ("a=1,b=2"); a value
+// without a key is stored under defaultKey.
+func parseACMEPropertyString(value, defaultKey string) map[string]string {
+	props := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if k, v, ok := strings.Cut(part, "="); ok {
+			props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			continue
+		}
+		props[defaultKey] = part
+	}
+	return props
+}
+
+func formatCertificateExpiry(r *CertificateExpiryReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Certificate expiry generated %s\n", r.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Warning threshold: %d day(s)\n", r.WarnDays)
+
+	sb.WriteString("\nCertificates\n")
+	for _, cert := range r.Certificates {
+		if cert.Error != "" {
+			fmt.Fprintf(&sb, "  %-18s %s: %s\n", cert.Name, cert.Path, cert.Error)
+			continue
+		}
+		state := "ok"
+		switch {
+		case cert.Expired:
+			state = "EXPIRED"
+		case cert.ExpiringSoon:
+			state = "EXPIRING"
+		}
+		acme := ""
+		if cert.ACMEManaged {
+			acme = " [ACME]"
+		}
+		fmt.Fprintf(&sb, "  %-18s %-8s expires %s (%d day(s))%s\n",
+			cert.Name, state, cert.NotAfter.Format("2006-01-02"), cert.DaysRemaining, acme)
+		fmt.Fprintf(&sb, "  %-18s subject %s, issuer %s\n", "", cert.Subject, cert.Issuer)
+	}
+
+	sb.WriteString("\nACME renewal\n")
+	if len(r.ACME) == 0 {
+		sb.WriteString("  (not configured)\n")
+	}
+	for _, acme := range r.ACME {
+		fmt.Fprintf(&sb, "  %s\n", acme.describe())
+	}
+	return sb.String()
+}
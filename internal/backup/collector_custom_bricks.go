@@ -0,0 +1,521 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CustomBricksIndexPath is the archive-relative location of the custom brick
+// index, which restore reads to map custom brick outputs to restore categories.
+const CustomBricksIndexPath = "var/lib/proxsave-info/custom_bricks.json"
+
+const (
+	customBricksIndexFile = "custom_bricks.json"
+	customBrickIDPrefix   = "custom_"
+	// customBrickCommandsComponent is the commands/ subdirectory holding custom
+	// brick command outputs, one directory per brick.
+	customBrickCommandsComponent = "custom"
+
+	customBrickDefaultCommandTimeout = 60 * time.Second
+	customBricksFileMaxBytes         = 1 << 20
+)
+
+var customBrickIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// CustomBrickFile is the declarative brick definition file (CUSTOM_BRICKS_FILE).
+type CustomBrickFile struct {
+	Bricks []CustomBrickSpec `json:"bricks"`
+}
+
+// CustomBrickSpec declares one extra collection step: directories copied into the
+// archive at their original path and commands whose output is saved as a report.
+type CustomBrickSpec struct {
+	ID           string                 `json:"id"`
+	Description  string                 `json:"description,omitempty"`
+	Category     string                 `json:"category"`
+	CategoryName string                 `json:"category_name,omitempty"`
+	Directories  []CustomBrickDirectory `json:"directories,omitempty"`
+	Commands     []CustomBrickCommand   `json:"commands,omitempty"`
+}
+
+// CustomBrickDirectory is a directory copied by a custom brick. Include and
+// Exclude are globs matched against the path relative to Path and against the
+// file name; an empty Include keeps every file.
+type CustomBrickDirectory struct {
+	Path    string   `json:"path"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// CustomBrickCommand is a command run by a custom brick. Command[0] is resolved
+// through PATH; its stdout/stderr is written to Output below the brick's
+// commands directory.
+type CustomBrickCommand struct {
+	Command        []string `json:"command"`
+	Output         string   `json:"output"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// CustomBrickManifest is the manifest record of one custom brick run, keyed by
+// tempDir-relative target path like the other manifest sections.
+type CustomBrickManifest struct {
+	Category string                   `json:"category"`
+	Files    map[string]ManifestEntry `json:"files,omitempty"`
+}
+
+// CustomBrickIndex is written to CustomBricksIndexPath. Paths are category
+// patterns ("./etc/nginx/") for the collected directories; Outputs lists the
+// command reports, which stay in the export-only proxsave_info category.
+type CustomBrickIndex struct {
+	Bricks []CustomBrickIndexEntry `json:"bricks"`
+}
+
+// CustomBrickIndexEntry describes the restore mapping of one custom brick.
+type CustomBrickIndexEntry struct {
+	ID           string   `json:"id"`
+	Description  string   `json:"description,omitempty"`
+	Category     string   `json:"category"`
+	CategoryName string   `json:"category_name,omitempty"`
+	Paths        []string `json:"paths,omitempty"`
+	Outputs      []string `json:"outputs,omitempty"`
+}
+
+// BrickID returns the recipe brick ID of the spec.
+func (s CustomBrickSpec) BrickID() BrickID {
+	return BrickID(customBrickIDPrefix + s.ID)
+}
+
+func (s CustomBrickSpec) describe() string {
+	if strings.TrimSpace(s.Description) != "" {
+		return s.Description
+	}
+	return fmt.Sprintf("Collect custom brick %s", s.ID)
+}
+
+func (cmd CustomBrickCommand) spec() CommandSpec {
+	spec := commandSpec(cmd.Command[0], cmd.Command[1:]...)
+	spec.Timeout = customBrickDefaultCommandTimeout
+	if cmd.TimeoutSeconds > 0 {
+		spec.Timeout = time.Duration(cmd.TimeoutSeconds) * time.Second
+	}
+	return spec
+}
+
+// LoadCustomBricks reads and validates a declarative brick definition file. The
+// file defines commands run as root on every backup, so it must be owned by
+// root (or the running user) and not group/world-writable.
+func LoadCustomBricks(path string) ([]CustomBrickSpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("custom bricks file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("custom bricks file %s is not a regular file", path)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return nil, fmt.Errorf("custom bricks file %s is group/world-writable (mode %#o)", path, info.Mode().Perm())
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if st.Uid != 0 && int(st.Uid) != os.Geteuid() {
+			return nil, fmt.Errorf("custom bricks file %s is owned by uid %d, not root", path, st.Uid)
+		}
+	}
+	if info.Size() > customBricksFileMaxBytes {
+		return nil, fmt.Errorf("custom bricks file %s too large (%d bytes)", path, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("custom bricks file: %w", err)
+	}
+	return ParseCustomBricks(data)
+}
+
+// ParseCustomBricks decodes and validates brick definitions. Unknown fields are
+// rejected so a misspelled key does not silently disable a filter.
+func ParseCustomBricks(data []byte) ([]CustomBrickSpec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file CustomBrickFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse custom bricks: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(file.Bricks))
+	for i := range file.Bricks {
+		spec := &file.Bricks[i]
+		spec.ID = strings.TrimSpace(spec.ID)
+		spec.Category = strings.TrimSpace(spec.Category)
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("custom brick #%d: %w", i+1, err)
+		}
+		if _, dup := seen[spec.ID]; dup {
+			return nil, fmt.Errorf("custom brick %s: duplicate id", spec.ID)
+		}
+		seen[spec.ID] = struct{}{}
+	}
+	return file.Bricks, nil
+}
+
+func (s *CustomBrickSpec) validate() error {
+	if !customBrickIDPattern.MatchString(s.ID) {
+		return fmt.Errorf("invalid id %q (use lowercase letters, digits, '_' or '-')", s.ID)
+	}
+	if s.BrickID() == brickCustomBricksIndex {
+		return fmt.Errorf("id %q is reserved", s.ID)
+	}
+	if !customBrickIDPattern.MatchString(s.Category) {
+		return fmt.Errorf("%s: invalid category %q (use lowercase letters, digits, '_' or '-')", s.ID, s.Category)
+	}
+	if len(s.Directories) == 0 && len(s.Commands) == 0 {
+		return fmt.Errorf("%s: no directories or commands defined", s.ID)
+	}
+
+	for i := range s.Directories {
+		dir := &s.Directories[i]
+		dir.Path = strings.TrimSpace(dir.Path)
+		if !filepath.IsAbs(dir.Path) {
+			return fmt.Errorf("%s: directory %q must be an absolute path", s.ID, dir.Path)
+		}
+		dir.Path = filepath.Clean(dir.Path)
+		if dir.Path == "/" {
+			return fmt.Errorf("%s: directory / is not allowed", s.ID)
+		}
+		for _, pattern := range append(append([]string(nil), dir.Include...), dir.Exclude...) {
+			if _, err := filepath.Match(filepath.ToSlash(pattern), ""); err != nil {
+				return fmt.Errorf("%s: invalid glob %q for %s: %w", s.ID, pattern, dir.Path, err)
+			}
+		}
+	}
+
+	outputs := make(map[string]struct{}, len(s.Commands))
+	for i := range s.Commands {
+		cmd := &s.Commands[i]
+		if len(cmd.Command) == 0 {
+			return fmt.Errorf("%s: command #%d is empty", s.ID, i+1)
+		}
+		if err := commandSpec(cmd.Command[0], cmd.Command[1:]...).validate(); err != nil {
+			return fmt.Errorf("%s: command #%d: %w", s.ID, i+1, err)
+		}
+		cmd.Output = strings.TrimSpace(cmd.Output)
+		if cmd.Output == "" || cmd.Output == "." || cmd.Output == ".." || strings.ContainsAny(cmd.Output, `/\`) {
+			return fmt.Errorf("%s: command #%d: output must be a plain file name, got %q", s.ID, i+1, cmd.Output)
+		}
+		if _, dup := outputs[cmd.Output]; dup {
+			return fmt.Errorf("%s: duplicate output %s", s.ID, cmd.Output)
+		}
+		outputs[cmd.Output] = struct{}{}
+		if cmd.TimeoutSeconds < 0 {
+			return fmt.Errorf("%s: command #%d: timeout_seconds must not be negative", s.ID, i+1)
+		}
+	}
+	return nil
+}
+
+// loadCustomBrickSpecs loads CUSTOM_BRICKS_FILE. A missing or invalid file is
+// reported and disables custom bricks without failing the backup.
+func (c *Collector) loadCustomBrickSpecs() []CustomBrickSpec {
+	path := strings.TrimSpace(c.config.CustomBricksFile)
+	if path == "" {
+		return nil
+	}
+	specs, err := LoadCustomBricks(path)
+	if err != nil {
+		c.logger.Warning("Custom bricks disabled: %v", err)
+		return nil
+	}
+	c.logger.Debug("Loaded %d custom brick(s) from %s", len(specs), path)
+	return specs
+}
+
+// newCustomBricks builds recipe bricks for the loaded specs, followed by the
+// brick writing the restore index. It returns nil when no specs are defined.
+func newCustomBricks(specs []CustomBrickSpec) []collectionBrick {
+	if len(specs) == 0 {
+		return nil
+	}
+	bricks := make([]collectionBrick, 0, len(specs)+1)
+	for _, spec := range specs {
+		spec := spec
+		bricks = append(bricks, brick(spec.BrickID(), spec.describe(), func(ctx context.Context, state *collectionState) error {
+			return state.collector.runCustomBrick(ctx, spec)
+		}))
+	}
+	bricks = append(bricks, brick(brickCustomBricksIndex, "Write custom brick restore index", func(ctx context.Context, state *collectionState) error {
+		return state.collector.writeCustomBricksIndex(specs)
+	}))
+	return bricks
+}
+
+// runCustomBrick executes one custom brick. Individual failures are recorded in
+// the manifest and logged; only cancellation aborts the recipe. The brick holds
+// customBrickMu for its whole run: it writes the manifest and flips collector
+// flags that are not safe to share with another brick.
+func (c *Collector) runCustomBrick(ctx context.Context, spec CustomBrickSpec) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.customBrickMu.Lock()
+	defer c.customBrickMu.Unlock()
+	c.logger.Debug("Running custom brick %s (category %s)", spec.ID, spec.Category)
+
+	record := CustomBrickManifest{Category: spec.Category, Files: make(map[string]ManifestEntry)}
+	if c.customBrickManifest == nil {
+		c.customBrickManifest = make(map[string]CustomBrickManifest)
+	}
+	c.customBrickManifest[spec.ID] = record
+
+	for _, dir := range spec.Directories {
+		dest := filepath.Join(c.tempDir, strings.TrimPrefix(dir.Path, "/"))
+		entry, err := c.copyCustomBrickDirectory(ctx, spec.ID, dir, dest)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		record.Files[pveManifestKey(c.tempDir, dest)] = entry
+	}
+
+	if len(spec.Commands) > 0 {
+		outDir := c.customBrickCommandsDir(spec.ID)
+		for _, cmd := range spec.Commands {
+			output := filepath.Join(outDir, cmd.Output)
+			entry := c.runCustomBrickCommand(ctx, spec.ID, cmd, output)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			record.Files[pveManifestKey(c.tempDir, output)] = entry
+		}
+	}
+	return nil
+}
+
+func (c *Collector) customBrickCommandsDir(id string) string {
+	return c.proxsaveCommandsDir(filepath.Join(customBrickCommandsComponent, id))
+}
+
+// copyCustomBrickDirectory copies the files of dir selected by its globs. Like
+// CUSTOM_BACKUP_PATHS the source is operator-supplied, so the staging workspace
+// is pruned from the walk (#56).
+func (c *Collector) copyCustomBrickDirectory(ctx context.Context, id string, dir CustomBrickDirectory, dest string) (ManifestEntry, error) {
+	src := c.systemPath(dir.Path)
+	info, err := os.Stat(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.logger.Debug("Custom brick %s: directory %s not found (skipping)", id, dir.Path)
+			return ManifestEntry{Status: StatusNotFound}, nil
+		}
+		c.logger.Warning("Custom brick %s: cannot access %s: %v", id, dir.Path, err)
+		return ManifestEntry{Status: StatusFailed, Error: err.Error()}, err
+	}
+	if !info.IsDir() {
+		err := fmt.Errorf("%s is not a directory", dir.Path)
+		c.logger.Warning("Custom brick %s: %v", id, err)
+		return ManifestEntry{Status: StatusFailed, Error: err.Error()}, err
+	}
+
+	c.collectingCustomPaths = true
+	defer func() { c.collectingCustomPaths = false }()
+	// Per-file recording in the system manifest is suppressed as in safeCopyDir;
+	// the custom brick section records the directory target instead.
+	c.systemManifestDepth++
+	defer func() { c.systemManifestDepth-- }()
+
+	var size int64
+	failed := 0
+	walkErr := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if errCtx := ctx.Err(); errCtx != nil {
+			return errCtx
+		}
+		if err != nil {
+			if path == src {
+				return err
+			}
+			c.logger.Debug("Custom brick %s: skipping %s: %v", id, path, err)
+			return nil
+		}
+		if c.isWithinStagingDir(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if customBrickGlobMatch(dir.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if len(dir.Include) > 0 && !customBrickGlobMatch(dir.Include, rel) {
+			return nil
+		}
+
+		target := filepath.Join(dest, rel)
+		if err := c.safeCopyFile(ctx, path, target, fmt.Sprintf("custom brick %s file %s", id, rel)); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			c.logger.Warning("Custom brick %s: skipping unreadable file %s: %v", id, path, err)
+			failed++
+			return nil
+		}
+		if fi, err := os.Lstat(target); err == nil && fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	if walkErr != nil {
+		c.logger.Warning("Custom brick %s: failed to copy %s: %v", id, dir.Path, walkErr)
+		return ManifestEntry{Status: StatusFailed, Error: walkErr.Error()}, walkErr
+	}
+	if failed > 0 {
+		return ManifestEntry{Status: StatusFailed, Size: size, Error: fmt.Sprintf("%d file(s) could not be copied", failed)}, nil
+	}
+	return ManifestEntry{Status: StatusCollected, Size: size}, nil
+}
+
+// customBrickGlobMatch reports whether rel (or its file name) matches a pattern.
+func customBrickGlobMatch(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(rel)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if matchesGlob(pattern, rel) || matchesGlob(pattern, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCustomBrickExecutable is the ownership check on a resolved custom brick
+// command; tests replace it to run fake commands.
+var checkCustomBrickExecutable = validateCustomBrickExecutable
+
+// validateCustomBrickExecutable accepts only an absolute, root-owned regular
+// executable that is not group/world-writable: custom brick commands run as
+// root and are outside the safeexec allowlist.
+func validateCustomBrickExecutable(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%q is not an absolute path", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not an executable file", resolved)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("%s is group/world-writable (mode %#o)", resolved, info.Mode().Perm())
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Uid != 0 {
+		return fmt.Errorf("%s is owned by uid %d, not root", resolved, st.Uid)
+	}
+	return nil
+}
+
+func (c *Collector) runCustomBrickCommand(ctx context.Context, id string, cmd CustomBrickCommand, output string) ManifestEntry {
+	spec := cmd.spec()
+	description := fmt.Sprintf("custom brick %s output %s", id, cmd.Output)
+	execPath, err := c.depLookPath(spec.Name)
+	if err != nil {
+		c.logger.Debug("Command not available: %s (skipping %s)", spec.Name, description)
+		return ManifestEntry{Status: StatusSkipped}
+	}
+	if err := checkCustomBrickExecutable(execPath); err != nil {
+		c.logger.Warning("Custom brick %s: refusing to run %s: %v", id, spec.Name, err)
+		return ManifestEntry{Status: StatusFailed, Error: err.Error()}
+	}
+	result, err := c.runAndClassifyCommand(ctx, spec, commandRunOptions{
+		output:        output,
+		description:   description,
+		caller:        "customBrick",
+		logCollection: true,
+		execPath:      execPath,
+	})
+	if err != nil {
+		return ManifestEntry{Status: StatusFailed, Error: err.Error()}
+	}
+
+	switch result.classification {
+	case commandRunSucceeded:
+	case commandRunNonCriticalFailure:
+		return ManifestEntry{Status: StatusFailed, Error: result.outputSummary}
+	default:
+		if c.dryRun {
+			return ManifestEntry{Status: StatusCollected}
+		}
+		return ManifestEntry{Status: StatusSkipped}
+	}
+
+	if err := c.writeReportFile(output, result.output); err != nil {
+		c.logger.Warning("Custom brick %s: failed to write %s: %v", id, cmd.Output, err)
+		return ManifestEntry{Status: StatusFailed, Error: err.Error()}
+	}
+	return ManifestEntry{Status: StatusCollected, Size: int64(len(result.output))}
+}
+
+// writeCustomBricksIndex records which archive paths each custom brick produced,
+// so restore can offer them under the brick's category.
+func (c *Collector) writeCustomBricksIndex(specs []CustomBrickSpec) error {
+	c.customBrickMu.Lock()
+	defer c.customBrickMu.Unlock()
+	index := CustomBrickIndex{Bricks: make([]CustomBrickIndexEntry, 0, len(specs))}
+	for _, spec := range specs {
+		entry := CustomBrickIndexEntry{
+			ID:           spec.ID,
+			Description:  spec.Description,
+			Category:     spec.Category,
+			CategoryName: spec.CategoryName,
+		}
+		record := c.customBrickManifest[spec.ID]
+		for _, dir := range spec.Directories {
+			key := pveManifestKey(c.tempDir, filepath.Join(c.tempDir, strings.TrimPrefix(dir.Path, "/")))
+			if status := record.Files[key].Status; status == StatusCollected || status == StatusFailed {
+				entry.Paths = append(entry.Paths, "./"+key+"/")
+			}
+		}
+		for _, cmd := range spec.Commands {
+			key := pveManifestKey(c.tempDir, filepath.Join(c.customBrickCommandsDir(spec.ID), cmd.Output))
+			if record.Files[key].Status == StatusCollected {
+				entry.Outputs = append(entry.Outputs, key)
+			}
+		}
+		sort.Strings(entry.Paths)
+		index.Bricks = append(index.Bricks, entry)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := c.writeReportFile(c.proxsaveInfoDir(customBricksIndexFile), data); err != nil {
+		c.logger.Warning("Failed to write custom brick index: %v", err)
+	}
+	return nil
+}
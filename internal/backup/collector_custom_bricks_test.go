@@ -0,0 +1,310 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCustomBricks(t *testing.T) {
+	specs, err := ParseCustomBricks([]byte(`{"bricks":[{
+		"id":"nginx","category":"nginx","category_name":"Nginx",
+		"directories":[{"path":"/etc/nginx/","include":["*.conf"],"exclude":["**/*.bak"]}],
+		"commands":[{"command":["nginx","-T"],"output":"nginx_T.txt","timeout_seconds":5}]
+	}]}`))
+	if err != nil {
+		t.Fatalf("ParseCustomBricks: %v", err)
+	}
+	if len(specs) != 1 || specs[0].BrickID() != "custom_nginx" || specs[0].Directories[0].Path != "/etc/nginx" {
+		t.Fatalf("specs = %+v", specs)
+	}
+	if got := specs[0].Commands[0].spec(); got.Timeout != 5*time.Second || got.String() != "nginx -T" {
+		t.Fatalf("command spec = %+v", got)
+	}
+
+	invalid := map[string]string{
+		"unknown field":      `{"bricks":[{"id":"a","category":"a","dirs":[]}]}`,
+		"bad id":             `{"bricks":[{"id":"A b","category":"a","commands":[{"command":["true"],"output":"o"}]}]}`,
+		"reserved id":        `{"bricks":[{"id":"bricks_index","category":"a","commands":[{"command":["true"],"output":"o"}]}]}`,
+		"missing category":   `{"bricks":[{"id":"a","commands":[{"command":["true"],"output":"o"}]}]}`,
+		"empty brick":        `{"bricks":[{"id":"a","category":"a"}]}`,
+		"relative dir":       `{"bricks":[{"id":"a","category":"a","directories":[{"path":"etc/nginx"}]}]}`,
+		"root dir":           `{"bricks":[{"id":"a","category":"a","directories":[{"path":"/"}]}]}`,
+		"bad glob":           `{"bricks":[{"id":"a","category":"a","directories":[{"path":"/etc","include":["["]}]}]}`,
+		"command path":       `{"bricks":[{"id":"a","category":"a","commands":[{"command":["/usr/sbin/nginx"],"output":"o"}]}]}`,
+		"output path":        `{"bricks":[{"id":"a","category":"a","commands":[{"command":["true"],"output":"../o"}]}]}`,
+		"duplicate output":   `{"bricks":[{"id":"a","category":"a","commands":[{"command":["true"],"output":"o"},{"command":["false"],"output":"o"}]}]}`,
+		"negative timeout":   `{"bricks":[{"id":"a","category":"a","commands":[{"command":["true"],"output":"o","timeout_seconds":-1}]}]}`,
+		"duplicate brick":    `{"bricks":[{"id":"a","category":"a","commands":[{"command":["true"],"output":"o"}]},{"id":"a","category":"b","commands":[{"command":["true"],"output":"o"}]}]}`,
+		"empty command":      `{"bricks":[{"id":"a","category":"a","commands":[{"command":[],"output":"o"}]}]}`,
+		"malformed json doc": `{"bricks":`,
+	}
+	for name, doc := range invalid {
+		if _, err := ParseCustomBricks([]byte(doc)); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestLoadCustomBricksMissingFile(t *testing.T) {
+	if _, err := LoadCustomBricks(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestRunCustomBricks(t *testing.T) {
+	var gotTimeout time.Duration
+	origCheck := checkCustomBrickExecutable
+	checkCustomBrickExecutable = func(string) error { return nil }
+	t.Cleanup(func() { checkCustomBrickExecutable = origCheck })
+	collector := newTestCollectorWithDeps(t, CollectorDeps{
+		LookPath: func(name string) (string, error) { return "/usr/bin/" + name, nil },
+		RunTrustedCommand: func(ctx context.Context, execPath string, args ...string) ([]byte, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatalf("expected a deadline for %s", execPath)
+			}
+			gotTimeout = time.Until(deadline)
+			if execPath == "/usr/bin/slow" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return []byte("server { listen 80; }\n"), nil
+		},
+	})
+	root := t.TempDir()
+	collector.config.SystemRootPrefix = root
+	for path, content := range map[string]string{
+		"etc/nginx/nginx.conf":                 "main",
+		"etc/nginx/sites/default.conf":         "site",
+		"etc/nginx/sites/default.conf.bak":     "old",
+		"etc/nginx/mime.types":                 "types",
+		"etc/nginx/cache/entry.conf":           "cached",
+		"etc/nginx/sites/snippets/ssl.conf.in": "template",
+	} {
+		writeCoverageFixture(t, filepath.Join(root, path), content)
+	}
+
+	specs, err := ParseCustomBricks([]byte(`{"bricks":[{
+		"id":"nginx","category":"nginx",
+		"directories":[
+			{"path":"/etc/nginx","include":["*.conf"],"exclude":["*.bak","cache"]},
+			{"path":"/etc/missing"}
+		],
+		"commands":[
+			{"command":["nginx","-T"],"output":"nginx_T.txt","timeout_seconds":30},
+			{"command":["slow"],"output":"slow.txt","timeout_seconds":1}
+		]
+	}]}`))
+	if err != nil {
+		t.Fatalf("ParseCustomBricks: %v", err)
+	}
+
+	state := newCollectionState(collector)
+	if err := runRecipe(context.Background(), recipe{Name: "custom", Bricks: newCustomBricks(specs)}, state); err != nil {
+		t.Fatalf("runRecipe: %v", err)
+	}
+
+	for rel, want := range map[string]bool{
+		"etc/nginx/nginx.conf":             true,
+		"etc/nginx/sites/default.conf":     true,
+		"etc/nginx/sites/default.conf.bak": false,
+		"etc/nginx/mime.types":             false,
+		"etc/nginx/cache/entry.conf":       false,
+	} {
+		_, err := os.Stat(filepath.Join(collector.tempDir, rel))
+		if got := err == nil; got != want {
+			t.Errorf("%s collected = %v, want %v", rel, got, want)
+		}
+	}
+	if gotTimeout <= 0 || gotTimeout > time.Second {
+		t.Errorf("last command deadline = %v, want within the 1s timeout", gotTimeout)
+	}
+
+	outputPath := filepath.Join(collector.customBrickCommandsDir("nginx"), "nginx_T.txt")
+	if data, err := os.ReadFile(outputPath); err != nil || !strings.Contains(string(data), "listen 80") {
+		t.Fatalf("command output = %q, %v", data, err)
+	}
+
+	record := collector.customBrickManifest["nginx"]
+	if record.Category != "nginx" {
+		t.Fatalf("manifest category = %q", record.Category)
+	}
+	wantStatus := map[string]ManifestFileStatus{
+		"etc/nginx":   StatusCollected,
+		"etc/missing": StatusNotFound,
+		"var/lib/proxsave-info/commands/custom/nginx/nginx_T.txt": StatusCollected,
+		"var/lib/proxsave-info/commands/custom/nginx/slow.txt":    StatusFailed,
+	}
+	for key, status := range wantStatus {
+		if got := record.Files[key].Status; got != status {
+			t.Errorf("manifest[%s] = %q, want %q", key, got, status)
+		}
+	}
+	if size := record.Files["etc/nginx"].Size; size != int64(len("main")+len("site")) {
+		t.Errorf("directory size = %d", size)
+	}
+	if len(collector.systemManifest) != 0 {
+		t.Errorf("custom brick files leaked into system manifest: %v", collector.systemManifest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(collector.tempDir, CustomBricksIndexPath))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	var index CustomBrickIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("decode index: %v", err)
+	}
+	if len(index.Bricks) != 1 {
+		t.Fatalf("index = %+v", index)
+	}
+	entry := index.Bricks[0]
+	if entry.Category != "nginx" || len(entry.Paths) != 1 || entry.Paths[0] != "./etc/nginx/" {
+		t.Fatalf("index entry = %+v", entry)
+	}
+	if len(entry.Outputs) != 1 || entry.Outputs[0] != "var/lib/proxsave-info/commands/custom/nginx/nginx_T.txt" {
+		t.Fatalf("index outputs = %v", entry.Outputs)
+	}
+}
+
+func TestLoadCustomBricksRejectsWritableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bricks.json")
+	doc := `{"bricks":[{"id":"a","category":"a","commands":[{"command":["true"],"output":"o"}]}]}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCustomBricks(path); err != nil {
+		t.Fatalf("LoadCustomBricks(0600): %v", err)
+	}
+	if err := os.Chmod(path, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCustomBricks(path); err == nil || !strings.Contains(err.Error(), "writable") {
+		t.Fatalf("expected group/world-writable file to be rejected, got %v", err)
+	}
+}
+
+func TestRunCustomBrickCommandUsesTrustedExecutable(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root-owned system executables")
+	}
+	if _, err := exec.LookPath("printf"); err != nil {
+		t.Skip("printf not available")
+	}
+	// printf is not in the safeexec allowlist: it must run via its trusted path.
+	collector := newTestCollector(t)
+	specs, err := ParseCustomBricks([]byte(`{"bricks":[{"id":"site","category":"site",
+		"commands":[{"command":["printf","custom-brick"],"output":"out.txt"}]}]}`))
+	if err != nil {
+		t.Fatalf("ParseCustomBricks: %v", err)
+	}
+	state := newCollectionState(collector)
+	if err := runRecipe(context.Background(), recipe{Name: "custom", Bricks: newCustomBricks(specs)}, state); err != nil {
+		t.Fatalf("runRecipe: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(collector.customBrickCommandsDir("site"), "out.txt"))
+	if err != nil || string(data) != "custom-brick" {
+		t.Fatalf("command output = %q, %v", data, err)
+	}
+}
+
+func TestValidateCustomBrickExecutable(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(script, 0o775); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateCustomBrickExecutable(script); err == nil {
+		t.Fatal("expected group-writable executable to be rejected")
+	}
+	if err := validateCustomBrickExecutable("tool"); err == nil {
+		t.Fatal("expected relative path to be rejected")
+	}
+	if err := os.Chmod(script, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := validateCustomBrickExecutable(script)
+	if os.Geteuid() == 0 && err != nil {
+		t.Fatalf("root-owned 0755 executable rejected: %v", err)
+	}
+	if os.Geteuid() != 0 && err == nil {
+		t.Fatal("expected non-root-owned executable to be rejected")
+	}
+}
+
+func TestLoadCustomBrickSpecsInvalidFileDisablesBricks(t *testing.T) {
+	collector := newTestCollector(t)
+	path := filepath.Join(t.TempDir(), "bricks.json")
+	if err := os.WriteFile(path, []byte(`{"bricks":[{"id":"x"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	collector.config.CustomBricksFile = path
+	if specs := collector.loadCustomBrickSpecs(); specs != nil {
+		t.Fatalf("expected invalid file to disable custom bricks, got %+v", specs)
+	}
+	if bricks := newCustomBricks(nil); bricks != nil {
+		t.Fatalf("expected no bricks without specs")
+	}
+}
+
+func TestRunCustomBrickSerializesConcurrentRuns(t *testing.T) {
+	origCheck := checkCustomBrickExecutable
+	checkCustomBrickExecutable = func(string) error { return nil }
+	t.Cleanup(func() { checkCustomBrickExecutable = origCheck })
+
+	var active, peak int32
+	collector := newTestCollectorWithDeps(t, CollectorDeps{
+		LookPath: func(name string) (string, error) { return "/usr/bin/" + name, nil },
+		RunTrustedCommand: func(ctx context.Context, execPath string, args ...string) ([]byte, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return []byte("ok\n"), nil
+		},
+	})
+	specs, err := ParseCustomBricks([]byte(`{"bricks":[
+		{"id":"first","category":"first","commands":[{"command":["first"],"output":"first.txt"}]},
+		{"id":"second","category":"second","commands":[{"command":["second"],"output":"second.txt"}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseCustomBricks: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, spec := range specs {
+		wg.Add(1)
+		go func(spec CustomBrickSpec) {
+			defer wg.Done()
+			if err := collector.runCustomBrick(context.Background(), spec); err != nil {
+				t.Errorf("runCustomBrick(%s): %v", spec.ID, err)
+			}
+		}(spec)
+	}
+	wg.Wait()
+
+	if peak != 1 {
+		t.Fatalf("peak concurrent custom brick commands = %d, want 1", peak)
+	}
+	for _, spec := range specs {
+		key := "var/lib/proxsave-info/commands/custom/" + spec.ID + "/" + spec.ID + ".txt"
+		if got := collector.customBrickManifest[spec.ID].Files[key].Status; got != StatusCollected {
+			t.Errorf("manifest[%s][%s] = %q, want %q", spec.ID, key, got, StatusCollected)
+		}
+	}
+}
@@ -26,6 +26,16 @@ var (
 		return runCommandWithEnv(ctx, nil, name, args...)
 	}
 
+	// runTrustedCommand runs an operator-configured executable by absolute path,
+	// for commands outside the safeexec allowlist (custom bricks).
+	runTrustedCommand = func(ctx context.Context, execPath string, args ...string) ([]byte, error) {
+		cmd, err := safeexec.TrustedCommandContext(ctx, execPath, args...)
+		if err != nil {
+			return nil, err
+		}
+		return cmd.CombinedOutput()
+	}
+
 	statFunc = os.Stat
 )
 
@@ -34,6 +44,7 @@ type CollectorDeps struct {
 	LookPath                    func(string) (string, error)
 	RunCommandWithEnv           func(context.Context, []string, string, ...string) ([]byte, error)
 	RunCommand                  func(context.Context, string, ...string) ([]byte, error)
+	RunTrustedCommand           func(context.Context, string, ...string) ([]byte, error)
 	Stat                        func(string) (os.FileInfo, error)
 	DetectUnprivilegedContainer func() (bool, string)
 }
@@ -49,6 +60,9 @@ func defaultCollectorDeps() CollectorDeps {
 		RunCommand: func(ctx context.Context, name string, args ...string) ([]byte, error) {
 			return runCommand(ctx, name, args...)
 		},
+		RunTrustedCommand: func(ctx context.Context, execPath string, args ...string) ([]byte, error) {
+			return runTrustedCommand(ctx, execPath, args...)
+		},
 		Stat: func(path string) (os.FileInfo, error) {
 			return statFunc(path)
 		},
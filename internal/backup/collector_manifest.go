@@ -31,14 +31,16 @@ type ManifestEntry struct {
 // record of the shipped payload is the archive sidecar (<archive>.sha256 and
 // <archive>.manifest.json), computed after the archive is built.
 type BackupManifest struct {
-	CreatedAt      time.Time                `json:"created_at"`
-	Hostname       string                   `json:"hostname"`
-	ProxmoxType    string                   `json:"proxmox_type"`
-	ProxmoxTargets []string                 `json:"proxmox_targets,omitempty"`
-	PBSConfigs     map[string]ManifestEntry `json:"pbs_configs,omitempty"`
-	PVEConfigs     map[string]ManifestEntry `json:"pve_configs,omitempty"`
-	SystemFiles    map[string]ManifestEntry `json:"system_files,omitempty"`
-	Stats          ManifestStats            `json:"stats"`
+	CreatedAt      time.Time                      `json:"created_at"`
+	Hostname       string                         `json:"hostname"`
+	ProxmoxType    string                         `json:"proxmox_type"`
+	ProxmoxTargets []string                       `json:"proxmox_targets,omitempty"`
+	PBSConfigs     map[string]ManifestEntry       `json:"pbs_configs,omitempty"`
+	PVEConfigs     map[string]ManifestEntry       `json:"pve_configs,omitempty"`
+	SystemFiles    map[string]ManifestEntry       `json:"system_files,omitempty"`
+	CustomBricks   map[string]CustomBrickManifest `json:"custom_bricks,omitempty"`
+	Bricks         []BrickRun                     `json:"bricks,omitempty"`
+	Stats          ManifestStats                  `json:"stats"`
 }
 
 // ManifestStats contains summary statistics for the manifest
@@ -53,6 +55,8 @@ type ManifestStats struct {
 
 // WriteManifest writes the backup manifest to the temp directory
 func (c *Collector) WriteManifest(hostname string) error {
+	c.customBrickMu.Lock()
+	defer c.customBrickMu.Unlock()
 	manifest := BackupManifest{
 		CreatedAt:      time.Now().UTC(),
 		Hostname:       hostname,
@@ -61,6 +65,8 @@ func (c *Collector) WriteManifest(hostname string) error {
 		PBSConfigs:     c.pbsManifest,
 		PVEConfigs:     c.pveManifest,
 		SystemFiles:    c.systemManifest,
+		CustomBricks:   c.customBrickManifest,
+		Bricks:         c.BrickRuns(),
 		Stats: ManifestStats{
 			FilesProcessed: c.stats.FilesProcessed,
 			FilesFailed:    c.stats.FilesFailed,
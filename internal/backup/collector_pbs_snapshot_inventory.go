@@ -0,0 +1,335 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/pbs"
+)
+
+var (
+	listSnapshotsFunc               = pbs.ListSnapshots
+	listSnapshotsFromFilesystemFunc = pbs.ListSnapshotsFromFilesystem
+	pbsSnapshotInventoryNow         = time.Now
+)
+
+const (
+	pbsSnapshotInventoryJSON = "snapshot_inventory.json"
+	pbsSnapshotInventoryText = "snapshot_inventory.txt"
+)
+
+// PBSSnapshotInventory is the per-datastore, per-namespace content report of a PBS host.
+type PBSSnapshotInventory struct {
+	GeneratedAt      time.Time                       `json:"generated_at"`
+	VerifyMaxAgeDays int                             `json:"verify_max_age_days"`
+	StaleAfterDays   int                             `json:"stale_after_days"`
+	Datastores       []PBSDatastoreSnapshotInventory `json:"datastores"`
+}
+
+// PBSDatastoreSnapshotInventory groups the namespace reports of one datastore.
+type PBSDatastoreSnapshotInventory struct {
+	Name       string                          `json:"name"`
+	Path       string                          `json:"path,omitempty"`
+	Namespaces []PBSNamespaceSnapshotInventory `json:"namespaces"`
+}
+
+// PBSNamespaceSnapshotInventory summarises the backup groups found in a namespace.
+// UnverifiedSnapshots counts snapshots older than the verify window that have no
+// successful verification within it; FailedSnapshots counts snapshots whose last
+// verification failed. Source is "cli" or "filesystem".
+type PBSNamespaceSnapshotInventory struct {
+	Namespace           string                      `json:"namespace"`
+	Source              string                      `json:"source,omitempty"`
+	Error               string                      `json:"error,omitempty"`
+	GroupCounts         map[string]int              `json:"group_counts"`
+	Snapshots           int                         `json:"snapshots"`
+	UnverifiedSnapshots int                         `json:"unverified_snapshots"`
+	FailedSnapshots     int                         `json:"failed_snapshots"`
+	StaleGroups         int                         `json:"stale_groups"`
+	Groups              []PBSSnapshotGroupInventory `json:"groups"`
+}
+
+// PBSSnapshotGroupInventory describes a single VM/CT/host backup group.
+type PBSSnapshotGroupInventory struct {
+	Type                string     `json:"type"`
+	ID                  string     `json:"id"`
+	Snapshots           int        `json:"snapshots"`
+	Oldest              time.Time  `json:"oldest"`
+	Newest              time.Time  `json:"newest"`
+	LastVerified        *time.Time `json:"last_verified,omitempty"`
+	UnverifiedSnapshots int        `json:"unverified_snapshots"`
+	FailedSnapshots     int        `json:"failed_snapshots"`
+	Stale               bool       `json:"stale"`
+}
+
+// PBSSnapshotInventorySummary aggregates an inventory into the counters used by
+// notifications. StaleGroupNames lists "<datastore>:<ns>/<type>/<id>" entries.
+type PBSSnapshotInventorySummary struct {
+	Datastores          int
+	Namespaces          int
+	Groups              int
+	Snapshots           int
+	UnverifiedSnapshots int
+	FailedSnapshots     int
+	StaleGroups         int
+	VerifyMaxAgeDays    int
+	StaleAfterDays      int
+	StaleGroupNames     []string
+	Errors              int
+}
+
+// Summary returns the aggregated counters of the inventory.
+func (inv *PBSSnapshotInventory) Summary() PBSSnapshotInventorySummary {
+	if inv == nil {
+		return PBSSnapshotInventorySummary{}
+	}
+	sum := PBSSnapshotInventorySummary{
+		Datastores:       len(inv.Datastores),
+		VerifyMaxAgeDays: inv.VerifyMaxAgeDays,
+		StaleAfterDays:   inv.StaleAfterDays,
+	}
+	for _, ds := range inv.Datastores {
+		for _, ns := range ds.Namespaces {
+			sum.Namespaces++
+			if ns.Error != "" {
+				sum.Errors++
+			}
+			sum.Groups += len(ns.Groups)
+			sum.Snapshots += ns.Snapshots
+			sum.UnverifiedSnapshots += ns.UnverifiedSnapshots
+			sum.FailedSnapshots += ns.FailedSnapshots
+			sum.StaleGroups += ns.StaleGroups
+			for _, g := range ns.Groups {
+				if g.Stale {
+					sum.StaleGroupNames = append(sum.StaleGroupNames, pbsSnapshotGroupLabel(ds.Name, ns.Namespace, g))
+				}
+			}
+		}
+	}
+	return sum
+}
+
+func pbsSnapshotGroupLabel(datastore, ns string, g PBSSnapshotGroupInventory) string {
+	if ns == "" {
+		return fmt.Sprintf("%s:%s/%s", datastore, g.Type, g.ID)
+	}
+	return fmt.Sprintf("%s:%s/%s/%s", datastore, ns, g.Type, g.ID)
+}
+
+// buildPBSNamespaceSnapshotInventory folds a snapshot listing into per-group
+// statistics. verifyMaxAge and staleAfter of zero disable the respective checks.
+func buildPBSNamespaceSnapshotInventory(ns string, snapshots []pbs.Snapshot, now time.Time, verifyMaxAge, staleAfter time.Duration) PBSNamespaceSnapshotInventory {
+	out := PBSNamespaceSnapshotInventory{
+		Namespace:   ns,
+		GroupCounts: make(map[string]int),
+	}
+	groups := make(map[string]*PBSSnapshotGroupInventory)
+	for _, snap := range snapshots {
+		key := snap.GroupKey()
+		g, ok := groups[key]
+		if !ok {
+			g = &PBSSnapshotGroupInventory{Type: snap.BackupType, ID: snap.BackupID}
+			groups[key] = g
+			out.GroupCounts[snap.BackupType]++
+		}
+		taken := time.Unix(snap.BackupTime, 0).UTC()
+		g.Snapshots++
+		if g.Oldest.IsZero() || taken.Before(g.Oldest) {
+			g.Oldest = taken
+		}
+		if taken.After(g.Newest) {
+			g.Newest = taken
+		}
+
+		state := ""
+		if snap.Verification != nil {
+			state = strings.ToLower(strings.TrimSpace(snap.Verification.State))
+		}
+		verifiedAt, hasVerifyTime := snap.Verification.Time()
+		if state == "ok" && hasVerifyTime {
+			verifiedAt = verifiedAt.UTC()
+			if g.LastVerified == nil || verifiedAt.After(*g.LastVerified) {
+				g.LastVerified = &verifiedAt
+			}
+		}
+		switch {
+		case state == "failed":
+			g.FailedSnapshots++
+		case verifyMaxAge > 0 && now.Sub(taken) > verifyMaxAge:
+			// Fresh snapshots are not expected to be verified yet; only flag the
+			// ones that had a full window to be picked up by a verify job.
+			if state != "ok" || (hasVerifyTime && now.Sub(verifiedAt) > verifyMaxAge) {
+				g.UnverifiedSnapshots++
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		g := groups[key]
+		g.Stale = staleAfter > 0 && now.Sub(g.Newest) > staleAfter
+		out.Snapshots += g.Snapshots
+		out.UnverifiedSnapshots += g.UnverifiedSnapshots
+		out.FailedSnapshots += g.FailedSnapshots
+		if g.Stale {
+			out.StaleGroups++
+		}
+		out.Groups = append(out.Groups, *g)
+	}
+	return out
+}
+
+// collectPBSSnapshotInventory lists the snapshots of every datastore namespace and
+// writes the content inventory report under proxsave_info/pbs/datastores.
+func (c *Collector) collectPBSSnapshotInventory(ctx context.Context, state *pbsDatastoreConfigState) error {
+	if state == nil || len(state.datastores) == 0 {
+		return nil
+	}
+	jsonPath := filepath.Join(state.datastoreDir, pbsSnapshotInventoryJSON)
+	if c.shouldExclude(jsonPath) {
+		c.incFilesSkipped()
+		return nil
+	}
+
+	ioTimeout := time.Duration(0)
+	if c.config.FsIoTimeoutSeconds > 0 {
+		ioTimeout = time.Duration(c.config.FsIoTimeoutSeconds) * time.Second
+	}
+	verifyMaxAge := time.Duration(max(c.config.PBSSnapshotVerifyMaxAgeDays, 0)) * 24 * time.Hour
+	staleAfter := time.Duration(max(c.config.PBSSnapshotStaleDays, 0)) * 24 * time.Hour
+	now := pbsSnapshotInventoryNow()
+
+	inv := &PBSSnapshotInventory{
+		GeneratedAt:      now,
+		VerifyMaxAgeDays: max(c.config.PBSSnapshotVerifyMaxAgeDays, 0),
+		StaleAfterDays:   max(c.config.PBSSnapshotStaleDays, 0),
+	}
+	for _, ds := range state.datastores {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dsInv := PBSDatastoreSnapshotInventory{Name: ds.Name, Path: ds.Path}
+		namespaces := state.namespaceResults[ds.pathKey()]
+		if len(namespaces) == 0 {
+			namespaces = []pbs.Namespace{{Ns: ""}}
+		}
+		for _, ns := range namespaces {
+			var (
+				snapshots    []pbs.Snapshot
+				fromFallback bool
+				err          error
+			)
+			if ds.isOverride() {
+				dir := strings.TrimSpace(ns.Path)
+				if dir == "" {
+					dir = pbs.NamespaceDir(ds.normalizedPath(), ns.Ns)
+				}
+				snapshots, err = listSnapshotsFromFilesystemFunc(ctx, dir, ioTimeout)
+				fromFallback = true
+			} else {
+				snapshots, fromFallback, err = listSnapshotsFunc(ctx, ds.cliName(), ds.Path, ns, ioTimeout)
+			}
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				c.logger.Debug("Failed to list snapshots for datastore %s namespace %q: %v", ds.Name, ns.Ns, err)
+				dsInv.Namespaces = append(dsInv.Namespaces, PBSNamespaceSnapshotInventory{
+					Namespace:   ns.Ns,
+					Error:       err.Error(),
+					GroupCounts: map[string]int{},
+				})
+				continue
+			}
+			nsInv := buildPBSNamespaceSnapshotInventory(ns.Ns, snapshots, now, verifyMaxAge, staleAfter)
+			nsInv.Source = pbsDatastoreSourceCLI
+			if fromFallback {
+				nsInv.Source = "filesystem"
+			}
+			dsInv.Namespaces = append(dsInv.Namespaces, nsInv)
+		}
+		inv.Datastores = append(inv.Datastores, dsInv)
+	}
+
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot inventory: %w", err)
+	}
+	if err := c.writeReportFile(jsonPath, data); err != nil {
+		return fmt.Errorf("failed to write snapshot inventory: %w", err)
+	}
+	if err := c.writeReportFile(filepath.Join(state.datastoreDir, pbsSnapshotInventoryText), []byte(formatPBSSnapshotInventory(inv))); err != nil {
+		return fmt.Errorf("failed to write snapshot inventory summary: %w", err)
+	}
+	c.pbsSnapshotInventory = inv
+
+	sum := inv.Summary()
+	c.logger.Info("PBS snapshot inventory: %d datastore(s), %d namespace(s), %d group(s), %d snapshot(s)",
+		sum.Datastores, sum.Namespaces, sum.Groups, sum.Snapshots)
+	if sum.FailedSnapshots > 0 {
+		c.logger.Warning("PBS snapshot inventory: %d snapshot(s) failed their last verification", sum.FailedSnapshots)
+	}
+	if sum.StaleGroups > 0 {
+		c.logger.Warning("PBS snapshot inventory: %d backup group(s) without a snapshot in the last %d day(s)", sum.StaleGroups, sum.StaleAfterDays)
+	}
+	if sum.UnverifiedSnapshots > 0 {
+		c.logger.Info("PBS snapshot inventory: %d snapshot(s) not verified within %d day(s)", sum.UnverifiedSnapshots, sum.VerifyMaxAgeDays)
+	}
+	return nil
+}
+
+func formatPBSSnapshotInventory(inv *PBSSnapshotInventory) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PBS snapshot inventory generated %s\n", inv.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Verify window: %s, stale after: %s\n", formatInventoryDays(inv.VerifyMaxAgeDays), formatInventoryDays(inv.StaleAfterDays))
+	for _, ds := range inv.Datastores {
+		fmt.Fprintf(&sb, "\nDatastore %s", ds.Name)
+		if ds.Path != "" {
+			fmt.Fprintf(&sb, " (%s)", ds.Path)
+		}
+		sb.WriteString("\n")
+		for _, ns := range ds.Namespaces {
+			name := ns.Namespace
+			if name == "" {
+				name = "<root>"
+			}
+			if ns.Error != "" {
+				fmt.Fprintf(&sb, "  Namespace %s: error: %s\n", name, ns.Error)
+				continue
+			}
+			fmt.Fprintf(&sb, "  Namespace %s: %d vm, %d ct, %d host group(s); %d snapshot(s); %d unverified; %d failed; %d stale\n",
+				name, ns.GroupCounts["vm"], ns.GroupCounts["ct"], ns.GroupCounts["host"],
+				ns.Snapshots, ns.UnverifiedSnapshots, ns.FailedSnapshots, ns.StaleGroups)
+			for _, g := range ns.Groups {
+				verified := "never"
+				if g.LastVerified != nil {
+					verified = g.LastVerified.Format("2006-01-02")
+				}
+				flag := ""
+				if g.Stale {
+					flag = " [STALE]"
+				}
+				fmt.Fprintf(&sb, "    %s/%s: %d snapshot(s), oldest %s, newest %s, last verified %s%s\n",
+					g.Type, g.ID, g.Snapshots,
+					g.Oldest.Format("2006-01-02 15:04"), g.Newest.Format("2006-01-02 15:04"), verified, flag)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func formatInventoryDays(days int) string {
+	if days <= 0 {
+		return "disabled"
+	}
+	return fmt.Sprintf("%d day(s)", days)
+}
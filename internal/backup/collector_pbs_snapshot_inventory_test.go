@@ -0,0 +1,172 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/pbs"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+const testVerifyUPIDPrefix = "UPID:pbs:00001234:00005678:00000001:"
+
+func testVerifyUPID(at time.Time) string {
+	return testVerifyUPIDPrefix + strings.ToUpper(strconv.FormatInt(at.Unix(), 16)) + ":verify:store:root@pam:"
+}
+
+func TestBuildPBSNamespaceSnapshotInventory(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	snap := func(kind, id string, age time.Duration, v *pbs.SnapshotVerification) pbs.Snapshot {
+		return pbs.Snapshot{BackupType: kind, BackupID: id, BackupTime: now.Add(-age).Unix(), Verification: v}
+	}
+	okAt := func(age time.Duration) *pbs.SnapshotVerification {
+		return &pbs.SnapshotVerification{State: "ok", UPID: testVerifyUPID(now.Add(-age))}
+	}
+
+	snapshots := []pbs.Snapshot{
+		snap("vm", "100", 40*day, okAt(35*day)), // verified, but outside the window
+		snap("vm", "100", 20*day, okAt(19*day)), // verified recently
+		snap("vm", "100", 12*time.Hour, nil),    // fresh: not expected to be verified yet
+		snap("ct", "200", 60*day, nil),          // never verified, and the group is stale
+		snap("ct", "201", 5*day, &pbs.SnapshotVerification{State: "failed"}),
+	}
+
+	inv := buildPBSNamespaceSnapshotInventory("prod", snapshots, now, 30*day, 2*day)
+
+	if inv.GroupCounts["vm"] != 1 || inv.GroupCounts["ct"] != 2 {
+		t.Fatalf("group counts = %v", inv.GroupCounts)
+	}
+	if inv.Snapshots != 5 {
+		t.Fatalf("snapshots = %d, want 5", inv.Snapshots)
+	}
+	if inv.UnverifiedSnapshots != 2 {
+		t.Fatalf("unverified = %d, want 2", inv.UnverifiedSnapshots)
+	}
+	if inv.FailedSnapshots != 1 {
+		t.Fatalf("failed = %d, want 1", inv.FailedSnapshots)
+	}
+	if inv.StaleGroups != 2 {
+		t.Fatalf("stale groups = %d, want 2", inv.StaleGroups)
+	}
+
+	var vm *PBSSnapshotGroupInventory
+	for i := range inv.Groups {
+		if inv.Groups[i].Type == "vm" {
+			vm = &inv.Groups[i]
+		}
+	}
+	if vm == nil {
+		t.Fatalf("vm group missing: %+v", inv.Groups)
+	}
+	if !vm.Oldest.Equal(now.Add(-40*day)) || !vm.Newest.Equal(now.Add(-12*time.Hour)) {
+		t.Fatalf("vm oldest/newest = %v/%v", vm.Oldest, vm.Newest)
+	}
+	if vm.LastVerified == nil || !vm.LastVerified.Equal(now.Add(-19*day)) {
+		t.Fatalf("vm last verified = %v", vm.LastVerified)
+	}
+	if vm.Stale {
+		t.Fatal("vm group has a fresh snapshot and must not be stale")
+	}
+}
+
+func TestBuildPBSNamespaceSnapshotInventoryChecksDisabled(t *testing.T) {
+	now := time.Now()
+	snapshots := []pbs.Snapshot{{BackupType: "vm", BackupID: "100", BackupTime: now.Add(-400 * 24 * time.Hour).Unix()}}
+	inv := buildPBSNamespaceSnapshotInventory("", snapshots, now, 0, 0)
+	if inv.UnverifiedSnapshots != 0 || inv.StaleGroups != 0 {
+		t.Fatalf("expected disabled checks to report nothing, got %+v", inv)
+	}
+}
+
+func TestCollectPBSSnapshotInventoryWritesReportAndSummary(t *testing.T) {
+	origNamespaces, origSnapshots, origNow := listNamespacesFunc, listSnapshotsFunc, pbsSnapshotInventoryNow
+	t.Cleanup(func() {
+		listNamespacesFunc, listSnapshotsFunc, pbsSnapshotInventoryNow = origNamespaces, origSnapshots, origNow
+	})
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	pbsSnapshotInventoryNow = func() time.Time { return now }
+	listNamespacesFunc = func(context.Context, string, string, time.Duration) ([]pbs.Namespace, bool, error) {
+		return []pbs.Namespace{{Ns: ""}, {Ns: "prod"}, {Ns: "broken"}}, false, nil
+	}
+	listSnapshotsFunc = func(_ context.Context, store, _ string, ns pbs.Namespace, _ time.Duration) ([]pbs.Snapshot, bool, error) {
+		switch ns.Ns {
+		case "":
+			return []pbs.Snapshot{{BackupType: "vm", BackupID: "100", BackupTime: now.Add(-time.Hour).Unix()}}, false, nil
+		case "prod":
+			return []pbs.Snapshot{{BackupType: "ct", BackupID: "200", BackupTime: now.Add(-10 * 24 * time.Hour).Unix()}}, true, nil
+		default:
+			return nil, false, errors.New("namespace unreadable")
+		}
+	}
+
+	cfg := GetDefaultCollectorConfig()
+	deps := CollectorDeps{
+		LookPath:   func(name string) (string, error) { return "/bin/" + name, nil },
+		RunCommand: func(context.Context, string, ...string) ([]byte, error) { return []byte(`{}`), nil },
+	}
+	tmp := t.TempDir()
+	collector := NewCollectorWithDeps(newTestLogger(), cfg, tmp, types.ProxmoxBS, false, deps)
+	runRecipeForTest(t, context.Background(), collector, newPBSDatastoreConfigRecipe(), func(state *collectionState) {
+		state.pbs.datastores = []pbsDatastore{{Name: "store", Path: "/fake/path"}}
+	})
+
+	datastoreDir := filepath.Join(tmp, "var/lib/proxsave-info", "pbs", "datastores")
+	data, err := os.ReadFile(filepath.Join(datastoreDir, pbsSnapshotInventoryJSON))
+	if err != nil {
+		t.Fatalf("expected inventory report: %v", err)
+	}
+	var report PBSSnapshotInventory
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if len(report.Datastores) != 1 || len(report.Datastores[0].Namespaces) != 3 {
+		t.Fatalf("unexpected report layout: %+v", report)
+	}
+	if got := report.Datastores[0].Namespaces[1].Source; got != "filesystem" {
+		t.Fatalf("prod namespace source = %q, want filesystem", got)
+	}
+	if got := report.Datastores[0].Namespaces[2].Error; got == "" {
+		t.Fatal("expected the failing namespace to carry its error")
+	}
+
+	text, err := os.ReadFile(filepath.Join(datastoreDir, pbsSnapshotInventoryText))
+	if err != nil {
+		t.Fatalf("expected text summary: %v", err)
+	}
+	if !strings.Contains(string(text), "ct/200") || !strings.Contains(string(text), "[STALE]") {
+		t.Fatalf("text summary missing stale group:\n%s", text)
+	}
+
+	sum := collector.PBSSnapshotInventory().Summary()
+	if sum.Groups != 2 || sum.Snapshots != 2 || sum.StaleGroups != 1 || sum.Errors != 1 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+	if len(sum.StaleGroupNames) != 1 || sum.StaleGroupNames[0] != "store:prod/ct/200" {
+		t.Fatalf("stale group names = %v", sum.StaleGroupNames)
+	}
+}
+
+func TestCollectPBSSnapshotInventoryDisabled(t *testing.T) {
+	cfg := GetDefaultCollectorConfig()
+	cfg.PBSSnapshotInventory = false
+	cfg.BackupDatastoreConfigs = false
+	tmp := t.TempDir()
+	collector := NewCollectorWithDeps(newTestLogger(), cfg, tmp, types.ProxmoxBS, false, CollectorDeps{})
+	runRecipeForTest(t, context.Background(), collector, newPBSDatastoreConfigRecipe(), func(state *collectionState) {
+		state.pbs.datastores = []pbsDatastore{{Name: "store", Path: "/fake/path"}}
+	})
+	if collector.PBSSnapshotInventory() != nil {
+		t.Fatal("expected no inventory when disabled")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "var/lib/proxsave-info", "pbs", "datastores", pbsSnapshotInventoryJSON)); !os.IsNotExist(err) {
+		t.Fatalf("inventory report should not exist, err=%v", err)
+	}
+}
@@ -369,11 +369,17 @@ func (c *Collector) collectPVEConfigSnapshot(ctx context.Context) error {
 	if len(extraExclude) > 0 {
 		c.logger.Debug("PVE config exclusions enabled (disabled features): %s", strings.Join(extraExclude, ", "))
 	}
+	source, description := pveConfigPath, "PVE configuration"
+	if c.pveNodeScopeOnly() {
+		// The elected node archives the rest of /etc/pve for this slot.
+		source = filepath.Join(pveConfigPath, "nodes", c.clusterCapture.Node)
+		description = "PVE node configuration"
+	}
 	return c.withTemporaryExcludes(extraExclude, func() error {
 		return c.safeCopyDir(ctx,
-			pveConfigPath,
-			c.targetPathFor(pveConfigPath),
-			"PVE configuration")
+			source,
+			c.targetPathFor(source),
+			description)
 	})
 }
 
@@ -385,7 +391,7 @@ func (c *Collector) collectPVEClusterSnapshot(ctx context.Context, clustered boo
 	// excludes the flat priv files. Warn so the operator is not left with a false
 	// sense of exclusion; the only way to drop them entirely is to also disable
 	// cluster backup.
-	if c.config.BackupClusterConfig && !c.config.BackupPVEACL {
+	if c.config.BackupClusterConfig && !c.config.BackupPVEACL && !c.pveNodeScopeOnly() {
 		c.logger.Warning("PVE access control: BACKUP_PVE_ACL=false excludes /etc/pve/priv/{shadow,token,tfa}.cfg but the same secrets remain in the cluster database config.db. To exclude them entirely, set BACKUP_CLUSTER_CONFIG=false.")
 	}
 
@@ -398,7 +404,9 @@ func (c *Collector) collectPVEClusterSnapshot(ctx context.Context, clustered boo
 			c.logger.Warning("Failed to copy corosync.conf: %v", err)
 		}
 
-		if clustered {
+		if clustered && c.pveNodeScopeOnly() {
+			c.logger.Skip("PVE cluster data (captured by %s)", c.clusterCapture.CaptureNode)
+		} else if clustered {
 			if err := c.safeCopyDir(ctx,
 				clusterPath,
 				c.targetPathFor(clusterPath),
@@ -423,7 +431,9 @@ func (c *Collector) collectPVEClusterSnapshot(ctx context.Context, clustered boo
 		}
 
 		configDB := filepath.Join(clusterPath, "config.db")
-		if info, err := os.Stat(configDB); err == nil && !info.IsDir() {
+		if c.pveNodeScopeOnly() {
+			c.logger.Debug("Skipping PVE cluster database capture: captured by %s for slot %s", c.clusterCapture.CaptureNode, c.clusterCapture.Slot)
+		} else if info, err := os.Stat(configDB); err == nil && !info.IsDir() {
 			target := c.targetPathFor(configDB)
 			c.logger.Debug("Copying PVE cluster database %s to %s", configDB, target)
 			if err := c.safeCopyFile(ctx, configDB, target, "PVE cluster database"); err != nil {
@@ -441,7 +451,9 @@ func (c *Collector) collectPVEClusterSnapshot(ctx context.Context, clustered boo
 
 func (c *Collector) collectPVEFirewallSnapshot(ctx context.Context) error {
 	pveConfigPath := c.effectivePVEConfigPath()
-	if c.config.BackupPVEFirewall {
+	if c.config.BackupPVEFirewall && c.pveNodeScopeOnly() {
+		c.logger.Debug("Cluster firewall rules captured by %s; node rules are part of the node configuration", c.clusterCapture.CaptureNode)
+	} else if c.config.BackupPVEFirewall {
 		firewallSrc := filepath.Join(pveConfigPath, "firewall")
 		if info, err := os.Stat(firewallSrc); err == nil {
 			if info.IsDir() {
@@ -541,6 +553,10 @@ func (c *Collector) collectPVEACLRuntime(ctx context.Context, commandsDir string
 	if !c.config.BackupPVEACL {
 		return nil
 	}
+	if c.pveNodeScopeOnly() {
+		c.logger.Debug("Skipping PVE access control runtime: captured by %s", c.clusterCapture.CaptureNode)
+		return nil
+	}
 
 	if err := c.safeCmdOutput(ctx,
 		commandSpec("pveum", "user", "list", "--output-format=json"),
@@ -589,6 +605,10 @@ func (c *Collector) collectPVEClusterRuntime(ctx context.Context, commandsDir st
 			false); err != nil {
 			return err
 		}
+		if c.pveNodeScopeOnly() {
+			c.logger.Debug("Skipping cluster-wide runtime state (HA, resource mappings): captured by %s", c.clusterCapture.CaptureNode)
+			return nil
+		}
 		if err := c.safeCmdOutput(ctx,
 			commandSpec("pvesh", "get", "/cluster/ha/status", "--output-format=json"),
 			filepath.Join(commandsDir, "ha_status.json"),
@@ -683,38 +703,40 @@ func (c *Collector) finalizePVERuntimeInfo(info *pveRuntimeInfo) {
 	}
 }
 
-func parseNodeStorageList(data []byte) ([]pveStorageEntry, error) {
-	parseOptionalBool := func(value any) *bool {
-		if value == nil {
+// parsePVEOptionalBool decodes the loosely typed boolean flags pvesh emits
+// (JSON bools, 0/1 numbers or strings); unknown values yield nil.
+func parsePVEOptionalBool(value any) *bool {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case bool:
+		b := v
+		return &b
+	case float64:
+		b := v != 0
+		return &b
+	case string:
+		s := strings.ToLower(strings.TrimSpace(v))
+		if s == "" {
 			return nil
 		}
-		switch v := value.(type) {
-		case bool:
-			b := v
+		switch s {
+		case "1", "true", "yes", "on":
+			b := true
 			return &b
-		case float64:
-			b := v != 0
+		case "0", "false", "no", "off":
+			b := false
 			return &b
-		case string:
-			s := strings.ToLower(strings.TrimSpace(v))
-			if s == "" {
-				return nil
-			}
-			switch s {
-			case "1", "true", "yes", "on":
-				b := true
-				return &b
-			case "0", "false", "no", "off":
-				b := false
-				return &b
-			default:
-				return nil
-			}
 		default:
 			return nil
 		}
+	default:
+		return nil
 	}
+}
 
+func parseNodeStorageList(data []byte) ([]pveStorageEntry, error) {
 	var raw []struct {
 		Storage string `json:"storage"`
 		Name    string `json:"name"`
@@ -747,8 +769,8 @@ func parseNodeStorageList(data []byte) ([]pveStorageEntry, error) {
 			Path:    strings.TrimSpace(item.Path),
 			Type:    strings.TrimSpace(item.Type),
 			Content: strings.TrimSpace(item.Content),
-			Active:  parseOptionalBool(item.Active),
-			Enabled: parseOptionalBool(item.Enabled),
+			Active:  parsePVEOptionalBool(item.Active),
+			Enabled: parsePVEOptionalBool(item.Enabled),
 			Status:  strings.TrimSpace(item.Status),
 		})
 	}
@@ -0,0 +1,334 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cluster capture scopes recorded in the archive manifest when CLUSTER_BACKUP_MODE=coordinated.
+const (
+	// ClusterScopeFull archives carry the cluster-wide /etc/pve contents, the cluster
+	// database and the cluster runtime state, plus the node-local files.
+	ClusterScopeFull = "full"
+	// ClusterScopeNode archives carry only the node-local files; the cluster-wide
+	// payload of the same slot lives in the archive of ClusterCapture.CaptureNode.
+	ClusterScopeNode = "node"
+)
+
+const (
+	// pveClusterCoordinationDir is the claim directory, relative to the PVE config
+	// path. It lives on pmxcfs, so a claim is visible on every node as soon as it is
+	// written and cannot be written at all by a node without quorum.
+	pveClusterCoordinationDir = "priv/proxsave"
+	pveClusterClaimPrefix     = "cluster-capture-"
+	pveClusterClaimRetention  = 7 * 24 * time.Hour
+	pveClusterClaimReadTries  = 5
+	pveClusterClaimReadDelay  = 200 * time.Millisecond
+)
+
+var (
+	// pveClusterCaptureWait bounds how long a node that lost the election waits for
+	// the elected node's archive before capturing the cluster-wide payload itself.
+	pveClusterCaptureWait = 30 * time.Minute
+	pveClusterCapturePoll = 15 * time.Second
+
+	pveClusterCaptureNow = time.Now
+	pveClusterNodeName   = func() string {
+		hostname, _ := os.Hostname()
+		if short := shortHostname(hostname); short != "" {
+			return short
+		}
+		return hostname
+	}
+)
+
+// pveClusterWideCommandFiles are the PVE command outputs that describe the whole cluster
+// rather than the node; only the elected node captures them in coordinated mode.
+var pveClusterWideCommandFiles = []string{
+	"ha_status.json",
+	"mapping_pci.json",
+	"mapping_usb.json",
+	"mapping_dir.json",
+	"pve_users.json",
+	"pve_groups.json",
+	"pve_roles.json",
+	"pools.json",
+}
+
+// ClusterCapture records how a coordinated cluster backup split the PVE payload.
+type ClusterCapture struct {
+	// Scope is ClusterScopeFull or ClusterScopeNode.
+	Scope string `json:"scope"`
+	// Node is the node that produced this archive.
+	Node string `json:"node"`
+	// CaptureNode is the node that captured the cluster-wide payload for Slot.
+	CaptureNode string `json:"capture_node"`
+	// Slot identifies the schedule window the election was held for.
+	Slot string `json:"slot"`
+	// Reason explains a full capture that was not won through the election (no quorum,
+	// unreadable claim, dry run, elected node did not finish).
+	Reason string `json:"reason,omitempty"`
+
+	// claimPath is the claim this node holds and must settle with FinishClusterCapture.
+	claimPath string
+}
+
+// NodeOnly reports whether the cluster-wide payload was left to another node.
+func (cc *ClusterCapture) NodeOnly() bool {
+	return cc != nil && cc.Scope == ClusterScopeNode
+}
+
+// pveClusterClaim is the content of a claim file. Complete is set once the claiming
+// node has a verified archive with the cluster-wide payload.
+type pveClusterClaim struct {
+	Node        string     `json:"node"`
+	Slot        string     `json:"slot"`
+	ClaimedAt   time.Time  `json:"claimed_at"`
+	Complete    bool       `json:"complete,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ClusterCaptureSlot returns the schedule window t falls in. Windows start at local
+// midnight and last interval (a day when interval is not below 24h), so nodes that run
+// the same schedule agree on the slot even with a few minutes of clock skew.
+func ClusterCaptureSlot(t time.Time, interval time.Duration) string {
+	day := t.Format("20060102")
+	if interval <= 0 || interval >= 24*time.Hour {
+		return day
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return fmt.Sprintf("%s-%02d", day, int(t.Sub(midnight)/interval))
+}
+
+// ClusterWideArchivePaths lists the archive paths (relative, slash-separated; directories
+// end with "/") of the cluster-wide PVE payload that a node-scope archive leaves out.
+func ClusterWideArchivePaths() []string {
+	paths := []string{"etc/pve/", "var/lib/pve-cluster/"}
+	for _, name := range pveClusterWideCommandFiles {
+		paths = append(paths, "var/lib/proxsave-info/commands/pve/"+name)
+	}
+	return paths
+}
+
+// ClusterCapture returns the coordinated capture decision, or nil when the run was not
+// a coordinated cluster backup.
+func (c *Collector) ClusterCapture() *ClusterCapture {
+	if c.clusterCapture == nil {
+		return nil
+	}
+	cc := *c.clusterCapture
+	return &cc
+}
+
+// pveNodeScopeOnly reports whether this run leaves the cluster-wide payload to another node.
+func (c *Collector) pveNodeScopeOnly() bool {
+	return c.clusterCapture.NodeOnly()
+}
+
+// resolvePVEClusterCapture holds the per-slot election when CLUSTER_BACKUP_MODE=coordinated.
+// Every doubt resolves to a full capture: a duplicate copy of /etc/pve is cheap, a slot
+// without any copy is not. A node that lost the election only skips the cluster-wide
+// payload once the elected node has marked its claim complete.
+func (c *Collector) resolvePVEClusterCapture(ctx context.Context, clustered bool) {
+	c.clusterCapture = nil
+	if !c.config.ClusterCoordination || !clustered {
+		return
+	}
+	node := pveClusterNodeName()
+	slot := ClusterCaptureSlot(pveClusterCaptureNow(), c.config.ClusterCaptureInterval)
+	cc := &ClusterCapture{Scope: ClusterScopeFull, Node: node, CaptureNode: node, Slot: slot}
+	c.clusterCapture = cc
+
+	if !c.config.BackupClusterConfig {
+		cc.Reason = "BACKUP_CLUSTER_CONFIG=false"
+		c.logger.Debug("Cluster coordination: cluster config backup disabled; node %s keeps the full scope", node)
+		return
+	}
+	if c.dryRun {
+		cc.Reason = "dry run"
+		c.logger.Info("Cluster coordination: dry run, no claim written for slot %s; capturing cluster-wide config on %s", slot, node)
+		return
+	}
+
+	dir := filepath.Join(c.effectivePVEConfigPath(), pveClusterCoordinationDir)
+	claim, err := claimPVEClusterCapture(dir, slot, node, pveClusterCaptureNow())
+	if err == nil && claim.Node != node && !claim.Complete {
+		claim, err = c.waitPVEClusterCapture(ctx, dir, slot, node, claim)
+	}
+	switch {
+	case err != nil:
+		cc.Reason = err.Error()
+		c.logger.Warning("Cluster coordination: %v; capturing cluster-wide config on %s to be safe", err, node)
+	case claim.Node == node:
+		if !claim.Complete {
+			cc.claimPath = pveClusterClaimPath(dir, slot)
+		}
+		c.logger.Info("Cluster coordination: %s elected to capture the cluster-wide config for slot %s", node, slot)
+		c.prunePVEClusterClaims(dir)
+	case claim.Complete:
+		cc.Scope = ClusterScopeNode
+		cc.CaptureNode = claim.Node
+		c.logger.Info("Cluster coordination: cluster-wide config for slot %s is captured by %s; collecting node-local files only", slot, claim.Node)
+	default:
+		cc.Reason = fmt.Sprintf("%s did not complete the cluster-wide capture within %s", claim.Node, pveClusterCaptureWait)
+		c.logger.Warning("Cluster coordination: %s; capturing cluster-wide config on %s as well", cc.Reason, node)
+	}
+}
+
+// waitPVEClusterCapture polls the claim of another node until it is complete, until
+// it is released (a failed run), in which case this node claims the slot itself, or
+// until pveClusterCaptureWait elapses. It returns the last claim seen.
+func (c *Collector) waitPVEClusterCapture(ctx context.Context, dir, slot, node string, claim pveClusterClaim) (pveClusterClaim, error) {
+	c.logger.Info("Cluster coordination: waiting up to %s for %s to finish the cluster-wide capture of slot %s", pveClusterCaptureWait, claim.Node, slot)
+	deadline := time.NewTimer(pveClusterCaptureWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pveClusterCapturePoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return claim, ctx.Err()
+		case <-deadline.C:
+			return claim, nil
+		case <-ticker.C:
+		}
+		current, err := claimPVEClusterCapture(dir, slot, node, pveClusterCaptureNow())
+		if err != nil {
+			// A claim being rewritten as complete can read as malformed for a moment.
+			c.logger.Debug("Cluster coordination: claim of slot %s not readable yet: %v", slot, err)
+			continue
+		}
+		claim = current
+		if claim.Node == node {
+			c.logger.Info("Cluster coordination: claim of slot %s was released; taking over the cluster-wide capture", slot)
+			return claim, nil
+		}
+		if claim.Complete {
+			return claim, nil
+		}
+	}
+}
+
+// FinishClusterCapture settles the claim this node holds once the archive outcome is
+// known: a verified archive marks it complete, so the other nodes skip the cluster-wide
+// payload; any failure removes it, so a waiting node or the next run captures the slot.
+// It is a no-op for nodes that hold no claim and after the first call.
+func (c *Collector) FinishClusterCapture(archived bool) {
+	cc := c.clusterCapture
+	if cc == nil || cc.claimPath == "" {
+		return
+	}
+	path := cc.claimPath
+	cc.claimPath = ""
+	if archived {
+		if err := completePVEClusterClaim(path, pveClusterCaptureNow()); err != nil {
+			c.logger.Warning("Cluster coordination: cannot mark the capture of slot %s complete: %v; other nodes will capture it themselves", cc.Slot, err)
+			return
+		}
+		c.logger.Debug("Cluster coordination: capture of slot %s marked complete", cc.Slot)
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.logger.Warning("Cluster coordination: cannot release the claim of slot %s: %v", cc.Slot, err)
+		return
+	}
+	c.logger.Info("Cluster coordination: backup failed, released the claim of slot %s for another node", cc.Slot)
+}
+
+func pveClusterClaimPath(dir, slot string) string {
+	return filepath.Join(dir, pveClusterClaimPrefix+slot+".json")
+}
+
+// claimPVEClusterCapture creates the claim of slot for node, or returns the claim of
+// the node that already holds it. pmxcfs applies file creation through its ordered
+// cluster log, so exactly one O_EXCL create per slot succeeds across the cluster.
+func claimPVEClusterCapture(dir, slot, node string, now time.Time) (pveClusterClaim, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return pveClusterClaim{}, fmt.Errorf("cannot prepare claim directory %s (no quorum?): %w", dir, err)
+	}
+	path := pveClusterClaimPath(dir, slot)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err == nil {
+		claim := pveClusterClaim{Node: node, Slot: slot, ClaimedAt: now.UTC()}
+		data, _ := json.Marshal(claim)
+		_, werr := f.Write(data)
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return pveClusterClaim{}, fmt.Errorf("cannot write claim %s: %w", path, werr)
+		}
+		return claim, nil
+	}
+	if !errors.Is(err, fs.ErrExist) {
+		return pveClusterClaim{}, fmt.Errorf("cannot create claim %s (no quorum?): %w", path, err)
+	}
+
+	// The winner creates the file before writing it; give it a moment to land.
+	var lastErr error
+	for try := 0; try < pveClusterClaimReadTries; try++ {
+		if try > 0 {
+			time.Sleep(pveClusterClaimReadDelay)
+		}
+		claim, err := readPVEClusterClaim(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return claim, nil
+	}
+	return pveClusterClaim{}, fmt.Errorf("cannot read claim %s: %w", path, lastErr)
+}
+
+func readPVEClusterClaim(path string) (pveClusterClaim, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pveClusterClaim{}, err
+	}
+	var claim pveClusterClaim
+	if err := json.Unmarshal(data, &claim); err != nil || strings.TrimSpace(claim.Node) == "" {
+		return pveClusterClaim{}, fmt.Errorf("claim is empty or malformed")
+	}
+	return claim, nil
+}
+
+// completePVEClusterClaim rewrites the claim at path as complete.
+func completePVEClusterClaim(path string, now time.Time) error {
+	claim, err := readPVEClusterClaim(path)
+	if err != nil {
+		return err
+	}
+	completed := now.UTC()
+	claim.Complete = true
+	claim.CompletedAt = &completed
+	data, _ := json.Marshal(claim)
+	return os.WriteFile(path, data, 0o600)
+}
+
+// prunePVEClusterClaims removes claims of slots older than pveClusterClaimRetention.
+func (c *Collector) prunePVEClusterClaims(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := pveClusterCaptureNow().Add(-pveClusterClaimRetention)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), pveClusterClaimPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			c.logger.Debug("Cluster coordination: cannot remove old claim %s: %v", entry.Name(), err)
+		}
+	}
+}
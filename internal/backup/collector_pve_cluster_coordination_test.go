@@ -0,0 +1,201 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func TestClusterCaptureSlot(t *testing.T) {
+	at := time.Date(2026, 3, 14, 13, 30, 0, 0, time.UTC)
+	cases := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{24 * time.Hour, "20260314"},
+		{0, "20260314"},
+		{6 * time.Hour, "20260314-02"},
+		{time.Hour, "20260314-13"},
+	}
+	for _, tc := range cases {
+		if got := ClusterCaptureSlot(at, tc.interval); got != tc.want {
+			t.Errorf("ClusterCaptureSlot(%s) = %q, want %q", tc.interval, got, tc.want)
+		}
+	}
+}
+
+func TestClaimPVEClusterCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "priv", "proxsave")
+	now := time.Now()
+
+	claim, err := claimPVEClusterCapture(dir, "20260314", "pve1", now)
+	if err != nil || claim.Node != "pve1" || claim.Complete {
+		t.Fatalf("first claim = %+v, %v; want pve1", claim, err)
+	}
+	claim, err = claimPVEClusterCapture(dir, "20260314", "pve2", now)
+	if err != nil || claim.Node != "pve1" {
+		t.Fatalf("second claim = %+v, %v; want pve1", claim, err)
+	}
+	if err := completePVEClusterClaim(pveClusterClaimPath(dir, "20260314"), now); err != nil {
+		t.Fatalf("completePVEClusterClaim() = %v", err)
+	}
+	claim, err = claimPVEClusterCapture(dir, "20260314", "pve2", now)
+	if err != nil || claim.Node != "pve1" || !claim.Complete || claim.CompletedAt == nil {
+		t.Fatalf("completed claim = %+v, %v", claim, err)
+	}
+	claim, err = claimPVEClusterCapture(dir, "20260315", "pve2", now)
+	if err != nil || claim.Node != "pve2" {
+		t.Fatalf("next slot claim = %+v, %v; want pve2", claim, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, pveClusterClaimPrefix+"broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := claimPVEClusterCapture(dir, "broken", "pve3", now); err == nil {
+		t.Fatalf("malformed claim should fail")
+	}
+}
+
+func setupClusterCaptureTest(t *testing.T, wait time.Duration) *CollectorConfig {
+	t.Helper()
+	origNode, origNow, origWait, origPoll := pveClusterNodeName, pveClusterCaptureNow, pveClusterCaptureWait, pveClusterCapturePoll
+	t.Cleanup(func() {
+		pveClusterNodeName, pveClusterCaptureNow, pveClusterCaptureWait, pveClusterCapturePoll = origNode, origNow, origWait, origPoll
+	})
+	now := time.Date(2026, 3, 14, 2, 0, 0, 0, time.UTC)
+	pveClusterCaptureNow = func() time.Time { return now }
+	pveClusterCaptureWait = wait
+	pveClusterCapturePoll = 10 * time.Millisecond
+
+	cfg := GetDefaultCollectorConfig()
+	cfg.PVEConfigPath = t.TempDir()
+	cfg.ClusterCoordination = true
+	cfg.ClusterCaptureInterval = 24 * time.Hour
+	return cfg
+}
+
+func TestResolvePVEClusterCapture(t *testing.T) {
+	cfg := setupClusterCaptureTest(t, time.Second)
+	logger := logging.New(types.LogLevelError, false)
+
+	pveClusterNodeName = func() string { return "pve1" }
+	first := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	first.resolvePVEClusterCapture(t.Context(), true)
+	if cc := first.ClusterCapture(); cc == nil || cc.Scope != ClusterScopeFull || cc.CaptureNode != "pve1" || cc.Slot != "20260314" {
+		t.Fatalf("winner capture = %+v", cc)
+	}
+	first.FinishClusterCapture(true)
+
+	pveClusterNodeName = func() string { return "pve2" }
+	second := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	second.resolvePVEClusterCapture(t.Context(), true)
+	if cc := second.ClusterCapture(); !cc.NodeOnly() || cc.CaptureNode != "pve1" || cc.Node != "pve2" {
+		t.Fatalf("second node capture = %+v", cc)
+	}
+
+	standalone := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	standalone.resolvePVEClusterCapture(t.Context(), false)
+	if cc := standalone.ClusterCapture(); cc != nil {
+		t.Fatalf("standalone node capture = %+v, want nil", cc)
+	}
+
+	dry := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, true)
+	dry.resolvePVEClusterCapture(t.Context(), true)
+	if cc := dry.ClusterCapture(); cc == nil || cc.Scope != ClusterScopeFull || cc.Reason == "" {
+		t.Fatalf("dry-run capture = %+v", cc)
+	}
+}
+
+func TestResolvePVEClusterCaptureElectedNodeFails(t *testing.T) {
+	cfg := setupClusterCaptureTest(t, 10*time.Second)
+	logger := logging.New(types.LogLevelError, false)
+
+	pveClusterNodeName = func() string { return "pve1" }
+	elected := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	elected.resolvePVEClusterCapture(t.Context(), true)
+
+	pveClusterNodeName = func() string { return "pve2" }
+	waiting := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		waiting.resolvePVEClusterCapture(t.Context(), true)
+	}()
+
+	// The elected node's run fails before a verified archive: its claim is released
+	// and the waiting node must capture the cluster-wide payload itself.
+	time.Sleep(50 * time.Millisecond)
+	elected.FinishClusterCapture(false)
+	<-done
+	cc := waiting.ClusterCapture()
+	if cc == nil || cc.Scope != ClusterScopeFull || cc.CaptureNode != "pve2" {
+		t.Fatalf("capture after the elected node failed = %+v, want a full capture by pve2", cc)
+	}
+	waiting.FinishClusterCapture(true)
+	claim, err := readPVEClusterClaim(pveClusterClaimPath(filepath.Join(cfg.PVEConfigPath, pveClusterCoordinationDir), cc.Slot))
+	if err != nil || claim.Node != "pve2" || !claim.Complete {
+		t.Fatalf("claim after take-over = %+v, %v", claim, err)
+	}
+}
+
+func TestResolvePVEClusterCaptureFallsBackWhenCaptureNeverCompletes(t *testing.T) {
+	cfg := setupClusterCaptureTest(t, 50*time.Millisecond)
+	logger := logging.New(types.LogLevelError, false)
+
+	pveClusterNodeName = func() string { return "pve1" }
+	elected := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	elected.resolvePVEClusterCapture(t.Context(), true)
+
+	// pve1 crashed: its claim stays incomplete and is never released.
+	pveClusterNodeName = func() string { return "pve2" }
+	other := NewCollector(logger, cfg, t.TempDir(), types.ProxmoxVE, false)
+	other.resolvePVEClusterCapture(t.Context(), true)
+	cc := other.ClusterCapture()
+	if cc == nil || cc.Scope != ClusterScopeFull || !strings.Contains(cc.Reason, "pve1 did not complete") {
+		t.Fatalf("capture without completion = %+v, want a full fallback capture", cc)
+	}
+	// A fallback capture holds no claim, so finishing it must leave pve1's claim alone.
+	other.FinishClusterCapture(false)
+	if _, err := readPVEClusterClaim(pveClusterClaimPath(filepath.Join(cfg.PVEConfigPath, pveClusterCoordinationDir), cc.Slot)); err != nil {
+		t.Fatalf("pve1 claim removed by another node: %v", err)
+	}
+}
+
+func TestCollectPVEConfigSnapshotNodeScope(t *testing.T) {
+	origNode := pveClusterNodeName
+	t.Cleanup(func() { pveClusterNodeName = origNode })
+	pveClusterNodeName = func() string { return "pve2" }
+
+	cfg := GetDefaultCollectorConfig()
+	cfg.PVEConfigPath = t.TempDir()
+	for _, rel := range []string{"storage.cfg", "nodes/pve2/lxc/101.conf", "nodes/pve1/qemu-server/100.conf"} {
+		path := filepath.Join(cfg.PVEConfigPath, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tempDir := t.TempDir()
+	collector := NewCollector(logging.New(types.LogLevelError, false), cfg, tempDir, types.ProxmoxVE, false)
+	collector.clusterCapture = &ClusterCapture{Scope: ClusterScopeNode, Node: "pve2", CaptureNode: "pve1", Slot: "20260314"}
+
+	if err := collector.collectPVEConfigSnapshot(t.Context()); err != nil {
+		t.Fatalf("collectPVEConfigSnapshot() = %v", err)
+	}
+	dest := collector.targetPathFor(cfg.PVEConfigPath)
+	if _, err := os.Stat(filepath.Join(dest, "nodes", "pve2", "lxc", "101.conf")); err != nil {
+		t.Fatalf("node-local file missing: %v", err)
+	}
+	for _, rel := range []string{"storage.cfg", "nodes/pve1/qemu-server/100.conf"} {
+		if _, err := os.Stat(filepath.Join(dest, rel)); !os.IsNotExist(err) {
+			t.Fatalf("%s should be left to the capture node (err=%v)", rel, err)
+		}
+	}
+}
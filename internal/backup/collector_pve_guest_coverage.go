@@ -0,0 +1,631 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pveBackupCoverageNow = time.Now
+
+const (
+	pveBackupCoverageJSON = "backup_coverage.json"
+	pveBackupCoverageText = "backup_coverage.txt"
+
+	// pveJobStateDir holds the per-job state files pvescheduler keeps for each
+	// vzdump job (vzdump-<id>.json), including the UPID of the last run.
+	pveJobStateDir = "/var/lib/pve-manager/jobs"
+)
+
+// PVEBackupCoverage cross-references the guests of a PVE node with the vzdump job
+// definitions, the job run history and the backups present on the node storages.
+type PVEBackupCoverage struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Node        string    `json:"node"`
+	MaxAgeDays  int       `json:"max_age_days"`
+	// BackupsScanned is false when no backup storage could be listed; guest
+	// staleness is not evaluated in that case.
+	BackupsScanned bool                   `json:"backups_scanned"`
+	Guests         []PVEGuestBackupStatus `json:"guests"`
+	Jobs           []PVEBackupJobStatus   `json:"jobs"`
+}
+
+// PVEGuestBackupStatus describes the backup coverage of a single VM or container.
+// A guest is Stale when its newest backup is older than MaxAgeDays, or when it is
+// selected by a job but no backup was found at all.
+type PVEGuestBackupStatus struct {
+	VMID              string     `json:"vmid"`
+	Type              string     `json:"type"`
+	Name              string     `json:"name,omitempty"`
+	Jobs              []string   `json:"jobs,omitempty"`
+	LastBackup        *time.Time `json:"last_backup,omitempty"`
+	LastBackupStorage string     `json:"last_backup_storage,omitempty"`
+	Uncovered         bool       `json:"uncovered"`
+	Stale             bool       `json:"stale"`
+}
+
+// PVEBackupJobStatus describes a vzdump job and the outcome of its last run.
+// Selection is "all", "vmid:<list>" or "pool:<name>".
+type PVEBackupJobStatus struct {
+	ID             string     `json:"id"`
+	Enabled        bool       `json:"enabled"`
+	Schedule       string     `json:"schedule,omitempty"`
+	Storage        string     `json:"storage,omitempty"`
+	Node           string     `json:"node,omitempty"`
+	Selection      string     `json:"selection"`
+	Guests         int        `json:"guests"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	Failed         bool       `json:"failed"`
+	StorageOffline bool       `json:"storage_offline"`
+	StorageReason  string     `json:"storage_reason,omitempty"`
+}
+
+// PVEBackupCoverageSummary aggregates a coverage audit into the counters used by
+// notifications and metrics.
+type PVEBackupCoverageSummary struct {
+	Guests                 int
+	UncoveredGuests        int
+	StaleGuests            int
+	Jobs                   int
+	FailedJobs             int
+	OfflineStorageJobs     int
+	MaxAgeDays             int
+	UncoveredGuestNames    []string
+	StaleGuestNames        []string
+	FailedJobNames         []string
+	OfflineStorageJobNames []string
+}
+
+// Summary returns the aggregated counters of the audit.
+func (cov *PVEBackupCoverage) Summary() PVEBackupCoverageSummary {
+	if cov == nil {
+		return PVEBackupCoverageSummary{}
+	}
+	sum := PVEBackupCoverageSummary{
+		Guests:     len(cov.Guests),
+		Jobs:       len(cov.Jobs),
+		MaxAgeDays: cov.MaxAgeDays,
+	}
+	for _, g := range cov.Guests {
+		if g.Uncovered {
+			sum.UncoveredGuests++
+			sum.UncoveredGuestNames = append(sum.UncoveredGuestNames, g.label())
+		}
+		if g.Stale {
+			sum.StaleGuests++
+			sum.StaleGuestNames = append(sum.StaleGuestNames, g.label())
+		}
+	}
+	for _, j := range cov.Jobs {
+		if j.Failed {
+			sum.FailedJobs++
+			sum.FailedJobNames = append(sum.FailedJobNames, j.ID)
+		}
+		if j.StorageOffline {
+			sum.OfflineStorageJobs++
+			sum.OfflineStorageJobNames = append(sum.OfflineStorageJobNames, fmt.Sprintf("%s (%s)", j.ID, j.Storage))
+		}
+	}
+	return sum
+}
+
+func (g PVEGuestBackupStatus) label() string {
+	if g.Name == "" {
+		return g.VMID
+	}
+	return fmt.Sprintf("%s (%s)", g.VMID, g.Name)
+}
+
+type pveGuestListEntry struct {
+	VMID     any    `json:"vmid"`
+	Name     string `json:"name"`
+	Template any    `json:"template"`
+}
+
+type pveBackupJobDefinition struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Enabled  any    `json:"enabled"`
+	Schedule string `json:"schedule"`
+	Storage  string `json:"storage"`
+	Node     string `json:"node"`
+	All      any    `json:"all"`
+	VMID     any    `json:"vmid"`
+	Exclude  any    `json:"exclude"`
+	Pool     string `json:"pool"`
+}
+
+type pveTaskHistoryEntry struct {
+	UPID      string `json:"upid"`
+	StartTime int64  `json:"starttime"`
+	EndTime   int64  `json:"endtime"`
+	Status    string `json:"status"`
+}
+
+type pveJobState struct {
+	State string `json:"state"`
+	UPID  string `json:"upid"`
+	Msg   string `json:"msg"`
+	Time  int64  `json:"time"`
+}
+
+type pveBackupContentEntry struct {
+	VMID  any   `json:"vmid"`
+	CTime int64 `json:"ctime"`
+}
+
+// pveJSONString renders the scalar values pvesh emits (numbers or strings) as text.
+func pveJSONString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// splitPVEIDList splits the comma/space separated VMID lists of vzdump jobs.
+func splitPVEIDList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t'
+	})
+}
+
+func localPVENodeName() string {
+	hostname, _ := os.Hostname()
+	if node := shortHostname(hostname); node != "" {
+		return node
+	}
+	return hostname
+}
+
+// collectPVEBackupCoverage audits which local guests are protected by vzdump jobs,
+// using the guest inventory and job files staged earlier in the PVE recipe, and
+// writes the report under proxsave_info/pve.
+func (c *Collector) collectPVEBackupCoverage(ctx context.Context, storages []pveStorageEntry) error {
+	jsonPath := c.proxsaveInfoDir("pve", pveBackupCoverageJSON)
+	if c.shouldExclude(jsonPath) {
+		c.incFilesSkipped()
+		return nil
+	}
+
+	node := localPVENodeName()
+	commandsDir := c.proxsaveCommandsDir("pve")
+	guests, err := c.readPVEGuestList(filepath.Join(commandsDir, "qemu_vms.json"), "qemu")
+	if err != nil {
+		return err
+	}
+	containers, err := c.readPVEGuestList(filepath.Join(commandsDir, "lxc_containers.json"), "lxc")
+	if err != nil {
+		return err
+	}
+	guests = append(guests, containers...)
+	if len(guests) == 0 {
+		c.logger.Debug("PVE backup coverage audit skipped: no guest inventory available")
+		return nil
+	}
+
+	var jobs []pveBackupJobDefinition
+	if data, err := os.ReadFile(filepath.Join(c.pveJobsDir(), "backup_jobs.json")); err == nil {
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return fmt.Errorf("failed to parse backup job definitions: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read backup job definitions: %w", err)
+	}
+	history := c.readPVETaskHistory(filepath.Join(c.pveJobsDir(), fmt.Sprintf("%s_backup_history.json", node)))
+
+	now := pveBackupCoverageNow()
+	maxAgeDays := max(c.config.PVEGuestBackupMaxAgeDays, 0)
+	cov := &PVEBackupCoverage{
+		GeneratedAt: now,
+		Node:        node,
+		MaxAgeDays:  maxAgeDays,
+		Guests:      guests,
+	}
+
+	guestIndex := make(map[string]int, len(guests))
+	for i, g := range guests {
+		guestIndex[g.VMID] = i
+	}
+	storageByName := make(map[string]pveStorageEntry, len(storages))
+	for _, s := range storages {
+		storageByName[s.Name] = s
+	}
+
+	for _, def := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if def.Type != "" && def.Type != "vzdump" {
+			continue
+		}
+		job := PVEBackupJobStatus{
+			ID:       strings.TrimSpace(def.ID),
+			Enabled:  true,
+			Schedule: strings.TrimSpace(def.Schedule),
+			Storage:  strings.TrimSpace(def.Storage),
+			Node:     strings.TrimSpace(def.Node),
+		}
+		if enabled := parsePVEOptionalBool(def.Enabled); enabled != nil {
+			job.Enabled = *enabled
+		}
+
+		selected, selection := c.pveJobSelection(ctx, def, guests)
+		job.Selection = selection
+		if job.Node != "" && job.Node != node {
+			selected = nil
+		}
+		for _, vmid := range selected {
+			idx, ok := guestIndex[vmid]
+			if !ok {
+				continue
+			}
+			job.Guests++
+			if job.Enabled {
+				cov.Guests[idx].Jobs = append(cov.Guests[idx].Jobs, job.ID)
+			}
+		}
+
+		c.applyPVEJobLastRun(&job, history)
+		if job.Enabled && job.Storage != "" && len(storages) > 0 {
+			if storage, ok := storageByName[job.Storage]; !ok {
+				job.StorageOffline = true
+				job.StorageReason = "not available on node " + node
+			} else if reason := c.pveStorageUnavailableReason(storage); reason != "" {
+				job.StorageOffline = true
+				job.StorageReason = reason
+			}
+		}
+		cov.Jobs = append(cov.Jobs, job)
+	}
+
+	newest, scanned, err := c.scanPVEGuestBackups(ctx, node, storages)
+	if err != nil {
+		return err
+	}
+	cov.BackupsScanned = scanned
+	maxAge := time.Duration(maxAgeDays) * 24 * time.Hour
+	for i := range cov.Guests {
+		g := &cov.Guests[i]
+		g.Uncovered = len(g.Jobs) == 0
+		if found, ok := newest[g.VMID]; ok {
+			taken := found.taken.UTC()
+			g.LastBackup = &taken
+			g.LastBackupStorage = found.storage
+		}
+		if !scanned || maxAge <= 0 {
+			continue
+		}
+		switch {
+		case g.LastBackup != nil:
+			g.Stale = now.Sub(*g.LastBackup) > maxAge
+		case !g.Uncovered:
+			g.Stale = true
+		}
+	}
+
+	data, err := json.MarshalIndent(cov, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup coverage: %w", err)
+	}
+	if err := c.writeReportFile(jsonPath, data); err != nil {
+		return fmt.Errorf("failed to write backup coverage: %w", err)
+	}
+	if err := c.writeReportFile(c.proxsaveInfoDir("pve", pveBackupCoverageText), []byte(formatPVEBackupCoverage(cov))); err != nil {
+		return fmt.Errorf("failed to write backup coverage summary: %w", err)
+	}
+	c.pveBackupCoverage = cov
+
+	sum := cov.Summary()
+	c.logger.Info("PVE backup coverage: %d guest(s), %d vzdump job(s)", sum.Guests, sum.Jobs)
+	if sum.UncoveredGuests > 0 {
+		c.logger.Warning("PVE backup coverage: %d guest(s) not covered by any enabled backup job: %s",
+			sum.UncoveredGuests, strings.Join(sum.UncoveredGuestNames, ", "))
+	}
+	if sum.StaleGuests > 0 {
+		c.logger.Warning("PVE backup coverage: %d guest(s) without a backup in the last %d day(s): %s",
+			sum.StaleGuests, sum.MaxAgeDays, strings.Join(sum.StaleGuestNames, ", "))
+	}
+	if sum.FailedJobs > 0 {
+		c.logger.Warning("PVE backup coverage: last run of %d backup job(s) failed: %s",
+			sum.FailedJobs, strings.Join(sum.FailedJobNames, ", "))
+	}
+	if sum.OfflineStorageJobs > 0 {
+		c.logger.Warning("PVE backup coverage: %d backup job(s) target an offline storage: %s",
+			sum.OfflineStorageJobs, strings.Join(sum.OfflineStorageJobNames, ", "))
+	}
+	return nil
+}
+
+// readPVEGuestList parses a staged qemu/lxc list. Templates are skipped since
+// they are not expected to be part of a backup schedule.
+func (c *Collector) readPVEGuestList(path, guestType string) ([]PVEGuestBackupStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read guest inventory %s: %w", path, err)
+	}
+	var raw []pveGuestListEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse guest inventory %s: %w", path, err)
+	}
+	guests := make([]PVEGuestBackupStatus, 0, len(raw))
+	for _, item := range raw {
+		vmid := pveJSONString(item.VMID)
+		if vmid == "" {
+			continue
+		}
+		if template := parsePVEOptionalBool(item.Template); template != nil && *template {
+			continue
+		}
+		guests = append(guests, PVEGuestBackupStatus{VMID: vmid, Type: guestType, Name: strings.TrimSpace(item.Name)})
+	}
+	sort.SliceStable(guests, func(i, j int) bool {
+		a, errA := strconv.Atoi(guests[i].VMID)
+		b, errB := strconv.Atoi(guests[j].VMID)
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return guests[i].VMID < guests[j].VMID
+	})
+	return guests, nil
+}
+
+func (c *Collector) readPVETaskHistory(path string) map[string]pveTaskHistoryEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []pveTaskHistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		c.logger.Debug("Failed to parse backup history %s: %v", path, err)
+		return nil
+	}
+	byUPID := make(map[string]pveTaskHistoryEntry, len(entries))
+	for _, entry := range entries {
+		if entry.UPID != "" {
+			byUPID[entry.UPID] = entry
+		}
+	}
+	return byUPID
+}
+
+// pveJobSelection resolves the VMIDs a vzdump job selects among the local guests.
+func (c *Collector) pveJobSelection(ctx context.Context, def pveBackupJobDefinition, guests []PVEGuestBackupStatus) ([]string, string) {
+	excluded := make(map[string]struct{})
+	for _, id := range splitPVEIDList(pveJSONString(def.Exclude)) {
+		excluded[id] = struct{}{}
+	}
+
+	if all := parsePVEOptionalBool(def.All); all != nil && *all {
+		var selected []string
+		for _, g := range guests {
+			if _, skip := excluded[g.VMID]; !skip {
+				selected = append(selected, g.VMID)
+			}
+		}
+		return selected, "all"
+	}
+
+	if pool := strings.TrimSpace(def.Pool); pool != "" {
+		members, err := c.pvePoolMembers(ctx, pool)
+		if err != nil {
+			c.logger.Debug("Failed to resolve members of pool %s for job %s: %v", pool, def.ID, err)
+		}
+		return members, "pool:" + pool
+	}
+
+	list := pveJSONString(def.VMID)
+	return splitPVEIDList(list), "vmid:" + list
+}
+
+func (c *Collector) pvePoolMembers(ctx context.Context, pool string) ([]string, error) {
+	output, err := c.runPVEAuditCommand(ctx, "pvesh", "get", "/pools/"+pool, "--output-format=json")
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Members []struct {
+			VMID any `json:"vmid"`
+		} `json:"members"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, err
+	}
+	var members []string
+	for _, m := range parsed.Members {
+		if vmid := pveJSONString(m.VMID); vmid != "" {
+			members = append(members, vmid)
+		}
+	}
+	return members, nil
+}
+
+// applyPVEJobLastRun fills the last run outcome from the pvescheduler state file,
+// preferring the final task status recorded in the node task history.
+func (c *Collector) applyPVEJobLastRun(job *PVEBackupJobStatus, history map[string]pveTaskHistoryEntry) {
+	if job.ID == "" {
+		return
+	}
+	data, err := os.ReadFile(c.systemPath(filepath.Join(pveJobStateDir, "vzdump-"+job.ID+".json")))
+	if err != nil {
+		return
+	}
+	var state pveJobState
+	if err := json.Unmarshal(data, &state); err != nil {
+		c.logger.Debug("Failed to parse state of backup job %s: %v", job.ID, err)
+		return
+	}
+
+	switch state.State {
+	case "created":
+		job.LastStatus = "never run"
+		return
+	case "started":
+		job.LastStatus = "running"
+	}
+
+	status := strings.TrimSpace(state.Msg)
+	runAt := state.Time
+	if task, ok := history[state.UPID]; ok {
+		if task.Status != "" {
+			status = strings.TrimSpace(task.Status)
+		}
+		if task.EndTime > 0 {
+			runAt = task.EndTime
+		} else if task.StartTime > 0 {
+			runAt = task.StartTime
+		}
+	}
+	if runAt > 0 {
+		t := time.Unix(runAt, 0).UTC()
+		job.LastRun = &t
+	}
+	if state.State != "stopped" {
+		return
+	}
+	job.LastStatus = status
+	job.Failed = status != "" && status != "OK" && !strings.HasPrefix(status, "WARNINGS")
+}
+
+type pveGuestBackupRef struct {
+	taken   time.Time
+	storage string
+}
+
+// scanPVEGuestBackups lists the vzdump archives on every available storage that
+// holds backups and returns the newest one per VMID.
+func (c *Collector) scanPVEGuestBackups(ctx context.Context, node string, storages []pveStorageEntry) (map[string]pveGuestBackupRef, bool, error) {
+	newest := make(map[string]pveGuestBackupRef)
+	scanned := false
+	for _, storage := range storages {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		if !pveStorageHoldsBackups(storage) || c.pveStorageUnavailableReason(storage) != "" {
+			continue
+		}
+		output, err := c.runPVEAuditCommand(ctx, "pvesh", "get",
+			fmt.Sprintf("/nodes/%s/storage/%s/content", node, storage.Name),
+			"--content", "backup", "--output-format=json")
+		if err != nil {
+			if isParentContextError(ctx, err) {
+				return nil, false, err
+			}
+			c.logger.Debug("Failed to list backups on storage %s: %v", storage.Name, err)
+			continue
+		}
+		var items []pveBackupContentEntry
+		if err := json.Unmarshal(output, &items); err != nil {
+			c.logger.Debug("Failed to parse backup list of storage %s: %v", storage.Name, err)
+			continue
+		}
+		scanned = true
+		for _, item := range items {
+			vmid := pveJSONString(item.VMID)
+			if vmid == "" || item.CTime <= 0 {
+				continue
+			}
+			taken := time.Unix(item.CTime, 0)
+			if cur, ok := newest[vmid]; !ok || taken.After(cur.taken) {
+				newest[vmid] = pveGuestBackupRef{taken: taken, storage: storage.Name}
+			}
+		}
+	}
+	return newest, scanned, nil
+}
+
+func pveStorageHoldsBackups(storage pveStorageEntry) bool {
+	for _, content := range splitPVEIDList(storage.Content) {
+		if content == "backup" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Collector) runPVEAuditCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if c.config.PveshTimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.config.PveshTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	return c.depRunCommand(ctx, name, args...)
+}
+
+func formatPVEBackupCoverage(cov *PVEBackupCoverage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PVE backup coverage for node %s generated %s\n", cov.Node, cov.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Maximum backup age: %s\n", formatInventoryDays(cov.MaxAgeDays))
+	if !cov.BackupsScanned {
+		sb.WriteString("Backup storages could not be listed: backup age not evaluated\n")
+	}
+
+	sb.WriteString("\nJobs\n")
+	if len(cov.Jobs) == 0 {
+		sb.WriteString("  (none)\n")
+	}
+	for _, j := range cov.Jobs {
+		state := "enabled"
+		if !j.Enabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(&sb, "  %s: %s, %s, storage %s, %d local guest(s)", j.ID, state, j.Selection, valueOrDash(j.Storage), j.Guests)
+		if j.LastRun != nil {
+			fmt.Fprintf(&sb, ", last run %s", j.LastRun.Format("2006-01-02 15:04"))
+		}
+		if j.LastStatus != "" {
+			fmt.Fprintf(&sb, " (%s)", j.LastStatus)
+		}
+		if j.Failed {
+			sb.WriteString(" [FAILED]")
+		}
+		if j.StorageOffline {
+			fmt.Fprintf(&sb, " [STORAGE OFFLINE: %s]", j.StorageReason)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\nGuests\n")
+	for _, g := range cov.Guests {
+		last := "none found"
+		if g.LastBackup != nil {
+			last = fmt.Sprintf("%s on %s", g.LastBackup.Format("2006-01-02 15:04"), g.LastBackupStorage)
+		}
+		jobs := "none"
+		if len(g.Jobs) > 0 {
+			jobs = strings.Join(g.Jobs, ",")
+		}
+		flags := ""
+		if g.Uncovered {
+			flags += " [UNCOVERED]"
+		}
+		if g.Stale {
+			flags += " [STALE]"
+		}
+		fmt.Fprintf(&sb, "  %s %s: jobs %s, newest backup %s%s\n", g.Type, g.label(), jobs, last, flags)
+	}
+	return sb.String()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCoverageFixture(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestCollectPVEBackupCoverage(t *testing.T) {
+	now := time.Date(2024, time.April, 10, 12, 0, 0, 0, time.UTC)
+	origNow := pveBackupCoverageNow
+	pveBackupCoverageNow = func() time.Time { return now }
+	t.Cleanup(func() { pveBackupCoverageNow = origNow })
+
+	node := localPVENodeName()
+	var storageCalls []string
+	collector := newPVECollectorWithDeps(t, CollectorDeps{
+		RunCommand: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			cmd := commandSpec(name, args...).String()
+			switch {
+			case strings.Contains(cmd, "/pools/prod"):
+				return []byte(`{"members":[{"vmid":101,"type":"qemu"}]}`), nil
+			case strings.Contains(cmd, "/storage/"):
+				storageCalls = append(storageCalls, cmd)
+				return []byte(fmt.Sprintf(`[{"volid":"pbs:backup/vm/100","vmid":100,"ctime":%d},{"volid":"pbs:backup/vm/101","vmid":101,"ctime":%d}]`,
+					now.Add(-24*time.Hour).Unix(), now.Add(-5*24*time.Hour).Unix())), nil
+			}
+			return nil, fmt.Errorf("unexpected command %s", cmd)
+		},
+	})
+	collector.config.SystemRootPrefix = t.TempDir()
+
+	commandsDir := collector.proxsaveCommandsDir("pve")
+	writeCoverageFixture(t, filepath.Join(commandsDir, "qemu_vms.json"),
+		`[{"vmid":100,"name":"web"},{"vmid":101,"name":"db"},{"vmid":9000,"name":"tmpl","template":1}]`)
+	writeCoverageFixture(t, filepath.Join(commandsDir, "lxc_containers.json"), `[{"vmid":"200","name":"dns"}]`)
+	writeCoverageFixture(t, filepath.Join(collector.pveJobsDir(), "backup_jobs.json"), `[
+		{"id":"backup-daily","type":"vzdump","all":1,"exclude":"101,200","storage":"pbs","schedule":"21:00"},
+		{"id":"backup-weekly","type":"vzdump","pool":"prod","storage":"nfs","schedule":"sun 02:00"},
+		{"id":"backup-old","type":"vzdump","enabled":0,"vmid":"200","storage":"gone"}
+	]`)
+	upid := "UPID:" + node + ":00001234:00005678:66160000:vzdump::root@pam:"
+	writeCoverageFixture(t, filepath.Join(collector.pveJobsDir(), node+"_backup_history.json"),
+		fmt.Sprintf(`[{"upid":%q,"starttime":%d,"endtime":%d,"status":"job errors"}]`, upid, now.Add(-15*time.Hour).Unix(), now.Add(-14*time.Hour).Unix()))
+	writeCoverageFixture(t, collector.systemPath(filepath.Join(pveJobStateDir, "vzdump-backup-daily.json")),
+		fmt.Sprintf(`{"state":"stopped","upid":%q,"msg":"","time":%d}`, upid, now.Add(-15*time.Hour).Unix()))
+
+	active, inactive := true, false
+	storages := []pveStorageEntry{
+		{Name: "pbs", Type: "pbs", Content: "backup", Active: &active},
+		{Name: "nfs", Type: "nfs", Content: "backup,iso", Active: &inactive},
+		{Name: "local", Type: "dir", Content: "iso,vztmpl", Active: &active},
+	}
+
+	if err := collector.collectPVEBackupCoverage(context.Background(), storages); err != nil {
+		t.Fatalf("collectPVEBackupCoverage: %v", err)
+	}
+
+	if len(storageCalls) != 1 || !strings.Contains(storageCalls[0], "/storage/pbs/content") {
+		t.Fatalf("storage listings = %v, want only pbs", storageCalls)
+	}
+
+	cov := collector.PVEBackupCoverage()
+	if cov == nil {
+		t.Fatalf("expected coverage audit to be recorded")
+	}
+	sum := cov.Summary()
+	if sum.Guests != 3 || sum.Jobs != 3 {
+		t.Fatalf("guests=%d jobs=%d, want 3/3", sum.Guests, sum.Jobs)
+	}
+	if sum.UncoveredGuests != 1 || sum.UncoveredGuestNames[0] != "200 (dns)" {
+		t.Fatalf("uncovered = %d %v", sum.UncoveredGuests, sum.UncoveredGuestNames)
+	}
+	if sum.StaleGuests != 1 || sum.StaleGuestNames[0] != "101 (db)" {
+		t.Fatalf("stale = %d %v", sum.StaleGuests, sum.StaleGuestNames)
+	}
+	if sum.FailedJobs != 1 || sum.FailedJobNames[0] != "backup-daily" {
+		t.Fatalf("failed jobs = %d %v", sum.FailedJobs, sum.FailedJobNames)
+	}
+	if sum.OfflineStorageJobs != 1 || sum.OfflineStorageJobNames[0] != "backup-weekly (nfs)" {
+		t.Fatalf("offline storage jobs = %d %v", sum.OfflineStorageJobs, sum.OfflineStorageJobNames)
+	}
+
+	daily := cov.Jobs[0]
+	if daily.LastRun == nil || !daily.LastRun.Equal(now.Add(-14*time.Hour)) || daily.LastStatus != "job errors" {
+		t.Fatalf("daily job last run = %v %q", daily.LastRun, daily.LastStatus)
+	}
+	if web := cov.Guests[0]; web.VMID != "100" || web.LastBackupStorage != "pbs" || web.Stale || web.Uncovered {
+		t.Fatalf("guest 100 = %+v", web)
+	}
+
+	data, err := os.ReadFile(collector.proxsaveInfoDir("pve", pveBackupCoverageJSON))
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var decoded PVEBackupCoverage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if !decoded.BackupsScanned || len(decoded.Guests) != 3 {
+		t.Fatalf("decoded report = %+v", decoded)
+	}
+	text, err := os.ReadFile(collector.proxsaveInfoDir("pve", pveBackupCoverageText))
+	if err != nil {
+		t.Fatalf("read text report: %v", err)
+	}
+	for _, want := range []string{"[FAILED]", "[STORAGE OFFLINE: active=false]", "lxc 200 (dns): jobs none", "[UNCOVERED]", "[STALE]"} {
+		if !strings.Contains(string(text), want) {
+			t.Fatalf("text report missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCollectPVEBackupCoverageSkipsWithoutInventory(t *testing.T) {
+	collector := newPVECollectorWithDeps(t, CollectorDeps{
+		RunCommand: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatalf("unexpected command %s %v", name, args)
+			return nil, nil
+		},
+	})
+
+	if err := collector.collectPVEBackupCoverage(context.Background(), nil); err != nil {
+		t.Fatalf("collectPVEBackupCoverage: %v", err)
+	}
+	if collector.PVEBackupCoverage() != nil {
+		t.Fatalf("expected no coverage audit without a guest inventory")
+	}
+	if _, err := os.Stat(collector.proxsaveInfoDir("pve", pveBackupCoverageJSON)); !os.IsNotExist(err) {
+		t.Fatalf("expected no report file, stat err = %v", err)
+	}
+}
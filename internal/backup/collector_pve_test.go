@@ -306,6 +306,9 @@ func newPVECollectorWithDeps(t *testing.T, override CollectorDeps) *Collector {
 	if override.RunCommandWithEnv != nil {
 		deps.RunCommandWithEnv = override.RunCommandWithEnv
 	}
+	if override.RunTrustedCommand != nil {
+		deps.RunTrustedCommand = override.RunTrustedCommand
+	}
 	if override.Stat != nil {
 		deps.Stat = override.Stat
 	}
@@ -146,8 +146,12 @@ func (c *Collector) CollectSystemInfo(ctx context.Context) error {
 	c.recordSystemManifest = true
 	defer func() { c.recordSystemManifest = false }()
 
+	// Declarative custom bricks run after the built-in ones.
+	r := newSystemRecipe()
+	r.Bricks = append(r.Bricks, newCustomBricks(c.loadCustomBrickSpecs())...)
+
 	state := newCollectionState(c)
-	if err := runRecipe(ctx, newSystemRecipe(), state); err != nil {
+	if err := runRecipe(ctx, r, state); err != nil {
 		return err
 	}
 
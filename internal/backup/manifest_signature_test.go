@@ -0,0 +1,65 @@
+package backup
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestSignAndVerifyManifestSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	data := []byte(`{"archive_path":"host-backup.tar.zst","sha256":"abc"}`)
+
+	sig, err := SignManifestData(data, key)
+	if err != nil {
+		t.Fatalf("SignManifestData: %v", err)
+	}
+	if err := VerifyManifestSignature(data, sig, []*ecdsa.PublicKey{&other.PublicKey, &key.PublicKey}); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := VerifyManifestSignature(data, sig, []*ecdsa.PublicKey{&other.PublicKey}); !errors.Is(err, ErrManifestSignatureInvalid) {
+		t.Fatalf("untrusted key err = %v", err)
+	}
+	tampered := []byte(`{"archive_path":"host-backup.tar.zst","sha256":"abd"}`)
+	if err := VerifyManifestSignature(tampered, sig, []*ecdsa.PublicKey{&key.PublicKey}); !errors.Is(err, ErrManifestSignatureInvalid) {
+		t.Fatalf("tampered manifest err = %v", err)
+	}
+	if err := VerifyManifestSignature(data, nil, []*ecdsa.PublicKey{&key.PublicKey}); !errors.Is(err, ErrManifestUnsigned) {
+		t.Fatalf("unsigned manifest err = %v", err)
+	}
+	if _, err := SignManifestData(data, nil); err == nil {
+		t.Fatal("signing without a key must fail")
+	}
+}
+
+func TestParseManifestPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	pub, err := ParseManifestPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil || !pub.Equal(&key.PublicKey) {
+		t.Fatalf("ParseManifestPublicKey = %v, %v", pub, err)
+	}
+	if _, err := ParseManifestPublicKey([]byte("not a key")); err == nil {
+		t.Fatal("expected error for non-PEM input")
+	}
+	if got := ManifestSignaturePath("/b/host.tar.zst"); got != "/b/host.tar.zst.manifest.sig" {
+		t.Fatalf("ManifestSignaturePath = %q", got)
+	}
+}
@@ -0,0 +1,331 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/tis24dev/proxsave/internal/types"
+	"github.com/ulikunitz/xz"
+)
+
+// Block sizes for the parallel gzip/xz writers. Each block becomes a complete
+// gzip member / xz stream; concatenated members are valid input for gzip, xz and
+// the Go readers, so the output is readable by every standard tool.
+const (
+	nativeGzipBlockSize  = 4 << 20
+	nativeXZMinBlockSize = 4 << 20
+	nativeXZMaxBlockSize = 48 << 20
+)
+
+// xzDictSizes mirrors the dictionary sizes of the xz presets -0 ... -9.
+var xzDictSizes = [...]int{
+	256 << 10, 1 << 20, 2 << 20, 4 << 20, 4 << 20,
+	8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20,
+}
+
+// SupportsNativeCompression reports whether comp has a built-in Go encoder
+// and decoder.
+func SupportsNativeCompression(comp types.CompressionType) bool {
+	switch comp {
+	case types.CompressionGzip, types.CompressionPigz, types.CompressionXZ, types.CompressionZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+func nativeThreads(threads int) int {
+	if threads > 0 {
+		return threads
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// newNativeCompressor returns a writer compressing into w with the Go codec for
+// comp. Closing it flushes the last block; it does not close w.
+func newNativeCompressor(w io.Writer, comp types.CompressionType, level, threads int, mode string) (io.WriteCloser, error) {
+	threads = nativeThreads(threads)
+	switch comp {
+	case types.CompressionZstd:
+		return zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(threads),
+		)
+	case types.CompressionGzip, types.CompressionPigz:
+		if requiresExtremeMode(mode) {
+			level = gzip.BestCompression
+		}
+		if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+			return nil, err
+		}
+		return newBlockCompressor(w, nativeGzipBlockSize, threads, func(dst io.Writer, block []byte) error {
+			gz, err := gzip.NewWriterLevel(dst, level)
+			if err != nil {
+				return err
+			}
+			if _, err := gz.Write(block); err != nil {
+				return err
+			}
+			return gz.Close()
+		}), nil
+	case types.CompressionXZ:
+		if level < 0 || level >= len(xzDictSizes) {
+			return nil, fmt.Errorf("xz compression level must be 0-9, got %d", level)
+		}
+		dict := xzDictSizes[level]
+		blockSize := min(max(3*dict, nativeXZMinBlockSize), nativeXZMaxBlockSize)
+		cfg := xz.WriterConfig{DictCap: min(dict, blockSize), CheckSum: xz.CRC64}
+		if err := cfg.Verify(); err != nil {
+			return nil, err
+		}
+		return newBlockCompressor(w, blockSize, threads, func(dst io.Writer, block []byte) error {
+			xw, err := cfg.NewWriter(dst)
+			if err != nil {
+				return err
+			}
+			if _, err := xw.Write(block); err != nil {
+				return err
+			}
+			return xw.Close()
+		}), nil
+	default:
+		return nil, fmt.Errorf("no built-in encoder for %s", comp)
+	}
+}
+
+// NewNativeDecompressor returns a reader decompressing r with the Go codec for
+// comp. Concatenated gzip members and xz streams are read back to back.
+func NewNativeDecompressor(r io.Reader, comp types.CompressionType) (io.ReadCloser, error) {
+	switch comp {
+	case types.CompressionZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case types.CompressionGzip, types.CompressionPigz:
+		return gzip.NewReader(r)
+	case types.CompressionXZ:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	default:
+		return nil, fmt.Errorf("no built-in decoder for %s", comp)
+	}
+}
+
+// blockCompressor cuts the stream into fixed-size blocks and compresses up to
+// threads of them concurrently, writing the results to dst in input order.
+type blockCompressor struct {
+	dst       io.Writer
+	compress  func(io.Writer, []byte) error
+	blockSize int
+	buf       []byte
+	blocks    int
+
+	queue chan chan blockResult
+	done  chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+type blockResult struct {
+	data []byte
+	err  error
+}
+
+func newBlockCompressor(dst io.Writer, blockSize, threads int, compress func(io.Writer, []byte) error) *blockCompressor {
+	bc := &blockCompressor{
+		dst:       dst,
+		compress:  compress,
+		blockSize: blockSize,
+		buf:       make([]byte, 0, blockSize),
+		queue:     make(chan chan blockResult, threads),
+		done:      make(chan struct{}),
+	}
+	go bc.drain()
+	return bc
+}
+
+// drain writes the compressed blocks in the order they were queued. After the
+// first error the remaining results are still received, so no worker blocks,
+// but discarded.
+func (bc *blockCompressor) drain() {
+	defer close(bc.done)
+	for result := range bc.queue {
+		res := <-result
+		if bc.failed() != nil {
+			continue
+		}
+		if res.err != nil {
+			bc.setErr(res.err)
+			continue
+		}
+		if _, err := bc.dst.Write(res.data); err != nil {
+			bc.setErr(err)
+		}
+	}
+}
+
+func (bc *blockCompressor) failed() error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.err
+}
+
+func (bc *blockCompressor) setErr(err error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if bc.err == nil {
+		bc.err = err
+	}
+}
+
+func (bc *blockCompressor) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if err := bc.failed(); err != nil {
+			return written, err
+		}
+		n := min(len(p), bc.blockSize-len(bc.buf))
+		bc.buf = append(bc.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(bc.buf) == bc.blockSize {
+			bc.flushBlock()
+		}
+	}
+	return written, nil
+}
+
+// flushBlock hands the buffered block to a worker. The send blocks while the
+// queue is full, which bounds the blocks being compressed or waiting to be
+// written (and so the memory in use) to about threads.
+func (bc *blockCompressor) flushBlock() {
+	block := bc.buf
+	bc.buf = make([]byte, 0, bc.blockSize)
+	bc.blocks++
+
+	result := make(chan blockResult, 1)
+	bc.queue <- result
+	go func() {
+		var out bytes.Buffer
+		err := bc.compress(&out, block)
+		result <- blockResult{data: out.Bytes(), err: err}
+	}()
+}
+
+// Close compresses the last partial block (or an empty one, so empty input
+// still yields a valid stream) and waits for every block to be written.
+func (bc *blockCompressor) Close() error {
+	if len(bc.buf) > 0 || bc.blocks == 0 {
+		bc.flushBlock()
+	}
+	close(bc.queue)
+	<-bc.done
+	return bc.failed()
+}
+
+// createNativeArchive streams the tar through the built-in Go encoder for the
+// resolved compression, with the same output and encryption handling as the
+// external-tool paths.
+func (a *Archiver) createNativeArchive(ctx context.Context, sourceDir, outputPath string) (err error) {
+	a.logger.Debug("Creating %s archive with the built-in encoder (level %d, mode %s, threads %d)",
+		a.compression, a.compressionLevel, a.CompressionMode(), nativeThreads(a.compressionThreads))
+
+	outFile, err := a.openArchiveOutput(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeIntoErr(&err, outFile, "close output archive")
+
+	writer, finalizeEncryption, err := a.wrapEncryptionWriter(outFile)
+	if err != nil {
+		return err
+	}
+	defer finalizeEncryptionInto(&err, finalizeEncryption)
+
+	compressor, err := newNativeCompressor(writer, a.compression, a.compressionLevel, a.compressionThreads, a.CompressionMode())
+	if err != nil {
+		return &CompressionError{Algorithm: string(a.compression), Err: err}
+	}
+	tarErr := a.writeTar(ctx, sourceDir, compressor)
+	if closeErr := compressor.Close(); closeErr != nil && tarErr == nil {
+		return &CompressionError{Algorithm: string(a.compression), Err: closeErr}
+	}
+	if tarErr != nil {
+		return fmt.Errorf("failed to write tar stream: %w", tarErr)
+	}
+
+	a.logger.Debug("%s compression completed successfully (built-in encoder)", a.compression)
+	return nil
+}
+
+// verifyNativeArchive decompresses the archive with the Go decoder and walks
+// every tar entry, the in-process equivalent of `<tool> --test` + `tar -t`.
+func (a *Archiver) verifyNativeArchive(ctx context.Context, archivePath string) (err error) {
+	a.logger.Debug("Testing %s compression and tar integrity with the built-in decoder", a.compression)
+
+	file, err := a.openVerifyInput(archivePath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer closeIntoErr(&err, file, "close archive")
+
+	reader, err := NewNativeDecompressor(file, a.compression)
+	if err != nil {
+		return fmt.Errorf("%s integrity test failed: %w", a.compression, err)
+	}
+	defer closeIntoErr(&err, reader, "close decompressor")
+
+	listed, err := countTarEntries(ctx, reader)
+	if err != nil {
+		return fmt.Errorf("%s/tar verification failed: %w", a.compression, err)
+	}
+	if err := a.reconcileEntryCount(listed); err != nil {
+		return err
+	}
+
+	a.logger.Debug("Archive verification passed: %s compression and tar structure are valid", a.compression)
+	return nil
+}
+
+// countTarEntries reads a tar stream to the end, including every entry body so
+// the decoder checks the whole compressed stream, and returns the entry count.
+func countTarEntries(ctx context.Context, r io.Reader) (int, error) {
+	tr := tar.NewReader(r)
+	entries := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		_, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return entries, err
+		}
+		entries++
+	}
+	// Drain the padding after the end-of-archive marker so trailing corruption
+	// in the compressed stream is still reported.
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return entries, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// Benchmarks of the built-in encoders against the external tools on the same
+// payload and level. Run with:
+//
+//	go test ./internal/backup -run '^$' -bench 'Compression(Native|External)' -benchtime 5x
+//
+// MB/s is input throughput; the "ratio" metric is compressed/original size.
+const benchPayloadSize = 32 << 20
+
+var benchCodecs = []struct {
+	comp  types.CompressionType
+	tool  string
+	level int
+}{
+	{types.CompressionGzip, "pigz", 6},
+	{types.CompressionXZ, "xz", 6},
+	{types.CompressionZstd, "zstd", 3},
+}
+
+func BenchmarkCompressionNative(b *testing.B) {
+	payload := nativeTestPayload(benchPayloadSize)
+	for _, codec := range benchCodecs {
+		b.Run(string(codec.comp), func(b *testing.B) {
+			b.SetBytes(int64(len(payload)))
+			var size int
+			for i := 0; i < b.N; i++ {
+				var out countingWriter
+				w, err := newNativeCompressor(&out, codec.comp, codec.level, 0, "standard")
+				if err != nil {
+					b.Fatalf("newNativeCompressor: %v", err)
+				}
+				if _, err := w.Write(payload); err != nil {
+					b.Fatalf("Write: %v", err)
+				}
+				if err := w.Close(); err != nil {
+					b.Fatalf("Close: %v", err)
+				}
+				size = out.n
+			}
+			b.ReportMetric(float64(size)/float64(len(payload)), "ratio")
+		})
+	}
+}
+
+func BenchmarkCompressionExternal(b *testing.B) {
+	payload := nativeTestPayload(benchPayloadSize)
+	for _, codec := range benchCodecs {
+		b.Run(string(codec.comp), func(b *testing.B) {
+			if _, err := exec.LookPath(codec.tool); err != nil {
+				b.Skipf("%s not available", codec.tool)
+			}
+			var args []string
+			switch codec.comp {
+			case types.CompressionGzip:
+				args = buildPigzArgs(codec.level, 0, "standard")
+			case types.CompressionXZ:
+				args = buildXZArgs(codec.level, 0, "standard")
+			case types.CompressionZstd:
+				args = buildZstdArgs(codec.level, 0)
+			}
+			b.SetBytes(int64(len(payload)))
+			var size int
+			for i := 0; i < b.N; i++ {
+				var out countingWriter
+				cmd := exec.Command(codec.tool, args...)
+				cmd.Stdin = bytes.NewReader(payload)
+				cmd.Stdout = &out
+				if err := cmd.Run(); err != nil {
+					b.Fatalf("%s: %v", codec.tool, err)
+				}
+				size = out.n
+			}
+			b.ReportMetric(float64(size)/float64(len(payload)), "ratio")
+		})
+	}
+}
+
+type countingWriter struct{ n int }
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
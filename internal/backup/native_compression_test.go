@@ -0,0 +1,250 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func nativeTestPayload(size int) []byte {
+	var b bytes.Buffer
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "line %d: proxsave native compression payload\n", i)
+	}
+	return b.Bytes()[:size]
+}
+
+func TestNativeCompressionRoundTrip(t *testing.T) {
+	payload := nativeTestPayload(3 * nativeGzipBlockSize / 2)
+	for _, comp := range []types.CompressionType{types.CompressionGzip, types.CompressionPigz, types.CompressionXZ, types.CompressionZstd} {
+		t.Run(string(comp), func(t *testing.T) {
+			level := normalizeLevelForCompression(comp, 0)
+			var compressed bytes.Buffer
+			w, err := newNativeCompressor(&compressed, comp, level, 4, "standard")
+			if err != nil {
+				t.Fatalf("newNativeCompressor: %v", err)
+			}
+			if _, err := w.Write(payload); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			r, err := NewNativeDecompressor(bytes.NewReader(compressed.Bytes()), comp)
+			if err != nil {
+				t.Fatalf("NewNativeDecompressor: %v", err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("round trip returned %d bytes, want %d", len(got), len(payload))
+			}
+		})
+	}
+}
+
+// Blocks are compressed out of order by the workers but must land in input
+// order, each as a complete stream the standard tools can read back to back.
+func TestBlockCompressorKeepsBlockOrder(t *testing.T) {
+	payload := nativeTestPayload(10_000)
+	var out bytes.Buffer
+	bc := newBlockCompressor(&out, 1000, 3, func(dst io.Writer, block []byte) error {
+		_, err := fmt.Fprintf(dst, "[%s]", block)
+		return err
+	})
+	for chunk := payload; len(chunk) > 0; {
+		n := min(len(chunk), 333)
+		if _, err := bc.Write(chunk[:n]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		chunk = chunk[n:]
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var want bytes.Buffer
+	for i := 0; i < len(payload); i += 1000 {
+		fmt.Fprintf(&want, "[%s]", payload[i:min(i+1000, len(payload))])
+	}
+	if !bytes.Equal(out.Bytes(), want.Bytes()) {
+		t.Fatalf("blocks written out of order")
+	}
+}
+
+func TestBlockCompressorReportsBlockError(t *testing.T) {
+	boom := errors.New("boom")
+	bc := newBlockCompressor(io.Discard, 10, 2, func(io.Writer, []byte) error { return boom })
+	_, _ = bc.Write(make([]byte, 50))
+	if err := bc.Close(); !errors.Is(err, boom) {
+		t.Fatalf("Close() error = %v, want %v", err, boom)
+	}
+}
+
+func TestBlockCompressorEmptyInputWritesOneBlock(t *testing.T) {
+	calls := 0
+	bc := newBlockCompressor(io.Discard, 10, 2, func(io.Writer, []byte) error {
+		calls++
+		return nil
+	})
+	if err := bc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("compressed %d blocks for empty input, want 1", calls)
+	}
+}
+
+func TestResolveCompressionAutoUsesNativeWhenToolMissing(t *testing.T) {
+	archiver := NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+		Compression:       types.CompressionXZ,
+		CompressionLevel:  9,
+		CompressionEngine: types.CompressionEngineAuto,
+	})
+	archiver.deps.LookPath = func(binary string) (string, error) {
+		return "", fmt.Errorf("%s not available", binary)
+	}
+
+	if actual := archiver.ResolveCompression(); actual != types.CompressionXZ {
+		t.Fatalf("expected xz to be kept, got %s", actual)
+	}
+	if !archiver.NativeCompression() || archiver.CompressionLevel() != 9 {
+		t.Fatalf("native=%v level=%d; want built-in encoder at level 9", archiver.NativeCompression(), archiver.CompressionLevel())
+	}
+}
+
+func TestResolveCompressionAutoPrefersInstalledTool(t *testing.T) {
+	archiver := NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+		Compression:       types.CompressionZstd,
+		CompressionLevel:  3,
+		CompressionEngine: types.CompressionEngineAuto,
+	})
+	archiver.deps.LookPath = func(binary string) (string, error) { return "/usr/bin/" + binary, nil }
+
+	archiver.ResolveCompression()
+	if archiver.NativeCompression() {
+		t.Fatalf("expected the installed zstd to be used")
+	}
+}
+
+func TestCreateArchiveNativeEngineRoundTrip(t *testing.T) {
+	source := t.TempDir()
+	for name, content := range map[string]string{
+		"etc/hosts":         "127.0.0.1 localhost\n",
+		"etc/pve/user.cfg":  strings.Repeat("user:root@pam:1:0:::\n", 100),
+		"var/lib/empty.txt": "",
+	} {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	for _, comp := range []types.CompressionType{types.CompressionGzip, types.CompressionXZ, types.CompressionZstd} {
+		t.Run(string(comp), func(t *testing.T) {
+			archiver := NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+				Compression:       comp,
+				CompressionLevel:  normalizeLevelForCompression(comp, 0),
+				CompressionEngine: types.CompressionEngineNative,
+			})
+			archiver.deps.LookPath = func(binary string) (string, error) {
+				t.Fatalf("external tool %s looked up with the native engine", binary)
+				return "", nil
+			}
+			archiver.deps.CommandContext = func(_ context.Context, name string, _ ...string) (*exec.Cmd, error) {
+				t.Fatalf("external command %s run with the native engine", name)
+				return nil, nil
+			}
+
+			archivePath := filepath.Join(t.TempDir(), "backup"+archiver.GetArchiveExtension())
+			if err := archiver.CreateArchive(context.Background(), source, archivePath); err != nil {
+				t.Fatalf("CreateArchive: %v", err)
+			}
+			if err := archiver.VerifyArchive(context.Background(), archivePath); err != nil {
+				t.Fatalf("VerifyArchive: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyNativeArchiveDetectsCorruption(t *testing.T) {
+	archiver := NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+		Compression:       types.CompressionZstd,
+		CompressionLevel:  3,
+		CompressionEngine: types.CompressionEngineNative,
+	})
+	archiver.ResolveCompression()
+
+	var compressed bytes.Buffer
+	w, err := newNativeCompressor(&compressed, types.CompressionZstd, 3, 1, "standard")
+	if err != nil {
+		t.Fatalf("newNativeCompressor: %v", err)
+	}
+	_, _ = w.Write(nativeTestPayload(64 << 10))
+	_ = w.Close()
+	data := compressed.Bytes()
+	data[len(data)/2] ^= 0xff
+
+	path := filepath.Join(t.TempDir(), "corrupt.tar.zst")
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := archiver.VerifyArchive(context.Background(), path); err == nil {
+		t.Fatalf("VerifyArchive accepted a corrupted archive")
+	}
+}
+
+// The multi-block gzip/xz output must stay readable by the standard tools.
+func TestNativeCompressionReadableByExternalTools(t *testing.T) {
+	payload := nativeTestPayload(3 * nativeXZMinBlockSize / 2)
+	for _, tc := range []struct {
+		comp types.CompressionType
+		tool string
+	}{
+		{types.CompressionGzip, "gzip"},
+		{types.CompressionXZ, "xz"},
+		{types.CompressionZstd, "zstd"},
+	} {
+		t.Run(tc.tool, func(t *testing.T) {
+			if _, err := exec.LookPath(tc.tool); err != nil {
+				t.Skipf("%s not available, skipping test", tc.tool)
+			}
+			var compressed bytes.Buffer
+			w, err := newNativeCompressor(&compressed, tc.comp, 1, 4, "standard")
+			if err != nil {
+				t.Fatalf("newNativeCompressor: %v", err)
+			}
+			_, _ = w.Write(payload)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			cmd := exec.Command(tc.tool, "-d", "-c")
+			cmd.Stdin = &compressed
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%s -d: %v", tc.tool, err)
+			}
+			if !bytes.Equal(out, payload) {
+				t.Fatalf("%s decompressed %d bytes, want %d", tc.tool, len(out), len(payload))
+			}
+		})
+	}
+}
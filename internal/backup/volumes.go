@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// volumeSuffix introduces the 1-based volume number of a multi-volume archive:
+// "<archive>.part001", "<archive>.part002", ...
+const volumeSuffix = ".part"
+
+// volumeIndexDigits is the minimum width of the volume number; sets larger than
+// 999 volumes keep sorting correctly because every name grows together.
+const volumeIndexDigits = 3
+
+// ArchiveVolume describes one volume of a multi-volume archive. The volumes,
+// concatenated in order, are byte-identical to the archive they were split from:
+// the manifest SHA256 and ArchiveSize keep describing the whole stream.
+type ArchiveVolume struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// VolumePath returns the path of volume index (1-based) of archivePath.
+func VolumePath(archivePath string, index int) string {
+	return fmt.Sprintf("%s%s%0*d", archivePath, volumeSuffix, volumeIndexDigits, index)
+}
+
+// SplitVolumePath reports whether path names an archive volume and, if so,
+// returns the archive path it belongs to and its 1-based index.
+func SplitVolumePath(path string) (archivePath string, index int, ok bool) {
+	pos := strings.LastIndex(path, volumeSuffix)
+	if pos <= 0 {
+		return "", 0, false
+	}
+	digits := path[pos+len(volumeSuffix):]
+	if len(digits) < volumeIndexDigits {
+		return "", 0, false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", 0, false
+		}
+	}
+	index, err := strconv.Atoi(digits)
+	if err != nil || index <= 0 {
+		return "", 0, false
+	}
+	return path[:pos], index, true
+}
+
+// VolumePaths returns the paths of the volumes listed by a manifest, resolved
+// next to archivePath.
+func VolumePaths(archivePath string, volumes []ArchiveVolume) []string {
+	dir := filepath.Dir(archivePath)
+	paths := make([]string, 0, len(volumes))
+	for _, volume := range volumes {
+		paths = append(paths, filepath.Join(dir, volume.Name))
+	}
+	return paths
+}
+
+// VolumeSize returns the configured volume size in bytes (0 = no splitting).
+func (a *Archiver) VolumeSize() int64 {
+	return a.volumeSize
+}
+
+// ArchiveVolumes returns the volumes written by the last CreateArchive, in
+// order. It is empty when splitting is disabled or the archive fit in one
+// volume, in which case the archive was written to its output path as usual.
+func (a *Archiver) ArchiveVolumes() []ArchiveVolume {
+	return append([]ArchiveVolume(nil), a.volumes...)
+}
+
+// ExistingVolumePaths returns the volumes of archivePath present on disk,
+// "<archive>.part001" onwards up to the first missing index.
+func ExistingVolumePaths(archivePath string) []string {
+	var paths []string
+	for index := 1; ; index++ {
+		path := VolumePath(archivePath, index)
+		if _, err := os.Stat(path); err != nil {
+			return paths
+		}
+		paths = append(paths, path)
+	}
+}
+
+// openArchiveOutput opens the destination of the archive stream: the output
+// file itself, or a volumeWriter when ARCHIVE_VOLUME_SIZE is set so no file on
+// the backup path ever grows beyond one volume.
+func (a *Archiver) openArchiveOutput(outputPath string) (io.WriteCloser, error) {
+	if a.volumeSize <= 0 {
+		return createBackupOutputFile(outputPath)
+	}
+	return &volumeWriter{
+		archivePath: outputPath,
+		volumeSize:  a.volumeSize,
+		done:        func(volumes []ArchiveVolume) { a.volumes = volumes },
+	}, nil
+}
+
+// volumeWriter writes a stream into "<archive>.part001", "<archive>.part002",
+// ... rotating to a new volume every volumeSize bytes. A volume is only opened
+// when data arrives for it, so the set never ends with an empty volume. A
+// stream that fits in a single volume is renamed to the archive path on Close.
+type volumeWriter struct {
+	archivePath string
+	volumeSize  int64
+	done        func([]ArchiveVolume)
+
+	current *os.File
+	hash    hash.Hash
+	written int64
+	volumes []ArchiveVolume
+	err     error
+}
+
+func (w *volumeWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	total := 0
+	for len(p) > 0 {
+		if w.current == nil {
+			if w.err = w.openVolume(); w.err != nil {
+				return total, w.err
+			}
+		}
+		chunk := p[:min(int64(len(p)), w.volumeSize-w.written)]
+		n, err := w.current.Write(chunk)
+		w.hash.Write(chunk[:n])
+		w.written += int64(n)
+		total += n
+		if err != nil {
+			w.err = err
+			return total, err
+		}
+		p = p[n:]
+		if w.written == w.volumeSize {
+			if w.err = w.closeVolume(); w.err != nil {
+				return total, w.err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (w *volumeWriter) openVolume() error {
+	path := VolumePath(w.archivePath, len(w.volumes)+1)
+	file, err := createBackupOutputFile(path)
+	if err != nil {
+		return fmt.Errorf("create volume %s: %w", filepath.Base(path), err)
+	}
+	w.current, w.hash, w.written = file, sha256.New(), 0
+	return nil
+}
+
+func (w *volumeWriter) closeVolume() error {
+	file := w.current
+	w.current = nil
+	name := filepath.Base(file.Name())
+	syncErr := file.Sync()
+	if err := errors.Join(syncErr, file.Close()); err != nil {
+		return fmt.Errorf("close volume %s: %w", name, err)
+	}
+	w.volumes = append(w.volumes, ArchiveVolume{
+		Name:   name,
+		Size:   w.written,
+		SHA256: hex.EncodeToString(w.hash.Sum(nil)),
+	})
+	return nil
+}
+
+// Close finishes the last volume and publishes the volume list. On a failed
+// stream the volumes stay on disk for the caller to discard with the archive.
+func (w *volumeWriter) Close() error {
+	if w.current != nil {
+		err := w.closeVolume()
+		if w.err == nil {
+			w.err = err
+		}
+	}
+	if w.err != nil {
+		return w.err
+	}
+	volumes := w.volumes
+	switch len(volumes) {
+	case 0:
+		// Nothing was written: leave an empty archive for verification to reject.
+		file, err := createBackupOutputFile(w.archivePath)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	case 1:
+		if err := os.Rename(VolumePath(w.archivePath, 1), w.archivePath); err != nil {
+			return fmt.Errorf("rename single volume: %w", err)
+		}
+		volumes = nil
+	}
+	w.done(volumes)
+	return nil
+}
+
+// volumeReader reads a list of volume files back to back, opening each one
+// only when the previous one is exhausted.
+type volumeReader struct {
+	paths   []string
+	current *os.File
+}
+
+// OpenVolumes returns a reader over the concatenation of the given volumes, in
+// order: reading it yields the original archive stream.
+func OpenVolumes(paths []string) (io.ReadCloser, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no volumes to open")
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("volume %s: %w", filepath.Base(path), err)
+		}
+	}
+	return &volumeReader{paths: append([]string(nil), paths...)}, nil
+}
+
+func (r *volumeReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			file, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, fmt.Errorf("volume %s: %w", filepath.Base(r.paths[0]), err)
+			}
+			r.current = file
+			r.paths = r.paths[1:]
+		}
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			closeErr := r.current.Close()
+			r.current = nil
+			if closeErr != nil {
+				return n, closeErr
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *volumeReader) Close() error {
+	r.paths = nil
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
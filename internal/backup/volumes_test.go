@@ -0,0 +1,225 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func newVolumeArchiver(volumeSize int64) *Archiver {
+	return NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+		Compression: types.CompressionNone,
+		VolumeSize:  volumeSize,
+	})
+}
+
+func testVolumeData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestVolumeWriterRotatesVolumes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host-backup-20260101-000000.tar.zst")
+	data := testVolumeData(2500)
+	archiver := newVolumeArchiver(1000)
+
+	out, err := archiver.openArchiveOutput(path)
+	if err != nil {
+		t.Fatalf("openArchiveOutput() error = %v", err)
+	}
+	// Uneven writes straddle the volume boundaries.
+	for rest := data; len(rest) > 0; {
+		n := min(len(rest), 333)
+		if _, err := out.Write(rest[:n]); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		rest = rest[n:]
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	volumes := archiver.ArchiveVolumes()
+	if len(volumes) != 3 {
+		t.Fatalf("got %d volumes, want 3", len(volumes))
+	}
+	wantSizes := []int64{1000, 1000, 500}
+	for i, volume := range volumes {
+		if volume.Name != filepath.Base(VolumePath(path, i+1)) || volume.Size != wantSizes[i] {
+			t.Fatalf("volume %d = %+v, want name %s size %d", i, volume, filepath.Base(VolumePath(path, i+1)), wantSizes[i])
+		}
+		sum := sha256.Sum256(data[int64(i)*1000 : int64(i)*1000+volume.Size])
+		if volume.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Fatalf("volume %d checksum = %s", i, volume.SHA256)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("archive path written next to its volumes: %v", err)
+	}
+	if got := ExistingVolumePaths(path); !reflect.DeepEqual(got, VolumePaths(path, volumes)) {
+		t.Fatalf("ExistingVolumePaths() = %v", got)
+	}
+
+	reader, err := OpenVolumes(VolumePaths(path, volumes))
+	if err != nil {
+		t.Fatalf("OpenVolumes() error = %v", err)
+	}
+	defer reader.Close()
+	joined, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read volumes: %v", err)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatalf("joined volumes differ from the written stream (%d vs %d bytes)", len(joined), len(data))
+	}
+}
+
+func TestVolumeWriterKeepsSingleVolumeAsArchive(t *testing.T) {
+	for _, size := range []int{1000, 10} {
+		path := filepath.Join(t.TempDir(), "host-backup-20260101-000000.tar.zst")
+		data := testVolumeData(size)
+		archiver := newVolumeArchiver(1000)
+
+		out, err := archiver.openArchiveOutput(path)
+		if err != nil {
+			t.Fatalf("openArchiveOutput() error = %v", err)
+		}
+		if _, err := out.Write(data); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if volumes := archiver.ArchiveVolumes(); len(volumes) != 0 {
+			t.Fatalf("size %d: volumes = %+v, want a single-file archive", size, volumes)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("size %d: archive = %d bytes, %v", size, len(got), err)
+		}
+		if matches, _ := filepath.Glob(path + ".part*"); len(matches) != 0 {
+			t.Fatalf("size %d: volumes left next to the archive: %v", size, matches)
+		}
+	}
+}
+
+func TestCreateArchiveNeverWritesFileLargerThanVolume(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar not available")
+	}
+	const volumeSize = 64 * 1024
+	for _, comp := range []types.CompressionType{types.CompressionNone, types.CompressionGzip} {
+		t.Run(string(comp), func(t *testing.T) {
+			source := t.TempDir()
+			// Pseudo-random content does not compress, so even the gzip stream
+			// is several volumes long.
+			rng := rand.New(rand.NewPCG(1, 2))
+			for i := range 4 {
+				data := make([]byte, 100*1024)
+				for j := range data {
+					data[j] = byte(rng.Uint32())
+				}
+				if err := os.WriteFile(filepath.Join(source, fmt.Sprintf("file%d.bin", i)), data, 0o640); err != nil {
+					t.Fatal(err)
+				}
+			}
+			outDir := t.TempDir()
+			path := filepath.Join(outDir, "host-backup-20260101-000000.tar")
+			archiver := NewArchiver(logging.New(types.LogLevelError, false), &ArchiverConfig{
+				Compression:      comp,
+				CompressionLevel: 6,
+				VolumeSize:       volumeSize,
+			})
+
+			if err := archiver.CreateArchive(context.Background(), source, path); err != nil {
+				t.Fatalf("CreateArchive() error = %v", err)
+			}
+			volumes := archiver.ArchiveVolumes()
+			if len(volumes) < 2 {
+				t.Fatalf("got %d volumes, want the archive split", len(volumes))
+			}
+			entries, err := os.ReadDir(outDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var total int64
+			for _, entry := range entries {
+				info, err := entry.Info()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Size() > volumeSize {
+					t.Fatalf("%s is %d bytes, larger than the %d byte volume size", entry.Name(), info.Size(), volumeSize)
+				}
+				total += info.Size()
+			}
+			if len(entries) != len(volumes) {
+				t.Fatalf("output directory holds %d files, want only the %d volumes", len(entries), len(volumes))
+			}
+
+			if err := archiver.VerifyArchive(context.Background(), path); err != nil {
+				t.Fatalf("VerifyArchive() error = %v", err)
+			}
+			if size, err := archiver.GetArchiveSize(path); err != nil || size != total {
+				t.Fatalf("GetArchiveSize() = %d, %v; want %d", size, err, total)
+			}
+			logger := logging.New(types.LogLevelError, false)
+			checksum, err := GenerateVolumesChecksumBounded(context.Background(), logger, VolumePaths(path, volumes), 0)
+			if err != nil {
+				t.Fatalf("GenerateVolumesChecksumBounded() error = %v", err)
+			}
+			reader, err := OpenVolumes(VolumePaths(path, volumes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+			hash := sha256.New()
+			if _, err := io.Copy(hash, reader); err != nil {
+				t.Fatal(err)
+			}
+			if want := hex.EncodeToString(hash.Sum(nil)); checksum != want {
+				t.Fatalf("volume checksum = %s, want %s", checksum, want)
+			}
+		})
+	}
+}
+
+func TestSplitVolumePath(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantArchive string
+		wantIndex   int
+		wantOK      bool
+	}{
+		{path: "/b/x.tar.zst.part001", wantArchive: "/b/x.tar.zst", wantIndex: 1, wantOK: true},
+		{path: "x.tar.zst.part012", wantArchive: "x.tar.zst", wantIndex: 12, wantOK: true},
+		{path: "x.tar.zst.part1000", wantArchive: "x.tar.zst", wantIndex: 1000, wantOK: true},
+		{path: "x.tar.zst.part000"},
+		{path: "x.tar.zst.part01"},
+		{path: "x.tar.zst.partial"},
+		{path: "x.tar.zst.part001.sha256"},
+		{path: "x.tar.zst"},
+	}
+	for _, tt := range tests {
+		archive, index, ok := SplitVolumePath(tt.path)
+		if archive != tt.wantArchive || index != tt.wantIndex || ok != tt.wantOK {
+			t.Errorf("SplitVolumePath(%q) = (%q, %d, %v), want (%q, %d, %v)",
+				tt.path, archive, index, ok, tt.wantArchive, tt.wantIndex, tt.wantOK)
+		}
+	}
+}
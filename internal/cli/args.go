@@ -41,6 +41,9 @@ type Args struct {
 	ForceNewKey       bool
 	Decrypt           bool
 	Restore           bool
+	RestoreRollback   bool
+	RestoreGuests     bool
+	ClusterRebuild    bool
 	Install           bool
 	NewInstall        bool
 	UpgradeConfig     bool
@@ -52,11 +55,29 @@ type Args struct {
 	// notes (the notes registry is compiled into each binary).
 	ShowWhatsnew  bool
 	CleanupGuards bool
-	Backup            bool
-	Daemon            bool
-	DaemonSetup       bool
-	DaemonRemove      bool
-	DaemonStatus      bool
+	Backup        bool
+	Daemon        bool
+	DaemonSetup   bool
+	DaemonRemove  bool
+	DaemonStatus  bool
+	// DaemonCtl is the --daemon-ctl action sent to the running daemon's control socket
+	// (status, run, cancel, pings or logs).
+	DaemonCtl string
+	// FleetCollector runs the fleet-mode collector: it serves the signed report endpoint
+	// and the fleet /metrics over HTTPS and sends the daily fleet digest.
+	FleetCollector bool
+	// RetentionPlan prints what the retention policy keeps and deletes per storage
+	// location without deleting anything. RetentionLocation limits it to one location
+	// (primary, secondary or cloud), RetentionOverrides are KEY=VALUE retention
+	// settings applied on top of backup.env and RetentionSimulateRuns simulates that
+	// many future daily runs first.
+	RetentionPlan         bool
+	RetentionLocation     string
+	RetentionOverrides    []string
+	RetentionSimulateRuns int
+	// ClusterRebuildProfile is the KEY=VALUE answer file used by --cluster-rebuild
+	// instead of interactive prompts.
+	ClusterRebuildProfile string
 }
 
 var osExit = os.Exit
@@ -106,6 +127,14 @@ func Parse() *Args {
 		"Run the decrypt workflow (converts encrypted bundles into plaintext bundles)")
 	flag.BoolVar(&args.Restore, "restore", false,
 		"Run the restore workflow (select bundle, optionally decrypt, apply to system)")
+	flag.BoolVar(&args.RestoreRollback, "restore-rollback", false,
+		"List the safety backups taken before previous restores and roll the system back to one of them")
+	flag.BoolVar(&args.RestoreGuests, "restore-guests", false,
+		"Restore individual VM/CT configs from a backup via pvesh, remapping VMIDs already taken on the cluster")
+	flag.BoolVar(&args.ClusterRebuild, "cluster-rebuild", false,
+		"Rebuild the cluster membership of this node from a backup: rejoin an existing cluster or convert the node to standalone")
+	flag.StringVar(&args.ClusterRebuildProfile, "cluster-rebuild-profile", "",
+		"With --cluster-rebuild: read the answers from this KEY=VALUE profile instead of prompting")
 	flag.BoolVar(&args.Backup, "backup", false,
 		"Run the backup now (skips the interactive dashboard; this is the default behavior when proxsave runs non-interactively, e.g. from cron)")
 	flag.BoolVar(&args.Daemon, "daemon", false,
@@ -116,6 +145,18 @@ func Parse() *Args {
 		"Remove daemon mode: disable the service, restore the cron entry, and prevent future upgrades from reinstalling the daemon")
 	flag.BoolVar(&args.DaemonStatus, "daemon-status", false,
 		"Print the resident daemon's status (scheduler mode, service state, running version, binary alignment) and exit")
+	flag.StringVar(&args.DaemonCtl, "daemon-ctl", "",
+		"Talk to the running daemon over its control socket and exit: status, run, cancel, pings (JSON on stdout) or logs (follow the running backup)")
+	flag.BoolVar(&args.FleetCollector, "fleet-collector", false,
+		"Run as the fleet collector: receive signed run reports from other proxsave hosts, serve fleet-wide /metrics and send a daily fleet digest (FLEET_* keys)")
+	flag.BoolVar(&args.RetentionPlan, "retention-plan", false,
+		"Show, per storage location, which backups the retention policy keeps (and in which GFS category) and which it would delete, without deleting anything")
+	flag.StringVar(&args.RetentionLocation, "location", "",
+		"With --retention-plan: only plan this location (primary|secondary|cloud)")
+	flag.Var((*stringListFlag)(&args.RetentionOverrides), "override",
+		"With --retention-plan: preview a retention setting instead of the one in backup.env, as KEY=VALUE (e.g. RETENTION_WEEKLY=8). Repeatable")
+	flag.IntVar(&args.RetentionSimulateRuns, "simulate-runs", 0,
+		"With --retention-plan: simulate this many future daily runs and show the set after the last one")
 	flag.BoolVar(&args.Install, "install", false,
 		"Run the interactive installer (generate/configure backup.env)")
 	flag.BoolVar(&args.NewInstall, "new-install", false,
@@ -291,3 +332,15 @@ func (s *stringFlag) Set(val string) error {
 	s.set = true
 	return nil
 }
+
+// stringListFlag collects every occurrence of a repeatable flag.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
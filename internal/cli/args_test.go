@@ -190,6 +190,75 @@ func TestParseShowWhatsnew(t *testing.T) {
 	}
 }
 
+func TestParseRestoreRollback(t *testing.T) {
+	if args := parseWithArgs(t, nil); args.RestoreRollback {
+		t.Fatal("RestoreRollback must default to false")
+	}
+	args := parseWithArgs(t, []string{"--restore-rollback", "--dry-run"})
+	if !args.RestoreRollback || !args.DryRun {
+		t.Fatal("--restore-rollback --dry-run should set RestoreRollback and DryRun")
+	}
+	if args.Restore {
+		t.Fatal("--restore-rollback must not enable the restore workflow")
+	}
+}
+
+func TestParseRestoreGuests(t *testing.T) {
+	if args := parseWithArgs(t, nil); args.RestoreGuests {
+		t.Fatal("RestoreGuests must default to false")
+	}
+	args := parseWithArgs(t, []string{"--restore-guests", "--dry-run"})
+	if !args.RestoreGuests || !args.DryRun {
+		t.Fatal("--restore-guests --dry-run should set RestoreGuests and DryRun")
+	}
+	if args.Restore {
+		t.Fatal("--restore-guests must not enable the restore workflow")
+	}
+}
+
+func TestParseClusterRebuild(t *testing.T) {
+	if args := parseWithArgs(t, nil); args.ClusterRebuild || args.ClusterRebuildProfile != "" {
+		t.Fatal("ClusterRebuild must default to false with no profile")
+	}
+	args := parseWithArgs(t, []string{"--cluster-rebuild", "--cluster-rebuild-profile", "/root/rebuild.env"})
+	if !args.ClusterRebuild || args.ClusterRebuildProfile != "/root/rebuild.env" {
+		t.Fatalf("unexpected args: rebuild=%v profile=%q", args.ClusterRebuild, args.ClusterRebuildProfile)
+	}
+}
+
+func TestParseDaemonCtl(t *testing.T) {
+	if args := parseWithArgs(t, nil); args.DaemonCtl != "" {
+		t.Fatalf("DaemonCtl must default to empty, got %q", args.DaemonCtl)
+	}
+	if args := parseWithArgs(t, []string{"--daemon-ctl", "status"}); args.DaemonCtl != "status" {
+		t.Fatalf("--daemon-ctl status parsed as %q", args.DaemonCtl)
+	}
+}
+
+func TestParseFleetCollector(t *testing.T) {
+	if args := parseWithArgs(t, nil); args.FleetCollector {
+		t.Fatal("FleetCollector must default to false")
+	}
+	if args := parseWithArgs(t, []string{"--fleet-collector"}); !args.FleetCollector {
+		t.Fatal("--fleet-collector was not parsed")
+	}
+}
+
+func TestParseRetentionPlan(t *testing.T) {
+	args := parseWithArgs(t, nil)
+	if args.RetentionPlan || args.RetentionLocation != "" || len(args.RetentionOverrides) != 0 || args.RetentionSimulateRuns != 0 {
+		t.Fatalf("retention plan flags must default to off, got %+v", args)
+	}
+	args = parseWithArgs(t, []string{"--retention-plan", "--location", "cloud",
+		"--override", "RETENTION_WEEKLY=8", "--override", "RETENTION_DAILY=3", "--simulate-runs", "30"})
+	if !args.RetentionPlan || args.RetentionLocation != "cloud" || args.RetentionSimulateRuns != 30 {
+		t.Fatalf("retention plan flags parsed as %+v", args)
+	}
+	if len(args.RetentionOverrides) != 2 || args.RetentionOverrides[0] != "RETENTION_WEEKLY=8" || args.RetentionOverrides[1] != "RETENTION_DAILY=3" {
+		t.Fatalf("RetentionOverrides = %v", args.RetentionOverrides)
+	}
+}
+
 func parseWithArgs(t *testing.T, cliArgs []string) *Args {
 	t.Helper()
 	origCommandLine := flag.CommandLine
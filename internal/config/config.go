@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tis24dev/proxsave/internal/cron"
 	"github.com/tis24dev/proxsave/internal/safeexec"
 	"github.com/tis24dev/proxsave/internal/types"
 	"github.com/tis24dev/proxsave/pkg/utils"
@@ -30,6 +31,10 @@ const (
 	webhookEnableLegacyKey    = "WEBHOOK_ENABLE"
 )
 
+// minCloudUploadChunkSize keeps chunked uploads from degenerating into thousands
+// of tiny remote objects (and matches the smallest S3 multipart part size).
+const minCloudUploadChunkSize = 5 * 1024 * 1024
+
 var (
 	multiValueKeys = map[string]bool{
 		"BACKUP_EXCLUDE_PATTERNS": true,
@@ -106,6 +111,7 @@ type Config struct {
 	CompressionLevel   int
 	CompressionThreads int
 	CompressionMode    string
+	CompressionEngine  types.CompressionEngine
 
 	// Safety settings
 	MinDiskPrimaryGB   float64
@@ -113,6 +119,10 @@ type Config struct {
 	MinDiskCloudGB     float64
 	SafetyFactor       float64
 
+	// Capacity forecast: warn when a storage target is projected to fill within
+	// CapacityForecastWarnDays days (0 disables the forecast).
+	CapacityForecastWarnDays int
+
 	// Optimization settings
 	EnableDeduplication    bool
 	EnablePrefilter        bool
@@ -157,6 +167,22 @@ type Config struct {
 	RcloneRetries           int
 	RcloneVerifyMethod      string // "primary" or "alternative"
 	RcloneFlags             []string
+	// RcloneBandwidthSchedule is an rclone --bwlimit timetable ("08:00,512k 23:00,off");
+	// when set it replaces RcloneBandwidthLimit.
+	RcloneBandwidthSchedule string
+
+	// Upload scheduling: outside CloudUploadWindow (cron.ParseWindow syntax, empty =
+	// always) the cloud upload is queued in CloudUploadQueueFile and drained by the
+	// daemon once the window opens. Entries older than CloudUploadQueueMaxAge are dropped.
+	CloudUploadWindow      string
+	CloudUploadQueueFile   string
+	CloudUploadQueueMaxAge time.Duration
+
+	// Chunked uploads: archives larger than CloudUploadChunkSize (0 = whole-file
+	// uploads) are uploaded in parts tracked in CloudUploadJournalDir, so an
+	// interrupted upload resumes instead of restarting.
+	CloudUploadChunkSize  int64
+	CloudUploadJournalDir string
 
 	// Retention settings (applied to both backups and logs)
 	LocalRetentionDays     int
@@ -166,7 +192,7 @@ type Config struct {
 	MaxSecondaryBackups    int
 	MaxCloudBackups        int
 
-	// Retention policy selector ("simple" or "gfs")
+	// Retention policy selector ("simple", "gfs" or "thinning")
 	RetentionPolicy string
 
 	// GFS (Grandfather-Father-Son) retention settings
@@ -175,6 +201,14 @@ type Config struct {
 	RetentionWeekly  int // Keep N weekly backups, one per week (0 = disabled)
 	RetentionMonthly int // Keep N monthly backups, one per month (0 = disabled)
 	RetentionYearly  int // Keep N yearly backups, one per year (0 = keep all yearly)
+	RetentionHourly  int // Keep N hourly backups, one per clock hour, after the daily tier (0 = disabled)
+
+	// Age-based thinning rules (RETENTION_POLICY=thinning), youngest first
+	RetentionRules []ThinningRule
+
+	// Restore safety backups (pre-restore snapshots under /tmp/proxsave)
+	SafetyBackupKeep       int // Keep the newest N safety backups per kind (0 = no count limit)
+	SafetyBackupMaxAgeDays int // Remove safety backups older than N days (0 = no age limit)
 
 	// Batch deletion settings (cloud storage)
 	CloudBatchSize  int // Number of files to delete per batch (default: 20)
@@ -186,6 +220,21 @@ type Config struct {
 	AgeRecipients         []string
 	AgeRecipientFile      string
 
+	// Signed manifests: SignManifests writes <archive>.manifest.sig with the
+	// per-host key under <BASE_DIR>/identity; ManifestSignaturePolicy decides
+	// whether decrypt/restore ignore, warn on or refuse unsigned or mis-signed
+	// backups; ManifestTrustedKeys lists extra PEM public keys to accept.
+	SignManifests           bool
+	ManifestSignaturePolicy string
+	ManifestTrustedKeys     []string
+
+	// Multi-volume archives (ARCHIVE_VOLUME_SIZE): split archives larger than
+	// ArchiveVolumeSize bytes into .part001, .part002, ... volumes (0 = off).
+	// ArchiveVolumeAuto defers the size to the storage init, which enables
+	// FATArchiveVolumeSize when the primary or secondary path is on FAT.
+	ArchiveVolumeSize int64
+	ArchiveVolumeAuto bool
+
 	// Telegram Notifications
 	TelegramEnabled      bool
 	TelegramBotType      string // "personal" or "centralized"
@@ -236,6 +285,22 @@ type Config struct {
 	MetricsEnabled bool
 	MetricsPath    string
 
+	// Fleet mode: agents push HMAC-signed run reports to one collector (internal/fleet).
+	FleetCollectorURL     string        // agent: collector report endpoint (empty = no push)
+	FleetSecret           string        // agent: HMAC-SHA256 secret of this host
+	FleetAgentSecretsFile string        // collector: "<host> <secret>" file, one secret per agent
+	FleetReadToken        string        // collector: bearer token for the digest and metrics reads
+	FleetCAFile           string        // agent: PEM CA that signs the collector certificate (empty = system roots)
+	FleetPushTimeout      time.Duration // agent: bound on one report push
+	FleetListen           string        // collector: HTTPS listen address
+	FleetTLSCert          string        // collector: PEM certificate
+	FleetTLSKey           string        // collector: PEM private key
+	FleetDataDir          string        // collector: report store
+	FleetStaleAfter       time.Duration // collector: a host without a report for this long is stale
+	FleetSpaceWarnPercent float64       // collector: storage usage at/above this is "short on space"
+	FleetDigestTime       string        // collector: daily HH:MM of the fleet digest
+	FleetHistoryDays      int           // collector: days of report history kept
+
 	// Scheduler engine (cron vs resident daemon). Defaults keep existing installs
 	// on cron; the install wizard and the --upgrade auto-migration are what set daemon.
 	SchedulerMode  string        // "cron" | "daemon"
@@ -275,6 +340,9 @@ type Config struct {
 	CheckOpenPorts       bool
 	SuspiciousPorts      []int
 	PortWhitelist        []string
+	// HardeningAudit runs the CIS-style hardening audit during backups and stores
+	// the scored report in the archive.
+	HardeningAudit bool
 
 	// Collector options
 	ExcludePatterns []string
@@ -294,8 +362,17 @@ type Config struct {
 	PVEBackupIncludePattern string
 	BackupCephConfig        bool
 	CephConfigPath          string
-	PveshTimeoutSeconds     int
-	FsIoTimeoutSeconds      int
+
+	// Cluster coordination: "independent" (every node captures /etc/pve) or
+	// "coordinated" (one elected node per capture interval).
+	ClusterBackupMode      string
+	ClusterCaptureInterval time.Duration
+
+	// PVE guest backup coverage audit
+	PVEBackupCoverageAudit   bool
+	PVEGuestBackupMaxAgeDays int
+	PveshTimeoutSeconds      int
+	FsIoTimeoutSeconds       int
 
 	// PBS-specific collection options
 	BackupDatastoreConfigs     bool
@@ -319,12 +396,18 @@ type Config struct {
 	PxarFileIncludePatterns    []string
 	PxarFileExcludePatterns    []string
 
+	// PBS snapshot inventory report
+	PBSSnapshotInventory        bool
+	PBSSnapshotVerifyMaxAgeDays int
+	PBSSnapshotStaleDays        int
+
 	// System collection options
 	BackupNetworkConfigs    bool
 	BackupAptSources        bool
 	BackupCronJobs          bool
 	BackupSystemdServices   bool
 	BackupSSLCerts          bool
+	CertExpiryWarnDays      int // 0 disables the certificate expiry audit
 	BackupSysctlConfig      bool
 	BackupKernelModules     bool
 	BackupFirewallRules     bool
@@ -347,6 +430,8 @@ type Config struct {
 
 	CustomBackupPaths []string
 	BackupBlacklist   []string
+	CustomBricksFile  string
+	CollectorWorkers  int
 
 	// PBS Authentication (auto-detected, no manual input required)
 	PBSRepository  string // Auto-detected from environment or generated
@@ -422,11 +507,15 @@ func (c *Config) loadEnvOverrides() {
 		"CLOUD_WRITE_HEALTHCHECK",
 		"RCLONE_TIMEOUT_CONNECTION", "RCLONE_TIMEOUT_OPERATION",
 		"RCLONE_BANDWIDTH_LIMIT", "RCLONE_TRANSFERS", "RCLONE_RETRIES", "RCLONE_VERIFY_METHOD",
-		"RCLONE_FLAGS",
+		"RCLONE_FLAGS", "RCLONE_BANDWIDTH_SCHEDULE",
+		"CLOUD_UPLOAD_WINDOW", "CLOUD_UPLOAD_QUEUE_FILE", "CLOUD_UPLOAD_QUEUE_MAX_AGE",
+		"CLOUD_UPLOAD_CHUNK_SIZE", "CLOUD_UPLOAD_JOURNAL_DIR",
 		"CLOUD_BATCH_SIZE", "CLOUD_BATCH_PAUSE",
 		"MAX_LOCAL_BACKUPS", "MAX_SECONDARY_BACKUPS", "MAX_CLOUD_BACKUPS",
-		"RETENTION_DAILY", "RETENTION_WEEKLY", "RETENTION_MONTHLY", "RETENTION_YEARLY",
-		"BUNDLE_ASSOCIATED_FILES", "ENCRYPT_ARCHIVE", "AGE_RECIPIENT", "AGE_RECIPIENT_FILE",
+		"RETENTION_HOURLY", "RETENTION_DAILY", "RETENTION_WEEKLY", "RETENTION_MONTHLY", "RETENTION_YEARLY",
+		"RETENTION_RULES",
+		"SAFETY_BACKUP_KEEP", "SAFETY_BACKUP_MAX_AGE_DAYS",
+		"BUNDLE_ASSOCIATED_FILES", "ARCHIVE_VOLUME_SIZE", "ENCRYPT_ARCHIVE", "AGE_RECIPIENT", "AGE_RECIPIENT_FILE",
 		"TELEGRAM_ENABLE", "TELEGRAM_ENABLED", "BOT_TELEGRAM_TYPE", "TELEGRAM_BOT_TOKEN", "TELEGRAM_CHAT_ID",
 		"EMAIL_ENABLE", "EMAIL_ENABLED", "EMAIL_DELIVERY_METHOD", "EMAIL_FALLBACK_PMF", "EMAIL_FALLBACK_SENDMAIL",
 		"EMAIL_RECIPIENT", "EMAIL_FROM",
@@ -440,6 +529,7 @@ func (c *Config) loadEnvOverrides() {
 		"CHECK_OPEN_PORTS", "SUSPICIOUS_PORTS", "PORT_WHITELIST",
 		"SUSPICIOUS_PROCESSES", "SAFE_BRACKET_PROCESSES", "SAFE_KERNEL_PROCESSES", "SAFE_PROCESSES",
 		"MIN_DISK_SPACE_PRIMARY_GB", "MIN_DISK_SPACE_SECONDARY_GB", "MIN_DISK_SPACE_CLOUD_GB",
+		"CAPACITY_FORECAST_WARN_DAYS",
 		"DISABLE_NETWORK_PREFLIGHT", "BACKUP_EXCLUDE_PATTERNS",
 		"SKIP_PERMISSION_CHECK", "BACKUP_CONFIG_FILE",
 		"BACKUP_USER", "BACKUP_GROUP", "SET_BACKUP_PERMISSIONS",
@@ -465,11 +555,22 @@ func (c *Config) parse() error {
 	c.parseOptimizationSettings()
 	c.parseSecuritySettings()
 	c.parsePathSettings()
-	c.parseStorageSettings()
-	c.parseRetentionSettings()
+	if err := c.parseStorageSettings(); err != nil {
+		return err
+	}
+	if err := c.parseRetentionSettings(); err != nil {
+		return err
+	}
+	if err := c.parseArchiveVolumeSettings(); err != nil {
+		return err
+	}
+	if err := c.parseManifestSignatureSettings(); err != nil {
+		return err
+	}
 	c.parseNotificationSettings()
 	c.parseSchedulerSettings()
 	c.parseHealthcheckSettings()
+	c.parseFleetSettings()
 	if err := c.parseCollectionSettings(); err != nil {
 		return err
 	}
@@ -512,9 +613,58 @@ func (c *Config) validateCloudSettings() error {
 	if err := safeexec.ValidateRemoteRelativePath(strings.Trim(strings.TrimSpace(c.CloudRemotePath), "/"), "CLOUD_REMOTE_PATH"); err != nil {
 		return err
 	}
+	if _, err := cron.ParseWindow(c.CloudUploadWindow); err != nil {
+		return fmt.Errorf("CLOUD_UPLOAD_WINDOW invalid: %w", err)
+	}
+	if err := validateBandwidthSchedule(c.RcloneBandwidthSchedule); err != nil {
+		return fmt.Errorf("RCLONE_BANDWIDTH_SCHEDULE invalid: %w", err)
+	}
 	return nil
 }
 
+// validateBandwidthSchedule checks an rclone --bwlimit timetable: space-separated
+// "[Ddd-]HH:MM,RATE" slots where RATE is "off" or a size ("512k", "10M", "1M:256k"
+// for separate upload:download limits).
+func validateBandwidthSchedule(schedule string) error {
+	for _, slot := range strings.Fields(schedule) {
+		at, rate, ok := strings.Cut(slot, ",")
+		if !ok {
+			return fmt.Errorf("slot %q must be HH:MM,RATE", slot)
+		}
+		if day, hhmm, hasDay := strings.Cut(at, "-"); hasDay {
+			switch strings.ToLower(day) {
+			case "mon", "tue", "wed", "thu", "fri", "sat", "sun":
+			default:
+				return fmt.Errorf("slot %q: unknown day %q", slot, day)
+			}
+			at = hhmm
+		}
+		if _, err := cron.NormalizeTime(at, ""); err != nil {
+			return fmt.Errorf("slot %q: %w", slot, err)
+		}
+		if !isBandwidthRate(rate) {
+			return fmt.Errorf("slot %q: invalid rate %q", slot, rate)
+		}
+	}
+	return nil
+}
+
+func isBandwidthRate(rate string) bool {
+	if strings.EqualFold(rate, "off") {
+		return true
+	}
+	for _, part := range strings.SplitN(rate, ":", 2) {
+		number := strings.TrimRight(part, "BbKkMmGgTtPp")
+		if len(part)-len(number) > 1 || number == "" {
+			return false
+		}
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 func isAbsoluteCloudRemoteRef(remoteName, basePath string) bool {
 	remoteName = strings.TrimSpace(remoteName)
 	basePath = strings.TrimSpace(basePath)
@@ -557,13 +707,32 @@ func (c *Config) parseCompressionSettings() {
 	c.CompressionType = normalizeCompressionType(c.getCompressionType("COMPRESSION_TYPE", types.CompressionXZ))
 	c.CompressionLevel = c.getInt("COMPRESSION_LEVEL", 6)
 	c.CompressionThreads = c.getInt("COMPRESSION_THREADS", 0) // 0 = auto
-	c.CompressionMode = strings.ToLower(c.getString("COMPRESSION_MODE", "standard"))
-	if c.CompressionMode == "" {
-		c.CompressionMode = "standard"
-	}
+	c.CompressionMode, c.CompressionEngine = parseCompressionMode(c.getString("COMPRESSION_MODE", "standard"))
 	c.CompressionLevel = adjustLevelForMode(c.CompressionType, c.CompressionMode, c.CompressionLevel)
 }
 
+// parseCompressionMode splits COMPRESSION_MODE into the level mode
+// (fast/standard/maximum/ultra) and the optional engine token that may follow
+// it, e.g. "ultra,native". Without an engine token the external tool is used
+// when installed and the built-in Go codec otherwise.
+func parseCompressionMode(value string) (string, types.CompressionEngine) {
+	mode, engine := "", types.CompressionEngineAuto
+	for _, token := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == ',' || r == '+' || r == ' ' || r == '\t'
+	}) {
+		switch types.CompressionEngine(token) {
+		case types.CompressionEngineAuto, types.CompressionEngineNative, types.CompressionEngineExternal:
+			engine = types.CompressionEngine(token)
+		default:
+			mode = token
+		}
+	}
+	if mode == "" {
+		mode = "standard"
+	}
+	return mode, engine
+}
+
 func normalizeCompressionType(ct types.CompressionType) types.CompressionType {
 	v := strings.ToLower(strings.TrimSpace(string(ct)))
 	switch v {
@@ -602,6 +771,10 @@ func (c *Config) parseOptimizationSettings() {
 	c.MinDiskPrimaryGB = sanitizeMinDisk(c.getFloat("MIN_DISK_SPACE_PRIMARY_GB", 10.0))
 	c.MinDiskSecondaryGB = sanitizeMinDisk(c.getFloat("MIN_DISK_SPACE_SECONDARY_GB", c.MinDiskPrimaryGB))
 	c.MinDiskCloudGB = sanitizeMinDisk(c.getFloat("MIN_DISK_SPACE_CLOUD_GB", c.MinDiskPrimaryGB))
+	c.CapacityForecastWarnDays = c.getInt("CAPACITY_FORECAST_WARN_DAYS", 14)
+	if c.CapacityForecastWarnDays < 0 {
+		c.CapacityForecastWarnDays = 0
+	}
 }
 
 func (c *Config) parseSecuritySettings() {
@@ -636,6 +809,7 @@ func (c *Config) parseSecuritySettings() {
 	c.CheckOpenPorts = c.getBool("CHECK_OPEN_PORTS", false)
 	c.SuspiciousPorts = c.getIntList("SUSPICIOUS_PORTS", []int{6666, 6665, 1337, 31337, 4444, 5555, 4242, 6324, 8888, 2222, 3389, 5900})
 	c.PortWhitelist = c.getStringSlice("PORT_WHITELIST", nil)
+	c.HardeningAudit = c.getBool("SECURITY_HARDENING_AUDIT", true)
 	defaultSuspicious := []string{
 		"ncat", "cryptominer", "xmrig", "kdevtmpfsi", "kinsing", "minerd", "mr.sh",
 	}
@@ -690,7 +864,7 @@ func (c *Config) parsePathSettings() {
 	c.SecureAccount = c.getString("SECURE_ACCOUNT", filepath.Join(c.BaseDir, "secure_account"))
 }
 
-func (c *Config) parseStorageSettings() {
+func (c *Config) parseStorageSettings() error {
 	c.SecondaryEnabled = c.getBoolWithFallback([]string{"ENABLE_SECONDARY_BACKUP", "SECONDARY_ENABLED"}, false)
 	c.SecondaryPath = c.getStringWithFallback([]string{"SECONDARY_BACKUP_PATH", "SECONDARY_PATH"}, "")
 
@@ -730,9 +904,25 @@ func (c *Config) parseStorageSettings() {
 	if rawFlags := strings.TrimSpace(c.getString("RCLONE_FLAGS", "")); rawFlags != "" {
 		c.RcloneFlags = strings.Fields(rawFlags)
 	}
+	c.RcloneBandwidthSchedule = strings.Join(strings.Fields(c.getString("RCLONE_BANDWIDTH_SCHEDULE", "")), " ")
+
+	c.CloudUploadWindow = strings.TrimSpace(c.getString("CLOUD_UPLOAD_WINDOW", ""))
+	c.CloudUploadQueueFile = strings.TrimSpace(c.getString("CLOUD_UPLOAD_QUEUE_FILE", filepath.Join(c.BaseDir, "upload-queue.json")))
+	c.CloudUploadQueueMaxAge = c.getDuration("CLOUD_UPLOAD_QUEUE_MAX_AGE", 7*24*time.Hour)
+
+	chunkSize, err := parseSizeToBytes(c.getString("CLOUD_UPLOAD_CHUNK_SIZE", ""))
+	if err != nil {
+		return fmt.Errorf("invalid CLOUD_UPLOAD_CHUNK_SIZE: %w", err)
+	}
+	if chunkSize > 0 && chunkSize < minCloudUploadChunkSize {
+		return fmt.Errorf("invalid CLOUD_UPLOAD_CHUNK_SIZE: must be at least 5M")
+	}
+	c.CloudUploadChunkSize = chunkSize
+	c.CloudUploadJournalDir = strings.TrimSpace(c.getString("CLOUD_UPLOAD_JOURNAL_DIR", filepath.Join(c.BaseDir, "upload-journal")))
+	return nil
 }
 
-func (c *Config) parseRetentionSettings() {
+func (c *Config) parseRetentionSettings() error {
 	c.LocalRetentionDays = c.getIntWithFallback([]string{"MAX_LOCAL_BACKUPS", "LOCAL_RETENTION_DAYS"}, 7)
 	c.SecondaryRetentionDays = c.getIntWithFallback([]string{"MAX_SECONDARY_BACKUPS", "SECONDARY_RETENTION_DAYS"}, 14)
 	c.CloudRetentionDays = c.getIntWithFallback([]string{"MAX_CLOUD_BACKUPS", "CLOUD_RETENTION_DAYS"}, 30)
@@ -744,15 +934,42 @@ func (c *Config) parseRetentionSettings() {
 	c.RetentionWeekly = c.getInt("RETENTION_WEEKLY", 0)
 	c.RetentionMonthly = c.getInt("RETENTION_MONTHLY", 0)
 	c.RetentionYearly = c.getInt("RETENTION_YEARLY", 0)
+	c.RetentionHourly = c.getInt("RETENTION_HOURLY", 0)
+	if c.RetentionHourly < 0 {
+		c.RetentionHourly = 0
+	}
+
+	c.RetentionRules = nil
+	if spec := strings.TrimSpace(c.getString("RETENTION_RULES", "")); spec != "" {
+		rules, err := ParseThinningRules(spec)
+		if err != nil {
+			return fmt.Errorf("invalid RETENTION_RULES %q: %w", spec, err)
+		}
+		c.RetentionRules = rules
+	}
 
 	policy := strings.ToLower(strings.TrimSpace(c.getString("RETENTION_POLICY", "simple")))
 	switch policy {
 	case "gfs":
 		c.RetentionPolicy = "gfs"
+	case "thinning":
+		if len(c.RetentionRules) == 0 {
+			return fmt.Errorf("RETENTION_POLICY=thinning requires RETENTION_RULES")
+		}
+		c.RetentionPolicy = "thinning"
 	default:
 		c.RetentionPolicy = "simple"
 	}
 
+	c.SafetyBackupKeep = c.getInt("SAFETY_BACKUP_KEEP", 5)
+	if c.SafetyBackupKeep < 0 {
+		c.SafetyBackupKeep = 0
+	}
+	c.SafetyBackupMaxAgeDays = c.getInt("SAFETY_BACKUP_MAX_AGE_DAYS", 30)
+	if c.SafetyBackupMaxAgeDays < 0 {
+		c.SafetyBackupMaxAgeDays = 0
+	}
+
 	c.CloudBatchSize = c.getInt("CLOUD_BATCH_SIZE", 20)
 	if c.CloudBatchSize <= 0 {
 		c.CloudBatchSize = 20
@@ -764,6 +981,52 @@ func (c *Config) parseRetentionSettings() {
 
 	c.BundleAssociatedFiles = c.getBool("BUNDLE_ASSOCIATED_FILES", true)
 	c.SafetyFactor = 1.5
+	return nil
+}
+
+// FATArchiveVolumeSize is the volume size used by ARCHIVE_VOLUME_SIZE=auto on
+// FAT filesystems, one byte below their 4 GiB file size limit.
+const FATArchiveVolumeSize = 4*1024*1024*1024 - 1
+
+// minArchiveVolumeSize keeps a typo like "ARCHIVE_VOLUME_SIZE=4" from turning
+// one archive into millions of volumes.
+const minArchiveVolumeSize = 1024 * 1024
+
+func (c *Config) parseArchiveVolumeSettings() error {
+	raw := strings.TrimSpace(c.getString("ARCHIVE_VOLUME_SIZE", ""))
+	c.ArchiveVolumeAuto = strings.EqualFold(raw, "auto")
+	c.ArchiveVolumeSize = 0
+	if c.ArchiveVolumeAuto {
+		return nil
+	}
+	size, err := parseSizeToBytes(raw)
+	if err != nil {
+		return fmt.Errorf("invalid ARCHIVE_VOLUME_SIZE: %w", err)
+	}
+	if size > 0 && size < minArchiveVolumeSize {
+		return fmt.Errorf("invalid ARCHIVE_VOLUME_SIZE: must be at least 1M")
+	}
+	c.ArchiveVolumeSize = size
+	return nil
+}
+
+// MANIFEST_SIGNATURE_POLICY values.
+const (
+	ManifestSignatureOff     = "off"
+	ManifestSignatureWarn    = "warn"
+	ManifestSignatureRequire = "require"
+)
+
+func (c *Config) parseManifestSignatureSettings() error {
+	c.SignManifests = c.getBool("SIGN_MANIFESTS", true)
+	c.ManifestSignaturePolicy = strings.ToLower(strings.TrimSpace(c.getString("MANIFEST_SIGNATURE_POLICY", ManifestSignatureWarn)))
+	switch c.ManifestSignaturePolicy {
+	case ManifestSignatureOff, ManifestSignatureWarn, ManifestSignatureRequire:
+	default:
+		return fmt.Errorf("invalid MANIFEST_SIGNATURE_POLICY %q (expected %s, %s or %s)", c.ManifestSignaturePolicy, ManifestSignatureOff, ManifestSignatureWarn, ManifestSignatureRequire)
+	}
+	c.ManifestTrustedKeys = c.getStringSlice("MANIFEST_TRUSTED_KEYS", nil)
+	return nil
 }
 
 func (c *Config) parseNotificationSettings() {
@@ -862,6 +1125,12 @@ func (c *Config) parseHealthcheckSettings() {
 	c.HealthcheckNotifyWebhookID = strings.TrimSpace(c.getString("HEALTHCHECK_NOTIFY_WEBHOOK_ID", ""))
 }
 
+// CLUSTER_BACKUP_MODE values.
+const (
+	ClusterBackupIndependent = "independent"
+	ClusterBackupCoordinated = "coordinated"
+)
+
 // normalizeSchedulerMode maps any unrecognised value to the safe default "cron".
 func normalizeSchedulerMode(v string) string {
 	if strings.ToLower(strings.TrimSpace(v)) == "daemon" {
@@ -885,6 +1154,28 @@ func normalizeHealthcheckMode(v string) string {
 	return HealthcheckModeCentralized
 }
 
+// parseFleetSettings reads the fleet-mode keys. An empty FLEET_COLLECTOR_URL keeps the
+// agent push off; the collector keys only matter to --fleet-collector.
+func (c *Config) parseFleetSettings() {
+	c.FleetCollectorURL = strings.TrimSpace(c.getString("FLEET_COLLECTOR_URL", ""))
+	c.FleetSecret = strings.TrimSpace(c.getString("FLEET_SECRET", ""))
+	c.FleetAgentSecretsFile = strings.TrimSpace(c.getString("FLEET_AGENT_SECRETS_FILE", ""))
+	c.FleetReadToken = strings.TrimSpace(c.getString("FLEET_READ_TOKEN", ""))
+	c.FleetCAFile = strings.TrimSpace(c.getString("FLEET_CA_FILE", ""))
+	c.FleetPushTimeout = c.getDuration("FLEET_PUSH_TIMEOUT", 30*time.Second)
+	c.FleetListen = strings.TrimSpace(c.getString("FLEET_LISTEN", ":8443"))
+	c.FleetTLSCert = strings.TrimSpace(c.getString("FLEET_TLS_CERT", ""))
+	c.FleetTLSKey = strings.TrimSpace(c.getString("FLEET_TLS_KEY", ""))
+	c.FleetDataDir = strings.TrimSpace(c.getString("FLEET_DATA_DIR", filepath.Join(c.BaseDir, "fleet")))
+	c.FleetStaleAfter = c.getDuration("FLEET_STALE_AFTER", 26*time.Hour)
+	c.FleetSpaceWarnPercent = c.getFloat("FLEET_SPACE_WARN_PERCENT", 90)
+	c.FleetDigestTime = strings.TrimSpace(c.getString("FLEET_DIGEST_TIME", "07:00"))
+	c.FleetHistoryDays = c.getInt("FLEET_HISTORY_DAYS", 30)
+	if c.FleetHistoryDays < 1 {
+		c.FleetHistoryDays = 1
+	}
+}
+
 func (c *Config) parseCollectionSettings() error {
 	if patterns := c.getStringSlice("BACKUP_EXCLUDE_PATTERNS", nil); patterns != nil {
 		c.ExcludePatterns = patterns
@@ -900,12 +1191,27 @@ func (c *Config) parseCollectionSettings() error {
 
 	c.CustomBackupPaths = normalizeList(c.getStringSlice("CUSTOM_BACKUP_PATHS", nil))
 	c.BackupBlacklist = normalizeList(c.getStringSlice("BACKUP_BLACKLIST", nil))
+	c.CustomBricksFile = strings.TrimSpace(c.getString("CUSTOM_BRICKS_FILE", ""))
+	c.CollectorWorkers = c.getInt("COLLECTOR_WORKERS", 4)
+	if c.CollectorWorkers < 1 {
+		c.CollectorWorkers = 1
+	}
 	return nil
 }
 
 func (c *Config) parsePVESettings() error {
 	c.BackupVMConfigs = c.getBool("BACKUP_VM_CONFIGS", true)
 	c.BackupClusterConfig = c.getBool("BACKUP_CLUSTER_CONFIG", true)
+	c.ClusterBackupMode = strings.ToLower(strings.TrimSpace(c.getString("CLUSTER_BACKUP_MODE", ClusterBackupIndependent)))
+	switch c.ClusterBackupMode {
+	case ClusterBackupIndependent, ClusterBackupCoordinated:
+	default:
+		return fmt.Errorf("invalid CLUSTER_BACKUP_MODE %q (expected %s or %s)", c.ClusterBackupMode, ClusterBackupIndependent, ClusterBackupCoordinated)
+	}
+	c.ClusterCaptureInterval = c.getDuration("CLUSTER_CAPTURE_INTERVAL", 24*time.Hour)
+	if c.ClusterCaptureInterval < time.Hour || c.ClusterCaptureInterval > 24*time.Hour {
+		return fmt.Errorf("invalid CLUSTER_CAPTURE_INTERVAL %s (must be between 1h and 24h)", c.ClusterCaptureInterval)
+	}
 	c.BackupPVEFirewall = c.getBool("BACKUP_PVE_FIREWALL", true)
 	c.BackupVZDumpConfig = c.getBool("BACKUP_VZDUMP_CONFIG", true)
 	c.BackupPVEACL = c.getBool("BACKUP_PVE_ACL", true)
@@ -913,6 +1219,11 @@ func (c *Config) parsePVESettings() error {
 	c.BackupPVESchedules = c.getBool("BACKUP_PVE_SCHEDULES", true)
 	c.BackupPVEReplication = c.getBool("BACKUP_PVE_REPLICATION", true)
 	c.BackupPVEBackupFiles = c.getBool("BACKUP_PVE_BACKUP_FILES", true)
+	c.PVEBackupCoverageAudit = c.getBool("BACKUP_PVE_COVERAGE_AUDIT", true)
+	c.PVEGuestBackupMaxAgeDays = c.getInt("PVE_GUEST_BACKUP_MAX_AGE_DAYS", 2)
+	if c.PVEGuestBackupMaxAgeDays < 0 {
+		c.PVEGuestBackupMaxAgeDays = 0
+	}
 	c.PveshTimeoutSeconds = c.getInt("PVESH_TIMEOUT", 15)
 	if c.PveshTimeoutSeconds < 0 {
 		c.PveshTimeoutSeconds = 15
@@ -965,6 +1276,15 @@ func (c *Config) parsePBSSettings() {
 	c.PxarDatastoreConcurrency = c.getInt("PXAR_SCAN_DS_CONCURRENCY", 3)
 	c.PxarFileIncludePatterns = normalizeList(c.getStringSliceWithFallback([]string{"PXAR_FILE_INCLUDE_PATTERN", "PXAR_INCLUDE_PATTERN"}, nil))
 	c.PxarFileExcludePatterns = normalizeList(c.getStringSlice("PXAR_FILE_EXCLUDE_PATTERN", nil))
+	c.PBSSnapshotInventory = c.getBool("BACKUP_PBS_SNAPSHOT_INVENTORY", true)
+	c.PBSSnapshotVerifyMaxAgeDays = c.getInt("PBS_SNAPSHOT_VERIFY_MAX_AGE_DAYS", 30)
+	c.PBSSnapshotStaleDays = c.getInt("PBS_SNAPSHOT_STALE_DAYS", 2)
+	if c.PBSSnapshotVerifyMaxAgeDays < 0 {
+		c.PBSSnapshotVerifyMaxAgeDays = 0
+	}
+	if c.PBSSnapshotStaleDays < 0 {
+		c.PBSSnapshotStaleDays = 0
+	}
 }
 
 func (c *Config) parseSystemSettings() {
@@ -973,6 +1293,10 @@ func (c *Config) parseSystemSettings() {
 	c.BackupCronJobs = c.getBoolWithFallback([]string{"BACKUP_CRON_JOBS", "BACKUP_CRONTABS"}, true)
 	c.BackupSystemdServices = c.getBool("BACKUP_SYSTEMD_SERVICES", true)
 	c.BackupSSLCerts = c.getBool("BACKUP_SSL_CERTS", true)
+	c.CertExpiryWarnDays = c.getInt("CERT_EXPIRY_WARN_DAYS", 30)
+	if c.CertExpiryWarnDays < 0 {
+		c.CertExpiryWarnDays = 0
+	}
 	c.BackupSysctlConfig = c.getBool("BACKUP_SYSCTL_CONFIG", true)
 	c.BackupKernelModules = c.getBool("BACKUP_KERNEL_MODULES", true)
 	c.BackupFirewallRules = c.getBool("BACKUP_FIREWALL_RULES", false)
@@ -1835,11 +2159,75 @@ func (c *Config) IsGFSRetentionEnabled() bool {
 	return strings.ToLower(strings.TrimSpace(c.RetentionPolicy)) == "gfs"
 }
 
+// retentionOverrideKeys are the backup.env keys ApplyRetentionOverride accepts.
+var retentionOverrideKeys = []string{
+	"RETENTION_POLICY",
+	"RETENTION_HOURLY", "RETENTION_DAILY", "RETENTION_WEEKLY", "RETENTION_MONTHLY", "RETENTION_YEARLY",
+	"RETENTION_RULES",
+	"MAX_LOCAL_BACKUPS", "MAX_SECONDARY_BACKUPS", "MAX_CLOUD_BACKUPS",
+}
+
+// ApplyRetentionOverride sets one retention key as if it were in backup.env and
+// re-parses the retention settings, so a policy change can be previewed
+// (--retention-plan --override) without editing the file.
+func (c *Config) ApplyRetentionOverride(key, value string) error {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	known := false
+	for _, k := range retentionOverrideKeys {
+		if k == key {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("%s is not a retention setting (use one of %s)", key, strings.Join(retentionOverrideKeys, ", "))
+	}
+	switch key {
+	case "RETENTION_POLICY":
+		if v := strings.ToLower(value); v != "simple" && v != "gfs" && v != "thinning" {
+			return fmt.Errorf("invalid RETENTION_POLICY %q (use simple, gfs or thinning)", value)
+		}
+	case "RETENTION_RULES":
+		if _, err := ParseThinningRules(value); err != nil {
+			return fmt.Errorf("invalid RETENTION_RULES %q: %w", value, err)
+		}
+	default:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q: must be a non-negative integer", key, value)
+		}
+	}
+	if c.raw == nil {
+		c.raw = make(map[string]string)
+	}
+	previous, hadPrevious := c.raw[key]
+	c.raw[key] = value
+	if err := c.parseRetentionSettings(); err != nil {
+		if hadPrevious {
+			c.raw[key] = previous
+		} else {
+			delete(c.raw, key)
+		}
+		_ = c.parseRetentionSettings()
+		return err
+	}
+	return nil
+}
+
+// IsThinningRetentionEnabled returns true if age-based thinning retention
+// (RETENTION_POLICY=thinning with RETENTION_RULES) is configured.
+func (c *Config) IsThinningRetentionEnabled() bool {
+	return strings.ToLower(strings.TrimSpace(c.RetentionPolicy)) == "thinning" && len(c.RetentionRules) > 0
+}
+
 // GetRetentionPolicy returns the active retention policy type
-// Returns "gfs" if GFS retention is enabled, "simple" otherwise
+// Returns "gfs" or "thinning" when enabled, "simple" otherwise
 func (c *Config) GetRetentionPolicy() string {
-	if c.IsGFSRetentionEnabled() {
+	switch {
+	case c.IsGFSRetentionEnabled():
 		return "gfs"
+	case c.IsThinningRetentionEnabled():
+		return "thinning"
 	}
 	return "simple"
 }
@@ -11,6 +11,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -194,6 +195,29 @@ BACKUP_BLACKLIST=/var/data/tmp
 		t.Error("Expected BackupPxarFiles to be false")
 	}
 
+	if !cfg.PBSSnapshotInventory || cfg.PBSSnapshotVerifyMaxAgeDays != 30 || cfg.PBSSnapshotStaleDays != 2 {
+		t.Errorf("PBS snapshot inventory defaults = (%v, %d, %d); want (true, 30, 2)",
+			cfg.PBSSnapshotInventory, cfg.PBSSnapshotVerifyMaxAgeDays, cfg.PBSSnapshotStaleDays)
+	}
+
+	if !cfg.PVEBackupCoverageAudit || cfg.PVEGuestBackupMaxAgeDays != 2 {
+		t.Errorf("PVE backup coverage defaults = (%v, %d); want (true, 2)",
+			cfg.PVEBackupCoverageAudit, cfg.PVEGuestBackupMaxAgeDays)
+	}
+
+	if !cfg.HardeningAudit {
+		t.Error("HardeningAudit should default to true")
+	}
+
+	if cfg.CertExpiryWarnDays != 30 {
+		t.Errorf("CertExpiryWarnDays = %d; want 30", cfg.CertExpiryWarnDays)
+	}
+
+	if !cfg.SignManifests || cfg.ManifestSignaturePolicy != ManifestSignatureWarn || len(cfg.ManifestTrustedKeys) != 0 {
+		t.Errorf("manifest signature defaults = (%v, %q, %v); want (true, warn, [])",
+			cfg.SignManifests, cfg.ManifestSignaturePolicy, cfg.ManifestTrustedKeys)
+	}
+
 	if len(cfg.CustomBackupPaths) != 2 || cfg.CustomBackupPaths[0] != "/etc/custom" || cfg.CustomBackupPaths[1] != "/var/data" {
 		t.Errorf("CustomBackupPaths = %#v; want [/etc/custom /var/data]", cfg.CustomBackupPaths)
 	}
@@ -201,6 +225,37 @@ BACKUP_BLACKLIST=/var/data/tmp
 	if len(cfg.BackupBlacklist) != 1 || cfg.BackupBlacklist[0] != "/var/data/tmp" {
 		t.Errorf("BackupBlacklist = %#v; want [/var/data/tmp]", cfg.BackupBlacklist)
 	}
+
+	if cfg.CustomBricksFile != "" {
+		t.Errorf("Expected CustomBricksFile to be empty by default, got %q", cfg.CustomBricksFile)
+	}
+	if cfg.CollectorWorkers != 4 {
+		t.Errorf("Expected CollectorWorkers=4 by default, got %d", cfg.CollectorWorkers)
+	}
+	if cfg.FleetCollectorURL != "" {
+		t.Errorf("Expected FleetCollectorURL to be empty by default, got %q", cfg.FleetCollectorURL)
+	}
+	if cfg.FleetStaleAfter != 26*time.Hour || cfg.FleetSpaceWarnPercent != 90 || cfg.FleetDigestTime != "07:00" {
+		t.Errorf("Fleet defaults = stale %v, space %v%%, digest %q; want 26h, 90%%, 07:00",
+			cfg.FleetStaleAfter, cfg.FleetSpaceWarnPercent, cfg.FleetDigestTime)
+	}
+	if cfg.ClusterBackupMode != ClusterBackupIndependent || cfg.ClusterCaptureInterval != 24*time.Hour {
+		t.Errorf("Cluster backup defaults = (%q, %v); want (%q, 24h)",
+			cfg.ClusterBackupMode, cfg.ClusterCaptureInterval, ClusterBackupIndependent)
+	}
+	if cfg.CloudUploadChunkSize != 0 {
+		t.Errorf("Expected whole-file cloud uploads by default, got CloudUploadChunkSize=%d", cfg.CloudUploadChunkSize)
+	}
+	if cfg.ArchiveVolumeSize != 0 || cfg.ArchiveVolumeAuto {
+		t.Errorf("Expected single-file archives by default, got ArchiveVolumeSize=%d auto=%v", cfg.ArchiveVolumeSize, cfg.ArchiveVolumeAuto)
+	}
+	if cfg.CloudUploadWindow != "" || cfg.RcloneBandwidthSchedule != "" || cfg.CloudUploadQueueMaxAge != 168*time.Hour {
+		t.Errorf("Cloud upload defaults = window %q, schedule %q, max age %v; want always, none, 168h",
+			cfg.CloudUploadWindow, cfg.RcloneBandwidthSchedule, cfg.CloudUploadQueueMaxAge)
+	}
+	if cfg.CapacityForecastWarnDays != 14 {
+		t.Errorf("Expected CapacityForecastWarnDays=14 by default, got %d", cfg.CapacityForecastWarnDays)
+	}
 }
 
 func TestConfigAdvancedOptions(t *testing.T) {
@@ -432,6 +487,37 @@ SECONDARY_PATH=remote:path
 	}
 }
 
+func TestLoadConfigRejectsInvalidClusterBackupMode(t *testing.T) {
+	cases := map[string]string{
+		"CLUSTER_BACKUP_MODE=leader\n":   "invalid CLUSTER_BACKUP_MODE",
+		"CLUSTER_CAPTURE_INTERVAL=30m\n": "invalid CLUSTER_CAPTURE_INTERVAL",
+		"CLUSTER_CAPTURE_INTERVAL=48h\n": "invalid CLUSTER_CAPTURE_INTERVAL",
+	}
+	for line, want := range cases {
+		configPath := filepath.Join(t.TempDir(), "cluster.env")
+		content := "BACKUP_PATH=/test/backup\nLOG_PATH=/test/log\n" + line
+		if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create config file: %v", err)
+		}
+		_, err := LoadConfig(configPath)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("LoadConfig(%q) error = %v, want substring %q", line, err, want)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidManifestSignaturePolicy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "signature.env")
+	content := "BACKUP_PATH=/test/backup\nLOG_PATH=/test/log\nMANIFEST_SIGNATURE_POLICY=strict\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "invalid MANIFEST_SIGNATURE_POLICY") {
+		t.Fatalf("LoadConfig() error = %v, want invalid MANIFEST_SIGNATURE_POLICY", err)
+	}
+}
+
 func TestLoadConfigRejectsInvalidSecondaryLogPathWhenConfigured(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "invalid-secondary-log.env")
@@ -453,6 +539,184 @@ SECONDARY_LOG_PATH=remote:/logs
 	}
 }
 
+func TestLoadConfigRejectsInvalidCloudUploadSettings(t *testing.T) {
+	tests := map[string]string{
+		"CLOUD_UPLOAD_WINDOW=mon-fri 22:00":           "CLOUD_UPLOAD_WINDOW invalid",
+		"RCLONE_BANDWIDTH_SCHEDULE=08:00,fast":        "RCLONE_BANDWIDTH_SCHEDULE invalid",
+		"RCLONE_BANDWIDTH_SCHEDULE=Funday-08:00,512k": "RCLONE_BANDWIDTH_SCHEDULE invalid",
+		"CLOUD_UPLOAD_CHUNK_SIZE=1M":                  "CLOUD_UPLOAD_CHUNK_SIZE",
+	}
+	for line, want := range tests {
+		t.Run(line, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "invalid-upload-schedule.env")
+			content := "BACKUP_PATH=/test/backup\nCLOUD_ENABLED=true\nCLOUD_REMOTE=remote\n" + line + "\n"
+			if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(configPath)
+			if err == nil {
+				t.Fatal("expected LoadConfig to fail")
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("LoadConfig() error = %q, want substring %q", err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigArchiveVolumeSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantSize int64
+		wantAuto bool
+		wantErr  string
+	}{
+		{value: "", wantSize: 0},
+		{value: "0", wantSize: 0},
+		{value: "2G", wantSize: 2 * 1024 * 1024 * 1024},
+		{value: "auto", wantAuto: true},
+		{value: "AUTO", wantAuto: true},
+		{value: "512k", wantErr: "must be at least 1M"},
+		{value: "big", wantErr: "invalid ARCHIVE_VOLUME_SIZE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "volumes.env")
+			content := "BACKUP_PATH=/test/backup\nARCHIVE_VOLUME_SIZE=" + tt.value + "\n"
+			if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if cfg.ArchiveVolumeSize != tt.wantSize || cfg.ArchiveVolumeAuto != tt.wantAuto {
+				t.Fatalf("ArchiveVolumeSize=%d auto=%v, want %d auto=%v", cfg.ArchiveVolumeSize, cfg.ArchiveVolumeAuto, tt.wantSize, tt.wantAuto)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionOverride(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "retention.env")
+	content := "BACKUP_PATH=/test/backup\nMAX_LOCAL_BACKUPS=5\nRETENTION_DAILY=7\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	for key, value := range map[string]string{"retention_policy": "gfs", "RETENTION_WEEKLY": "8", "MAX_LOCAL_BACKUPS": "3"} {
+		if err := cfg.ApplyRetentionOverride(key, value); err != nil {
+			t.Fatalf("ApplyRetentionOverride(%s=%s) error = %v", key, value, err)
+		}
+	}
+	if !cfg.IsGFSRetentionEnabled() || cfg.RetentionDaily != 7 || cfg.RetentionWeekly != 8 || cfg.LocalRetentionDays != 3 {
+		t.Fatalf("after overrides: policy=%s daily=%d weekly=%d max_local=%d",
+			cfg.RetentionPolicy, cfg.RetentionDaily, cfg.RetentionWeekly, cfg.LocalRetentionDays)
+	}
+
+	for _, bad := range [][2]string{{"BACKUP_PATH", "/tmp"}, {"RETENTION_DAILY", "-1"}, {"RETENTION_POLICY", "fifo"}} {
+		if err := cfg.ApplyRetentionOverride(bad[0], bad[1]); err == nil {
+			t.Fatalf("ApplyRetentionOverride(%s=%s) accepted", bad[0], bad[1])
+		}
+	}
+}
+
+func TestParseThinningRules(t *testing.T) {
+	rules, err := ParseThinningRules("48h:all, 7d:6h, 60d:1d, forever:1M")
+	if err != nil {
+		t.Fatalf("ParseThinningRules() error = %v", err)
+	}
+	want := []ThinningRule{
+		{MaxAge: 48 * time.Hour},
+		{MaxAge: 7 * 24 * time.Hour, Every: 6, Unit: 'h'},
+		{MaxAge: 60 * 24 * time.Hour, Every: 1, Unit: 'd'},
+		{Every: 1, Unit: 'M'},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Fatalf("ParseThinningRules() = %+v, want %+v", rules, want)
+	}
+	var rendered []string
+	for _, r := range rules {
+		rendered = append(rendered, r.String())
+	}
+	if got := strings.Join(rendered, ","); got != "48h:all,7d:6h,60d:1d,forever:1M" {
+		t.Fatalf("String() = %q", got)
+	}
+
+	for _, bad := range []string{
+		"",
+		"48h",
+		"48h:all,24h:1d",
+		"forever:1d,30d:1w",
+		"7d:0h",
+		"7x:1d",
+		"7d:6s",
+		"-1d:all",
+	} {
+		if _, err := ParseThinningRules(bad); err == nil {
+			t.Errorf("ParseThinningRules(%q) accepted", bad)
+		}
+	}
+}
+
+func TestLoadConfigThinningRetention(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "thinning.env")
+	content := "BACKUP_PATH=/test/backup\nRETENTION_POLICY=thinning\nRETENTION_RULES=48h:all,7d:6h,forever:1M\nRETENTION_HOURLY=12\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.GetRetentionPolicy() != "thinning" || !cfg.IsThinningRetentionEnabled() || len(cfg.RetentionRules) != 3 {
+		t.Fatalf("policy=%s rules=%v, want thinning with 3 rules", cfg.GetRetentionPolicy(), cfg.RetentionRules)
+	}
+	if cfg.RetentionHourly != 12 {
+		t.Fatalf("RetentionHourly = %d, want 12", cfg.RetentionHourly)
+	}
+
+	for name, body := range map[string]string{
+		"no rules":      "BACKUP_PATH=/test/backup\nRETENTION_POLICY=thinning\n",
+		"invalid rules": "BACKUP_PATH=/test/backup\nRETENTION_RULES=7d:all,1d:all\n",
+	} {
+		path := filepath.Join(dir, "bad.env")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("Failed to create config file: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: LoadConfig() accepted", name)
+		}
+	}
+
+	// Overrides are rolled back when they leave the settings invalid.
+	if err := cfg.ApplyRetentionOverride("RETENTION_RULES", "30d:all"); err != nil {
+		t.Fatalf("ApplyRetentionOverride(RETENTION_RULES) error = %v", err)
+	}
+	if len(cfg.RetentionRules) != 1 {
+		t.Fatalf("RetentionRules = %v after override", cfg.RetentionRules)
+	}
+	if err := cfg.ApplyRetentionOverride("RETENTION_RULES", "30d"); err == nil {
+		t.Fatal("ApplyRetentionOverride accepted an invalid rule")
+	}
+	if len(cfg.RetentionRules) != 1 || !cfg.IsThinningRetentionEnabled() {
+		t.Fatalf("invalid override changed the rules: %v", cfg.RetentionRules)
+	}
+}
+
 func TestValidateCloudSettingsAllowsAbsoluteCloudRemote(t *testing.T) {
 	tests := []string{
 		"/mnt/cloud",
@@ -957,6 +1221,29 @@ func TestAdjustLevelForMode(t *testing.T) {
 	}
 }
 
+func TestParseCompressionMode(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantMode   string
+		wantEngine types.CompressionEngine
+	}{
+		{"", "standard", types.CompressionEngineAuto},
+		{"ultra", "ultra", types.CompressionEngineAuto},
+		{"ultra,native", "ultra", types.CompressionEngineNative},
+		{"Maximum + External", "maximum", types.CompressionEngineExternal},
+		{"native", "standard", types.CompressionEngineNative},
+		{"fast auto", "fast", types.CompressionEngineAuto},
+	}
+
+	for _, tt := range tests {
+		mode, engine := parseCompressionMode(tt.value)
+		if mode != tt.wantMode || engine != tt.wantEngine {
+			t.Fatalf("parseCompressionMode(%q) = (%q, %q); want (%q, %q)",
+				tt.value, mode, engine, tt.wantMode, tt.wantEngine)
+		}
+	}
+}
+
 func TestLoadConfigCompressionTypeAliases(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "compression_alias.env")
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThinningRule is one age-based retention rule of RETENTION_RULES, written
+// "<age>:<spacing>": backups younger than the age (the first matching rule wins)
+// are all kept ("all") or thinned to one per spacing bucket ("6h", "1d", "1w",
+// "1M", "1y"). The age "forever" matches every backup and must be last.
+//
+// Example: "48h:all,7d:6h,60d:1d,forever:1M" keeps everything for 48 hours, one
+// backup per 6 hours for a week, one per day for 60 days and one per month forever.
+type ThinningRule struct {
+	// MaxAge is the age limit of the rule; 0 means forever.
+	MaxAge time.Duration
+	// Every is the bucket size in Unit; 0 keeps every backup.
+	Every int
+	// Unit is the bucket unit: 'h' (hours), 'd' (days), 'w' (ISO weeks),
+	// 'M' (months) or 'y' (years).
+	Unit byte
+}
+
+// String renders the rule in RETENTION_RULES syntax (e.g. "7d:6h").
+func (r ThinningRule) String() string {
+	age := "forever"
+	if r.MaxAge > 0 {
+		age = formatRuleAge(r.MaxAge)
+	}
+	spacing := "all"
+	if r.Every > 0 {
+		spacing = fmt.Sprintf("%d%c", r.Every, r.Unit)
+	}
+	return age + ":" + spacing
+}
+
+// ParseThinningRules parses a comma-separated RETENTION_RULES value. Rule ages
+// must grow strictly from one rule to the next; "forever" can only be last.
+func ParseThinningRules(spec string) ([]ThinningRule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("no rules")
+	}
+	var rules []ThinningRule
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		age, spacing, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: expected <age>:<spacing>", field)
+		}
+		var rule ThinningRule
+		if strings.TrimSpace(age) != "forever" {
+			n, unit, err := parseRuleSpan(age)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: age %w", field, err)
+			}
+			rule.MaxAge = ruleSpanDuration(n, unit)
+		}
+		if strings.TrimSpace(spacing) != "all" {
+			n, unit, err := parseRuleSpan(spacing)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: spacing %w", field, err)
+			}
+			rule.Every, rule.Unit = n, unit
+		}
+		if len(rules) > 0 {
+			prev := rules[len(rules)-1]
+			if prev.MaxAge == 0 {
+				return nil, fmt.Errorf("rule %q follows a forever rule", field)
+			}
+			if rule.MaxAge != 0 && rule.MaxAge <= prev.MaxAge {
+				return nil, fmt.Errorf("rule %q: ages must grow from one rule to the next", field)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseRuleSpan parses "<n><unit>" with unit h, d, w, M or y.
+func parseRuleSpan(s string) (int, byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("%q: expected <n>h|d|w|M|y", s)
+	}
+	unit := s[len(s)-1]
+	switch unit {
+	case 'h', 'd', 'w', 'M', 'y':
+	default:
+		return 0, 0, fmt.Errorf("%q: unknown unit %q (use h, d, w, M or y)", s, unit)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf("%q: expected a positive number", s)
+	}
+	return n, unit, nil
+}
+
+// ruleSpanDuration converts an age to a duration; months and years are
+// counted as 30 and 365 days.
+func ruleSpanDuration(n int, unit byte) time.Duration {
+	day := 24 * time.Hour
+	switch unit {
+	case 'h':
+		return time.Duration(n) * time.Hour
+	case 'd':
+		return time.Duration(n) * day
+	case 'w':
+		return time.Duration(n) * 7 * day
+	case 'M':
+		return time.Duration(n) * 30 * day
+	default:
+		return time.Duration(n) * 365 * day
+	}
+}
+
+// formatRuleAge renders an age in hours below three days and in days above,
+// which is unambiguous whatever unit the rule was written with.
+func formatRuleAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day != 0 || d < 3*day {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dd", d/day)
+}
@@ -0,0 +1,212 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a set of recurring weekly time ranges, e.g. "01:00-06:00" or
+// "mon-fri 22:00-06:00; sat,sun". The zero value is always open.
+type Window struct {
+	spec  string
+	rules []windowRule
+}
+
+type windowRule struct {
+	days       [7]bool
+	start, end int // minutes after midnight; end <= start wraps past midnight
+	allDay     bool
+}
+
+// ParseWindow parses a window spec: rules separated by ";", each an optional day
+// list ("mon-fri", "sat,sun") followed by an optional HH:MM-HH:MM range. A rule
+// without days applies every day, one without a range covers the whole day, and
+// a range ending before it starts runs past midnight (it belongs to its start
+// day). An empty spec returns the zero (always open) window.
+func ParseWindow(spec string) (Window, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Window{}, nil
+	}
+	w := Window{spec: spec}
+	for _, raw := range strings.Split(spec, ";") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		rule, err := parseWindowRule(raw)
+		if err != nil {
+			return Window{}, fmt.Errorf("window %q: %w", raw, err)
+		}
+		w.rules = append(w.rules, rule)
+	}
+	if len(w.rules) == 0 {
+		return Window{}, nil
+	}
+	return w, nil
+}
+
+func parseWindowRule(raw string) (windowRule, error) {
+	var rule windowRule
+	fields := strings.Fields(raw)
+	if len(fields) > 2 {
+		return rule, fmt.Errorf("expected [days] [HH:MM-HH:MM]")
+	}
+	daysSet := false
+	rule.allDay = true
+	for _, field := range fields {
+		if strings.Contains(field, ":") {
+			if !rule.allDay {
+				return rule, fmt.Errorf("more than one time range")
+			}
+			start, end, err := parseTimeRange(field)
+			if err != nil {
+				return rule, err
+			}
+			rule.start, rule.end, rule.allDay = start, end, false
+			continue
+		}
+		if daysSet {
+			return rule, fmt.Errorf("more than one day list")
+		}
+		days, err := parseDays(field)
+		if err != nil {
+			return rule, err
+		}
+		rule.days, daysSet = days, true
+	}
+	if !daysSet {
+		for i := range rule.days {
+			rule.days[i] = true
+		}
+	}
+	return rule, nil
+}
+
+func parseTimeRange(value string) (int, int, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("time range must be HH:MM-HH:MM")
+	}
+	sh, sm, err := parseTime(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end := strings.TrimSpace(parts[1])
+	endMinutes := 24 * 60
+	if end != "24:00" {
+		eh, em, err := parseTime(end)
+		if err != nil {
+			return 0, 0, err
+		}
+		endMinutes = eh*60 + em
+	}
+	start := sh*60 + sm
+	if start == endMinutes {
+		return 0, 0, fmt.Errorf("time range %s is empty", value)
+	}
+	return start, endMinutes, nil
+}
+
+func parseDays(value string) ([7]bool, error) {
+	var days [7]bool
+	for _, item := range strings.Split(strings.ToLower(value), ",") {
+		item = strings.TrimSpace(item)
+		if from, to, ok := strings.Cut(item, "-"); ok {
+			first, ok1 := weekdayNames[from]
+			last, ok2 := weekdayNames[to]
+			if !ok1 || !ok2 {
+				return days, fmt.Errorf("unknown day range %q (use mon..sun)", item)
+			}
+			for d := first; ; d = (d + 1) % 7 {
+				days[d] = true
+				if d == last {
+					break
+				}
+			}
+			continue
+		}
+		day, ok := weekdayNames[item]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q (use mon..sun)", item)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
+
+// IsZero reports whether the window is always open.
+func (w Window) IsZero() bool {
+	return len(w.rules) == 0
+}
+
+// String returns the spec the window was parsed from ("always" for the zero window).
+func (w Window) String() string {
+	if w.IsZero() {
+		return "always"
+	}
+	return w.spec
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	if w.IsZero() {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, rule := range w.rules {
+		switch {
+		case rule.allDay:
+			if rule.days[today] {
+				return true
+			}
+		case rule.start < rule.end:
+			if rule.days[today] && minute >= rule.start && minute < rule.end {
+				return true
+			}
+		default: // wraps past midnight
+			if rule.days[today] && minute >= rule.start {
+				return true
+			}
+			if rule.days[yesterday] && minute < rule.end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextOpen returns t when the window is open, otherwise the next time it opens.
+func (w Window) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	var best time.Time
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for offset := 0; offset <= 7; offset++ {
+		day := midnight.AddDate(0, 0, offset)
+		for _, rule := range w.rules {
+			if !rule.days[day.Weekday()] {
+				continue
+			}
+			opens := day.Add(time.Duration(rule.start) * time.Minute)
+			if opens.After(t) && (best.IsZero() || opens.Before(best)) {
+				best = opens
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowContains(t *testing.T) {
+	// 2026-07-04 is a Saturday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 7, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"", at(6, 12, 0), true},
+		{"01:00-06:00", at(6, 3, 0), true},
+		{"01:00-06:00", at(6, 6, 0), false},
+		{"mon-fri 22:00-06:00", at(6, 23, 0), true},          // Monday night
+		{"mon-fri 22:00-06:00", at(7, 5, 59), true},          // Tuesday morning, opened Monday
+		{"mon-fri 22:00-06:00", at(6, 5, 0), false},          // Monday morning, opened Sunday
+		{"mon-fri 22:00-06:00; sat,sun", at(5, 13, 0), true}, // Sunday
+		{"sat,sun", at(6, 13, 0), false},
+		{"fri-mon 00:00-24:00", at(5, 13, 0), true},
+	}
+	for _, tc := range tests {
+		w, err := ParseWindow(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q): %v", tc.spec, err)
+		}
+		if got := w.Contains(tc.t); got != tc.want {
+			t.Errorf("ParseWindow(%q).Contains(%s) = %v, want %v", tc.spec, tc.t.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestWindowNextOpen(t *testing.T) {
+	w, err := ParseWindow("mon-fri 22:00-06:00; sat,sun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wed := time.Date(2026, 7, 8, 12, 0, 0, 0, time.UTC)
+	if got, want := w.NextOpen(wed), time.Date(2026, 7, 8, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("NextOpen(Wed 12:00) = %s, want %s", got, want)
+	}
+	fri := time.Date(2026, 7, 10, 7, 0, 0, 0, time.UTC)
+	if got, want := w.NextOpen(fri), time.Date(2026, 7, 10, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("NextOpen(Fri 07:00) = %s, want %s", got, want)
+	}
+	open := time.Date(2026, 7, 11, 9, 0, 0, 0, time.UTC)
+	if got := w.NextOpen(open); !got.Equal(open) {
+		t.Fatalf("NextOpen inside the window = %s, want %s", got, open)
+	}
+}
+
+func TestParseWindowInvalid(t *testing.T) {
+	for _, bad := range []string{"funday", "01:00", "01:00-01:00", "mon 01:00-02:00 03:00-04:00", "25:00-02:00", "mon sat"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Errorf("ParseWindow(%q) expected error, got nil", bad)
+		}
+	}
+}
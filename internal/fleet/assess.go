@@ -0,0 +1,82 @@
+package fleet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/metrics"
+)
+
+// Policy holds the collector thresholds (FLEET_STALE_AFTER, FLEET_SPACE_WARN_PERCENT).
+type Policy struct {
+	StaleAfter       time.Duration
+	SpaceWarnPercent float64
+}
+
+// HostState is the collector's view of one host, derived from its latest report.
+type HostState struct {
+	Host        string
+	ProxmoxType string
+	LastReport  time.Time // collector clock
+	LastRun     time.Time
+	Status      int // metrics.StatusSuccess / StatusWarning / StatusError
+	Stale       bool
+	Failing     bool
+	LowSpace    bool
+	Storage     []StorageReport
+	ArchiveSize int64
+	// Problems lists the human-readable reasons the host needs attention, in a stable order.
+	Problems []string
+}
+
+// Healthy reports whether the host is fresh, not failing and has space left.
+func (h HostState) Healthy() bool {
+	return !h.Stale && !h.Failing && !h.LowSpace
+}
+
+// Assess derives the state of every reported host at now.
+func Assess(reports []Report, p Policy, now time.Time) []HostState {
+	states := make([]HostState, 0, len(reports))
+	for i := range reports {
+		r := &reports[i]
+		st := HostState{
+			Host:        r.Host,
+			ProxmoxType: r.Run.ProxmoxType,
+			LastReport:  r.ReceivedAt,
+			LastRun:     r.RunTime(),
+			Status:      r.RunStatus(),
+			Storage:     r.Run.Storage,
+		}
+		if st.LastReport.IsZero() {
+			st.LastReport = r.SentAt
+		}
+		if r.Metrics != nil {
+			st.ArchiveSize = r.Metrics.ArchiveSize
+		}
+
+		if p.StaleAfter > 0 {
+			if age := now.Sub(st.LastReport); age > p.StaleAfter {
+				st.Stale = true
+				st.Problems = append(st.Problems, fmt.Sprintf("no report for %s", age.Round(time.Minute)))
+			}
+		}
+		if st.Status == metrics.StatusError {
+			st.Failing = true
+			msg := r.Run.StatusMessage
+			if msg == "" {
+				msg = "last backup failed"
+			}
+			st.Problems = append(st.Problems, fmt.Sprintf("%s (exit code %d)", msg, r.Run.ExitCode))
+		}
+		if p.SpaceWarnPercent > 0 {
+			for _, s := range st.Storage {
+				if s.UsagePercent >= p.SpaceWarnPercent {
+					st.LowSpace = true
+					st.Problems = append(st.Problems, fmt.Sprintf("%s storage %.1f%% used", s.Location, s.UsagePercent))
+				}
+			}
+		}
+		states = append(states, st)
+	}
+	return states
+}
@@ -0,0 +1,145 @@
+package fleet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/notify"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// Digest is the daily fleet summary: every host that needs attention plus the totals.
+type Digest struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Hosts       int           `json:"hosts"`
+	Healthy     int           `json:"healthy"`
+	Failing     int           `json:"failing"`
+	Stale       int           `json:"stale"`
+	LowSpace    int           `json:"low_space"`
+	Attention   []DigestEntry `json:"attention,omitempty"`
+}
+
+// DigestEntry is one host that needs attention.
+type DigestEntry struct {
+	Host        string    `json:"host"`
+	ProxmoxType string    `json:"proxmox_type"`
+	LastReport  time.Time `json:"last_report"`
+	Failing     bool      `json:"failing"`
+	Problems    []string  `json:"problems"`
+}
+
+// BuildDigest summarises the assessed fleet at now.
+func BuildDigest(states []HostState, now time.Time) Digest {
+	d := Digest{GeneratedAt: now, Hosts: len(states)}
+	for _, st := range states {
+		if st.Healthy() {
+			d.Healthy++
+			continue
+		}
+		if st.Failing {
+			d.Failing++
+		}
+		if st.Stale {
+			d.Stale++
+		}
+		if st.LowSpace {
+			d.LowSpace++
+		}
+		d.Attention = append(d.Attention, DigestEntry{
+			Host:        st.Host,
+			ProxmoxType: st.ProxmoxType,
+			LastReport:  st.LastReport,
+			Failing:     st.Failing,
+			Problems:    st.Problems,
+		})
+	}
+	return d
+}
+
+// Status is failure when a host is failing, warning when one is stale or short on space.
+func (d Digest) Status() notify.NotificationStatus {
+	switch {
+	case d.Failing > 0:
+		return notify.StatusFailure
+	case d.Stale > 0 || d.LowSpace > 0:
+		return notify.StatusWarning
+	}
+	return notify.StatusSuccess
+}
+
+// Summary is the one-line headline of the digest.
+func (d Digest) Summary() string {
+	return fmt.Sprintf("Fleet digest: %d hosts, %d healthy, %d failing, %d stale, %d short on space",
+		d.Hosts, d.Healthy, d.Failing, d.Stale, d.LowSpace)
+}
+
+// Text renders the digest as plain text.
+func (d Digest) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", notify.GetStatusEmoji(d.Status()), d.Summary())
+	fmt.Fprintf(&b, "Generated: %s\n", d.GeneratedAt.Format("2006-01-02 15:04:05"))
+	if len(d.Attention) == 0 {
+		b.WriteString("\nAll hosts reported a successful backup in time.\n")
+		return b.String()
+	}
+	b.WriteString("\nHOSTS NEEDING ATTENTION:\n")
+	for _, e := range d.Attention {
+		fmt.Fprintf(&b, "  %s (%s, last report %s)\n", e.Host, strings.ToUpper(e.ProxmoxType), e.LastReport.Format("2006-01-02 15:04"))
+		for _, p := range e.Problems {
+			fmt.Fprintf(&b, "    - %s\n", p)
+		}
+	}
+	return b.String()
+}
+
+// NotificationData maps the digest onto the notification model so it can be sent through
+// a notify.Notifier: each host needing attention becomes one issue category.
+func (d Digest) NotificationData(collectorHost, scriptVersion string) *notify.NotificationData {
+	data := &notify.NotificationData{
+		Status:        d.Status(),
+		StatusMessage: d.Summary(),
+		Hostname:      collectorHost,
+		ProxmoxType:   types.ProxmoxUnknown,
+		BackupDate:    d.GeneratedAt,
+		ErrorCount:    d.Failing,
+		WarningCount:  d.Stale + d.LowSpace,
+		ScriptVersion: scriptVersion,
+		FleetDigest:   &notify.FleetDigestSummary{Headline: d.Summary(), Text: d.Text()},
+	}
+	if d.Status() == notify.StatusFailure {
+		data.ExitCode = types.ExitBackupError.Int()
+	} else if d.Status() == notify.StatusWarning {
+		data.ExitCode = types.ExitGenericError.Int()
+	}
+	data.TotalIssues = data.ErrorCount + data.WarningCount
+	for _, e := range d.Attention {
+		kind := "WARNING"
+		if e.Failing {
+			kind = "ERROR"
+		}
+		data.LogCategories = append(data.LogCategories, notify.LogCategory{
+			Label:   e.Host,
+			Type:    kind,
+			Count:   len(e.Problems),
+			Example: strings.Join(e.Problems, "; "),
+		})
+	}
+	return data
+}
+
+// WriteDigest stores the digest as digest/YYYY-MM-DD.txt and .json under the store dir and
+// returns the text path.
+func (s *Store) WriteDigest(d Digest) (string, error) {
+	base := filepath.Join(s.dir, "digest", d.GeneratedAt.Format(historyDateLayout))
+	if err := writeJSONAtomic(base+".json", d); err != nil {
+		return "", err
+	}
+	txt := base + ".txt"
+	if err := os.WriteFile(txt, []byte(d.Text()), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", filepath.Base(txt), err)
+	}
+	return txt, nil
+}
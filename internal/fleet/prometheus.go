@@ -0,0 +1,87 @@
+package fleet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMetrics renders the fleet view in the Prometheus text exposition format. Series are
+// keyed by the host label; the proxmox_backup_fleet_ prefix keeps them apart from the
+// per-host textfile metrics (proxmox_backup_*) an agent may export alongside.
+func WriteMetrics(w io.Writer, states []HostState) error {
+	var b strings.Builder
+	family := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	flag := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	var stale, failing, lowSpace int
+	for _, st := range states {
+		stale += flag(st.Stale)
+		failing += flag(st.Failing)
+		lowSpace += flag(st.LowSpace)
+	}
+	family("proxmox_backup_fleet_hosts", "Number of reporting hosts by state (total, stale, failing, low_space)")
+	fmt.Fprintf(&b, "proxmox_backup_fleet_hosts{state=\"total\"} %d\n", len(states))
+	fmt.Fprintf(&b, "proxmox_backup_fleet_hosts{state=\"stale\"} %d\n", stale)
+	fmt.Fprintf(&b, "proxmox_backup_fleet_hosts{state=\"failing\"} %d\n", failing)
+	fmt.Fprintf(&b, "proxmox_backup_fleet_hosts{state=\"low_space\"} %d\n", lowSpace)
+
+	perHost := []struct {
+		name  string
+		help  string
+		value func(HostState) int64
+	}{
+		{"proxmox_backup_fleet_host_last_report_timestamp_seconds", "Unix timestamp of the last report received from the host", func(st HostState) int64 { return st.LastReport.Unix() }},
+		{"proxmox_backup_fleet_host_last_run_timestamp_seconds", "Unix timestamp of the end of the last reported backup run", func(st HostState) int64 { return st.LastRun.Unix() }},
+		{"proxmox_backup_fleet_host_status", "Status of the last reported backup (0=success,1=warning,2=error)", func(st HostState) int64 { return int64(st.Status) }},
+		{"proxmox_backup_fleet_host_stale", "1 when the host has not reported within FLEET_STALE_AFTER", func(st HostState) int64 { return int64(flag(st.Stale)) }},
+		{"proxmox_backup_fleet_host_failing", "1 when the last reported backup failed", func(st HostState) int64 { return int64(flag(st.Failing)) }},
+		{"proxmox_backup_fleet_host_low_space", "1 when a backup location is at or above FLEET_SPACE_WARN_PERCENT", func(st HostState) int64 { return int64(flag(st.LowSpace)) }},
+		{"proxmox_backup_fleet_host_archive_size_bytes", "Size of the last reported backup archive in bytes", func(st HostState) int64 { return st.ArchiveSize }},
+	}
+	family("proxmox_backup_fleet_host_info", "Static information about a reporting host")
+	for _, st := range states {
+		fmt.Fprintf(&b, "proxmox_backup_fleet_host_info{host=%q,proxmox_type=%q} 1\n", st.Host, st.ProxmoxType)
+	}
+	for _, m := range perHost {
+		family(m.name, m.help)
+		for _, st := range states {
+			fmt.Fprintf(&b, "%s{host=%q} %d\n", m.name, st.Host, m.value(st))
+		}
+	}
+
+	family("proxmox_backup_fleet_host_storage_usage_percent", "Usage of a backup location in percent, per host")
+	for _, st := range states {
+		for _, s := range st.Storage {
+			if s.Location == LocationCloud {
+				continue
+			}
+			fmt.Fprintf(&b, "proxmox_backup_fleet_host_storage_usage_percent{host=%q,location=%q} %.2f\n", st.Host, s.Location, s.UsagePercent)
+		}
+	}
+	family("proxmox_backup_fleet_host_storage_free_bytes", "Free space of a backup location in bytes, per host")
+	for _, st := range states {
+		for _, s := range st.Storage {
+			if s.Location == LocationCloud {
+				continue
+			}
+			fmt.Fprintf(&b, "proxmox_backup_fleet_host_storage_free_bytes{host=%q,location=%q} %d\n", st.Host, s.Location, s.FreeBytes)
+		}
+	}
+	family("proxmox_backup_fleet_host_backups", "Number of backups per host and location")
+	for _, st := range states {
+		for _, s := range st.Storage {
+			fmt.Fprintf(&b, "proxmox_backup_fleet_host_backups{host=%q,location=%q} %d\n", st.Host, s.Location, s.Backups)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,170 @@
+// Package fleet implements fleet mode: proxsave agents push one signed report per run to a
+// collector, which stores the latest report per host and derives a fleet-wide /metrics
+// endpoint and a daily digest of stale, failing and short-on-space hosts.
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/metrics"
+	"github.com/tis24dev/proxsave/internal/notify"
+)
+
+// ReportSchema is the version of the Report JSON document. The collector refuses reports
+// with a newer schema instead of silently dropping fields it does not know.
+const ReportSchema = 1
+
+// Storage locations reported per run.
+const (
+	LocationLocal     = "local"
+	LocationSecondary = "secondary"
+	LocationCloud     = "cloud"
+)
+
+// hostPattern bounds a reported host name: it becomes a file name in the store.
+var hostPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,252}$`)
+
+// Report is one agent run as pushed to the collector: the notification view of the run
+// (Run) plus the Prometheus metrics snapshot (Metrics).
+type Report struct {
+	Schema  int                    `json:"schema"`
+	Host    string                 `json:"host"`
+	SentAt  time.Time              `json:"sent_at"`
+	Run     RunReport              `json:"run"`
+	Metrics *metrics.BackupMetrics `json:"metrics"`
+	// ReceivedAt is stamped by the collector (collector clock), never trusted from the agent.
+	ReceivedAt time.Time `json:"received_at,omitempty"`
+}
+
+// RunReport is the JSON form of notify.NotificationData. Local paths, the server MAC and
+// the per-channel delivery details stay on the agent.
+type RunReport struct {
+	Status              string                          `json:"status"`
+	StatusMessage       string                          `json:"status_message"`
+	ExitCode            int                             `json:"exit_code"`
+	ProxmoxType         string                          `json:"proxmox_type"`
+	ServerID            string                          `json:"server_id,omitempty"`
+	BackupDate          time.Time                       `json:"backup_date"`
+	BackupFile          string                          `json:"backup_file,omitempty"`
+	BackupSize          int64                           `json:"backup_size"`
+	CompressionType     string                          `json:"compression_type,omitempty"`
+	Storage             []StorageReport                 `json:"storage"`
+	ErrorCount          int                             `json:"error_count"`
+	WarningCount        int                             `json:"warning_count"`
+	LogCategories       []notify.LogCategory            `json:"log_categories,omitempty"`
+	PBSSnapshots        *notify.PBSSnapshotSummary      `json:"pbs_snapshots,omitempty"`
+	PVEGuestCoverage    *notify.PVEGuestCoverageSummary `json:"pve_guest_coverage,omitempty"`
+	ScriptVersion       string                          `json:"script_version"`
+	NewVersionAvailable bool                            `json:"new_version_available,omitempty"`
+}
+
+// StorageReport is the state of one backup location after the run. FreeBytes and
+// UsagePercent are zero when the location does not expose filesystem usage (cloud).
+type StorageReport struct {
+	Location     string  `json:"location"`
+	Status       string  `json:"status"`
+	Backups      int     `json:"backups"`
+	FreeBytes    uint64  `json:"free_bytes,omitempty"`
+	UsagePercent float64 `json:"usage_percent,omitempty"`
+}
+
+// NewReport builds the report for one run. data is required; m may be nil when the run
+// produced no metrics snapshot.
+func NewReport(data *notify.NotificationData, m *metrics.BackupMetrics, now time.Time) Report {
+	r := Report{
+		Schema:  ReportSchema,
+		Host:    strings.TrimSpace(data.Hostname),
+		SentAt:  now.UTC(),
+		Metrics: m,
+		Run: RunReport{
+			Status:              data.Status.String(),
+			StatusMessage:       data.StatusMessage,
+			ExitCode:            data.ExitCode,
+			ProxmoxType:         data.ProxmoxType.String(),
+			ServerID:            data.ServerID,
+			BackupDate:          data.BackupDate,
+			BackupFile:          data.BackupFileName,
+			BackupSize:          data.BackupSize,
+			CompressionType:     data.CompressionType,
+			ErrorCount:          data.ErrorCount,
+			WarningCount:        data.WarningCount,
+			LogCategories:       data.LogCategories,
+			PBSSnapshots:        data.PBSSnapshots,
+			PVEGuestCoverage:    data.PVEGuestCoverage,
+			ScriptVersion:       data.ScriptVersion,
+			NewVersionAvailable: data.NewVersionAvailable,
+		},
+	}
+	if r.Host == "" && m != nil {
+		r.Host = strings.TrimSpace(m.Hostname)
+	}
+
+	r.Run.Storage = append(r.Run.Storage, StorageReport{
+		Location:     LocationLocal,
+		Status:       data.LocalStatus,
+		Backups:      data.LocalCount,
+		FreeBytes:    data.LocalSpaceBytes,
+		UsagePercent: data.LocalUsagePercent,
+	})
+	if data.SecondaryEnabled {
+		r.Run.Storage = append(r.Run.Storage, StorageReport{
+			Location:     LocationSecondary,
+			Status:       data.SecondaryStatus,
+			Backups:      data.SecondaryCount,
+			FreeBytes:    data.SecondarySpaceBytes,
+			UsagePercent: data.SecondaryUsagePercent,
+		})
+	}
+	if data.CloudEnabled {
+		r.Run.Storage = append(r.Run.Storage, StorageReport{
+			Location: LocationCloud,
+			Status:   data.CloudStatus,
+			Backups:  data.CloudCount,
+		})
+	}
+	return r
+}
+
+// Validate checks the fields the collector relies on before storing a report.
+func (r *Report) Validate() error {
+	if r.Schema < 1 || r.Schema > ReportSchema {
+		return fmt.Errorf("unsupported report schema %d (collector supports up to %d)", r.Schema, ReportSchema)
+	}
+	if !hostPattern.MatchString(r.Host) {
+		return fmt.Errorf("invalid host %q", r.Host)
+	}
+	if r.SentAt.IsZero() {
+		return errors.New("report has no sent_at timestamp")
+	}
+	return nil
+}
+
+// RunStatus is the run classification used by the fleet views: the metrics status when a
+// snapshot was pushed, otherwise derived from the notification status.
+func (r *Report) RunStatus() int {
+	if r.Metrics != nil {
+		return r.Metrics.Status()
+	}
+	switch r.Run.Status {
+	case notify.StatusSuccess.String():
+		return metrics.StatusSuccess
+	case notify.StatusWarning.String():
+		return metrics.StatusWarning
+	}
+	return metrics.StatusError
+}
+
+// RunTime is when the reported run ended (falling back to the backup date, then the push).
+func (r *Report) RunTime() time.Time {
+	if r.Metrics != nil && !r.Metrics.EndTime.IsZero() {
+		return r.Metrics.EndTime
+	}
+	if !r.Run.BackupDate.IsZero() {
+		return r.Run.BackupDate
+	}
+	return r.SentAt
+}
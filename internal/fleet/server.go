@@ -0,0 +1,160 @@
+package fleet
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+const (
+	// ReportPath is where agents POST their reports.
+	ReportPath = "/api/v1/reports"
+	// DigestPath serves the current fleet digest as JSON.
+	DigestPath = "/api/v1/digest"
+	// MetricsPath serves the fleet Prometheus metrics.
+	MetricsPath = "/metrics"
+
+	// maxReportBytes bounds one pushed report; a real one is a few KB.
+	maxReportBytes = 1 << 20
+)
+
+// Server is the collector HTTP surface. TLS is terminated by the caller's http.Server.
+type Server struct {
+	store     *Store
+	secrets   map[string]string
+	readToken string
+	policy    Policy
+	logger    *logging.Logger
+	now       func() time.Time
+}
+
+// NewServer returns a collector serving store. secrets maps each agent host to its own
+// HMAC secret: a report is accepted only for the host whose secret signed it. The digest
+// and metrics reads require readToken as a bearer token; an empty readToken disables
+// them.
+func NewServer(store *Store, secrets map[string]string, readToken string, policy Policy, logger *logging.Logger) *Server {
+	return &Server{store: store, secrets: secrets, readToken: readToken, policy: policy, logger: logger, now: time.Now}
+}
+
+// Handler returns the collector routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+ReportPath, s.handleReport)
+	mux.HandleFunc("GET "+DigestPath, s.requireReadToken(s.handleDigest))
+	mux.HandleFunc("GET "+MetricsPath, s.requireReadToken(s.handleMetrics))
+	return mux
+}
+
+// requireReadToken guards the fleet inventory reads: they expose every host, its
+// failures and storage, so they need the read token even over TLS.
+func (s *Server) requireReadToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.readToken == "" {
+			http.Error(w, "fleet reads disabled (FLEET_READ_TOKEN not set)", http.StatusForbidden)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.readToken)) != 1 {
+			s.warn("fleet: rejected unauthenticated read of %s from %s", r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="proxsave-fleet"`)
+			http.Error(w, "invalid or missing read token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// States assesses the stored fleet at the current time.
+func (s *Server) States() []HostState {
+	reports, errs := s.store.Latest()
+	for _, err := range errs {
+		s.warn("fleet: skipping unreadable host report: %v", err)
+	}
+	return Assess(reports, s.policy, s.now())
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxReportBytes))
+	if err != nil {
+		http.Error(w, "report too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	now := s.now()
+	signer := strings.TrimSpace(r.Header.Get(HeaderHost))
+	secret, ok := s.secrets[signer]
+	if !ok {
+		s.warn("fleet: rejected report from %s: %v (%s %q)", r.RemoteAddr, ErrUnknownHost, HeaderHost, signer)
+		http.Error(w, "unknown host", http.StatusUnauthorized)
+		return
+	}
+	if err := Verify(body,
+		r.Header.Get(HeaderSignatureAlgorithm),
+		r.Header.Get(HeaderSignatureTimestamp),
+		r.Header.Get(HeaderSignature),
+		secret, now); err != nil {
+		s.warn("fleet: rejected report from %s for %s: %v", r.RemoteAddr, signer, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var rep Report
+	if err := json.Unmarshal(body, &rep); err != nil {
+		http.Error(w, fmt.Sprintf("invalid report: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := rep.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The secret authenticates the signer only: an agent must not report for another host.
+	if rep.Host != signer {
+		s.warn("fleet: rejected report from %s: host %q signed with the secret of %q", r.RemoteAddr, rep.Host, signer)
+		http.Error(w, "report host does not match the signing host", http.StatusForbidden)
+		return
+	}
+	rep.ReceivedAt = now.UTC()
+	if err := s.store.Save(rep); err != nil {
+		if errors.Is(err, ErrReplayedReport) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		s.warn("fleet: cannot store report from %s: %v", rep.Host, err)
+		http.Error(w, "cannot store report", http.StatusInternalServerError)
+		return
+	}
+	s.debug("fleet: stored report from %s (status=%s)", rep.Host, rep.Run.Status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDigest(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(BuildDigest(s.States(), s.now()))
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := WriteMetrics(w, s.States()); err != nil {
+		s.debug("fleet: metrics write failed: %v", err)
+	}
+}
+
+func (s *Server) warn(format string, args ...any) {
+	if s.logger != nil {
+		s.logger.Warning(format, args...)
+	}
+}
+
+func (s *Server) debug(format string, args ...any) {
+	if s.logger != nil {
+		s.logger.Debug(format, args...)
+	}
+}
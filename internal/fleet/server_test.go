@@ -0,0 +1,281 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/notify"
+)
+
+const testReadToken = "r3ad-token"
+
+// testAgentSecrets gives every test agent its own secret.
+var testAgentSecrets = map[string]string{"pve1": "s3cret", "pve2": "0ther"}
+
+func newTestCollector(t *testing.T, now time.Time) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := NewServer(NewStore(t.TempDir()), testAgentSecrets, testReadToken, Policy{StaleAfter: 26 * time.Hour, SpaceWarnPercent: 90}, nil)
+	srv.now = func() time.Time { return now }
+	ts := httptest.NewTLSServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func TestPushStoresSignedReport(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+	p := &Pusher{URL: ts.URL + ReportPath, Secret: "s3cret", Client: ts.Client(), Now: func() time.Time { return now }}
+
+	if err := p.Push(context.Background(), testReport("pve1", now)); err != nil {
+		t.Fatalf("Push() = %v", err)
+	}
+	states := srv.States()
+	if len(states) != 1 || states[0].Host != "pve1" || !states[0].Healthy() {
+		t.Fatalf("States() = %+v, want one healthy pve1", states)
+	}
+	if !states[0].LastReport.Equal(now) {
+		t.Fatalf("LastReport = %v, want the collector receive time %v", states[0].LastReport, now)
+	}
+
+	// The same signed request again is refused by the per-host ordering check.
+	err := p.Push(context.Background(), testReport("pve1", now))
+	if err == nil || !strings.Contains(err.Error(), "409") {
+		t.Fatalf("replayed Push() = %v, want a 409", err)
+	}
+}
+
+func TestPushRejectedWithWrongSecret(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+	p := &Pusher{URL: ts.URL + ReportPath, Secret: "wrong", Client: ts.Client(), Now: func() time.Time { return now }}
+
+	err := p.Push(context.Background(), testReport("pve1", now))
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Push() = %v, want a 401", err)
+	}
+	if states := srv.States(); len(states) != 0 {
+		t.Fatalf("States() = %+v, want nothing stored", states)
+	}
+}
+
+func TestPushRejectedForUnknownHost(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+	p := &Pusher{URL: ts.URL + ReportPath, Secret: "s3cret", Client: ts.Client(), Now: func() time.Time { return now }}
+
+	err := p.Push(context.Background(), testReport("pve9", now))
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Push() = %v, want a 401", err)
+	}
+	if states := srv.States(); len(states) != 0 {
+		t.Fatalf("States() = %+v, want nothing stored", states)
+	}
+}
+
+func TestReportRejectedForAnotherHost(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+
+	// pve2 signs correctly with its own secret but reports as pve1.
+	body, err := json.Marshal(testReport("pve1", now))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, ts.URL+ReportPath, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(HeaderHost, "pve2")
+	req.Header.Set(HeaderSignature, Sign(body, now.Unix(), "0ther"))
+	req.Header.Set(HeaderSignatureTimestamp, strconv.FormatInt(now.Unix(), 10))
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("report for another host = %s, want 403", resp.Status)
+	}
+	if states := srv.States(); len(states) != 0 {
+		t.Fatalf("States() = %+v, want nothing stored", states)
+	}
+}
+
+func TestPushRequiresHTTPS(t *testing.T) {
+	p := &Pusher{URL: "http://collector.example/api/v1/reports", Secret: "s3cret"}
+	if err := p.Push(context.Background(), testReport("pve1", time.Now())); err == nil {
+		t.Fatal("Push() to an http:// URL succeeded, want an error")
+	}
+}
+
+func TestAssessFlagsStaleFailingAndLowSpace(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+
+	healthy := testReport("pve-ok", now.Add(-5*time.Hour))
+	healthy.ReceivedAt = healthy.SentAt
+
+	stale := testReport("pve-stale", now.Add(-72*time.Hour))
+	stale.ReceivedAt = stale.SentAt
+
+	failing := testReport("pbs-fail", now.Add(-4*time.Hour))
+	failing.ReceivedAt = failing.SentAt
+	failing.Metrics.Failed = true
+	failing.Metrics.ExitCode = 4
+	failing.Run.Status = notify.StatusFailure.String()
+	failing.Run.StatusMessage = "Backup failed"
+	failing.Run.ExitCode = 4
+
+	full := testReport("pve-full", now.Add(-3*time.Hour))
+	full.ReceivedAt = full.SentAt
+	full.Run.Storage[0].UsagePercent = 95.5
+
+	states := Assess([]Report{healthy, stale, failing, full}, Policy{StaleAfter: 26 * time.Hour, SpaceWarnPercent: 90}, now)
+	got := map[string]HostState{}
+	for _, st := range states {
+		got[st.Host] = st
+	}
+	if !got["pve-ok"].Healthy() {
+		t.Fatalf("pve-ok = %+v, want healthy", got["pve-ok"])
+	}
+	if st := got["pve-stale"]; !st.Stale || st.Failing || st.LowSpace {
+		t.Fatalf("pve-stale = %+v, want only stale", st)
+	}
+	if st := got["pbs-fail"]; !st.Failing || st.Stale || len(st.Problems) != 1 || !strings.Contains(st.Problems[0], "exit code 4") {
+		t.Fatalf("pbs-fail = %+v, want failing with exit code 4", st)
+	}
+	if st := got["pve-full"]; !st.LowSpace || st.Failing || !strings.Contains(strings.Join(st.Problems, ""), "local storage 95.5% used") {
+		t.Fatalf("pve-full = %+v, want short on local space", st)
+	}
+
+	d := BuildDigest(states, now)
+	if d.Hosts != 4 || d.Healthy != 1 || d.Failing != 1 || d.Stale != 1 || d.LowSpace != 1 || len(d.Attention) != 3 {
+		t.Fatalf("digest = %+v", d)
+	}
+	if d.Status() != notify.StatusFailure {
+		t.Fatalf("digest status = %v, want failure", d.Status())
+	}
+	data := d.NotificationData("collector", "1.0.0")
+	if data.StatusMessage != d.Summary() || len(data.LogCategories) != 3 || data.ErrorCount != 1 || data.WarningCount != 2 {
+		t.Fatalf("digest notification = %+v", data)
+	}
+	if text := d.Text(); !strings.Contains(text, "pbs-fail (PVE") || !strings.Contains(text, "no report for 72h0m0s") {
+		t.Fatalf("digest text:\n%s", text)
+	}
+}
+
+func TestMetricsEndpointExposesFleetState(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+	fresh := testReport("pve1", now.Add(-time.Hour))
+	fresh.ReceivedAt = now.Add(-time.Hour)
+	old := testReport("pve2", now.Add(-48*time.Hour))
+	old.ReceivedAt = now.Add(-48 * time.Hour)
+	for _, r := range []Report{fresh, old} {
+		if err := srv.store.Save(r); err != nil {
+			t.Fatalf("Save(%s) = %v", r.Host, err)
+		}
+	}
+
+	resp, err := authorizedGet(ts, MetricsPath, testReadToken)
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics = %s", resp.Status)
+	}
+	for _, want := range []string{
+		`proxmox_backup_fleet_hosts{state="total"} 2`,
+		`proxmox_backup_fleet_hosts{state="stale"} 1`,
+		`proxmox_backup_fleet_host_info{host="pve1",proxmox_type="pve"} 1`,
+		`proxmox_backup_fleet_host_stale{host="pve1"} 0`,
+		`proxmox_backup_fleet_host_stale{host="pve2"} 1`,
+		`proxmox_backup_fleet_host_status{host="pve1"} 0`,
+		`proxmox_backup_fleet_host_storage_usage_percent{host="pve1",location="local"} 40.00`,
+		`proxmox_backup_fleet_host_archive_size_bytes{host="pve1"} 1234`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+
+	// A report push is the only accepted write: GET on the report path is not routed.
+	resp2, err := ts.Client().Get(ts.URL + ReportPath)
+	if err != nil {
+		t.Fatalf("GET report path: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET %s = %s, want 405", ReportPath, resp2.Status)
+	}
+}
+
+func authorizedGet(ts *httptest.Server, path, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return ts.Client().Do(req)
+}
+
+func TestReadEndpointsRequireReadToken(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	srv, ts := newTestCollector(t, now)
+	rep := testReport("pve1", now.Add(-time.Hour))
+	rep.ReceivedAt = now.Add(-time.Hour)
+	if err := srv.store.Save(rep); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	// Each read exposes fleet data identified by its marker.
+	for path, marker := range map[string]string{DigestPath: `"hosts": 1`, MetricsPath: `host="pve1"`} {
+		for _, token := range []string{"", "wrong", "s3cret"} {
+			resp, err := authorizedGet(ts, path, token)
+			if err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized || strings.Contains(string(body), marker) {
+				t.Errorf("GET %s with token %q = %s, want 401 without fleet data", path, token, resp.Status)
+			}
+		}
+		resp, err := authorizedGet(ts, path, testReadToken)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), marker) {
+			t.Errorf("GET %s with the read token = %s\n%s", path, resp.Status, body)
+		}
+	}
+}
+
+func TestReadEndpointsDisabledWithoutReadToken(t *testing.T) {
+	srv := NewServer(NewStore(t.TempDir()), testAgentSecrets, "", Policy{StaleAfter: 26 * time.Hour}, nil)
+	ts := httptest.NewTLSServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	for _, path := range []string{DigestPath, MetricsPath} {
+		resp, err := authorizedGet(ts, path, "")
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("GET %s without FLEET_READ_TOKEN = %s, want 403", path, resp.Status)
+		}
+	}
+}
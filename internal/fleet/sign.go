@@ -0,0 +1,115 @@
+package fleet
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request signing headers. The signature scheme matches the webhook/relay hmac auth
+// (hex HMAC-SHA256 in X-Signature) but also covers a timestamp, so a captured report
+// cannot be replayed outside MaxClockSkew.
+const (
+	HeaderSignature          = "X-Signature"
+	HeaderSignatureAlgorithm = "X-Signature-Algorithm"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	SignatureAlgorithm       = "hmac-sha256"
+	// HeaderHost names the agent whose secret signed the report.
+	HeaderHost = "X-Fleet-Host"
+
+	// MaxClockSkew bounds the difference between the agent timestamp and the collector clock.
+	MaxClockSkew = 5 * time.Minute
+)
+
+var (
+	// ErrBadSignature is returned when the signature is missing or does not match.
+	ErrBadSignature = errors.New("invalid report signature")
+	// ErrStaleSignature is returned when the signed timestamp is outside MaxClockSkew.
+	ErrStaleSignature = errors.New("report signature timestamp outside the allowed clock skew")
+	// ErrUnknownHost is returned when the collector holds no secret for the signing host.
+	ErrUnknownHost = errors.New("no fleet secret for this host")
+)
+
+// Sign returns the hex HMAC-SHA256 of "<ts>.<body>" under secret.
+func Sign(body []byte, ts int64, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(ts, 10)))
+	h.Write([]byte{'.'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks the signature headers of a pushed report against secret.
+func Verify(body []byte, algorithm, tsHeader, signature, secret string, now time.Time) error {
+	if secret == "" {
+		return errors.New("fleet secret is empty")
+	}
+	if algorithm != "" && !strings.EqualFold(algorithm, SignatureAlgorithm) {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrBadSignature, algorithm)
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(tsHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed %s", ErrBadSignature, HeaderSignatureTimestamp)
+	}
+	want := Sign(body, ts, secret)
+	got := strings.ToLower(strings.TrimSpace(signature))
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return ErrBadSignature
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrStaleSignature
+	}
+	return nil
+}
+
+// LoadAgentSecrets reads the collector's per-agent secrets: one "<host> <secret>" pair
+// per line, blank lines and # comments ignored. The file holds the key of every agent,
+// so it must not be accessible to group or others.
+func LoadAgentSecrets(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("fleet agent secrets: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return nil, fmt.Errorf("fleet agent secrets %s is accessible to group/others (mode %#o); chmod 600 it", path, perm)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fleet agent secrets: %w", err)
+	}
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("fleet agent secrets %s line %d: want \"<host> <secret>\"", path, n)
+		}
+		host, secret := fields[0], fields[1]
+		if !hostPattern.MatchString(host) {
+			return nil, fmt.Errorf("fleet agent secrets %s line %d: invalid host %q", path, n, host)
+		}
+		if _, dup := secrets[host]; dup {
+			return nil, fmt.Errorf("fleet agent secrets %s line %d: duplicate host %q", path, n, host)
+		}
+		secrets[host] = secret
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fleet agent secrets: %w", err)
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("fleet agent secrets %s lists no agent", path)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,106 @@
+package fleet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyAcceptsSignedBody(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"host":"pve1"}`)
+	sig := Sign(body, now.Unix(), "s3cret")
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	if err := Verify(body, SignatureAlgorithm, ts, sig, "s3cret", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := Verify(body, "", ts, sig, "s3cret", now); err != nil {
+		t.Fatalf("Verify() without algorithm header = %v, want nil", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"host":"pve1"}`)
+	sig := Sign(body, now.Unix(), "s3cret")
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	cases := []struct {
+		name      string
+		body      []byte
+		algorithm string
+		ts        string
+		sig       string
+		secret    string
+		want      error
+	}{
+		{"modified body", []byte(`{"host":"pve2"}`), SignatureAlgorithm, ts, sig, "s3cret", ErrBadSignature},
+		{"wrong secret", body, SignatureAlgorithm, ts, sig, "other", ErrBadSignature},
+		{"shifted timestamp", body, SignatureAlgorithm, strconv.FormatInt(now.Unix()+1, 10), sig, "s3cret", ErrBadSignature},
+		{"missing timestamp", body, SignatureAlgorithm, "", sig, "s3cret", ErrBadSignature},
+		{"other algorithm", body, "hmac-sha1", ts, sig, "s3cret", ErrBadSignature},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.body, tc.algorithm, tc.ts, tc.sig, tc.secret, now)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsReplayOutsideClockSkew(t *testing.T) {
+	signedAt := time.Unix(1_700_000_000, 0)
+	body := []byte(`{}`)
+	sig := Sign(body, signedAt.Unix(), "s3cret")
+	ts := strconv.FormatInt(signedAt.Unix(), 10)
+
+	for _, now := range []time.Time{signedAt.Add(MaxClockSkew + time.Second), signedAt.Add(-MaxClockSkew - time.Second)} {
+		if err := Verify(body, SignatureAlgorithm, ts, sig, "s3cret", now); !errors.Is(err, ErrStaleSignature) {
+			t.Fatalf("Verify() at %v = %v, want ErrStaleSignature", now.Sub(signedAt), err)
+		}
+	}
+}
+
+func TestLoadAgentSecrets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	secrets, err := LoadAgentSecrets(write("ok", "# agents\npve1 s3cret\n\n  pbs.example.com   0ther  \n", 0o600))
+	if err != nil {
+		t.Fatalf("LoadAgentSecrets() = %v", err)
+	}
+	if len(secrets) != 2 || secrets["pve1"] != "s3cret" || secrets["pbs.example.com"] != "0ther" {
+		t.Fatalf("LoadAgentSecrets() = %v", secrets)
+	}
+
+	cases := []struct {
+		name, content string
+		mode          os.FileMode
+		want          string
+	}{
+		{"readable by others", "pve1 s3cret\n", 0o644, "group/others"},
+		{"missing secret", "pve1\n", 0o600, "line 1"},
+		{"invalid host", "../pve1 s3cret\n", 0o600, "invalid host"},
+		{"duplicate host", "pve1 a\npve1 b\n", 0o600, "duplicate host"},
+		{"no agents", "# none yet\n", 0o600, "no agent"},
+	}
+	for _, tc := range cases {
+		if _, err := LoadAgentSecrets(write(tc.name, tc.content, tc.mode)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: LoadAgentSecrets() = %v, want an error mentioning %q", tc.name, err, tc.want)
+		}
+	}
+}
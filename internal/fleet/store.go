@@ -0,0 +1,180 @@
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReplayedReport is returned when a report is not newer than the stored one for its host.
+var ErrReplayedReport = errors.New("report is not newer than the last one stored for this host")
+
+const historyDateLayout = "2006-01-02"
+
+// Store keeps the latest report per host (hosts/<host>.json) and an append-only daily
+// history (history/YYYY-MM-DD.jsonl) under dir.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore returns a store rooted at dir; directories are created on first write.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) hostPath(host string) string {
+	return filepath.Join(s.dir, "hosts", host+".json")
+}
+
+func (s *Store) historyPath(day time.Time) string {
+	return filepath.Join(s.dir, "history", day.UTC().Format(historyDateLayout)+".jsonl")
+}
+
+// Save stores r as the latest report of its host and appends it to the history. A report
+// whose SentAt is not after the stored one is refused with ErrReplayedReport, so a valid
+// signed request cannot be replayed to roll a host back to an older state.
+func (s *Store) Save(r Report) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.ReceivedAt.IsZero() {
+		r.ReceivedAt = r.SentAt
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, err := s.load(r.Host)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil && !r.SentAt.After(prev.SentAt) {
+		return ErrReplayedReport
+	}
+
+	if err := writeJSONAtomic(s.hostPath(r.Host), r); err != nil {
+		return err
+	}
+	return s.appendHistory(r)
+}
+
+func (s *Store) appendHistory(r Report) error {
+	path := s.historyPath(r.ReceivedAt)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create dir %s: %w", filepath.Dir(path), err)
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal history entry: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("append %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+func (s *Store) load(host string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(s.hostPath(host))
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("parse %s report: %w", host, err)
+	}
+	return r, nil
+}
+
+// Latest returns the latest report of every known host, sorted by host. A corrupt host
+// file is skipped and reported in the returned error list, never hiding the other hosts.
+func (s *Store) Latest() ([]Report, []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, "hosts"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+	var reports []Report
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		r, err := s.load(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Host < reports[j].Host })
+	return reports, errs
+}
+
+// PruneHistory deletes daily history files older than keepDays before now.
+func (s *Store) PruneHistory(keepDays int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := filepath.Join(s.dir, "history")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	cutoff := now.UTC().AddDate(0, 0, -keepDays).Format(historyDateLayout)
+	var errs []error
+	for _, e := range entries {
+		day, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if e.IsDir() || !ok {
+			continue
+		}
+		if _, err := time.Parse(historyDateLayout, day); err != nil || day >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeJSONAtomic writes v as indented JSON to path via a ".tmp" sibling and a rename, the
+// same idiom as the health status file.
+func writeJSONAtomic(path string, v any) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create dir %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", filepath.Base(path), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp) // best-effort cleanup so a failed rename leaves no stray ".tmp"
+		return fmt.Errorf("rename %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
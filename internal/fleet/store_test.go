@@ -0,0 +1,137 @@
+package fleet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/metrics"
+	"github.com/tis24dev/proxsave/internal/notify"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func testReport(host string, sentAt time.Time) Report {
+	data := &notify.NotificationData{
+		Status:            notify.StatusSuccess,
+		StatusMessage:     "Backup completed successfully",
+		Hostname:          host,
+		ProxmoxType:       types.ProxmoxVE,
+		BackupDate:        sentAt,
+		LocalStatus:       "ok",
+		LocalCount:        7,
+		LocalSpaceBytes:   50 << 30,
+		LocalUsagePercent: 40,
+		LocalPath:         "/opt/proxsave/backup",
+		ServerMAC:         "aa:bb:cc:dd:ee:ff",
+	}
+	m := &metrics.BackupMetrics{Hostname: host, EndTime: sentAt, ArchiveSize: 1234}
+	return NewReport(data, m, sentAt)
+}
+
+func TestNewReportMapsNotificationData(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	r := testReport("pve1", now)
+	if r.Schema != ReportSchema || r.Host != "pve1" || !r.SentAt.Equal(now) {
+		t.Fatalf("report header = %+v", r)
+	}
+	if r.Run.Status != "success" || r.Run.ProxmoxType != "pve" {
+		t.Fatalf("run = %+v", r.Run)
+	}
+	if len(r.Run.Storage) != 1 || r.Run.Storage[0].Location != LocationLocal || r.Run.Storage[0].UsagePercent != 40 {
+		t.Fatalf("storage = %+v, want only local at 40%%", r.Run.Storage)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestReportValidateRejectsUnsafeHost(t *testing.T) {
+	for _, host := range []string{"", "../etc", "a/b", ".hidden"} {
+		r := testReport(host, time.Now())
+		r.Host = host
+		if err := r.Validate(); err == nil {
+			t.Fatalf("Validate() accepted host %q", host)
+		}
+	}
+}
+
+func TestStoreSaveKeepsLatestAndRefusesReplay(t *testing.T) {
+	s := NewStore(t.TempDir())
+	t0 := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	if err := s.Save(testReport("pve1", t0)); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+	if err := s.Save(testReport("pve1", t0)); !errors.Is(err, ErrReplayedReport) {
+		t.Fatalf("Save() of the same report = %v, want ErrReplayedReport", err)
+	}
+	if err := s.Save(testReport("pve1", t0.Add(-time.Hour))); !errors.Is(err, ErrReplayedReport) {
+		t.Fatalf("Save() of an older report = %v, want ErrReplayedReport", err)
+	}
+	if err := s.Save(testReport("pbs1", t0)); err != nil {
+		t.Fatalf("Save(pbs1) = %v", err)
+	}
+	if err := s.Save(testReport("pve1", t0.Add(24*time.Hour))); err != nil {
+		t.Fatalf("Save() of a newer report = %v", err)
+	}
+
+	reports, errs := s.Latest()
+	if len(errs) != 0 {
+		t.Fatalf("Latest() errors = %v", errs)
+	}
+	if len(reports) != 2 || reports[0].Host != "pbs1" || reports[1].Host != "pve1" {
+		t.Fatalf("Latest() hosts = %+v, want [pbs1 pve1]", reports)
+	}
+	if !reports[1].SentAt.Equal(t0.Add(24 * time.Hour)) {
+		t.Fatalf("pve1 latest SentAt = %v, want the newer report", reports[1].SentAt)
+	}
+
+	history, err := os.ReadFile(filepath.Join(s.dir, "history", "2026-03-01.jsonl"))
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if n := strings.Count(string(history), "\n"); n != 2 {
+		t.Fatalf("history 2026-03-01 has %d lines, want 2 (replays are not recorded)", n)
+	}
+}
+
+func TestStoreLatestSkipsCorruptHostFile(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if err := s.Save(testReport("pve1", time.Now())); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, "hosts", "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reports, errs := s.Latest()
+	if len(reports) != 1 || len(errs) != 1 {
+		t.Fatalf("Latest() = %d reports, %d errors; want 1 and 1", len(reports), len(errs))
+	}
+}
+
+func TestStorePruneHistory(t *testing.T) {
+	s := NewStore(t.TempDir())
+	dir := filepath.Join(s.dir, "history")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"2026-01-01.jsonl", "2026-02-25.jsonl", "2026-03-01.jsonl", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.PruneHistory(7, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("PruneHistory() = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if got := strings.Join(left, ","); got != "2026-02-25.jsonl,2026-03-01.jsonl,notes.txt" {
+		t.Fatalf("history after prune = %s", got)
+	}
+}
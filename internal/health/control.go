@@ -0,0 +1,380 @@
+// control.go defines the resident daemon's local control API: one newline-delimited JSON request
+// and response per connection over a Unix socket in the identity dir. It lets the dashboard and
+// scripts ask the daemon directly (trigger or cancel a run, read the scheduler's next fire time,
+// the last outcome and the ping records, follow a run's log lines) instead of reconstructing state
+// from the pid/info/status files. The socket is root-only twice over: it is created 0o600 inside
+// the identity dir, and every connection's peer credentials (SO_PEERCRED) must belong to root or to
+// the daemon's own uid. Like its siblings this file stays logging-free and stdlib-only; the daemon
+// supplies the behaviour through ControlHandler.
+
+package health
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Control commands accepted on the socket.
+const (
+	ControlCmdStatus    = "status"
+	ControlCmdRun       = "run"
+	ControlCmdCancel    = "cancel"
+	ControlCmdPings     = "pings"
+	ControlCmdSubscribe = "subscribe"
+)
+
+// Run triggers and results reported in RunInfo/RunOutcome.
+const (
+	RunTriggerScheduled = "scheduled"
+	RunTriggerManual    = "manual"
+
+	RunResultSuccess  = "success"
+	RunResultFailed   = "failed"
+	RunResultHang     = "hang"
+	RunResultCanceled = "canceled"
+	RunResultSkipped  = "skipped"
+)
+
+const (
+	// controlRequestTimeout bounds how long a client may take to send its request, and how long a
+	// one-shot call waits for the answer when its context has no deadline.
+	controlRequestTimeout = 10 * time.Second
+	// controlWriteTimeout drops a subscriber that stops reading instead of stalling the stream.
+	controlWriteTimeout = 5 * time.Second
+	// controlMaxRequest caps the request line; real requests are a few dozen bytes.
+	controlMaxRequest = 4 * 1024
+)
+
+// ErrControlUnavailable means no daemon is listening on the control socket (not running, an older
+// build without the API, or a stale socket file).
+var ErrControlUnavailable = errors.New("daemon control socket unavailable")
+
+// ControlSocketPath returns the control socket path, a sibling of the pid/info/status files in the
+// identity dir.
+func ControlSocketPath(baseDir string) string {
+	return filepath.Join(baseDir, "identity", ".daemon.sock")
+}
+
+// ControlRequest is the single JSON line a client sends.
+type ControlRequest struct {
+	Cmd string `json:"cmd"`
+}
+
+// ControlResponse is the JSON line the daemon answers with. A subscription answers once with OK and
+// then streams one response per log line, each carrying only Line.
+type ControlResponse struct {
+	OK     bool           `json:"ok"`
+	Error  string         `json:"error,omitempty"`
+	Status *DaemonRuntime `json:"status,omitempty"`
+	Pings  *Status        `json:"pings,omitempty"`
+	Line   *RunLogLine    `json:"line,omitempty"`
+}
+
+// DaemonRuntime is the daemon's live scheduler state. Timestamps are unix seconds.
+type DaemonRuntime struct {
+	PID       int    `json:"pid"`
+	Version   string `json:"version,omitempty"`
+	StartTS   int64  `json:"start_ts"`
+	NextRunTS int64  `json:"next_run_ts,omitempty"`
+	// Queued is set while a manual run is waiting to be picked up by the scheduler.
+	Queued  bool        `json:"queued,omitempty"`
+	Running *RunInfo    `json:"running,omitempty"`
+	LastRun *RunOutcome `json:"last_run,omitempty"`
+}
+
+// RunInfo describes the supervised backup in progress.
+type RunInfo struct {
+	RID     string `json:"rid"`
+	Trigger string `json:"trigger"`
+	StartTS int64  `json:"start_ts"`
+}
+
+// RunOutcome is how the last supervised backup ended. ExitCode is only meaningful for the
+// success/failed/skipped results.
+type RunOutcome struct {
+	RID      string `json:"rid"`
+	Trigger  string `json:"trigger"`
+	StartTS  int64  `json:"start_ts"`
+	EndTS    int64  `json:"end_ts"`
+	Result   string `json:"result"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// RunLogLine is one output line of the supervised backup child.
+type RunLogLine struct {
+	RID  string `json:"rid"`
+	TS   int64  `json:"ts"`
+	Text string `json:"text"`
+}
+
+// ControlHandler is the daemon side of the API. TriggerRun and CancelRun return a user-facing error
+// when the request cannot be honoured (nothing to cancel, a run already in progress, ...).
+// SubscribeLog returns a channel of run log lines and the function that releases it.
+type ControlHandler interface {
+	RuntimeStatus() DaemonRuntime
+	TriggerRun() error
+	CancelRun() error
+	PingRecords() (Status, error)
+	SubscribeLog() (<-chan RunLogLine, func())
+}
+
+// ControlServer serves ControlHandler on the control socket.
+type ControlServer struct {
+	ln      *net.UnixListener
+	handler ControlHandler
+	// allowUID is the non-root uid also allowed to connect: the daemon's own effective uid.
+	allowUID uint32
+	wg       sync.WaitGroup
+}
+
+// ListenControl binds the control socket at path. A leftover socket from a previous daemon is
+// replaced; any other file at that path is an error rather than being deleted.
+func ListenControl(path string, handler ControlHandler) (*ControlServer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create dir %s: %w", filepath.Dir(path), err)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("control socket path %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale control socket: %w", err)
+		}
+	}
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("listen on control socket: %w", err)
+	}
+	ln.SetUnlinkOnClose(true)
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("chmod control socket: %w", err)
+	}
+	return &ControlServer{ln: ln, handler: handler, allowUID: uint32(os.Geteuid())}, nil
+}
+
+// Serve accepts connections until ctx is cancelled, then closes the socket (removing the file) and
+// waits for in-flight connections, including subscriptions, to end.
+func (s *ControlServer) Serve(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() { _ = s.ln.Close() })
+	defer stop()
+	defer s.wg.Wait()
+	for {
+		conn, err := s.ln.AcceptUnix()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accept on control socket: %w", err)
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// Close stops accepting connections and removes the socket file.
+func (s *ControlServer) Close() error {
+	return s.ln.Close()
+}
+
+func (s *ControlServer) serveConn(ctx context.Context, conn *net.UnixConn) {
+	defer conn.Close()
+	enc := json.NewEncoder(conn)
+	reply := func(resp ControlResponse) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(controlWriteTimeout))
+		return enc.Encode(resp)
+	}
+
+	uid, err := controlPeerUID(conn)
+	if err != nil || (uid != 0 && uid != s.allowUID) {
+		_ = reply(ControlResponse{Error: "permission denied"})
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(controlRequestTimeout))
+	reader := bufio.NewReaderSize(conn, controlMaxRequest)
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		_ = reply(ControlResponse{Error: "malformed request"})
+		return
+	}
+	var req ControlRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		_ = reply(ControlResponse{Error: "malformed request"})
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	switch req.Cmd {
+	case ControlCmdStatus:
+		st := s.handler.RuntimeStatus()
+		_ = reply(ControlResponse{OK: true, Status: &st})
+	case ControlCmdRun, ControlCmdCancel:
+		action := s.handler.TriggerRun
+		if req.Cmd == ControlCmdCancel {
+			action = s.handler.CancelRun
+		}
+		if err := action(); err != nil {
+			_ = reply(ControlResponse{Error: err.Error()})
+			return
+		}
+		st := s.handler.RuntimeStatus()
+		_ = reply(ControlResponse{OK: true, Status: &st})
+	case ControlCmdPings:
+		st, err := s.handler.PingRecords()
+		if err != nil {
+			_ = reply(ControlResponse{Error: err.Error()})
+			return
+		}
+		_ = reply(ControlResponse{OK: true, Pings: &st})
+	case ControlCmdSubscribe:
+		s.streamLog(ctx, conn, reply)
+	default:
+		_ = reply(ControlResponse{Error: fmt.Sprintf("unknown command %q", req.Cmd)})
+	}
+}
+
+// streamLog forwards run log lines until the daemon stops, the client hangs up, or a write stalls.
+func (s *ControlServer) streamLog(ctx context.Context, conn *net.UnixConn, reply func(ControlResponse) error) {
+	lines, release := s.handler.SubscribeLog()
+	defer release()
+	if err := reply(ControlResponse{OK: true}); err != nil {
+		return
+	}
+	// The client never sends anything after its request, so a read returning means it hung up.
+	hangup := make(chan struct{})
+	go func() {
+		defer close(hangup)
+		_, _ = conn.Read(make([]byte, 1))
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if err := reply(ControlResponse{OK: true, Line: &line}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// controlPeerUID returns the uid of the process on the other end of conn.
+func controlPeerUID(conn *net.UnixConn) (uint32, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, credErr
+	}
+	return cred.Uid, nil
+}
+
+// CallControl sends one command to the daemon at socketPath and returns its answer. A daemon-side
+// refusal is returned as an error carrying the daemon's message; no listener is ErrControlUnavailable.
+func CallControl(ctx context.Context, socketPath, cmd string) (ControlResponse, error) {
+	conn, err := dialControl(ctx, socketPath, cmd)
+	if err != nil {
+		return ControlResponse{}, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(controlRequestTimeout)
+	}
+	_ = conn.SetReadDeadline(deadline)
+	resp, err := readControlResponse(bufio.NewReader(conn))
+	if err != nil {
+		return ControlResponse{}, err
+	}
+	return resp, nil
+}
+
+// FollowControlLog subscribes to the daemon's run log and calls fn for each line until ctx is
+// cancelled (returns nil) or the daemon closes the stream.
+func FollowControlLog(ctx context.Context, socketPath string, fn func(RunLogLine)) error {
+	conn, err := dialControl(ctx, socketPath, ControlCmdSubscribe)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	if _, err := readControlResponse(reader); err != nil {
+		return err
+	}
+	for {
+		resp, err := readControlResponse(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if resp.Line != nil {
+			fn(*resp.Line)
+		}
+	}
+}
+
+func dialControl(ctx context.Context, socketPath, cmd string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, ErrControlUnavailable
+		}
+		return nil, fmt.Errorf("connect to daemon control socket: %w", err)
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(controlRequestTimeout))
+	if err := json.NewEncoder(conn).Encode(ControlRequest{Cmd: cmd}); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("send control request: %w", err)
+	}
+	_ = conn.SetWriteDeadline(time.Time{})
+	return conn, nil
+}
+
+func readControlResponse(reader *bufio.Reader) (ControlResponse, error) {
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return ControlResponse{}, fmt.Errorf("read control response: %w", err)
+	}
+	var resp ControlResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return ControlResponse{}, fmt.Errorf("parse control response: %w", err)
+	}
+	if !resp.OK {
+		return resp, fmt.Errorf("daemon: %s", resp.Error)
+	}
+	return resp, nil
+}
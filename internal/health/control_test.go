@@ -0,0 +1,191 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeControlHandler struct {
+	mu       sync.Mutex
+	runs     int
+	cancels  int
+	running  bool
+	lines    chan RunLogLine
+	released bool
+}
+
+func (f *fakeControlHandler) RuntimeStatus() DaemonRuntime {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st := DaemonRuntime{PID: 42, NextRunTS: 1700000000}
+	if f.running {
+		st.Running = &RunInfo{RID: "rid-1", Trigger: RunTriggerManual}
+	}
+	return st
+}
+
+func (f *fakeControlHandler) TriggerRun() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.running {
+		return errors.New("a backup is already running (rid=rid-1)")
+	}
+	f.runs++
+	f.running = true
+	return nil
+}
+
+func (f *fakeControlHandler) CancelRun() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.running {
+		return errors.New("no backup is running")
+	}
+	f.cancels++
+	f.running = false
+	return nil
+}
+
+func (f *fakeControlHandler) PingRecords() (Status, error) {
+	return Status{Mode: "self", Records: map[string]*PingRecord{KindHeartbeat: {TS: 7, OK: true}}}, nil
+}
+
+func (f *fakeControlHandler) SubscribeLog() (<-chan RunLogLine, func()) {
+	return f.lines, func() {
+		f.mu.Lock()
+		f.released = true
+		f.mu.Unlock()
+	}
+}
+
+func startControlServer(t *testing.T, h ControlHandler) string {
+	t.Helper()
+	path := ControlSocketPath(t.TempDir())
+	srv, err := ListenControl(path, h)
+	if err != nil {
+		t.Fatalf("ListenControl: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("socket file should be removed on shutdown, stat err=%v", err)
+		}
+	})
+	return path
+}
+
+func TestControlSocketIsOwnerOnly(t *testing.T) {
+	path := startControlServer(t, &fakeControlHandler{})
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, want a 0600 socket", info.Mode())
+	}
+}
+
+func TestControlRunCancelStatusAndPings(t *testing.T) {
+	h := &fakeControlHandler{}
+	path := startControlServer(t, h)
+	ctx := context.Background()
+
+	resp, err := CallControl(ctx, path, ControlCmdStatus)
+	if err != nil || resp.Status == nil || resp.Status.PID != 42 || resp.Status.NextRunTS != 1700000000 {
+		t.Fatalf("status = %+v, %v", resp, err)
+	}
+
+	resp, err = CallControl(ctx, path, ControlCmdRun)
+	if err != nil || resp.Status == nil || resp.Status.Running == nil {
+		t.Fatalf("run = %+v, %v; want the refreshed status with the run in progress", resp, err)
+	}
+	if _, err := CallControl(ctx, path, ControlCmdRun); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("second run error = %v, want the daemon's refusal", err)
+	}
+	if _, err := CallControl(ctx, path, ControlCmdCancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if h.runs != 1 || h.cancels != 1 {
+		t.Fatalf("handler runs=%d cancels=%d, want 1/1", h.runs, h.cancels)
+	}
+
+	resp, err = CallControl(ctx, path, ControlCmdPings)
+	if err != nil || resp.Pings == nil || resp.Pings.Record(KindHeartbeat) == nil || !resp.Pings.Record(KindHeartbeat).OK {
+		t.Fatalf("pings = %+v, %v", resp, err)
+	}
+
+	if _, err := CallControl(ctx, path, "reboot"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Fatalf("unknown command error = %v", err)
+	}
+}
+
+func TestFollowControlLogStreamsLines(t *testing.T) {
+	h := &fakeControlHandler{lines: make(chan RunLogLine, 2)}
+	path := startControlServer(t, h)
+	h.lines <- RunLogLine{RID: "rid-1", Text: "first"}
+	h.lines <- RunLogLine{RID: "rid-1", Text: "second"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	err := FollowControlLog(ctx, path, func(line RunLogLine) {
+		got = append(got, line.Text)
+		if len(got) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("FollowControlLog: %v", err)
+	}
+	if strings.Join(got, ",") != "first,second" {
+		t.Fatalf("lines = %v", got)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.mu.Lock()
+		released := h.released
+		h.mu.Unlock()
+		if released {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not released after the client hung up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCallControlWithoutDaemon(t *testing.T) {
+	path := ControlSocketPath(t.TempDir())
+	if _, err := CallControl(context.Background(), path, ControlCmdStatus); !errors.Is(err, ErrControlUnavailable) {
+		t.Fatalf("missing socket error = %v, want ErrControlUnavailable", err)
+	}
+}
+
+func TestListenControlRefusesNonSocketFile(t *testing.T) {
+	path := ControlSocketPath(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("not a socket"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenControl(path, &fakeControlHandler{}); err == nil {
+		t.Fatal("ListenControl must not replace a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "not a socket" {
+		t.Fatal("regular file at the socket path was modified")
+	}
+}
@@ -0,0 +1,129 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+const (
+	manifestSigningKeyFileName = ".manifest_signing_key"
+	manifestSigningPubFileName = "manifest_signing.pub"
+)
+
+// ManifestSigningKeyPath returns the immutable identity-file path for the
+// per-host manifest signing key.
+func ManifestSigningKeyPath(baseDir string) string {
+	return filepath.Join(strings.TrimSpace(baseDir), identityDirName, manifestSigningKeyFileName)
+}
+
+// ManifestSigningPublicKeyPath returns the path of the exported public half of
+// the manifest signing key, the file other hosts list in MANIFEST_TRUSTED_KEYS.
+func ManifestSigningPublicKeyPath(baseDir string) string {
+	return filepath.Join(strings.TrimSpace(baseDir), identityDirName, manifestSigningPubFileName)
+}
+
+// LoadManifestSigningKey returns the persisted signing key, or (nil, nil) when
+// none has been generated yet.
+func LoadManifestSigningKey(baseDir string) (*ecdsa.PrivateKey, error) {
+	baseDir = strings.TrimSpace(baseDir)
+	if baseDir == "" {
+		return nil, nil
+	}
+	data, err := readFileUnderRoot(filepath.Join(baseDir, identityDirName), manifestSigningKeyFileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("manifest signing key %s is not a PEM private key", ManifestSigningKeyPath(baseDir))
+	}
+	keyAny, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest signing key: %w", err)
+	}
+	key, ok := keyAny.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("manifest signing key %s is not ECDSA", ManifestSigningKeyPath(baseDir))
+	}
+	return key, nil
+}
+
+// LoadManifestSigningPublicKey returns the public half of the local signing
+// key, or (nil, nil) when none has been generated yet.
+func LoadManifestSigningPublicKey(baseDir string) (*ecdsa.PublicKey, error) {
+	key, err := LoadManifestSigningKey(baseDir)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return &key.PublicKey, nil
+}
+
+// LoadOrCreateManifestSigningKey returns the per-host ECDSA P-256 key used to
+// sign backup manifests, generating it on first use. The private key is stored
+// through the immutable identity mechanism (0600 + chattr +i); the public key
+// is (re)exported next to it so it can be copied to the hosts that restore
+// these backups.
+func LoadOrCreateManifestSigningKey(ctx context.Context, baseDir string, logger *logging.Logger) (*ecdsa.PrivateKey, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	baseDir = strings.TrimSpace(baseDir)
+	if baseDir == "" {
+		return nil, fmt.Errorf("base directory is empty; cannot load manifest signing key")
+	}
+	key, err := LoadManifestSigningKey(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		dir := filepath.Join(baseDir, identityDirName)
+		if err := os.MkdirAll(dir, 0o750); err != nil { // same mode as Detect
+			return nil, fmt.Errorf("failed to create identity directory %s: %w", dir, err)
+		}
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate manifest signing key: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("encode manifest signing key: %w", err)
+		}
+		path := ManifestSigningKeyPath(baseDir)
+		logDebug(logger, "Identity: generating manifest signing key %s", path)
+		content := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		if err := writeIdentityFileWithContext(ctx, path, content, logger); err != nil {
+			return nil, err
+		}
+	}
+	if err := exportManifestSigningPublicKey(baseDir, &key.PublicKey); err != nil {
+		logWarning(logger, "Identity: failed to export manifest signing public key: %v", err)
+	}
+	return key, nil
+}
+
+func exportManifestSigningPublicKey(baseDir string, pub *ecdsa.PublicKey) error {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	content := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	path := ManifestSigningPublicKeyPath(baseDir)
+	if current, err := readFileUnderRoot(filepath.Dir(path), manifestSigningPubFileName); err == nil && string(current) == string(content) {
+		return nil
+	}
+	return atomicWriteIdentityFile(path, content, 0o644)
+}
@@ -0,0 +1,77 @@
+package identity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// noImmutableIdentityFiles skips chattr +i so the test's TempDir can be removed.
+func noImmutableIdentityFiles(t *testing.T) {
+	t.Helper()
+	orig := writeIdentityFileWithContextSetImmutable
+	writeIdentityFileWithContextSetImmutable = func(context.Context, string, bool, *logging.Logger) error { return nil }
+	t.Cleanup(func() { writeIdentityFileWithContextSetImmutable = orig })
+}
+
+func TestLoadOrCreateManifestSigningKey(t *testing.T) {
+	noImmutableIdentityFiles(t)
+	baseDir := t.TempDir()
+
+	if key, err := LoadManifestSigningKey(baseDir); err != nil || key != nil {
+		t.Fatalf("LoadManifestSigningKey before creation = %v, %v", key, err)
+	}
+
+	key, err := LoadOrCreateManifestSigningKey(context.Background(), baseDir, nil)
+	if err != nil {
+		t.Fatalf("LoadOrCreateManifestSigningKey: %v", err)
+	}
+	info, err := os.Stat(ManifestSigningKeyPath(baseDir))
+	if err != nil {
+		t.Fatalf("stat signing key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("signing key mode = %o, want 0600", perm)
+	}
+
+	again, err := LoadOrCreateManifestSigningKey(context.Background(), baseDir, nil)
+	if err != nil || !again.Equal(key) {
+		t.Fatalf("second load returned a different key (err %v)", err)
+	}
+
+	pubPEM, err := os.ReadFile(ManifestSigningPublicKeyPath(baseDir))
+	if err != nil {
+		t.Fatalf("read exported public key: %v", err)
+	}
+	pub, err := backup.ParseManifestPublicKey(pubPEM)
+	if err != nil || !pub.Equal(&key.PublicKey) {
+		t.Fatalf("exported public key = %v, %v", pub, err)
+	}
+	local, err := LoadManifestSigningPublicKey(baseDir)
+	if err != nil || !local.Equal(&key.PublicKey) {
+		t.Fatalf("LoadManifestSigningPublicKey = %v, %v", local, err)
+	}
+	if got := ManifestSigningPublicKeyPath(baseDir); got != filepath.Join(baseDir, "identity", "manifest_signing.pub") {
+		t.Fatalf("ManifestSigningPublicKeyPath = %q", got)
+	}
+}
+
+func TestLoadManifestSigningKeyRejectsGarbage(t *testing.T) {
+	baseDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(baseDir, "identity"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ManifestSigningKeyPath(baseDir), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOrCreateManifestSigningKey(context.Background(), baseDir, nil); err == nil {
+		t.Fatal("a corrupt signing key must not be silently replaced")
+	}
+	if _, err := LoadOrCreateManifestSigningKey(context.Background(), " ", nil); err == nil {
+		t.Fatal("expected error for empty base directory")
+	}
+}
@@ -11,30 +11,107 @@ import (
 )
 
 // BackupMetrics represents the subset of backup statistics exported as Prometheus metrics.
+// The JSON form is what fleet agents push to the collector (internal/fleet).
 type BackupMetrics struct {
-	Hostname       string
-	ProxmoxType    string
-	ProxmoxVersion string
-	ScriptVersion  string
-
-	StartTime time.Time
-	EndTime   time.Time
-	Duration  time.Duration
-
-	Failed       bool // the backup run itself failed (runErr != nil); authoritative for status
-	ExitCode     int
-	ErrorCount   int
-	WarningCount int
+	Hostname       string `json:"hostname"`
+	ProxmoxType    string `json:"proxmox_type"`
+	ProxmoxVersion string `json:"proxmox_version"`
+	ScriptVersion  string `json:"script_version"`
+
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Duration  time.Duration `json:"duration_ns"`
+
+	Failed       bool `json:"failed"` // the backup run itself failed (runErr != nil); authoritative for status
+	ExitCode     int  `json:"exit_code"`
+	ErrorCount   int  `json:"error_count"`
+	WarningCount int  `json:"warning_count"`
 	// NotifyCount tallies notification/communication failures: warning-weight for the
 	// status (never escalate to error), so a notify-only run is status 1, not 2.
-	NotifyCount    int
-	LocalBackups   int
-	SecBackups     int
-	CloudBackups   int
-	BytesCollected int64
-	ArchiveSize    int64
-	FilesCollected int
-	FilesFailed    int
+	NotifyCount    int   `json:"notify_count"`
+	LocalBackups   int   `json:"local_backups"`
+	SecBackups     int   `json:"secondary_backups"`
+	CloudBackups   int   `json:"cloud_backups"`
+	BytesCollected int64 `json:"bytes_collected"`
+	ArchiveSize    int64 `json:"archive_size"`
+	FilesCollected int   `json:"files_collected"`
+	FilesFailed    int   `json:"files_failed"`
+
+	// GuestCoverage is the PVE guest backup coverage audit; nil when it did not run.
+	GuestCoverage *GuestCoverageMetrics `json:"guest_coverage,omitempty"`
+
+	// CapacityForecasts is the projected fill-up per storage target; empty when
+	// the forecast is disabled or no target had enough history.
+	CapacityForecasts []CapacityForecastMetrics `json:"capacity_forecasts,omitempty"`
+
+	// CertificateExpiry lists the PVE/PBS web UI/API certificates; empty when the
+	// certificate expiry audit is disabled or found no certificate.
+	CertificateExpiry []CertificateExpiryMetrics `json:"certificate_expiry,omitempty"`
+}
+
+// CertificateExpiryMetrics is the expiry date of one certificate file.
+type CertificateExpiryMetrics struct {
+	Name     string    `json:"name"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// CapacityForecastMetrics is the capacity forecast of one storage target.
+// DaysUntilFull is -1 when the target does not fill within the forecast horizon.
+type CapacityForecastMetrics struct {
+	Location         string `json:"location"`
+	DaysUntilFull    int    `json:"days_until_full"`
+	SteadyStateBytes int64  `json:"steady_state_bytes"`
+}
+
+// GuestCoverageMetrics summarises which PVE guests are protected by vzdump jobs.
+type GuestCoverageMetrics struct {
+	Guests             int               `json:"guests"`
+	UncoveredGuests    int               `json:"uncovered_guests"`
+	StaleGuests        int               `json:"stale_guests"`
+	Jobs               int               `json:"jobs"`
+	FailedJobs         int               `json:"failed_jobs"`
+	OfflineStorageJobs int               `json:"offline_storage_jobs"`
+	LastBackups        []GuestLastBackup `json:"last_backups,omitempty"`
+}
+
+// GuestLastBackup is the newest vzdump archive found for a guest.
+type GuestLastBackup struct {
+	VMID string    `json:"vmid"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+// Status values of proxmox_backup_status.
+const (
+	StatusSuccess = 0
+	StatusWarning = 1
+	StatusError   = 2
+)
+
+// Status classifies the run as StatusSuccess, StatusWarning or StatusError.
+//
+// A genuinely FAILED backup run (m.Failed, set from runErr != nil) is authoritative ->
+// error, even when the terminal [ERROR] line was not yet counted at export time and only
+// warnings were logged (F11-02: keying off counts+exit-code alone masked such a failure
+// as a warning, because the generic failure exit code collides with the warning-only
+// promotion code). A warning-only run is NOT a failure (m.Failed == false): it is
+// promoted to a non-zero (generic) exit code upstream but must stay status=1, not 2
+// (PS-BH-004). Notification/communication errors never set m.Failed, so they never
+// escalate a run to error. A non-zero exit code with no failure and no counted
+// errors/warnings (e.g. an early abort) still maps to error.
+func (m *BackupMetrics) Status() int {
+	switch {
+	case m.Failed || m.ErrorCount > 0:
+		return StatusError
+	case m.WarningCount > 0 || m.NotifyCount > 0:
+		// A notification/communication failure is warning-weight: it must keep the run
+		// at status 1 and, crucially, be checked BEFORE the non-zero-exit-code fallback
+		// below (a notify-only run carries the generic exit code) so it never reads as error.
+		return StatusWarning
+	case m.ExitCode != 0:
+		return StatusError
+	}
+	return StatusSuccess
 }
 
 // PrometheusExporter writes backup metrics in Prometheus textfile format for node_exporter.
@@ -130,28 +207,8 @@ func (pe *PrometheusExporter) Export(m *BackupMetrics) (err error) {
 		endTs = float64(m.StartTime.Unix() + int64(m.Duration.Seconds()))
 	}
 
-	// Status gauge: 0=success, 1=warning, 2=error. A genuinely FAILED backup run
-	// (m.Failed, set from runErr != nil) is authoritative -> error, even when the
-	// terminal [ERROR] line was not yet counted at export time and only warnings were
-	// logged (F11-02: keying off counts+exit-code alone masked such a failure as a
-	// warning, because the generic failure exit code collides with the warning-only
-	// promotion code). A warning-only run is NOT a failure (m.Failed == false): it is
-	// promoted to a non-zero (generic) exit code upstream but must stay status=1, not 2
-	// (PS-BH-004). Notification/communication errors never set m.Failed, so they never
-	// escalate a run to error. A non-zero exit code with no failure and no counted
-	// errors/warnings (e.g. an early abort) still maps to error.
-	status := 0
-	switch {
-	case m.Failed || m.ErrorCount > 0:
-		status = 2
-	case m.WarningCount > 0 || m.NotifyCount > 0:
-		// A notification/communication failure is warning-weight: it must keep the run
-		// at status 1 and, crucially, be checked BEFORE the non-zero-exit-code fallback
-		// below (a notify-only run carries the generic exit code) so it never reads as error.
-		status = 1
-	case m.ExitCode != 0:
-		status = 2
-	}
+	// Status gauge: 0=success, 1=warning, 2=error (see BackupMetrics.Status).
+	status := m.Status()
 
 	// Core metrics
 	if err := writeMetric(
@@ -270,6 +327,83 @@ func (pe *PrometheusExporter) Export(m *BackupMetrics) (err error) {
 		return err
 	}
 
+	if cov := m.GuestCoverage; cov != nil {
+		gauges := []struct {
+			name  string
+			help  string
+			value int
+		}{
+			{"proxmox_backup_guests_total", "Number of PVE guests (templates excluded) seen by the coverage audit", cov.Guests},
+			{"proxmox_backup_guests_uncovered", "Number of PVE guests not selected by any enabled vzdump job", cov.UncoveredGuests},
+			{"proxmox_backup_guests_stale", "Number of PVE guests whose newest vzdump is older than the configured maximum age", cov.StaleGuests},
+			{"proxmox_backup_vzdump_jobs_total", "Number of vzdump backup jobs defined", cov.Jobs},
+			{"proxmox_backup_vzdump_jobs_failed", "Number of vzdump jobs whose last run failed", cov.FailedJobs},
+			{"proxmox_backup_vzdump_jobs_offline_storage", "Number of enabled vzdump jobs targeting an offline storage", cov.OfflineStorageJobs},
+		}
+		for _, g := range gauges {
+			if err := writeMetric(g.name, "gauge", g.help, fmt.Sprintf("%s %d", g.name, g.value)); err != nil {
+				return err
+			}
+		}
+
+		if len(cov.LastBackups) > 0 {
+			if err := writef("# HELP proxmox_backup_guest_last_backup_timestamp_seconds Unix timestamp of the newest vzdump archive per guest\n"); err != nil {
+				return err
+			}
+			if err := writef("# TYPE proxmox_backup_guest_last_backup_timestamp_seconds gauge\n"); err != nil {
+				return err
+			}
+			for _, b := range cov.LastBackups {
+				if err := writef("proxmox_backup_guest_last_backup_timestamp_seconds{vmid=%q,type=%q} %d\n", b.VMID, b.Type, b.Time.Unix()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(m.CapacityForecasts) > 0 {
+		if err := writef("# HELP proxsave_storage_days_until_full Projected days until the storage target can no longer fit a backup (+Inf when not within the forecast horizon)\n"); err != nil {
+			return err
+		}
+		if err := writef("# TYPE proxsave_storage_days_until_full gauge\n"); err != nil {
+			return err
+		}
+		for _, f := range m.CapacityForecasts {
+			days := "+Inf"
+			if f.DaysUntilFull >= 0 {
+				days = fmt.Sprintf("%d", f.DaysUntilFull)
+			}
+			if err := writef("proxsave_storage_days_until_full{location=%q} %s\n", f.Location, days); err != nil {
+				return err
+			}
+		}
+		if err := writef("# HELP proxsave_storage_projected_retained_bytes Projected size of the retained backups once the retention policy settles\n"); err != nil {
+			return err
+		}
+		if err := writef("# TYPE proxsave_storage_projected_retained_bytes gauge\n"); err != nil {
+			return err
+		}
+		for _, f := range m.CapacityForecasts {
+			if err := writef("proxsave_storage_projected_retained_bytes{location=%q} %d\n", f.Location, f.SteadyStateBytes); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(m.CertificateExpiry) > 0 {
+		if err := writef("# HELP proxsave_cert_expiry_seconds Unix timestamp at which the PVE/PBS web UI/API certificate expires\n"); err != nil {
+			return err
+		}
+		if err := writef("# TYPE proxsave_cert_expiry_seconds gauge\n"); err != nil {
+			return err
+		}
+		for _, c := range m.CertificateExpiry {
+			if err := writef("proxsave_cert_expiry_seconds{certificate=%q} %d\n", c.Name, c.NotAfter.Unix()); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Static info metric with labels
 	if err := writef("# HELP proxmox_backup_info Static information about this backup instance\n"); err != nil {
 		return err
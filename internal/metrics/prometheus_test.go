@@ -68,6 +68,121 @@ func TestPrometheusExporterExport(t *testing.T) {
 	}
 }
 
+func TestPrometheusExporterGuestCoverage(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewPrometheusExporter(dir, nil)
+
+	if err := exporter.Export(&BackupMetrics{Hostname: "pve1"}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "proxmox_backup.prom"))
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	if strings.Contains(string(data), "proxmox_backup_guests_total") {
+		t.Fatalf("guest coverage metrics must be omitted without an audit\n%s", data)
+	}
+
+	m := &BackupMetrics{
+		Hostname: "pve1",
+		GuestCoverage: &GuestCoverageMetrics{
+			Guests:             4,
+			UncoveredGuests:    1,
+			StaleGuests:        2,
+			Jobs:               3,
+			FailedJobs:         1,
+			OfflineStorageJobs: 1,
+			LastBackups: []GuestLastBackup{
+				{VMID: "100", Type: "qemu", Time: time.Unix(1700000000, 0)},
+				{VMID: "200", Type: "lxc", Time: time.Unix(1700003600, 0)},
+			},
+		},
+	}
+	if err := exporter.Export(m); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "proxmox_backup.prom"))
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	content := string(data)
+	for _, expected := range []string{
+		"proxmox_backup_guests_total 4",
+		"proxmox_backup_guests_uncovered 1",
+		"proxmox_backup_guests_stale 2",
+		"proxmox_backup_vzdump_jobs_total 3",
+		"proxmox_backup_vzdump_jobs_failed 1",
+		"proxmox_backup_vzdump_jobs_offline_storage 1",
+		"proxmox_backup_guest_last_backup_timestamp_seconds{vmid=\"100\",type=\"qemu\"} 1700000000",
+		"proxmox_backup_guest_last_backup_timestamp_seconds{vmid=\"200\",type=\"lxc\"} 1700003600",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("metrics output missing %q\n%s", expected, content)
+		}
+	}
+}
+
+func TestPrometheusExporterCapacityForecasts(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewPrometheusExporter(dir, nil)
+
+	m := &BackupMetrics{
+		Hostname: "pve1",
+		CapacityForecasts: []CapacityForecastMetrics{
+			{Location: "local", DaysUntilFull: 9, SteadyStateBytes: 4096},
+			{Location: "secondary", DaysUntilFull: -1, SteadyStateBytes: 2048},
+		},
+	}
+	if err := exporter.Export(m); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "proxmox_backup.prom"))
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	content := string(data)
+	for _, expected := range []string{
+		"# TYPE proxsave_storage_days_until_full gauge",
+		"proxsave_storage_days_until_full{location=\"local\"} 9",
+		"proxsave_storage_days_until_full{location=\"secondary\"} +Inf",
+		"proxsave_storage_projected_retained_bytes{location=\"local\"} 4096",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("metrics output missing %q\n%s", expected, content)
+		}
+	}
+}
+
+func TestPrometheusExporterCertificateExpiry(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewPrometheusExporter(dir, nil)
+
+	m := &BackupMetrics{
+		Hostname: "pve1",
+		CertificateExpiry: []CertificateExpiryMetrics{
+			{Name: "pve-ssl.pem", NotAfter: time.Unix(1809000000, 0)},
+			{Name: "proxy.pem", NotAfter: time.Unix(1777000000, 0)},
+		},
+	}
+	if err := exporter.Export(m); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "proxmox_backup.prom"))
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	content := string(data)
+	for _, expected := range []string{
+		"# TYPE proxsave_cert_expiry_seconds gauge",
+		"proxsave_cert_expiry_seconds{certificate=\"pve-ssl.pem\"} 1809000000",
+		"proxsave_cert_expiry_seconds{certificate=\"proxy.pem\"} 1777000000",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("metrics output missing %q\n%s", expected, content)
+		}
+	}
+}
+
 func TestPrometheusExporterNilMetrics(t *testing.T) {
 	dir := t.TempDir()
 	exporter := NewPrometheusExporter(dir, nil)
@@ -5,6 +5,8 @@ package notify
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/tis24dev/proxsave/internal/types"
@@ -89,17 +91,10 @@ type NotificationData struct {
 	LocalUsagePercent  float64
 
 	// Local retention info
-	LocalRetentionPolicy   string // "simple" or "gfs"
-	LocalRetentionLimit    int    // MAX_LOCAL_BACKUPS (simple mode)
-	LocalGFSDaily          int    // GFS limits
-	LocalGFSWeekly         int
-	LocalGFSMonthly        int
-	LocalGFSYearly         int
-	LocalGFSCurrentDaily   int // GFS current counts
-	LocalGFSCurrentWeekly  int
-	LocalGFSCurrentMonthly int
-	LocalGFSCurrentYearly  int
-	LocalBackups           int // Total current backups
+	LocalRetentionPolicy string          // "simple", "gfs" or "thinning"
+	LocalRetentionLimit  int             // MAX_LOCAL_BACKUPS (simple mode)
+	LocalRetentionTiers  []RetentionTier // kept backups per tier of the retention policy
+	LocalBackups         int             // Total current backups
 
 	SecondaryEnabled       bool
 	SecondaryStatus        string
@@ -112,17 +107,10 @@ type NotificationData struct {
 	SecondaryUsagePercent  float64
 
 	// Secondary retention info
-	SecondaryRetentionPolicy   string
-	SecondaryRetentionLimit    int
-	SecondaryGFSDaily          int
-	SecondaryGFSWeekly         int
-	SecondaryGFSMonthly        int
-	SecondaryGFSYearly         int
-	SecondaryGFSCurrentDaily   int
-	SecondaryGFSCurrentWeekly  int
-	SecondaryGFSCurrentMonthly int
-	SecondaryGFSCurrentYearly  int
-	SecondaryBackups           int
+	SecondaryRetentionPolicy string
+	SecondaryRetentionLimit  int
+	SecondaryRetentionTiers  []RetentionTier // kept backups per tier of the retention policy
+	SecondaryBackups         int
 
 	CloudEnabled       bool
 	CloudStatus        string
@@ -130,17 +118,32 @@ type NotificationData struct {
 	CloudCount         int
 
 	// Cloud retention info
-	CloudRetentionPolicy   string
-	CloudRetentionLimit    int
-	CloudGFSDaily          int
-	CloudGFSWeekly         int
-	CloudGFSMonthly        int
-	CloudGFSYearly         int
-	CloudGFSCurrentDaily   int
-	CloudGFSCurrentWeekly  int
-	CloudGFSCurrentMonthly int
-	CloudGFSCurrentYearly  int
-	CloudBackups           int
+	CloudRetentionPolicy string
+	CloudRetentionLimit  int
+	CloudRetentionTiers  []RetentionTier // kept backups per tier of the retention policy
+	CloudBackups         int
+
+	// Deferred cloud uploads (nil when CLOUD_UPLOAD_WINDOW is unset and nothing is queued)
+	CloudUploadQueue *CloudUploadQueueSummary
+
+	// Storage capacity forecast per target (empty when disabled or without enough history)
+	CapacityForecasts []CapacityForecastSummary
+
+	// PBS datastore content health (PBS hosts only; nil when not collected)
+	PBSSnapshots *PBSSnapshotSummary
+
+	// PVE guest backup coverage audit (PVE hosts only; nil when not collected)
+	PVEGuestCoverage *PVEGuestCoverageSummary
+
+	// Web UI/API certificate expiry (PVE/PBS hosts only; nil when CERT_EXPIRY_WARN_DAYS=0)
+	CertificateExpiry *CertificateExpirySummary
+
+	// Hardening audit of the host (nil when SECURITY_HARDENING_AUDIT is disabled)
+	Hardening *HardeningSummary
+
+	// Daily fleet digest from the fleet collector; when set the notification is the
+	// digest, not a backup report
+	FleetDigest *FleetDigestSummary
 
 	// Email notification status (for Telegram messages)
 	EmailStatus    string
@@ -167,6 +170,225 @@ type NotificationData struct {
 	LatestVersion       string
 }
 
+// CloudUploadQueueSummary reports the cloud uploads waiting for the next
+// CLOUD_UPLOAD_WINDOW opening. Oldest and NextWindow are nil when not applicable.
+type CloudUploadQueueSummary struct {
+	Pending    int        `json:"pending"`
+	Window     string     `json:"window"`
+	Oldest     *time.Time `json:"oldest,omitempty"`
+	NextWindow *time.Time `json:"next_window,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// Describe renders the queue as one line, e.g. "2 pending, oldest 2026-07-04 14:05,
+// next window 2026-07-04 22:00 (mon-fri 22:00-06:00)".
+func (s *CloudUploadQueueSummary) Describe() string {
+	if s == nil {
+		return ""
+	}
+	parts := []string{fmt.Sprintf("%d pending", s.Pending)}
+	if s.Oldest != nil {
+		parts = append(parts, "oldest "+s.Oldest.Format("2006-01-02 15:04"))
+	}
+	if s.NextWindow != nil {
+		parts = append(parts, "next window "+s.NextWindow.Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(parts, ", "), s.Window)
+}
+
+// RetentionTier is one tier of a retention policy (a GFS category such as
+// "daily", a thinning rule such as "7d:6h", or "keep" for simple retention)
+// with the backups it currently keeps and its limit (0 = no fixed limit).
+type RetentionTier struct {
+	Name  string `json:"name"`
+	Kept  int    `json:"kept"`
+	Limit int    `json:"limit"`
+}
+
+// DescribeRetentionTiers renders tiers as one line, e.g. "daily 7/7, weekly 4/4,
+// yearly 2" or "48h:all 12, 7d:6h 20".
+func DescribeRetentionTiers(tiers []RetentionTier) string {
+	parts := make([]string, 0, len(tiers))
+	for _, t := range tiers {
+		if t.Limit > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d/%d", t.Name, t.Kept, t.Limit))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", t.Name, t.Kept))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CapacityForecastSummary is the projected fill-up of one storage target.
+// DaysUntilFull is -1 when the target does not fill within HorizonDays; Warning
+// is set when it fills within CAPACITY_FORECAST_WARN_DAYS.
+type CapacityForecastSummary struct {
+	Location         string `json:"location"`
+	DaysUntilFull    int    `json:"days_until_full"`
+	HorizonDays      int    `json:"horizon_days"`
+	AvailableBytes   int64  `json:"available_bytes"`
+	SteadyStateBytes int64  `json:"steady_state_bytes"`
+	Warning          bool   `json:"warning"`
+}
+
+// Describe renders the forecast as one line, e.g. "full in ~9 days (12.0 GiB
+// free, retention settles at 30.5 GiB)".
+func (s CapacityForecastSummary) Describe() string {
+	when := fmt.Sprintf("not full within %d days", s.HorizonDays)
+	switch {
+	case s.DaysUntilFull == 0:
+		when = "full at the next backup"
+	case s.DaysUntilFull == 1:
+		when = "full in ~1 day"
+	case s.DaysUntilFull > 1:
+		when = fmt.Sprintf("full in ~%d days", s.DaysUntilFull)
+	}
+	return fmt.Sprintf("%s (%s free, retention settles at %s)",
+		when, formatForecastBytes(s.AvailableBytes), formatForecastBytes(s.SteadyStateBytes))
+}
+
+func formatForecastBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// PBSSnapshotSummary reports the content of the PBS datastores on the backed-up host:
+// group/snapshot counts, snapshots not verified within VerifyMaxAgeDays, snapshots
+// whose last verification failed and groups with no snapshot within StaleAfterDays.
+type PBSSnapshotSummary struct {
+	Datastores          int      `json:"datastores"`
+	Namespaces          int      `json:"namespaces"`
+	Groups              int      `json:"groups"`
+	Snapshots           int      `json:"snapshots"`
+	UnverifiedSnapshots int      `json:"unverified_snapshots"`
+	FailedSnapshots     int      `json:"failed_snapshots"`
+	StaleGroups         int      `json:"stale_groups"`
+	VerifyMaxAgeDays    int      `json:"verify_max_age_days"`
+	StaleAfterDays      int      `json:"stale_after_days"`
+	StaleGroupNames     []string `json:"stale_group_names,omitempty"`
+}
+
+// HasIssues reports whether the summary contains failed, unverified or stale entries.
+func (s *PBSSnapshotSummary) HasIssues() bool {
+	return s != nil && (s.UnverifiedSnapshots > 0 || s.FailedSnapshots > 0 || s.StaleGroups > 0)
+}
+
+// StaleGroupsPreview returns up to limit stale group names, noting how many were omitted.
+func (s *PBSSnapshotSummary) StaleGroupsPreview(limit int) string {
+	if s == nil {
+		return ""
+	}
+	return previewNames(s.StaleGroupNames, limit)
+}
+
+// PVEGuestCoverageSummary reports how well the guests of a PVE node are protected
+// by vzdump jobs: guests selected by no enabled job, guests without a backup within
+// MaxAgeDays, jobs whose last run failed and jobs targeting an offline storage.
+type PVEGuestCoverageSummary struct {
+	Guests                 int      `json:"guests"`
+	UncoveredGuests        int      `json:"uncovered_guests"`
+	StaleGuests            int      `json:"stale_guests"`
+	Jobs                   int      `json:"jobs"`
+	FailedJobs             int      `json:"failed_jobs"`
+	OfflineStorageJobs     int      `json:"offline_storage_jobs"`
+	MaxAgeDays             int      `json:"max_age_days"`
+	UncoveredGuestNames    []string `json:"uncovered_guest_names,omitempty"`
+	StaleGuestNames        []string `json:"stale_guest_names,omitempty"`
+	FailedJobNames         []string `json:"failed_job_names,omitempty"`
+	OfflineStorageJobNames []string `json:"offline_storage_job_names,omitempty"`
+}
+
+// HasIssues reports whether any guest or job needs attention.
+func (s *PVEGuestCoverageSummary) HasIssues() bool {
+	return s != nil && (s.UncoveredGuests > 0 || s.StaleGuests > 0 || s.FailedJobs > 0 || s.OfflineStorageJobs > 0)
+}
+
+// CertificateExpirySummary reports the remaining lifetime of the node web UI/API
+// certificates, how many expired or expire within WarnDays, and the ACME renewal
+// configuration of the node (empty when ACME is not configured).
+type CertificateExpirySummary struct {
+	WarnDays     int                     `json:"warn_days"`
+	Expired      int                     `json:"expired"`
+	ExpiringSoon int                     `json:"expiring_soon"`
+	Unreadable   []string                `json:"unreadable,omitempty"`
+	Certificates []CertificateExpiryInfo `json:"certificates"`
+	ACME         []string                `json:"acme,omitempty"`
+}
+
+// CertificateExpiryInfo is the expiry of a single certificate file.
+type CertificateExpiryInfo struct {
+	Name          string    `json:"name"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	Expired       bool      `json:"expired"`
+	ACMEManaged   bool      `json:"acme_managed"`
+}
+
+// HasIssues reports whether a certificate expired, expires soon or could not be read.
+func (s *CertificateExpirySummary) HasIssues() bool {
+	return s != nil && (s.Expired > 0 || s.ExpiringSoon > 0 || len(s.Unreadable) > 0)
+}
+
+// Describe renders the expiry of a certificate, e.g. "expires 2026-06-01 (30 days)".
+func (c CertificateExpiryInfo) Describe() string {
+	date := c.NotAfter.Format("2006-01-02")
+	if c.Expired {
+		return "EXPIRED on " + date
+	}
+	return fmt.Sprintf("expires %s (%d days)", date, c.DaysRemaining)
+}
+
+// HardeningSummary is the scored result of the host hardening audit: the score is
+// the share of the applicable rule weight that passed (0-100) and FailedRules lists
+// the failed rule IDs, high severity first.
+type HardeningSummary struct {
+	Score       int      `json:"score"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Skipped     int      `json:"skipped"`
+	FailedHigh  int      `json:"failed_high"`
+	FailedRules []string `json:"failed_rules,omitempty"`
+}
+
+// HasIssues reports whether any hardening rule failed.
+func (s *HardeningSummary) HasIssues() bool {
+	return s != nil && s.Failed > 0
+}
+
+// FleetDigestSummary is the fleet collector's daily digest: Headline is the
+// one-line summary and Text the per-host report rendered as the message body.
+type FleetDigestSummary struct {
+	Headline string `json:"headline"`
+	Text     string `json:"text"`
+}
+
+// describeACME renders the ACME renewal settings of a certificate summary.
+func describeACME(settings []string) string {
+	if len(settings) == 0 {
+		return "not configured"
+	}
+	return strings.Join(settings, "; ")
+}
+
+// previewNames joins up to limit names, noting how many were omitted.
+func previewNames(names []string, limit int) string {
+	if len(names) == 0 {
+		return ""
+	}
+	if limit <= 0 || len(names) <= limit {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(names[:limit], ", "), len(names)-limit)
+}
+
 // LogCategory represents a normalized log issue classification.
 type LogCategory struct {
 	Label   string `json:"label"`
@@ -237,6 +459,10 @@ func GetStorageEmoji(status string) string {
 		return "❌"
 	case "disabled", "skipped":
 		return "➖"
+	case "queued":
+		return "⏳"
+	case "pending":
+		return "🔄"
 	default:
 		return "❓"
 	}
@@ -172,6 +172,205 @@ func TestBuildEmailHTMLEscapesHeaderStorageAndFooterValues(t *testing.T) {
 	}
 }
 
+func TestEmailTemplatesIncludePBSSnapshotSummary(t *testing.T) {
+	data := createTestNotificationData()
+	if strings.Contains(BuildEmailPlainText(data), "PBS DATASTORE CONTENT") {
+		t.Fatal("PBS content section must be omitted without a summary")
+	}
+
+	data.PBSSnapshots = &PBSSnapshotSummary{
+		Datastores:          2,
+		Namespaces:          3,
+		Groups:              12,
+		Snapshots:           240,
+		UnverifiedSnapshots: 4,
+		FailedSnapshots:     1,
+		StaleGroups:         2,
+		VerifyMaxAgeDays:    30,
+		StaleAfterDays:      2,
+		StaleGroupNames:     []string{"store1:vm/100", "store2:prod/ct/<201>"},
+	}
+
+	plain := BuildEmailPlainText(data)
+	for _, piece := range []string{"PBS DATASTORE CONTENT:", "Groups: 12, Snapshots: 240", "Not verified within 30 days: 4", "store1:vm/100"} {
+		if !strings.Contains(plain, piece) {
+			t.Fatalf("plain text missing %q\n%s", piece, plain)
+		}
+	}
+
+	html := BuildEmailHTML(data)
+	if !strings.Contains(html, "PBS Datastore Content") || !strings.Contains(html, "store2:prod/ct/&lt;201&gt;") {
+		t.Fatalf("HTML missing PBS content section or escaped stale group:\n%s", html)
+	}
+}
+
+func TestEmailTemplatesIncludeRetentionTiers(t *testing.T) {
+	data := createTestNotificationData()
+	if strings.Contains(BuildEmailPlainText(data), "Retention local:") {
+		t.Fatal("retention tiers must be omitted when not reported")
+	}
+
+	data.LocalRetentionTiers = []RetentionTier{
+		{Name: "48h:all", Kept: 12},
+		{Name: "7d:6h", Kept: 20},
+	}
+	data.CloudEnabled = false
+	data.CloudRetentionTiers = []RetentionTier{{Name: "daily", Kept: 7, Limit: 7}}
+
+	plain := BuildEmailPlainText(data)
+	if !strings.Contains(plain, "Retention local: 48h:all 12, 7d:6h 20") {
+		t.Fatalf("plain text missing local retention tiers\n%s", plain)
+	}
+	if strings.Contains(plain, "Retention cloud:") {
+		t.Fatalf("retention tiers of a disabled storage rendered\n%s", plain)
+	}
+	if html := BuildEmailHTML(data); !strings.Contains(html, "Retention (local)") {
+		t.Fatalf("HTML missing retention row:\n%s", html)
+	}
+	if got := DescribeRetentionTiers([]RetentionTier{{Name: "daily", Kept: 3, Limit: 7}, {Name: "yearly", Kept: 2}}); got != "daily 3/7, yearly 2" {
+		t.Fatalf("DescribeRetentionTiers() = %q", got)
+	}
+}
+
+func TestPBSSnapshotSummaryHelpers(t *testing.T) {
+	var nilSummary *PBSSnapshotSummary
+	if nilSummary.HasIssues() || nilSummary.StaleGroupsPreview(3) != "" {
+		t.Fatal("nil summary must report no issues")
+	}
+	s := &PBSSnapshotSummary{StaleGroups: 3, StaleGroupNames: []string{"a", "b", "c"}}
+	if !s.HasIssues() {
+		t.Fatal("expected stale groups to count as issues")
+	}
+	if got := s.StaleGroupsPreview(2); got != "a, b (+1 more)" {
+		t.Fatalf("StaleGroupsPreview = %q", got)
+	}
+}
+
+func TestEmailTemplatesIncludePVEGuestCoverage(t *testing.T) {
+	data := createTestNotificationData()
+	if strings.Contains(BuildEmailPlainText(data), "PVE GUEST BACKUP COVERAGE") {
+		t.Fatal("coverage section must be omitted without a summary")
+	}
+
+	data.PVEGuestCoverage = &PVEGuestCoverageSummary{
+		Guests:                 8,
+		UncoveredGuests:        1,
+		StaleGuests:            1,
+		Jobs:                   2,
+		FailedJobs:             1,
+		OfflineStorageJobs:     1,
+		MaxAgeDays:             2,
+		UncoveredGuestNames:    []string{"200 (dns)"},
+		StaleGuestNames:        []string{"101 (db)"},
+		FailedJobNames:         []string{"backup-daily"},
+		OfflineStorageJobNames: []string{"backup-weekly (nfs)"},
+	}
+
+	plain := BuildEmailPlainText(data)
+	for _, piece := range []string{"PVE GUEST BACKUP COVERAGE:", "Guests: 8, Backup Jobs: 2", "Without a backup in 2 days: 1", "200 (dns)", "backup-weekly (nfs)"} {
+		if !strings.Contains(plain, piece) {
+			t.Fatalf("plain text missing %q\n%s", piece, plain)
+		}
+	}
+
+	html := BuildEmailHTML(data)
+	if !strings.Contains(html, "PVE Guest Backup Coverage") || !strings.Contains(html, "backup-daily") {
+		t.Fatalf("HTML missing coverage section:\n%s", html)
+	}
+}
+
+func TestPVEGuestCoverageSummaryHasIssues(t *testing.T) {
+	var nilSummary *PVEGuestCoverageSummary
+	if nilSummary.HasIssues() {
+		t.Fatal("nil summary must report no issues")
+	}
+	if (&PVEGuestCoverageSummary{Guests: 3, Jobs: 1}).HasIssues() {
+		t.Fatal("clean summary must report no issues")
+	}
+	if !(&PVEGuestCoverageSummary{OfflineStorageJobs: 1}).HasIssues() {
+		t.Fatal("expected offline storage jobs to count as issues")
+	}
+}
+
+func TestEmailTemplatesIncludeHardening(t *testing.T) {
+	data := createTestNotificationData()
+	if strings.Contains(BuildEmailPlainText(data), "HARDENING AUDIT") {
+		t.Fatal("hardening section must be omitted without a summary")
+	}
+
+	data.Hardening = &HardeningSummary{Score: 64, Passed: 6, Failed: 3, Skipped: 1, FailedHigh: 1, FailedRules: []string{"root_pam_tfa", "pam_password_quality", "ssh_max_auth_tries"}}
+	plain := BuildEmailPlainText(data)
+	for _, piece := range []string{"HARDENING AUDIT:", "Score: 64/100", "Passed: 6, Failed: 3 (high: 1), Skipped: 1", "root_pam_tfa, pam_password_quality"} {
+		if !strings.Contains(plain, piece) {
+			t.Fatalf("plain text missing %q\n%s", piece, plain)
+		}
+	}
+	html := BuildEmailHTML(data)
+	if !strings.Contains(html, "Hardening Audit") || !strings.Contains(html, "3 (high severity: 1)") {
+		t.Fatalf("HTML missing hardening section:\n%s", html)
+	}
+}
+
+func TestEmailTemplatesRenderFleetDigest(t *testing.T) {
+	data := createTestNotificationData()
+	data.FleetDigest = &FleetDigestSummary{
+		Headline: "Fleet digest: 2 hosts, 1 healthy, 1 failing, 0 stale, 0 short on space",
+		Text:     "Fleet digest: 2 hosts\n\nHOSTS NEEDING ATTENTION:\n  pbs1 (PBS, last report 2026-03-02 02:00)\n    - exit code 4 <disk>\n",
+	}
+	if subject := BuildEmailSubject(data); !strings.Contains(subject, "Fleet digest from") || strings.Contains(subject, "Backup on") {
+		t.Fatalf("subject = %q", subject)
+	}
+	plain := BuildEmailPlainText(data)
+	if !strings.Contains(plain, "pbs1 (PBS") || strings.Contains(plain, "BACKUP STATUS:") {
+		t.Fatalf("plain text must be the digest, not a backup report:\n%s", plain)
+	}
+	html := BuildEmailHTML(data)
+	if !strings.Contains(html, "Fleet Digest") || !strings.Contains(html, "exit code 4 &lt;disk&gt;") || strings.Contains(html, "Local Storage") {
+		t.Fatalf("HTML must render the escaped digest:\n%s", html)
+	}
+}
+
+func TestEmailTemplatesIncludeCertificateExpiry(t *testing.T) {
+	data := createTestNotificationData()
+	if strings.Contains(BuildEmailPlainText(data), "CERTIFICATES:") {
+		t.Fatal("certificate section must be omitted without a summary")
+	}
+
+	data.CertificateExpiry = &CertificateExpirySummary{
+		WarnDays:     30,
+		Expired:      1,
+		ExpiringSoon: 1,
+		Certificates: []CertificateExpiryInfo{
+			{Name: "pve-ssl.pem", NotAfter: time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC), DaysRemaining: 364},
+			{Name: "pveproxy-ssl.pem", NotAfter: time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC), DaysRemaining: 9, ACMEManaged: true},
+			{Name: "proxy.pem", NotAfter: time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), DaysRemaining: -2, Expired: true},
+		},
+		ACME: []string{"PVE: account prod (pve1.example.com)"},
+	}
+	plain := BuildEmailPlainText(data)
+	for _, piece := range []string{
+		"CERTIFICATES:",
+		"pve-ssl.pem: expires 2027-05-01 (364 days)",
+		"pveproxy-ssl.pem: expires 2026-05-11 (9 days) [ACME]",
+		"proxy.pem: EXPIRED on 2026-04-30",
+		"Expired: 1, Expiring within 30 days: 1",
+		"ACME renewal: PVE: account prod (pve1.example.com)",
+	} {
+		if !strings.Contains(plain, piece) {
+			t.Fatalf("plain text missing %q\n%s", piece, plain)
+		}
+	}
+	html := BuildEmailHTML(data)
+	if !strings.Contains(html, "<h2>Certificates</h2>") || !strings.Contains(html, "expires 2026-05-11 (9 days) (ACME)") {
+		t.Fatalf("HTML missing certificate section:\n%s", html)
+	}
+
+	data.CertificateExpiry.ACME = nil
+	if plain := BuildEmailPlainText(data); !strings.Contains(plain, "ACME renewal: not configured") {
+		t.Fatalf("plain text missing ACME fallback\n%s", plain)
+	}
+}
+
 func TestValueHelpers(t *testing.T) {
 	if got := valueOrNA(" "); got != "N/A" {
 		t.Fatalf("valueOrNA blank = %s, want N/A", got)
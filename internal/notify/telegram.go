@@ -524,6 +524,11 @@ func (t *TelegramNotifier) buildMessage(data *NotificationData) string {
 
 	// Header with status and hostname
 	statusEmoji := GetStatusEmoji(data.Status)
+	if d := data.FleetDigest; d != nil {
+		fmt.Fprintf(&msg, "%s Fleet digest - %s\n\n", statusEmoji, data.Hostname)
+		msg.WriteString(strings.TrimRight(d.Text, "\n"))
+		return msg.String()
+	}
 	fmt.Fprintf(&msg, "%s Backup %s - %s\n\n",
 		statusEmoji,
 		data.ProxmoxType.String(),
@@ -543,9 +548,17 @@ func (t *TelegramNotifier) buildMessage(data *NotificationData) string {
 	if data.CloudEnabled {
 		cloudEmoji := GetStorageEmoji(data.CloudStatus)
 		fmt.Fprintf(&msg, "%s Cloud      (%s backups)\n", cloudEmoji, data.CloudStatusSummary)
+		if q := data.CloudUploadQueue; q != nil && q.Pending > 0 {
+			fmt.Fprintf(&msg, "⏳ Upload queue: %s\n", q.Describe())
+		}
 	} else {
 		msg.WriteString("➖ Cloud      (disabled)\n")
 	}
+	for _, f := range data.CapacityForecasts {
+		if f.Warning {
+			fmt.Fprintf(&msg, "⚠️ %s capacity: %s\n", f.Location, f.Describe())
+		}
+	}
 
 	// Email status
 	emailEmoji := GetStorageEmoji(data.EmailStatus)
@@ -566,6 +579,81 @@ func (t *TelegramNotifier) buildMessage(data *NotificationData) string {
 	}
 	msg.WriteString("\n")
 
+	// PBS datastore content
+	if pbs := data.PBSSnapshots; pbs != nil {
+		pbsEmoji := "✅"
+		if pbs.HasIssues() {
+			pbsEmoji = "⚠️"
+		}
+		fmt.Fprintf(&msg, "%s PBS content: %d groups, %d snapshots\n", pbsEmoji, pbs.Groups, pbs.Snapshots)
+		if pbs.FailedSnapshots > 0 {
+			fmt.Fprintf(&msg, "🔹 Failed verifications: %d\n", pbs.FailedSnapshots)
+		}
+		if pbs.UnverifiedSnapshots > 0 {
+			fmt.Fprintf(&msg, "🔹 Not verified in %dd: %d\n", pbs.VerifyMaxAgeDays, pbs.UnverifiedSnapshots)
+		}
+		if pbs.StaleGroups > 0 {
+			fmt.Fprintf(&msg, "🔹 No backup in %dd: %s\n", pbs.StaleAfterDays, pbs.StaleGroupsPreview(3))
+		}
+		msg.WriteString("\n")
+	}
+
+	// PVE guest backup coverage
+	if cov := data.PVEGuestCoverage; cov != nil {
+		covEmoji := "✅"
+		if cov.HasIssues() {
+			covEmoji = "⚠️"
+		}
+		fmt.Fprintf(&msg, "%s Guest coverage: %d guests, %d backup jobs\n", covEmoji, cov.Guests, cov.Jobs)
+		if cov.UncoveredGuests > 0 {
+			fmt.Fprintf(&msg, "🔹 Not in any job: %s\n", previewNames(cov.UncoveredGuestNames, 3))
+		}
+		if cov.StaleGuests > 0 {
+			fmt.Fprintf(&msg, "🔹 No backup in %dd: %s\n", cov.MaxAgeDays, previewNames(cov.StaleGuestNames, 3))
+		}
+		if cov.FailedJobs > 0 {
+			fmt.Fprintf(&msg, "🔹 Failed jobs: %s\n", previewNames(cov.FailedJobNames, 3))
+		}
+		if cov.OfflineStorageJobs > 0 {
+			fmt.Fprintf(&msg, "🔹 Offline storage: %s\n", previewNames(cov.OfflineStorageJobNames, 3))
+		}
+		msg.WriteString("\n")
+	}
+
+	// Certificate expiry
+	if ce := data.CertificateExpiry; ce != nil {
+		ceEmoji := "✅"
+		if ce.HasIssues() {
+			ceEmoji = "⚠️"
+		}
+		fmt.Fprintf(&msg, "%s Certificates: %d checked\n", ceEmoji, len(ce.Certificates))
+		for _, cert := range ce.Certificates {
+			if cert.Expired {
+				fmt.Fprintf(&msg, "🔹 %s: expired\n", cert.Name)
+			} else {
+				fmt.Fprintf(&msg, "🔹 %s: %dd left\n", cert.Name, cert.DaysRemaining)
+			}
+		}
+		if len(ce.Unreadable) > 0 {
+			fmt.Fprintf(&msg, "🔹 Unreadable: %s\n", previewNames(ce.Unreadable, 3))
+		}
+		fmt.Fprintf(&msg, "🔹 ACME: %s\n", describeACME(ce.ACME))
+		msg.WriteString("\n")
+	}
+
+	// Hardening audit
+	if h := data.Hardening; h != nil {
+		hEmoji := "✅"
+		if h.HasIssues() {
+			hEmoji = "⚠️"
+		}
+		fmt.Fprintf(&msg, "%s Hardening score: %d/100\n", hEmoji, h.Score)
+		if h.Failed > 0 {
+			fmt.Fprintf(&msg, "🔹 Failed rules: %s\n", previewNames(h.FailedRules, 3))
+		}
+		msg.WriteString("\n")
+	}
+
 	// Backup metadata
 	fmt.Fprintf(&msg, "📅 Backup date: %s\n", data.BackupDate.Format("2006-01-02 15:04"))
 	fmt.Fprintf(&msg, "⏱️ Duration: %s\n\n", FormatDuration(data.BackupDuration))
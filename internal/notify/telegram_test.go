@@ -191,6 +191,129 @@ func TestTelegramBuildMessageIncludesUpdateInfo(t *testing.T) {
 	}
 }
 
+func TestTelegramBuildMessageIncludesPBSSnapshotSummary(t *testing.T) {
+	notifier, err := NewTelegramNotifier(TelegramConfig{
+		Enabled:  true,
+		Mode:     TelegramModePersonal,
+		BotToken: "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		ChatID:   "123456",
+	}, logging.New(types.LogLevelDebug, false))
+	if err != nil {
+		t.Fatalf("unexpected error creating notifier: %v", err)
+	}
+
+	data := createTestNotificationData()
+	data.PBSSnapshots = &PBSSnapshotSummary{
+		Groups:          5,
+		Snapshots:       50,
+		StaleGroups:     1,
+		StaleAfterDays:  2,
+		StaleGroupNames: []string{"store:ct/200"},
+	}
+
+	msg := notifier.buildMessage(data)
+	if !strings.Contains(msg, "⚠️ PBS content: 5 groups, 50 snapshots") {
+		t.Fatalf("expected PBS content line, got: %s", msg)
+	}
+	if !strings.Contains(msg, "No backup in 2d: store:ct/200") {
+		t.Fatalf("expected stale group line, got: %s", msg)
+	}
+}
+
+func TestTelegramBuildMessageIncludesPVEGuestCoverage(t *testing.T) {
+	notifier, err := NewTelegramNotifier(TelegramConfig{
+		Enabled:  true,
+		Mode:     TelegramModePersonal,
+		BotToken: "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		ChatID:   "123456",
+	}, logging.New(types.LogLevelDebug, false))
+	if err != nil {
+		t.Fatalf("unexpected error creating notifier: %v", err)
+	}
+
+	data := createTestNotificationData()
+	data.PVEGuestCoverage = &PVEGuestCoverageSummary{
+		Guests:         4,
+		Jobs:           1,
+		FailedJobs:     1,
+		FailedJobNames: []string{"backup-daily"},
+	}
+
+	msg := notifier.buildMessage(data)
+	if !strings.Contains(msg, "⚠️ Guest coverage: 4 guests, 1 backup jobs") {
+		t.Fatalf("expected guest coverage line, got: %s", msg)
+	}
+	if !strings.Contains(msg, "Failed jobs: backup-daily") {
+		t.Fatalf("expected failed job line, got: %s", msg)
+	}
+}
+
+func TestTelegramBuildMessageIncludesHardening(t *testing.T) {
+	notifier, err := NewTelegramNotifier(TelegramConfig{
+		Enabled:  true,
+		Mode:     TelegramModePersonal,
+		BotToken: "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		ChatID:   "123456",
+	}, logging.New(types.LogLevelDebug, false))
+	if err != nil {
+		t.Fatalf("unexpected error creating notifier: %v", err)
+	}
+
+	data := createTestNotificationData()
+	data.Hardening = &HardeningSummary{Score: 80, Passed: 8, Failed: 2, FailedRules: []string{"root_pam_tfa", "ssh_max_auth_tries"}}
+	msg := notifier.buildMessage(data)
+	if !strings.Contains(msg, "⚠️ Hardening score: 80/100") || !strings.Contains(msg, "Failed rules: root_pam_tfa, ssh_max_auth_tries") {
+		t.Fatalf("expected hardening lines, got: %s", msg)
+	}
+}
+
+func TestTelegramBuildMessageRendersFleetDigest(t *testing.T) {
+	notifier, err := NewTelegramNotifier(TelegramConfig{
+		Enabled:  true,
+		Mode:     TelegramModePersonal,
+		BotToken: "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		ChatID:   "123456",
+	}, logging.New(types.LogLevelDebug, false))
+	if err != nil {
+		t.Fatalf("unexpected error creating notifier: %v", err)
+	}
+
+	data := createTestNotificationData()
+	data.FleetDigest = &FleetDigestSummary{Headline: "Fleet digest: 2 hosts", Text: "Fleet digest: 2 hosts\n  pbs1 (PBS)\n    - exit code 4\n"}
+	msg := notifier.buildMessage(data)
+	if !strings.Contains(msg, "Fleet digest - "+data.Hostname) || !strings.Contains(msg, "- exit code 4") || strings.Contains(msg, "Included files") {
+		t.Fatalf("expected the digest instead of a backup report, got: %s", msg)
+	}
+}
+
+func TestTelegramBuildMessageIncludesCertificateExpiry(t *testing.T) {
+	notifier, err := NewTelegramNotifier(TelegramConfig{
+		Enabled:  true,
+		Mode:     TelegramModePersonal,
+		BotToken: "123456:ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		ChatID:   "123456",
+	}, logging.New(types.LogLevelDebug, false))
+	if err != nil {
+		t.Fatalf("unexpected error creating notifier: %v", err)
+	}
+
+	data := createTestNotificationData()
+	data.CertificateExpiry = &CertificateExpirySummary{
+		WarnDays: 30,
+		Expired:  1,
+		Certificates: []CertificateExpiryInfo{
+			{Name: "pve-ssl.pem", DaysRemaining: 364},
+			{Name: "proxy.pem", DaysRemaining: -1, Expired: true},
+		},
+	}
+	msg := notifier.buildMessage(data)
+	for _, want := range []string{"⚠️ Certificates: 2 checked", "🔹 pve-ssl.pem: 364d left", "🔹 proxy.pem: expired", "🔹 ACME: not configured"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q, got: %s", want, msg)
+		}
+	}
+}
+
 func TestTelegramSendCentralized(t *testing.T) {
 	logger := logging.New(types.LogLevelDebug, false)
 	data := createTestNotificationData()
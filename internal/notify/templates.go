@@ -10,13 +10,19 @@ import (
 func BuildEmailSubject(data *NotificationData) string {
 	statusEmoji := GetStatusEmoji(data.Status)
 
-	proxmoxType := strings.ToUpper(data.ProxmoxType.String())
 	timestamp := data.BackupDate.Format("2006-01-02 15:04")
+	if data.FleetDigest != nil {
+		return fmt.Sprintf("%s Fleet digest from %s - %s", statusEmoji, data.Hostname, timestamp)
+	}
+	proxmoxType := strings.ToUpper(data.ProxmoxType.String())
 	return fmt.Sprintf("%s %s Backup on %s - %s", statusEmoji, proxmoxType, data.Hostname, timestamp)
 }
 
 // BuildEmailPlainText builds a plain text email body
 func BuildEmailPlainText(data *NotificationData) string {
+	if d := data.FleetDigest; d != nil {
+		return fmt.Sprintf("%s\nCollector: %s\nScript Version: %s\n", strings.TrimRight(d.Text, "\n"), data.Hostname, data.ScriptVersion)
+	}
 	var body strings.Builder
 
 	statusEmoji := GetStatusEmoji(data.Status)
@@ -32,6 +38,22 @@ func BuildEmailPlainText(data *NotificationData) string {
 	}
 	if data.CloudEnabled {
 		fmt.Fprintf(&body, "  Cloud:     %s backups\n", data.CloudStatusSummary)
+		if q := data.CloudUploadQueue; q != nil {
+			fmt.Fprintf(&body, "  Upload queue: %s\n", q.Describe())
+			if q.LastError != "" {
+				fmt.Fprintf(&body, "    Last error: %s\n", q.LastError)
+			}
+		}
+	}
+	for _, f := range data.CapacityForecasts {
+		marker := ""
+		if f.Warning {
+			marker = " [WARNING]"
+		}
+		fmt.Fprintf(&body, "  Forecast %s: %s%s\n", f.Location, f.Describe(), marker)
+	}
+	for _, r := range retentionTierRows(data) {
+		fmt.Fprintf(&body, "  Retention %s: %s\n", r.location, DescribeRetentionTiers(r.tiers))
 	}
 	body.WriteString("\n")
 
@@ -45,6 +67,74 @@ func BuildEmailPlainText(data *NotificationData) string {
 		data.CompressionType, data.CompressionLevel, data.CompressionRatio)
 	body.WriteString("\n")
 
+	if pbs := data.PBSSnapshots; pbs != nil {
+		body.WriteString("PBS DATASTORE CONTENT:\n")
+		fmt.Fprintf(&body, "  Datastores: %d (%d namespaces)\n", pbs.Datastores, pbs.Namespaces)
+		fmt.Fprintf(&body, "  Groups: %d, Snapshots: %d\n", pbs.Groups, pbs.Snapshots)
+		if pbs.VerifyMaxAgeDays > 0 {
+			fmt.Fprintf(&body, "  Not verified within %d days: %d\n", pbs.VerifyMaxAgeDays, pbs.UnverifiedSnapshots)
+		}
+		fmt.Fprintf(&body, "  Failed verifications: %d\n", pbs.FailedSnapshots)
+		if pbs.StaleAfterDays > 0 {
+			fmt.Fprintf(&body, "  Groups without a backup in %d days: %d\n", pbs.StaleAfterDays, pbs.StaleGroups)
+			if preview := pbs.StaleGroupsPreview(5); preview != "" {
+				fmt.Fprintf(&body, "    %s\n", preview)
+			}
+		}
+		body.WriteString("\n")
+	}
+
+	if cov := data.PVEGuestCoverage; cov != nil {
+		body.WriteString("PVE GUEST BACKUP COVERAGE:\n")
+		fmt.Fprintf(&body, "  Guests: %d, Backup Jobs: %d\n", cov.Guests, cov.Jobs)
+		fmt.Fprintf(&body, "  Not covered by any job: %d\n", cov.UncoveredGuests)
+		if preview := previewNames(cov.UncoveredGuestNames, 5); preview != "" {
+			fmt.Fprintf(&body, "    %s\n", preview)
+		}
+		if cov.MaxAgeDays > 0 {
+			fmt.Fprintf(&body, "  Without a backup in %d days: %d\n", cov.MaxAgeDays, cov.StaleGuests)
+			if preview := previewNames(cov.StaleGuestNames, 5); preview != "" {
+				fmt.Fprintf(&body, "    %s\n", preview)
+			}
+		}
+		fmt.Fprintf(&body, "  Jobs with a failed last run: %d\n", cov.FailedJobs)
+		if preview := previewNames(cov.FailedJobNames, 5); preview != "" {
+			fmt.Fprintf(&body, "    %s\n", preview)
+		}
+		fmt.Fprintf(&body, "  Jobs targeting an offline storage: %d\n", cov.OfflineStorageJobs)
+		if preview := previewNames(cov.OfflineStorageJobNames, 5); preview != "" {
+			fmt.Fprintf(&body, "    %s\n", preview)
+		}
+		body.WriteString("\n")
+	}
+
+	if ce := data.CertificateExpiry; ce != nil {
+		body.WriteString("CERTIFICATES:\n")
+		for _, cert := range ce.Certificates {
+			acme := ""
+			if cert.ACMEManaged {
+				acme = " [ACME]"
+			}
+			fmt.Fprintf(&body, "  %s: %s%s\n", cert.Name, cert.Describe(), acme)
+		}
+		if len(ce.Unreadable) > 0 {
+			fmt.Fprintf(&body, "  Unreadable: %s\n", strings.Join(ce.Unreadable, ", "))
+		}
+		fmt.Fprintf(&body, "  Expired: %d, Expiring within %d days: %d\n", ce.Expired, ce.WarnDays, ce.ExpiringSoon)
+		fmt.Fprintf(&body, "  ACME renewal: %s\n", describeACME(ce.ACME))
+		body.WriteString("\n")
+	}
+
+	if h := data.Hardening; h != nil {
+		body.WriteString("HARDENING AUDIT:\n")
+		fmt.Fprintf(&body, "  Score: %d/100\n", h.Score)
+		fmt.Fprintf(&body, "  Passed: %d, Failed: %d (high: %d), Skipped: %d\n", h.Passed, h.Failed, h.FailedHigh, h.Skipped)
+		if preview := previewNames(h.FailedRules, 5); preview != "" {
+			fmt.Fprintf(&body, "    %s\n", preview)
+		}
+		body.WriteString("\n")
+	}
+
 	body.WriteString("ISSUES:\n")
 	fmt.Fprintf(&body, "  Errors: %d\n", data.ErrorCount)
 	fmt.Fprintf(&body, "  Warnings: %d\n", data.WarningCount)
@@ -73,6 +163,9 @@ func BuildEmailPlainText(data *NotificationData) string {
 
 // BuildEmailHTML builds an HTML email body matching Bash template exactly
 func BuildEmailHTML(data *NotificationData) string {
+	if data.FleetDigest != nil {
+		return buildFleetDigestHTML(data)
+	}
 	// Determine status color
 	statusColor := getStatusColor(data.Status)
 	statusText := strings.ToUpper(data.Status.String())
@@ -218,9 +311,118 @@ func BuildEmailHTML(data *NotificationData) string {
 	if data.CloudEnabled && data.CloudPath != "" {
 		html.WriteString(buildInfoTableRow("Cloud Storage", data.CloudPath))
 	}
+	if q := data.CloudUploadQueue; data.CloudEnabled && q != nil {
+		html.WriteString(buildInfoTableRow("Cloud Upload Queue", q.Describe()))
+		if q.LastError != "" {
+			html.WriteString(buildInfoTableRow("Upload Queue Error", q.LastError))
+		}
+	}
+	for _, f := range data.CapacityForecasts {
+		value := f.Describe()
+		if f.Warning {
+			value = "⚠️ " + value
+		}
+		html.WriteString(buildInfoTableRow("Capacity Forecast ("+f.Location+")", value))
+	}
+	for _, r := range retentionTierRows(data) {
+		html.WriteString(buildInfoTableRow("Retention ("+r.location+")", DescribeRetentionTiers(r.tiers)))
+	}
 	html.WriteString("                </table>\n")
 	html.WriteString("            </div>\n")
 
+	// PBS Datastore Content Section
+	if pbs := data.PBSSnapshots; pbs != nil {
+		html.WriteString("            \n")
+		html.WriteString("            <div class=\"section\">\n")
+		html.WriteString("                <h2>PBS Datastore Content</h2>\n")
+		html.WriteString("                <table class=\"info-table\">\n")
+		html.WriteString(buildInfoTableRow("Datastores", fmt.Sprintf("%d (%d namespaces)", pbs.Datastores, pbs.Namespaces)))
+		html.WriteString(buildInfoTableRow("Backup Groups", fmt.Sprintf("%d", pbs.Groups)))
+		html.WriteString(buildInfoTableRow("Snapshots", fmt.Sprintf("%d", pbs.Snapshots)))
+		if pbs.VerifyMaxAgeDays > 0 {
+			html.WriteString(buildInfoTableRow(fmt.Sprintf("Not Verified (%d days)", pbs.VerifyMaxAgeDays), fmt.Sprintf("%d", pbs.UnverifiedSnapshots)))
+		}
+		html.WriteString(buildInfoTableRow("Failed Verifications", fmt.Sprintf("%d", pbs.FailedSnapshots)))
+		if pbs.StaleAfterDays > 0 {
+			html.WriteString(buildInfoTableRow(fmt.Sprintf("Stale Groups (%d days)", pbs.StaleAfterDays), fmt.Sprintf("%d", pbs.StaleGroups)))
+			if preview := pbs.StaleGroupsPreview(10); preview != "" {
+				html.WriteString(buildInfoTableRow("Stale Group List", preview))
+			}
+		}
+		html.WriteString("                </table>\n")
+		html.WriteString("            </div>\n")
+	}
+
+	// PVE Guest Backup Coverage Section
+	if cov := data.PVEGuestCoverage; cov != nil {
+		html.WriteString("            \n")
+		html.WriteString("            <div class=\"section\">\n")
+		html.WriteString("                <h2>PVE Guest Backup Coverage</h2>\n")
+		html.WriteString("                <table class=\"info-table\">\n")
+		html.WriteString(buildInfoTableRow("Guests", fmt.Sprintf("%d", cov.Guests)))
+		html.WriteString(buildInfoTableRow("Backup Jobs", fmt.Sprintf("%d", cov.Jobs)))
+		html.WriteString(buildInfoTableRow("Uncovered Guests", fmt.Sprintf("%d", cov.UncoveredGuests)))
+		if preview := previewNames(cov.UncoveredGuestNames, 10); preview != "" {
+			html.WriteString(buildInfoTableRow("Uncovered Guest List", preview))
+		}
+		if cov.MaxAgeDays > 0 {
+			html.WriteString(buildInfoTableRow(fmt.Sprintf("Stale Guests (%d days)", cov.MaxAgeDays), fmt.Sprintf("%d", cov.StaleGuests)))
+			if preview := previewNames(cov.StaleGuestNames, 10); preview != "" {
+				html.WriteString(buildInfoTableRow("Stale Guest List", preview))
+			}
+		}
+		html.WriteString(buildInfoTableRow("Failed Jobs", fmt.Sprintf("%d", cov.FailedJobs)))
+		if preview := previewNames(cov.FailedJobNames, 10); preview != "" {
+			html.WriteString(buildInfoTableRow("Failed Job List", preview))
+		}
+		html.WriteString(buildInfoTableRow("Jobs on Offline Storage", fmt.Sprintf("%d", cov.OfflineStorageJobs)))
+		if preview := previewNames(cov.OfflineStorageJobNames, 10); preview != "" {
+			html.WriteString(buildInfoTableRow("Offline Storage Job List", preview))
+		}
+		html.WriteString("                </table>\n")
+		html.WriteString("            </div>\n")
+	}
+
+	// Certificate Expiry Section
+	if ce := data.CertificateExpiry; ce != nil {
+		html.WriteString("            \n")
+		html.WriteString("            <div class=\"section\">\n")
+		html.WriteString("                <h2>Certificates</h2>\n")
+		html.WriteString("                <table class=\"info-table\">\n")
+		for _, cert := range ce.Certificates {
+			value := cert.Describe()
+			if cert.ACMEManaged {
+				value += " (ACME)"
+			}
+			html.WriteString(buildInfoTableRow(cert.Name, value))
+		}
+		if len(ce.Unreadable) > 0 {
+			html.WriteString(buildInfoTableRow("Unreadable", strings.Join(ce.Unreadable, ", ")))
+		}
+		html.WriteString(buildInfoTableRow(fmt.Sprintf("Expiring (%d days)", ce.WarnDays), fmt.Sprintf("%d", ce.ExpiringSoon)))
+		html.WriteString(buildInfoTableRow("Expired", fmt.Sprintf("%d", ce.Expired)))
+		html.WriteString(buildInfoTableRow("ACME Renewal", describeACME(ce.ACME)))
+		html.WriteString("                </table>\n")
+		html.WriteString("            </div>\n")
+	}
+
+	// Hardening Audit Section
+	if h := data.Hardening; h != nil {
+		html.WriteString("            \n")
+		html.WriteString("            <div class=\"section\">\n")
+		html.WriteString("                <h2>Hardening Audit</h2>\n")
+		html.WriteString("                <table class=\"info-table\">\n")
+		html.WriteString(buildInfoTableRow("Score", fmt.Sprintf("%d/100", h.Score)))
+		html.WriteString(buildInfoTableRow("Passed Rules", fmt.Sprintf("%d", h.Passed)))
+		html.WriteString(buildInfoTableRow("Failed Rules", fmt.Sprintf("%d (high severity: %d)", h.Failed, h.FailedHigh)))
+		html.WriteString(buildInfoTableRow("Skipped Rules", fmt.Sprintf("%d", h.Skipped)))
+		if preview := previewNames(h.FailedRules, 10); preview != "" {
+			html.WriteString(buildInfoTableRow("Failed Rule List", preview))
+		}
+		html.WriteString("                </table>\n")
+		html.WriteString("            </div>\n")
+	}
+
 	// Error/Warning Section
 	html.WriteString("            \n")
 	html.WriteString("            <div class=\"section\">\n")
@@ -283,6 +485,38 @@ func BuildEmailHTML(data *NotificationData) string {
 	return html.String()
 }
 
+// buildFleetDigestHTML renders the fleet digest in the backup report layout, with the
+// digest text as the single content section.
+func buildFleetDigestHTML(data *NotificationData) string {
+	generated := escapeHTML(data.BackupDate.Format("2006-01-02 15:04:05"))
+	var html strings.Builder
+	html.WriteString("<!DOCTYPE html>\n")
+	html.WriteString("<html>\n<head>\n")
+	html.WriteString("    <meta charset=\"UTF-8\">\n")
+	html.WriteString("    <title>Fleet Digest</title>\n")
+	html.WriteString("    <style>\n")
+	html.WriteString(getEmbeddedCSS())
+	html.WriteString("    </style>\n")
+	html.WriteString("</head>\n<body>\n")
+	html.WriteString("    <div class=\"container\">\n")
+	fmt.Fprintf(&html, "        <div class=\"header\" style=\"background-color: %s;\">\n", getStatusColor(data.Status))
+	fmt.Fprintf(&html, "            <h1>Fleet Digest - %s</h1>\n", strings.ToUpper(data.Status.String()))
+	fmt.Fprintf(&html, "            <p>%s - %s</p>\n", escapeHTML(data.Hostname), generated)
+	html.WriteString("        </div>\n")
+	html.WriteString("        <div class=\"content\">\n")
+	html.WriteString("            <div class=\"section\">\n")
+	fmt.Fprintf(&html, "                <h2>%s</h2>\n", escapeHTML(data.FleetDigest.Headline))
+	fmt.Fprintf(&html, "                <pre style=\"white-space:pre-wrap;\">%s</pre>\n", escapeHTML(data.FleetDigest.Text))
+	html.WriteString("            </div>\n")
+	html.WriteString("        </div>\n")
+	html.WriteString("        <div class=\"footer\">\n")
+	fmt.Fprintf(&html, "            <p>Generated on %s by the ProxSave fleet collector v%s</p>\n", generated, escapeHTML(data.ScriptVersion))
+	html.WriteString("        </div>\n")
+	html.WriteString("    </div>\n")
+	html.WriteString("</body>\n</html>")
+	return html.String()
+}
+
 // buildInfoTableRow builds a table row for the info table (Bash style)
 func buildInfoTableRow(label, value string) string {
 	return fmt.Sprintf("                    <tr>\n                        <td>%s</td>\n                        <td>%s</td>\n                    </tr>\n", escapeHTML(label), escapeHTML(value))
@@ -452,3 +686,24 @@ func getEmbeddedCSS() string {
         }
 `
 }
+
+type retentionTierRow struct {
+	location string
+	tiers    []RetentionTier
+}
+
+// retentionTierRows returns the retention tiers of the enabled storage
+// locations that report them (time-based policies only).
+func retentionTierRows(data *NotificationData) []retentionTierRow {
+	var rows []retentionTierRow
+	if len(data.LocalRetentionTiers) > 0 {
+		rows = append(rows, retentionTierRow{"local", data.LocalRetentionTiers})
+	}
+	if data.SecondaryEnabled && len(data.SecondaryRetentionTiers) > 0 {
+		rows = append(rows, retentionTierRow{"secondary", data.SecondaryRetentionTiers})
+	}
+	if data.CloudEnabled && len(data.CloudRetentionTiers) > 0 {
+		rows = append(rows, retentionTierRow{"cloud", data.CloudRetentionTiers})
+	}
+	return rows
+}
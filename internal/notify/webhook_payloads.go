@@ -558,6 +558,42 @@ func buildGenericPayload(data *NotificationData, logger *logging.Logger) (map[st
 		logger.Debug("Cloud storage added to generic payload")
 	}
 
+	if data.CloudUploadQueue != nil {
+		payload["cloud_upload_queue"] = data.CloudUploadQueue
+		logger.Debug("Cloud upload queue added to generic payload")
+	}
+
+	for _, r := range retentionTierRows(data) {
+		if entry, ok := storage[r.location].(map[string]interface{}); ok {
+			entry["retention_tiers"] = r.tiers
+		}
+	}
+
+	if len(data.CapacityForecasts) > 0 {
+		payload["capacity_forecasts"] = data.CapacityForecasts
+		logger.Debug("Capacity forecasts added to generic payload")
+	}
+
+	if data.PBSSnapshots != nil {
+		payload["pbs_snapshots"] = data.PBSSnapshots
+		logger.Debug("PBS snapshot summary added to generic payload")
+	}
+
+	if data.PVEGuestCoverage != nil {
+		payload["pve_guest_coverage"] = data.PVEGuestCoverage
+		logger.Debug("PVE guest coverage summary added to generic payload")
+	}
+
+	if data.CertificateExpiry != nil {
+		payload["certificate_expiry"] = data.CertificateExpiry
+		logger.Debug("Certificate expiry summary added to generic payload")
+	}
+
+	if data.Hardening != nil {
+		payload["hardening"] = data.Hardening
+		logger.Debug("Hardening audit summary added to generic payload")
+	}
+
 	// Add log categories if present
 	if len(data.LogCategories) > 0 {
 		categories := make([]map[string]interface{}, 0, len(data.LogCategories))
@@ -991,6 +991,48 @@ func TestBuildGenericPayload(t *testing.T) {
 	if _, ok := storage["local"]; !ok {
 		t.Error("Storage missing local")
 	}
+	if _, ok := payload["pbs_snapshots"]; ok {
+		t.Error("pbs_snapshots should be omitted without a summary")
+	}
+	if _, ok := payload["pve_guest_coverage"]; ok {
+		t.Error("pve_guest_coverage should be omitted without a summary")
+	}
+
+	data.PBSSnapshots = &PBSSnapshotSummary{Groups: 3, Snapshots: 9}
+	payload, err = buildGenericPayload(data, logger)
+	if err != nil {
+		t.Fatalf("buildGenericPayload() error: %v", err)
+	}
+	if got, ok := payload["pbs_snapshots"].(*PBSSnapshotSummary); !ok || got.Groups != 3 {
+		t.Errorf("pbs_snapshots = %#v", payload["pbs_snapshots"])
+	}
+
+	data.PVEGuestCoverage = &PVEGuestCoverageSummary{Guests: 5, UncoveredGuests: 1}
+	payload, err = buildGenericPayload(data, logger)
+	if err != nil {
+		t.Fatalf("buildGenericPayload() error: %v", err)
+	}
+	if got, ok := payload["pve_guest_coverage"].(*PVEGuestCoverageSummary); !ok || got.UncoveredGuests != 1 {
+		t.Errorf("pve_guest_coverage = %#v", payload["pve_guest_coverage"])
+	}
+
+	data.Hardening = &HardeningSummary{Score: 90, Failed: 1}
+	payload, err = buildGenericPayload(data, logger)
+	if err != nil {
+		t.Fatalf("buildGenericPayload() error: %v", err)
+	}
+	if got, ok := payload["hardening"].(*HardeningSummary); !ok || got.Score != 90 {
+		t.Errorf("hardening = %#v", payload["hardening"])
+	}
+
+	data.CertificateExpiry = &CertificateExpirySummary{WarnDays: 30, ExpiringSoon: 1}
+	payload, err = buildGenericPayload(data, logger)
+	if err != nil {
+		t.Fatalf("buildGenericPayload() error: %v", err)
+	}
+	if got, ok := payload["certificate_expiry"].(*CertificateExpirySummary); !ok || got.ExpiringSoon != 1 {
+		t.Errorf("certificate_expiry = %#v", payload["certificate_expiry"])
+	}
 }
 
 func TestMaskURL(t *testing.T) {
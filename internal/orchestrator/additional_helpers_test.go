@@ -82,6 +82,61 @@ func TestBackupStatsToPrometheusMetrics(t *testing.T) {
 	if metrics.FilesFailed != stats.FilesFailed {
 		t.Fatalf("FilesFailed mismatch: %d vs %d", metrics.FilesFailed, stats.FilesFailed)
 	}
+	if metrics.GuestCoverage != nil {
+		t.Fatalf("GuestCoverage should be nil without a coverage audit")
+	}
+}
+
+func TestBackupStatsGuestCoverageMetrics(t *testing.T) {
+	last := time.Date(2024, 4, 9, 21, 0, 0, 0, time.UTC)
+	cov := &backup.PVEBackupCoverage{
+		Guests: []backup.PVEGuestBackupStatus{
+			{VMID: "100", Type: "qemu", Jobs: []string{"backup-daily"}, LastBackup: &last},
+			{VMID: "200", Type: "lxc", Uncovered: true},
+		},
+		Jobs: []backup.PVEBackupJobStatus{{ID: "backup-daily", Failed: true}},
+	}
+	stats := &BackupStats{
+		PVEGuestCoverage:    pveGuestCoverageSummaryForNotification(cov.Summary()),
+		PVEGuestLastBackups: pveGuestLastBackups(cov),
+	}
+
+	m := stats.toPrometheusMetrics().GuestCoverage
+	if m == nil {
+		t.Fatalf("expected guest coverage metrics")
+	}
+	if m.Guests != 2 || m.UncoveredGuests != 1 || m.Jobs != 1 || m.FailedJobs != 1 {
+		t.Fatalf("unexpected guest coverage metrics: %+v", m)
+	}
+	if len(m.LastBackups) != 1 || m.LastBackups[0].VMID != "100" || !m.LastBackups[0].Time.Equal(last) {
+		t.Fatalf("unexpected last backups: %+v", m.LastBackups)
+	}
+}
+
+func TestBackupStatsCertificateExpiry(t *testing.T) {
+	notAfter := time.Date(2026, 5, 11, 12, 0, 0, 0, time.UTC)
+	report := &backup.CertificateExpiryReport{
+		WarnDays: 30,
+		Certificates: []backup.CertificateExpiry{
+			{Name: "pveproxy-ssl.pem", NotAfter: notAfter, DaysRemaining: 10, ExpiringSoon: true, ACMEManaged: true},
+			{Name: "pve-ssl.pem", Error: "no PEM certificate"},
+		},
+		ACME: []backup.ACMERenewalSetting{{Product: "pve", Account: "prod"}},
+	}
+	stats := &BackupStats{CertificateExpiry: certificateExpirySummaryForNotification(report)}
+
+	sum := stats.CertificateExpiry
+	if sum.ExpiringSoon != 1 || len(sum.Certificates) != 1 || !sum.Certificates[0].ACMEManaged ||
+		sum.Unreadable[0] != "pve-ssl.pem" || sum.ACME[0] != "PVE: account prod" {
+		t.Fatalf("unexpected certificate summary: %+v", sum)
+	}
+	m := stats.toPrometheusMetrics().CertificateExpiry
+	if len(m) != 1 || m[0].Name != "pveproxy-ssl.pem" || !m[0].NotAfter.Equal(notAfter) {
+		t.Fatalf("unexpected certificate metrics: %+v", m)
+	}
+	if (&BackupStats{}).toPrometheusMetrics().CertificateExpiry != nil {
+		t.Fatal("certificate metrics must be omitted without a summary")
+	}
 }
 
 func TestDescribeTelegramConfigVariants(t *testing.T) {
@@ -221,8 +276,12 @@ func TestApplyStorageStatsGFSPrimary(t *testing.T) {
 	if stats.LocalBackups != len(backups) {
 		t.Fatalf("LocalBackups = %d, want %d", stats.LocalBackups, len(backups))
 	}
-	if stats.LocalGFSCurrentDaily == 0 || stats.LocalGFSCurrentWeekly == 0 || stats.LocalGFSCurrentMonthly == 0 || stats.LocalGFSCurrentYearly == 0 {
-		t.Fatalf("GFS counters not populated: %+v", stats)
+	kept := make(map[string]int)
+	for _, tier := range stats.LocalRetentionTiers {
+		kept[tier.Name] = tier.Kept
+	}
+	if kept["daily"] == 0 || kept["weekly"] == 0 || kept["monthly"] == 0 || kept["yearly"] == 0 {
+		t.Fatalf("GFS tiers not populated: %+v", stats.LocalRetentionTiers)
 	}
 	if stats.LocalRetentionPolicy != "gfs" {
 		t.Fatalf("LocalRetentionPolicy = %q, want gfs", stats.LocalRetentionPolicy)
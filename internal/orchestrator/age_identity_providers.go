@@ -0,0 +1,488 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/safeexec"
+	"github.com/tis24dev/proxsave/internal/safefs"
+)
+
+// Identity sources accepted at the decrypt prompt in place of a key or
+// passphrase. Each one resolves to the AGE identities stored elsewhere, so the
+// private key never has to be typed in.
+const (
+	ageIdentitySourceSystemdCreds = "systemd-creds:"
+	ageIdentitySourceUnlockFile   = "unlock-file:"
+	ageIdentitySourcePlugin       = "plugin:"
+
+	// DefaultAgeCredentialName is the systemd credential sealed by the setup
+	// wizard and read by "systemd-creds:" when no name is given.
+	DefaultAgeCredentialName = "proxsave-age"
+	// AgeUnlockFileName is the file searched on removable media by "unlock-file:".
+	AgeUnlockFileName = "proxsave.unlock"
+
+	maxAgeIdentitySourceSize = 64 << 10
+
+	ageIdentitySourceHint = "Key sources: systemd-creds:[name], unlock-file:[path], plugin:<identity file> or an AGE-PLUGIN-... identity."
+)
+
+// errAgeIdentitySource marks failures of an identity provider (missing
+// credential, unreadable unlock file, plugin or token error) so the decrypt
+// prompt can report them and ask again instead of aborting.
+var errAgeIdentitySource = errors.New("identity source")
+
+// ageIdentitySourceUI is the part of the active workflow UI that identity
+// sources prompt through: age plugin messages, PIN requests and confirmations,
+// and the confirmation of a discovered unlock file.
+type ageIdentitySourceUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
+	PromptAgePluginValue(ctx context.Context, pluginName, prompt string, secret bool) (string, error)
+}
+
+// agePluginNamePattern matches the plugin names accepted for age-plugin-<name>.
+var agePluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+._-]*$`)
+
+var (
+	systemdCredstoreDir = "/etc/credstore.encrypted"
+	removableMediaRoots = []string{"/media", "/run/media", "/mnt"}
+
+	// agePluginDirs are the only directories an age-plugin-<name> binary is
+	// accepted from. The age client starts plugins by name through PATH, so a
+	// binary that PATH resolves anywhere else is refused.
+	agePluginDirs = []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin", "/sbin", "/bin"}
+
+	// checkAgePluginExecutable is the ownership check on a resolved plugin
+	// binary. Tests replace it: their fake plugin is not root-owned.
+	checkAgePluginExecutable = validateAgePluginExecutable
+
+	// runSystemdCreds runs systemd-creds with optional stdin and returns stdout.
+	runSystemdCreds = func(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+		cmd, err := safeexec.CommandContext(ctx, "systemd-creds", args...)
+		if err != nil {
+			return nil, err
+		}
+		if stdin != nil {
+			cmd.Stdin = bytes.NewReader(stdin)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("systemd-creds %s: %w: %s", args[0], err, msg)
+			}
+			return nil, fmt.Errorf("systemd-creds %s: %w", args[0], err)
+		}
+		return out, nil
+	}
+
+	// newAgePluginClientUI answers age plugin requests (PIN, touch, confirm)
+	// through the active workflow UI. Without one (scheduled backups) plugins
+	// talk to the controlling terminal directly, like the age CLI.
+	newAgePluginClientUI = func(ctx context.Context, ui ageIdentitySourceUI) *plugin.ClientUI {
+		logger := logging.GetDefaultLogger()
+		if isNilInterface(ui) {
+			return plugin.NewTerminalUI(
+				func(format string, v ...any) { logger.Info(format, v...) },
+				func(format string, v ...any) { logger.Warning(format, v...) },
+			)
+		}
+		return &plugin.ClientUI{
+			DisplayMessage: func(name, message string) error {
+				return ui.ShowMessage(ctx, "age-plugin-"+name, message)
+			},
+			RequestValue: func(name, prompt string, secret bool) (string, error) {
+				return ui.PromptAgePluginValue(ctx, name, prompt, secret)
+			},
+			Confirm: func(name, prompt, yes, no string) (bool, error) {
+				if no == "" {
+					// A single choice is an acknowledgement, not a question.
+					return true, ui.ShowMessage(ctx, "age-plugin-"+name, prompt)
+				}
+				return ui.ConfirmAction(ctx, "age-plugin-"+name, prompt, yes, no, 0, false)
+			},
+			// WaitTimer runs on its own goroutine, so it only logs.
+			WaitTimer: func(name string) {
+				logger.Info("Waiting for age-plugin-%s (the token may need a touch)", name)
+			},
+		}
+	}
+)
+
+// resolveAgeIdentitySource loads identities when input names an identity
+// source (systemd-creds:, unlock-file:, plugin: or a raw AGE-PLUGIN- identity).
+// handled is false for plain keys and passphrases. ui, when not nil, hosts the
+// prompts of plugins and the unlock file confirmation.
+func resolveAgeIdentitySource(ctx context.Context, input string, ui ageIdentitySourceUI) (identities []age.Identity, handled bool, err error) {
+	pluginUI := newAgePluginClientUI(ctx, ui)
+	lower := strings.ToLower(input)
+	switch {
+	case strings.HasPrefix(lower, ageIdentitySourceSystemdCreds):
+		identities, err = loadSystemdCredsIdentities(ctx, input[len(ageIdentitySourceSystemdCreds):], pluginUI)
+	case strings.HasPrefix(lower, ageIdentitySourceUnlockFile):
+		identities, err = loadUnlockFileIdentities(ctx, input[len(ageIdentitySourceUnlockFile):], ui, pluginUI)
+	case strings.HasPrefix(lower, ageIdentitySourcePlugin):
+		identities, err = loadIdentityFile(strings.TrimSpace(input[len(ageIdentitySourcePlugin):]), pluginUI)
+	case strings.HasPrefix(strings.ToUpper(input), "AGE-PLUGIN-"):
+		identities, err = parseAgeIdentityData([]byte(input), pluginUI)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %w", errAgeIdentitySource, err)
+	}
+	return identities, true, nil
+}
+
+// isAgeIdentitySourceRef reports whether input names an identity source
+// (systemd-creds:, unlock-file: or plugin:) rather than carrying a key.
+func isAgeIdentitySourceRef(input string) bool {
+	lower := strings.ToLower(strings.TrimSpace(input))
+	for _, prefix := range []string{ageIdentitySourceSystemdCreds, ageIdentitySourceUnlockFile, ageIdentitySourcePlugin} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSystemdCredsIdentities reads a credential sealed with systemd-creds.
+// Inside a unit with LoadCredentialEncrypted= the already-decrypted copy in
+// $CREDENTIALS_DIRECTORY is used; otherwise the credential is decrypted from
+// the given path or from /etc/credstore.encrypted/<name>.
+func loadSystemdCredsIdentities(ctx context.Context, ref string, pluginUI *plugin.ClientUI) ([]age.Identity, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		ref = DefaultAgeCredentialName
+	}
+	path := ref
+	if !filepath.IsAbs(ref) {
+		if err := ValidateAgeCredentialName(ref); err != nil {
+			return nil, err
+		}
+		if dir := strings.TrimSpace(os.Getenv("CREDENTIALS_DIRECTORY")); dir != "" {
+			if data, err := readAgeIdentitySourceFile(filepath.Join(dir, ref)); err == nil {
+				defer zeroBytes(data)
+				return parseAgeIdentityData(data, pluginUI)
+			}
+		}
+		path = filepath.Join(systemdCredstoreDir, ref)
+	}
+	data, err := runSystemdCreds(ctx, nil, "decrypt", path, "-")
+	if err != nil {
+		return nil, fmt.Errorf("decrypt credential %s: %w", path, err)
+	}
+	defer zeroBytes(data)
+	return parseAgeIdentityData(data, pluginUI)
+}
+
+// loadUnlockFileIdentities reads an unlock file from removable media. ref may
+// be the file itself, a mount point to search, or empty to search the usual
+// removable media mount roots. A discovered file is only used once the user
+// confirms it; an explicit file path needs no confirmation.
+func loadUnlockFileIdentities(ctx context.Context, ref string, ui ageIdentitySourceUI, pluginUI *plugin.ClientUI) ([]age.Identity, error) {
+	path, discovered, err := findAgeUnlockFile(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	if discovered {
+		if isNilInterface(ui) {
+			return nil, fmt.Errorf("found unlock file %s; enter %s%s to use it", path, ageIdentitySourceUnlockFile, path)
+		}
+		ok, err := ui.ConfirmAction(ctx, "Unlock file", fmt.Sprintf("Use the unlock file found at %s?", path), "Use it", "Cancel", 0, false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("unlock file %s not confirmed", path)
+		}
+	}
+	logging.GetDefaultLogger().Info("Using unlock file %s", path)
+	return loadIdentityFile(path, pluginUI)
+}
+
+// findAgeUnlockFile returns the unlock file named by ref, or searches for it.
+// discovered is true when the file was found by the search, which must match
+// exactly one file.
+func findAgeUnlockFile(ref string) (path string, discovered bool, err error) {
+	roots := removableMediaRoots
+	if ref != "" {
+		info, err := os.Stat(ref)
+		if err != nil {
+			return "", false, err
+		}
+		if !info.IsDir() {
+			return ref, false, nil
+		}
+		roots = []string{ref}
+	}
+	// The root itself, mount points right below it (/media/usb, /mnt/key) and
+	// per-user automounts (/run/media/<user>/<label>).
+	var found []string
+	for _, root := range roots {
+		for _, depth := range []string{"", "*", filepath.Join("*", "*")} {
+			matches, _ := filepath.Glob(filepath.Join(root, depth, AgeUnlockFileName))
+			found = append(found, matches...)
+		}
+	}
+	sort.Strings(found)
+	found = slices.Compact(found)
+	switch len(found) {
+	case 0:
+		return "", false, fmt.Errorf("no %s found under %s; is the removable media mounted?", AgeUnlockFileName, strings.Join(roots, ", "))
+	case 1:
+		return found[0], true, nil
+	default:
+		return "", false, fmt.Errorf("several unlock files found (%s); enter %s<path> to choose one", strings.Join(found, ", "), ageIdentitySourceUnlockFile)
+	}
+}
+
+func loadIdentityFile(path string, pluginUI *plugin.ClientUI) ([]age.Identity, error) {
+	if path == "" {
+		return nil, fmt.Errorf("identity file path is empty")
+	}
+	data, err := readAgeIdentitySourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(data)
+	return parseAgeIdentityData(data, pluginUI)
+}
+
+func readAgeIdentitySourceFile(path string) (data []byte, err error) {
+	f, err := safefs.OpenFileUnderRoot(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer closeIntoErr(&err, f, "close identity file")
+	return io.ReadAll(io.LimitReader(f, maxAgeIdentitySourceSize))
+}
+
+// parseAgeIdentityData parses an age identity file: one AGE-SECRET-KEY- or
+// AGE-PLUGIN- identity per line, blank lines and # comments ignored. Plugin
+// identities prompt through pluginUI.
+func parseAgeIdentityData(data []byte, pluginUI *plugin.ClientUI) ([]age.Identity, error) {
+	var identities []age.Identity
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		upper := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(upper, "AGE-SECRET-KEY-"):
+			id, err := age.ParseX25519Identity(upper)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			identities = append(identities, id)
+		case strings.HasPrefix(upper, "AGE-PLUGIN-"):
+			id, err := plugin.NewIdentity(upper, pluginUI)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if _, err := resolveAgePlugin(id.Name()); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			identities = append(identities, agePluginIdentity{id})
+		default:
+			return nil, fmt.Errorf("line %d: not an AGE identity", n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no AGE identities found")
+	}
+	return identities, nil
+}
+
+// agePluginIdentity tags plugin failures (binary missing, wrong PIN, token not
+// inserted) as identity source errors; a non-matching stanza is passed through
+// so age tries the next identity.
+type agePluginIdentity struct {
+	*plugin.Identity
+}
+
+func (i agePluginIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	fileKey, err := i.Identity.Unwrap(stanzas)
+	if err != nil && !errors.Is(err, age.ErrIncorrectIdentity) {
+		return nil, fmt.Errorf("%w: %w", errAgeIdentitySource, err)
+	}
+	return fileKey, err
+}
+
+// ValidateHardwareTokenRecipient checks an age plugin recipient (age1yubikey1...
+// and similar) and that its age-plugin-<name> binary is installed: backups
+// encrypt through it.
+func ValidateHardwareTokenRecipient(value string) error {
+	name, _, err := plugin.ParseRecipient(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("not an age plugin recipient: %w", err)
+	}
+	_, err = resolveAgePlugin(name)
+	return err
+}
+
+// resolveAgePlugin returns the age-plugin-<name> binary the age client will
+// start. It must be found in PATH inside one of agePluginDirs and pass
+// checkAgePluginExecutable; anything else is refused before it can run.
+func resolveAgePlugin(name string) (string, error) {
+	if !agePluginNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid age plugin name %q", name)
+	}
+	binary := "age-plugin-" + name
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("%s not found in PATH; install it before using this recipient", binary)
+	}
+	if !filepath.IsAbs(path) || !slices.Contains(agePluginDirs, filepath.Dir(path)) {
+		return "", fmt.Errorf("refusing %s: not in a trusted plugin directory (%s)", path, strings.Join(agePluginDirs, ", "))
+	}
+	if err := checkAgePluginExecutable(path); err != nil {
+		return "", fmt.Errorf("refusing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// validateAgePluginExecutable requires the plugin binary (after symlinks) to
+// be a root-owned executable that neither group nor others can write.
+func validateAgePluginExecutable(path string) error {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not an executable file", resolved)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("%s is group/world-writable (mode %#o)", resolved, info.Mode().Perm())
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Uid != 0 {
+		return fmt.Errorf("%s is owned by uid %d, not root", resolved, st.Uid)
+	}
+	return nil
+}
+
+// ValidateAgeCredentialName checks a systemd credential name for the setup wizard.
+func ValidateAgeCredentialName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, os.PathSeparator) {
+		return fmt.Errorf("invalid credential name %q", name)
+	}
+	return nil
+}
+
+// ValidateAgeUnlockFilePath checks the unlock file destination for the setup
+// wizard: an absolute file path or the mount point of the removable media.
+func ValidateAgeUnlockFilePath(path string) error {
+	path = strings.TrimSpace(path)
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("unlock file path must be absolute: %q", path)
+	}
+	return nil
+}
+
+// generateAgeIdentityFile creates an X25519 identity in the age-keygen file
+// format and returns the file content with its public recipient.
+func generateAgeIdentityFile(now time.Time) (content []byte, recipient string, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate AGE identity: %w", err)
+	}
+	recipient = id.Recipient().String()
+	content = fmt.Appendf(nil, "# created: %s\n# public key: %s\n%s\n", now.Format(time.RFC3339), recipient, id.String())
+	return content, recipient, nil
+}
+
+// sealAgeIdentityWithSystemdCreds generates a new identity, seals it with
+// systemd-creds (TPM2-bound when the host has one) under
+// /etc/credstore.encrypted/<name> and returns its recipient. An existing
+// credential is never replaced: it may be the only key for older backups.
+func (o *Orchestrator) sealAgeIdentityWithSystemdCreds(ctx context.Context, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultAgeCredentialName
+	}
+	if err := ValidateAgeCredentialName(name); err != nil {
+		return "", err
+	}
+	fs := o.filesystem()
+	target := filepath.Join(systemdCredstoreDir, name)
+	if _, err := fs.Stat(target); err == nil {
+		return "", fmt.Errorf("credential %s already exists; remove it or choose another name", target)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("inspect credential %s: %w", target, err)
+	}
+	if err := fs.MkdirAll(systemdCredstoreDir, 0o700); err != nil {
+		return "", fmt.Errorf("create %s: %w", systemdCredstoreDir, err)
+	}
+	content, recipient, err := generateAgeIdentityFile(o.now())
+	if err != nil {
+		return "", err
+	}
+	defer zeroBytes(content)
+	if _, err := runSystemdCreds(ctx, content, "encrypt", "--name="+name, "-", target); err != nil {
+		return "", err
+	}
+	if o.logger != nil {
+		o.logger.Info("Encryption setup: AGE identity sealed in %s; decrypt with \"systemd-creds:%s\"", target, name)
+	}
+	return recipient, nil
+}
+
+// writeAgeUnlockFile generates a new identity and stores it as an unlock file
+// at path (or path/proxsave.unlock when path is a directory, e.g. the mount
+// point of a USB stick). Existing files are never overwritten.
+func (o *Orchestrator) writeAgeUnlockFile(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if err := ValidateAgeUnlockFilePath(path); err != nil {
+		return "", err
+	}
+	fs := o.filesystem()
+	if info, err := fs.Stat(path); err == nil {
+		if !info.IsDir() {
+			return "", fmt.Errorf("unlock file %s already exists", path)
+		}
+		path = filepath.Join(path, AgeUnlockFileName)
+		if _, err := fs.Stat(path); err == nil {
+			return "", fmt.Errorf("unlock file %s already exists", path)
+		}
+	}
+	content, recipient, err := generateAgeIdentityFile(o.now())
+	if err != nil {
+		return "", err
+	}
+	defer zeroBytes(content)
+	if err := fs.WriteFile(path, content, 0o600); err != nil {
+		return "", fmt.Errorf("write unlock file %s: %w", path, err)
+	}
+	if o.logger != nil {
+		o.logger.Info("Encryption setup: unlock file written to %s; keep the media offline and decrypt with \"unlock-file:\"", path)
+	}
+	return recipient, nil
+}
@@ -0,0 +1,395 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+	"github.com/tis24dev/proxsave/internal/input"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+const (
+	fakeAgePluginName = "proxsavetest"
+	fakeAgePluginPIN  = "123456"
+)
+
+// TestMain doubles as a fake age plugin: installFakeAgePlugin links the test
+// binary as age-plugin-proxsavetest, and the age client re-executes it.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "age-plugin-"+fakeAgePluginName {
+		os.Exit(runFakeAgePlugin())
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeAgePlugin emulates a PIN-protected hardware token whose identity and
+// recipient data are plain X25519 key strings.
+func runFakeAgePlugin() int {
+	p, err := plugin.New(fakeAgePluginName)
+	if err != nil {
+		return 1
+	}
+	p.HandleRecipient(func(data []byte) (age.Recipient, error) {
+		return age.ParseX25519Recipient(string(data))
+	})
+	p.HandleIdentity(func(data []byte) (age.Identity, error) {
+		id, err := age.ParseX25519Identity(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return &fakeTokenIdentity{p: p, id: id}, nil
+	})
+	return p.Main()
+}
+
+type fakeTokenIdentity struct {
+	p  *plugin.Plugin
+	id *age.X25519Identity
+}
+
+func (i *fakeTokenIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	pin, err := i.p.RequestValue("Token PIN", true)
+	if err != nil {
+		return nil, err
+	}
+	if pin != fakeAgePluginPIN {
+		return nil, errors.New("wrong PIN")
+	}
+	return i.id.Unwrap(stanzas)
+}
+
+// fakeAgeSourceUI stands in for the workflow UI hosting identity source
+// prompts: plugin values answer with *pin, confirmations with confirm.
+type fakeAgeSourceUI struct {
+	pin      *string
+	confirm  bool
+	prompts  []string
+	confirms []string
+}
+
+func (u *fakeAgeSourceUI) ShowMessage(ctx context.Context, title, message string) error { return nil }
+
+func (u *fakeAgeSourceUI) ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error) {
+	u.confirms = append(u.confirms, message)
+	return u.confirm, nil
+}
+
+func (u *fakeAgeSourceUI) PromptAgePluginValue(ctx context.Context, pluginName, prompt string, secret bool) (string, error) {
+	u.prompts = append(u.prompts, pluginName+": "+prompt)
+	return *u.pin, nil
+}
+
+// installFakeAgePlugin puts age-plugin-proxsavetest on PATH in a directory
+// trusted for plugins. It returns the token's identity and recipient strings.
+func installFakeAgePlugin(t *testing.T) (identity, recipient string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	binDir := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(binDir, "age-plugin-"+fakeAgePluginName)); err != nil {
+		t.Fatalf("symlink fake plugin: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	origDirs, origCheck := agePluginDirs, checkAgePluginExecutable
+	agePluginDirs = []string{binDir}
+	checkAgePluginExecutable = func(string) error { return nil }
+	t.Cleanup(func() { agePluginDirs, checkAgePluginExecutable = origDirs, origCheck })
+
+	x, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	return plugin.EncodeIdentity(fakeAgePluginName, []byte(x.String())),
+		plugin.EncodeRecipient(fakeAgePluginName, []byte(x.Recipient().String()))
+}
+
+func writeAgeEncryptedFixture(t *testing.T, recipient age.Recipient) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup.tar.age")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	w, err := age.Encrypt(f, recipient)
+	if err != nil {
+		t.Fatalf("age.Encrypt: %v", err)
+	}
+	if _, err := w.Write([]byte("payload")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close age writer: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+// decryptWithInputs runs the decrypt prompt loop feeding inputs in order and
+// returns the prompt errors shown before each retry.
+func decryptWithInputs(t *testing.T, encrypted string, sourceUI ageIdentitySourceUI, inputs ...string) (shown []string, err error) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "backup.tar")
+	prompt := func(ctx context.Context, displayName, previousError string) (string, error) {
+		if previousError != "" {
+			shown = append(shown, previousError)
+		}
+		if len(inputs) == 0 {
+			return "", input.ErrInputAborted
+		}
+		next := inputs[0]
+		inputs = inputs[1:]
+		return next, nil
+	}
+	err = decryptArchiveWithSecretPrompt(context.Background(), encrypted, output, "backup", prompt, sourceUI, nil)
+	if err == nil {
+		data, readErr := os.ReadFile(output)
+		if readErr != nil || string(data) != "payload" {
+			t.Fatalf("decrypted output = %q, %v", data, readErr)
+		}
+	}
+	return shown, err
+}
+
+func TestAgePluginRecipientAndIdentityRoundTrip(t *testing.T) {
+	pin := "000000"
+	identity, recipient := installFakeAgePlugin(t)
+	ui := &fakeAgeSourceUI{pin: &pin}
+
+	if err := ValidateRecipientString(recipient); err != nil {
+		t.Fatalf("plugin recipient rejected: %v", err)
+	}
+	if err := ValidateHardwareTokenRecipient(recipient); err != nil {
+		t.Fatalf("ValidateHardwareTokenRecipient: %v", err)
+	}
+	if err := ValidateHardwareTokenRecipient(plugin.EncodeRecipient("missingtoken", []byte("x"))); err == nil || !strings.Contains(err.Error(), "age-plugin-missingtoken not found") {
+		t.Fatalf("missing plugin err = %v", err)
+	}
+	parsed, err := parseRecipientString(recipient)
+	if err != nil {
+		t.Fatalf("parseRecipientString: %v", err)
+	}
+	encrypted := writeAgeEncryptedFixture(t, parsed)
+
+	// Wrong PIN: the plugin failure is reported and the prompt asks again.
+	shown, err := decryptWithInputs(t, encrypted, ui, identity)
+	if !errors.Is(err, ErrDecryptAborted) || len(shown) != 1 || !strings.Contains(shown[0], "wrong PIN") {
+		t.Fatalf("wrong PIN: err=%v shown=%q", err, shown)
+	}
+	if len(ui.prompts) != 1 || ui.prompts[0] != fakeAgePluginName+": Token PIN" {
+		t.Fatalf("plugin PIN request must go through the workflow UI, got %q", ui.prompts)
+	}
+
+	pin = fakeAgePluginPIN
+	if _, err := decryptWithInputs(t, encrypted, ui, identity); err != nil {
+		t.Fatalf("raw plugin identity: %v", err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "token.txt")
+	if err := os.WriteFile(identityFile, []byte("# token\n"+identity+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptWithInputs(t, encrypted, ui, "plugin:"+identityFile); err != nil {
+		t.Fatalf("plugin identity file: %v", err)
+	}
+}
+
+func TestResolveAgePluginRefusesUntrustedBinaries(t *testing.T) {
+	identity, recipient := installFakeAgePlugin(t)
+
+	// The plugin found in PATH sits outside the trusted directories.
+	agePluginDirs = []string{"/usr/bin"}
+	if err := ValidateHardwareTokenRecipient(recipient); err == nil || !strings.Contains(err.Error(), "trusted plugin directory") {
+		t.Fatalf("untrusted directory err = %v", err)
+	}
+	if _, err := parseRecipientString(recipient); err == nil {
+		t.Fatal("a recipient whose plugin is not trusted must be rejected at backup time")
+	}
+	if _, _, err := resolveAgeIdentitySource(context.Background(), identity, nil); err == nil {
+		t.Fatal("an identity whose plugin is not trusted must be rejected")
+	}
+
+	// A trusted directory still needs a binary that passes the ownership check.
+	agePluginDirs = []string{filepath.Dir(mustLookPath(t, "age-plugin-"+fakeAgePluginName))}
+	checkAgePluginExecutable = func(string) error { return errors.New("owned by uid 1000, not root") }
+	if _, err := resolveAgePlugin(fakeAgePluginName); err == nil || !strings.Contains(err.Error(), "not root") {
+		t.Fatalf("ownership err = %v", err)
+	}
+
+	for _, name := range []string{"", "../evil", "-x", "a/b", "UPPER"} {
+		if _, err := resolveAgePlugin(name); err == nil || !strings.Contains(err.Error(), "invalid age plugin name") {
+			t.Fatalf("name %q: err = %v", name, err)
+		}
+	}
+}
+
+func mustLookPath(t *testing.T, name string) string {
+	t.Helper()
+	path, err := exec.LookPath(name)
+	if err != nil {
+		t.Fatalf("LookPath(%s): %v", name, err)
+	}
+	return path
+}
+
+func TestUnlockFileProvider(t *testing.T) {
+	root := t.TempDir()
+	origRoots := removableMediaRoots
+	removableMediaRoots = []string{filepath.Join(root, "media"), filepath.Join(root, "run", "media")}
+	t.Cleanup(func() { removableMediaRoots = origRoots })
+
+	mount := filepath.Join(root, "run", "media", "admin", "KEYS")
+	if err := os.MkdirAll(mount, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	o := &Orchestrator{logger: logging.New(types.LogLevelError, false), fs: osFS{}}
+	recipientStr, err := o.resolveAgeRecipientDraft(context.Background(), &AgeRecipientDraft{Kind: AgeRecipientInputUnlockFile, UnlockFilePath: mount}, "")
+	if err != nil {
+		t.Fatalf("resolve unlock file draft: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(mount, AgeUnlockFileName))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("unlock file = %v, %v", info, err)
+	}
+	if _, err := o.writeAgeUnlockFile(mount); err == nil {
+		t.Fatal("an existing unlock file must not be overwritten")
+	}
+
+	recipient, err := age.ParseX25519Recipient(recipientStr)
+	if err != nil {
+		t.Fatalf("parse recipient: %v", err)
+	}
+	encrypted := writeAgeEncryptedFixture(t, recipient)
+	ui := &fakeAgeSourceUI{confirm: true}
+	shown, err := decryptWithInputs(t, encrypted, ui, "unlock-file:"+filepath.Join(root, "elsewhere"), "unlock-file:")
+	if err != nil {
+		t.Fatalf("unlock-file discovery: %v (shown %q)", err, shown)
+	}
+	if len(shown) != 1 || !strings.Contains(shown[0], "identity source") {
+		t.Fatalf("missing media must be reported, shown %q", shown)
+	}
+	unlockFile := filepath.Join(mount, AgeUnlockFileName)
+	if len(ui.confirms) != 1 || !strings.Contains(ui.confirms[0], unlockFile) {
+		t.Fatalf("a discovered unlock file must be confirmed, asked %q", ui.confirms)
+	}
+
+	// Declined, or no UI to confirm with: the discovered file is not used.
+	ui = &fakeAgeSourceUI{confirm: false}
+	if shown, err := decryptWithInputs(t, encrypted, ui, "unlock-file:"); !errors.Is(err, ErrDecryptAborted) || len(shown) != 1 || !strings.Contains(shown[0], "not confirmed") {
+		t.Fatalf("declined unlock file: err=%v shown=%q", err, shown)
+	}
+	if shown, err := decryptWithInputs(t, encrypted, nil, "unlock-file:"); !errors.Is(err, ErrDecryptAborted) || len(shown) != 1 || !strings.Contains(shown[0], "unlock-file:"+unlockFile) {
+		t.Fatalf("unlock file without UI: err=%v shown=%q", err, shown)
+	}
+	// An explicit file path needs no confirmation.
+	ui = &fakeAgeSourceUI{}
+	if _, err := decryptWithInputs(t, encrypted, ui, "unlock-file:"+unlockFile); err != nil || len(ui.confirms) != 0 {
+		t.Fatalf("explicit unlock file: err=%v asked=%q", err, ui.confirms)
+	}
+
+	// A second unlock file makes discovery ambiguous.
+	other := filepath.Join(root, "media", "OTHER")
+	if err := os.MkdirAll(other, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other, AgeUnlockFileName), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ui = &fakeAgeSourceUI{confirm: true}
+	if shown, err := decryptWithInputs(t, encrypted, ui, "unlock-file:"); !errors.Is(err, ErrDecryptAborted) || len(shown) != 1 || !strings.Contains(shown[0], "several unlock files") || len(ui.confirms) != 0 {
+		t.Fatalf("ambiguous unlock files: err=%v shown=%q asked=%q", err, shown, ui.confirms)
+	}
+}
+
+func TestSystemdCredsProvider(t *testing.T) {
+	credstore := t.TempDir()
+	origDir, origRun := systemdCredstoreDir, runSystemdCreds
+	systemdCredstoreDir = credstore
+	t.Cleanup(func() { systemdCredstoreDir, runSystemdCreds = origDir, origRun })
+
+	// Fake systemd-creds: "encrypt" stores stdin as-is, "decrypt" reads it back.
+	var calls [][]string
+	runSystemdCreds = func(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		switch args[0] {
+		case "encrypt":
+			return nil, os.WriteFile(args[len(args)-1], stdin, 0o600)
+		case "decrypt":
+			return os.ReadFile(args[1])
+		}
+		return nil, errors.New("unexpected systemd-creds call")
+	}
+
+	o := &Orchestrator{logger: logging.New(types.LogLevelError, false), fs: osFS{}}
+	recipientStr, err := o.resolveAgeRecipientDraft(context.Background(), &AgeRecipientDraft{Kind: AgeRecipientInputSystemdCreds}, "")
+	if err != nil {
+		t.Fatalf("resolve systemd-creds draft: %v", err)
+	}
+	if got := strings.Join(calls[0], " "); got != "encrypt --name=proxsave-age - "+filepath.Join(credstore, DefaultAgeCredentialName) {
+		t.Fatalf("encrypt call = %q", got)
+	}
+	if _, err := o.sealAgeIdentityWithSystemdCreds(context.Background(), DefaultAgeCredentialName); err == nil {
+		t.Fatal("an existing credential must not be replaced")
+	}
+	if _, err := o.sealAgeIdentityWithSystemdCreds(context.Background(), "../escape"); err == nil {
+		t.Fatal("expected error for a credential name with a path separator")
+	}
+
+	recipient, err := age.ParseX25519Recipient(recipientStr)
+	if err != nil {
+		t.Fatalf("parse recipient: %v", err)
+	}
+	encrypted := writeAgeEncryptedFixture(t, recipient)
+	if _, err := decryptWithInputs(t, encrypted, nil, "systemd-creds:"); err != nil {
+		t.Fatalf("systemd-creds default credential: %v", err)
+	}
+
+	// Inside a unit with LoadCredentialEncrypted= the decrypted copy is used.
+	credsDir := t.TempDir()
+	content, err := os.ReadFile(filepath.Join(credstore, DefaultAgeCredentialName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(credsDir, "unit-key"), content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CREDENTIALS_DIRECTORY", credsDir)
+	calls = nil
+	if _, err := decryptWithInputs(t, encrypted, nil, "SYSTEMD-CREDS:unit-key"); err != nil {
+		t.Fatalf("systemd-creds from CREDENTIALS_DIRECTORY: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("CREDENTIALS_DIRECTORY copy must not call systemd-creds, got %q", calls)
+	}
+}
+
+func TestParseAgeIdentityData(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	ids, err := parseAgeIdentityData([]byte("# created\n\n"+strings.ToLower(id.String())+"\n"), nil)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("parseAgeIdentityData = %v, %v", ids, err)
+	}
+	for _, bad := range []string{"", "# only comments\n", "not-a-key\n", "AGE-SECRET-KEY-1BROKEN\n"} {
+		if _, err := parseAgeIdentityData([]byte(bad), nil); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if _, handled, _ := resolveAgeIdentitySource(context.Background(), "correct horse battery staple", nil); handled {
+		t.Fatal("a passphrase must not be treated as an identity source")
+	}
+}
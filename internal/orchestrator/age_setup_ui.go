@@ -8,13 +8,21 @@ const (
 	AgeRecipientInputExisting AgeRecipientInputKind = iota
 	AgeRecipientInputPassphrase
 	AgeRecipientInputPrivateKey
+	// AgeRecipientInputSystemdCreds generates a key sealed with systemd-creds.
+	AgeRecipientInputSystemdCreds
+	// AgeRecipientInputHardwareToken takes an age plugin recipient (YubiKey etc.).
+	AgeRecipientInputHardwareToken
+	// AgeRecipientInputUnlockFile generates a key stored on removable media.
+	AgeRecipientInputUnlockFile
 )
 
 type AgeRecipientDraft struct {
-	Kind       AgeRecipientInputKind
-	PublicKey  string
-	Passphrase string
-	PrivateKey string
+	Kind           AgeRecipientInputKind
+	PublicKey      string
+	Passphrase     string
+	PrivateKey     string
+	CredentialName string
+	UnlockFilePath string
 }
 
 type AgeSetupUI interface {
@@ -34,13 +34,16 @@ func (u *cliAgeSetupUI) CollectRecipientDraft(ctx context.Context, recipientPath
 		fmt.Println("\n[1] Use an existing AGE public key")
 		fmt.Println("[2] Generate an AGE public key using a personal passphrase/password - not stored on the server")
 		fmt.Println("[3] Generate an AGE public key from an existing personal private key - not stored on the server")
-		fmt.Println("[4] Exit setup")
+		fmt.Println("[4] Generate a key sealed with systemd-creds (TPM2 when available) - decrypt with systemd-creds:")
+		fmt.Println("[5] Use a hardware token through an age plugin (e.g. age1yubikey1...)")
+		fmt.Println("[6] Generate a key stored as an unlock file on removable media - decrypt with unlock-file:")
+		fmt.Println("[7] Exit setup")
 
-		option, err := promptOptionAge(ctx, u.reader, "Select an option [1-4]: ")
+		option, err := promptOptionAge(ctx, u.reader, "Select an option [1-7]: ")
 		if err != nil {
 			return nil, err
 		}
-		if option == "4" {
+		if option == "7" {
 			return nil, ErrAgeRecipientSetupAborted
 		}
 
@@ -66,6 +69,39 @@ func (u *cliAgeSetupUI) CollectRecipientDraft(ctx context.Context, recipientPath
 				continue
 			}
 			return &AgeRecipientDraft{Kind: AgeRecipientInputPrivateKey, PrivateKey: privateKey}, nil
+		case "4":
+			name, err := promptLineAge(ctx, u.reader, fmt.Sprintf("Credential name [%s]: ", DefaultAgeCredentialName))
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				name = DefaultAgeCredentialName
+			}
+			if err := ValidateAgeCredentialName(name); err != nil {
+				u.warn(err)
+				continue
+			}
+			return &AgeRecipientDraft{Kind: AgeRecipientInputSystemdCreds, CredentialName: name}, nil
+		case "5":
+			value, err := promptLineAge(ctx, u.reader, "Paste the age plugin recipient of your token (e.g. from age-plugin-yubikey --list): ")
+			if err != nil {
+				return nil, err
+			}
+			if err := ValidateHardwareTokenRecipient(value); err != nil {
+				u.warn(err)
+				continue
+			}
+			return &AgeRecipientDraft{Kind: AgeRecipientInputHardwareToken, PublicKey: value}, nil
+		case "6":
+			path, err := promptLineAge(ctx, u.reader, fmt.Sprintf("Mount point of the removable media or full path of the unlock file (e.g. /media/usb/%s): ", AgeUnlockFileName))
+			if err != nil {
+				return nil, err
+			}
+			if err := ValidateAgeUnlockFilePath(path); err != nil {
+				u.warn(err)
+				continue
+			}
+			return &AgeRecipientDraft{Kind: AgeRecipientInputUnlockFile, UnlockFilePath: path}, nil
 		}
 	}
 }
@@ -143,7 +143,7 @@ func (o *Orchestrator) runAgeSetupWorkflow(ctx context.Context, candidatePath st
 			return nil, nil, ErrAgeRecipientSetupAborted
 		}
 
-		value, err := o.resolveAgeRecipientDraft(draft, targetPath)
+		value, err := o.resolveAgeRecipientDraft(ctx, draft, targetPath)
 		if err != nil {
 			if o.logger != nil {
 				o.logger.Warning("Encryption setup: %v", err)
@@ -215,7 +215,7 @@ func (o *Orchestrator) runAgeSetupWorkflow(ctx context.Context, candidatePath st
 	}, nil
 }
 
-func (o *Orchestrator) resolveAgeRecipientDraft(draft *AgeRecipientDraft, recipientPath string) (string, error) {
+func (o *Orchestrator) resolveAgeRecipientDraft(ctx context.Context, draft *AgeRecipientDraft, recipientPath string) (string, error) {
 	if draft == nil {
 		return "", fmt.Errorf("recipient draft is required")
 	}
@@ -253,6 +253,16 @@ func (o *Orchestrator) resolveAgeRecipientDraft(draft *AgeRecipientDraft, recipi
 		privateKey := strings.TrimSpace(draft.PrivateKey)
 		defer resetString(&privateKey)
 		return ParseAgePrivateKeyRecipient(privateKey)
+	case AgeRecipientInputSystemdCreds:
+		return o.sealAgeIdentityWithSystemdCreds(ctx, draft.CredentialName)
+	case AgeRecipientInputHardwareToken:
+		value := strings.TrimSpace(draft.PublicKey)
+		if err := ValidateHardwareTokenRecipient(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case AgeRecipientInputUnlockFile:
+		return o.writeAgeUnlockFile(draft.UnlockFilePath)
 	default:
 		return "", fmt.Errorf("unsupported AGE setup input kind: %d", draft.Kind)
 	}
@@ -125,7 +125,7 @@ func formatBackupCandidateTarget(manifest *backup.Manifest) string {
 		targets = fmt.Sprintf("%s %s", targets, version)
 	}
 
-	if cluster := formatClusterMode(manifest.ClusterMode); cluster != "" {
+	if cluster := formatManifestCluster(manifest); cluster != "" {
 		targets = fmt.Sprintf("%s (%s)", targets, cluster)
 	}
 
@@ -84,3 +84,47 @@ func TestCreateBackupArchiveFileTargetsPartial(t *testing.T) {
 		t.Fatalf("archive must NOT be created at the final path; stat err=%v", err)
 	}
 }
+
+// A split archive is promoted volume by volume onto the final name, and a
+// discarded partial takes its volumes with it.
+func TestPromoteAndDiscardPartialArchiveVolumes(t *testing.T) {
+	dir := t.TempDir()
+	final := filepath.Join(dir, "host-backup-20260717.tar.zst")
+	partial := final + ".partial"
+	fs := osFS{}
+
+	volumes := make([]backup.ArchiveVolume, 3)
+	for i := range volumes {
+		path := backup.VolumePath(partial, i+1)
+		if err := os.WriteFile(path, []byte{byte(i)}, 0o640); err != nil {
+			t.Fatalf("seed volume: %v", err)
+		}
+		volumes[i] = backup.ArchiveVolume{Name: filepath.Base(path), Size: 1}
+	}
+	if err := promoteBackupVolumes(fs, partial, final, volumes); err != nil {
+		t.Fatalf("promoteBackupVolumes: %v", err)
+	}
+	for i, volume := range volumes {
+		want := backup.VolumePath(final, i+1)
+		if volume.Name != filepath.Base(want) {
+			t.Fatalf("volume %d name=%s, want %s", i, volume.Name, filepath.Base(want))
+		}
+		if b, err := os.ReadFile(want); err != nil || len(b) != 1 || b[0] != byte(i) {
+			t.Fatalf("promoted volume %d content=%v err=%v", i, b, err)
+		}
+	}
+	if left := backup.ExistingVolumePaths(partial); len(left) != 0 {
+		t.Fatalf("partial volumes left after promote: %v", left)
+	}
+
+	partial2 := filepath.Join(dir, "host-backup-err.tar.zst.partial")
+	for i := 1; i <= 2; i++ {
+		if err := os.WriteFile(backup.VolumePath(partial2, i), []byte("x"), 0o640); err != nil {
+			t.Fatalf("seed volume: %v", err)
+		}
+	}
+	discardPartialArchive(fs, partial2)
+	if left := backup.ExistingVolumePaths(partial2); len(left) != 0 {
+		t.Fatalf("partial volumes must be discarded, left %v", left)
+	}
+}
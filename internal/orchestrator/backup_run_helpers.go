@@ -3,6 +3,7 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -10,9 +11,19 @@ import (
 	"filippo.io/age"
 	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/metrics"
+	"github.com/tis24dev/proxsave/internal/notify"
+	"github.com/tis24dev/proxsave/internal/security"
 	"github.com/tis24dev/proxsave/internal/types"
 )
 
+const (
+	hardeningAuditJSON = "hardening_audit.json"
+	hardeningAuditText = "hardening_audit.txt"
+)
+
+// runHardeningAudit is the hardening audit entry point (test seam).
+var runHardeningAudit = security.RunHardeningAudit
+
 func (o *Orchestrator) shouldExportBackupMetrics(stats *BackupStats) bool {
 	return stats != nil && o.cfg != nil && o.cfg.MetricsEnabled && !o.dryRun
 }
@@ -90,6 +101,7 @@ func (o *Orchestrator) buildBackupCollectorConfig() *backup.CollectorConfig {
 
 func (o *Orchestrator) runBackupCollector(run *backupRunContext, workspace *backupWorkspace, collectorConfig *backup.CollectorConfig) (*backup.Collector, error) {
 	collector := backup.NewCollectorWithDeps(o.logger, collectorConfig, workspace.tempDir, run.proxmoxType, o.dryRun, o.collectorDeps())
+	run.collector = collector
 	o.logger.Debug("Starting collector run (type=%s)", run.proxmoxType)
 	if err := collector.CollectAll(run.ctx); err != nil {
 		return nil, err
@@ -97,6 +109,14 @@ func (o *Orchestrator) runBackupCollector(run *backupRunContext, workspace *back
 	return collector, nil
 }
 
+// settleClusterCapture marks the coordinated cluster claim of this node complete once
+// its archive is verified, or releases it when the run fails before that.
+func (o *Orchestrator) settleClusterCapture(run *backupRunContext, archived bool) {
+	if run.collector != nil {
+		run.collector.FinishClusterCapture(archived)
+	}
+}
+
 func (o *Orchestrator) applyBackupCollectionStats(stats *BackupStats, collStats *backup.CollectionStats, collector *backup.Collector) {
 	stats.FilesCollected = int(collStats.FilesProcessed)
 	stats.FilesFailed = int(collStats.FilesFailed)
@@ -108,9 +128,89 @@ func (o *Orchestrator) applyBackupCollectionStats(stats *BackupStats, collStats
 	stats.UncompressedSize = collStats.BytesCollected
 	if stats.ProxmoxType.SupportsPVE() {
 		stats.ClusterMode = standaloneClusterMode(collector)
+		if cc := collector.ClusterCapture(); cc != nil {
+			stats.ClusterScope = cc.Scope
+			stats.ClusterCaptureNode = cc.CaptureNode
+			stats.ClusterSlot = cc.Slot
+		}
+	}
+	if inv := collector.PBSSnapshotInventory(); inv != nil {
+		stats.PBSSnapshots = pbsSnapshotSummaryForNotification(inv.Summary())
+	}
+	if cov := collector.PVEBackupCoverage(); cov != nil {
+		stats.PVEGuestCoverage = pveGuestCoverageSummaryForNotification(cov.Summary())
+		stats.PVEGuestLastBackups = pveGuestLastBackups(cov)
+	}
+	if report := collector.CertificateExpiry(); report != nil {
+		stats.CertificateExpiry = certificateExpirySummaryForNotification(report)
+	}
+}
+
+func certificateExpirySummaryForNotification(report *backup.CertificateExpiryReport) *notify.CertificateExpirySummary {
+	sum := report.Summary()
+	out := &notify.CertificateExpirySummary{
+		WarnDays:     sum.WarnDays,
+		Expired:      sum.Expired,
+		ExpiringSoon: sum.ExpiringSoon,
+		Unreadable:   append([]string(nil), sum.UnreadableNames...),
+		ACME:         append([]string(nil), sum.ACMEDescriptions...),
+	}
+	for _, cert := range report.Certificates {
+		if cert.Error != "" {
+			continue
+		}
+		out.Certificates = append(out.Certificates, notify.CertificateExpiryInfo{
+			Name:          cert.Name,
+			NotAfter:      cert.NotAfter,
+			DaysRemaining: cert.DaysRemaining,
+			Expired:       cert.Expired,
+			ACMEManaged:   cert.ACMEManaged,
+		})
+	}
+	return out
+}
+
+func pbsSnapshotSummaryForNotification(sum backup.PBSSnapshotInventorySummary) *notify.PBSSnapshotSummary {
+	return &notify.PBSSnapshotSummary{
+		Datastores:          sum.Datastores,
+		Namespaces:          sum.Namespaces,
+		Groups:              sum.Groups,
+		Snapshots:           sum.Snapshots,
+		UnverifiedSnapshots: sum.UnverifiedSnapshots,
+		FailedSnapshots:     sum.FailedSnapshots,
+		StaleGroups:         sum.StaleGroups,
+		VerifyMaxAgeDays:    sum.VerifyMaxAgeDays,
+		StaleAfterDays:      sum.StaleAfterDays,
+		StaleGroupNames:     append([]string(nil), sum.StaleGroupNames...),
 	}
 }
 
+func pveGuestCoverageSummaryForNotification(sum backup.PVEBackupCoverageSummary) *notify.PVEGuestCoverageSummary {
+	return &notify.PVEGuestCoverageSummary{
+		Guests:                 sum.Guests,
+		UncoveredGuests:        sum.UncoveredGuests,
+		StaleGuests:            sum.StaleGuests,
+		Jobs:                   sum.Jobs,
+		FailedJobs:             sum.FailedJobs,
+		OfflineStorageJobs:     sum.OfflineStorageJobs,
+		MaxAgeDays:             sum.MaxAgeDays,
+		UncoveredGuestNames:    append([]string(nil), sum.UncoveredGuestNames...),
+		StaleGuestNames:        append([]string(nil), sum.StaleGuestNames...),
+		FailedJobNames:         append([]string(nil), sum.FailedJobNames...),
+		OfflineStorageJobNames: append([]string(nil), sum.OfflineStorageJobNames...),
+	}
+}
+
+func pveGuestLastBackups(cov *backup.PVEBackupCoverage) []metrics.GuestLastBackup {
+	var out []metrics.GuestLastBackup
+	for _, g := range cov.Guests {
+		if g.LastBackup != nil {
+			out = append(out, metrics.GuestLastBackup{VMID: g.VMID, Type: g.Type, Time: *g.LastBackup})
+		}
+	}
+	return out
+}
+
 func standaloneClusterMode(collector *backup.Collector) string {
 	if collector.IsClusteredPVE() {
 		return "cluster"
@@ -132,6 +232,70 @@ func (o *Orchestrator) writeBackupCollectionMetadata(tempDir, hostname string, s
 	}
 }
 
+// runBackupHardeningAudit scores the host hardening rules, stores the report under
+// var/lib/proxsave-info/security in the archive and records the summary for the
+// notifications. Failed rules are logged but do not turn the run into a warning.
+func (o *Orchestrator) runBackupHardeningAudit(ctx context.Context, tempDir string, stats *BackupStats) {
+	if o.cfg == nil || !o.cfg.HardeningAudit {
+		return
+	}
+	report := runHardeningAudit(ctx, security.HardeningAuditOptions{ProxmoxType: stats.ProxmoxType})
+	sum := report.Summary()
+	stats.Hardening = &notify.HardeningSummary{
+		Score:       sum.Score,
+		Passed:      sum.Passed,
+		Failed:      sum.Failed,
+		Skipped:     sum.Skipped,
+		FailedHigh:  sum.FailedHigh,
+		FailedRules: append([]string(nil), sum.FailedRules...),
+	}
+	o.logger.Info("Hardening audit: score %d/100 (%d passed, %d failed, %d skipped)", sum.Score, sum.Passed, sum.Failed, sum.Skipped)
+	for _, f := range report.Findings {
+		if f.Status == security.HardeningFail {
+			o.logger.Info("  [%s] %s: %s", f.Severity, f.ID, f.Detail)
+		}
+	}
+	if err := o.writeHardeningAuditReport(tempDir, report); err != nil {
+		o.logger.Warning("WARNING: Failed to write hardening audit report: %v", err)
+	}
+}
+
+func (o *Orchestrator) writeHardeningAuditReport(tempDir string, report *security.HardeningReport) error {
+	if o.dryRun {
+		return nil
+	}
+	fs := o.filesystem()
+	dir := filepath.Join(tempDir, "var/lib/proxsave-info/security")
+	patterns := append([]string(nil), o.excludePatterns...)
+	if o.cfg != nil && len(o.cfg.BackupBlacklist) > 0 {
+		patterns = append(patterns, o.cfg.BackupBlacklist...)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{hardeningAuditJSON, append(data, '\n')},
+		{hardeningAuditText, []byte(report.Text())},
+	} {
+		target := filepath.Join(dir, file.name)
+		if excluded, pattern := backup.FindExcludeMatch(patterns, target, tempDir, ""); excluded {
+			o.logger.Debug("Skipping hardening report %s (matches pattern %s)", target, pattern)
+			continue
+		}
+		if err := fs.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := fs.WriteFile(target, file.data, 0o640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (o *Orchestrator) logBackupCollectionSummary(collStats *backup.CollectionStats) {
 	o.logger.Info("Collection completed: %d files (%s), %d failed, %d dirs created",
 		collStats.FilesProcessed,
@@ -185,7 +349,7 @@ func backupDiskValidationError(message string, diskErr error) error {
 }
 
 func (o *Orchestrator) buildBackupArchiverConfig(run *backupRunContext, ageRecipients []age.Recipient) *backup.ArchiverConfig {
-	return BuildArchiverConfig(
+	cfg := BuildArchiverConfig(
 		o.compressionType,
 		run.normalizedLevel,
 		o.compressionThreads,
@@ -195,6 +359,11 @@ func (o *Orchestrator) buildBackupArchiverConfig(run *backupRunContext, ageRecip
 		ageRecipients,
 		run.collectorConfig.ExcludePatterns,
 	)
+	if o.cfg != nil {
+		cfg.VolumeSize = o.cfg.ArchiveVolumeSize
+		cfg.CompressionEngine = o.cfg.CompressionEngine
+	}
+	return cfg
 }
 
 func (o *Orchestrator) applyBackupArchiverStats(stats *BackupStats, archiver *backup.Archiver) {
@@ -202,6 +371,7 @@ func (o *Orchestrator) applyBackupArchiverStats(stats *BackupStats, archiver *ba
 	stats.CompressionLevel = archiver.CompressionLevel()
 	stats.CompressionMode = archiver.CompressionMode()
 	stats.CompressionThreads = archiver.CompressionThreads()
+	stats.CompressionNative = archiver.NativeCompression()
 }
 
 func (o *Orchestrator) backupArchivePath(run *backupRunContext, archiver *backup.Archiver) string {
@@ -229,11 +399,32 @@ func promoteBackupArchive(fs FS, partialPath, finalPath string) error {
 	return fs.Rename(partialPath, finalPath)
 }
 
+// promoteBackupVolumes is promoteBackupArchive for an archive written as
+// volumes: every partial volume is renamed onto the final archive name and the
+// volume list is updated to the promoted names.
+func promoteBackupVolumes(fs FS, partialPath, finalPath string, volumes []backup.ArchiveVolume) error {
+	for i := range volumes {
+		from, to := backup.VolumePath(partialPath, i+1), backup.VolumePath(finalPath, i+1)
+		if err := fs.Rename(from, to); err != nil {
+			return err
+		}
+		volumes[i].Name = filepath.Base(to)
+	}
+	return nil
+}
+
 // discardPartialArchive best-effort removes a partial archive left by a failed
-// or cancelled backup so a truncated file never lingers on the backup path.
-// Absent partial is a no-op.
+// or cancelled backup so a truncated file never lingers on the backup path,
+// together with any volumes it was being written to. Absent partial is a no-op.
 func discardPartialArchive(fs FS, partialPath string) {
 	_ = fs.RemoveAll(partialPath)
+	for index := 1; ; index++ {
+		volume := backup.VolumePath(partialPath, index)
+		if _, err := fs.Stat(volume); err != nil {
+			return
+		}
+		_ = fs.RemoveAll(volume)
+	}
 }
 
 func backupArchiveCreationError(err error) error {
@@ -270,10 +461,18 @@ func (o *Orchestrator) recordArchiveSize(stats *BackupStats, artifacts *backupAr
 	o.logger.Debug("Archive created: %s (%s)", artifacts.archivePath, backup.FormatBytes(size))
 }
 
-func (o *Orchestrator) generateArchiveChecksum(ctx context.Context, archivePath string) (string, error) {
+// generateArchiveChecksum hashes the archive at archivePath or, when volumes are
+// given, the volumes it was written as, read back to back.
+func (o *Orchestrator) generateArchiveChecksum(ctx context.Context, archivePath string, volumes ...backup.ArchiveVolume) (string, error) {
 	// archivePath lives on BACKUP_PATH, which can be a dead/stale mount; bound the
 	// hash with FS_IO_TIMEOUT so it cannot wedge in an uninterruptible read.
-	checksum, err := backup.GenerateChecksumBounded(ctx, o.logger, archivePath, o.fsIoTimeout())
+	var checksum string
+	var err error
+	if len(volumes) > 0 {
+		checksum, err = backup.GenerateVolumesChecksumBounded(ctx, o.logger, backup.VolumePaths(archivePath, volumes), o.fsIoTimeout())
+	} else {
+		checksum, err = backup.GenerateChecksumBounded(ctx, o.logger, archivePath, o.fsIoTimeout())
+	}
 	if err != nil {
 		return "", &BackupError{
 			Phase: "verification",
@@ -300,6 +499,9 @@ func (o *Orchestrator) writeArchiveManifest(run *backupRunContext, artifacts *ba
 	// fails the backup (see backfillCoLocatedPassphraseSalt).
 	o.backfillCoLocatedPassphraseSalt()
 	manifest, err := o.newArchiveManifest(run.stats, artifacts.archivePath, checksum)
+	if err == nil {
+		manifest.Volumes = artifacts.volumes
+	}
 	if err != nil {
 		// Fail closed: an unreadable or empty passphrase salt would drop the salt
 		// from the manifest and leave a passphrase-derived archive permanently
@@ -328,23 +530,26 @@ func (o *Orchestrator) newArchiveManifest(stats *BackupStats, archivePath, check
 		return nil, err
 	}
 	return &backup.Manifest{
-		ArchivePath:      archivePath,
-		ArchiveSize:      stats.ArchiveSize,
-		SHA256:           checksum,
-		CreatedAt:        stats.Timestamp,
-		CompressionType:  string(stats.Compression),
-		CompressionLevel: stats.CompressionLevel,
-		CompressionMode:  stats.CompressionMode,
-		ProxmoxType:      string(stats.ProxmoxType),
-		ProxmoxTargets:   append([]string(nil), stats.ProxmoxTargets...),
-		ProxmoxVersion:   stats.ProxmoxVersion,
-		PVEVersion:       stats.PVEVersion,
-		PBSVersion:       stats.PBSVersion,
-		Hostname:         stats.Hostname,
-		ScriptVersion:    stats.ScriptVersion,
-		EncryptionMode:   o.archiveEncryptionMode(),
-		ClusterMode:      stats.ClusterMode,
-		PassphraseSalt:   passphraseSalt,
+		ArchivePath:        archivePath,
+		ArchiveSize:        stats.ArchiveSize,
+		SHA256:             checksum,
+		CreatedAt:          stats.Timestamp,
+		CompressionType:    string(stats.Compression),
+		CompressionLevel:   stats.CompressionLevel,
+		CompressionMode:    stats.CompressionMode,
+		ProxmoxType:        string(stats.ProxmoxType),
+		ProxmoxTargets:     append([]string(nil), stats.ProxmoxTargets...),
+		ProxmoxVersion:     stats.ProxmoxVersion,
+		PVEVersion:         stats.PVEVersion,
+		PBSVersion:         stats.PBSVersion,
+		Hostname:           stats.Hostname,
+		ScriptVersion:      stats.ScriptVersion,
+		EncryptionMode:     o.archiveEncryptionMode(),
+		ClusterMode:        stats.ClusterMode,
+		ClusterScope:       stats.ClusterScope,
+		ClusterCaptureNode: stats.ClusterCaptureNode,
+		ClusterSlot:        stats.ClusterSlot,
+		PassphraseSalt:     passphraseSalt,
 	}, nil
 }
 
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/security"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func stubHardeningAudit(t *testing.T) *types.ProxmoxType {
+	t.Helper()
+	orig := runHardeningAudit
+	t.Cleanup(func() { runHardeningAudit = orig })
+	var seen types.ProxmoxType
+	runHardeningAudit = func(_ context.Context, opts security.HardeningAuditOptions) *security.HardeningReport {
+		seen = opts.ProxmoxType
+		return &security.HardeningReport{
+			GeneratedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+			Score:       50,
+			Points:      3,
+			MaxPoints:   6,
+			Findings: []security.HardeningFinding{
+				{ID: "ssh_max_auth_tries", Severity: security.HardeningLow, Status: security.HardeningFail, Detail: "MaxAuthTries 6"},
+				{ID: "root_account", Severity: security.HardeningHigh, Status: security.HardeningPass},
+				{ID: "root_pam_tfa", Severity: security.HardeningHigh, Status: security.HardeningFail, Detail: "no factor"},
+				{ID: "expired_certificates", Severity: security.HardeningHigh, Status: security.HardeningSkip},
+			},
+		}
+	}
+	return &seen
+}
+
+func TestRunBackupHardeningAuditWritesReportAndSummary(t *testing.T) {
+	seen := stubHardeningAudit(t)
+	logger := logging.New(types.LogLevelWarning, false)
+	o := &Orchestrator{logger: logger, cfg: &config.Config{HardeningAudit: true}}
+	tempDir := t.TempDir()
+	stats := &BackupStats{ProxmoxType: types.ProxmoxVE}
+
+	o.runBackupHardeningAudit(context.Background(), tempDir, stats)
+
+	if *seen != types.ProxmoxVE {
+		t.Fatalf("audit ran for %q", *seen)
+	}
+	if stats.Hardening == nil || stats.Hardening.Score != 50 || stats.Hardening.Failed != 2 || stats.Hardening.FailedHigh != 1 ||
+		!reflect.DeepEqual(stats.Hardening.FailedRules, []string{"root_pam_tfa", "ssh_max_auth_tries"}) {
+		t.Fatalf("Hardening = %+v", stats.Hardening)
+	}
+	if logger.HasWarnings() {
+		t.Fatal("failed hardening rules must not raise warnings")
+	}
+
+	dir := filepath.Join(tempDir, "var/lib/proxsave-info/security")
+	raw, err := os.ReadFile(filepath.Join(dir, "hardening_audit.json"))
+	if err != nil {
+		t.Fatalf("read json report: %v", err)
+	}
+	var report security.HardeningReport
+	if err := json.Unmarshal(raw, &report); err != nil || report.Score != 50 || len(report.Findings) != 4 {
+		t.Fatalf("json report = %+v (err %v)", report, err)
+	}
+	text, err := os.ReadFile(filepath.Join(dir, "hardening_audit.txt"))
+	if err != nil || !strings.Contains(string(text), "Score: 50/100 (1 passed, 2 failed, 1 skipped)") {
+		t.Fatalf("text report = %q (err %v)", text, err)
+	}
+
+	data := (&NotificationAdapter{logger: logger}).convertBackupStatsToNotificationData(stats)
+	if data.Hardening != stats.Hardening {
+		t.Fatalf("notification Hardening = %+v", data.Hardening)
+	}
+}
+
+func TestRunBackupHardeningAuditDisabledOrDryRun(t *testing.T) {
+	stubHardeningAudit(t)
+	logger := logging.New(types.LogLevelError, false)
+
+	stats := &BackupStats{ProxmoxType: types.ProxmoxBS}
+	(&Orchestrator{logger: logger, cfg: &config.Config{}}).runBackupHardeningAudit(context.Background(), t.TempDir(), stats)
+	if stats.Hardening != nil {
+		t.Fatalf("disabled audit must not set a summary: %+v", stats.Hardening)
+	}
+
+	tempDir := t.TempDir()
+	(&Orchestrator{logger: logger, cfg: &config.Config{HardeningAudit: true}, dryRun: true}).runBackupHardeningAudit(context.Background(), tempDir, stats)
+	if stats.Hardening == nil {
+		t.Fatal("dry run still reports the summary")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "var/lib/proxsave-info/security")); !os.IsNotExist(err) {
+		t.Fatalf("dry run must not write the report, stat err = %v", err)
+	}
+}
@@ -22,6 +22,7 @@ type backupRunContext struct {
 	timestamp       string
 	normalizedLevel int
 	collectorConfig *backup.CollectorConfig
+	collector       *backup.Collector
 	stats           *BackupStats
 }
 
@@ -39,6 +40,7 @@ type backupArtifacts struct {
 	partialPath  string
 	manifestPath string
 	bundlePath   string
+	volumes      []backup.ArchiveVolume
 }
 
 func (o *Orchestrator) newBackupRunContext(ctx context.Context, envInfo *environment.EnvironmentInfo, hostname string) *backupRunContext {
@@ -103,7 +105,9 @@ func (o *Orchestrator) initBackupRun(run *backupRunContext) *BackupStats {
 
 func (o *Orchestrator) exportBackupMetrics(run *backupRunContext, runErr error) {
 	stats := run.stats
-	if !o.shouldExportBackupMetrics(stats) {
+	exportMetrics := o.shouldExportBackupMetrics(stats)
+	pushFleet := o.shouldPushFleetReport(stats)
+	if !exportMetrics && !pushFleet {
 		return
 	}
 
@@ -116,7 +120,12 @@ func (o *Orchestrator) exportBackupMetrics(run *backupRunContext, runErr error)
 		o.finalizeSuccessIssueStats(stats)
 	}
 	stats.ExitCode = backupMetricsExitCode(stats, runErr)
-	o.exportPrometheusBackupMetrics(stats)
+	if exportMetrics {
+		o.exportPrometheusBackupMetrics(stats)
+	}
+	if pushFleet {
+		o.pushFleetReport(run.ctx, stats)
+	}
 }
 
 // finalizeSuccessIssueStats re-parses the run log after notifications on a successful
@@ -231,6 +240,7 @@ func (o *Orchestrator) collectBackupData(run *backupRunContext, workspace *backu
 	collStats := collector.GetStats()
 	o.applyBackupCollectionStats(run.stats, collStats, collector)
 	o.writeBackupCollectionMetadata(workspace.tempDir, run.hostname, run.stats, collector)
+	o.runBackupHardeningAudit(run.ctx, workspace.tempDir, run.stats)
 
 	// The collection manifest is written through the collector after the first
 	// snapshot, so re-snapshot afterwards: this counts the manifest like every other
@@ -312,6 +322,7 @@ func (o *Orchestrator) createBackupArchive(run *backupRunContext, workspace *bac
 		archivePath:  archivePath,
 		partialPath:  partialPath,
 		checksumPath: archivePath + ".sha256",
+		volumes:      archiver.ArchiveVolumes(),
 	}, nil
 }
 
@@ -330,14 +341,22 @@ func (o *Orchestrator) verifyAndWriteBackupArtifacts(run *backupRunContext, work
 		return &BackupError{Phase: "verification", Err: err, Code: types.ExitVerificationError}
 	}
 
-	checksum, err := o.generateArchiveChecksum(run.ctx, artifacts.partialPath)
+	checksum, err := o.generateArchiveChecksum(run.ctx, artifacts.partialPath, artifacts.volumes...)
 	if err != nil {
 		discardPartialArchive(workspace.fs, artifacts.partialPath)
 		return err
 	}
 	stats.Checksum = checksum
 
-	if err := promoteBackupArchive(workspace.fs, artifacts.partialPath, artifacts.archivePath); err != nil {
+	if len(artifacts.volumes) > 0 {
+		if err := promoteBackupVolumes(workspace.fs, artifacts.partialPath, artifacts.archivePath, artifacts.volumes); err != nil {
+			discardPartialArchive(workspace.fs, artifacts.partialPath)
+			discardPartialArchive(workspace.fs, artifacts.archivePath)
+			return &BackupError{Phase: "archive", Err: fmt.Errorf("promote verified archive volumes: %w", err), Code: types.ExitArchiveError}
+		}
+		stats.ArchiveVolumes = len(artifacts.volumes)
+		o.logger.Info("Archive written as %d volumes of up to %s", len(artifacts.volumes), backup.FormatBytes(artifacts.archiver.VolumeSize()))
+	} else if err := promoteBackupArchive(workspace.fs, artifacts.partialPath, artifacts.archivePath); err != nil {
 		discardPartialArchive(workspace.fs, artifacts.partialPath)
 		return &BackupError{Phase: "archive", Err: fmt.Errorf("promote verified archive: %w", err), Code: types.ExitArchiveError}
 	}
@@ -352,6 +371,7 @@ func (o *Orchestrator) verifyAndWriteBackupArtifacts(run *backupRunContext, work
 	if err := o.writeArchiveManifest(run, artifacts, checksum); err != nil {
 		return err
 	}
+	o.signArchiveManifest(run.ctx, workspace, artifacts)
 	o.writeLegacyMetadataAlias(workspace, artifacts)
 	return nil
 }
@@ -369,6 +389,14 @@ func (o *Orchestrator) bundleBackupArtifacts(run *backupRunContext, workspace *b
 		o.logger.Info("✓ Archive created and verified")
 		return nil
 	}
+	if len(artifacts.volumes) > 0 {
+		// A bundle would join the volumes back into one oversized file.
+		fmt.Println()
+		o.logger.Skip("Bundling skipped: archive split into %d volumes", len(artifacts.volumes))
+		run.stats.EndTime = o.now()
+		o.logger.Info("✓ Archive created and verified")
+		return nil
+	}
 
 	fmt.Println()
 	o.logStep(5, "Bundling of archive, checksum and metadata")
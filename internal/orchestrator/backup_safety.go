@@ -46,12 +46,12 @@ func createSafetyBackup(logger *logging.Logger, selectedCategories []Category, d
 	defer func() { done(err) }()
 
 	timestamp := safetyNow().Format("20060102_150405")
-	baseDir := filepath.Join("/tmp", "proxsave")
+	baseDir := safetyBackupDir
 	if err := safetyFS.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("create safety backup directory: %w", err)
 	}
 	backupDir := filepath.Join(baseDir, fmt.Sprintf("%s_%s", prefix, timestamp))
-	backupArchive := backupDir + ".tar.gz"
+	backupArchive := backupDir + safetyBackupArchiveSuffix
 
 	logger.Info("Creating %s of current configuration...", strings.ToLower(desc))
 	logger.Debug("%s will be saved to: %s", desc, backupArchive)
@@ -150,6 +150,10 @@ func createSafetyBackup(logger *logging.Logger, selectedCategories []Category, d
 		result.FilesBackedUp,
 		float64(result.TotalSize)/(1024*1024))
 
+	if err := writeSafetyBackupSidecar(backupArchive, prefix, desc, selectedCategories, result); err != nil {
+		logger.Debug("Could not write %s catalog entry: %v", strings.ToLower(desc), err)
+	}
+
 	if spec.WriteLocationFile && locationFileName != "" {
 		locationFile := filepath.Join(baseDir, locationFileName)
 		if err := safetyFS.WriteFile(locationFile, []byte(backupArchive), 0644); err != nil {
@@ -17,6 +17,7 @@ import (
 	"github.com/tis24dev/proxsave/internal/config"
 	"github.com/tis24dev/proxsave/internal/logging"
 	"github.com/tis24dev/proxsave/internal/safeexec"
+	"github.com/tis24dev/proxsave/internal/storage"
 )
 
 // decryptPathOption describes a logical backup source (local, secondary, cloud)
@@ -88,6 +89,15 @@ func buildDecryptPathOptions(cfg *config.Config, logger *logging.Logger) (option
 	return options
 }
 
+// rcloneReadRef returns the rclone source to read a cloud backup object from:
+// the object itself, or a chunker overlay over it when it was uploaded in parts.
+func rcloneReadRef(ref string, chunked bool) string {
+	if !chunked {
+		return ref
+	}
+	return storage.ChunkedRemoteRef(ref)
+}
+
 // discoverRcloneBackups lists backup candidates from an rclone remote and returns
 // backup candidates backed by that remote (bundles and raw archives).
 func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath string, logger *logging.Logger, report ProgressReporter) (candidates []*backupCandidate, err error) {
@@ -143,12 +153,22 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 
 	snapshot := make(map[string]struct{}, len(lines))
 	ordered := make([]string, 0, len(lines))
+	// Archives uploaded in parts appear as "<name>.rclone_chunk.NNN"; list them
+	// under their composite name. Temporary parts of an unfinished upload are skipped.
+	chunked := make(map[string]bool)
 	for _, line := range lines {
 		filename := strings.TrimSpace(line)
 		if filename == "" {
 			emptyEntries++
 			continue
 		}
+		if base, committed, ok := storage.ChunkedObjectName(filename); ok {
+			if !committed || chunked[base] {
+				continue
+			}
+			chunked[base] = true
+			filename = base
+		}
 		if _, ok := snapshot[filename]; ok {
 			continue
 		}
@@ -165,12 +185,24 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 	}
 
 	type inspectItem struct {
-		kind           decryptSourceType
-		filename       string
-		remoteBundle   string
-		remoteArchive  string
-		remoteMetadata string
-		remoteChecksum string
+		kind            decryptSourceType
+		chunked         bool
+		filename        string
+		remoteBundle    string
+		remoteArchive   string
+		remoteMetadata  string
+		remoteChecksum  string
+		remoteSignature string
+		split           bool
+	}
+
+	// A split archive (ARCHIVE_VOLUME_SIZE) is listed as volumes instead of the archive.
+	archiveListed := func(archiveName string) (present, split bool) {
+		if _, ok := snapshot[archiveName]; ok {
+			return true, false
+		}
+		_, ok := snapshot[filepath.Base(backup.VolumePath(archiveName, 1))]
+		return ok, ok
 	}
 
 	items := make([]inspectItem, 0)
@@ -179,6 +211,7 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 		case strings.HasSuffix(filename, ".bundle.tar"):
 			items = append(items, inspectItem{
 				kind:         sourceBundle,
+				chunked:      chunked[filename],
 				filename:     filename,
 				remoteBundle: joinRemote(fullPath, filename),
 			})
@@ -188,7 +221,8 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 				nonCandidateEntries++
 				continue
 			}
-			if _, ok := snapshot[archiveName]; !ok {
+			present, split := archiveListed(archiveName)
+			if !present {
 				nonCandidateEntries++
 				continue
 			}
@@ -199,12 +233,19 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 			if _, ok := snapshot[archiveName+".sha256"]; ok {
 				remoteChecksum = joinRemote(fullPath, archiveName+".sha256")
 			}
+			remoteSignature := ""
+			if _, ok := snapshot[archiveName+backup.ManifestSignatureSuffix]; ok {
+				remoteSignature = joinRemote(fullPath, archiveName+backup.ManifestSignatureSuffix)
+			}
 			items = append(items, inspectItem{
-				kind:           sourceRaw,
-				filename:       filename,
-				remoteArchive:  remoteArchive,
-				remoteMetadata: remoteMetadata,
-				remoteChecksum: remoteChecksum,
+				kind:            sourceRaw,
+				chunked:         chunked[archiveName],
+				filename:        filename,
+				remoteArchive:   remoteArchive,
+				remoteMetadata:  remoteMetadata,
+				remoteChecksum:  remoteChecksum,
+				remoteSignature: remoteSignature,
+				split:           split,
 			})
 		case strings.HasSuffix(filename, ".manifest.json"):
 			archiveName := strings.TrimSuffix(filename, ".manifest.json")
@@ -212,7 +253,8 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 				nonCandidateEntries++
 				continue
 			}
-			if _, ok := snapshot[archiveName]; !ok {
+			present, split := archiveListed(archiveName)
+			if !present {
 				nonCandidateEntries++
 				continue
 			}
@@ -230,12 +272,19 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 			if _, ok := snapshot[archiveName+".sha256"]; ok {
 				remoteChecksum = joinRemote(fullPath, archiveName+".sha256")
 			}
+			remoteSignature := ""
+			if _, ok := snapshot[archiveName+backup.ManifestSignatureSuffix]; ok {
+				remoteSignature = joinRemote(fullPath, archiveName+backup.ManifestSignatureSuffix)
+			}
 			items = append(items, inspectItem{
-				kind:           sourceRaw,
-				filename:       filename,
-				remoteArchive:  remoteArchive,
-				remoteMetadata: remoteManifest,
-				remoteChecksum: remoteChecksum,
+				kind:            sourceRaw,
+				chunked:         chunked[archiveName],
+				filename:        filename,
+				remoteArchive:   remoteArchive,
+				remoteMetadata:  remoteManifest,
+				remoteChecksum:  remoteChecksum,
+				remoteSignature: remoteSignature,
+				split:           split,
 			})
 		default:
 			nonCandidateEntries++
@@ -254,7 +303,7 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 		switch item.kind {
 		case sourceBundle:
 			bundleCtx, cancel := context.WithTimeout(ctx, timeout)
-			manifest, perr := inspectRcloneBundleManifest(bundleCtx, item.remoteBundle, logger)
+			manifest, perr := inspectRcloneBundleManifest(bundleCtx, rcloneReadRef(item.remoteBundle, item.chunked), logger)
 			cancel()
 			if perr != nil {
 				if errors.Is(perr, context.DeadlineExceeded) {
@@ -273,11 +322,12 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 				displayBase = filepath.Base(item.filename)
 			}
 			candidates = append(candidates, &backupCandidate{
-				Manifest:    manifest,
-				Source:      sourceBundle,
-				BundlePath:  item.remoteBundle,
-				DisplayBase: displayBase,
-				IsRclone:    true,
+				Manifest:      manifest,
+				Source:        sourceBundle,
+				BundlePath:    item.remoteBundle,
+				DisplayBase:   displayBase,
+				IsRclone:      true,
+				RcloneChunked: item.chunked,
 			})
 			logDebug(logger, "Cloud (rclone): accepted backup bundle: %s", item.filename)
 
@@ -325,19 +375,30 @@ func discoverRcloneBackups(ctx context.Context, cfg *config.Config, remotePath s
 				logWarning(logger, "Skipping rclone backup %s: %v", item.filename, perr)
 				continue
 			}
+			var volumes []archiveVolumeRef
+			if item.split {
+				if volumes, perr = rcloneArchiveVolumes(item.remoteArchive, manifest, snapshot, chunked); perr != nil {
+					integrityMissing++
+					logWarning(logger, "Skipping rclone split backup %s: %v", item.filename, perr)
+					continue
+				}
+			}
 			displayBase := filepath.Base(manifest.ArchivePath)
 			if strings.TrimSpace(displayBase) == "" {
 				displayBase = filepath.Base(baseNameFromRemoteRef(item.remoteArchive))
 			}
 			candidates = append(candidates, &backupCandidate{
-				Manifest:        manifest,
-				Source:          sourceRaw,
-				RawArchivePath:  item.remoteArchive,
-				RawMetadataPath: item.remoteMetadata,
-				RawChecksumPath: item.remoteChecksum,
-				Integrity:       expectation,
-				DisplayBase:     displayBase,
-				IsRclone:        true,
+				Manifest:         manifest,
+				Source:           sourceRaw,
+				RawArchivePath:   item.remoteArchive,
+				RawMetadataPath:  item.remoteMetadata,
+				RawChecksumPath:  item.remoteChecksum,
+				RawSignaturePath: item.remoteSignature,
+				Integrity:        expectation,
+				DisplayBase:      displayBase,
+				IsRclone:         true,
+				RcloneChunked:    item.chunked,
+				RawVolumes:       volumes,
 			})
 		default:
 			continue
@@ -443,10 +504,15 @@ func discoverBackupCandidates(logger *logging.Logger, root string) (candidates [
 				continue
 			}
 			archivePath := filepath.Join(root, baseName)
+			// A split archive (ARCHIVE_VOLUME_SIZE) has volumes instead of the archive.
+			split := false
 			if _, err := restoreFS.Stat(archivePath); err != nil {
-				metadataMissingArchive++
-				logging.DebugStep(logger, "discover backup candidates", "skip metadata %s (missing archive %s)", name, baseName)
-				continue
+				if _, verr := restoreFS.Stat(backup.VolumePath(archivePath, 1)); verr != nil {
+					metadataMissingArchive++
+					logging.DebugStep(logger, "discover backup candidates", "skip metadata %s (missing archive %s)", name, baseName)
+					continue
+				}
+				split = true
 			}
 			checksumPath := archivePath + ".sha256"
 			hasChecksum := true
@@ -457,6 +523,10 @@ func discoverBackupCandidates(logger *logging.Logger, root string) (candidates [
 				checksumPath = ""
 				hasChecksum = false
 			}
+			signaturePath := backup.ManifestSignaturePath(archivePath)
+			if _, err := restoreFS.Stat(signaturePath); err != nil {
+				signaturePath = ""
+			}
 			logging.DebugStep(logger, "discover backup candidates", "load manifest: %s", name)
 			manifest, err := backup.LoadManifest(fullPath)
 			if err != nil {
@@ -485,16 +555,26 @@ func discoverBackupCandidates(logger *logging.Logger, root string) (candidates [
 				logWarning(logger, "Skipping backup %s: %v", baseName, err)
 				continue
 			}
+			var volumes []archiveVolumeRef
+			if split {
+				if volumes, err = localArchiveVolumes(archivePath, manifest); err != nil {
+					metadataMissingArchive++
+					logWarning(logger, "Skipping split backup %s: %v", baseName, err)
+					continue
+				}
+			}
 
 			rawBases[baseName] = struct{}{}
 			candidates = append(candidates, &backupCandidate{
-				Manifest:        manifest,
-				Source:          sourceRaw,
-				RawArchivePath:  archivePath,
-				RawMetadataPath: fullPath,
-				RawChecksumPath: checksumPath,
-				Integrity:       expectation,
-				DisplayBase:     filepath.Base(manifest.ArchivePath),
+				Manifest:         manifest,
+				Source:           sourceRaw,
+				RawArchivePath:   archivePath,
+				RawMetadataPath:  fullPath,
+				RawChecksumPath:  checksumPath,
+				RawSignaturePath: signaturePath,
+				Integrity:        expectation,
+				DisplayBase:      filepath.Base(manifest.ArchivePath),
+				RawVolumes:       volumes,
 			})
 			logging.DebugStep(logger, "discover backup candidates", "raw candidate accepted: %s created_at=%s", name, manifest.CreatedAt.Format(time.RFC3339))
 		}
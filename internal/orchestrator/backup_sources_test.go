@@ -370,6 +370,60 @@ esac
 	})
 }
 
+func TestDiscoverRcloneBackups_DiscoversChunkedArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	manifest := backup.Manifest{
+		ArchivePath:    "/var/backups/node-backup-20251207.tar.xz",
+		ProxmoxType:    "pve",
+		CreatedAt:      time.Date(2025, 12, 7, 12, 0, 0, 0, time.UTC),
+		EncryptionMode: "none",
+		SHA256:         checksumHexForBytes([]byte("node-backup-20251207")),
+	}
+	manifestBytes, err := json.Marshal(&manifest)
+	if err != nil {
+		t.Fatalf("marshal manifest: %v", err)
+	}
+	manifestPath := filepath.Join(tmpDir, "manifest.json")
+	if err := os.WriteFile(manifestPath, manifestBytes, 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	scriptPath := filepath.Join(tmpDir, "rclone")
+	script := `#!/bin/sh
+case "$1" in
+  lsf) printf '%s' "$LSF_LINES" ;;
+  cat) cat "$MANIFEST_PATH" ;;
+  *) echo "unexpected subcommand: $1" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(scriptPath, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake rclone: %v", err)
+	}
+	prependPathEnv(t, tmpDir)
+	t.Setenv("MANIFEST_PATH", manifestPath)
+	t.Setenv("LSF_LINES", "node-backup-20251207.tar.xz.rclone_chunk.001\n"+
+		"node-backup-20251207.tar.xz.rclone_chunk.002\n"+
+		"node-backup-20251207.tar.xz.manifest.json\n"+
+		"node-backup-20251208.tar.xz.rclone_chunk.001_ab12cd34\n"+
+		"node-backup-20251208.tar.xz.manifest.json\n")
+
+	candidates, err := discoverRcloneBackups(context.Background(), nil, "gdrive:pbs-backups/server1", nil, nil)
+	if err != nil {
+		t.Fatalf("discoverRcloneBackups() error = %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates; want 1 (the unfinished upload is skipped)", len(candidates))
+	}
+	cand := candidates[0]
+	if !cand.RcloneChunked || cand.RawArchivePath != "gdrive:pbs-backups/server1/node-backup-20251207.tar.xz" {
+		t.Fatalf("candidate = chunked %v, archive %q; want the chunked composite", cand.RcloneChunked, cand.RawArchivePath)
+	}
+	if got, want := rcloneReadRef(cand.RawArchivePath, cand.RcloneChunked), `:chunker,remote="gdrive:pbs-backups/server1",meta_format=none:node-backup-20251207.tar.xz`; got != want {
+		t.Fatalf("rcloneReadRef() = %s, want %s", got, want)
+	}
+}
+
 func TestDiscoverRcloneBackups_MixedCandidatesSortedByCreatedAt(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1,9 +1,13 @@
 package orchestrator
 
 import (
+	"fmt"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/tis24dev/proxsave/internal/backup"
 )
 
 // CategoryType represents the type of category
@@ -582,6 +586,47 @@ func GetSelectedPaths(categories []Category) []string {
 	return paths
 }
 
+// mergeCustomBrickCategories maps the directories collected by declarative
+// custom bricks onto restore categories: paths are appended to an existing
+// category with the same ID, otherwise a new common category is created.
+func mergeCustomBrickCategories(categories []Category, index *backup.CustomBrickIndex) []Category {
+	if index == nil || len(index.Bricks) == 0 {
+		return categories
+	}
+	merged := append([]Category(nil), categories...)
+	for _, brick := range index.Bricks {
+		if brick.Category == "" || len(brick.Paths) == 0 {
+			continue
+		}
+		cat := GetCategoryByID(brick.Category, merged)
+		if cat == nil {
+			name := strings.TrimSpace(brick.CategoryName)
+			if name == "" {
+				name = fmt.Sprintf("Custom: %s", brick.Category)
+			}
+			description := strings.TrimSpace(brick.Description)
+			if description == "" {
+				description = fmt.Sprintf("Files collected by custom brick %s", brick.ID)
+			}
+			merged = append(merged, Category{
+				ID:          brick.Category,
+				Name:        name,
+				Description: description,
+				Type:        CategoryTypeCommon,
+			})
+			cat = &merged[len(merged)-1]
+		}
+		paths := append([]string(nil), cat.Paths...)
+		for _, p := range brick.Paths {
+			if !slices.Contains(paths, p) {
+				paths = append(paths, p)
+			}
+		}
+		cat.Paths = paths
+	}
+	return merged
+}
+
 // GetCategoryByID finds a category by its ID
 func GetCategoryByID(id string, categories []Category) *Category {
 	for i := range categories {
@@ -0,0 +1,816 @@
+// Package orchestrator coordinates backup, restore, decrypt, and related workflows.
+package orchestrator
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// ErrNoArchivedCorosyncConfig is returned by the cluster rebuild assistant when the
+// selected backup does not carry a corosync.conf.
+var ErrNoArchivedCorosyncConfig = errors.New("no corosync.conf found in backup")
+
+// ClusterRebuildMode selects what the cluster rebuild assistant does with the node.
+type ClusterRebuildMode string
+
+const (
+	ClusterRebuildJoin       ClusterRebuildMode = "join"
+	ClusterRebuildStandalone ClusterRebuildMode = "standalone"
+)
+
+const maxCorosyncLinks = 8
+
+var (
+	clusterRebuildGeteuid     = os.Geteuid
+	clusterRebuildLocalAddrs  = listLocalInterfaceAddrs
+	clusterRebuildNodeName    = localNodeName
+	clusterRebuildSafetyBakFn = CreateClusterRebuildBackup
+)
+
+// ClusterRebuildUI groups the prompts used by the cluster rebuild assistant. The
+// key arguments name the profile entry that answers the prompt in unattended runs.
+type ClusterRebuildUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	SelectClusterRebuildMode(ctx context.Context, cluster corosyncClusterInfo, node string) (ClusterRebuildMode, error)
+	PromptClusterRebuildValue(ctx context.Context, key, label, defaultValue string) (string, error)
+	ConfirmClusterRebuild(ctx context.Context, key, title, message string, defaultYes bool) (bool, error)
+}
+
+// corosyncNode is one node entry of the corosync nodelist.
+type corosyncNode struct {
+	Name   string
+	NodeID string
+	Votes  string
+	Links  map[int]string // link (ring) number -> address
+}
+
+// corosyncClusterInfo is the part of corosync.conf the assistant works with.
+type corosyncClusterInfo struct {
+	ClusterName   string
+	ConfigVersion string
+	Nodes         []corosyncNode
+}
+
+func (c corosyncClusterInfo) node(name string) *corosyncNode {
+	for i := range c.Nodes {
+		if strings.EqualFold(c.Nodes[i].Name, name) {
+			return &c.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// localIfaceAddr is an address configured on a local network interface.
+type localIfaceAddr struct {
+	Iface  string
+	Prefix netip.Prefix
+}
+
+// corosyncLinkPlan is the address chosen for one corosync link of this node.
+type corosyncLinkPlan struct {
+	Link     int
+	Archived string
+	Address  string
+	Reason   string
+}
+
+// clusterFSState is the state of the cluster filesystem a step leaves behind; it
+// tells runClusterRebuildSteps what to bring back up when a later step fails.
+type clusterFSState int
+
+const (
+	clusterFSUnchanged clusterFSState = iota
+	clusterFSStopped
+	clusterFSLocalMode
+	clusterFSRunning
+)
+
+// clusterRebuildStep is one logged step of the rebuild. Manual steps are printed for
+// the operator instead of being executed. Steps with an Action run in-process and
+// their Command only documents the equivalent shell command.
+type clusterRebuildStep struct {
+	Description string
+	Command     []string
+	Manual      bool
+	Action      func() error
+	After       clusterFSState
+}
+
+func (s clusterRebuildStep) commandLine() string {
+	return strings.Join(s.Command, " ")
+}
+
+// clusterRebuildPlan is the full list of steps for the selected mode.
+type clusterRebuildPlan struct {
+	Mode       ClusterRebuildMode
+	Node       string
+	Links      []corosyncLinkPlan
+	StaleNodes []string
+	Steps      []clusterRebuildStep
+	Notes      []string
+}
+
+// RunClusterRebuildWorkflow rebuilds the cluster membership of a freshly installed
+// node from a cluster backup: it either rejoins the node to the existing cluster or
+// converts it to a standalone node. With a profile the decisions are read from the
+// profile instead of being prompted for.
+func RunClusterRebuildWorkflow(ctx context.Context, cfg *config.Config, logger *logging.Logger, version, profilePath string) (err error) {
+	if cfg == nil {
+		return fmt.Errorf("configuration not available")
+	}
+	if logger == nil {
+		logger = logging.GetDefaultLogger()
+	}
+	done := logging.DebugStart(logger, "cluster rebuild workflow", "dry_run=%v profile=%s", cfg.DryRun, profilePath)
+	defer func() { done(err) }()
+	defer func() { err = normalizeRestoreWorkflowUIError(ctx, logger, err) }()
+
+	if !restoreSystem.DetectCurrentSystem().SupportsPVE() {
+		return fmt.Errorf("cluster rebuild requires a Proxmox VE host")
+	}
+	if clusterRebuildGeteuid() != 0 && !cfg.DryRun {
+		return fmt.Errorf("cluster rebuild requires root privileges")
+	}
+
+	cliUI := newCLIWorkflowUI(bufio.NewReader(os.Stdin), logger)
+	var ui ClusterRebuildUI = cliUI
+	var profile *clusterRebuildProfile
+	if strings.TrimSpace(profilePath) != "" {
+		if profile, err = loadClusterRebuildProfile(profilePath); err != nil {
+			return err
+		}
+		ui = &clusterRebuildProfileUI{profile: profile, logger: logger}
+	}
+
+	var prepared *preparedBundle
+	if profile != nil && profile.values["ARCHIVE"] != "" {
+		prepared, err = prepareClusterRebuildProfileArchive(ctx, cfg, logger, version, profile)
+	} else {
+		_, prepared, err = prepareRestoreBundleFunc(ctx, cfg, logger, version, cliUI)
+	}
+	if err != nil {
+		return err
+	}
+	defer prepared.Cleanup()
+
+	return runClusterRebuildWithUI(ctx, logger, ui, prepared.ArchivePath, cfg.DryRun)
+}
+
+func runClusterRebuildWithUI(ctx context.Context, logger *logging.Logger, ui ClusterRebuildUI, archivePath string, dryRun bool) error {
+	cluster, err := loadArchivedCorosyncConfig(ctx, archivePath)
+	if err != nil {
+		return err
+	}
+	node := clusterRebuildNodeName()
+	logging.DebugStep(logger, "cluster rebuild", "cluster=%s nodes=%d local node=%s", cluster.ClusterName, len(cluster.Nodes), node)
+
+	mode, err := ui.SelectClusterRebuildMode(ctx, cluster, node)
+	if err != nil {
+		return err
+	}
+
+	var plan *clusterRebuildPlan
+	switch mode {
+	case ClusterRebuildJoin:
+		plan, err = planClusterRejoin(ctx, ui, cluster, node)
+	case ClusterRebuildStandalone:
+		plan, err = planClusterStandalone(ctx, ui, node)
+	default:
+		return fmt.Errorf("unknown cluster rebuild mode %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ui.ShowMessage(ctx, "Cluster rebuild plan", describeClusterRebuildPlan(plan)); err != nil {
+		return err
+	}
+	if dryRun {
+		logger.Info("Dry run: cluster rebuild plan not executed")
+		return nil
+	}
+	if len(plan.Steps) == 0 {
+		logger.Info("Cluster rebuild: nothing to do")
+		return nil
+	}
+
+	confirmed, err := ui.ConfirmClusterRebuild(ctx, "CONFIRM", "Run the cluster rebuild?",
+		"The steps above change the cluster membership of this node. A safety backup of /var/lib/pve-cluster and /etc/corosync is taken first; revert with 'proxsave --restore-rollback'.",
+		false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return ErrRestoreAborted
+	}
+
+	backup, err := clusterRebuildSafetyBakFn(logger)
+	if err != nil {
+		return fmt.Errorf("create cluster rebuild safety backup: %w", err)
+	}
+	backupPath := ""
+	if backup != nil {
+		backupPath = backup.BackupPath
+		logger.Info("Cluster rebuild safety backup: %s", backupPath)
+	}
+	return runClusterRebuildSteps(ctx, logger, plan.Steps, backupPath)
+}
+
+// planClusterRejoin reconciles the corosync link addresses of this node with the
+// current NICs and builds the pvecm add flow against a surviving cluster member.
+func planClusterRejoin(ctx context.Context, ui ClusterRebuildUI, cluster corosyncClusterInfo, node string) (*clusterRebuildPlan, error) {
+	plan := &clusterRebuildPlan{Mode: ClusterRebuildJoin, Node: node}
+	self := cluster.node(node)
+	var peers []corosyncNode
+	for _, n := range cluster.Nodes {
+		if !strings.EqualFold(n.Name, node) {
+			peers = append(peers, n)
+		}
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("cluster %s in the backup has no other node to join", cluster.ClusterName)
+	}
+
+	locals, err := clusterRebuildLocalAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("list local addresses: %w", err)
+	}
+	if self == nil {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("Node %s is not in the archived nodelist; it joins as a new member.", node))
+		self = &corosyncNode{Name: node, Links: map[int]string{0: ""}}
+	}
+	plan.Links = reconcileCorosyncLinks(*self, peers, locals)
+
+	target, err := ui.PromptClusterRebuildValue(ctx, "JOIN_ADDRESS", "Address of a running cluster member", peers[0].Links[0])
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(target) == "" {
+		return nil, fmt.Errorf("a cluster member address is required to join")
+	}
+	for i := range plan.Links {
+		link := &plan.Links[i]
+		label := fmt.Sprintf("Corosync link %d address for this node (archived %s)", link.Link, valueOrNone(link.Archived))
+		value, err := ui.PromptClusterRebuildValue(ctx, fmt.Sprintf("LINK%d", link.Link), label, link.Address)
+		if err != nil {
+			return nil, err
+		}
+		if value = strings.TrimSpace(value); value != link.Address {
+			link.Address = value
+			link.Reason = "set by operator"
+		}
+		if link.Address == "" {
+			return nil, fmt.Errorf("no address for corosync link %d", link.Link)
+		}
+	}
+	nodeID, err := ui.PromptClusterRebuildValue(ctx, "NODEID", "Node ID (empty: assigned by the cluster)", self.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := ui.PromptClusterRebuildValue(ctx, "JOIN_FINGERPRINT", "Certificate fingerprint of the cluster member (empty: verify interactively)", "")
+	if err != nil {
+		return nil, err
+	}
+	useSSH, err := ui.ConfirmClusterRebuild(ctx, "JOIN_USE_SSH", "Join over SSH?",
+		fmt.Sprintf("Run 'pvecm add' now using SSH key authentication to root@%s?\nChoose No to get the command to run yourself (it asks for the root password of %s).", target, target),
+		false)
+	if err != nil {
+		return nil, err
+	}
+
+	if live, _ := liveNodeIsClustered(); live {
+		plan.Steps = append(plan.Steps, clusterLocalCleanupSteps()...)
+	}
+	if cluster.node(node) != nil {
+		plan.Steps = append(plan.Steps, clusterRebuildStep{
+			Description: fmt.Sprintf("On %s, remove the old membership of %s (skip if already removed)", target, node),
+			Command:     []string{"pvecm", "delnode", node},
+			Manual:      true,
+		})
+	}
+	if guests := countLiveNodeGuests(node); guests > 0 {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%d guest config(s) exist on this node; 'pvecm add' refuses to join with guests (move them away or re-create them after the join).", guests))
+	}
+
+	join := []string{"pvecm", "add", target}
+	for _, link := range plan.Links {
+		join = append(join, fmt.Sprintf("--link%d", link.Link), link.Address)
+	}
+	join = appendNonEmptyFlag(join, "--nodeid", nodeID)
+	if useSSH {
+		join = append(join, "--use_ssh", "1")
+	} else {
+		join = appendNonEmptyFlag(join, "--fingerprint", fingerprint)
+	}
+	plan.Steps = append(plan.Steps, clusterRebuildStep{
+		Description: fmt.Sprintf("Join cluster %s through %s", cluster.ClusterName, target),
+		Command:     join,
+		Manual:      !useSSH,
+	})
+	return plan, nil
+}
+
+// planClusterStandalone turns the node into a standalone node: the expected votes
+// fix when the node has no quorum, removal of the corosync configuration in pmxcfs
+// local mode and removal of the directories of nodes that no longer exist.
+func planClusterStandalone(ctx context.Context, ui ClusterRebuildUI, node string) (*clusterRebuildPlan, error) {
+	plan := &clusterRebuildPlan{Mode: ClusterRebuildStandalone, Node: node}
+	if live, _ := liveNodeIsClustered(); live {
+		if !liveNodeIsQuorate(ctx) {
+			plan.Steps = append(plan.Steps, clusterRebuildStep{
+				Description: "Expected votes fix: regain quorum with this node alone",
+				Command:     []string{"pvecm", "expected", "1"},
+			})
+		}
+		plan.Steps = append(plan.Steps, clusterLocalCleanupSteps()...)
+	} else {
+		plan.Notes = append(plan.Notes, "No corosync configuration is active on this node.")
+	}
+
+	stale, withGuests := listStaleNodeDirs(node)
+	for _, name := range withGuests {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("Node directory %s still holds guest configs and is kept; restore them with --restore-guests or move them first.", name))
+	}
+	if len(stale) > 0 {
+		remove, err := ui.ConfirmClusterRebuild(ctx, "REMOVE_STALE_NODES", "Remove stale node directories?",
+			fmt.Sprintf("These node directories under /etc/pve/nodes belong to other cluster nodes and hold no guests:\n\n  %s\n\nRemove them?", strings.Join(stale, "\n  ")),
+			true)
+		if err != nil {
+			return nil, err
+		}
+		if remove {
+			plan.StaleNodes = stale
+			for _, name := range stale {
+				plan.Steps = append(plan.Steps, clusterRebuildStep{
+					Description: fmt.Sprintf("Remove stale node directory of %s", name),
+					Command:     []string{"rm", "-rf", filepath.Join(pveNodesDir, name)},
+					Action:      func() error { return removeStaleNodeDir(name) },
+				})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// clusterLocalCleanupSteps removes the corosync configuration of this node using
+// pmxcfs local mode, leaving /etc/pve content in place.
+func clusterLocalCleanupSteps() []clusterRebuildStep {
+	return []clusterRebuildStep{
+		{Description: "Stop the cluster filesystem and corosync", Command: []string{"systemctl", "stop", "pve-cluster", "corosync"}, After: clusterFSStopped},
+		{Description: "Start pmxcfs in local mode", Command: []string{"pmxcfs", "-l"}, After: clusterFSLocalMode},
+		{
+			Description: "Remove the cluster corosync.conf",
+			Command:     []string{"rm", "-f", "/etc/pve/corosync.conf"},
+			Action:      func() error { return removeIfExists("/etc/pve/corosync.conf") },
+		},
+		{
+			Description: "Remove the local corosync configuration and authkey",
+			Command:     []string{"find", "/etc/corosync", "-mindepth", "1", "-delete"},
+			Action:      func() error { return clearDirContents("/etc/corosync") },
+		},
+		{Description: "Stop local-mode pmxcfs", Command: []string{"killall", "pmxcfs"}, After: clusterFSStopped},
+		{Description: "Start the cluster filesystem standalone", Command: []string{"systemctl", "start", "pve-cluster"}, After: clusterFSRunning},
+	}
+}
+
+const pveNodesDir = "/etc/pve/nodes"
+
+// removeStaleNodeDir removes /etc/pve/nodes/<name>, refusing names that would
+// resolve anywhere else.
+func removeStaleNodeDir(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid node name %q", name)
+	}
+	path := filepath.Join(pveNodesDir, name)
+	if filepath.Dir(path) != pveNodesDir {
+		return fmt.Errorf("node directory %s is outside %s", path, pveNodesDir)
+	}
+	return restoreFS.RemoveAll(path)
+}
+
+// clearDirContents removes everything inside dir, keeping dir itself.
+func clearDirContents(dir string) error {
+	entries, err := restoreFS.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if err := restoreFS.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverClusterFS brings pve-cluster back after a failed step so the node is not
+// left with /etc/pve unmounted. Best-effort: the original error is what matters.
+func recoverClusterFS(ctx context.Context, logger *logging.Logger, state clusterFSState) {
+	if state != clusterFSStopped && state != clusterFSLocalMode {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if state == clusterFSLocalMode {
+		if _, err := restoreCmd.Run(ctx, "killall", "pmxcfs"); err != nil {
+			logger.Warning("Cluster rebuild: failed to stop local-mode pmxcfs: %v", err)
+		}
+	}
+	logger.Warning("Cluster rebuild: restarting pve-cluster after the failed step")
+	if _, err := restoreCmd.Run(ctx, "systemctl", "start", "pve-cluster"); err != nil {
+		logger.Warning("Cluster rebuild: failed to restart pve-cluster: %v (run 'systemctl start pve-cluster' manually)", err)
+	}
+}
+
+func runClusterRebuildSteps(ctx context.Context, logger *logging.Logger, steps []clusterRebuildStep, backupPath string) (err error) {
+	var manual []clusterRebuildStep
+	state := clusterFSUnchanged
+	defer func() {
+		if err != nil {
+			recoverClusterFS(ctx, logger, state)
+		}
+	}()
+	for i, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		logger.Info("Cluster rebuild [%d/%d] %s", i+1, len(steps), step.Description)
+		if step.Manual {
+			logger.Info("  manual step: %s", step.commandLine())
+			manual = append(manual, step)
+			continue
+		}
+		logger.Info("  $ %s", step.commandLine())
+		var stepErr error
+		if step.Action != nil {
+			stepErr = step.Action()
+		} else {
+			var out []byte
+			out, stepErr = restoreCmd.Run(ctx, step.Command[0], step.Command[1:]...)
+			if text := strings.TrimSpace(string(out)); text != "" {
+				logger.Debug("  %s", text)
+			}
+		}
+		if stepErr != nil {
+			hint := ""
+			if backupPath != "" {
+				hint = fmt.Sprintf(" (revert with 'proxsave --restore-rollback', safety backup %s)", backupPath)
+			}
+			return fmt.Errorf("cluster rebuild step %q failed: %w%s", step.Description, stepErr, hint)
+		}
+		if step.After != clusterFSUnchanged {
+			state = step.After
+		}
+	}
+	if len(manual) > 0 {
+		logger.Warning("Cluster rebuild: %d manual step(s) left to run:", len(manual))
+		for _, step := range manual {
+			logger.Warning("  %s: %s", step.Description, step.commandLine())
+		}
+	}
+	logger.Info("Cluster rebuild: %d step(s) completed", len(steps)-len(manual))
+	return nil
+}
+
+func describeClusterRebuildPlan(plan *clusterRebuildPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Mode: %s (node %s)\n", plan.Mode, plan.Node)
+	if len(plan.Links) > 0 {
+		b.WriteString("\nCorosync links:\n")
+		for _, link := range plan.Links {
+			fmt.Fprintf(&b, "  link%d: %s -> %s (%s)\n", link.Link, valueOrNone(link.Archived), valueOrNone(link.Address), link.Reason)
+		}
+	}
+	if len(plan.Steps) > 0 {
+		b.WriteString("\nSteps:\n")
+		for i, step := range plan.Steps {
+			kind := "run"
+			if step.Manual {
+				kind = "manual"
+			}
+			fmt.Fprintf(&b, "  %d. [%s] %s\n       %s\n", i+1, kind, step.Description, step.commandLine())
+		}
+	}
+	for _, note := range plan.Notes {
+		fmt.Fprintf(&b, "\nNote: %s\n", note)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func valueOrNone(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// reconcileCorosyncLinks picks the local address for every archived link of self: the
+// archived address when it is still configured, otherwise the local address in the
+// same subnet as a peer's address on that link.
+func reconcileCorosyncLinks(self corosyncNode, peers []corosyncNode, locals []localIfaceAddr) []corosyncLinkPlan {
+	links := make([]int, 0, len(self.Links))
+	for link := range self.Links {
+		links = append(links, link)
+	}
+	sort.Ints(links)
+
+	plans := make([]corosyncLinkPlan, 0, len(links))
+	for _, link := range links {
+		archived := self.Links[link]
+		plan := corosyncLinkPlan{Link: link, Archived: archived}
+		addr, err := netip.ParseAddr(archived)
+		switch {
+		case archived != "" && err != nil:
+			plan.Address = archived
+			plan.Reason = "host name, resolved by corosync"
+		case err == nil && localHasAddr(locals, addr):
+			plan.Address = archived
+			plan.Reason = "still configured on " + localIfaceFor(locals, addr)
+		default:
+			plan.Reason = "no local address on the link network"
+			for _, peer := range peers {
+				peerAddr, err := netip.ParseAddr(peer.Links[link])
+				if err != nil {
+					continue
+				}
+				for _, local := range locals {
+					if local.Prefix.Masked().Contains(peerAddr) {
+						plan.Address = local.Prefix.Addr().String()
+						plan.Reason = fmt.Sprintf("same network as %s (%s) on %s", peer.Name, peerAddr, local.Iface)
+						break
+					}
+				}
+				if plan.Address != "" {
+					break
+				}
+			}
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+func localHasAddr(locals []localIfaceAddr, addr netip.Addr) bool {
+	return localIfaceFor(locals, addr) != ""
+}
+
+func localIfaceFor(locals []localIfaceAddr, addr netip.Addr) string {
+	for _, local := range locals {
+		if local.Prefix.Addr() == addr {
+			return local.Iface
+		}
+	}
+	return ""
+}
+
+func listLocalInterfaceAddrs() ([]localIfaceAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var out []localIfaceAddr
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(ipNet.String())
+			if err != nil || prefix.Addr().IsLinkLocalUnicast() {
+				continue
+			}
+			out = append(out, localIfaceAddr{Iface: iface.Name, Prefix: prefix})
+		}
+	}
+	return out, nil
+}
+
+// liveNodeIsClustered reports whether a corosync configuration is present on this node.
+func liveNodeIsClustered() (bool, error) {
+	for _, path := range []string{"/etc/pve/corosync.conf", "/etc/corosync/corosync.conf"} {
+		if _, err := restoreFS.Stat(path); err == nil {
+			return true, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func liveNodeIsQuorate(ctx context.Context) bool {
+	out, err := restoreCmd.Run(ctx, "pvecm", "status")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "Quorate" {
+			return strings.EqualFold(strings.TrimSpace(value), "Yes")
+		}
+	}
+	return false
+}
+
+// listStaleNodeDirs returns the /etc/pve/nodes entries of other nodes, split into
+// those that can be removed and those still holding guest configs.
+func listStaleNodeDirs(node string) (removable, withGuests []string) {
+	entries, err := restoreFS.ReadDir(pveNodesDir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.EqualFold(name, node) {
+			continue
+		}
+		if countLiveNodeGuests(name) > 0 {
+			withGuests = append(withGuests, name)
+			continue
+		}
+		removable = append(removable, name)
+	}
+	sort.Strings(removable)
+	sort.Strings(withGuests)
+	return removable, withGuests
+}
+
+func countLiveNodeGuests(node string) int {
+	count := 0
+	for _, dir := range []string{"qemu-server", "lxc"} {
+		entries, err := restoreFS.ReadDir(filepath.Join(pveNodesDir, node, dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".conf") {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// loadArchivedCorosyncConfig reads corosync.conf from the backup, preferring the
+// cluster-wide copy under etc/pve over the local one under etc/corosync.
+func loadArchivedCorosyncConfig(ctx context.Context, archivePath string) (info corosyncClusterInfo, err error) {
+	file, err := restoreFS.Open(archivePath)
+	if err != nil {
+		return info, fmt.Errorf("open archive: %w", err)
+	}
+	defer closeIntoErr(&err, file, "close archive")
+
+	reader, err := createDecompressionReader(ctx, file, archivePath)
+	if err != nil {
+		return info, fmt.Errorf("create decompression reader: %w", err)
+	}
+	defer closeIntoErr(&err, reader, "close decompression reader")
+
+	found := map[string][]byte{}
+	tr := tar.NewReader(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return info, err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return info, err
+		}
+		name := strings.TrimPrefix(header.Name, "./")
+		if header.Typeflag != tar.TypeReg || (name != "etc/pve/corosync.conf" && name != "etc/corosync/corosync.conf") {
+			continue
+		}
+		data, err := readRestoreArchiveEntry(tr, header, maxArchiveInventoryBytes)
+		if err != nil {
+			return info, err
+		}
+		found[name] = data
+	}
+
+	data, ok := found["etc/pve/corosync.conf"]
+	if !ok {
+		data, ok = found["etc/corosync/corosync.conf"]
+	}
+	if !ok {
+		return info, ErrNoArchivedCorosyncConfig
+	}
+	return parseCorosyncConf(string(data))
+}
+
+// parseCorosyncConf extracts the cluster name, config version and nodelist from a
+// corosync.conf. Only the totem and nodelist sections are interpreted.
+func parseCorosyncConf(content string) (corosyncClusterInfo, error) {
+	var info corosyncClusterInfo
+	var stack []string
+	var current *corosyncNode
+	for lineNo, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(line, "{"):
+			section := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			stack = append(stack, section)
+			if section == "node" && len(stack) == 2 && stack[0] == "nodelist" {
+				current = &corosyncNode{Links: map[int]string{}}
+			}
+		case line == "}":
+			if len(stack) == 0 {
+				return info, fmt.Errorf("corosync.conf line %d: unbalanced '}'", lineNo+1)
+			}
+			if current != nil && len(stack) == 2 {
+				info.Nodes = append(info.Nodes, *current)
+				current = nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			switch {
+			case current != nil:
+				setCorosyncNodeValue(current, key, value)
+			case len(stack) == 1 && stack[0] == "totem" && key == "cluster_name":
+				info.ClusterName = value
+			case len(stack) == 1 && stack[0] == "totem" && key == "config_version":
+				info.ConfigVersion = value
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return info, fmt.Errorf("corosync.conf: unterminated section %q", stack[len(stack)-1])
+	}
+	if len(info.Nodes) == 0 {
+		return info, fmt.Errorf("corosync.conf: empty nodelist")
+	}
+	sort.SliceStable(info.Nodes, func(i, j int) bool {
+		a, _ := strconv.Atoi(info.Nodes[i].NodeID)
+		b, _ := strconv.Atoi(info.Nodes[j].NodeID)
+		return a < b
+	})
+	return info, nil
+}
+
+func setCorosyncNodeValue(node *corosyncNode, key, value string) {
+	switch key {
+	case "name":
+		node.Name = value
+	case "nodeid":
+		node.NodeID = value
+	case "quorum_votes":
+		node.Votes = value
+	default:
+		if rest, ok := strings.CutPrefix(key, "ring"); ok {
+			if n, ok := strings.CutSuffix(rest, "_addr"); ok {
+				if link, err := strconv.Atoi(n); err == nil && link >= 0 && link < maxCorosyncLinks {
+					node.Links[link] = value
+				}
+			}
+		}
+	}
+}
+
+// CreateClusterRebuildBackup saves the cluster database and the corosync
+// configuration before the cluster rebuild assistant changes them.
+func CreateClusterRebuildBackup(logger *logging.Logger) (*SafetyBackupResult, error) {
+	all := GetAllCategories()
+	var categories []Category
+	for _, id := range []string{"pve_cluster", "corosync"} {
+		if cat := GetCategoryByID(id, all); cat != nil {
+			categories = append(categories, *cat)
+		}
+	}
+	return createSafetyBackup(logger, categories, "/", safetyBackupSpec{
+		ArchivePrefix:     "cluster_rebuild_backup",
+		LocationFileName:  "cluster_rebuild_backup_location.txt",
+		HumanDescription:  "Cluster rebuild backup",
+		WriteLocationFile: true,
+	})
+}
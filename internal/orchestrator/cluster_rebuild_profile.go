@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// clusterRebuildProfileKeys lists the entries a cluster rebuild profile may set.
+var clusterRebuildProfileKeys = map[string]bool{
+	"MODE":               true,
+	"ARCHIVE":            true,
+	"DECRYPT_IDENTITY":   true,
+	"JOIN_ADDRESS":       true,
+	"JOIN_FINGERPRINT":   true,
+	"JOIN_USE_SSH":       true,
+	"NODEID":             true,
+	"REMOVE_STALE_NODES": true,
+	"CONFIRM":            true,
+}
+
+// clusterRebuildProfile holds the answers of an unattended cluster rebuild, read
+// from a KEY=VALUE file.
+type clusterRebuildProfile struct {
+	path   string
+	mode   ClusterRebuildMode
+	values map[string]string
+}
+
+func loadClusterRebuildProfile(path string) (*clusterRebuildProfile, error) {
+	data, err := restoreFS.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cluster rebuild profile: %w", err)
+	}
+	return parseClusterRebuildProfile(path, string(data))
+}
+
+func parseClusterRebuildProfile(path, content string) (*clusterRebuildProfile, error) {
+	profile := &clusterRebuildProfile{path: path, values: map[string]string{}}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if !clusterRebuildProfileKeys[key] && !isCorosyncLinkKey(key) {
+			return nil, fmt.Errorf("%s:%d: unknown key %s", path, lineNo, key)
+		}
+		profile.values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	switch mode := ClusterRebuildMode(strings.ToLower(profile.values["MODE"])); mode {
+	case ClusterRebuildJoin, ClusterRebuildStandalone:
+		profile.mode = mode
+	case "":
+		return nil, fmt.Errorf("%s: MODE is required (join or standalone)", path)
+	default:
+		return nil, fmt.Errorf("%s: invalid MODE %q (join or standalone)", path, mode)
+	}
+	// The profile is a plain file: it may point at an identity source but never
+	// hold a key or passphrase itself.
+	if identity := profile.values["DECRYPT_IDENTITY"]; identity != "" && !isAgeIdentitySourceRef(identity) {
+		return nil, fmt.Errorf("%s: DECRYPT_IDENTITY must name an identity source (%s, %s or %s)", path,
+			ageIdentitySourceSystemdCreds, ageIdentitySourceUnlockFile, ageIdentitySourcePlugin)
+	}
+	for _, key := range []string{"JOIN_USE_SSH", "REMOVE_STALE_NODES", "CONFIRM"} {
+		if value, ok := profile.values[key]; ok {
+			if _, err := parseProfileBool(value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+	return profile, nil
+}
+
+// prepareClusterRebuildProfileArchive stages the profile's ARCHIVE like an
+// interactively selected backup: integrity check, decryption through
+// DECRYPT_IDENTITY and the manifest signature policy.
+var prepareClusterRebuildProfileArchive = func(ctx context.Context, cfg *config.Config, logger *logging.Logger, version string, profile *clusterRebuildProfile) (*preparedBundle, error) {
+	return prepareRestoreBundleFromPath(ctx, cfg, logger, version, profile.values["ARCHIVE"], profile.values["DECRYPT_IDENTITY"])
+}
+
+func isCorosyncLinkKey(key string) bool {
+	n, ok := strings.CutPrefix(key, "LINK")
+	if !ok {
+		return false
+	}
+	link, err := strconv.Atoi(n)
+	return err == nil && link >= 0 && link < maxCorosyncLinks
+}
+
+func parseProfileBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "y":
+		return true, nil
+	case "0", "false", "no", "n", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// clusterRebuildDestructiveKeys are the confirmations a profile must answer
+// explicitly: left out, they are declined whatever the interactive default is.
+var clusterRebuildDestructiveKeys = map[string]bool{
+	"CONFIRM":            true,
+	"REMOVE_STALE_NODES": true,
+}
+
+// clusterRebuildProfileUI answers the cluster rebuild prompts from a profile. Values
+// missing from the profile fall back to the prompt default; so do confirmations,
+// except the destructive ones (clusterRebuildDestructiveKeys), which default to no.
+type clusterRebuildProfileUI struct {
+	profile *clusterRebuildProfile
+	logger  *logging.Logger
+}
+
+func (p *clusterRebuildProfileUI) ShowMessage(_ context.Context, title, message string) error {
+	p.logger.Info("%s", title)
+	for _, line := range strings.Split(message, "\n") {
+		p.logger.Info("  %s", line)
+	}
+	return nil
+}
+
+func (p *clusterRebuildProfileUI) SelectClusterRebuildMode(context.Context, corosyncClusterInfo, string) (ClusterRebuildMode, error) {
+	p.logger.Info("Cluster rebuild profile %s: mode %s", p.profile.path, p.profile.mode)
+	return p.profile.mode, nil
+}
+
+func (p *clusterRebuildProfileUI) PromptClusterRebuildValue(_ context.Context, key, label, defaultValue string) (string, error) {
+	value, ok := p.profile.values[key]
+	if !ok {
+		value = defaultValue
+	}
+	p.logger.Info("%s: %s (profile %s)", label, valueOrNone(value), key)
+	return value, nil
+}
+
+func (p *clusterRebuildProfileUI) ConfirmClusterRebuild(_ context.Context, key, title, _ string, defaultYes bool) (bool, error) {
+	raw, ok := p.profile.values[key]
+	answer := defaultYes && !clusterRebuildDestructiveKeys[key]
+	if ok {
+		answer, _ = parseProfileBool(raw)
+	}
+	p.logger.Info("%s %v (profile %s)", title, answer, key)
+	return answer, nil
+}
@@ -0,0 +1,483 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/safeexec"
+)
+
+const testCorosyncConf = `logging {
+  debug: off
+  to_syslog: yes
+}
+
+nodelist {
+  node {
+    name: pve2
+    nodeid: 2
+    quorum_votes: 1
+    ring0_addr: 10.0.0.12
+    ring1_addr: 172.16.0.12
+  }
+  node {
+    name: pve1
+    nodeid: 1
+    quorum_votes: 1
+    ring0_addr: 10.0.0.11
+    ring1_addr: 172.16.0.11
+  }
+}
+
+quorum {
+  provider: corosync_votequorum
+}
+
+totem {
+  cluster_name: lab
+  config_version: 5
+  interface {
+    linknumber: 0
+  }
+  ip_version: ipv4-6
+  secauth: on
+  version: 2
+}
+`
+
+func setupClusterRebuildTest(t *testing.T, files map[string]string, outputs map[string][]byte) (*FakeFS, *fakeCommandRunner) {
+	t.Helper()
+	origFS, origCmd := restoreFS, restoreCmd
+	origAddrs, origNode, origBackup := clusterRebuildLocalAddrs, clusterRebuildNodeName, clusterRebuildSafetyBakFn
+	t.Cleanup(func() {
+		restoreFS, restoreCmd = origFS, origCmd
+		clusterRebuildLocalAddrs, clusterRebuildNodeName, clusterRebuildSafetyBakFn = origAddrs, origNode, origBackup
+	})
+	fakeFS := NewFakeFS()
+	t.Cleanup(func() { _ = os.RemoveAll(fakeFS.Root) })
+	restoreFS = fakeFS
+	runner := &fakeCommandRunner{outputs: outputs}
+	restoreCmd = runner
+	clusterRebuildNodeName = func() string { return "pve1" }
+	clusterRebuildLocalAddrs = func() ([]localIfaceAddr, error) {
+		return []localIfaceAddr{
+			{Iface: "vmbr0", Prefix: netip.MustParsePrefix("10.0.0.11/24")},
+			{Iface: "ens19", Prefix: netip.MustParsePrefix("172.16.0.21/24")},
+		}, nil
+	}
+	clusterRebuildSafetyBakFn = func(*logging.Logger) (*SafetyBackupResult, error) {
+		return &SafetyBackupResult{BackupPath: "/tmp/cluster_rebuild_backup_test.tar.gz"}, nil
+	}
+	for path, content := range files {
+		if err := fakeFS.AddFile(path, []byte(content)); err != nil {
+			t.Fatalf("add %s: %v", path, err)
+		}
+	}
+	return fakeFS, runner
+}
+
+func writeClusterRebuildTestArchive(t *testing.T, fakeFS *FakeFS) string {
+	t.Helper()
+	archivePath := filepath.Join(fakeFS.Root, "backup.tar")
+	if err := writeTarFile(archivePath, map[string]string{
+		"./etc/pve/corosync.conf":      testCorosyncConf,
+		"./etc/corosync/corosync.conf": "nodelist {\n  node {\n    name: stale\n    nodeid: 9\n  }\n}\n",
+		"./etc/pve/storage.cfg":        "dir: local\n",
+	}); err != nil {
+		t.Fatalf("writeTarFile: %v", err)
+	}
+	return archivePath
+}
+
+func mustClusterRebuildProfile(t *testing.T, content string) *clusterRebuildProfileUI {
+	t.Helper()
+	profile, err := parseClusterRebuildProfile("rebuild.env", content)
+	if err != nil {
+		t.Fatalf("parseClusterRebuildProfile: %v", err)
+	}
+	return &clusterRebuildProfileUI{profile: profile, logger: newTestLogger()}
+}
+
+func TestParseCorosyncConf(t *testing.T) {
+	info, err := parseCorosyncConf(testCorosyncConf)
+	if err != nil {
+		t.Fatalf("parseCorosyncConf: %v", err)
+	}
+	if info.ClusterName != "lab" || info.ConfigVersion != "5" {
+		t.Fatalf("cluster=%q version=%q", info.ClusterName, info.ConfigVersion)
+	}
+	want := []corosyncNode{
+		{Name: "pve1", NodeID: "1", Votes: "1", Links: map[int]string{0: "10.0.0.11", 1: "172.16.0.11"}},
+		{Name: "pve2", NodeID: "2", Votes: "1", Links: map[int]string{0: "10.0.0.12", 1: "172.16.0.12"}},
+	}
+	if !reflect.DeepEqual(info.Nodes, want) {
+		t.Fatalf("nodes=%+v\nwant %+v", info.Nodes, want)
+	}
+
+	for _, bad := range []string{
+		"totem {\n  cluster_name: lab\n}\n",
+		"nodelist {\n  node {\n    name: pve1\n  }\n",
+		"totem {\n}\n}\n",
+	} {
+		if _, err := parseCorosyncConf(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestReconcileCorosyncLinks(t *testing.T) {
+	self := corosyncNode{Name: "pve1", Links: map[int]string{0: "10.0.0.11", 1: "172.16.0.11", 2: "192.168.9.11", 3: "pve1-ring3"}}
+	peers := []corosyncNode{{Name: "pve2", Links: map[int]string{0: "10.0.0.12", 1: "172.16.0.12", 2: "192.168.9.12"}}}
+	locals := []localIfaceAddr{
+		{Iface: "vmbr0", Prefix: netip.MustParsePrefix("10.0.0.11/24")},
+		{Iface: "ens19", Prefix: netip.MustParsePrefix("172.16.0.21/24")},
+	}
+
+	got := reconcileCorosyncLinks(self, peers, locals)
+	want := []corosyncLinkPlan{
+		{Link: 0, Archived: "10.0.0.11", Address: "10.0.0.11", Reason: "still configured on vmbr0"},
+		{Link: 1, Archived: "172.16.0.11", Address: "172.16.0.21", Reason: "same network as pve2 (172.16.0.12) on ens19"},
+		{Link: 2, Archived: "192.168.9.11", Reason: "no local address on the link network"},
+		{Link: 3, Archived: "pve1-ring3", Address: "pve1-ring3", Reason: "host name, resolved by corosync"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("links=%+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseClusterRebuildProfileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		want    string
+	}{
+		{"ARCHIVE=/root/backup.tar\n", "MODE is required"},
+		{"MODE=rejoin\n", `invalid MODE "rejoin"`},
+		{"MODE=join\nLINK8=10.0.0.1\n", "rebuild.env:2: unknown key LINK8"},
+		{"MODE=join\nJOIN_USE_SSH=maybe\n", `JOIN_USE_SSH: invalid boolean "maybe"`},
+		{"MODE=standalone\njust a line\n", "rebuild.env:2: expected KEY=VALUE"},
+	} {
+		_, err := parseClusterRebuildProfile("rebuild.env", tc.content)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("content %q: err=%v, want %q", tc.content, err, tc.want)
+		}
+	}
+
+	profile, err := parseClusterRebuildProfile("rebuild.env", "# rebuild\nmode = Join\nlink1 = \"172.16.0.30\"\n")
+	if err != nil {
+		t.Fatalf("parseClusterRebuildProfile: %v", err)
+	}
+	if profile.mode != ClusterRebuildJoin || profile.values["LINK1"] != "172.16.0.30" {
+		t.Fatalf("mode=%q values=%v", profile.mode, profile.values)
+	}
+}
+
+func TestPlanClusterStandaloneFixesVotesAndRemovesStaleNodes(t *testing.T) {
+	setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/corosync.conf":                    testCorosyncConf,
+		"/etc/pve/nodes/pve1/qemu-server/100.conf":  "name: local\n",
+		"/etc/pve/nodes/pve2/lrm_status":            "{}",
+		"/etc/pve/nodes/pve3/lxc/200.conf":          "hostname: dns\n",
+		"/etc/pve/nodes/pve4/qemu-server/notes.txt": "not a guest",
+		"/etc/pve/nodes/not-a-node":                 "file",
+	}, map[string][]byte{
+		"pvecm status": []byte("Quorum information\n------------------\nNodes:            1\nQuorate:          No\n"),
+	})
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\nREMOVE_STALE_NODES=yes\n")
+
+	plan, err := planClusterStandalone(context.Background(), ui, "pve1")
+	if err != nil {
+		t.Fatalf("planClusterStandalone: %v", err)
+	}
+	var got []string
+	for _, step := range plan.Steps {
+		got = append(got, step.commandLine())
+	}
+	want := []string{
+		"pvecm expected 1",
+		"systemctl stop pve-cluster corosync",
+		"pmxcfs -l",
+		"rm -f /etc/pve/corosync.conf",
+		"find /etc/corosync -mindepth 1 -delete",
+		"killall pmxcfs",
+		"systemctl start pve-cluster",
+		"rm -rf /etc/pve/nodes/pve2",
+		"rm -rf /etc/pve/nodes/pve4",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("steps=%v\nwant %v", got, want)
+	}
+	if len(plan.Notes) != 1 || !strings.Contains(plan.Notes[0], "pve3") {
+		t.Fatalf("notes=%v", plan.Notes)
+	}
+}
+
+func TestPlanClusterStandaloneKeepsStaleNodesWhenDeclined(t *testing.T) {
+	setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/nodes/pve2/lrm_status": "{}",
+	}, nil)
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\nREMOVE_STALE_NODES=no\n")
+
+	plan, err := planClusterStandalone(context.Background(), ui, "pve1")
+	if err != nil {
+		t.Fatalf("planClusterStandalone: %v", err)
+	}
+	if len(plan.Steps) != 0 || len(plan.StaleNodes) != 0 {
+		t.Fatalf("expected no steps, got %+v", plan)
+	}
+}
+
+func TestPlanClusterStandaloneKeepsStaleNodesWhenProfileOmitsKey(t *testing.T) {
+	setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/nodes/pve2/lrm_status": "{}",
+	}, nil)
+	// The interactive prompt defaults to removing them; a profile must say so.
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\nCONFIRM=yes\n")
+
+	plan, err := planClusterStandalone(context.Background(), ui, "pve1")
+	if err != nil {
+		t.Fatalf("planClusterStandalone: %v", err)
+	}
+	for _, step := range plan.Steps {
+		if strings.Contains(step.commandLine(), "/etc/pve/nodes/") {
+			t.Fatalf("stale node removed without REMOVE_STALE_NODES: %v", step.commandLine())
+		}
+	}
+	if len(plan.StaleNodes) != 0 {
+		t.Fatalf("stale nodes=%v, want none removed", plan.StaleNodes)
+	}
+}
+
+func TestRunClusterRebuildJoinsOverSSH(t *testing.T) {
+	fakeFS, runner := setupClusterRebuildTest(t, map[string]string{
+		"/etc/corosync/corosync.conf": testCorosyncConf,
+	}, nil)
+	archivePath := writeClusterRebuildTestArchive(t, fakeFS)
+	ui := mustClusterRebuildProfile(t, "MODE=join\nJOIN_USE_SSH=yes\nCONFIRM=yes\n")
+
+	if err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, false); err != nil {
+		t.Fatalf("runClusterRebuildWithUI: %v", err)
+	}
+	// The file removals run in-process, not as commands.
+	want := []string{
+		"systemctl stop pve-cluster corosync",
+		"pmxcfs -l",
+		"killall pmxcfs",
+		"systemctl start pve-cluster",
+		"pvecm add 10.0.0.12 --link0 10.0.0.11 --link1 172.16.0.21 --nodeid 1 --use_ssh 1",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v\nwant %v", runner.calls, want)
+	}
+	if _, err := fakeFS.Stat("/etc/corosync/corosync.conf"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("local corosync.conf must be removed, stat err=%v", err)
+	}
+	if _, err := fakeFS.Stat("/etc/corosync"); err != nil {
+		t.Fatalf("/etc/corosync itself must be kept: %v", err)
+	}
+}
+
+func TestClusterRebuildCommandStepsAreAllowed(t *testing.T) {
+	setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/corosync.conf":         testCorosyncConf,
+		"/etc/pve/nodes/pve2/lrm_status": "{}",
+	}, nil)
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\n")
+	plan, err := planClusterStandalone(context.Background(), ui, "pve1")
+	if err != nil {
+		t.Fatalf("planClusterStandalone: %v", err)
+	}
+	// Every step that runs a command must pass the real safeexec allowlist.
+	for _, step := range plan.Steps {
+		if step.Manual || step.Action != nil {
+			continue
+		}
+		if _, err := safeexec.CommandContext(context.Background(), step.Command[0], step.Command[1:]...); err != nil {
+			t.Fatalf("step %q: %v", step.commandLine(), err)
+		}
+	}
+	if err := removeStaleNodeDir("../pve"); err == nil {
+		t.Fatal("expected error for a node name escaping /etc/pve/nodes")
+	}
+}
+
+func TestRunClusterRebuildRestartsClusterAfterFailure(t *testing.T) {
+	fakeFS, runner := setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/corosync.conf": testCorosyncConf,
+	}, map[string][]byte{
+		"pvecm status": []byte("Quorate: Yes\n"),
+	})
+	archivePath := writeClusterRebuildTestArchive(t, fakeFS)
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\nCONFIRM=1\n")
+	// Fail after pmxcfs runs in local mode: it must be stopped before pve-cluster restarts.
+	runner.errs = map[string]error{"systemctl start pve-cluster": errors.New("exit status 1")}
+
+	if err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, false); err == nil {
+		t.Fatal("expected the failed step to be reported")
+	}
+	want := []string{
+		"pvecm status",
+		"systemctl stop pve-cluster corosync",
+		"pmxcfs -l",
+		"killall pmxcfs",
+		"systemctl start pve-cluster",
+		"systemctl start pve-cluster",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v\nwant %v", runner.calls, want)
+	}
+}
+
+func TestRunClusterRebuildJoinWithoutSSHLeavesManualCommand(t *testing.T) {
+	fakeFS, runner := setupClusterRebuildTest(t, nil, nil)
+	archivePath := writeClusterRebuildTestArchive(t, fakeFS)
+	ui := mustClusterRebuildProfile(t, "MODE=join\nJOIN_ADDRESS=10.0.0.13\nLINK1=172.16.0.40\nNODEID=\nJOIN_FINGERPRINT=AA:BB\nCONFIRM=yes\n")
+
+	plan, err := planClusterRejoin(context.Background(), ui, mustParseCorosyncConf(t), "pve1")
+	if err != nil {
+		t.Fatalf("planClusterRejoin: %v", err)
+	}
+	last := plan.Steps[len(plan.Steps)-1]
+	if !last.Manual || last.commandLine() != "pvecm add 10.0.0.13 --link0 10.0.0.11 --link1 172.16.0.40 --fingerprint AA:BB" {
+		t.Fatalf("join step=%+v", last)
+	}
+	if plan.Links[1].Reason != "set by operator" {
+		t.Fatalf("link1=%+v", plan.Links[1])
+	}
+
+	if err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, false); err != nil {
+		t.Fatalf("runClusterRebuildWithUI: %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("manual steps must not run, got %v", runner.calls)
+	}
+}
+
+func TestRunClusterRebuildRequiresConfirmation(t *testing.T) {
+	fakeFS, runner := setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/corosync.conf": testCorosyncConf,
+	}, nil)
+	archivePath := writeClusterRebuildTestArchive(t, fakeFS)
+	backups := 0
+	clusterRebuildSafetyBakFn = func(*logging.Logger) (*SafetyBackupResult, error) {
+		backups++
+		return nil, nil
+	}
+
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\n")
+	err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, false)
+	if !errors.Is(err, ErrRestoreAborted) {
+		t.Fatalf("err=%v, want ErrRestoreAborted", err)
+	}
+	if err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, true); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if want := []string{"pvecm status", "pvecm status"}; !reflect.DeepEqual(runner.calls, want) || backups != 0 {
+		t.Fatalf("only the quorum check may run without confirmation: calls=%v backups=%d", runner.calls, backups)
+	}
+}
+
+func TestRunClusterRebuildReportsFailedStepWithRollbackHint(t *testing.T) {
+	fakeFS, runner := setupClusterRebuildTest(t, map[string]string{
+		"/etc/pve/corosync.conf": testCorosyncConf,
+	}, map[string][]byte{
+		"pvecm status": []byte("Quorate: Yes\n"),
+	})
+	runner.errs = map[string]error{"pmxcfs -l": errors.New("exit status 255")}
+	archivePath := writeClusterRebuildTestArchive(t, fakeFS)
+	ui := mustClusterRebuildProfile(t, "MODE=standalone\nCONFIRM=1\n")
+
+	err := runClusterRebuildWithUI(context.Background(), newTestLogger(), ui, archivePath, false)
+	if err == nil || !strings.Contains(err.Error(), "--restore-rollback") || !strings.Contains(err.Error(), "cluster_rebuild_backup_test.tar.gz") {
+		t.Fatalf("err=%v", err)
+	}
+	// pve-cluster is started again so the node is not left without /etc/pve.
+	if want := []string{"pvecm status", "systemctl stop pve-cluster corosync", "pmxcfs -l", "systemctl start pve-cluster"}; !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v want %v", runner.calls, want)
+	}
+}
+
+func TestLoadArchivedCorosyncConfigMissing(t *testing.T) {
+	fakeFS, _ := setupClusterRebuildTest(t, nil, nil)
+	archivePath := filepath.Join(fakeFS.Root, "empty.tar")
+	if err := writeTarFile(archivePath, map[string]string{"./etc/hostname": "pve1\n"}); err != nil {
+		t.Fatalf("writeTarFile: %v", err)
+	}
+	if _, err := loadArchivedCorosyncConfig(context.Background(), archivePath); !errors.Is(err, ErrNoArchivedCorosyncConfig) {
+		t.Fatalf("err=%v, want ErrNoArchivedCorosyncConfig", err)
+	}
+}
+
+func mustParseCorosyncConf(t *testing.T) corosyncClusterInfo {
+	t.Helper()
+	info, err := parseCorosyncConf(testCorosyncConf)
+	if err != nil {
+		t.Fatalf("parseCorosyncConf: %v", err)
+	}
+	return info
+}
+
+func TestPrepareClusterRebuildProfileArchiveEnforcesSignaturePolicy(t *testing.T) {
+	origFS, origRoot := restoreFS, workspaceRoot
+	t.Cleanup(func() { restoreFS, workspaceRoot = origFS, origRoot })
+	restoreFS = osFS{}
+	workspaceRoot = t.TempDir()
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "pve1-backup-20260301-020000.tar")
+	if err := writeTarFile(archivePath, map[string]string{"./etc/pve/corosync.conf": testCorosyncConf}); err != nil {
+		t.Fatalf("writeTarFile: %v", err)
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	writeBundleFixtures(t, archivePath, map[string]string{
+		".sha256":   checksum + "  " + filepath.Base(archivePath) + "\n",
+		".metadata": `{"archive_path":"` + archivePath + `","sha256":"` + checksum + `","created_at":"2026-03-01T02:00:00Z","encryption_mode":"none","proxmox_type":"pve","hostname":"pve1"}`,
+	})
+	profile, err := parseClusterRebuildProfile("rebuild.env", "MODE=standalone\nARCHIVE="+archivePath+"\n")
+	if err != nil {
+		t.Fatalf("parseClusterRebuildProfile: %v", err)
+	}
+	logger := newTestLogger()
+
+	// The profile archive gets the same signature check as a selected backup.
+	require := &config.Config{ManifestSignaturePolicy: config.ManifestSignatureRequire}
+	if _, err := prepareClusterRebuildProfileArchive(context.Background(), require, logger, "test", profile); !errors.Is(err, backup.ErrManifestUnsigned) {
+		t.Fatalf("require + unsigned profile archive err = %v, want ErrManifestUnsigned", err)
+	}
+
+	prepared, err := prepareClusterRebuildProfileArchive(context.Background(), &config.Config{}, logger, "test", profile)
+	if err != nil {
+		t.Fatalf("prepare without policy: %v", err)
+	}
+	defer prepared.Cleanup()
+	if prepared.ArchivePath == archivePath {
+		t.Fatalf("the archive must be staged, not read in place")
+	}
+	if _, err := loadArchivedCorosyncConfig(context.Background(), prepared.ArchivePath); err != nil {
+		t.Fatalf("loadArchivedCorosyncConfig(staged): %v", err)
+	}
+}
+
+func TestParseClusterRebuildProfileRejectsInlineIdentity(t *testing.T) {
+	if _, err := parseClusterRebuildProfile("rebuild.env", "MODE=join\nDECRYPT_IDENTITY=AGE-SECRET-KEY-1XYZ\n"); err == nil || !strings.Contains(err.Error(), "identity source") {
+		t.Fatalf("inline key err = %v", err)
+	}
+	if _, err := parseClusterRebuildProfile("rebuild.env", "MODE=join\nDECRYPT_IDENTITY=systemd-creds:proxsave-age\n"); err != nil {
+		t.Fatalf("identity source rejected: %v", err)
+	}
+}
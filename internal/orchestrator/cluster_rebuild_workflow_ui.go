@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/input"
+	"github.com/tis24dev/proxsave/internal/ui/components"
+)
+
+func (u *cliWorkflowUI) SelectClusterRebuildMode(ctx context.Context, cluster corosyncClusterInfo, node string) (ClusterRebuildMode, error) {
+	fmt.Fprintf(u.w(), "\nCluster %s in the backup (config version %s):\n", components.SanitizeLine(cluster.ClusterName), components.SanitizeLine(valueOrNone(cluster.ConfigVersion)))
+	for _, n := range cluster.Nodes {
+		marker := ""
+		if strings.EqualFold(n.Name, node) {
+			marker = " (this node)"
+		}
+		fmt.Fprintf(u.w(), "  node %s id=%s link0=%s%s\n", components.SanitizeLine(n.Name), n.NodeID, components.SanitizeLine(valueOrNone(n.Links[0])), marker)
+	}
+	fmt.Fprintln(u.w(), "\nCluster rebuild:")
+	fmt.Fprintln(u.w(), "  [1] Rejoin the existing cluster (pvecm add, link addresses matched to the current NICs)")
+	fmt.Fprintln(u.w(), "  [2] Convert this node to standalone (drop corosync config and stale node directories)")
+	fmt.Fprintln(u.w(), "  [0] Exit")
+
+	for {
+		fmt.Fprint(u.w(), "Choice: ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return "", err
+		}
+		switch strings.TrimSpace(line) {
+		case "1":
+			return ClusterRebuildJoin, nil
+		case "2":
+			return ClusterRebuildStandalone, nil
+		case "0":
+			return "", ErrRestoreAborted
+		default:
+			fmt.Fprintln(u.w(), "Please enter 1, 2 or 0.")
+		}
+	}
+}
+
+func (u *cliWorkflowUI) PromptClusterRebuildValue(ctx context.Context, key, label, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(u.w(), "%s [%s]: ", components.SanitizeLine(label), components.SanitizeLine(defaultValue))
+	} else {
+		fmt.Fprintf(u.w(), "%s: ", components.SanitizeLine(label))
+	}
+	line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+	if err != nil {
+		return "", err
+	}
+	if trimmed := strings.TrimSpace(line); trimmed != "" {
+		return trimmed, nil
+	}
+	return defaultValue, nil
+}
+
+func (u *cliWorkflowUI) ConfirmClusterRebuild(ctx context.Context, key, title, message string, defaultYes bool) (bool, error) {
+	return u.ConfirmAction(ctx, title, message, "Yes", "No", 0, defaultYes)
+}
@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"io"
@@ -9,6 +10,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tis24dev/proxsave/internal/types"
+	"github.com/ulikunitz/xz"
 )
 
 func TestCreateDecompressionReaderUnsupported(t *testing.T) {
@@ -91,7 +96,6 @@ func TestCreateDecompressionReaderUsesStreamingRunnerForCompressedFormats(t *tes
 
 	fake := &fakeStreamCommandRunner{
 		outputs: map[string]string{
-			"zstd":  "zstd-out",
 			"bzip2": "bzip2-out",
 			"lzma":  "lzma-out",
 		},
@@ -103,7 +107,6 @@ func TestCreateDecompressionReaderUsesStreamingRunnerForCompressedFormats(t *tes
 		wantCmd  string
 		wantText string
 	}{
-		{ext: ".tar.zst", wantCmd: "zstd -d -c", wantText: "zstd-out"},
 		{ext: ".tar.bz2", wantCmd: "bzip2 -d -c", wantText: "bzip2-out"},
 		{ext: ".tar.lzma", wantCmd: "lzma -d -c", wantText: "lzma-out"},
 	}
@@ -162,7 +165,7 @@ func TestExtractArchiveNativeReturnsDecompressionCloseError(t *testing.T) {
 	restoreCmd = &closeErrorStreamCommandRunner{data: tarData, closeErr: closeErr}
 	restoreFS = osFS{}
 
-	archivePath := filepath.Join(dir, "archive.tar.zst")
+	archivePath := filepath.Join(dir, "archive.tar.bz2")
 	if err := os.WriteFile(archivePath, []byte("compressed"), 0o640); err != nil {
 		t.Fatalf("WriteFile: %v", err)
 	}
@@ -176,3 +179,66 @@ func TestExtractArchiveNativeReturnsDecompressionCloseError(t *testing.T) {
 		t.Fatalf("extractArchiveNative error = %v, want close error %v", err, closeErr)
 	}
 }
+
+func TestCreateDecompressionReaderUsesBuiltInDecoders(t *testing.T) {
+	orig := restoreCmd
+	t.Cleanup(func() { restoreCmd = orig })
+	fake := &fakeStreamCommandRunner{}
+	restoreCmd = fake
+
+	payload := []byte(strings.Repeat("proxsave restore payload\n", 512))
+	for _, comp := range []types.CompressionType{types.CompressionGzip, types.CompressionXZ, types.CompressionZstd} {
+		t.Run(string(comp), func(t *testing.T) {
+			var compressed bytes.Buffer
+			compressTestPayload(t, &compressed, comp, payload)
+			path := filepath.Join(t.TempDir(), "archive.tar."+string(comp))
+			if err := os.WriteFile(path, compressed.Bytes(), 0o640); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer func() { _ = f.Close() }()
+
+			reader, err := createDecompressionReader(context.Background(), f, path)
+			if err != nil {
+				t.Fatalf("createDecompressionReader: %v", err)
+			}
+			defer func() { _ = reader.Close() }()
+			out, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(out, payload) {
+				t.Fatalf("decompressed %d bytes, want %d", len(out), len(payload))
+			}
+		})
+	}
+	if len(fake.calls) != 0 {
+		t.Fatalf("external decompressors invoked: %v", fake.calls)
+	}
+}
+
+func compressTestPayload(t *testing.T, dst io.Writer, comp types.CompressionType, payload []byte) {
+	t.Helper()
+	var w io.WriteCloser
+	var err error
+	switch comp {
+	case types.CompressionGzip:
+		w = gzip.NewWriter(dst)
+	case types.CompressionXZ:
+		w, err = xz.NewWriter(dst)
+	case types.CompressionZstd:
+		w, err = zstd.NewWriter(dst)
+	}
+	if err != nil {
+		t.Fatalf("new %s writer: %v", comp, err)
+	}
+	if _, err := w.Write(payload); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close %s writer: %v", comp, err)
+	}
+}
@@ -48,15 +48,32 @@ type backupCandidate struct {
 	RawArchivePath  string
 	RawMetadataPath string
 	RawChecksumPath string
-	Integrity       *stagedIntegrityExpectation
-	DisplayBase     string
-	IsRclone        bool
+	// RawSignaturePath is the optional <archive>.manifest.sig sidecar.
+	RawSignaturePath string
+	Integrity        *stagedIntegrityExpectation
+	DisplayBase      string
+	IsRclone         bool
+	// RcloneChunked marks a cloud object stored in parts (CLOUD_UPLOAD_CHUNK_SIZE);
+	// it is read through a chunker overlay (see rcloneReadRef).
+	RcloneChunked bool
+	// RawVolumes lists, in order, the volumes of a raw archive split by
+	// ARCHIVE_VOLUME_SIZE; RawArchivePath then names the archive they rebuild.
+	RawVolumes []archiveVolumeRef
+}
+
+// archiveVolumeRef is one volume of a split raw archive: a local path or an
+// rclone reference, with its manifest entry.
+type archiveVolumeRef struct {
+	Path    string
+	Chunked bool
+	Volume  backup.ArchiveVolume
 }
 
 type stagedFiles struct {
-	ArchivePath  string
-	MetadataPath string
-	ChecksumPath string
+	ArchivePath   string
+	MetadataPath  string
+	ChecksumPath  string
+	SignaturePath string
 }
 
 type preparedBundle struct {
@@ -64,7 +81,12 @@ type preparedBundle struct {
 	Manifest       backup.Manifest
 	Checksum       string
 	SourceChecksum string
-	cleanup        func()
+	// manifestData and manifestSignature are the staged manifest bytes and
+	// their detached signature (nil when unsigned), checked against
+	// MANIFEST_SIGNATURE_POLICY by enforceManifestSignaturePolicy.
+	manifestData      []byte
+	manifestSignature []byte
+	cleanup           func()
 }
 
 func (p *preparedBundle) Cleanup() {
@@ -506,6 +528,10 @@ func prepareDecryptedBackup(ctx context.Context, reader *bufio.Reader, cfg *conf
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := enforceManifestSignaturePolicy(cfg, logger, prepared); err != nil {
+		prepared.Cleanup()
+		return nil, nil, err
+	}
 
 	return candidate, prepared, nil
 }
@@ -593,6 +619,9 @@ func extractBundleToWorkdirWithLogger(bundlePath, workDir string, logger *loggin
 		extracted++
 
 		switch {
+		case strings.HasSuffix(target, backup.ManifestSignatureSuffix):
+			staged.SignaturePath = target
+			logging.DebugStep(logger, "extract bundle", "found manifest signature=%s", filepath.Base(target))
 		case strings.HasSuffix(target, ".metadata"):
 			staged.MetadataPath = target
 			logging.DebugStep(logger, "extract bundle", "found metadata=%s", filepath.Base(target))
@@ -681,11 +710,30 @@ func copyRawArtifactsToWorkdirWithLogger(ctx context.Context, cand *backupCandid
 	if sumBase != "" {
 		checksumDest = filepath.Join(workDir, sumBase)
 	}
+	signatureDest := ""
+	if cand.RawSignaturePath != "" {
+		sigBase := filepath.Base(cand.RawSignaturePath)
+		if cand.IsRclone {
+			sigBase = baseNameFromRemoteRef(cand.RawSignaturePath)
+		}
+		if sigBase != "" {
+			signatureDest = filepath.Join(workDir, sigBase)
+		}
+	}
+
+	if len(cand.RawVolumes) > 0 {
+		logging.DebugStep(logger, "stage raw artifacts", "join %d volumes into %s", len(cand.RawVolumes), archiveDest)
+		if err := stageArchiveVolumes(ctx, cand, workDir, archiveDest, logger, timeout); err != nil {
+			return stagedFiles{}, err
+		}
+	}
 
 	if cand.IsRclone {
-		logging.DebugStep(logger, "stage raw artifacts", "download archive to %s", archiveDest)
-		if err := rcloneCopyTo(ctx, cand.RawArchivePath, archiveDest, true); err != nil {
-			return stagedFiles{}, fmt.Errorf("rclone download archive: %w", err)
+		if len(cand.RawVolumes) == 0 {
+			logging.DebugStep(logger, "stage raw artifacts", "download archive to %s", archiveDest)
+			if err := rcloneCopyTo(ctx, rcloneReadRef(cand.RawArchivePath, cand.RcloneChunked), archiveDest, true); err != nil {
+				return stagedFiles{}, fmt.Errorf("rclone download archive: %w", err)
+			}
 		}
 		logging.DebugStep(logger, "stage raw artifacts", "download metadata to %s", metadataDest)
 		if err := rcloneCopyTo(ctx, cand.RawMetadataPath, metadataDest, false); err != nil {
@@ -698,10 +746,19 @@ func copyRawArtifactsToWorkdirWithLogger(ctx context.Context, cand *backupCandid
 				checksumDest = ""
 			}
 		}
+		if signatureDest != "" {
+			logging.DebugStep(logger, "stage raw artifacts", "download manifest signature to %s", signatureDest)
+			if err := rcloneCopyTo(ctx, cand.RawSignaturePath, signatureDest, false); err != nil {
+				logWarning(logger, "Failed to download manifest signature %s: %v", cand.RawSignaturePath, err)
+				signatureDest = ""
+			}
+		}
 	} else {
-		logging.DebugStep(logger, "stage raw artifacts", "copy archive to %s", archiveDest)
-		if err := copyFileBounded(ctx, restoreFS, cand.RawArchivePath, archiveDest, timeout); err != nil {
-			return stagedFiles{}, fmt.Errorf("copy archive: %w", err)
+		if len(cand.RawVolumes) == 0 {
+			logging.DebugStep(logger, "stage raw artifacts", "copy archive to %s", archiveDest)
+			if err := copyFileBounded(ctx, restoreFS, cand.RawArchivePath, archiveDest, timeout); err != nil {
+				return stagedFiles{}, fmt.Errorf("copy archive: %w", err)
+			}
 		}
 		logging.DebugStep(logger, "stage raw artifacts", "copy metadata to %s", metadataDest)
 		if err := copyFileBounded(ctx, restoreFS, cand.RawMetadataPath, metadataDest, timeout); err != nil {
@@ -714,19 +771,27 @@ func copyRawArtifactsToWorkdirWithLogger(ctx context.Context, cand *backupCandid
 				checksumDest = ""
 			}
 		}
+		if signatureDest != "" {
+			logging.DebugStep(logger, "stage raw artifacts", "copy manifest signature to %s", signatureDest)
+			if err := copyFileBounded(ctx, restoreFS, cand.RawSignaturePath, signatureDest, timeout); err != nil {
+				logWarning(logger, "Failed to copy manifest signature %s: %v", cand.RawSignaturePath, err)
+				signatureDest = ""
+			}
+		}
 	}
 
 	return stagedFiles{
-		ArchivePath:  archiveDest,
-		MetadataPath: metadataDest,
-		ChecksumPath: checksumDest,
+		ArchivePath:   archiveDest,
+		MetadataPath:  metadataDest,
+		ChecksumPath:  checksumDest,
+		SignaturePath: signatureDest,
 	}, nil
 }
 
 func decryptArchiveWithPrompts(ctx context.Context, reader *bufio.Reader, encryptedPath, outputPath string, logger *logging.Logger) error {
 	ui := newCLIWorkflowUI(reader, logger)
 	displayName := filepath.Base(encryptedPath)
-	return decryptArchiveWithSecretPrompt(ctx, encryptedPath, outputPath, displayName, ui.PromptDecryptSecret, nil)
+	return decryptArchiveWithSecretPrompt(ctx, encryptedPath, outputPath, displayName, ui.PromptDecryptSecret, ui, nil)
 }
 
 func parseIdentityInput(input string) ([]age.Identity, error) {
@@ -812,7 +877,7 @@ func formatTargetSummary(manifest *backup.Manifest) string {
 		version = "v" + version
 	}
 	summary := fmt.Sprintf("%s %s", targets, version)
-	if cluster := formatClusterMode(manifest.ClusterMode); cluster != "" {
+	if cluster := formatManifestCluster(manifest); cluster != "" {
 		summary = fmt.Sprintf("%s (%s)", summary, cluster)
 	}
 	return summary
@@ -841,6 +906,16 @@ func ensureWritablePath(ctx context.Context, reader *bufio.Reader, path, descrip
 	return ensureWritablePathWithUI(ctx, ui, path, description)
 }
 
+// formatManifestCluster is formatClusterMode plus a marker for node-only archives of a
+// coordinated cluster backup, which need the cluster archive for a full restore.
+func formatManifestCluster(manifest *backup.Manifest) string {
+	cluster := formatClusterMode(manifest.ClusterMode)
+	if cluster != "" && manifest.ClusterScope == backup.ClusterScopeNode {
+		cluster += ", node files only"
+	}
+	return cluster
+}
+
 func formatClusterMode(value string) string {
 	mode := strings.ToLower(strings.TrimSpace(value))
 	switch mode {
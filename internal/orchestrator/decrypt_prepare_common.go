@@ -74,7 +74,7 @@ func preparePlainBundleCommon(ctx context.Context, cand *backupCandidate, versio
 	var rcloneCleanup func()
 	if cand.IsRclone && cand.Source == sourceBundle {
 		logger.Debug("Detected rclone backup, downloading...")
-		localPath, cleanup, err := downloadRcloneBackup(ctx, cand.BundlePath, logger)
+		localPath, cleanup, err := downloadRcloneBackup(ctx, rcloneReadRef(cand.BundlePath, cand.RcloneChunked), logger)
 		if err != nil {
 			return nil, fmt.Errorf("failed to download rclone backup: %w", err)
 		}
@@ -127,6 +127,12 @@ func preparePlainBundleCommon(ctx context.Context, cand *backupCandidate, versio
 		return nil, err
 	}
 
+	manifestData, manifestSignature, err := readStagedManifestSignature(staged)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
 	manifestCopy := *cand.Manifest
 	currentEncryption := strings.ToLower(strings.TrimSpace(manifestCopy.EncryptionMode))
 	logger.Info("Preparing archive %s for decryption (mode: %s)", manifestCopy.ArchivePath, statusFromManifest(&manifestCopy))
@@ -178,10 +184,12 @@ func preparePlainBundleCommon(ctx context.Context, cand *backupCandidate, versio
 	}
 
 	return &preparedBundle{
-		ArchivePath:    plainArchivePath,
-		Manifest:       manifestCopy,
-		Checksum:       plainChecksum,
-		SourceChecksum: sourceChecksum,
-		cleanup:        cleanup,
+		ArchivePath:       plainArchivePath,
+		Manifest:          manifestCopy,
+		Checksum:          plainChecksum,
+		SourceChecksum:    sourceChecksum,
+		manifestData:      manifestData,
+		manifestSignature: manifestSignature,
+		cleanup:           cleanup,
 	}, nil
 }
@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/safefs"
+)
+
+// localArchiveVolumes resolves the volumes listed by the manifest of a split
+// archive next to archivePath, failing when one of them is missing.
+func localArchiveVolumes(archivePath string, manifest *backup.Manifest) ([]archiveVolumeRef, error) {
+	if manifest == nil || len(manifest.Volumes) == 0 {
+		return nil, fmt.Errorf("archive is missing and the manifest lists no volumes")
+	}
+	paths := backup.VolumePaths(archivePath, manifest.Volumes)
+	refs := make([]archiveVolumeRef, 0, len(paths))
+	for i, volumePath := range paths {
+		if _, err := restoreFS.Stat(volumePath); err != nil {
+			return nil, fmt.Errorf("volume %s: %w", manifest.Volumes[i].Name, err)
+		}
+		refs = append(refs, archiveVolumeRef{Path: volumePath, Volume: manifest.Volumes[i]})
+	}
+	return refs, nil
+}
+
+// rcloneArchiveVolumes resolves the volumes listed by the manifest of a split
+// archive against the listed remote entries (snapshot, with chunked objects
+// under their composite name), failing when one of them is missing.
+func rcloneArchiveVolumes(remoteArchive string, manifest *backup.Manifest, snapshot map[string]struct{}, chunked map[string]bool) ([]archiveVolumeRef, error) {
+	if manifest == nil || len(manifest.Volumes) == 0 {
+		return nil, fmt.Errorf("archive is missing and the manifest lists no volumes")
+	}
+	dir := strings.TrimSuffix(remoteArchive, baseNameFromRemoteRef(remoteArchive))
+	refs := make([]archiveVolumeRef, 0, len(manifest.Volumes))
+	for _, volume := range manifest.Volumes {
+		if _, ok := snapshot[volume.Name]; !ok {
+			return nil, fmt.Errorf("volume %s is missing", volume.Name)
+		}
+		refs = append(refs, archiveVolumeRef{Path: dir + volume.Name, Chunked: chunked[volume.Name], Volume: volume})
+	}
+	return refs, nil
+}
+
+// stageArchiveVolumes rebuilds a split archive at dest by appending its volumes
+// in order. Each volume is checked against its manifest entry as it streams, so
+// a damaged volume is named instead of only failing the archive checksum later.
+func stageArchiveVolumes(ctx context.Context, cand *backupCandidate, workDir, dest string, logger *logging.Logger, timeout time.Duration) (err error) {
+	out, err := restoreFS.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("create staged archive: %w", err)
+	}
+	defer closeIntoErr(&err, out, "close staged archive")
+
+	for i, ref := range cand.RawVolumes {
+		logging.DebugStep(logger, "stage raw artifacts", "volume %d/%d: %s", i+1, len(cand.RawVolumes), ref.Volume.Name)
+		if err := appendArchiveVolume(ctx, cand.IsRclone, ref, workDir, out, timeout); err != nil {
+			return fmt.Errorf("volume %d/%d (%s): %w", i+1, len(cand.RawVolumes), ref.Volume.Name, err)
+		}
+	}
+	return out.Sync()
+}
+
+func appendArchiveVolume(ctx context.Context, isRclone bool, ref archiveVolumeRef, workDir string, out io.Writer, timeout time.Duration) (err error) {
+	src := ref.Path
+	if isRclone {
+		src = filepath.Join(workDir, baseNameFromRemoteRef(ref.Path))
+		if err := rcloneCopyTo(ctx, rcloneReadRef(ref.Path, ref.Chunked), src, true); err != nil {
+			return fmt.Errorf("rclone download: %w", err)
+		}
+		defer func() { _ = restoreFS.Remove(src) }()
+	}
+
+	in, err := restoreFS.Open(src)
+	if err != nil {
+		return err
+	}
+	defer closeIntoErr(&err, in, "close volume")
+
+	hash := sha256.New()
+	written, err := safefs.CopyBounded(ctx, io.MultiWriter(out, hash), in, 0, timeout, "stage archive volume", src)
+	if err != nil {
+		return err
+	}
+	if ref.Volume.Size > 0 && written != ref.Volume.Size {
+		return fmt.Errorf("size %d, manifest records %d", written, ref.Volume.Size)
+	}
+	if ref.Volume.SHA256 == "" {
+		return nil
+	}
+	expected, err := backup.NormalizeChecksum(ref.Volume.SHA256)
+	if err != nil {
+		return fmt.Errorf("manifest checksum: %w", err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("checksum mismatch (expected %s, got %s)", expected, actual)
+	}
+	return nil
+}
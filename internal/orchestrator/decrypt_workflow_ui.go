@@ -167,7 +167,10 @@ func ensureWritablePathWithUI(ctx context.Context, ui DecryptWorkflowUI, targetP
 	}
 }
 
-func decryptArchiveWithSecretPrompt(ctx context.Context, encryptedPath, outputPath, displayName string, prompt func(ctx context.Context, displayName, previousError string) (string, error), extraSalts []string) error {
+// decryptArchiveWithSecretPrompt asks for keys until one decrypts the archive.
+// sourceUI hosts the prompts of identity sources (plugins, unlock file) and may
+// be nil.
+func decryptArchiveWithSecretPrompt(ctx context.Context, encryptedPath, outputPath, displayName string, prompt func(ctx context.Context, displayName, previousError string) (string, error), sourceUI ageIdentitySourceUI, extraSalts []string) error {
 	promptError := ""
 	for {
 		secret, err := prompt(ctx, displayName, promptError)
@@ -185,10 +188,16 @@ func decryptArchiveWithSecretPrompt(ctx context.Context, encryptedPath, outputPa
 			continue
 		}
 
-		identities, err := parseIdentityInputWithSalts(secret, extraSalts)
+		identities, fromSource, err := resolveAgeIdentitySource(ctx, secret, sourceUI)
+		if !fromSource {
+			identities, err = parseIdentityInputWithSalts(secret, extraSalts)
+		}
 		resetString(&secret)
 		if err != nil {
 			promptError = "Invalid key or passphrase."
+			if fromSource {
+				promptError = err.Error()
+			}
 			continue
 		}
 
@@ -198,6 +207,10 @@ func decryptArchiveWithSecretPrompt(ctx context.Context, encryptedPath, outputPa
 				promptError = "Provided key or passphrase does not match this archive."
 				continue
 			}
+			if errors.Is(err, errAgeIdentitySource) {
+				promptError = err.Error()
+				continue
+			}
 			return err
 		}
 		return nil
@@ -217,8 +230,9 @@ func preparePlainBundleWithUI(ctx context.Context, cand *backupCandidate, versio
 	done := logging.DebugStart(logger, "prepare plain bundle (ui)", "source=%v rclone=%v", cand.Source, cand.IsRclone)
 	defer func() { done(err) }()
 	extraSalts := manifestPassphraseSalts(cand.Manifest)
+	sourceUI, _ := ui.(ageIdentitySourceUI)
 	return preparePlainBundleCommon(ctx, cand, version, logger, func(ctx context.Context, encryptedPath, outputPath, displayName string) error {
-		return decryptArchiveWithSecretPrompt(ctx, encryptedPath, outputPath, displayName, ui.PromptDecryptSecret, extraSalts)
+		return decryptArchiveWithSecretPrompt(ctx, encryptedPath, outputPath, displayName, ui.PromptDecryptSecret, sourceUI, extraSalts)
 	}, timeout)
 }
 
@@ -256,6 +270,9 @@ func runDecryptWorkflowWithUI(ctx context.Context, cfg *config.Config, logger *l
 		return "", err
 	}
 	defer prepared.Cleanup()
+	if err := enforceManifestSignaturePolicy(cfg, logger, prepared); err != nil {
+		return "", err
+	}
 
 	defaultDir := "./decrypt"
 	if strings.TrimSpace(cfg.BaseDir) != "" {
@@ -300,6 +317,14 @@ func runDecryptWorkflowWithUI(ctx context.Context, cfg *config.Config, logger *l
 		return "", fmt.Errorf("write checksum file: %w", err)
 	}
 
+	// The decrypted manifest differs from the signed original, so re-sign it
+	// with this host's key when signing is enabled.
+	if cfg.SignManifests {
+		if err := signManifestFile(ctx, restoreFS, cfg, logger, metadataPath, backup.ManifestSignaturePath(tempArchivePath)); err != nil {
+			logger.Warning("Failed to sign decrypted manifest: %v", err)
+		}
+	}
+
 	logger.Info("Creating decrypted bundle...")
 	o := &Orchestrator{logger: logger, fs: osFS{}}
 	tempBundlePath, err := o.createBundle(ctx, tempArchivePath)
@@ -387,7 +387,7 @@ func TestDecryptArchiveWithSecretPromptGenericParseError(t *testing.T) {
 		captured = previousError
 		return "", input.ErrInputAborted
 	}
-	err := decryptArchiveWithSecretPrompt(context.Background(), "", "", "archive", prompt, nil)
+	err := decryptArchiveWithSecretPrompt(context.Background(), "", "", "archive", prompt, nil, nil)
 	if !errors.Is(err, ErrDecryptAborted) {
 		t.Fatalf("expected ErrDecryptAborted after abort, got %v", err)
 	}
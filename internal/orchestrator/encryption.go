@@ -16,6 +16,7 @@ import (
 
 	"filippo.io/age"
 	"filippo.io/age/agessh"
+	"filippo.io/age/plugin"
 	"github.com/tis24dev/proxsave/internal/input"
 	"github.com/tis24dev/proxsave/internal/safefs"
 	"github.com/tis24dev/proxsave/pkg/bech32"
@@ -131,12 +132,12 @@ func promptOptionAge(ctx context.Context, reader *bufio.Reader, prompt string) (
 		}
 		sw := strings.TrimSpace(line)
 		switch sw {
-		case "1", "2", "3", "4":
+		case "1", "2", "3", "4", "5", "6", "7":
 			return sw, nil
 		case "":
 			continue
 		}
-		fmt.Println("Please enter 1, 2, 3 or 4.")
+		fmt.Println("Please enter a number between 1 and 7.")
 	}
 }
 
@@ -153,6 +154,15 @@ func promptPublicRecipientAge(ctx context.Context, reader *bufio.Reader) (string
 	return value, nil
 }
 
+func promptLineAge(ctx context.Context, reader *bufio.Reader, prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := input.ReadLineWithIdle(ctx, reader, cliIdleTimeout)
+	if err != nil {
+		return "", mapInputAbortToAgeAbort(err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
 func promptPrivateKeyRecipientAge(ctx context.Context) (string, error) {
 	secret, err := promptPrivateKeyValueAge(ctx)
 	if err != nil {
@@ -290,7 +300,20 @@ func parseRecipientStrings(values []string) ([]age.Recipient, error) {
 func parseRecipientString(value string) (age.Recipient, error) {
 	switch {
 	case strings.HasPrefix(value, "age1"):
-		return age.ParseX25519Recipient(value)
+		recipient, err := age.ParseX25519Recipient(value)
+		if err == nil {
+			return recipient, nil
+		}
+		// age1<plugin>1... recipients (hardware tokens) wrap through
+		// age-plugin-<plugin>, which must be installed in a trusted
+		// directory at backup time.
+		if name, _, perr := plugin.ParseRecipient(value); perr == nil {
+			if _, err := resolveAgePlugin(name); err != nil {
+				return nil, err
+			}
+			return plugin.NewRecipient(value, newAgePluginClientUI(context.Background(), nil))
+		}
+		return nil, err
 	case strings.HasPrefix(strings.ToLower(value), "ssh-"):
 		// golang.org/x/crypto >= 0.54 rejects authorized_keys entries whose
 		// human-readable key type differs in case from the encoded type
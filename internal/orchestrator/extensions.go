@@ -319,6 +319,12 @@ func (o *Orchestrator) DispatchEarlyErrorNotification(ctx context.Context, early
 		o.ensureBackupStatsTiming(stats)
 		o.exportPrometheusBackupMetrics(stats)
 	}
+	// Same for the fleet collector: an agent that dies before collecting must not keep
+	// looking healthy there until it goes stale.
+	if o.shouldPushFleetReport(stats) {
+		o.ensureBackupStatsTiming(stats)
+		o.pushFleetReport(ctx, stats)
+	}
 
 	// Honor dry-run like the normal finalize path: never send real notifications.
 	if o.dryRun {
@@ -0,0 +1,65 @@
+package orchestrator
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/fleet"
+)
+
+// defaultFleetPushTimeout bounds a push when FLEET_PUSH_TIMEOUT is not positive.
+const defaultFleetPushTimeout = 30 * time.Second
+
+// shouldPushFleetReport reports whether this run is sent to a fleet collector
+// (FLEET_COLLECTOR_URL). Dry runs never report, like the Prometheus export.
+func (o *Orchestrator) shouldPushFleetReport(stats *BackupStats) bool {
+	return stats != nil && o.cfg != nil && strings.TrimSpace(o.cfg.FleetCollectorURL) != "" && !o.dryRun
+}
+
+// pushFleetReport sends the run report (notification view + metrics snapshot) to the fleet
+// collector. Best-effort like a notification channel: a failure is a warning and never
+// changes the run outcome. The push gets its own timeout, detached from ctx, so a run that
+// was cancelled still reports its failure.
+func (o *Orchestrator) pushFleetReport(ctx context.Context, stats *BackupStats) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := o.cfg.FleetPushTimeout
+	if timeout <= 0 {
+		timeout = defaultFleetPushTimeout
+	}
+	client, err := fleet.NewHTTPClient(o.cfg.FleetCAFile, timeout)
+	if err != nil {
+		o.logger.Warning("Fleet report not sent: %v", err)
+		return
+	}
+	// A nil adapter: the usage-consistency warnings of the conversion were already logged
+	// by the notification channels.
+	data := (*NotificationAdapter)(nil).convertBackupStatsToNotificationData(stats)
+	report := fleet.NewReport(data, stats.toPrometheusMetrics(), o.now())
+	pusher := &fleet.Pusher{
+		URL:    o.cfg.FleetCollectorURL,
+		Secret: o.cfg.FleetSecret,
+		Client: client,
+		Now:    o.now,
+	}
+
+	pushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if err := pusher.Push(pushCtx, report); err != nil {
+		o.logger.Warning("Fleet report not sent: %v", err)
+		return
+	}
+	o.logger.Info("✓ Fleet report sent to %s", fleetCollectorHost(o.cfg.FleetCollectorURL))
+}
+
+// fleetCollectorHost returns the host part of the collector URL for log lines.
+func fleetCollectorHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "the fleet collector"
+	}
+	return u.Host
+}
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/fleet"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/metrics"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// TestDispatchEarlyErrorNotification_PushesFleetReport covers the agent side of fleet mode:
+// an early-init failure reaches the collector as a failing host, over TLS pinned by
+// FLEET_CA_FILE and signed with FLEET_SECRET.
+func TestDispatchEarlyErrorNotification_PushesFleetReport(t *testing.T) {
+	store := fleet.NewStore(t.TempDir())
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("os.Hostname: %v", err)
+	}
+	collector := fleet.NewServer(store, map[string]string{host: "s3cret"}, "", fleet.Policy{StaleAfter: 26 * time.Hour}, nil)
+	ts := httptest.NewTLSServer(collector.Handler())
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "collector-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &Orchestrator{
+		logger: logging.New(types.LogLevelInfo, false),
+		cfg: &config.Config{
+			FleetCollectorURL: ts.URL + fleet.ReportPath,
+			FleetSecret:       "s3cret",
+			FleetCAFile:       caFile,
+			FleetPushTimeout:  10 * time.Second,
+		},
+	}
+	o.DispatchEarlyErrorNotification(context.Background(), newEarlyErrorState())
+
+	reports, errs := store.Latest()
+	if len(errs) != 0 || len(reports) != 1 {
+		t.Fatalf("collector store = %d reports, errors %v; want 1 report", len(reports), errs)
+	}
+	r := reports[0]
+	if r.RunStatus() != metrics.StatusError || r.Run.ExitCode != types.ExitStorageError.Int() {
+		t.Fatalf("stored report status=%d exit=%d, want error/%d", r.RunStatus(), r.Run.ExitCode, types.ExitStorageError.Int())
+	}
+}
+
+func TestShouldPushFleetReport(t *testing.T) {
+	stats := &BackupStats{}
+	cases := []struct {
+		name string
+		o    *Orchestrator
+		want bool
+	}{
+		{"no config", &Orchestrator{}, false},
+		{"no collector url", &Orchestrator{cfg: &config.Config{}}, false},
+		{"collector url", &Orchestrator{cfg: &config.Config{FleetCollectorURL: "https://c:8443/api/v1/reports"}}, true},
+		{"dry run", &Orchestrator{cfg: &config.Config{FleetCollectorURL: "https://c:8443/api/v1/reports"}, dryRun: true}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.o.shouldPushFleetReport(stats); got != tc.want {
+			t.Errorf("%s: shouldPushFleetReport() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
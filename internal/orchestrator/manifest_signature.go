@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/identity"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/safefs"
+)
+
+// Seams for the per-host manifest signing key; tests point them at fixtures.
+var (
+	loadOrCreateManifestSigningKey = identity.LoadOrCreateManifestSigningKey
+	loadLocalManifestPublicKey     = identity.LoadManifestSigningPublicKey
+)
+
+// signArchiveManifest writes <archive>.manifest.sig next to the manifest when
+// SIGN_MANIFESTS is enabled. Best-effort like the legacy .metadata alias: a
+// missing signature is reported by decrypt/restore according to
+// MANIFEST_SIGNATURE_POLICY, so a signing failure only warns.
+func (o *Orchestrator) signArchiveManifest(ctx context.Context, workspace *backupWorkspace, artifacts *backupArtifacts) {
+	if o.cfg == nil || !o.cfg.SignManifests {
+		return
+	}
+	signaturePath := backup.ManifestSignaturePath(artifacts.archivePath)
+	if err := signManifestFile(ctx, workspace.fs, o.cfg, o.logger, artifacts.manifestPath, signaturePath); err != nil {
+		o.logger.Warning("Failed to sign backup manifest %s: %v", filepath.Base(artifacts.manifestPath), err)
+		return
+	}
+	o.logger.Debug("Manifest signature written to %s", signaturePath)
+}
+
+func signManifestFile(ctx context.Context, fs FS, cfg *config.Config, logger *logging.Logger, manifestPath, signaturePath string) error {
+	if cfg == nil || strings.TrimSpace(cfg.BaseDir) == "" {
+		return fmt.Errorf("base directory not configured")
+	}
+	key, err := loadOrCreateManifestSigningKey(ctx, cfg.BaseDir, logger)
+	if err != nil {
+		return fmt.Errorf("load signing key: %w", err)
+	}
+	data, err := fs.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+	sig, err := backup.SignManifestData(data, key)
+	if err != nil {
+		return err
+	}
+	if err := fs.WriteFile(signaturePath, sig, 0o640); err != nil {
+		return fmt.Errorf("write signature %s: %w", signaturePath, err)
+	}
+	return nil
+}
+
+// readStagedManifestSignature returns the staged manifest bytes and, when the
+// backup carries one, its detached signature.
+func readStagedManifestSignature(staged stagedFiles) (data, sig []byte, err error) {
+	if strings.TrimSpace(staged.MetadataPath) == "" {
+		return nil, nil, nil
+	}
+	data, err = restoreFS.ReadFile(staged.MetadataPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read staged manifest: %w", err)
+	}
+	if strings.TrimSpace(staged.SignaturePath) != "" {
+		sig, err = restoreFS.ReadFile(staged.SignaturePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read manifest signature: %w", err)
+		}
+	}
+	return data, sig, nil
+}
+
+// manifestSignatureTrustedKeys returns the local host's public key plus every
+// readable key listed in MANIFEST_TRUSTED_KEYS. Unreadable keys are logged and
+// skipped so one stale path does not reject every backup.
+func manifestSignatureTrustedKeys(cfg *config.Config, logger *logging.Logger) []*ecdsa.PublicKey {
+	var keys []*ecdsa.PublicKey
+	if local, err := loadLocalManifestPublicKey(cfg.BaseDir); err != nil {
+		logger.Warning("Cannot load local manifest signing key: %v", err)
+	} else if local != nil {
+		keys = append(keys, local)
+	}
+	for _, path := range cfg.ManifestTrustedKeys {
+		pub, err := readManifestPublicKeyFile(path)
+		if err != nil {
+			logger.Warning("Ignoring trusted manifest key %s: %v", path, err)
+			continue
+		}
+		keys = append(keys, pub)
+	}
+	return keys
+}
+
+func readManifestPublicKeyFile(path string) (pub *ecdsa.PublicKey, err error) {
+	f, err := safefs.OpenFileUnderRoot(strings.TrimSpace(path), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer closeIntoErr(&err, f, "close trusted key")
+	data, err := io.ReadAll(io.LimitReader(f, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	return backup.ParseManifestPublicKey(data)
+}
+
+// verifyPreparedManifestSignature checks the staged manifest signature and that
+// the signed manifest describes the archive whose checksum was just verified,
+// so a valid signature cannot be replayed next to a different archive.
+func verifyPreparedManifestSignature(prepared *preparedBundle, trusted []*ecdsa.PublicKey) error {
+	if err := backup.VerifyManifestSignature(prepared.manifestData, prepared.manifestSignature, trusted); err != nil {
+		return err
+	}
+	var signed backup.Manifest
+	if err := json.Unmarshal(prepared.manifestData, &signed); err != nil {
+		return fmt.Errorf("parse signed manifest: %w", err)
+	}
+	checksum, err := backup.NormalizeChecksum(signed.SHA256)
+	if err != nil {
+		return fmt.Errorf("signed manifest checksum: %w", err)
+	}
+	if checksum != prepared.SourceChecksum {
+		return fmt.Errorf("signed manifest does not describe this archive (checksum %s, archive %s)", checksum, prepared.SourceChecksum)
+	}
+	return nil
+}
+
+// enforceManifestSignaturePolicy applies MANIFEST_SIGNATURE_POLICY to a
+// prepared backup: "require" refuses unsigned or mis-signed backups, "warn"
+// logs a warning and continues, "off" (or an unset policy) skips the check.
+func enforceManifestSignaturePolicy(cfg *config.Config, logger *logging.Logger, prepared *preparedBundle) error {
+	if cfg == nil || prepared == nil {
+		return nil
+	}
+	policy := strings.TrimSpace(cfg.ManifestSignaturePolicy)
+	if policy == "" || policy == config.ManifestSignatureOff {
+		return nil
+	}
+	if logger == nil {
+		logger = logging.GetDefaultLogger()
+	}
+	err := verifyPreparedManifestSignature(prepared, manifestSignatureTrustedKeys(cfg, logger))
+	if err == nil {
+		logger.Info("Backup manifest signature verified")
+		return nil
+	}
+	hint := ""
+	if errors.Is(err, backup.ErrManifestSignatureInvalid) {
+		hint = " (backups from another host need its identity/manifest_signing.pub in MANIFEST_TRUSTED_KEYS)"
+	}
+	if policy == config.ManifestSignatureRequire {
+		return fmt.Errorf("refusing backup: %w%s; set MANIFEST_SIGNATURE_POLICY=warn to continue anyway", err, hint)
+	}
+	logger.Warning("Backup manifest signature check failed: %v%s", err, hint)
+	return nil
+}
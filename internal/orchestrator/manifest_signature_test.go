@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+const signedManifestChecksum = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func stubManifestSigningKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	origLoad, origLocal := loadOrCreateManifestSigningKey, loadLocalManifestPublicKey
+	loadOrCreateManifestSigningKey = func(context.Context, string, *logging.Logger) (*ecdsa.PrivateKey, error) { return key, nil }
+	loadLocalManifestPublicKey = func(string) (*ecdsa.PublicKey, error) { return &key.PublicKey, nil }
+	t.Cleanup(func() {
+		loadOrCreateManifestSigningKey, loadLocalManifestPublicKey = origLoad, origLocal
+	})
+	return key
+}
+
+func signedPreparedBundle(t *testing.T, key *ecdsa.PrivateKey, checksum string) *preparedBundle {
+	t.Helper()
+	data := []byte(`{"archive_path":"host-backup.tar.zst","sha256":"` + signedManifestChecksum + `"}`)
+	sig, err := backup.SignManifestData(data, key)
+	if err != nil {
+		t.Fatalf("SignManifestData: %v", err)
+	}
+	return &preparedBundle{SourceChecksum: checksum, manifestData: data, manifestSignature: sig}
+}
+
+func TestSignedManifestBundleRoundTrip(t *testing.T) {
+	stubManifestSigningKey(t)
+	logger := logging.New(types.LogLevelWarning, false)
+	tempDir := t.TempDir()
+	archive := filepath.Join(tempDir, "backup.tar")
+	writeBundleFixtures(t, archive, map[string]string{
+		"":          "archive-content",
+		".sha256":   signedManifestChecksum + "  backup.tar\n",
+		".metadata": `{"sha256":"` + signedManifestChecksum + `"}`,
+	})
+	cfg := &config.Config{BaseDir: tempDir, SignManifests: true}
+	if err := signManifestFile(context.Background(), osFS{}, cfg, logger, archive+".metadata", backup.ManifestSignaturePath(archive)); err != nil {
+		t.Fatalf("signManifestFile: %v", err)
+	}
+
+	o := &Orchestrator{logger: logger, fs: osFS{}}
+	bundlePath, err := o.createBundle(context.Background(), archive)
+	if err != nil {
+		t.Fatalf("createBundle: %v", err)
+	}
+	workDir := t.TempDir()
+	staged, err := extractBundleToWorkdirWithLogger(bundlePath, workDir, logger)
+	if err != nil {
+		t.Fatalf("extract bundle: %v", err)
+	}
+	if filepath.Base(staged.ArchivePath) != "backup.tar" || filepath.Base(staged.SignaturePath) != "backup.tar.manifest.sig" {
+		t.Fatalf("staged = %+v", staged)
+	}
+
+	data, sig, err := readStagedManifestSignature(staged)
+	if err != nil {
+		t.Fatalf("readStagedManifestSignature: %v", err)
+	}
+	prepared := &preparedBundle{SourceChecksum: signedManifestChecksum, manifestData: data, manifestSignature: sig}
+	cfg.ManifestSignaturePolicy = config.ManifestSignatureRequire
+	if err := enforceManifestSignaturePolicy(cfg, logger, prepared); err != nil {
+		t.Fatalf("signed bundle refused: %v", err)
+	}
+	if logger.HasWarnings() {
+		t.Fatal("a valid signature must not warn")
+	}
+}
+
+func TestEnforceManifestSignaturePolicy(t *testing.T) {
+	key := stubManifestSigningKey(t)
+	foreign, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	unsigned := &preparedBundle{SourceChecksum: signedManifestChecksum, manifestData: []byte(`{}`)}
+
+	require := &config.Config{ManifestSignaturePolicy: config.ManifestSignatureRequire}
+	if err := enforceManifestSignaturePolicy(require, logging.New(types.LogLevelError, false), unsigned); !errors.Is(err, backup.ErrManifestUnsigned) {
+		t.Fatalf("require + unsigned err = %v", err)
+	}
+	// A valid signature copied next to a different archive is rejected.
+	replayed := signedPreparedBundle(t, key, strings.Repeat("f", 64))
+	if err := enforceManifestSignaturePolicy(require, logging.New(types.LogLevelError, false), replayed); err == nil || !strings.Contains(err.Error(), "does not describe this archive") {
+		t.Fatalf("require + replayed err = %v", err)
+	}
+	foreignSigned := signedPreparedBundle(t, foreign, signedManifestChecksum)
+	err = enforceManifestSignaturePolicy(require, logging.New(types.LogLevelError, false), foreignSigned)
+	if !errors.Is(err, backup.ErrManifestSignatureInvalid) || !strings.Contains(err.Error(), "MANIFEST_TRUSTED_KEYS") {
+		t.Fatalf("require + foreign key err = %v", err)
+	}
+
+	// The other host's exported public key makes its backups trusted.
+	der, err := x509.MarshalPKIXPublicKey(&foreign.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	pubPath := filepath.Join(t.TempDir(), "other.pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	trusting := &config.Config{ManifestSignaturePolicy: config.ManifestSignatureRequire, ManifestTrustedKeys: []string{pubPath, filepath.Join(t.TempDir(), "missing.pub")}}
+	logger := logging.New(types.LogLevelWarning, false)
+	if err := enforceManifestSignaturePolicy(trusting, logger, foreignSigned); err != nil {
+		t.Fatalf("trusted foreign key refused: %v", err)
+	}
+	if !logger.HasWarnings() {
+		t.Fatal("an unreadable trusted key path must be reported")
+	}
+
+	warnLogger := logging.New(types.LogLevelWarning, false)
+	if err := enforceManifestSignaturePolicy(&config.Config{ManifestSignaturePolicy: config.ManifestSignatureWarn}, warnLogger, unsigned); err != nil {
+		t.Fatalf("warn policy must not fail: %v", err)
+	}
+	if !warnLogger.HasWarnings() {
+		t.Fatal("warn policy must log the unsigned backup")
+	}
+
+	offLogger := logging.New(types.LogLevelWarning, false)
+	for _, cfg := range []*config.Config{{}, {ManifestSignaturePolicy: config.ManifestSignatureOff}} {
+		if err := enforceManifestSignaturePolicy(cfg, offLogger, unsigned); err != nil {
+			t.Fatalf("policy %q must skip the check: %v", cfg.ManifestSignaturePolicy, err)
+		}
+	}
+	if offLogger.HasWarnings() {
+		t.Fatal("disabled policy must not warn")
+	}
+}
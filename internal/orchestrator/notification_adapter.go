@@ -234,17 +234,10 @@ func (n *NotificationAdapter) convertBackupStatsToNotificationData(stats *Backup
 		LocalUsagePercent:  calculateUsagePercent(stats.LocalUsedSpace, stats.LocalTotalSpace),
 
 		// Local retention info
-		LocalRetentionPolicy:   stats.LocalRetentionPolicy,
-		LocalRetentionLimit:    stats.MaxLocalBackups,
-		LocalGFSDaily:          stats.LocalGFSDaily,
-		LocalGFSWeekly:         stats.LocalGFSWeekly,
-		LocalGFSMonthly:        stats.LocalGFSMonthly,
-		LocalGFSYearly:         stats.LocalGFSYearly,
-		LocalGFSCurrentDaily:   stats.LocalGFSCurrentDaily,
-		LocalGFSCurrentWeekly:  stats.LocalGFSCurrentWeekly,
-		LocalGFSCurrentMonthly: stats.LocalGFSCurrentMonthly,
-		LocalGFSCurrentYearly:  stats.LocalGFSCurrentYearly,
-		LocalBackups:           stats.LocalBackups,
+		LocalRetentionPolicy: stats.LocalRetentionPolicy,
+		LocalRetentionLimit:  stats.MaxLocalBackups,
+		LocalRetentionTiers:  stats.LocalRetentionTiers,
+		LocalBackups:         stats.LocalBackups,
 
 		SecondaryEnabled:       stats.SecondaryEnabled,
 		SecondaryStatus:        secondaryStatus,
@@ -257,17 +250,10 @@ func (n *NotificationAdapter) convertBackupStatsToNotificationData(stats *Backup
 		SecondaryUsagePercent:  calculateUsagePercent(stats.SecondaryUsedSpace, stats.SecondaryTotalSpace),
 
 		// Secondary retention info
-		SecondaryRetentionPolicy:   stats.SecondaryRetentionPolicy,
-		SecondaryRetentionLimit:    stats.MaxSecondaryBackups,
-		SecondaryGFSDaily:          stats.SecondaryGFSDaily,
-		SecondaryGFSWeekly:         stats.SecondaryGFSWeekly,
-		SecondaryGFSMonthly:        stats.SecondaryGFSMonthly,
-		SecondaryGFSYearly:         stats.SecondaryGFSYearly,
-		SecondaryGFSCurrentDaily:   stats.SecondaryGFSCurrentDaily,
-		SecondaryGFSCurrentWeekly:  stats.SecondaryGFSCurrentWeekly,
-		SecondaryGFSCurrentMonthly: stats.SecondaryGFSCurrentMonthly,
-		SecondaryGFSCurrentYearly:  stats.SecondaryGFSCurrentYearly,
-		SecondaryBackups:           stats.SecondaryBackups,
+		SecondaryRetentionPolicy: stats.SecondaryRetentionPolicy,
+		SecondaryRetentionLimit:  stats.MaxSecondaryBackups,
+		SecondaryRetentionTiers:  stats.SecondaryRetentionTiers,
+		SecondaryBackups:         stats.SecondaryBackups,
 
 		CloudEnabled:       stats.CloudEnabled,
 		CloudStatus:        cloudStatus,
@@ -275,17 +261,17 @@ func (n *NotificationAdapter) convertBackupStatsToNotificationData(stats *Backup
 		CloudCount:         stats.CloudBackups,
 
 		// Cloud retention info
-		CloudRetentionPolicy:   stats.CloudRetentionPolicy,
-		CloudRetentionLimit:    stats.MaxCloudBackups,
-		CloudGFSDaily:          stats.CloudGFSDaily,
-		CloudGFSWeekly:         stats.CloudGFSWeekly,
-		CloudGFSMonthly:        stats.CloudGFSMonthly,
-		CloudGFSYearly:         stats.CloudGFSYearly,
-		CloudGFSCurrentDaily:   stats.CloudGFSCurrentDaily,
-		CloudGFSCurrentWeekly:  stats.CloudGFSCurrentWeekly,
-		CloudGFSCurrentMonthly: stats.CloudGFSCurrentMonthly,
-		CloudGFSCurrentYearly:  stats.CloudGFSCurrentYearly,
-		CloudBackups:           stats.CloudBackups,
+		CloudRetentionPolicy: stats.CloudRetentionPolicy,
+		CloudRetentionLimit:  stats.MaxCloudBackups,
+		CloudRetentionTiers:  stats.CloudRetentionTiers,
+		CloudBackups:         stats.CloudBackups,
+		CloudUploadQueue:     stats.CloudUploadQueue,
+		CapacityForecasts:    stats.CapacityForecasts,
+
+		PBSSnapshots:      stats.PBSSnapshots,
+		PVEGuestCoverage:  stats.PVEGuestCoverage,
+		CertificateExpiry: stats.CertificateExpiry,
+		Hardening:         stats.Hardening,
 
 		EmailStatus:    emailStatus,
 		TelegramStatus: telegramStatus,
@@ -365,8 +351,8 @@ func formatPercentString(percent float64) string {
 }
 
 func formatBackupStatusSummary(policy string, count, max int) string {
-	// GFS mode: show X/- (no fixed limit)
-	if policy == "gfs" {
+	// GFS/thinning mode: show X/- (no fixed limit)
+	if policy == "gfs" || policy == "thinning" {
 		return fmt.Sprintf("%d/-", count)
 	}
 
@@ -7,10 +7,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/logging"
 	"github.com/tis24dev/proxsave/internal/notify"
 	"github.com/tis24dev/proxsave/internal/types"
@@ -201,6 +203,7 @@ func TestConvertBackupStatsToNotificationData(t *testing.T) {
 	logger := logging.New(types.LogLevelDebug, false)
 	adapter := NewNotificationAdapter(&stubNotifier{name: "Email", enabled: true}, logger)
 
+	secondaryTiers := []notify.RetentionTier{{Name: "daily", Kept: 1, Limit: 1}, {Name: "weekly", Kept: 2, Limit: 2}}
 	stats := &BackupStats{
 		ExitCode:                  1,
 		Hostname:                  "host",
@@ -228,10 +231,7 @@ func TestConvertBackupStatsToNotificationData(t *testing.T) {
 		LocalRetentionPolicy:      "simple",
 		SecondaryRetentionPolicy:  "gfs",
 		CloudRetentionPolicy:      "simple",
-		SecondaryGFSDaily:         1,
-		SecondaryGFSWeekly:        2,
-		SecondaryGFSMonthly:       3,
-		SecondaryGFSYearly:        0,
+		SecondaryRetentionTiers:   secondaryTiers,
 		ErrorCount:                1,
 		WarningCount:              2,
 		ScriptVersion:             "1.0.0",
@@ -271,6 +271,9 @@ func TestConvertBackupStatsToNotificationData(t *testing.T) {
 	if data.CloudStatusSummary != "3/30" {
 		t.Fatalf("CloudStatusSummary = %q; want 3/30", data.CloudStatusSummary)
 	}
+	if !reflect.DeepEqual(data.SecondaryRetentionTiers, secondaryTiers) {
+		t.Fatalf("SecondaryRetentionTiers = %+v; want %+v", data.SecondaryRetentionTiers, secondaryTiers)
+	}
 	if data.EmailStatus != "disabled" || data.TelegramStatus != "N/A" {
 		t.Fatalf("Email/Telegram status unexpected: %q / %q", data.EmailStatus, data.TelegramStatus)
 	}
@@ -484,3 +487,36 @@ func sampleBackupStats() *BackupStats {
 		Compression:         types.CompressionZstd,
 	}
 }
+
+func TestConvertBackupStatsToNotificationDataCarriesPBSSnapshots(t *testing.T) {
+	adapter := NewNotificationAdapter(&stubNotifier{name: "Email", enabled: true}, logging.New(types.LogLevelError, false))
+	stats := sampleBackupStats()
+	stats.PBSSnapshots = pbsSnapshotSummaryForNotification(backup.PBSSnapshotInventorySummary{
+		Datastores:      1,
+		Groups:          4,
+		Snapshots:       20,
+		StaleGroups:     1,
+		StaleAfterDays:  2,
+		StaleGroupNames: []string{"store:vm/100"},
+	})
+
+	data := adapter.convertBackupStatsToNotificationData(stats)
+	if data.PBSSnapshots == nil || data.PBSSnapshots.Groups != 4 || data.PBSSnapshots.StaleGroupNames[0] != "store:vm/100" {
+		t.Fatalf("PBSSnapshots = %+v", data.PBSSnapshots)
+	}
+}
+
+func TestConvertBackupStatsToNotificationDataCarriesPVEGuestCoverage(t *testing.T) {
+	adapter := NewNotificationAdapter(&stubNotifier{name: "Email", enabled: true}, logging.New(types.LogLevelError, false))
+	stats := sampleBackupStats()
+	stats.PVEGuestCoverage = pveGuestCoverageSummaryForNotification(backup.PVEBackupCoverageSummary{
+		Guests:              6,
+		UncoveredGuests:     1,
+		UncoveredGuestNames: []string{"200 (dns)"},
+	})
+
+	data := adapter.convertBackupStatsToNotificationData(stats)
+	if data.PVEGuestCoverage == nil || data.PVEGuestCoverage.Guests != 6 || data.PVEGuestCoverage.UncoveredGuestNames[0] != "200 (dns)" {
+		t.Fatalf("PVEGuestCoverage = %+v", data.PVEGuestCoverage)
+	}
+}
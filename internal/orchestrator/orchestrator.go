@@ -63,6 +63,7 @@ type BackupStats struct {
 	PVEVersion                string
 	PBSVersion                string
 	BundleCreated             bool
+	ArchiveVolumes            int // .partNNN volumes of a split archive (0 = single file)
 	Timestamp                 time.Time
 	Version                   string
 	StartTime                 time.Time
@@ -83,6 +84,7 @@ type BackupStats struct {
 	CompressionLevel          int
 	CompressionMode           string
 	CompressionThreads        int
+	CompressionNative         bool // built-in Go codec instead of an external tool
 	CompressionRatio          float64
 	CompressionRatioPercent   float64
 	CompressionSavingsPercent float64
@@ -103,6 +105,29 @@ type BackupStats struct {
 
 	// Cluster mode (only meaningful for PVE)
 	ClusterMode string // "cluster" or "standalone"
+	// Coordinated cluster capture (CLUSTER_BACKUP_MODE=coordinated, clustered PVE only)
+	ClusterScope       string // "full" or "node"
+	ClusterCaptureNode string
+	ClusterSlot        string
+
+	// PBS datastore content inventory (only meaningful for PBS, nil when disabled)
+	PBSSnapshots *notify.PBSSnapshotSummary
+
+	// PVE guest backup coverage audit (only meaningful for PVE, nil when disabled)
+	PVEGuestCoverage    *notify.PVEGuestCoverageSummary
+	PVEGuestLastBackups []metrics.GuestLastBackup
+
+	// Web UI/API certificate expiry (nil when CERT_EXPIRY_WARN_DAYS=0 or not a PVE/PBS host)
+	CertificateExpiry *notify.CertificateExpirySummary
+
+	// Host hardening audit (nil when SECURITY_HARDENING_AUDIT is disabled)
+	Hardening *notify.HardeningSummary
+
+	// Cloud uploads deferred by CLOUD_UPLOAD_WINDOW (nil when not in use)
+	CloudUploadQueue *notify.CloudUploadQueueSummary
+
+	// Projected fill-up per storage target (empty when CAPACITY_FORECAST_WARN_DAYS=0)
+	CapacityForecasts []notify.CapacityForecastSummary
 
 	// File counts for notifications
 	FilesIncluded int
@@ -125,33 +150,12 @@ type BackupStats struct {
 	MaxCloudBackups     int
 
 	// Retention policy info (for notifications)
-	LocalRetentionPolicy       string
-	LocalGFSDaily              int
-	LocalGFSWeekly             int
-	LocalGFSMonthly            int
-	LocalGFSYearly             int
-	LocalGFSCurrentDaily       int
-	LocalGFSCurrentWeekly      int
-	LocalGFSCurrentMonthly     int
-	LocalGFSCurrentYearly      int
-	SecondaryRetentionPolicy   string
-	SecondaryGFSDaily          int
-	SecondaryGFSWeekly         int
-	SecondaryGFSMonthly        int
-	SecondaryGFSYearly         int
-	SecondaryGFSCurrentDaily   int
-	SecondaryGFSCurrentWeekly  int
-	SecondaryGFSCurrentMonthly int
-	SecondaryGFSCurrentYearly  int
-	CloudRetentionPolicy       string
-	CloudGFSDaily              int
-	CloudGFSWeekly             int
-	CloudGFSMonthly            int
-	CloudGFSYearly             int
-	CloudGFSCurrentDaily       int
-	CloudGFSCurrentWeekly      int
-	CloudGFSCurrentMonthly     int
-	CloudGFSCurrentYearly      int
+	LocalRetentionPolicy     string
+	LocalRetentionTiers      []notify.RetentionTier
+	SecondaryRetentionPolicy string
+	SecondaryRetentionTiers  []notify.RetentionTier
+	CloudRetentionPolicy     string
+	CloudRetentionTiers      []notify.RetentionTier
 
 	// Error/warning counts
 	ErrorCount   int
@@ -294,8 +298,14 @@ func (o *Orchestrator) logGlobalRetentionPolicy() {
 	if o.cfg.IsGFSRetentionEnabled() {
 		rc := storage.NewRetentionConfigFromConfig(o.cfg, storage.LocationPrimary)
 		rc = storage.NormalizeGFSRetentionConfig(o.logger, "All Storage", rc)
-		o.logger.Info("  Policy: GFS (daily=%d, weekly=%d, monthly=%d, yearly=%d)",
-			rc.Daily, rc.Weekly, rc.Monthly, rc.Yearly)
+		o.logger.Info("  Policy: %s", rc.Describe())
+		return
+	}
+
+	// Thinning rules are global too
+	if o.cfg.IsThinningRetentionEnabled() {
+		rc := storage.NewRetentionConfigFromConfig(o.cfg, storage.LocationPrimary)
+		o.logger.Info("  Policy: %s", rc.Describe())
 		return
 	}
 
@@ -568,6 +578,7 @@ func (o *Orchestrator) RunGoBackup(ctx context.Context, envInfo *environment.Env
 		return stats, fmt.Errorf("failed to create temp marker file: %w", err)
 	}
 	o.registerBackupWorkspace(workspace)
+	defer o.settleClusterCapture(run, false)
 
 	if err := o.collectBackupData(run, workspace); err != nil {
 		return stats, err
@@ -579,6 +590,7 @@ func (o *Orchestrator) RunGoBackup(ctx context.Context, envInfo *environment.Env
 	if err := o.verifyAndWriteBackupArtifacts(run, workspace, artifacts); err != nil {
 		return stats, err
 	}
+	o.settleClusterCapture(run, true)
 	if err := o.bundleBackupArtifacts(run, workspace, artifacts); err != nil {
 		return stats, err
 	}
@@ -669,6 +681,52 @@ func (s *BackupStats) toPrometheusMetrics() *metrics.BackupMetrics {
 		ArchiveSize:    s.ArchiveSize,
 		FilesCollected: s.FilesCollected,
 		FilesFailed:    s.FilesFailed,
+		GuestCoverage:  s.guestCoverageMetrics(),
+
+		CapacityForecasts: s.capacityForecastMetrics(),
+		CertificateExpiry: s.certificateExpiryMetrics(),
+	}
+}
+
+func (s *BackupStats) certificateExpiryMetrics() []metrics.CertificateExpiryMetrics {
+	if s.CertificateExpiry == nil {
+		return nil
+	}
+	out := make([]metrics.CertificateExpiryMetrics, 0, len(s.CertificateExpiry.Certificates))
+	for _, c := range s.CertificateExpiry.Certificates {
+		out = append(out, metrics.CertificateExpiryMetrics{Name: c.Name, NotAfter: c.NotAfter})
+	}
+	return out
+}
+
+func (s *BackupStats) capacityForecastMetrics() []metrics.CapacityForecastMetrics {
+	if len(s.CapacityForecasts) == 0 {
+		return nil
+	}
+	out := make([]metrics.CapacityForecastMetrics, 0, len(s.CapacityForecasts))
+	for _, f := range s.CapacityForecasts {
+		out = append(out, metrics.CapacityForecastMetrics{
+			Location:         f.Location,
+			DaysUntilFull:    f.DaysUntilFull,
+			SteadyStateBytes: f.SteadyStateBytes,
+		})
+	}
+	return out
+}
+
+func (s *BackupStats) guestCoverageMetrics() *metrics.GuestCoverageMetrics {
+	cov := s.PVEGuestCoverage
+	if cov == nil {
+		return nil
+	}
+	return &metrics.GuestCoverageMetrics{
+		Guests:             cov.Guests,
+		UncoveredGuests:    cov.UncoveredGuests,
+		StaleGuests:        cov.StaleGuests,
+		Jobs:               cov.Jobs,
+		FailedJobs:         cov.FailedJobs,
+		OfflineStorageJobs: cov.OfflineStorageJobs,
+		LastBackups:        s.PVEGuestLastBackups,
 	}
 }
 
@@ -707,6 +765,14 @@ func (o *Orchestrator) createBundle(ctx context.Context, archivePath string) (bu
 		}
 	}
 
+	// The manifest signature is optional (SIGN_MANIFESTS); keep the archive as
+	// the last entry so older readers that treat any unknown entry as the
+	// archive still pick the right file.
+	signature := base + backup.ManifestSignatureSuffix
+	if _, err := fs.Stat(filepath.Join(dir, signature)); err == nil {
+		associated = append(associated[:len(associated)-1], signature, base)
+	}
+
 	bundlePath = archivePath + ".bundle.tar"
 	logger.Debug("Creating bundle with native Go tar: %s (files: %v)", bundlePath, associated)
 
@@ -831,6 +897,7 @@ func (o *Orchestrator) removeAssociatedFiles(archivePath string) error {
 		archivePath + ".metadata",
 		archivePath + ".metadata.sha256",
 		archivePath + ".manifest.json",
+		backup.ManifestSignaturePath(archivePath),
 	}
 
 	for _, f := range files {
@@ -1149,6 +1216,11 @@ func (o *Orchestrator) writeBackupMetadata(tempDir string, stats *BackupStats) e
 	if stats.ClusterMode != "" {
 		fmt.Fprintf(&builder, "PVE_CLUSTER_MODE=%s\n", stats.ClusterMode)
 	}
+	if stats.ClusterScope != "" {
+		fmt.Fprintf(&builder, "PVE_CLUSTER_SCOPE=%s\n", stats.ClusterScope)
+		fmt.Fprintf(&builder, "PVE_CLUSTER_CAPTURE_NODE=%s\n", stats.ClusterCaptureNode)
+		fmt.Fprintf(&builder, "PVE_CLUSTER_SLOT=%s\n", stats.ClusterSlot)
+	}
 	builder.WriteString("SUPPORTS_SELECTIVE_RESTORE=true\n")
 	builder.WriteString("BACKUP_FEATURES=selective_restore,category_mapping,version_detection,auto_directory_creation\n")
 
@@ -1184,8 +1256,12 @@ func applyCollectorOverrides(cc *backup.CollectorConfig, cfg *config.Config) {
 	cc.BackupSmallPVEBackups = cfg.BackupSmallPVEBackups
 	cc.MaxPVEBackupSizeBytes = cfg.MaxPVEBackupSizeBytes
 	cc.PVEBackupIncludePattern = cfg.PVEBackupIncludePattern
+	cc.PVEBackupCoverageAudit = cfg.PVEBackupCoverageAudit
+	cc.PVEGuestBackupMaxAgeDays = cfg.PVEGuestBackupMaxAgeDays
 	cc.BackupCephConfig = cfg.BackupCephConfig
 	cc.CephConfigPath = cfg.CephConfigPath
+	cc.ClusterCoordination = cfg.ClusterBackupMode == config.ClusterBackupCoordinated
+	cc.ClusterCaptureInterval = cfg.ClusterCaptureInterval
 	cc.PveshTimeoutSeconds = cfg.PveshTimeoutSeconds
 	cc.FsIoTimeoutSeconds = cfg.FsIoTimeoutSeconds
 
@@ -1206,12 +1282,16 @@ func applyCollectorOverrides(cc *backup.CollectorConfig, cfg *config.Config) {
 	cc.BackupPBSNetworkConfig = cfg.BackupPBSNetworkConfig
 	cc.BackupPruneSchedules = cfg.BackupPruneSchedules
 	cc.BackupPxarFiles = cfg.BackupPxarFiles
+	cc.PBSSnapshotInventory = cfg.PBSSnapshotInventory
+	cc.PBSSnapshotVerifyMaxAgeDays = cfg.PBSSnapshotVerifyMaxAgeDays
+	cc.PBSSnapshotStaleDays = cfg.PBSSnapshotStaleDays
 
 	cc.BackupNetworkConfigs = cfg.BackupNetworkConfigs
 	cc.BackupAptSources = cfg.BackupAptSources
 	cc.BackupCronJobs = cfg.BackupCronJobs
 	cc.BackupSystemdServices = cfg.BackupSystemdServices
 	cc.BackupSSLCerts = cfg.BackupSSLCerts
+	cc.CertExpiryWarnDays = cfg.CertExpiryWarnDays
 	cc.BackupSysctlConfig = cfg.BackupSysctlConfig
 	cc.BackupKernelModules = cfg.BackupKernelModules
 	cc.BackupFirewallRules = cfg.BackupFirewallRules
@@ -1233,6 +1313,10 @@ func applyCollectorOverrides(cc *backup.CollectorConfig, cfg *config.Config) {
 	cc.PxarFileExcludePatterns = append([]string(nil), cfg.PxarFileExcludePatterns...)
 
 	cc.CustomBackupPaths = append([]string(nil), cfg.CustomBackupPaths...)
+	cc.CustomBricksFile = cfg.CustomBricksFile
+	if cfg.CollectorWorkers > 0 {
+		cc.CollectorWorkers = cfg.CollectorWorkers
+	}
 	cc.BackupBlacklist = append([]string(nil), cfg.BackupBlacklist...)
 
 	cc.ConfigFilePath = cfg.ConfigPath
@@ -340,6 +340,13 @@ func applyPBSS3CfgViaAPI(ctx context.Context, logger *logging.Logger, stageRoot
 }
 
 func applyPBSDatastoreCfgViaAPI(ctx context.Context, logger *logging.Logger, stageRoot string, strict bool) error {
+	return applyPBSDatastoreCfgViaAPIWithSkip(ctx, logger, stageRoot, strict, nil)
+}
+
+// applyPBSDatastoreCfgViaAPIWithSkip applies datastore.cfg but leaves the datastores
+// in skip untouched: they are neither created nor updated, and strict mode does not
+// prune an existing datastore with the same name.
+func applyPBSDatastoreCfgViaAPIWithSkip(ctx context.Context, logger *logging.Logger, stageRoot string, strict bool, skip map[string]bool) error {
 	dsRaw, present, err := readStageFileOptional(stageRoot, "etc/proxmox-backup/datastore.cfg")
 	if err != nil {
 		return err
@@ -426,6 +433,10 @@ func applyPBSDatastoreCfgViaAPI(ctx context.Context, logger *logging.Logger, sta
 	}
 	sort.Strings(names)
 	for _, name := range names {
+		if skip[name] {
+			logger.Info("PBS API apply: datastore %s skipped (not ready on this host)", name)
+			continue
+		}
 		s := desired[name]
 		path, entries, ok := popEntryValue(s.Entries, "path")
 		if !ok || strings.TrimSpace(path) == "" {
@@ -0,0 +1,490 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/pbs"
+)
+
+// pbsReattachMount is a mounted filesystem scanned for existing datastores.
+type pbsReattachMount struct {
+	Path   string
+	FSType string
+	// UUID is the filesystem UUID of the mounted device, when known.
+	UUID string
+}
+
+// pbsDiscoveredDatastore is a PBS datastore layout found on disk.
+type pbsDiscoveredDatastore struct {
+	Path       string
+	UUID       string
+	Groups     []string
+	Namespaces int
+}
+
+// pbsReattachMatch pairs an archived datastore.cfg entry with a layout on disk.
+type pbsReattachMatch struct {
+	Name    string
+	Path    string
+	MatchBy string
+	Section proxmoxNotificationSection
+	Found   pbsDiscoveredDatastore
+}
+
+const (
+	pbsReattachScanDepth = 3
+	pbsReattachIOTimeout = 5 * time.Second
+)
+
+// Filesystems that can hold a datastore; pseudo and in-memory filesystems are never scanned.
+var pbsReattachFSTypes = map[string]bool{
+	"ext2": true, "ext3": true, "ext4": true, "xfs": true, "btrfs": true, "zfs": true, "f2fs": true,
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "ceph": true, "fuse.glusterfs": true,
+}
+
+var (
+	pbsReattachMountsFn = listPBSReattachMounts
+	pbsReattachScanSkip = []string{"/boot", "/dev", "/proc", "/run", "/sys", "/var/lib/docker"}
+)
+
+// listPBSReattachMounts returns the mounted data filesystems. The root filesystem
+// is left out: a datastore on "/" is only found through its archived path.
+func listPBSReattachMounts() ([]pbsReattachMount, error) {
+	data, err := mountGuardReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("read mountinfo: %w", err)
+	}
+	uuids := deviceUUIDsByPath()
+
+	var mounts []pbsReattachMount
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		// mountinfo: id parent major:minor root mountpoint opts ... - fstype source superopts
+		pre, post, ok := strings.Cut(line, " - ")
+		if !ok {
+			continue
+		}
+		fields, tail := strings.Fields(pre), strings.Fields(post)
+		if len(fields) < 5 || len(tail) < 2 {
+			continue
+		}
+		mp := filepath.Clean(unescapeProcPath(fields[4]))
+		fsType := tail[0]
+		if mp == "/" || seen[mp] || !pbsReattachFSTypes[fsType] || pbsReattachSkipped(mp) {
+			continue
+		}
+		seen[mp] = true
+		source := tail[1]
+		if resolved, err := filepath.EvalSymlinks(source); err == nil {
+			source = resolved
+		}
+		mounts = append(mounts, pbsReattachMount{Path: mp, FSType: fsType, UUID: uuids[source]})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Path < mounts[j].Path })
+	return mounts, nil
+}
+
+// deviceUUIDsByPath maps resolved block device paths to their filesystem UUID.
+func deviceUUIDsByPath() map[string]string {
+	out := make(map[string]string)
+	entries, err := os.ReadDir("/dev/disk/by-uuid")
+	if err != nil {
+		return out
+	}
+	for _, e := range entries {
+		dev, err := filepath.EvalSymlinks(filepath.Join("/dev/disk/by-uuid", e.Name()))
+		if err == nil {
+			out[dev] = e.Name()
+		}
+	}
+	return out
+}
+
+func pbsReattachSkipped(path string) bool {
+	for _, prefix := range pbsReattachScanSkip {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverPBSDatastores walks each mount (up to pbsReattachScanDepth levels) and
+// returns the directories laid out as a PBS datastore (a .chunks directory).
+func discoverPBSDatastores(ctx context.Context, mounts []pbsReattachMount, extra []string) []pbsDiscoveredDatastore {
+	var found []pbsDiscoveredDatastore
+	seen := make(map[string]bool)
+	add := func(path, uuid string) bool {
+		path = filepath.Clean(path)
+		if seen[path] {
+			return true
+		}
+		ds, ok := inspectPBSDatastoreLayout(ctx, path)
+		if ok {
+			seen[path] = true
+			ds.UUID = uuid
+			found = append(found, ds)
+		}
+		return ok
+	}
+
+	var walk func(dir, uuid string, depth int)
+	walk = func(dir, uuid string, depth int) {
+		if ctx.Err() != nil || add(dir, uuid) {
+			return
+		}
+		if depth >= pbsReattachScanDepth {
+			return
+		}
+		entries, err := restoreFS.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			walk(filepath.Join(dir, e.Name()), uuid, depth+1)
+		}
+	}
+	for _, m := range mounts {
+		walk(m.Path, m.UUID, 0)
+	}
+	for _, path := range extra {
+		if filepath.IsAbs(path) {
+			add(path, "")
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
+
+func inspectPBSDatastoreLayout(ctx context.Context, path string) (pbsDiscoveredDatastore, bool) {
+	info, err := restoreFS.Stat(filepath.Join(path, ".chunks"))
+	if err != nil || !info.IsDir() {
+		return pbsDiscoveredDatastore{}, false
+	}
+	ds := pbsDiscoveredDatastore{Path: path}
+	for _, group := range []string{"vm", "ct", "host"} {
+		if info, err := restoreFS.Stat(filepath.Join(path, group)); err == nil && info.IsDir() {
+			ds.Groups = append(ds.Groups, group)
+		}
+	}
+	if namespaces, err := pbs.DiscoverNamespacesFromFilesystem(ctx, path, pbsReattachIOTimeout); err == nil && len(namespaces) > 0 {
+		ds.Namespaces = len(namespaces) - 1 // the root namespace is always listed
+	}
+	return ds, true
+}
+
+// matchPBSDatastores pairs archived datastores with discovered layouts. Each
+// layout is used once, by the strongest match: archived path, then the backing
+// device UUID of a removable datastore, then a directory named like the datastore.
+func matchPBSDatastores(sections []proxmoxNotificationSection, found []pbsDiscoveredDatastore, registered map[string]bool) []pbsReattachMatch {
+	used := make(map[string]bool)
+	pending := make([]proxmoxNotificationSection, 0, len(sections))
+	for _, s := range sections {
+		if name := strings.TrimSpace(s.Name); name != "" && !registered[name] {
+			pending = append(pending, s)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+
+	var matches []pbsReattachMatch
+	claim := func(s proxmoxNotificationSection, by, path string, ds pbsDiscoveredDatastore) {
+		used[ds.Path] = true
+		matches = append(matches, pbsReattachMatch{Name: strings.TrimSpace(s.Name), Path: path, MatchBy: by, Section: s, Found: ds})
+	}
+	matched := make(map[string]bool)
+	rules := []struct {
+		by   string
+		test func(s proxmoxNotificationSection, ds pbsDiscoveredDatastore) (string, bool)
+	}{
+		{"path", func(s proxmoxNotificationSection, ds pbsDiscoveredDatastore) (string, bool) {
+			path := storageEntryValue(s.Entries, "path")
+			return path, filepath.IsAbs(path) && filepath.Clean(path) == ds.Path
+		}},
+		{"device UUID", func(s proxmoxNotificationSection, ds pbsDiscoveredDatastore) (string, bool) {
+			uuid := storageEntryValue(s.Entries, "backing-device")
+			// Removable datastores keep their archived relative path on the device,
+			// which also tells apart several datastores on the same device.
+			path := storageEntryValue(s.Entries, "path")
+			rel := strings.Trim(path, "/")
+			onDevice := rel == "" || strings.HasSuffix(ds.Path, "/"+rel)
+			return path, uuid != "" && uuid == ds.UUID && onDevice
+		}},
+		{"name", func(s proxmoxNotificationSection, ds pbsDiscoveredDatastore) (string, bool) {
+			if storageEntryValue(s.Entries, "backing-device") != "" {
+				return "", false
+			}
+			return ds.Path, filepath.Base(ds.Path) == strings.TrimSpace(s.Name)
+		}},
+	}
+	for _, rule := range rules {
+		for _, s := range pending {
+			if matched[s.Name] {
+				continue
+			}
+			for _, ds := range found {
+				if used[ds.Path] {
+					continue
+				}
+				if path, ok := rule.test(s, ds); ok {
+					claim(s, rule.by, path, ds)
+					matched[s.Name] = true
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+func (m pbsReattachMatch) describe() string {
+	content := "empty"
+	if len(m.Found.Groups) > 0 {
+		content = strings.Join(m.Found.Groups, "/")
+	}
+	if m.Found.Namespaces > 0 {
+		content += fmt.Sprintf(", %d namespace(s)", m.Found.Namespaces)
+	}
+	line := fmt.Sprintf("%s -> %s (matched by %s; %s)", m.Name, m.Found.Path, m.MatchBy, content)
+	if !m.verified() {
+		line += fmt.Sprintf(" [verify: %s match only]", m.MatchBy)
+	}
+	return line
+}
+
+// verified reports whether the layout is tied to the archived datastore by the
+// identity of its backing device rather than by where it was found.
+func (m pbsReattachMatch) verified() bool {
+	return m.MatchBy == "device UUID"
+}
+
+// reattachArgs builds the datastore create call that reuses the existing chunk
+// store with the archived settings (GC schedule, keep-*, verify-new, tuning, ...).
+func (m pbsReattachMatch) reattachArgs() []string {
+	_, entries, _ := popEntryValue(m.Section.Entries, "path")
+	args := []string{"datastore", "create", m.Name, m.Path, "--reuse-datastore", "true"}
+	return append(args, buildProxmoxManagerFlags(entries, "reuse-datastore")...)
+}
+
+// maybeReattachPBSDatastoresWithUI looks for datastores of the archive that still
+// exist on disk but are not registered on this host, and re-registers them after
+// confirmation: device UUID matches are confirmed together, path and name matches
+// one by one with "skip" as the default. Re-attached names are excluded from the
+// later datastore.cfg apply.
+func maybeReattachPBSDatastoresWithUI(ctx context.Context, ui storageValidationUI, logger *logging.Logger, plan *RestorePlan, stageRoot string, dryRun bool) (err error) {
+	if plan == nil || !plan.SystemType.SupportsPBS() || !plan.HasCategoryID("datastore_pbs") || strings.TrimSpace(stageRoot) == "" {
+		return nil
+	}
+	if !pbsStagedApplyIsRealRestoreFSFn(restoreFS) {
+		logging.DebugStep(logger, "pbs datastore reattach", "Skipped: non-system filesystem in use")
+		return nil
+	}
+
+	raw, present, err := readStageFileOptional(stageRoot, "etc/proxmox-backup/datastore.cfg")
+	if err != nil || !present {
+		return err
+	}
+	sections, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return fmt.Errorf("parse staged datastore.cfg: %w", err)
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+
+	done := logging.DebugStart(logger, "pbs datastore reattach", "archived=%d dryRun=%v", len(sections), dryRun)
+	defer func() { done(err) }()
+
+	if !dryRun {
+		if pbsStagedApplyGeteuidFn() != 0 {
+			logger.Warning("Skipping PBS datastore re-attach: requires root privileges")
+			return nil
+		}
+		if err := pbsStagedApplyEnsurePBSServicesForAPIFn(ctx, logger); err != nil {
+			logger.Warning("Skipping PBS datastore re-attach: PBS API unavailable: %v", err)
+			return nil
+		}
+	}
+	registered, err := listRegisteredPBSDatastores(ctx)
+	if err != nil {
+		if dryRun {
+			logging.DebugStep(logger, "pbs datastore reattach", "datastore list unavailable in dry run: %v", err)
+			registered = map[string]bool{}
+		} else {
+			return fmt.Errorf("list registered datastores: %w", err)
+		}
+	}
+
+	mounts, err := pbsReattachMountsFn()
+	if err != nil {
+		logger.Warning("PBS datastore re-attach: %v; only archived paths are checked", err)
+	}
+	var archivedPaths []string
+	for _, s := range sections {
+		if path := storageEntryValue(s.Entries, "path"); filepath.IsAbs(path) {
+			archivedPaths = append(archivedPaths, path)
+		}
+	}
+	found := discoverPBSDatastores(ctx, mounts, archivedPaths)
+	matches := matchPBSDatastores(sections, found, registered)
+	logging.DebugStep(logger, "pbs datastore reattach", "mounts=%d layouts=%d matches=%d", len(mounts), len(found), len(matches))
+	if len(matches) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(matches))
+	var verified, unverified []pbsReattachMatch
+	for _, m := range matches {
+		lines = append(lines, "  "+m.describe())
+		if m.verified() {
+			verified = append(verified, m)
+		} else {
+			unverified = append(unverified, m)
+		}
+	}
+	report := strings.Join(lines, "\n")
+	if dryRun {
+		return ui.ShowMessage(ctx, "PBS datastore re-attach (dry run)", "These datastores would be re-attached (matches not confirmed by device UUID ask for confirmation one by one):\n\n"+report)
+	}
+
+	var accepted []pbsReattachMatch
+	if len(verified) > 0 {
+		vlines := make([]string, 0, len(verified))
+		for _, m := range verified {
+			vlines = append(vlines, "  "+m.describe())
+		}
+		ok, err := ui.ConfirmAction(ctx,
+			"Re-attach existing datastores?",
+			fmt.Sprintf("%d datastore(s) of the backup still exist on disk but are not registered on this host:\n\n%s\n\nRe-attaching registers them on the existing chunk store (no data is initialized or removed) with the archived settings.", len(verified), strings.Join(vlines, "\n")),
+			"Re-attach", "Skip", 90*time.Second, true)
+		if err != nil {
+			return err
+		}
+		if ok {
+			accepted = append(accepted, verified...)
+		} else {
+			logger.Info("PBS datastore re-attach skipped by user")
+		}
+	}
+	// A path or name match only says a chunk store sits where the archived one
+	// could be; it may belong to another datastore, so each one is confirmed on
+	// its own and skipped when nobody answers.
+	for _, m := range unverified {
+		ok, err := ui.ConfirmAction(ctx,
+			fmt.Sprintf("Re-attach datastore %s?", m.Name),
+			fmt.Sprintf("A datastore layout was found that is not tied to the archived datastore by its device UUID:\n\n  %s\n\nRe-attach it only if this chunk store belongs to datastore %q; attaching the wrong store mixes its backups into this datastore.", m.describe(), m.Name),
+			"Re-attach", "Skip", 90*time.Second, false)
+		if err != nil {
+			return err
+		}
+		if ok {
+			accepted = append(accepted, m)
+		} else {
+			logger.Info("PBS datastore re-attach of %s (matched by %s) skipped", m.Name, m.MatchBy)
+		}
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+
+	if plan.StorageDecisions == nil {
+		plan.StorageDecisions = &StorageDecisions{}
+	}
+	if plan.StorageDecisions.SkipPBSDatastores == nil {
+		plan.StorageDecisions.SkipPBSDatastores = make(map[string]bool)
+	}
+	var failed []string
+	attached := make(map[string]bool)
+	for _, m := range accepted {
+		if _, err := runPBSManager(ctx, m.reattachArgs()...); err != nil {
+			logger.Warning("PBS datastore re-attach: %s: %v", m.Name, err)
+			failed = append(failed, m.Name)
+			continue
+		}
+		logger.Info("Re-attached PBS datastore %s at %s", m.Name, m.Found.Path)
+		attached[m.Name] = true
+		plan.StorageDecisions.SkipPBSDatastores[m.Name] = true
+	}
+
+	// Without pbs_jobs the job configs are not restored; bring back the prune and
+	// verify jobs of the re-attached datastores so they keep their schedules.
+	if len(attached) > 0 && !plan.HasCategoryID("pbs_jobs") {
+		for _, kind := range []struct{ file, cmd string }{
+			{"etc/proxmox-backup/prune.cfg", "prune-job"},
+			{"etc/proxmox-backup/verification.cfg", "verify-job"},
+		} {
+			if err := applyPBSJobsForDatastores(ctx, logger, stageRoot, kind.file, kind.cmd, attached); err != nil {
+				logger.Warning("PBS datastore re-attach: %s: %v", kind.cmd, err)
+				failed = append(failed, kind.cmd)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("PBS datastore re-attach failed for: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func listRegisteredPBSDatastores(ctx context.Context) (map[string]bool, error) {
+	out, err := runPBSManager(ctx, "datastore", "list", "--output-format=json")
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Name  string `json:"name"`
+		Store string `json:"store"`
+	}
+	if err := json.Unmarshal(unwrapPBSJSONData(out), &rows); err != nil {
+		return nil, fmt.Errorf("parse datastore list: %w", err)
+	}
+	registered := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		name := strings.TrimSpace(row.Name)
+		if name == "" {
+			name = strings.TrimSpace(row.Store)
+		}
+		if name != "" {
+			registered[name] = true
+		}
+	}
+	return registered, nil
+}
+
+// applyPBSJobsForDatastores creates (or updates) the staged jobs of rel whose
+// store is one of stores.
+func applyPBSJobsForDatastores(ctx context.Context, logger *logging.Logger, stageRoot, rel, cmd string, stores map[string]bool) error {
+	raw, present, err := readStageFileOptional(stageRoot, rel)
+	if err != nil || !present {
+		return err
+	}
+	sections, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return fmt.Errorf("parse staged %s: %w", filepath.Base(rel), err)
+	}
+	for _, s := range sections {
+		id := strings.TrimSpace(s.Name)
+		if id == "" || !stores[storageEntryValue(s.Entries, "store")] {
+			continue
+		}
+		flags := buildProxmoxManagerFlags(s.Entries)
+		if _, err := runPBSManager(ctx, append([]string{cmd, "create", id}, flags...)...); err != nil {
+			if _, upErr := runPBSManager(ctx, append([]string{cmd, "update", id}, flags...)...); upErr != nil {
+				return fmt.Errorf("%s %s: %v (create) / %v (update)", cmd, id, err, upErr)
+			}
+		}
+		logging.DebugStep(logger, "pbs datastore reattach", "restored %s %s", cmd, id)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+func makePBSDatastoreLayout(t *testing.T, path string, groups ...string) {
+	t.Helper()
+	for _, dir := range append([]string{".chunks"}, groups...) {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+}
+
+func TestMatchPBSDatastores(t *testing.T) {
+	section := func(name string, kv ...string) proxmoxNotificationSection {
+		s := proxmoxNotificationSection{Type: "datastore", Name: name}
+		for i := 0; i+1 < len(kv); i += 2 {
+			s.Entries = append(s.Entries, proxmoxNotificationEntry{Key: kv[i], Value: kv[i+1]})
+		}
+		return s
+	}
+	sections := []proxmoxNotificationSection{
+		section("main", "path", "/mnt/main"),
+		section("usb", "path", "usb", "backing-device", "uuid-usb"),
+		section("archive", "path", "/old/archive"),
+		section("live", "path", "/mnt/live"),
+		section("gone", "path", "/mnt/gone"),
+	}
+	found := []pbsDiscoveredDatastore{
+		{Path: "/mnt/main"},
+		{Path: "/media/disk/usb", UUID: "uuid-usb"},
+		{Path: "/srv/new/archive"},
+		{Path: "/mnt/live"},
+	}
+
+	matches := matchPBSDatastores(sections, found, map[string]bool{"live": true})
+	got := make(map[string][2]string, len(matches))
+	for _, m := range matches {
+		got[m.Name] = [2]string{m.MatchBy, m.Path}
+	}
+	want := map[string][2]string{
+		"main":    {"path", "/mnt/main"},
+		"usb":     {"device UUID", "usb"},
+		"archive": {"name", "/srv/new/archive"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("matches=%v want %v", got, want)
+	}
+}
+
+func TestDiscoverPBSDatastoresScansMounts(t *testing.T) {
+	origFS := restoreFS
+	t.Cleanup(func() { restoreFS = origFS })
+	restoreFS = osFS{}
+
+	root := t.TempDir()
+	makePBSDatastoreLayout(t, filepath.Join(root, "disk1", "store1"), "vm", "ct")
+	makePBSDatastoreLayout(t, filepath.Join(root, "disk1", "a", "b", "c", "too-deep"))
+	makePBSDatastoreLayout(t, filepath.Join(root, "other", "store2"))
+	if err := os.MkdirAll(filepath.Join(root, "disk1", "store1", "ns1", "vm"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	found := discoverPBSDatastores(context.Background(),
+		[]pbsReattachMount{{Path: filepath.Join(root, "disk1"), UUID: "u1"}},
+		[]string{filepath.Join(root, "other", "store2"), "relative"})
+
+	if len(found) != 2 {
+		t.Fatalf("found=%+v, want 2 layouts", found)
+	}
+	if found[0].Path != filepath.Join(root, "disk1", "store1") || found[0].UUID != "u1" ||
+		!reflect.DeepEqual(found[0].Groups, []string{"vm", "ct"}) || found[0].Namespaces != 1 {
+		t.Fatalf("unexpected first layout: %+v", found[0])
+	}
+	if found[1].Path != filepath.Join(root, "other", "store2") || found[1].UUID != "" {
+		t.Fatalf("unexpected second layout: %+v", found[1])
+	}
+}
+
+func setupPBSReattachTest(t *testing.T, datastoreCfg string, mounts []pbsReattachMount) (string, *fakeCommandRunner) {
+	t.Helper()
+	origFS, origCmd, origMounts := restoreFS, restoreCmd, pbsReattachMountsFn
+	origEUID, origEnsure := pbsStagedApplyGeteuidFn, pbsStagedApplyEnsurePBSServicesForAPIFn
+	t.Cleanup(func() {
+		restoreFS, restoreCmd, pbsReattachMountsFn = origFS, origCmd, origMounts
+		pbsStagedApplyGeteuidFn, pbsStagedApplyEnsurePBSServicesForAPIFn = origEUID, origEnsure
+	})
+	restoreFS = osFS{}
+	runner := &fakeCommandRunner{outputs: map[string][]byte{
+		"proxmox-backup-manager datastore list --output-format=json": []byte(`[{"name":"live"}]`),
+	}}
+	restoreCmd = runner
+	pbsReattachMountsFn = func() ([]pbsReattachMount, error) { return mounts, nil }
+	pbsStagedApplyGeteuidFn = func() int { return 0 }
+	pbsStagedApplyEnsurePBSServicesForAPIFn = func(context.Context, *logging.Logger) error { return nil }
+
+	stageRoot := t.TempDir()
+	files := map[string]string{
+		"etc/proxmox-backup/datastore.cfg": datastoreCfg,
+		"etc/proxmox-backup/prune.cfg":     "prune: p-main\n    store main\n    schedule daily\n    keep-last 7\n\nprune: p-other\n    store other\n    schedule daily\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(stageRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return stageRoot, runner
+}
+
+func TestReattachPBSDatastoresRegistersAndSkipsApply(t *testing.T) {
+	disk := t.TempDir()
+	store := filepath.Join(disk, "main")
+	makePBSDatastoreLayout(t, store, "vm")
+
+	cfg := "datastore: main\n    path /old/main\n    gc-schedule daily\n    comment prod\n\ndatastore: live\n    path /mnt/live\n"
+	stageRoot, runner := setupPBSReattachTest(t, cfg, []pbsReattachMount{{Path: disk}})
+	plan := &RestorePlan{SystemType: SystemTypePBS, StagedCategories: []Category{{ID: "datastore_pbs"}}}
+	ui := &fakeStorageValidationUI{confirm: true}
+
+	if err := maybeReattachPBSDatastoresWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, false); err != nil {
+		t.Fatalf("maybeReattachPBSDatastoresWithUI: %v", err)
+	}
+	want := []string{
+		"proxmox-backup-manager datastore list --output-format=json",
+		"proxmox-backup-manager datastore create main " + store + " --reuse-datastore true --gc-schedule daily --comment prod",
+		"proxmox-backup-manager prune-job create p-main --store main --schedule daily --keep-last 7",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v\nwant %v", runner.calls, want)
+	}
+	if !plan.StorageDecisions.SkipPBSDatastores["main"] {
+		t.Fatalf("re-attached datastore must be excluded from the datastore.cfg apply: %+v", plan.StorageDecisions)
+	}
+}
+
+func TestReattachPBSDatastoresDeclinedOrDryRun(t *testing.T) {
+	disk := t.TempDir()
+	makePBSDatastoreLayout(t, filepath.Join(disk, "main"))
+	cfg := "datastore: main\n    path /old/main\n"
+
+	for _, tc := range []struct {
+		name    string
+		dryRun  bool
+		confirm bool
+	}{
+		{"declined", false, false},
+		{"dry run", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stageRoot, runner := setupPBSReattachTest(t, cfg, []pbsReattachMount{{Path: disk}})
+			plan := &RestorePlan{SystemType: SystemTypePBS, StagedCategories: []Category{{ID: "datastore_pbs"}}}
+			ui := &fakeStorageValidationUI{confirm: tc.confirm}
+
+			if err := maybeReattachPBSDatastoresWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, tc.dryRun); err != nil {
+				t.Fatalf("maybeReattachPBSDatastoresWithUI: %v", err)
+			}
+			if want := []string{"proxmox-backup-manager datastore list --output-format=json"}; !reflect.DeepEqual(runner.calls, want) {
+				t.Fatalf("calls=%v want %v", runner.calls, want)
+			}
+			if plan.StorageDecisions != nil {
+				t.Fatalf("no decision expected, got %+v", plan.StorageDecisions)
+			}
+		})
+	}
+}
+
+// defaultingReattachUI answers every confirmation with its default, as an
+// unattended restore does when the prompt times out.
+type defaultingReattachUI struct {
+	fakeStorageValidationUI
+	defaults map[string]bool
+}
+
+func (u *defaultingReattachUI) ConfirmAction(_ context.Context, title, _, _, _ string, _ time.Duration, defaultYes bool) (bool, error) {
+	u.asked = append(u.asked, title)
+	u.defaults[title] = defaultYes
+	return defaultYes, nil
+}
+
+func TestReattachPBSDatastoresConfirmsUnverifiedMatchesOneByOne(t *testing.T) {
+	disk := t.TempDir()
+	usb := filepath.Join(disk, "usb")
+	main := filepath.Join(disk, "main")
+	makePBSDatastoreLayout(t, usb, "vm")
+	makePBSDatastoreLayout(t, main, "vm")
+
+	cfg := "datastore: usb\n    path usb\n    backing-device uuid-usb\n\ndatastore: main\n    path /old/main\n"
+	stageRoot, runner := setupPBSReattachTest(t, cfg, []pbsReattachMount{{Path: disk, UUID: "uuid-usb"}})
+	plan := &RestorePlan{SystemType: SystemTypePBS, StagedCategories: []Category{{ID: "datastore_pbs"}}}
+	ui := &defaultingReattachUI{defaults: map[string]bool{}}
+
+	if err := maybeReattachPBSDatastoresWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, false); err != nil {
+		t.Fatalf("maybeReattachPBSDatastoresWithUI: %v", err)
+	}
+	wantDefaults := map[string]bool{
+		"Re-attach existing datastores?": true,
+		"Re-attach datastore main?":      false,
+	}
+	if !reflect.DeepEqual(ui.defaults, wantDefaults) {
+		t.Fatalf("prompts=%v want %v", ui.defaults, wantDefaults)
+	}
+	want := []string{
+		"proxmox-backup-manager datastore list --output-format=json",
+		"proxmox-backup-manager datastore create usb usb --reuse-datastore true --backing-device uuid-usb",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v\nwant %v", runner.calls, want)
+	}
+	if plan.StorageDecisions.SkipPBSDatastores["main"] || !plan.StorageDecisions.SkipPBSDatastores["usb"] {
+		t.Fatalf("only the UUID match may be re-attached: %+v", plan.StorageDecisions)
+	}
+}
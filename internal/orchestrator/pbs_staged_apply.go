@@ -20,7 +20,7 @@ var (
 	pbsStagedApplyTrafficControlCfgViaAPIFn = applyPBSTrafficControlCfgViaAPI
 	pbsStagedApplyNodeCfgViaAPIFn           = applyPBSNodeCfgViaAPI
 	pbsStagedApplyS3CfgViaAPIFn             = applyPBSS3CfgViaAPI
-	pbsStagedApplyDatastoreCfgViaAPIFn      = applyPBSDatastoreCfgViaAPI
+	pbsStagedApplyDatastoreCfgViaAPIFn      = applyPBSDatastoreCfgViaAPIWithSkip
 	pbsStagedApplyRemoteCfgViaAPIFn         = applyPBSRemoteCfgViaAPI
 	pbsStagedApplySyncCfgViaAPIFn           = applyPBSSyncCfgViaAPI
 	pbsStagedApplyVerificationCfgViaAPIFn   = applyPBSVerificationCfgViaAPI
@@ -140,12 +140,12 @@ func maybeApplyPBSConfigsFromStage(ctx context.Context, logger *logging.Logger,
 					failedItems = append(failedItems, "s3.cfg")
 				}
 			}
-			if err := pbsStagedApplyDatastoreCfgViaAPIFn(ctx, logger, stageRoot, strict); err != nil {
+			if err := pbsStagedApplyDatastoreCfgViaAPIFn(ctx, logger, stageRoot, strict, plan.StorageDecisions.pbsDatastoreSkips()); err != nil {
 				logger.Warning("PBS API apply: datastore.cfg failed: %v", err)
 				if errors.Is(err, errPBSCleanRemoveIncomplete) {
 					failedItems = append(failedItems, "datastore.cfg (clean 1:1 incomplete)")
 				} else if !pbsFallbackApplied(logger, "datastore.cfg", allowFileFallback, func() error {
-					return applyPBSDatastoreCfgFromStage(ctx, logger, stageRoot)
+					return applyPBSDatastoreCfgFromStageWithSkip(ctx, logger, stageRoot, plan.StorageDecisions.pbsDatastoreSkips())
 				}) {
 					failedItems = append(failedItems, "datastore.cfg")
 				}
@@ -155,7 +155,7 @@ func maybeApplyPBSConfigsFromStage(ctx context.Context, logger *logging.Logger,
 				logger.Warning("PBS staged apply: s3.cfg: %v", err)
 				failedItems = append(failedItems, "s3.cfg")
 			}
-			if err := applyPBSDatastoreCfgFromStage(ctx, logger, stageRoot); err != nil {
+			if err := applyPBSDatastoreCfgFromStageWithSkip(ctx, logger, stageRoot, plan.StorageDecisions.pbsDatastoreSkips()); err != nil {
 				logger.Warning("PBS staged apply: datastore.cfg: %v", err)
 				failedItems = append(failedItems, "datastore.cfg")
 			}
@@ -300,7 +300,14 @@ type pbsDatastoreBlock struct {
 	Lines []string
 }
 
-func applyPBSDatastoreCfgFromStage(ctx context.Context, logger *logging.Logger, stageRoot string) (err error) {
+func applyPBSDatastoreCfgFromStage(ctx context.Context, logger *logging.Logger, stageRoot string) error {
+	return applyPBSDatastoreCfgFromStageWithSkip(ctx, logger, stageRoot, nil)
+}
+
+// applyPBSDatastoreCfgFromStageWithSkip is the file-based counterpart of
+// applyPBSDatastoreCfgViaAPIWithSkip: staged datastores in skip are not written,
+// and a live definition with the same name is carried over unchanged.
+func applyPBSDatastoreCfgFromStageWithSkip(ctx context.Context, logger *logging.Logger, stageRoot string, skip map[string]bool) (err error) {
 	_ = ctx // reserved for future validation hooks
 
 	done := logging.DebugStart(logger, "pbs staged apply datastore.cfg", "stage=%s", stageRoot)
@@ -364,7 +371,13 @@ func applyPBSDatastoreCfgFromStage(ctx context.Context, logger *logging.Logger,
 
 	var applyBlocks []pbsDatastoreBlock
 	var deferred []pbsDatastoreBlock
+	skipped := 0
 	for _, b := range blocks {
+		if skip[strings.TrimSpace(b.Name)] {
+			logger.Info("PBS staged apply: datastore %s skipped (not ready on this host)", b.Name)
+			skipped++
+			continue
+		}
 		ok, reason := shouldApplyPBSDatastoreBlock(b, logger)
 		if ok {
 			applyBlocks = append(applyBlocks, b)
@@ -386,6 +399,9 @@ func applyPBSDatastoreCfgFromStage(ctx context.Context, logger *logging.Logger,
 		logger.Warning("PBS staged apply: datastore.cfg contains no safe datastore definitions to apply; leaving current configuration unchanged")
 		return nil
 	}
+	if skipped > 0 {
+		applyBlocks = append(applyBlocks, livePBSDatastoreBlocks(logger, skip)...)
+	}
 
 	var out strings.Builder
 	for i, b := range applyBlocks {
@@ -405,6 +421,31 @@ func applyPBSDatastoreCfgFromStage(ctx context.Context, logger *logging.Logger,
 	return nil
 }
 
+// livePBSDatastoreBlocks returns the blocks of the live datastore.cfg whose
+// names are in keep, so rewriting the file does not drop a skipped datastore.
+func livePBSDatastoreBlocks(logger *logging.Logger, keep map[string]bool) []pbsDatastoreBlock {
+	data, err := restoreFS.ReadFile("/etc/proxmox-backup/datastore.cfg")
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warning("PBS staged apply: read current datastore.cfg: %v", err)
+		}
+		return nil
+	}
+	normalized, _ := normalizePBSDatastoreCfgContent(string(data))
+	blocks, err := parsePBSDatastoreCfgBlocks(normalized)
+	if err != nil {
+		logger.Warning("PBS staged apply: parse current datastore.cfg: %v", err)
+		return nil
+	}
+	var kept []pbsDatastoreBlock
+	for _, b := range blocks {
+		if keep[strings.TrimSpace(b.Name)] {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
 type pbsDatastoreInventoryRestoreLite struct {
 	Files map[string]struct {
 		Content string `json:"content"`
@@ -146,6 +146,65 @@ func TestMaybeApplyPBSConfigsFromStage_CleanMode_ApiUnavailableFallsBackToFiles(
 	}
 }
 
+func TestMaybeApplyPBSConfigsFromStage_FileFallbackHonorsDatastoreSkips(t *testing.T) {
+	origFS := restoreFS
+	origIsReal := pbsStagedApplyIsRealRestoreFSFn
+	origGeteuid := pbsStagedApplyGeteuidFn
+	origEnsure := pbsStagedApplyEnsurePBSServicesForAPIFn
+	t.Cleanup(func() {
+		restoreFS = origFS
+		pbsStagedApplyIsRealRestoreFSFn = origIsReal
+		pbsStagedApplyGeteuidFn = origGeteuid
+		pbsStagedApplyEnsurePBSServicesForAPIFn = origEnsure
+	})
+
+	fakeFS := NewFakeFS()
+	t.Cleanup(func() { _ = os.RemoveAll(fakeFS.Root) })
+	restoreFS = fakeFS
+
+	pbsStagedApplyIsRealRestoreFSFn = func(FS) bool { return true }
+	pbsStagedApplyGeteuidFn = func() int { return 0 }
+	pbsStagedApplyEnsurePBSServicesForAPIFn = func(context.Context, *logging.Logger) error {
+		return errors.New("forced API unavailable")
+	}
+
+	readyDir := t.TempDir()
+	stageRoot := "/stage"
+	staged := "datastore: Ready\n    path " + readyDir + "\n\ndatastore: USB\n    path /mnt/usb-staged\n"
+	if err := fakeFS.WriteFile(stageRoot+"/etc/proxmox-backup/datastore.cfg", []byte(staged), 0o640); err != nil {
+		t.Fatalf("write staged datastore.cfg: %v", err)
+	}
+	live := "datastore: USB\n    path /mnt/usb-live\n"
+	if err := fakeFS.WriteFile("/etc/proxmox-backup/datastore.cfg", []byte(live), 0o640); err != nil {
+		t.Fatalf("write live datastore.cfg: %v", err)
+	}
+
+	plan := &RestorePlan{
+		SystemType:         SystemTypePBS,
+		PBSRestoreBehavior: PBSRestoreBehaviorClean,
+		NormalCategories:   []Category{{ID: "datastore_pbs"}},
+		StorageDecisions:   &StorageDecisions{SkipPBSDatastores: map[string]bool{"USB": true}},
+	}
+	if err := maybeApplyPBSConfigsFromStage(context.Background(), newTestLogger(), plan, stageRoot, false); err != nil {
+		t.Fatalf("maybeApplyPBSConfigsFromStage: %v", err)
+	}
+
+	out, err := fakeFS.ReadFile("/etc/proxmox-backup/datastore.cfg")
+	if err != nil {
+		t.Fatalf("read applied datastore.cfg: %v", err)
+	}
+	got := string(out)
+	if !strings.Contains(got, "datastore: Ready") {
+		t.Fatalf("expected Ready datastore to be applied: %q", got)
+	}
+	if strings.Contains(got, "/mnt/usb-staged") {
+		t.Fatalf("skipped datastore must not be written from the stage: %q", got)
+	}
+	if !strings.Contains(got, "/mnt/usb-live") {
+		t.Fatalf("skipped datastore must keep its live definition: %q", got)
+	}
+}
+
 func TestMaybeApplyPBSConfigsFromStage_MergeMode_ApiUnavailableSkipsApiCategories(t *testing.T) {
 	origFS := restoreFS
 	origIsReal := pbsStagedApplyIsRealRestoreFSFn
@@ -262,7 +321,7 @@ func TestMaybeApplyPBSConfigsFromStage_ApiErrorsTriggerFallbackOnlyInCleanMode(t
 		strictSink(strict)
 		return errors.New("forced API error")
 	}
-	pbsStagedApplyDatastoreCfgViaAPIFn = func(_ context.Context, _ *logging.Logger, _ string, strict bool) error {
+	pbsStagedApplyDatastoreCfgViaAPIFn = func(_ context.Context, _ *logging.Logger, _ string, strict bool, _ map[string]bool) error {
 		strictSink(strict)
 		return errors.New("forced API error")
 	}
@@ -438,7 +497,7 @@ func TestMaybeApplyPBSConfigsFromStage_CleanRemoveIncomplete_SurfacesWithoutFall
 	pbsStagedApplyEnsurePBSServicesForAPIFn = func(context.Context, *logging.Logger) error { return nil }
 
 	// Create/update succeed but a stale datastore could not be removed in Clean mode.
-	pbsStagedApplyDatastoreCfgViaAPIFn = func(context.Context, *logging.Logger, string, bool) error {
+	pbsStagedApplyDatastoreCfgViaAPIFn = func(context.Context, *logging.Logger, string, bool, map[string]bool) error {
 		return pbsCleanRemoveResult("datastore", []string{"stale-ds"})
 	}
 
@@ -60,7 +60,7 @@ func maybeApplyPVEConfigsFromStage(ctx context.Context, logger *logging.Logger,
 		if plan.NeedsClusterRestore {
 			logging.DebugStep(logger, "pve staged apply", "Skip PVE storage/datacenter apply: cluster RECOVERY restores config.db")
 		} else {
-			if err := applyPVEStorageCfgFromStage(ctx, logger, stageRoot); err != nil {
+			if err := applyPVEStorageCfgFromStage(ctx, logger, stageRoot, plan.StorageDecisions); err != nil {
 				logger.Warning("PVE staged apply: storage.cfg: %v", err)
 				failedItems = append(failedItems, "storage.cfg")
 			}
@@ -123,7 +123,7 @@ func applyPVEVzdumpConfFromStage(logger *logging.Logger, stageRoot string) error
 	return nil
 }
 
-func applyPVEStorageCfgFromStage(ctx context.Context, logger *logging.Logger, stageRoot string) error {
+func applyPVEStorageCfgFromStage(ctx context.Context, logger *logging.Logger, stageRoot string, decisions *StorageDecisions) error {
 	if _, err := restoreCmd.Run(ctx, "which", "pvesh"); err != nil {
 		logger.Warning("pvesh not found; skipping PVE storage.cfg apply")
 		return nil
@@ -143,7 +143,7 @@ func applyPVEStorageCfgFromStage(ctx context.Context, logger *logging.Logger, st
 		return nil
 	}
 
-	applied, failed, err := applyStorageCfg(ctx, stagePath, logger)
+	applied, failed, err := applyStorageCfgWithDecisions(ctx, stagePath, logger, decisions)
 	if err != nil {
 		return err
 	}
@@ -256,7 +256,7 @@ func TestApplyPVEStorageCfgFromStage_Branches(t *testing.T) {
 				"which pvesh": errors.New("missing"),
 			},
 		}
-		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage"); err != nil {
+		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage", nil); err != nil {
 			t.Fatalf("expected nil when pvesh missing, got %v", err)
 		}
 	})
@@ -274,7 +274,7 @@ func TestApplyPVEStorageCfgFromStage_Branches(t *testing.T) {
 		restoreFS = fakeFS
 		restoreCmd = &FakeCommandRunner{}
 
-		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage"); err != nil {
+		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage", nil); err != nil {
 			t.Fatalf("missing staged storage.cfg should be ignored: %v", err)
 		}
 	})
@@ -293,7 +293,7 @@ func TestApplyPVEStorageCfgFromStage_Branches(t *testing.T) {
 		restoreFS = readFileFailFS{FS: baseFS, failPath: stagePath, err: syscall.EPERM}
 		restoreCmd = &FakeCommandRunner{}
 
-		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage"); err == nil || !strings.Contains(err.Error(), "read staged storage.cfg") {
+		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage", nil); err == nil || !strings.Contains(err.Error(), "read staged storage.cfg") {
 			t.Fatalf("expected staged read error, got %v", err)
 		}
 	})
@@ -314,7 +314,7 @@ func TestApplyPVEStorageCfgFromStage_Branches(t *testing.T) {
 			t.Fatalf("write staged storage.cfg: %v", err)
 		}
 
-		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage"); err != nil {
+		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage", nil); err != nil {
 			t.Fatalf("empty storage.cfg should be ignored: %v", err)
 		}
 	})
@@ -344,7 +344,7 @@ func TestApplyPVEStorageCfgFromStage_Branches(t *testing.T) {
 			t.Fatalf("write staged storage.cfg: %v", err)
 		}
 
-		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage"); err != nil {
+		if err := applyPVEStorageCfgFromStage(ctx, logger, "/stage", nil); err != nil {
 			t.Fatalf("applyPVEStorageCfgFromStage: %v", err)
 		}
 		calls := strings.Join(fakeCmd.CallsList(), "\n")
@@ -122,7 +122,7 @@ func TestApplyPVEStorageCfgFromStage_ReturnsErrorOnApplyFailure(t *testing.T) {
 		t.Fatalf("add storage.cfg: %v", err)
 	}
 
-	if err := applyPVEStorageCfgFromStage(context.Background(), newTestLogger(), stageRoot); err == nil {
+	if err := applyPVEStorageCfgFromStage(context.Background(), newTestLogger(), stageRoot, nil); err == nil {
 		t.Fatalf("expected an error when storage.cfg entries fail to apply")
 	}
 }
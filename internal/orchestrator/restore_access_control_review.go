@@ -0,0 +1,672 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+const accessTokenReportDir = "/var/lib/proxsave/access-control"
+
+var accessTokenReportHostname = os.Hostname
+
+// restoredAPIToken is an API token restored from the backup and present on the
+// live system after the access control apply.
+type restoredAPIToken struct {
+	System    string // "pve" or "pbs"
+	AuthID    string // user@realm!tokenid
+	UserID    string
+	TokenID   string
+	Comment   string
+	Expire    string
+	PrivSep   string
+	HasSecret bool
+}
+
+func (t restoredAPIToken) Summary() string {
+	secret := "secret restored"
+	if !t.HasSecret {
+		secret = "no secret in backup"
+	}
+	summary := fmt.Sprintf("%s %s (%s)", strings.ToUpper(t.System), t.AuthID, secret)
+	if t.Comment != "" {
+		summary += " - " + t.Comment
+	}
+	return summary
+}
+
+// tokenACL is one ACL entry granted to an API token; deleting a token drops its
+// ACLs, so they are captured before a secret is regenerated and granted again.
+type tokenACL struct {
+	Path      string
+	Role      string
+	Propagate bool
+}
+
+// restoredRealm is an authentication realm restored from the backup.
+type restoredRealm struct {
+	System string
+	Name   string
+	Type   string // ldap, ad or openid
+}
+
+type accessControlReviewUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
+	SelectAccessTokensToRegenerate(ctx context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error)
+}
+
+// maybeReviewAccessControlWithUI runs after the staged access control apply: it lists
+// the restored API tokens and optionally regenerates their secrets (sealing the new
+// secrets to the AGE recipients), syncs LDAP/AD realms and flags users whose TFA
+// entries did not survive the restore. It works from the live state, so nothing is
+// reported when the access control apply was skipped.
+func maybeReviewAccessControlWithUI(ctx context.Context, ui accessControlReviewUI, logger *logging.Logger, plan *RestorePlan, stageRoot string, recipients []string, dryRun bool) (err error) {
+	if plan == nil || strings.TrimSpace(stageRoot) == "" {
+		return nil
+	}
+	systems := accessControlReviewSystems(plan)
+	if len(systems) == 0 {
+		return nil
+	}
+	if dryRun {
+		logger.Info("Dry run enabled: skipping access control review")
+		return nil
+	}
+	if !accessControlIsRealRestoreFS(restoreFS) {
+		logger.Debug("Skipping access control review: non-system filesystem in use")
+		return nil
+	}
+	if accessControlApplyGeteuid() != 0 {
+		logger.Warning("Skipping access control review: requires root privileges")
+		return nil
+	}
+
+	done := logging.DebugStart(logger, "access control review", "systems=%v stage=%s", systems, stageRoot)
+	defer func() { done(err) }()
+
+	var tokens []restoredAPIToken
+	var realms []restoredRealm
+	var droppedTFA []string
+	for _, system := range systems {
+		t, err := collectRestoredAPITokens(system, stageRoot)
+		if err != nil {
+			return err
+		}
+		tokens = append(tokens, t...)
+		r, err := collectRestoredRealms(system, stageRoot)
+		if err != nil {
+			return err
+		}
+		realms = append(realms, r...)
+		d, err := findDroppedTFAUsers(system, stageRoot)
+		if err != nil {
+			return err
+		}
+		droppedTFA = append(droppedTFA, d...)
+	}
+	logging.DebugStep(logger, "access control review", "tokens=%d realms=%d droppedTFA=%d", len(tokens), len(realms), len(droppedTFA))
+
+	if len(tokens) > 0 {
+		if err := reviewRestoredAPITokens(ctx, ui, logger, tokens, recipients); err != nil {
+			return err
+		}
+	}
+	if len(realms) > 0 {
+		if err := maybeSyncRestoredRealms(ctx, ui, logger, realms); err != nil {
+			return err
+		}
+	}
+	if len(droppedTFA) > 0 {
+		logger.Warning("Access control: TFA entries from the backup were dropped for %d user(s): %s", len(droppedTFA), summarizeUserIDs(droppedTFA, 8))
+		message := "These users had second factors in the backup that are not active on this system:\n\n" +
+			strings.Join(droppedTFA, "\n") +
+			"\n\nThey can log in with their password only (or not at all if the realm enforces TFA) until they enroll again."
+		if err := ui.ShowMessage(ctx, "TFA entries dropped", message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func accessControlReviewSystems(plan *RestorePlan) []string {
+	var systems []string
+	if plan.SystemType.SupportsPVE() && plan.HasCategoryID("pve_access_control") && !plan.NeedsClusterRestore {
+		systems = append(systems, "pve")
+	}
+	if plan.SystemType.SupportsPBS() && plan.HasCategoryID("pbs_access_control") {
+		systems = append(systems, "pbs")
+	}
+	return systems
+}
+
+// accessControlStagePaths returns the staged (relative) and live paths of user.cfg,
+// domains.cfg and the TFA store for a system.
+func accessControlStagePaths(system string) (userRel, userLive, domainsRel, domainsLive, tfaRel, tfaLive string) {
+	if system == "pbs" {
+		return "etc/proxmox-backup/user.cfg", pbsUserCfgPath,
+			"etc/proxmox-backup/domains.cfg", pbsDomainsCfgPath,
+			"etc/proxmox-backup/tfa.json", pbsTFAJSONPath
+	}
+	return "etc/pve/user.cfg", pveUserCfgPath,
+		"etc/pve/domains.cfg", pveDomainsCfgPath,
+		"etc/pve/priv/tfa.cfg", pveTFACfgPath
+}
+
+func collectRestoredAPITokens(system, stageRoot string) ([]restoredAPIToken, error) {
+	userRel, userLive, _, _, _, _ := accessControlStagePaths(system)
+	raw, present, err := readStageFileOptional(stageRoot, userRel)
+	if err != nil || !present {
+		return nil, err
+	}
+	staged, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse staged %s: %w", userRel, err)
+	}
+	live, _ := readProxmoxConfigSectionsOptional(userLive)
+
+	secrets, err := stagedTokenSecretIDs(system, stageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []restoredAPIToken
+	for _, s := range staged {
+		if !strings.EqualFold(strings.TrimSpace(s.Type), "token") {
+			continue
+		}
+		authID := strings.TrimSpace(s.Name)
+		userID, tokenID, ok := splitPVETokenSectionName(authID)
+		if !ok || isRootPBSUserID(userID) {
+			continue
+		}
+		if findSection(live, "token", authID) == nil {
+			continue
+		}
+		tokens = append(tokens, restoredAPIToken{
+			System:    system,
+			AuthID:    authID,
+			UserID:    userID,
+			TokenID:   tokenID,
+			Comment:   storageEntryValue(s.Entries, "comment"),
+			Expire:    storageEntryValue(s.Entries, "expire"),
+			PrivSep:   storageEntryValue(s.Entries, "privsep"),
+			HasSecret: secrets[authID],
+		})
+	}
+	return tokens, nil
+}
+
+// stagedTokenSecretIDs returns the token IDs that have a secret in the backup.
+func stagedTokenSecretIDs(system, stageRoot string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	if system == "pbs" {
+		raw, present, err := readStageFileOptional(stageRoot, "etc/proxmox-backup/token.shadow")
+		if err != nil || !present || strings.TrimSpace(raw) == "" {
+			return ids, err
+		}
+		var shadow map[string]string
+		if err := json.Unmarshal([]byte(raw), &shadow); err != nil {
+			return nil, fmt.Errorf("parse staged token.shadow: %w", err)
+		}
+		for id := range shadow {
+			ids[id] = true
+		}
+		return ids, nil
+	}
+	raw, present, err := readStageFileOptional(stageRoot, "etc/pve/priv/token.cfg")
+	if err != nil || !present {
+		return ids, err
+	}
+	sections, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse staged priv/token.cfg: %w", err)
+	}
+	for _, s := range sections {
+		ids[strings.TrimSpace(s.Name)] = true
+	}
+	return ids, nil
+}
+
+func reviewRestoredAPITokens(ctx context.Context, ui accessControlReviewUI, logger *logging.Logger, tokens []restoredAPIToken, recipients []string) error {
+	lines := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		lines = append(lines, "  "+t.Summary())
+		logger.Info("Access control: restored API token %s", t.Summary())
+	}
+	message := fmt.Sprintf("%d API token(s) were restored from the backup:\n\n%s\n\n"+
+		"Restored secrets keep working only if the clients still hold them. Regenerating a secret "+
+		"invalidates the old one; the new secrets are written to a report encrypted to the AGE recipients.\n\n"+
+		"Review the tokens and choose which secrets to regenerate?", len(tokens), strings.Join(lines, "\n"))
+	review, err := ui.ConfirmAction(ctx, "Restored API tokens", message, "Choose tokens", "Keep all", 90*time.Second, false)
+	if err != nil || !review {
+		return err
+	}
+
+	selected, err := ui.SelectAccessTokensToRegenerate(ctx, tokens)
+	if err != nil || len(selected) == 0 {
+		return err
+	}
+	if len(recipients) == 0 {
+		logger.Warning("Access control: no AGE recipient configured; not regenerating %d token secret(s)", len(selected))
+		return ui.ShowMessage(ctx, "Token secrets not regenerated",
+			"No AGE recipient is configured (AGE_RECIPIENT / AGE_RECIPIENT_FILE), so the new secrets could not be stored safely.\n\nNo token was changed.")
+	}
+	if _, err := parseRecipientStrings(recipients); err != nil {
+		return fmt.Errorf("token secret report: %w", err)
+	}
+
+	var report strings.Builder
+	host, _ := accessTokenReportHostname()
+	fmt.Fprintf(&report, "# API token secrets regenerated by proxsave on %s at %s\n", strings.TrimSpace(host), nowRestore().Format(time.RFC3339))
+	fmt.Fprintln(&report, "# system\ttoken\tsecret")
+
+	// Once a secret has been regenerated the old one is gone, so the report is
+	// written on every exit path below, including cancellation.
+	var regenerated, failed, lost []string
+	var runErr error
+	for _, t := range selected {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+		secret, err := regenerateAPITokenSecret(ctx, logger, t)
+		if err != nil {
+			if errors.Is(err, errAPITokenNotRecreated) {
+				logger.Warning("Access control: regenerate %s: %v", t.AuthID, err)
+				fmt.Fprintf(&report, "# FAILED\t%s\t%s\t%v\n", t.System, t.AuthID, err)
+				lost = append(lost, t.AuthID)
+				continue
+			}
+			if errors.Is(err, context.Canceled) {
+				runErr = err
+				break
+			}
+			logger.Warning("Access control: regenerate %s: %v", t.AuthID, err)
+			failed = append(failed, t.AuthID)
+			continue
+		}
+		fmt.Fprintf(&report, "%s\t%s\t%s\n", t.System, t.AuthID, secret)
+		regenerated = append(regenerated, t.AuthID)
+	}
+	if len(regenerated) == 0 && len(lost) == 0 {
+		if runErr != nil {
+			return runErr
+		}
+		return fmt.Errorf("no token secret regenerated (%d failed)", len(failed))
+	}
+
+	reportPath := filepath.Join(accessTokenReportDir, fmt.Sprintf("token-secrets-%s.age", nowRestore().Format("20060102-150405")))
+	reportPath, sealed, err := writeAccessTokenReport(logger, reportPath, recipients, []byte(report.String()))
+	if err != nil {
+		return fmt.Errorf("write token secret report (tokens %s were already regenerated): %w", strings.Join(regenerated, ", "), err)
+	}
+	logger.Info("Access control: regenerated %d token secret(s); report: %s", len(regenerated), reportPath)
+
+	summary := fmt.Sprintf("Regenerated: %s\n\nThe new secrets are in %s (encrypted to the AGE recipients; decrypt with your AGE identity).",
+		strings.Join(regenerated, ", "), reportPath)
+	if !sealed {
+		summary = fmt.Sprintf("Regenerated: %s\n\nThe report could not be encrypted; the new secrets are in %s (plain text, readable by root only). "+
+			"Store them safely and delete the file.", strings.Join(regenerated, ", "), reportPath)
+	}
+	if len(lost) > 0 {
+		summary += fmt.Sprintf("\n\nDeleted but not re-created (create them again manually): %s", strings.Join(lost, ", "))
+	}
+	if len(failed) > 0 {
+		summary += fmt.Sprintf("\n\nFailed (old secret kept): %s", strings.Join(failed, ", "))
+	}
+	if runErr != nil {
+		logger.Warning("Access control: token review interrupted; regenerated secrets saved to %s", reportPath)
+		return runErr
+	}
+	return ui.ShowMessage(ctx, "Token secrets regenerated", summary)
+}
+
+// writeAccessTokenReport seals the report to the AGE recipients. If sealing fails
+// it keeps a root-only plain text copy instead, since the regenerated secrets exist
+// nowhere else; it returns the path written and whether it is sealed.
+func writeAccessTokenReport(logger *logging.Logger, sealedPath string, recipients []string, body []byte) (string, bool, error) {
+	sealErr := sealAccessTokenReport(sealedPath, recipients, body)
+	if sealErr == nil {
+		return sealedPath, true, nil
+	}
+	fallback := strings.TrimSuffix(sealedPath, ".age") + ".txt"
+	if err := writeFileAtomic(fallback, body, 0o600); err != nil {
+		return "", false, errors.Join(sealErr, err)
+	}
+	logger.Warning("Access control: sealing the token secret report failed (%v); kept a root-only plain text copy at %s", sealErr, fallback)
+	return fallback, false, nil
+}
+
+// errAPITokenNotRecreated marks a token that was deleted but could not be created
+// again: the user has lost the token, not just its old secret.
+var errAPITokenNotRecreated = errors.New("token deleted and not re-created")
+
+// regenerateAPITokenSecret recreates a token with the archived settings and returns
+// the new secret. The token ACLs are read first and granted again afterwards.
+func regenerateAPITokenSecret(ctx context.Context, logger *logging.Logger, t restoredAPIToken) (string, error) {
+	acls, err := listTokenACLs(ctx, t)
+	if err != nil {
+		return "", err
+	}
+
+	if t.System == "pbs" {
+		_, err = restoreCmd.Run(ctx, "proxmox-backup-manager", "user", "delete-token", t.UserID, t.TokenID)
+	} else {
+		_, err = restoreCmd.Run(ctx, "pvesh", "delete", fmt.Sprintf("/access/users/%s/token/%s", t.UserID, t.TokenID))
+	}
+	if err != nil {
+		return "", fmt.Errorf("delete token: %w", err)
+	}
+
+	// The token is gone now: finish the re-create and grants even if the restore is
+	// interrupted, and retry once so a transient failure does not lose the token.
+	ctx = context.WithoutCancel(ctx)
+	secret, err := createAPIToken(ctx, t)
+	if err != nil {
+		logger.Warning("Access control: re-create %s failed, retrying: %v", t.AuthID, err)
+		var retryErr error
+		if secret, retryErr = createAPIToken(ctx, t); retryErr != nil {
+			return "", fmt.Errorf("%w (comment %q, expire %q, privsep %q, ACLs %s): %v",
+				errAPITokenNotRecreated, t.Comment, t.Expire, t.PrivSep, formatTokenACLs(acls), retryErr)
+		}
+	}
+
+	for _, acl := range acls {
+		var err error
+		if t.System == "pbs" {
+			_, err = restoreCmd.Run(ctx, "proxmox-backup-manager", "acl", "update", acl.Path, acl.Role, "--auth-id", t.AuthID, "--propagate", fmt.Sprintf("%t", acl.Propagate))
+		} else {
+			propagate := "0"
+			if acl.Propagate {
+				propagate = "1"
+			}
+			_, err = restoreCmd.Run(ctx, "pveum", "acl", "modify", acl.Path, "--tokens", t.AuthID, "--roles", acl.Role, "--propagate", propagate)
+		}
+		if err != nil {
+			// The secret already changed: report it, and surface the missing grant.
+			logger.Warning("Access control: token %s: re-grant %s on %s failed: %v", t.AuthID, acl.Role, acl.Path, err)
+		}
+	}
+	return secret, nil
+}
+
+// createAPIToken creates a token with its archived comment, expiry and privsep and
+// returns the new secret.
+func createAPIToken(ctx context.Context, t restoredAPIToken) (string, error) {
+	if t.System == "pbs" {
+		args := []string{"user", "generate-token", t.UserID, t.TokenID}
+		args = appendNonEmptyFlag(args, "--comment", t.Comment)
+		args = appendNonEmptyFlag(args, "--expire", t.Expire)
+		out, err := restoreCmd.Run(ctx, "proxmox-backup-manager", append(args, "--output-format", "json")...)
+		if err != nil {
+			return "", fmt.Errorf("generate token: %w", err)
+		}
+		return parseTokenSecret(out)
+	}
+	args := []string{"create", fmt.Sprintf("/access/users/%s/token/%s", t.UserID, t.TokenID)}
+	args = appendNonEmptyFlag(args, "--privsep", t.PrivSep)
+	args = appendNonEmptyFlag(args, "--comment", t.Comment)
+	args = appendNonEmptyFlag(args, "--expire", t.Expire)
+	out, err := restoreCmd.Run(ctx, "pvesh", append(args, "--output-format", "json")...)
+	if err != nil {
+		return "", fmt.Errorf("create token: %w", err)
+	}
+	return parseTokenSecret(out)
+}
+
+func formatTokenACLs(acls []tokenACL) string {
+	if len(acls) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(acls))
+	for _, acl := range acls {
+		parts = append(parts, acl.Path+":"+acl.Role)
+	}
+	return strings.Join(parts, ",")
+}
+
+func appendNonEmptyFlag(args []string, flag, value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return args
+	}
+	return append(args, flag, strings.TrimSpace(value))
+}
+
+func parseTokenSecret(out []byte) (string, error) {
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(unwrapPBSJSONData(out), &resp); err != nil {
+		return "", fmt.Errorf("parse token secret: %w", err)
+	}
+	if strings.TrimSpace(resp.Value) == "" {
+		return "", fmt.Errorf("parse token secret: empty value")
+	}
+	return strings.TrimSpace(resp.Value), nil
+}
+
+func listTokenACLs(ctx context.Context, t restoredAPIToken) ([]tokenACL, error) {
+	var out []byte
+	var err error
+	if t.System == "pbs" {
+		out, err = restoreCmd.Run(ctx, "proxmox-backup-manager", "acl", "list", "--output-format", "json")
+	} else {
+		out, err = restoreCmd.Run(ctx, "pvesh", "get", "/access/acl", "--output-format", "json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list ACLs: %w", err)
+	}
+	var entries []struct {
+		Path      string          `json:"path"`
+		RoleID    string          `json:"roleid"`
+		UGID      string          `json:"ugid"`
+		Propagate json.RawMessage `json:"propagate"`
+	}
+	if err := json.Unmarshal(unwrapPBSJSONData(out), &entries); err != nil {
+		return nil, fmt.Errorf("parse ACL list: %w", err)
+	}
+	var acls []tokenACL
+	for _, e := range entries {
+		if strings.TrimSpace(e.UGID) != t.AuthID {
+			continue
+		}
+		propagate := strings.Trim(strings.TrimSpace(string(e.Propagate)), `"`)
+		acls = append(acls, tokenACL{Path: e.Path, Role: e.RoleID, Propagate: propagate == "1" || propagate == "true"})
+	}
+	return acls, nil
+}
+
+func sealAccessTokenReport(path string, recipients []string, body []byte) error {
+	parsed, err := parseRecipientStrings(recipients)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, parsed...)
+	if err != nil {
+		return fmt.Errorf("initialize age encryption: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o600)
+}
+
+// accessControlReportRecipients returns the AGE recipients configured for backups
+// (AGE_RECIPIENT plus the recipient file), used to seal regenerated secrets.
+func accessControlReportRecipients(cfg *config.Config) ([]string, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	recipients := append([]string{}, cfg.AgeRecipients...)
+	path := strings.TrimSpace(cfg.AgeRecipientFile)
+	if path == "" && cfg.BaseDir != "" {
+		path = filepath.Join(cfg.BaseDir, "identity", "age", "recipient.txt")
+	}
+	if path != "" {
+		fileRecipients, err := readRecipientFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("read AGE recipients from %s: %w", path, err)
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	return dedupeRecipientStrings(recipients), nil
+}
+
+func collectRestoredRealms(system, stageRoot string) ([]restoredRealm, error) {
+	_, _, domainsRel, domainsLive, _, _ := accessControlStagePaths(system)
+	raw, present, err := readStageFileOptional(stageRoot, domainsRel)
+	if err != nil || !present {
+		return nil, err
+	}
+	staged, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse staged %s: %w", domainsRel, err)
+	}
+	live, _ := readProxmoxConfigSectionsOptional(domainsLive)
+
+	var realms []restoredRealm
+	for _, s := range staged {
+		typ := strings.ToLower(strings.TrimSpace(s.Type))
+		if typ != "ldap" && typ != "ad" && typ != "openid" {
+			continue
+		}
+		if findSection(live, s.Type, s.Name) == nil {
+			continue
+		}
+		realms = append(realms, restoredRealm{System: system, Name: strings.TrimSpace(s.Name), Type: typ})
+	}
+	return realms, nil
+}
+
+func maybeSyncRestoredRealms(ctx context.Context, ui accessControlReviewUI, logger *logging.Logger, realms []restoredRealm) error {
+	var syncable []restoredRealm
+	for _, r := range realms {
+		if r.Type == "openid" {
+			logger.Info("Access control: OpenID realm %s (%s) has no directory sync; users are created on first login when autocreate is enabled", r.Name, strings.ToUpper(r.System))
+			continue
+		}
+		syncable = append(syncable, r)
+	}
+	if len(syncable) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(syncable))
+	for _, r := range syncable {
+		lines = append(lines, fmt.Sprintf("  %s %s (%s)", strings.ToUpper(r.System), r.Name, strings.ToUpper(r.Type)))
+	}
+	message := fmt.Sprintf("Directory realms restored from the backup:\n\n%s\n\n"+
+		"A sync imports users and groups from the directory using each realm's sync defaults "+
+		"(which may remove vanished users). The directory servers must be reachable from this host.\n\n"+
+		"Sync these realms now?", strings.Join(lines, "\n"))
+	syncNow, err := ui.ConfirmAction(ctx, "Sync LDAP/AD realms", message, "Sync now", "Skip", 90*time.Second, false)
+	if err != nil || !syncNow {
+		return err
+	}
+
+	var failed []string
+	for _, r := range syncable {
+		var err error
+		if r.System == "pbs" {
+			_, err = restoreCmd.Run(ctx, "proxmox-backup-manager", r.Type, "sync", r.Name)
+		} else {
+			_, err = restoreCmd.Run(ctx, "pveum", "realm", "sync", r.Name)
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			logger.Warning("Access control: realm sync %s (%s) failed: %v", r.Name, strings.ToUpper(r.System), err)
+			failed = append(failed, r.Name)
+			continue
+		}
+		logger.Info("Access control: realm %s (%s) synced", r.Name, strings.ToUpper(r.System))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("realm sync failed for: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// findDroppedTFAUsers returns the live users that had TFA entries in the backup but
+// have none on the system after the apply (for example root@pam, whose TFA is kept
+// from the fresh install).
+func findDroppedTFAUsers(system, stageRoot string) ([]string, error) {
+	userRel, userLive, _, _, tfaRel, tfaLive := accessControlStagePaths(system)
+	if _, present, err := readStageFileOptional(stageRoot, userRel); err != nil || !present {
+		return nil, err
+	}
+	raw, present, err := readStageFileOptional(stageRoot, tfaRel)
+	if err != nil || !present {
+		return nil, err
+	}
+	staged, err := tfaUsersFromContent(system, raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse staged %s: %w", tfaRel, err)
+	}
+	liveRaw, err := restoreFS.ReadFile(tfaLive)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", tfaLive, err)
+	}
+	live, _ := tfaUsersFromContent(system, string(liveRaw))
+	liveUsers, _ := readProxmoxConfigSectionsOptional(userLive)
+
+	var dropped []string
+	for userID := range staged {
+		if live[userID] {
+			continue
+		}
+		if findSection(liveUsers, "user", userID) == nil && !isRootPBSUserID(userID) {
+			continue
+		}
+		dropped = append(dropped, userID)
+	}
+	sort.Strings(dropped)
+	return dropped, nil
+}
+
+func tfaUsersFromContent(system, raw string) (map[string]bool, error) {
+	users := make(map[string]bool)
+	if strings.TrimSpace(raw) == "" {
+		return users, nil
+	}
+	if system == "pbs" {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			return nil, err
+		}
+		for userID, payload := range parseTFAUsersMap(obj) {
+			if jsonRawNonNull(payload) {
+				users[userID] = true
+			}
+		}
+		return users, nil
+	}
+	sections, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sections {
+		if userID := strings.TrimSpace(tfaSectionUserID(s)); userID != "" {
+			users[userID] = true
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,304 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+)
+
+type fakeAccessControlReviewUI struct {
+	fakeStorageValidationUI
+	regenerate []string
+}
+
+func (u *fakeAccessControlReviewUI) SelectAccessTokensToRegenerate(_ context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error) {
+	var selected []restoredAPIToken
+	for _, t := range tokens {
+		for _, id := range u.regenerate {
+			if t.AuthID == id {
+				selected = append(selected, t)
+			}
+		}
+	}
+	return selected, nil
+}
+
+func setupAccessControlReviewTest(t *testing.T, files map[string]string, outputs map[string][]byte) (*FakeFS, *fakeCommandRunner) {
+	t.Helper()
+	origFS, origCmd, origReal, origEUID, origNow := restoreFS, restoreCmd, accessControlIsRealRestoreFS, accessControlApplyGeteuid, restoreTime
+	t.Cleanup(func() {
+		restoreFS, restoreCmd, accessControlIsRealRestoreFS, accessControlApplyGeteuid, restoreTime = origFS, origCmd, origReal, origEUID, origNow
+	})
+	fakeFS := NewFakeFS()
+	t.Cleanup(func() { _ = os.RemoveAll(fakeFS.Root) })
+	restoreFS = fakeFS
+	runner := &fakeCommandRunner{outputs: outputs}
+	restoreCmd = runner
+	accessControlIsRealRestoreFS = func(FS) bool { return true }
+	accessControlApplyGeteuid = func() int { return 0 }
+	restoreTime = &FakeTime{Current: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}
+	for path, content := range files {
+		if err := fakeFS.AddFile(path, []byte(content)); err != nil {
+			t.Fatalf("add %s: %v", path, err)
+		}
+	}
+	return fakeFS, runner
+}
+
+const reviewPVEUserCfg = "user: alice@pve\n    enable 1\n\ntoken: alice@pve!ci\n    comment CI runner\n    privsep 1\n\ntoken: root@pam!old\n    privsep 0\n\ntoken: bob@pve!gone\n    privsep 1\n"
+
+func TestReviewAccessControlRegeneratesSelectedTokenSecrets(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	fakeFS, runner := setupAccessControlReviewTest(t, map[string]string{
+		"/stage/etc/pve/user.cfg":       reviewPVEUserCfg,
+		"/stage/etc/pve/priv/token.cfg": "token: alice@pve!ci\n    value 1111\n",
+		"/etc/pve/user.cfg":             "user: alice@pve\n    enable 1\n\ntoken: alice@pve!ci\n    comment CI runner\n    privsep 1\n",
+	}, map[string][]byte{
+		"pvesh get /access/acl --output-format json":                                                         []byte(`[{"path":"/vms","roleid":"PVEVMUser","type":"token","ugid":"alice@pve!ci","propagate":1},{"path":"/","roleid":"PVEAuditor","type":"user","ugid":"alice@pve","propagate":1}]`),
+		"pvesh create /access/users/alice@pve/token/ci --privsep 1 --comment CI runner --output-format json": []byte(`{"full-tokenid":"alice@pve!ci","value":"new-secret"}`),
+	})
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}, regenerate: []string{"alice@pve!ci"}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", []string{identity.Recipient().String()}, false); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	want := []string{
+		"pvesh get /access/acl --output-format json",
+		"pvesh delete /access/users/alice@pve/token/ci",
+		"pvesh create /access/users/alice@pve/token/ci --privsep 1 --comment CI runner --output-format json",
+		"pveum acl modify /vms --tokens alice@pve!ci --roles PVEVMUser --propagate 1",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v\nwant %v", runner.calls, want)
+	}
+
+	sealed, err := fakeFS.ReadFile("/var/lib/proxsave/access-control/token-secrets-20260301-100000.age")
+	if err != nil {
+		t.Fatalf("read sealed report: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("new-secret")) {
+		t.Fatalf("report must not contain the secret in clear text")
+	}
+	r, err := age.Decrypt(bytes.NewReader(sealed), identity)
+	if err != nil {
+		t.Fatalf("decrypt report: %v", err)
+	}
+	plain, _ := io.ReadAll(r)
+	if !strings.Contains(string(plain), "pve\talice@pve!ci\tnew-secret\n") {
+		t.Fatalf("unexpected report:\n%s", plain)
+	}
+}
+
+const reviewTwoTokensUserCfg = "user: alice@pve\n    enable 1\n\ntoken: alice@pve!ci\n    comment CI runner\n    privsep 1\n\n" +
+	"user: carol@pve\n    enable 1\n\ntoken: carol@pve!bot\n    privsep 0\n"
+
+func setupTwoTokenReview(t *testing.T, errs map[string]error) (*FakeFS, *fakeCommandRunner, *age.X25519Identity) {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	fakeFS, runner := setupAccessControlReviewTest(t, map[string]string{
+		"/stage/etc/pve/user.cfg": reviewTwoTokensUserCfg,
+		"/etc/pve/user.cfg":       reviewTwoTokensUserCfg,
+	}, map[string][]byte{
+		"pvesh get /access/acl --output-format json":                                                         []byte(`[{"path":"/vms","roleid":"PVEVMUser","ugid":"alice@pve!ci","propagate":1}]`),
+		"pvesh create /access/users/alice@pve/token/ci --privsep 1 --comment CI runner --output-format json": []byte(`{"value":"alice-secret"}`),
+		"pvesh create /access/users/carol@pve/token/bot --privsep 0 --output-format json":                    []byte(`{"value":"carol-secret"}`),
+	})
+	runner.errs = errs
+	return fakeFS, runner, identity
+}
+
+func readSealedTokenReport(t *testing.T, fakeFS *FakeFS, identity *age.X25519Identity) string {
+	t.Helper()
+	sealed, err := fakeFS.ReadFile("/var/lib/proxsave/access-control/token-secrets-20260301-100000.age")
+	if err != nil {
+		t.Fatalf("read sealed report: %v", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(sealed), identity)
+	if err != nil {
+		t.Fatalf("decrypt report: %v", err)
+	}
+	plain, _ := io.ReadAll(r)
+	return string(plain)
+}
+
+func TestReviewAccessControlRecordsTokenNotRecreatedAfterDelete(t *testing.T) {
+	createAlice := "pvesh create /access/users/alice@pve/token/ci --privsep 1 --comment CI runner --output-format json"
+	fakeFS, runner, identity := setupTwoTokenReview(t, map[string]error{createAlice: errors.New("api unavailable")})
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}, regenerate: []string{"alice@pve!ci", "carol@pve!bot"}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", []string{identity.Recipient().String()}, false); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	creates := 0
+	for _, call := range runner.calls {
+		if call == createAlice {
+			creates++
+		}
+		if strings.HasPrefix(call, "pveum acl modify /vms --tokens alice@pve!ci") {
+			t.Fatalf("ACLs must not be granted to a token that was not re-created: %v", runner.calls)
+		}
+	}
+	if creates != 2 {
+		t.Fatalf("expected the deleted token to be re-created with a retry, calls=%v", runner.calls)
+	}
+
+	report := readSealedTokenReport(t, fakeFS, identity)
+	if !strings.Contains(report, "# FAILED\tpve\talice@pve!ci\ttoken deleted and not re-created") ||
+		!strings.Contains(report, `comment "CI runner"`) || !strings.Contains(report, "/vms:PVEVMUser") {
+		t.Fatalf("report must record the lost token and its settings:\n%s", report)
+	}
+	if !strings.Contains(report, "pve\tcarol@pve!bot\tcarol-secret\n") {
+		t.Fatalf("report must still contain the regenerated secret:\n%s", report)
+	}
+	if len(ui.messages) != 1 || !strings.Contains(ui.messages[0], "Deleted but not re-created (create them again manually): alice@pve!ci") {
+		t.Fatalf("messages=%v", ui.messages)
+	}
+}
+
+// cancelingCommandRunner cancels the restore context once a given command ran.
+type cancelingCommandRunner struct {
+	*fakeCommandRunner
+	after  string
+	cancel context.CancelFunc
+}
+
+func (r *cancelingCommandRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := r.fakeCommandRunner.Run(ctx, name, args...)
+	if strings.Join(append([]string{name}, args...), " ") == r.after {
+		r.cancel()
+	}
+	return out, err
+}
+
+func TestReviewAccessControlSealsPartialReportOnCancel(t *testing.T) {
+	fakeFS, runner, identity := setupTwoTokenReview(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restoreCmd = &cancelingCommandRunner{
+		fakeCommandRunner: runner,
+		after:             "pvesh create /access/users/alice@pve/token/ci --privsep 1 --comment CI runner --output-format json",
+		cancel:            cancel,
+	}
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}, regenerate: []string{"alice@pve!ci", "carol@pve!bot"}}
+
+	err := maybeReviewAccessControlWithUI(ctx, ui, newTestLogger(), plan, "/stage", []string{identity.Recipient().String()}, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	for _, call := range runner.calls {
+		if strings.Contains(call, "carol@pve") {
+			t.Fatalf("no token must be touched after cancellation: %v", runner.calls)
+		}
+	}
+	if want := "pveum acl modify /vms --tokens alice@pve!ci --roles PVEVMUser --propagate 1"; runner.calls[len(runner.calls)-1] != want {
+		t.Fatalf("grants of the re-created token must complete, calls=%v", runner.calls)
+	}
+	if report := readSealedTokenReport(t, fakeFS, identity); !strings.Contains(report, "pve\talice@pve!ci\talice-secret\n") {
+		t.Fatalf("partial report must hold the regenerated secret:\n%s", report)
+	}
+}
+
+func TestReviewAccessControlKeepsRootOnlyReportWhenSealingFails(t *testing.T) {
+	fakeFS, _, identity := setupTwoTokenReview(t, nil)
+	// A directory in place of the sealed report makes the sealed write fail.
+	if err := os.MkdirAll(filepath.Join(fakeFS.Root, "/var/lib/proxsave/access-control/token-secrets-20260301-100000.age"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}, regenerate: []string{"carol@pve!bot"}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", []string{identity.Recipient().String()}, false); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	fallback := "/var/lib/proxsave/access-control/token-secrets-20260301-100000.txt"
+	info, err := fakeFS.Stat(fallback)
+	if err != nil {
+		t.Fatalf("stat fallback report: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("fallback report mode = %#o, want 0600", info.Mode().Perm())
+	}
+	data, _ := fakeFS.ReadFile(fallback)
+	if !strings.Contains(string(data), "pve\tcarol@pve!bot\tcarol-secret\n") {
+		t.Fatalf("fallback report:\n%s", data)
+	}
+	if len(ui.messages) != 1 || !strings.Contains(ui.messages[0], fallback) {
+		t.Fatalf("the user must be told the fallback path, messages=%v", ui.messages)
+	}
+}
+
+func TestReviewAccessControlRefusesRegenerationWithoutRecipients(t *testing.T) {
+	_, runner := setupAccessControlReviewTest(t, map[string]string{
+		"/stage/etc/pve/user.cfg": reviewPVEUserCfg,
+		"/etc/pve/user.cfg":       reviewPVEUserCfg,
+	}, nil)
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}, regenerate: []string{"alice@pve!ci", "bob@pve!gone"}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", nil, false); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("no command expected, got %v", runner.calls)
+	}
+	if len(ui.messages) != 1 || !strings.HasPrefix(ui.messages[0], "Token secrets not regenerated") {
+		t.Fatalf("messages=%v", ui.messages)
+	}
+}
+
+func TestReviewAccessControlSyncsRealmsAndFlagsDroppedTFA(t *testing.T) {
+	_, runner := setupAccessControlReviewTest(t, map[string]string{
+		"/stage/etc/proxmox-backup/user.cfg":    "user: alice@corp\n    enable 1\n\nuser: carol@pbs\n    enable 1\n",
+		"/stage/etc/proxmox-backup/domains.cfg": "ldap: corp\n    server1 ldap.example\n\nopenid: sso\n    issuer-url https://sso.example\n",
+		"/stage/etc/proxmox-backup/tfa.json":    `{"users":{"alice@corp":{"totp":[{"id":"1"}]},"carol@pbs":{"totp":[{"id":"2"}]},"root@pam":{"webauthn":[{"id":"3"}]},"dave@pbs":{"totp":[{"id":"4"}]}}}`,
+		"/etc/proxmox-backup/user.cfg":          "user: alice@corp\n    enable 1\n\nuser: carol@pbs\n    enable 1\n\nuser: root@pam\n    enable 1\n",
+		"/etc/proxmox-backup/domains.cfg":       "ldap: corp\n    server1 ldap.example\n\nopenid: sso\n    issuer-url https://sso.example\n",
+		"/etc/proxmox-backup/tfa.json":          `{"users":{"alice@corp":{"totp":[{"id":"1"}]}}}`,
+	}, nil)
+	plan := &RestorePlan{SystemType: SystemTypePBS, StagedCategories: []Category{{ID: "pbs_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", nil, false); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	if want := []string{"proxmox-backup-manager ldap sync corp"}; !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v want %v", runner.calls, want)
+	}
+	if len(ui.messages) != 1 || !strings.Contains(ui.messages[0], "carol@pbs\nroot@pam\n") || strings.Contains(ui.messages[0], "dave@pbs") {
+		t.Fatalf("messages=%v", ui.messages)
+	}
+}
+
+func TestReviewAccessControlSkipsDryRun(t *testing.T) {
+	_, runner := setupAccessControlReviewTest(t, map[string]string{
+		"/stage/etc/pve/user.cfg": reviewPVEUserCfg,
+		"/etc/pve/user.cfg":       reviewPVEUserCfg,
+	}, nil)
+	plan := &RestorePlan{SystemType: SystemTypePVE, StagedCategories: []Category{{ID: "pve_access_control"}}}
+	ui := &fakeAccessControlReviewUI{fakeStorageValidationUI: fakeStorageValidationUI{confirm: true}}
+
+	if err := maybeReviewAccessControlWithUI(context.Background(), ui, newTestLogger(), plan, "/stage", nil, true); err != nil {
+		t.Fatalf("maybeReviewAccessControlWithUI: %v", err)
+	}
+	if len(runner.calls) != 0 || len(ui.asked) != 0 {
+		t.Fatalf("dry run must not prompt or run commands: calls=%v asked=%v", runner.calls, ui.asked)
+	}
+}
@@ -0,0 +1,39 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/input"
+	"github.com/tis24dev/proxsave/internal/ui/components"
+)
+
+func (u *cliWorkflowUI) SelectAccessTokensToRegenerate(ctx context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error) {
+	fmt.Fprintln(u.w(), "\nRestored API tokens (a regenerated token gets a new secret; clients using the old one stop working):")
+	for idx, t := range tokens {
+		fmt.Fprintf(u.w(), "  [%d] %s\n", idx+1, components.SanitizeLine(t.Summary()))
+	}
+
+	for {
+		fmt.Fprint(u.w(), "Tokens to regenerate (e.g. 1,3 or 2-4; 'all'; Enter for none): ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.EqualFold(trimmed, "none") {
+			return nil, nil
+		}
+		indexes, err := parseMenuSelection(trimmed, len(tokens))
+		if err != nil {
+			fmt.Fprintln(u.w(), err)
+			continue
+		}
+		selected := make([]restoredAPIToken, 0, len(indexes))
+		for _, idx := range indexes {
+			selected = append(selected, tokens[idx])
+		}
+		return selected, nil
+	}
+}
@@ -389,6 +389,13 @@ func parseColonConfigLine(line string) (key, value string, ok bool) {
 }
 
 func applyStorageCfg(ctx context.Context, cfgPath string, logger *logging.Logger) (applied, failed int, err error) {
+	return applyStorageCfgWithDecisions(ctx, cfgPath, logger, nil)
+}
+
+// applyStorageCfgWithDecisions applies storage.cfg, leaving out the storages the
+// validation pass marked as skipped and creating the ones marked as disabled with
+// --disable=1.
+func applyStorageCfgWithDecisions(ctx context.Context, cfgPath string, logger *logging.Logger, decisions *StorageDecisions) (applied, failed int, err error) {
 	blocks, perr := parseStorageBlocks(cfgPath)
 	if perr != nil {
 		return 0, 0, perr
@@ -405,6 +412,14 @@ func applyStorageCfg(ctx context.Context, cfgPath string, logger *logging.Logger
 			failed++
 			continue
 		}
+		if decisions.skipPVEStorage(blk.ID) {
+			logger.Info("Skipping storage %s: not ready on this host", blk.ID)
+			continue
+		}
+		if decisions.disablePVEStorage(blk.ID) {
+			createArgs = withStorageDisabled(createArgs)
+			logger.Info("Creating storage %s disabled: not ready on this host", blk.ID)
+		}
 		args := append([]string{"create", "/storage"}, createArgs...)
 
 		if runErr := runPvesh(ctx, logger, args); runErr != nil {
@@ -423,6 +438,18 @@ func applyStorageCfg(ctx context.Context, cfgPath string, logger *logging.Logger
 	return applied, failed, nil
 }
 
+// withStorageDisabled replaces any --disable flag with --disable=1.
+func withStorageDisabled(args []string) []string {
+	out := make([]string, 0, len(args)+1)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--disable=") {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return append(out, "--disable=1")
+}
+
 func parseStorageBlocks(cfgPath string) ([]storageBlock, error) {
 	data, err := restoreFS.ReadFile(cfgPath)
 	if err != nil {
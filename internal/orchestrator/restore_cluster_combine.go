@@ -0,0 +1,307 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// clusterWidePayloadCategory selects the cluster-wide PVE payload of a full-scope archive.
+func clusterWidePayloadCategory() Category {
+	var paths []string
+	for _, p := range backup.ClusterWideArchivePaths() {
+		paths = append(paths, "./"+p)
+	}
+	return Category{
+		ID:    "pve_cluster_wide_payload",
+		Name:  "PVE cluster-wide payload",
+		Type:  CategoryTypePVE,
+		Paths: paths,
+	}
+}
+
+// combineWithClusterArchive pairs a node-scope archive of a coordinated cluster backup
+// (CLUSTER_BACKUP_MODE=coordinated) with the full-scope archive of the node that captured
+// the cluster-wide payload for the same slot. The rest of the restore (category analysis,
+// SAFE apply, RECOVERY) then works on one combined archive. Without a cluster archive the
+// restore continues with the node-local files only.
+func (w *restoreUIWorkflowRun) combineWithClusterArchive() error {
+	manifest := &w.prepared.Manifest
+	if manifest.ClusterScope != backup.ClusterScopeNode {
+		return nil
+	}
+	w.logger.Info("")
+	w.logger.Info("This archive holds the node-local PVE files of %s; the cluster-wide configuration of slot %s was captured by %s",
+		manifest.Hostname, manifest.ClusterSlot, manifest.ClusterCaptureNode)
+
+	companion, err := w.selectClusterCompanion(manifest)
+	if err != nil {
+		return err
+	}
+	if companion == nil {
+		w.logger.Warning("Continuing with node-local files only: storage.cfg, datacenter.cfg, access control and the cluster database are not part of this archive")
+		return nil
+	}
+
+	prepared, err := preparePlainBundleWithUI(w.ctx, companion, w.version, w.logger, w.ui, fsIoTimeoutFromConfig(w.cfg))
+	if err != nil {
+		if restoreAbortOrInput(err) {
+			return err
+		}
+		w.logger.Warning("Cluster archive not usable (%v); continuing with node-local files only", err)
+		return nil
+	}
+	defer prepared.Cleanup()
+	if err := enforceManifestSignaturePolicy(w.cfg, w.logger, prepared); err != nil {
+		w.logger.Warning("Cluster archive not usable (%v); continuing with node-local files only", err)
+		return nil
+	}
+
+	combined, added, err := combineClusterArchives(w.ctx, w.prepared.ArchivePath, prepared.ArchivePath, w.logger)
+	if err != nil {
+		if w.ctx.Err() != nil {
+			return w.ctx.Err()
+		}
+		w.logger.Warning("Could not combine with cluster archive %s (%v); continuing with node-local files only", filepath.Base(prepared.Manifest.ArchivePath), err)
+		return nil
+	}
+	w.prepared.ArchivePath = combined
+	w.logger.Info("Combined with cluster archive %s: %d cluster-wide entries added", filepath.Base(prepared.Manifest.ArchivePath), added)
+	return nil
+}
+
+// selectClusterCompanion looks for the cluster archive next to the selected backup and
+// falls back to a manual selection. It returns nil when the operator declines.
+func (w *restoreUIWorkflowRun) selectClusterCompanion(manifest *backup.Manifest) (*backupCandidate, error) {
+	if dir := localCandidateDir(w.candidate); dir != "" {
+		candidates, err := discoverBackupCandidates(w.logger, dir)
+		if err != nil {
+			w.logger.Debug("Cluster archive lookup in %s failed: %v", dir, err)
+		} else if companion := pickClusterCompanion(candidates, manifest); companion != nil {
+			message := fmt.Sprintf("Cluster archive of %s found:\n\n  %s (%s)\n\nCombine it with the node archive? Its /etc/pve, cluster database and cluster-level state fill in what the node archive leaves out; files of this node are taken from the node archive.",
+				manifest.ClusterCaptureNode,
+				filepath.Base(companion.Manifest.ArchivePath),
+				companion.Manifest.CreatedAt.Format("2006-01-02 15:04"))
+			ok, err := w.ui.ConfirmAction(w.ctx, "Combine with cluster archive", message, "Combine", "Node files only", 0, true)
+			if err != nil || !ok {
+				return nil, err
+			}
+			return companion, nil
+		}
+	}
+
+	message := fmt.Sprintf("No archive of %s for slot %s was found next to this backup.\n\nSelect the cluster archive manually?", manifest.ClusterCaptureNode, manifest.ClusterSlot)
+	ok, err := w.ui.ConfirmAction(w.ctx, "Cluster archive not found", message, "Select archive", "Node files only", 0, false)
+	if err != nil || !ok {
+		return nil, err
+	}
+	companion, err := selectBackupCandidateWithUI(w.ctx, w.ui, w.cfg, w.logger, "Cluster archive", false)
+	if err != nil {
+		return nil, err
+	}
+	if companion.Manifest == nil || companion.Manifest.ClusterScope == backup.ClusterScopeNode {
+		w.logger.Warning("The selected archive does not carry the cluster-wide configuration")
+		return nil, nil
+	}
+	return companion, nil
+}
+
+// localCandidateDir returns the directory of a local candidate ("" for rclone).
+func localCandidateDir(cand *backupCandidate) string {
+	if cand == nil || cand.IsRclone {
+		return ""
+	}
+	switch cand.Source {
+	case sourceBundle:
+		return filepath.Dir(cand.BundlePath)
+	case sourceRaw:
+		return filepath.Dir(cand.RawArchivePath)
+	}
+	return ""
+}
+
+// pickClusterCompanion returns the full-scope archive of the capture node for the slot of
+// manifest, or failing that its newest full-scope archive not newer than manifest.
+func pickClusterCompanion(candidates []*backupCandidate, manifest *backup.Manifest) *backupCandidate {
+	var best *backupCandidate
+	bestSameSlot := false
+	for _, cand := range candidates {
+		cm := cand.Manifest
+		if cm == nil || cm.ClusterScope == backup.ClusterScopeNode || !strings.EqualFold(strings.TrimSpace(cm.ClusterMode), "cluster") {
+			continue
+		}
+		if !strings.EqualFold(shortHost(cm.Hostname), shortHost(manifest.ClusterCaptureNode)) {
+			continue
+		}
+		sameSlot := manifest.ClusterSlot != "" && cm.ClusterSlot == manifest.ClusterSlot
+		if !sameSlot && cm.CreatedAt.After(manifest.CreatedAt) {
+			continue
+		}
+		if best == nil || (sameSlot && !bestSameSlot) || (sameSlot == bestSameSlot && cm.CreatedAt.After(best.Manifest.CreatedAt)) {
+			best, bestSameSlot = cand, sameSlot
+		}
+	}
+	return best
+}
+
+// combineClusterArchives writes a plain tar next to nodeArchive holding every entry of
+// nodeArchive plus the cluster-wide entries of clusterArchive that nodeArchive lacks.
+// The cluster payload is extracted first so deduplicated entries come back as files.
+func combineClusterArchives(ctx context.Context, nodeArchive, clusterArchive string, logger *logging.Logger) (string, int, error) {
+	workDir := filepath.Dir(nodeArchive)
+	tree, err := restoreFS.MkdirTemp(workDir, "cluster-payload-")
+	if err != nil {
+		return "", 0, fmt.Errorf("create cluster payload directory: %w", err)
+	}
+	defer func() { _ = restoreFS.RemoveAll(tree) }()
+
+	if err := extractArchiveNative(ctx, restoreArchiveOptions{
+		archivePath: clusterArchive,
+		destRoot:    tree,
+		logger:      logger,
+		categories:  []Category{clusterWidePayloadCategory()},
+		mode:        RestoreModeCustom,
+	}); err != nil {
+		return "", 0, fmt.Errorf("extract cluster payload: %w", err)
+	}
+
+	base := filepath.Base(nodeArchive)
+	if idx := strings.Index(base, ".tar"); idx > 0 {
+		base = base[:idx]
+	}
+	combined := filepath.Join(workDir, base+".combined.tar")
+	added, err := writeCombinedArchive(ctx, nodeArchive, tree, combined)
+	if err != nil {
+		_ = restoreFS.Remove(combined)
+		return "", 0, err
+	}
+	return combined, added, nil
+}
+
+func writeCombinedArchive(ctx context.Context, nodeArchive, tree, out string) (added int, err error) {
+	outFile, err := restoreFS.Create(out)
+	if err != nil {
+		return 0, fmt.Errorf("create combined archive: %w", err)
+	}
+	defer closeIntoErr(&err, outFile, "close combined archive")
+	tw := tar.NewWriter(outFile)
+	defer closeIntoErr(&err, tw, "finish combined archive")
+
+	seen, err := copyArchiveEntries(ctx, nodeArchive, tw)
+	if err != nil {
+		return 0, err
+	}
+	err = appendTreeEntries(ctx, tw, tree, "", seen, &added)
+	return added, err
+}
+
+// copyArchiveEntries copies every entry of archivePath into tw and returns their
+// normalized names.
+func copyArchiveEntries(ctx context.Context, archivePath string, tw *tar.Writer) (seen map[string]bool, err error) {
+	file, err := restoreFS.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer closeIntoErr(&err, file, "close archive")
+	reader, err := createDecompressionReader(ctx, file, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("create decompression reader: %w", err)
+	}
+	defer closeDecompressionReader(reader, &err, "close decompression reader")
+
+	seen = make(map[string]bool)
+	tr := tar.NewReader(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return seen, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		seen[normalizeCombinedEntryName(header.Name)] = true
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("write combined archive: %w", err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return nil, fmt.Errorf("write combined archive: %w", err)
+		}
+	}
+}
+
+// appendTreeEntries adds the files under root/rel that are not in seen, named like the
+// archiver does ("./" prefix, no trailing slash).
+func appendTreeEntries(ctx context.Context, tw *tar.Writer, root, rel string, seen map[string]bool, added *int) error {
+	entries, err := restoreFS.ReadDir(filepath.Join(root, rel))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		childRel := path.Join(filepath.ToSlash(rel), entry.Name())
+		full := filepath.Join(root, filepath.FromSlash(childRel))
+		info, err := restoreFS.Lstat(full)
+		if err != nil {
+			return err
+		}
+		if !seen[childRel] {
+			if err := appendTreeEntry(tw, full, childRel, info); err != nil {
+				return err
+			}
+			*added++
+		}
+		if info.IsDir() {
+			if err := appendTreeEntries(ctx, tw, root, childRel, seen, added); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func appendTreeEntry(tw *tar.Writer, full, rel string, info os.FileInfo) (err error) {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = restoreFS.Readlink(full); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = "./" + rel
+	header.Format = tar.FormatPAX
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("write combined archive: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := restoreFS.Open(full)
+	if err != nil {
+		return err
+	}
+	defer closeIntoErr(&err, file, "close "+rel)
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("write combined archive: %w", err)
+	}
+	return nil
+}
+
+func normalizeCombinedEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
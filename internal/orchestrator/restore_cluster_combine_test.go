@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func TestPickClusterCompanion(t *testing.T) {
+	slotTime := time.Date(2026, 3, 14, 2, 0, 0, 0, time.UTC)
+	node := &backup.Manifest{Hostname: "pve2", ClusterMode: "cluster", ClusterScope: backup.ClusterScopeNode, ClusterCaptureNode: "pve1", ClusterSlot: "20260314", CreatedAt: slotTime}
+	cand := func(host, scope, slot string, at time.Time) *backupCandidate {
+		return &backupCandidate{Manifest: &backup.Manifest{Hostname: host, ClusterMode: "cluster", ClusterScope: scope, ClusterSlot: slot, CreatedAt: at}}
+	}
+
+	previous := cand("pve1.example.com", backup.ClusterScopeFull, "20260313", slotTime.Add(-24*time.Hour))
+	sameSlot := cand("pve1", backup.ClusterScopeFull, "20260314", slotTime.Add(3*time.Minute))
+	candidates := []*backupCandidate{
+		cand("pve2", backup.ClusterScopeFull, "20260314", slotTime),
+		cand("pve1", backup.ClusterScopeNode, "20260314", slotTime),
+		previous,
+		sameSlot,
+		cand("pve1", backup.ClusterScopeFull, "20260315", slotTime.Add(24*time.Hour)),
+	}
+	if got := pickClusterCompanion(candidates, node); got != sameSlot {
+		t.Fatalf("pickClusterCompanion() = %+v, want the same-slot archive", got)
+	}
+	if got := pickClusterCompanion(candidates[:3], node); got != previous {
+		t.Fatalf("pickClusterCompanion() without same slot = %+v, want the previous archive", got)
+	}
+	if got := pickClusterCompanion(candidates[:2], node); got != nil {
+		t.Fatalf("pickClusterCompanion() = %+v, want nil", got)
+	}
+}
+
+func TestCombineClusterArchives(t *testing.T) {
+	dir := t.TempDir()
+	nodeArchive := filepath.Join(dir, "pve2-backup.tar")
+	clusterArchive := filepath.Join(dir, "pve1-backup.tar")
+	writeCombineTestTar(t, nodeArchive, map[string]string{
+		"./etc/hostname":                        "pve2\n",
+		"./etc/pve/nodes/pve2/lxc/101.conf":     "node archive\n",
+		"./var/lib/proxsave-info/commands/pve/": "",
+	})
+	writeCombineTestTar(t, clusterArchive, map[string]string{
+		"./etc/hostname":                                  "pve1\n",
+		"./etc/pve/storage.cfg":                           "dir: local\n",
+		"./etc/pve/nodes/pve2/lxc/101.conf":               "cluster archive\n",
+		"./var/lib/pve-cluster/config.db":                 "db",
+		"./var/lib/proxsave-info/commands/pve/pools.json": "[]",
+		"./var/lib/proxsave-info/commands/pve/node.json":  "{}",
+	})
+
+	combined, added, err := combineClusterArchives(context.Background(), nodeArchive, clusterArchive, logging.New(types.LogLevelError, false))
+	if err != nil {
+		t.Fatalf("combineClusterArchives() = %v", err)
+	}
+	if filepath.Dir(combined) != dir {
+		t.Fatalf("combined archive %s not written next to the node archive", combined)
+	}
+	got := readCombineTestTar(t, combined)
+	want := map[string]string{
+		"./etc/hostname":                                  "pve2\n",
+		"./etc/pve/nodes/pve2/lxc/101.conf":               "node archive\n",
+		"./etc/pve/storage.cfg":                           "dir: local\n",
+		"./var/lib/pve-cluster/config.db":                 "db",
+		"./var/lib/proxsave-info/commands/pve/pools.json": "[]",
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+	if _, ok := got["./var/lib/proxsave-info/commands/pve/node.json"]; ok {
+		t.Errorf("node-local command output of the cluster archive must not be added")
+	}
+	if added == 0 {
+		t.Fatalf("added = 0")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			t.Fatalf("temporary payload directory %s left behind", entry.Name())
+		}
+	}
+}
+
+func writeCombineTestTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg, ModTime: time.Now()}
+		if name[len(name)-1] == '/' {
+			hdr = &tar.Header{Name: name, Mode: 0o755, Typeflag: tar.TypeDir, ModTime: time.Now()}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readCombineTestTar(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	out := make(map[string]string)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		out[hdr.Name] = string(data)
+	}
+}
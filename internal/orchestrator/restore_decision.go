@@ -5,11 +5,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path"
 	"strings"
 
+	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/logging"
 )
 
@@ -45,7 +47,9 @@ type restoreArchiveInspection struct {
 const (
 	restoreDecisionMetadataPath     = "var/lib/proxsave-info/backup_metadata.txt"
 	restoreDecisionMetadataMaxBytes = 8 * 1024
-	restoreDecisionNulTypeFlag      = byte(0)
+	// restoreCustomBricksIndexMaxBytes bounds the custom brick index read.
+	restoreCustomBricksIndexMaxBytes = 256 * 1024
+	restoreDecisionNulTypeFlag       = byte(0)
 )
 
 // AnalyzeRestoreArchive inspects the archive once and derives trusted restore facts
@@ -100,18 +104,22 @@ func inspectRestoreArchiveContents(archivePath string, logger *logging.Logger) (
 	}()
 
 	tarReader := tar.NewReader(reader)
-	archivePaths, metadata, metadataErr, collectErr := collectRestoreArchiveFacts(tarReader)
+	facts, collectErr := collectRestoreArchiveFacts(tarReader)
 	if collectErr != nil {
 		return nil, fmt.Errorf("inspect archive: %w", collectErr)
 	}
-	if metadataErr != nil {
-		logger.Warning("Could not parse internal backup metadata: %v", metadataErr)
+	if facts.MetadataErr != nil {
+		logger.Warning("Could not parse internal backup metadata: %v", facts.MetadataErr)
+	}
+	if facts.CustomBricksErr != nil {
+		logger.Warning("Could not parse custom brick index: %v", facts.CustomBricksErr)
 	}
 
-	logger.Debug("Found %d entries in archive", len(archivePaths))
-	availableCategories := AnalyzeArchivePaths(archivePaths, GetAllCategories())
+	logger.Debug("Found %d entries in archive", len(facts.Paths))
+	allCategories := mergeCustomBrickCategories(GetAllCategories(), facts.CustomBricks)
+	availableCategories := AnalyzeArchivePaths(facts.Paths, allCategories)
 
-	decision := buildRestoreDecisionInfo(metadata, availableCategories, logger)
+	decision := buildRestoreDecisionInfo(facts.Metadata, availableCategories, logger)
 	inspection = &restoreArchiveInspection{
 		AvailableCategories: availableCategories,
 		Decision:            decision,
@@ -119,12 +127,17 @@ func inspectRestoreArchiveContents(archivePath string, logger *logging.Logger) (
 	return inspection, nil
 }
 
-func collectRestoreArchiveFacts(tarReader *tar.Reader) ([]string, *restoreDecisionMetadata, error, error) {
-	var (
-		archivePaths []string
-		metadata     *restoreDecisionMetadata
-		metadataErr  error
-	)
+// restoreArchiveFacts is what a single pass over the archive yields.
+type restoreArchiveFacts struct {
+	Paths           []string
+	Metadata        *restoreDecisionMetadata
+	MetadataErr     error
+	CustomBricks    *backup.CustomBrickIndex
+	CustomBricksErr error
+}
+
+func collectRestoreArchiveFacts(tarReader *tar.Reader) (restoreArchiveFacts, error) {
+	var facts restoreArchiveFacts
 
 	for {
 		header, err := tarReader.Next()
@@ -132,34 +145,58 @@ func collectRestoreArchiveFacts(tarReader *tar.Reader) ([]string, *restoreDecisi
 			break
 		}
 		if err != nil {
-			return nil, nil, nil, err
+			return restoreArchiveFacts{}, err
 		}
 
-		archivePaths = append(archivePaths, header.Name)
-		if metadata != nil || header.FileInfo().IsDir() {
-			continue
-		}
-		if !isRestoreDecisionMetadataEntry(header.Name) {
+		facts.Paths = append(facts.Paths, header.Name)
+		if header.FileInfo().IsDir() {
 			continue
 		}
 
-		data, readErr := readRestoreDecisionMetadata(tarReader, header)
-		if readErr != nil {
-			metadataErr = readErr
-			continue
-		}
-		parsed, parseErr := parseRestoreDecisionMetadata(data)
-		if parseErr != nil {
-			metadataErr = parseErr
-			continue
+		switch {
+		case isRestoreDecisionMetadataEntry(header.Name):
+			if facts.Metadata != nil {
+				continue
+			}
+			data, readErr := readRestoreDecisionMetadata(tarReader, header)
+			if readErr != nil {
+				facts.MetadataErr = readErr
+				continue
+			}
+			parsed, parseErr := parseRestoreDecisionMetadata(data)
+			if parseErr != nil {
+				facts.MetadataErr = parseErr
+				continue
+			}
+			facts.Metadata = parsed
+		case normalizeRestoreEntryPath(header.Name) == backup.CustomBricksIndexPath:
+			if facts.CustomBricks != nil {
+				continue
+			}
+			data, readErr := readRestoreArchiveEntry(tarReader, header, restoreCustomBricksIndexMaxBytes)
+			if readErr != nil {
+				facts.CustomBricksErr = readErr
+				continue
+			}
+			var index backup.CustomBrickIndex
+			if parseErr := json.Unmarshal(data, &index); parseErr != nil {
+				facts.CustomBricksErr = parseErr
+				continue
+			}
+			facts.CustomBricks = &index
 		}
-		metadata = parsed
 	}
 
-	return archivePaths, metadata, metadataErr, nil
+	return facts, nil
 }
 
 func readRestoreDecisionMetadata(tarReader *tar.Reader, header *tar.Header) ([]byte, error) {
+	return readRestoreArchiveEntry(tarReader, header, restoreDecisionMetadataMaxBytes)
+}
+
+// readRestoreArchiveEntry reads a small regular-file entry, rejecting entries
+// larger than maxBytes.
+func readRestoreArchiveEntry(tarReader *tar.Reader, header *tar.Header, maxBytes int64) ([]byte, error) {
 	if header == nil {
 		return nil, fmt.Errorf("restore metadata entry is missing a tar header")
 	}
@@ -167,12 +204,12 @@ func readRestoreDecisionMetadata(tarReader *tar.Reader, header *tar.Header) ([]b
 		return nil, fmt.Errorf("archive entry %s is not a regular file", header.Name)
 	}
 
-	limited := io.LimitReader(tarReader, restoreDecisionMetadataMaxBytes+1)
+	limited := io.LimitReader(tarReader, maxBytes+1)
 	data, err := io.ReadAll(limited)
 	if err != nil {
 		return nil, err
 	}
-	if int64(len(data)) > restoreDecisionMetadataMaxBytes {
+	if int64(len(data)) > maxBytes {
 		size := header.Size
 		if size <= 0 {
 			size = int64(len(data))
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -221,10 +222,11 @@ func TestCollectRestoreArchiveFacts_RejectsOversizedMetadata(t *testing.T) {
 	}
 	defer func() { _ = file.Close() }()
 
-	archivePaths, metadata, metadataErr, err := collectRestoreArchiveFacts(tar.NewReader(file))
+	facts, err := collectRestoreArchiveFacts(tar.NewReader(file))
 	if err != nil {
 		t.Fatalf("collectRestoreArchiveFacts() error: %v", err)
 	}
+	archivePaths, metadata, metadataErr := facts.Paths, facts.Metadata, facts.MetadataErr
 	if metadata != nil {
 		t.Fatalf("metadata = %#v; want nil for oversized entry", metadata)
 	}
@@ -300,7 +302,7 @@ func TestAnalyzeRestoreArchive_PropagatesDecompressionCloseError(t *testing.T) {
 		},
 	}
 
-	archivePath := filepath.Join(t.TempDir(), "backup.tar.zst")
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.bz2")
 	if err := os.WriteFile(archivePath, []byte("compressed payload"), 0o600); err != nil {
 		t.Fatalf("WriteFile: %v", err)
 	}
@@ -335,7 +337,7 @@ func TestInspectRestoreArchiveContents_PrefersInspectErrorOverCloseError(t *test
 		},
 	}
 
-	archivePath := filepath.Join(t.TempDir(), "backup.tar.zst")
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.bz2")
 	if err := os.WriteFile(archivePath, []byte("compressed payload"), 0o600); err != nil {
 		t.Fatalf("WriteFile: %v", err)
 	}
@@ -355,3 +357,39 @@ func TestInspectRestoreArchiveContents_PrefersInspectErrorOverCloseError(t *test
 		t.Fatalf("inspectRestoreArchiveContents() err = %v; want inspect archive context", err)
 	}
 }
+
+func TestInspectRestoreArchiveContents_MapsCustomBrickCategories(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "backup.tar")
+	index := `{"bricks":[
+		{"id":"nginx","category":"nginx","category_name":"Nginx","paths":["./etc/nginx/"]},
+		{"id":"extra-net","category":"network","paths":["./etc/frr/"]},
+		{"id":"nothing","category":"empty"}
+	]}`
+	if err := writeTarFile(archivePath, map[string]string{
+		"var/lib/proxsave-info/custom_bricks.json": index,
+		"etc/nginx/nginx.conf":                     "main\n",
+		"etc/frr/frr.conf":                         "frr\n",
+	}); err != nil {
+		t.Fatalf("writeTarFile: %v", err)
+	}
+
+	inspection, err := inspectRestoreArchiveContents(archivePath, newTestLogger())
+	if err != nil {
+		t.Fatalf("inspectRestoreArchiveContents: %v", err)
+	}
+
+	nginx := GetCategoryByID("nginx", inspection.AvailableCategories)
+	if nginx == nil || nginx.Name != "Nginx" || nginx.Type != CategoryTypeCommon || !nginx.IsAvailable {
+		t.Fatalf("nginx category = %+v", nginx)
+	}
+	network := GetCategoryByID("network", inspection.AvailableCategories)
+	if network == nil || !slices.Contains(network.Paths, "./etc/frr/") || !slices.Contains(network.Paths, "./etc/network/") {
+		t.Fatalf("network category = %+v", network)
+	}
+	if GetCategoryByID("empty", inspection.AvailableCategories) != nil {
+		t.Fatalf("brick without paths must not create a category")
+	}
+	if base := GetCategoryByID("network", GetAllCategories()); slices.Contains(base.Paths, "./etc/frr/") {
+		t.Fatalf("custom paths leaked into the static category list")
+	}
+}
@@ -2,7 +2,6 @@
 package orchestrator
 
 import (
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -10,7 +9,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/safeexec"
+	"github.com/tis24dev/proxsave/internal/types"
 )
 
 type restoreDecompressionFormat struct {
@@ -41,7 +42,7 @@ func restoreDecompressionFormats() []restoreDecompressionFormat {
 	return []restoreDecompressionFormat{
 		{
 			matches: func(path string) bool { return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") },
-			open:    func(_ context.Context, file *os.File) (io.ReadCloser, error) { return gzip.NewReader(file) },
+			open:    createGzipReader,
 		},
 		{matches: func(path string) bool { return strings.HasSuffix(path, ".tar.xz") }, open: createXZReader},
 		{
@@ -56,14 +57,20 @@ func restoreDecompressionFormats() []restoreDecompressionFormat {
 	}
 }
 
-// createXZReader creates an XZ decompression reader using injectable command runner
-func createXZReader(ctx context.Context, file *os.File) (io.ReadCloser, error) {
-	return runRestoreCommandStream(ctx, "xz", file, "-d", "-c")
+// createGzipReader creates a gzip decompression reader using the built-in decoder
+func createGzipReader(_ context.Context, file *os.File) (io.ReadCloser, error) {
+	return backup.NewNativeDecompressor(file, types.CompressionGzip)
 }
 
-// createZstdReader creates a Zstd decompression reader using injectable command runner
-func createZstdReader(ctx context.Context, file *os.File) (io.ReadCloser, error) {
-	return runRestoreCommandStream(ctx, "zstd", file, "-d", "-c")
+// createXZReader creates an XZ decompression reader using the built-in decoder,
+// so restoring needs no xz binary on the (possibly freshly installed) host
+func createXZReader(_ context.Context, file *os.File) (io.ReadCloser, error) {
+	return backup.NewNativeDecompressor(file, types.CompressionXZ)
+}
+
+// createZstdReader creates a Zstd decompression reader using the built-in decoder
+func createZstdReader(_ context.Context, file *os.File) (io.ReadCloser, error) {
+	return backup.NewNativeDecompressor(file, types.CompressionZstd)
 }
 
 // createBzip2Reader creates a Bzip2 decompression reader using injectable command runner
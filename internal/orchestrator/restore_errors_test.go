@@ -39,7 +39,7 @@ func TestRunRestoreCommandStream_UsesStreamingRunner(t *testing.T) {
 
 	fake := &FakeCommandRunner{
 		Outputs: map[string][]byte{
-			"xz -d -c": []byte("hello"),
+			"bzip2 -d -c": []byte("hello"),
 		},
 	}
 	restoreCmd = fake
@@ -51,9 +51,9 @@ func TestRunRestoreCommandStream_UsesStreamingRunner(t *testing.T) {
 	defer func() { _ = os.Remove(tmp.Name()) }()
 	defer func() { _ = tmp.Close() }()
 
-	reader, err := createXZReader(context.Background(), tmp)
+	reader, err := createBzip2Reader(context.Background(), tmp)
 	if err != nil {
-		t.Fatalf("createXZReader: %v", err)
+		t.Fatalf("createBzip2Reader: %v", err)
 	}
 	defer func() { _ = reader.Close() }()
 
@@ -64,7 +64,7 @@ func TestRunRestoreCommandStream_UsesStreamingRunner(t *testing.T) {
 	if string(buf) != "hello" {
 		t.Fatalf("unexpected output: %q", string(buf))
 	}
-	if len(fake.Calls) != 1 || fake.Calls[0] != "xz -d -c" {
+	if len(fake.Calls) != 1 || fake.Calls[0] != "bzip2 -d -c" {
 		t.Fatalf("unexpected calls: %#v", fake.Calls)
 	}
 }
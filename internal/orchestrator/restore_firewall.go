@@ -26,6 +26,7 @@ var (
 	firewallArmRollback    = armFirewallRollback
 	firewallDisarmRollback = disarmFirewallRollback
 	firewallApplyFromStage = applyPVEFirewallFromStage
+	firewallApplyMerge     = applyPVEFirewallMerge
 	firewallRestartService = restartPVEFirewallService
 )
 
@@ -182,6 +183,34 @@ func maybeApplyPVEFirewallWithUI(
 		return nil
 	}
 
+	applyFromStage := func() ([]string, error) { return firewallApplyFromStage(logger, stageRoot) }
+	mode, err := ui.SelectFirewallRestoreMode(ctx)
+	if err != nil {
+		return err
+	}
+	logging.DebugStep(logger, "pve firewall restore (ui)", "User choice: mode=%s", mode)
+	if mode == FirewallRestoreMerge {
+		// Review before the rollback timer is armed: reading the changes takes time.
+		mergePlan, err := planPVEFirewallMerge(logger, stageRoot)
+		if err != nil {
+			return fmt.Errorf("plan firewall merge: %w", err)
+		}
+		if len(mergePlan.changes) == 0 {
+			logger.Info("PVE firewall restore: live firewall configuration already matches the backup; nothing to merge")
+			return nil
+		}
+		accepted, err := ui.ReviewFirewallChanges(ctx, mergePlan.changes)
+		if err != nil {
+			return err
+		}
+		logger.Info("PVE firewall merge: %d of %d change(s) accepted", len(accepted), len(mergePlan.changes))
+		if len(accepted) == 0 {
+			logger.Info("Skipping PVE firewall apply (no change accepted).")
+			return nil
+		}
+		applyFromStage = func() ([]string, error) { return firewallApplyMerge(logger, mergePlan, accepted) }
+	}
+
 	if rollbackPath == "" && fullRollbackPath != "" {
 		ok, err := ui.ConfirmAction(
 			ctx,
@@ -233,7 +262,7 @@ func maybeApplyPVEFirewallWithUI(
 		logger.Info("Firewall rollback log: %s", rollbackHandle.logPath)
 	}
 
-	applied, err := firewallApplyFromStage(logger, stageRoot)
+	applied, err := applyFromStage()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,560 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// FirewallRestoreMode selects how a staged PVE firewall configuration is applied.
+type FirewallRestoreMode string
+
+const (
+	// FirewallRestoreReplace replaces the firewall files with the staged ones (1:1).
+	FirewallRestoreReplace FirewallRestoreMode = "replace"
+	// FirewallRestoreMerge reviews the staged changes item by item and writes only the accepted ones.
+	FirewallRestoreMerge FirewallRestoreMode = "merge"
+)
+
+type firewallChangeAction string
+
+const (
+	firewallChangeAdded   firewallChangeAction = "added"
+	firewallChangeRemoved firewallChangeAction = "removed"
+	firewallChangeChanged firewallChangeAction = "changed"
+)
+
+// firewallChange is one reviewable difference between a live and a staged .fw file:
+// an option, an alias, a rule, or a whole IP set / security group.
+type firewallChange struct {
+	id      int
+	File    string
+	Kind    string
+	Name    string
+	Action  firewallChangeAction
+	Old     []string
+	New     []string
+	Default bool
+}
+
+// Summary is the one-line description shown in the review list.
+func (c firewallChange) Summary() string {
+	return fmt.Sprintf("%s %s %s: %s", filepath.Base(c.File), c.Action, c.Kind, c.Name)
+}
+
+// Detail shows the lines removed (-) and added (+) by the change.
+func (c firewallChange) Detail() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", c.File)
+	for _, line := range c.Old {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	for _, line := range c.New {
+		fmt.Fprintf(&b, "+ %s\n", line)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// defaultFirewallChanges returns the changes accepted by default: additions and
+// changes, but not removals of live items that are missing from the backup.
+func defaultFirewallChanges(changes []firewallChange) []firewallChange {
+	var out []firewallChange
+	for _, c := range changes {
+		if c.Default {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// fwSection is one [SECTION] of a .fw file; lines hold its non-comment content.
+type fwSection struct {
+	header string
+	key    string
+	lines  []string
+}
+
+type fwFile struct {
+	preamble []string
+	sections []*fwSection
+}
+
+func (f *fwFile) section(key string) *fwSection {
+	if f == nil {
+		return nil
+	}
+	for _, s := range f.sections {
+		if s.key == key {
+			return s
+		}
+	}
+	return nil
+}
+
+// parseFirewallFile parses a PVE .fw file. Comment-only lines inside sections are
+// not kept (PVE does not preserve them either); inline "# comment" parts are.
+func parseFirewallFile(content string) *fwFile {
+	f := &fwFile{}
+	var cur *fwSection
+	for _, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if end := strings.Index(line, "]"); end > 0 {
+				cur = &fwSection{header: line, key: firewallSectionKey(line[1:end])}
+				f.sections = append(f.sections, cur)
+				continue
+			}
+		}
+		if strings.HasPrefix(line, "#") {
+			if cur == nil {
+				f.preamble = append(f.preamble, line)
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		cur.lines = append(cur.lines, strings.Join(strings.Fields(line), " "))
+	}
+	return f
+}
+
+func firewallSectionKey(inner string) string {
+	fields := strings.Fields(inner)
+	if len(fields) == 0 {
+		return ""
+	}
+	kind := strings.ToLower(fields[0])
+	if len(fields) > 1 && (kind == "ipset" || kind == "group") {
+		return kind + " " + fields[1]
+	}
+	return strings.ToLower(strings.Join(fields, " "))
+}
+
+func firewallSectionRank(key string) int {
+	switch {
+	case key == "options":
+		return 0
+	case key == "aliases":
+		return 1
+	case strings.HasPrefix(key, "ipset "):
+		return 2
+	case key == "rules":
+		return 3
+	case strings.HasPrefix(key, "group "):
+		return 4
+	default:
+		return 5
+	}
+}
+
+func renderFirewallFile(f *fwFile) string {
+	sections := append([]*fwSection(nil), f.sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		return firewallSectionRank(sections[i].key) < firewallSectionRank(sections[j].key)
+	})
+	var b strings.Builder
+	for _, line := range f.preamble {
+		b.WriteString(line + "\n")
+	}
+	for i, s := range sections {
+		if i > 0 || len(f.preamble) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(s.header + "\n\n")
+		for _, line := range s.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// firewallKeyedLine returns the identity of an OPTIONS ("key:") or ALIASES ("name ...") line.
+func firewallKeyedLine(sectionKey, line string) string {
+	if sectionKey == "options" {
+		key, _, _ := strings.Cut(line, ":")
+		return strings.ToLower(strings.TrimSpace(key))
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// firewallRuleOp is one step of the rules diff; change is the id of the change it belongs to (-1 = unchanged).
+type firewallRuleOp struct {
+	del, ins bool
+	line     string
+	change   int
+}
+
+// firewallFileMerge is the review state of one live/staged .fw file pair.
+type firewallFileMerge struct {
+	dest  string
+	mode  os.FileMode
+	live  *fwFile
+	stage *fwFile
+	// rules holds the diff of the [RULES] section, the only order-sensitive list.
+	rules []firewallRuleOp
+}
+
+// firewallMergePlan holds every reviewable change of the staged firewall configuration.
+type firewallMergePlan struct {
+	files   []*firewallFileMerge
+	changes []firewallChange
+}
+
+func (p *firewallMergePlan) add(c firewallChange) int {
+	c.id = len(p.changes)
+	c.Default = c.Action != firewallChangeRemoved
+	p.changes = append(p.changes, c)
+	return c.id
+}
+
+// planPVEFirewallMerge compares the staged cluster.fw, guest .fw and host.fw files
+// with the live ones. Live files without a staged counterpart are left alone.
+func planPVEFirewallMerge(logger *logging.Logger, stageRoot string) (*firewallMergePlan, error) {
+	type pair struct{ src, dest string }
+	var pairs []pair
+
+	stageFirewall := filepath.Join(stageRoot, "etc", "pve", "firewall")
+	entries, err := restoreFS.ReadDir(stageFirewall)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("readdir %s: %w", stageFirewall, err)
+	}
+	for _, e := range entries {
+		if e == nil || e.IsDir() || !strings.HasSuffix(e.Name(), ".fw") {
+			continue
+		}
+		pairs = append(pairs, pair{filepath.Join(stageFirewall, e.Name()), filepath.Join("/etc/pve/firewall", e.Name())})
+	}
+	srcHostFW, _, ok, err := selectStageHostFirewall(logger, stageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		currentNode, _ := firewallHostname()
+		currentNode = shortHost(currentNode)
+		if strings.TrimSpace(currentNode) == "" {
+			currentNode = "localhost"
+		}
+		pairs = append(pairs, pair{srcHostFW, filepath.Join("/etc/pve/nodes", currentNode, "host.fw")})
+	}
+
+	plan := &firewallMergePlan{}
+	for _, p := range pairs {
+		stageData, err := restoreFS.ReadFile(p.src)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", p.src, err)
+		}
+		fm := &firewallFileMerge{dest: p.dest, mode: 0o640, stage: parseFirewallFile(string(stageData))}
+		liveData, err := restoreFS.ReadFile(p.dest)
+		switch {
+		case err == nil:
+			fm.live = parseFirewallFile(string(liveData))
+			if info, statErr := restoreFS.Stat(p.dest); statErr == nil {
+				fm.mode = info.Mode().Perm()
+			}
+		case errors.Is(err, os.ErrNotExist):
+			fm.live = &fwFile{preamble: fm.stage.preamble}
+		default:
+			return nil, fmt.Errorf("read %s: %w", p.dest, err)
+		}
+		plan.diffFile(fm)
+		plan.files = append(plan.files, fm)
+	}
+	return plan, nil
+}
+
+func (p *firewallMergePlan) diffFile(fm *firewallFileMerge) {
+	keys := make([]string, 0, len(fm.stage.sections)+len(fm.live.sections))
+	seen := make(map[string]bool)
+	for _, f := range []*fwFile{fm.live, fm.stage} {
+		for _, s := range f.sections {
+			if !seen[s.key] {
+				seen[s.key] = true
+				keys = append(keys, s.key)
+			}
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return firewallSectionRank(keys[i]) < firewallSectionRank(keys[j]) })
+
+	for _, key := range keys {
+		live, stage := fm.live.section(key), fm.stage.section(key)
+		switch {
+		case key == "options" || key == "aliases":
+			p.diffKeyed(fm.dest, key, live, stage)
+		case key == "rules":
+			fm.rules = p.diffRules(fm.dest, live, stage)
+		default:
+			p.diffWhole(fm.dest, key, live, stage)
+		}
+	}
+}
+
+func (p *firewallMergePlan) diffKeyed(dest, key string, live, stage *fwSection) {
+	kind := "option"
+	if key == "aliases" {
+		kind = "alias"
+	}
+	index := func(s *fwSection) (map[string]string, []string) {
+		m := make(map[string]string)
+		var order []string
+		if s != nil {
+			for _, line := range s.lines {
+				if k := firewallKeyedLine(key, line); k != "" {
+					if _, dup := m[k]; !dup {
+						order = append(order, k)
+					}
+					m[k] = line
+				}
+			}
+		}
+		return m, order
+	}
+	liveMap, liveOrder := index(live)
+	stageMap, stageOrder := index(stage)
+	for _, k := range stageOrder {
+		old, exists := liveMap[k]
+		switch {
+		case !exists:
+			p.add(firewallChange{File: dest, Kind: kind, Name: k, Action: firewallChangeAdded, New: []string{stageMap[k]}})
+		case old != stageMap[k]:
+			p.add(firewallChange{File: dest, Kind: kind, Name: k, Action: firewallChangeChanged, Old: []string{old}, New: []string{stageMap[k]}})
+		}
+	}
+	for _, k := range liveOrder {
+		if _, ok := stageMap[k]; !ok {
+			p.add(firewallChange{File: dest, Kind: kind, Name: k, Action: firewallChangeRemoved, Old: []string{liveMap[k]}})
+		}
+	}
+}
+
+// diffWhole compares IP sets, security groups and unknown sections as a unit.
+func (p *firewallMergePlan) diffWhole(dest, key string, live, stage *fwSection) {
+	kind, name := wholeSectionKind(key)
+	withHeader := func(s *fwSection) []string { return append([]string{s.header}, s.lines...) }
+	switch {
+	case live == nil && stage != nil:
+		p.add(firewallChange{File: dest, Kind: kind, Name: name, Action: firewallChangeAdded, New: withHeader(stage)})
+	case live != nil && stage == nil:
+		p.add(firewallChange{File: dest, Kind: kind, Name: name, Action: firewallChangeRemoved, Old: withHeader(live)})
+	case live != nil && stage != nil && strings.Join(withHeader(live), "\n") != strings.Join(withHeader(stage), "\n"):
+		p.add(firewallChange{File: dest, Kind: kind, Name: name, Action: firewallChangeChanged, Old: withHeader(live), New: withHeader(stage)})
+	}
+}
+
+// diffRules diffs the ordered rule lists (longest common subsequence). A run of
+// removed rules directly followed by added rules is paired into "changed" rules.
+func (p *firewallMergePlan) diffRules(dest string, live, stage *fwSection) []firewallRuleOp {
+	var a, b []string
+	if live != nil {
+		a = live.lines
+	}
+	if stage != nil {
+		b = stage.lines
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []firewallRuleOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, firewallRuleOp{line: a[i], change: -1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, firewallRuleOp{del: true, line: a[i], change: -1})
+			i++
+		default:
+			ops = append(ops, firewallRuleOp{ins: true, line: b[j], change: -1})
+			j++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		if ops[k].change >= 0 || (!ops[k].del && !ops[k].ins) {
+			k++
+			continue
+		}
+		start := k
+		for k < len(ops) && ops[k].del {
+			k++
+		}
+		dels := ops[start:k]
+		insStart := k
+		for k < len(ops) && ops[k].ins {
+			k++
+		}
+		inss := ops[insStart:k]
+		for n := 0; n < len(dels) || n < len(inss); n++ {
+			switch {
+			case n < len(dels) && n < len(inss):
+				id := p.add(firewallChange{File: dest, Kind: "rule", Name: inss[n].line, Action: firewallChangeChanged, Old: []string{dels[n].line}, New: []string{inss[n].line}})
+				dels[n].change, inss[n].change = id, id
+			case n < len(dels):
+				dels[n].change = p.add(firewallChange{File: dest, Kind: "rule", Name: dels[n].line, Action: firewallChangeRemoved, Old: []string{dels[n].line}})
+			default:
+				inss[n].change = p.add(firewallChange{File: dest, Kind: "rule", Name: inss[n].line, Action: firewallChangeAdded, New: []string{inss[n].line}})
+			}
+		}
+		if k == start {
+			k++
+		}
+	}
+	return ops
+}
+
+// mergedFile rebuilds the live file with the accepted changes applied.
+func (p *firewallMergePlan) mergedFile(fm *firewallFileMerge, accepted map[int]bool) (*fwFile, bool) {
+	changed := false
+	byKey := make(map[string]firewallChange)
+	for _, c := range p.changes {
+		if c.File == fm.dest && accepted[c.id] && c.Kind != "rule" {
+			byKey[c.Kind+"\x00"+c.Name] = c
+			changed = true
+		}
+	}
+	for _, op := range fm.rules {
+		if op.change >= 0 && accepted[op.change] {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, false
+	}
+
+	out := &fwFile{preamble: fm.live.preamble}
+	ensure := func(key, header string) *fwSection {
+		if s := out.section(key); s != nil {
+			return s
+		}
+		s := &fwSection{key: key, header: header}
+		out.sections = append(out.sections, s)
+		return s
+	}
+
+	for _, live := range fm.live.sections {
+		switch {
+		case live.key == "options" || live.key == "aliases":
+			s := ensure(live.key, live.header)
+			kind := map[string]string{"options": "option", "aliases": "alias"}[live.key]
+			for _, line := range live.lines {
+				c, ok := byKey[kind+"\x00"+firewallKeyedLine(live.key, line)]
+				switch {
+				case !ok:
+					s.lines = append(s.lines, line)
+				case c.Action == firewallChangeChanged:
+					s.lines = append(s.lines, c.New...)
+				}
+			}
+		case live.key == "rules":
+			// Rebuilt from the diff below.
+		default:
+			kind, name := wholeSectionKind(live.key)
+			c, ok := byKey[kind+"\x00"+name]
+			switch {
+			case !ok:
+				out.sections = append(out.sections, &fwSection{key: live.key, header: live.header, lines: live.lines})
+			case c.Action == firewallChangeChanged:
+				out.sections = append(out.sections, &fwSection{key: live.key, header: c.New[0], lines: c.New[1:]})
+			}
+		}
+	}
+
+	// Additions of keyed entries and new whole sections, in staged order.
+	for _, stage := range fm.stage.sections {
+		switch {
+		case stage.key == "options" || stage.key == "aliases":
+			kind := map[string]string{"options": "option", "aliases": "alias"}[stage.key]
+			for _, line := range stage.lines {
+				if c, ok := byKey[kind+"\x00"+firewallKeyedLine(stage.key, line)]; ok && c.Action == firewallChangeAdded {
+					s := ensure(stage.key, stage.header)
+					s.lines = append(s.lines, line)
+				}
+			}
+		case stage.key == "rules":
+		default:
+			kind, name := wholeSectionKind(stage.key)
+			if c, ok := byKey[kind+"\x00"+name]; ok && c.Action == firewallChangeAdded {
+				out.sections = append(out.sections, &fwSection{key: stage.key, header: c.New[0], lines: c.New[1:]})
+			}
+		}
+	}
+
+	var rules []string
+	for _, op := range fm.rules {
+		take := accepted[op.change] && op.change >= 0
+		switch {
+		case op.del && !take, op.ins && take, !op.del && !op.ins:
+			rules = append(rules, op.line)
+		}
+	}
+	if len(rules) > 0 || fm.live.section("rules") != nil {
+		header := "[RULES]"
+		if s := fm.live.section("rules"); s != nil {
+			header = s.header
+		}
+		s := ensure("rules", header)
+		s.lines = rules
+	}
+	return out, true
+}
+
+func wholeSectionKind(key string) (string, string) {
+	kind, name, _ := strings.Cut(key, " ")
+	switch kind {
+	case "ipset":
+		return "IP set", name
+	case "group":
+		return "security group", name
+	default:
+		return "section", key
+	}
+}
+
+// applyPVEFirewallMerge writes the files touched by the accepted changes.
+func applyPVEFirewallMerge(logger *logging.Logger, plan *firewallMergePlan, accepted []firewallChange) (applied []string, err error) {
+	done := logging.DebugStart(logger, "pve firewall merge", "accepted=%d", len(accepted))
+	defer func() { done(err) }()
+
+	ids := make(map[int]bool, len(accepted))
+	for _, c := range accepted {
+		ids[c.id] = true
+	}
+	for _, fm := range plan.files {
+		merged, changed := plan.mergedFile(fm, ids)
+		if !changed {
+			continue
+		}
+		if err := restoreFS.MkdirAll(filepath.Dir(fm.dest), 0o755); err != nil {
+			return applied, fmt.Errorf("mkdir %s: %w", filepath.Dir(fm.dest), err)
+		}
+		if err := writeFileAtomic(fm.dest, []byte(renderFirewallFile(merged)), fm.mode); err != nil {
+			return applied, fmt.Errorf("write %s: %w", fm.dest, err)
+		}
+		applied = append(applied, fm.dest)
+	}
+	return applied, nil
+}
@@ -0,0 +1,213 @@
+package orchestrator
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const liveClusterFW = `[OPTIONS]
+
+enable: 1
+policy_in: ACCEPT
+
+[ALIASES]
+
+tenant-a 10.1.0.0/24 # tenant A
+old-host 10.9.9.9
+
+[IPSET tenants]
+
+10.1.0.0/24
+10.2.0.0/24
+
+[RULES]
+
+IN ACCEPT -source +tenants -p tcp -dport 443
+IN ACCEPT -p tcp -dport 22
+IN DROP -source 10.9.9.9
+
+[group tenant-a]
+
+IN ACCEPT -p tcp -dport 80
+`
+
+const stageClusterFW = `[OPTIONS]
+
+enable: 1
+policy_in: DROP
+log_ratelimit: enable=1
+
+[ALIASES]
+
+tenant-a 10.1.0.0/24 # tenant A
+backup 10.5.0.10
+
+[IPSET tenants]
+
+10.1.0.0/24
+
+[RULES]
+
+IN ACCEPT -source +tenants -p tcp -dport 443
+IN ACCEPT -p tcp -dport 22 -source 10.0.0.0/8
+IN ACCEPT -p tcp -dport 8006
+
+[group tenant-a]
+
+IN ACCEPT -p tcp -dport 80
+
+[group monitoring]
+
+IN ACCEPT -p tcp -dport 9100
+`
+
+func setupFirewallMergeTest(t *testing.T, files map[string]string) *FakeFS {
+	t.Helper()
+	origFS, origHostname := restoreFS, firewallHostname
+	t.Cleanup(func() {
+		restoreFS = origFS
+		firewallHostname = origHostname
+	})
+	fakeFS := NewFakeFS()
+	t.Cleanup(func() { _ = os.RemoveAll(fakeFS.Root) })
+	restoreFS = fakeFS
+	firewallHostname = func() (string, error) { return "node1", nil }
+	for path, content := range files {
+		if err := fakeFS.AddFile(path, []byte(content)); err != nil {
+			t.Fatalf("add %s: %v", path, err)
+		}
+	}
+	return fakeFS
+}
+
+func firewallChangeSummaries(changes []firewallChange) []string {
+	out := make([]string, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, c.Summary())
+	}
+	return out
+}
+
+func TestPlanPVEFirewallMergeListsChanges(t *testing.T) {
+	setupFirewallMergeTest(t, map[string]string{
+		"/stage/etc/pve/firewall/cluster.fw": stageClusterFW,
+		"/stage/etc/pve/firewall/100.fw":     "[OPTIONS]\n\nenable: 1\n",
+		"/etc/pve/firewall/cluster.fw":       liveClusterFW,
+	})
+
+	plan, err := planPVEFirewallMerge(newTestLogger(), "/stage")
+	if err != nil {
+		t.Fatalf("planPVEFirewallMerge: %v", err)
+	}
+	want := []string{
+		"100.fw added option: enable",
+		"cluster.fw changed option: policy_in",
+		"cluster.fw added option: log_ratelimit",
+		"cluster.fw added alias: backup",
+		"cluster.fw removed alias: old-host",
+		"cluster.fw changed IP set: tenants",
+		"cluster.fw changed rule: IN ACCEPT -p tcp -dport 22 -source 10.0.0.0/8",
+		"cluster.fw changed rule: IN ACCEPT -p tcp -dport 8006",
+		"cluster.fw added security group: monitoring",
+	}
+	if got := firewallChangeSummaries(plan.changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes=\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, c := range plan.changes {
+		if c.Default != (c.Action != firewallChangeRemoved) {
+			t.Fatalf("%s: default=%v", c.Summary(), c.Default)
+		}
+	}
+}
+
+func TestApplyPVEFirewallMergeWritesOnlyAcceptedChanges(t *testing.T) {
+	fakeFS := setupFirewallMergeTest(t, map[string]string{
+		"/stage/etc/pve/firewall/cluster.fw": stageClusterFW,
+		"/etc/pve/firewall/cluster.fw":       liveClusterFW,
+	})
+	plan, err := planPVEFirewallMerge(newTestLogger(), "/stage")
+	if err != nil {
+		t.Fatalf("planPVEFirewallMerge: %v", err)
+	}
+
+	var accepted []firewallChange
+	for _, c := range plan.changes {
+		switch c.Summary() {
+		case "cluster.fw added alias: backup",
+			"cluster.fw changed rule: IN ACCEPT -p tcp -dport 8006",
+			"cluster.fw added security group: monitoring":
+			accepted = append(accepted, c)
+		}
+	}
+	applied, err := applyPVEFirewallMerge(newTestLogger(), plan, accepted)
+	if err != nil {
+		t.Fatalf("applyPVEFirewallMerge: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"/etc/pve/firewall/cluster.fw"}) {
+		t.Fatalf("applied=%v", applied)
+	}
+
+	data, err := fakeFS.ReadFile("/etc/pve/firewall/cluster.fw")
+	if err != nil {
+		t.Fatalf("read merged file: %v", err)
+	}
+	want := `[OPTIONS]
+
+enable: 1
+policy_in: ACCEPT
+
+[ALIASES]
+
+tenant-a 10.1.0.0/24 # tenant A
+old-host 10.9.9.9
+backup 10.5.0.10
+
+[IPSET tenants]
+
+10.1.0.0/24
+10.2.0.0/24
+
+[RULES]
+
+IN ACCEPT -source +tenants -p tcp -dport 443
+IN ACCEPT -p tcp -dport 22
+IN ACCEPT -p tcp -dport 8006
+
+[group tenant-a]
+
+IN ACCEPT -p tcp -dport 80
+
+[group monitoring]
+
+IN ACCEPT -p tcp -dport 9100
+`
+	if string(data) != want {
+		t.Fatalf("merged cluster.fw:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestApplyPVEFirewallMergeCreatesMissingHostFW(t *testing.T) {
+	fakeFS := setupFirewallMergeTest(t, map[string]string{
+		"/stage/etc/pve/nodes/node1/host.fw": "[OPTIONS]\n\nenable: 1\n\n[RULES]\n\nIN ACCEPT -p tcp -dport 22\n",
+	})
+	plan, err := planPVEFirewallMerge(newTestLogger(), "/stage")
+	if err != nil {
+		t.Fatalf("planPVEFirewallMerge: %v", err)
+	}
+	applied, err := applyPVEFirewallMerge(newTestLogger(), plan, defaultFirewallChanges(plan.changes))
+	if err != nil {
+		t.Fatalf("applyPVEFirewallMerge: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"/etc/pve/nodes/node1/host.fw"}) {
+		t.Fatalf("applied=%v", applied)
+	}
+	data, err := fakeFS.ReadFile("/etc/pve/nodes/node1/host.fw")
+	if err != nil {
+		t.Fatalf("read host.fw: %v", err)
+	}
+	if want := "[OPTIONS]\n\nenable: 1\n\n[RULES]\n\nIN ACCEPT -p tcp -dport 22\n"; string(data) != want {
+		t.Fatalf("host.fw=%q want %q", data, want)
+	}
+}
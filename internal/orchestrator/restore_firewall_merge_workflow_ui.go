@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/input"
+	"github.com/tis24dev/proxsave/internal/ui/components"
+)
+
+func (u *cliWorkflowUI) SelectFirewallRestoreMode(ctx context.Context) (FirewallRestoreMode, error) {
+	fmt.Fprintln(u.w())
+	fmt.Fprintln(u.w(), "PVE firewall restore:")
+	fmt.Fprintln(u.w(), "  [1] Replace (1:1) - Replace cluster.fw, guest .fw and host.fw with the files from the backup.")
+	fmt.Fprintln(u.w(), "  [2] Review and merge - Review rules, aliases, IP sets and security groups; write only the accepted changes.")
+	fmt.Fprintln(u.w(), "  [0] Exit")
+
+	for {
+		fmt.Fprint(u.w(), "Choice: ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return "", err
+		}
+		switch strings.TrimSpace(line) {
+		case "1":
+			return FirewallRestoreReplace, nil
+		case "2":
+			return FirewallRestoreMerge, nil
+		case "0":
+			return "", ErrRestoreAborted
+		default:
+			fmt.Fprintln(u.w(), "Please enter 1, 2 or 0.")
+		}
+	}
+}
+
+func (u *cliWorkflowUI) ReviewFirewallChanges(ctx context.Context, changes []firewallChange) ([]firewallChange, error) {
+	fmt.Fprintln(u.w(), "\nFirewall changes in the backup ([x] = applied by default; removals are not):")
+	for idx, c := range changes {
+		marker := " "
+		if c.Default {
+			marker = "x"
+		}
+		fmt.Fprintf(u.w(), "  [%d] [%s] %s\n", idx+1, marker, components.SanitizeLine(c.Summary()))
+		for _, line := range c.Old {
+			fmt.Fprintf(u.w(), "        - %s\n", components.SanitizeLine(line))
+		}
+		for _, line := range c.New {
+			fmt.Fprintf(u.w(), "        + %s\n", components.SanitizeLine(line))
+		}
+	}
+
+	for {
+		fmt.Fprint(u.w(), "Changes to apply (e.g. 1,3 or 2-4; 'all', 'none'; Enter for the [x] ones): ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimSpace(line)
+		switch strings.ToLower(trimmed) {
+		case "":
+			return defaultFirewallChanges(changes), nil
+		case "none":
+			return nil, nil
+		}
+		indexes, err := parseMenuSelection(trimmed, len(changes))
+		if err != nil {
+			fmt.Fprintln(u.w(), err)
+			continue
+		}
+		accepted := make([]firewallChange, 0, len(indexes))
+		for _, idx := range indexes {
+			accepted = append(accepted, changes[idx])
+		}
+		return accepted, nil
+	}
+}
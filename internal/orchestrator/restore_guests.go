@@ -0,0 +1,636 @@
+// Package orchestrator coordinates backup, restore, decrypt, and related workflows.
+package orchestrator
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// ErrNoArchivedGuests is returned by the guest restore workflow when the selected
+// backup contains no VM/CT configuration.
+var ErrNoArchivedGuests = errors.New("no VM/CT configurations found in backup")
+
+const (
+	archivedQemuInventoryPath = "var/lib/proxsave-info/commands/pve/qemu_vms.json"
+	archivedLXCInventoryPath  = "var/lib/proxsave-info/commands/pve/lxc_containers.json"
+	maxArchivedGuestConfBytes = 1 << 20
+	minGuestVMID              = 100
+	maxGuestVMID              = 999999999
+)
+
+// ArchivedGuest is a VM or container whose configuration was found in a backup.
+type ArchivedGuest struct {
+	VMID   string
+	Kind   string // qemu | lxc
+	Name   string
+	Node   string // node directory the config was archived under
+	Status string // status recorded by the guest inventory, if any
+	Config string
+}
+
+// Display returns a one-line description of the guest for menus and logs.
+func (g ArchivedGuest) Display() string {
+	label := fmt.Sprintf("%s %s", g.VMID, g.Kind)
+	if g.Name != "" {
+		label += " " + g.Name
+	}
+	details := []string{"node " + g.Node}
+	if g.Status != "" {
+		details = append(details, g.Status)
+	}
+	return fmt.Sprintf("%s (%s)", label, strings.Join(details, ", "))
+}
+
+// GuestRestoreUI groups prompts used by the guest restore workflow.
+type GuestRestoreUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	SelectGuests(ctx context.Context, guests []ArchivedGuest) ([]ArchivedGuest, error)
+	SelectGuestTargetNode(ctx context.Context, nodes []string, defaultNode string) (string, error)
+	// PromptGuestVMID asks for the VMID to restore guest under. reason explains
+	// why the archived VMID cannot be used as-is; an empty answer skips the guest.
+	PromptGuestVMID(ctx context.Context, guest ArchivedGuest, reason, suggested string) (string, error)
+	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
+}
+
+// liveGuest is a guest currently known to the cluster (pvesh /cluster/resources).
+type liveGuest struct {
+	VMID string
+	Kind string
+	Name string
+	Node string
+}
+
+// guestRestorePlan describes how one archived guest is placed on the cluster.
+type guestRestorePlan struct {
+	Guest     ArchivedGuest
+	VMID      string
+	Node      string
+	Overwrite bool
+	NameClash []liveGuest
+}
+
+func (p guestRestorePlan) remapped() bool {
+	return p.VMID != p.Guest.VMID
+}
+
+// RunGuestRestoreWorkflow restores individual VM/CT configurations from a backup,
+// remapping VMIDs that are already taken on the live cluster, using stdin prompts.
+func RunGuestRestoreWorkflow(ctx context.Context, cfg *config.Config, logger *logging.Logger, version string) (err error) {
+	if cfg == nil {
+		return fmt.Errorf("configuration not available")
+	}
+	if logger == nil {
+		logger = logging.GetDefaultLogger()
+	}
+	done := logging.DebugStart(logger, "guest restore workflow (cli)", "dry_run=%v", cfg.DryRun)
+	defer func() { done(err) }()
+	defer func() { err = normalizeRestoreWorkflowUIError(ctx, logger, err) }()
+
+	if !restoreSystem.DetectCurrentSystem().SupportsPVE() {
+		return fmt.Errorf("guest restore requires a Proxmox VE host")
+	}
+
+	ui := newCLIWorkflowUI(bufio.NewReader(os.Stdin), logger)
+	_, prepared, err := prepareRestoreBundleFunc(ctx, cfg, logger, version, ui)
+	if err != nil {
+		return err
+	}
+	defer prepared.Cleanup()
+
+	return runGuestRestoreWorkflowWithUI(ctx, cfg, logger, ui, prepared.ArchivePath)
+}
+
+func runGuestRestoreWorkflowWithUI(ctx context.Context, cfg *config.Config, logger *logging.Logger, ui GuestRestoreUI, archivePath string) error {
+	guests, err := loadArchivedGuests(ctx, archivePath)
+	if err != nil {
+		return fmt.Errorf("read guests from backup: %w", err)
+	}
+	if len(guests) == 0 {
+		return ErrNoArchivedGuests
+	}
+	logging.DebugStep(logger, "guest restore", "archived guests=%d", len(guests))
+
+	selected, err := ui.SelectGuests(ctx, guests)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return ErrRestoreAborted
+	}
+
+	live, err := listLiveGuests(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("list guests on the live cluster: %w", err)
+	}
+	nodes, err := listOnlineNodes(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("list cluster nodes: %w", err)
+	}
+	node, err := ui.SelectGuestTargetNode(ctx, nodes, defaultGuestTargetNode(nodes))
+	if err != nil {
+		return err
+	}
+
+	plans, err := planGuestRestores(ctx, logger, ui, selected, live, node)
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		return ErrRestoreAborted
+	}
+
+	if err := ui.ShowMessage(ctx, "Guest restore plan", describeGuestRestorePlans(plans)); err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		logger.Info("Dry run: no guest configuration was applied")
+		return nil
+	}
+
+	confirmed, err := ui.ConfirmAction(ctx,
+		"Restore the selected guest configs?",
+		fmt.Sprintf("%d guest config(s) will be created or updated via pvesh.", len(plans)),
+		"Restore", "Cancel", 0, false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return ErrRestoreAborted
+	}
+
+	applied, failed := applyGuestRestorePlans(ctx, logger, plans)
+	logger.Info("Guest restore: applied=%d failed=%d", applied, failed)
+	if applied == 0 && failed > 0 {
+		return fmt.Errorf("no guest configuration could be applied (%d failed)", failed)
+	}
+	return nil
+}
+
+// planGuestRestores resolves the target VMID of every selected guest, prompting for
+// a new VMID whenever the archived one is taken on the cluster or by another guest
+// of the same restore.
+func planGuestRestores(ctx context.Context, logger *logging.Logger, ui GuestRestoreUI, selected []ArchivedGuest, live []liveGuest, node string) ([]guestRestorePlan, error) {
+	liveByID := make(map[string]liveGuest, len(live))
+	for _, g := range live {
+		liveByID[g.VMID] = g
+	}
+	reserved := make(map[string]bool, len(selected))
+	var plans []guestRestorePlan
+
+	for _, guest := range selected {
+		plan := guestRestorePlan{Guest: guest, VMID: guest.VMID, Node: node}
+		reason := guestVMIDConflict(guest, guest.VMID, liveByID, reserved)
+		for reason != "" {
+			suggested := nextFreeGuestVMID(ctx, logger, liveByID, reserved)
+			answer, err := ui.PromptGuestVMID(ctx, guest, reason, suggested)
+			if err != nil {
+				return nil, err
+			}
+			answer = strings.TrimSpace(answer)
+			if answer == "" {
+				logger.Info("Skipping guest %s", guest.Display())
+				break
+			}
+			if err := validateGuestVMID(answer); err != nil {
+				reason = err.Error()
+				continue
+			}
+			if existing, ok := liveByID[answer]; ok && answer == guest.VMID && !reserved[answer] {
+				// Re-entering the archived VMID means "overwrite the live guest";
+				// only allowed for the same guest type, on the node hosting it.
+				if existing.Kind != guest.Kind {
+					reason = fmt.Sprintf("VMID %s is a %s on node %s and cannot be overwritten with a %s config", answer, existing.Kind, existing.Node, guest.Kind)
+					continue
+				}
+				confirmed, err := ui.ConfirmAction(ctx,
+					fmt.Sprintf("Overwrite the config of %s %s?", existing.Kind, answer),
+					fmt.Sprintf("The live guest %s on node %s will be reconfigured with the archived config.", liveGuestLabel(existing), existing.Node),
+					"Overwrite", "Back", 0, false)
+				if err != nil {
+					return nil, err
+				}
+				if !confirmed {
+					continue
+				}
+				plan.VMID = answer
+				plan.Node = existing.Node
+				plan.Overwrite = true
+				reason = ""
+				continue
+			}
+			plan.VMID = answer
+			reason = guestVMIDConflict(guest, answer, liveByID, reserved)
+		}
+		if reason != "" {
+			continue
+		}
+		reserved[plan.VMID] = true
+		plan.NameClash = guestNameClashes(guest, plan.VMID, live)
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func guestVMIDConflict(guest ArchivedGuest, vmid string, liveByID map[string]liveGuest, reserved map[string]bool) string {
+	if reserved[vmid] {
+		return fmt.Sprintf("VMID %s is already used by another guest of this restore", vmid)
+	}
+	if existing, ok := liveByID[vmid]; ok {
+		msg := fmt.Sprintf("VMID %s is in use on node %s by %s", vmid, existing.Node, liveGuestLabel(existing))
+		if vmid == guest.VMID {
+			msg += " (enter " + vmid + " again to overwrite it)"
+		}
+		return msg
+	}
+	return ""
+}
+
+func validateGuestVMID(value string) error {
+	id, err := strconv.Atoi(value)
+	if err != nil || id < minGuestVMID || id > maxGuestVMID {
+		return fmt.Errorf("%q is not a valid VMID (use %d-%d)", value, minGuestVMID, maxGuestVMID)
+	}
+	if strconv.Itoa(id) != value {
+		return fmt.Errorf("%q is not a valid VMID (no leading zeros)", value)
+	}
+	return nil
+}
+
+func guestNameClashes(guest ArchivedGuest, vmid string, live []liveGuest) []liveGuest {
+	if guest.Name == "" {
+		return nil
+	}
+	var clashes []liveGuest
+	for _, g := range live {
+		if g.VMID != vmid && strings.EqualFold(g.Name, guest.Name) {
+			clashes = append(clashes, g)
+		}
+	}
+	return clashes
+}
+
+// nextFreeGuestVMID returns the cluster's next free VMID, skipping IDs already
+// reserved by this restore (pvesh does not know about them yet).
+func nextFreeGuestVMID(ctx context.Context, logger *logging.Logger, liveByID map[string]liveGuest, reserved map[string]bool) string {
+	start := minGuestVMID
+	if output, err := restoreCmd.Run(ctx, "pvesh", "get", "/cluster/nextid", "--output-format=json"); err == nil {
+		if id, err := strconv.Atoi(strings.Trim(strings.TrimSpace(string(output)), `"`)); err == nil && id > start {
+			start = id
+		}
+	} else {
+		logging.DebugStep(logger, "guest restore", "pvesh nextid failed: %v", err)
+	}
+	for id := start; id <= maxGuestVMID; id++ {
+		candidate := strconv.Itoa(id)
+		if _, taken := liveByID[candidate]; !taken && !reserved[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func liveGuestLabel(g liveGuest) string {
+	if g.Name == "" {
+		return fmt.Sprintf("%s %s", g.Kind, g.VMID)
+	}
+	return fmt.Sprintf("%s %s (%s)", g.Kind, g.VMID, g.Name)
+}
+
+func describeGuestRestorePlans(plans []guestRestorePlan) string {
+	var b strings.Builder
+	for _, p := range plans {
+		action := "create"
+		if p.Overwrite {
+			action = "overwrite"
+		}
+		fmt.Fprintf(&b, "- %s -> %s %s on node %s (%s)\n", p.Guest.Display(), p.Guest.Kind, p.VMID, p.Node, action)
+		if p.remapped() {
+			if volumes := guestVolumeRenames(p.Guest.Config, p.Guest.VMID, p.VMID); len(volumes) > 0 {
+				fmt.Fprintf(&b, "    volumes renamed in config: %s\n", strings.Join(volumes, ", "))
+			}
+		}
+		for _, clash := range p.NameClash {
+			fmt.Fprintf(&b, "    warning: name also used by %s on node %s\n", liveGuestLabel(clash), clash.Node)
+		}
+	}
+	b.WriteString("\nOnly the configuration is restored; disk images must already exist under the listed volume names (restore them from vzdump/PBS or rename them on the storage).")
+	return b.String()
+}
+
+func applyGuestRestorePlans(ctx context.Context, logger *logging.Logger, plans []guestRestorePlan) (applied, failed int) {
+	for _, p := range plans {
+		if err := ctx.Err(); err != nil {
+			logger.Warning("Guest restore aborted: %v", err)
+			return applied, failed
+		}
+		if err := applyGuestRestorePlan(ctx, logger, p); err != nil {
+			logger.Warning("Failed to restore %s as %s %s: %v", p.Guest.Display(), p.Guest.Kind, p.VMID, err)
+			failed++
+			continue
+		}
+		logger.Info("Restored %s as %s %s on node %s", p.Guest.Display(), p.Guest.Kind, p.VMID, p.Node)
+		applied++
+	}
+	return applied, failed
+}
+
+func applyGuestRestorePlan(ctx context.Context, logger *logging.Logger, p guestRestorePlan) error {
+	conf := p.Guest.Config
+	if p.remapped() {
+		conf = rewriteGuestConfigVMID(conf, p.Guest.VMID, p.VMID)
+	}
+	configArgs := filterGuestConfigArgs(pveshArgsFromColonConfigLines(strings.Split(conf, "\n")))
+	target := fmt.Sprintf("/nodes/%s/%s/%s/config", p.Node, p.Guest.Kind, p.VMID)
+
+	// Re-check right before applying: the cluster may have changed since planning.
+	exists, err := pveshGuestExists(ctx, logger, target)
+	if err != nil {
+		return fmt.Errorf("check %s: %w", target, err)
+	}
+	if exists && !p.Overwrite {
+		return fmt.Errorf("VMID %s was created on node %s in the meantime", p.VMID, p.Node)
+	}
+	if !exists {
+		vm := vmEntry{VMID: p.VMID, Kind: p.Guest.Kind, Name: p.Guest.Name}
+		createArgs, err := pveshCreateGuestArgs(p.Node, vm, configArgs)
+		if err != nil {
+			return err
+		}
+		if err := runPvesh(ctx, logger, createArgs); err != nil {
+			return err
+		}
+	}
+	return runPvesh(ctx, logger, append([]string{"set", target}, configArgs...))
+}
+
+// guestConfigReadOnlyKeys are written by PVE itself and rejected by "pvesh set".
+var guestConfigReadOnlyKeys = []string{"digest", "lock", "parent"}
+
+func filterGuestConfigArgs(args []string) []string {
+	out := args[:0]
+	for _, arg := range args {
+		key := strings.TrimPrefix(arg, "--")
+		if idx := strings.Index(key, "="); idx >= 0 {
+			key = key[:idx]
+		}
+		if stringSliceContains(guestConfigReadOnlyKeys, key) {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// guestVolumePrefixes are the volume name prefixes PVE derives from the owner VMID
+// (vm-<id>-disk-0, base-<id>-disk-0, subvol-<id>-disk-0, vm-<id>-cloudinit, ...).
+const guestVolumePrefixes = `vm|base|subvol|basevol`
+
+// rewriteGuestConfigVMID rewrites the volume names owned by oldID to newID,
+// including the <id>/ directory of file-based storages (local:104/vm-104-disk-0.qcow2).
+// Volumes owned by other guests, such as the base image of a linked clone, are kept.
+func rewriteGuestConfigVMID(conf, oldID, newID string) string {
+	old := regexp.QuoteMeta(oldID)
+	dirRe := regexp.MustCompile(`([:/])` + old + `/((?:` + guestVolumePrefixes + `)-)` + old + `-`)
+	conf = dirRe.ReplaceAllString(conf, "${1}"+newID+"/${2}"+newID+"-")
+	volRe := regexp.MustCompile(`\b(` + guestVolumePrefixes + `)-` + old + `-`)
+	return volRe.ReplaceAllString(conf, "${1}-"+newID+"-")
+}
+
+// guestVolumeRenames lists "old -> new" for every volume renamed by a VMID rewrite.
+func guestVolumeRenames(conf, oldID, newID string) []string {
+	re := regexp.MustCompile(`\b(?:` + guestVolumePrefixes + `)-` + regexp.QuoteMeta(oldID) + `-[A-Za-z0-9_.-]+`)
+	seen := make(map[string]bool)
+	var renames []string
+	for _, line := range strings.Split(conf, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			break
+		}
+		for _, name := range re.FindAllString(line, -1) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			renames = append(renames, name+" -> "+rewriteGuestConfigVMID(name, oldID, newID))
+		}
+	}
+	return renames
+}
+
+func defaultGuestTargetNode(nodes []string) string {
+	local := localNodeName()
+	if len(nodes) == 0 || stringSliceContains(nodes, local) {
+		return local
+	}
+	return nodes[0]
+}
+
+func listLiveGuests(ctx context.Context, logger *logging.Logger) ([]liveGuest, error) {
+	output, err := restoreCmd.Run(ctx, "pvesh", "get", "/cluster/resources", "--type", "vm", "--output-format=json")
+	if err != nil {
+		return nil, fmt.Errorf("pvesh get /cluster/resources failed: %w", err)
+	}
+	var raw []struct {
+		VMID any    `json:"vmid"`
+		Type string `json:"type"`
+		Name string `json:"name"`
+		Node string `json:"node"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("parse cluster resources: %w", err)
+	}
+	guests := make([]liveGuest, 0, len(raw))
+	for _, item := range raw {
+		vmid := guestJSONString(item.VMID)
+		if vmid == "" {
+			continue
+		}
+		guests = append(guests, liveGuest{VMID: vmid, Kind: item.Type, Name: strings.TrimSpace(item.Name), Node: item.Node})
+	}
+	logging.DebugStep(logger, "guest restore", "live guests=%d", len(guests))
+	return guests, nil
+}
+
+func listOnlineNodes(ctx context.Context, logger *logging.Logger) ([]string, error) {
+	output, err := restoreCmd.Run(ctx, "pvesh", "get", "/nodes", "--output-format=json")
+	if err != nil {
+		return nil, fmt.Errorf("pvesh get /nodes failed: %w", err)
+	}
+	var raw []struct {
+		Node   string `json:"node"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("parse node list: %w", err)
+	}
+	var nodes []string
+	for _, item := range raw {
+		if item.Node == "" || (item.Status != "" && item.Status != "online") {
+			continue
+		}
+		nodes = append(nodes, item.Node)
+	}
+	sort.Strings(nodes)
+	logging.DebugStep(logger, "guest restore", "online nodes=%v", nodes)
+	return nodes, nil
+}
+
+func guestJSONString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	default:
+		return ""
+	}
+}
+
+// archivedGuestInventoryEntry is one guest of the archived pve_guest_inventory
+// (pvesh get /nodes/<node>/qemu|lxc).
+type archivedGuestInventoryEntry struct {
+	VMID   any    `json:"vmid"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// loadArchivedGuests reads the guest inventory and the VM/CT configs archived under
+// etc/pve/nodes/<node>/{qemu-server,lxc} in a single pass over the archive. Guests
+// listed by the inventory without an archived config cannot be restored and are
+// left out; configs of other cluster nodes are listed even without inventory data.
+func loadArchivedGuests(ctx context.Context, archivePath string) (guests []ArchivedGuest, err error) {
+	file, err := restoreFS.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer closeIntoErr(&err, file, "close archive")
+
+	reader, err := createDecompressionReader(ctx, file, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("create decompression reader: %w", err)
+	}
+	defer closeIntoErr(&err, reader, "close decompression reader")
+
+	inventory := make(map[string]archivedGuestInventoryEntry)
+	tr := tar.NewReader(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(header.Name, "./")
+		switch {
+		case name == archivedQemuInventoryPath || name == archivedLXCInventoryPath:
+			kind := "qemu"
+			if name == archivedLXCInventoryPath {
+				kind = "lxc"
+			}
+			data, err := readRestoreArchiveEntry(tr, header, maxArchiveInventoryBytes)
+			if err != nil {
+				return nil, err
+			}
+			var entries []archivedGuestInventoryEntry
+			if err := json.Unmarshal(data, &entries); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", name, err)
+			}
+			for _, entry := range entries {
+				if vmid := guestJSONString(entry.VMID); vmid != "" {
+					inventory[kind+"/"+vmid] = entry
+				}
+			}
+		default:
+			guest, ok := archivedGuestFromConfigPath(name)
+			if !ok {
+				continue
+			}
+			data, err := readRestoreArchiveEntry(tr, header, maxArchivedGuestConfBytes)
+			if err != nil {
+				return nil, err
+			}
+			guest.Config = string(data)
+			guest.Name = guestConfigName(guest.Config)
+			guests = append(guests, guest)
+		}
+	}
+
+	for i := range guests {
+		if entry, ok := inventory[guests[i].Kind+"/"+guests[i].VMID]; ok {
+			if guests[i].Name == "" {
+				guests[i].Name = strings.TrimSpace(entry.Name)
+			}
+			guests[i].Status = strings.TrimSpace(entry.Status)
+		}
+	}
+	sort.SliceStable(guests, func(i, j int) bool {
+		a, _ := strconv.Atoi(guests[i].VMID)
+		b, _ := strconv.Atoi(guests[j].VMID)
+		if a != b {
+			return a < b
+		}
+		return guests[i].Node < guests[j].Node
+	})
+	return guests, nil
+}
+
+// archivedGuestFromConfigPath recognises etc/pve/nodes/<node>/qemu-server/<vmid>.conf
+// and etc/pve/nodes/<node>/lxc/<vmid>.conf.
+func archivedGuestFromConfigPath(name string) (ArchivedGuest, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "etc" || parts[1] != "pve" || parts[2] != "nodes" {
+		return ArchivedGuest{}, false
+	}
+	if node := parts[3]; node == "" || node == "." || node == ".." {
+		return ArchivedGuest{}, false
+	}
+	var kind string
+	switch parts[4] {
+	case "qemu-server":
+		kind = "qemu"
+	case "lxc":
+		kind = "lxc"
+	default:
+		return ArchivedGuest{}, false
+	}
+	vmid, ok := strings.CutSuffix(parts[5], ".conf")
+	if !ok || validateGuestVMID(vmid) != nil {
+		return ArchivedGuest{}, false
+	}
+	return ArchivedGuest{VMID: vmid, Kind: kind, Node: parts[3]}, true
+}
+
+func guestConfigName(conf string) string {
+	for _, line := range strings.Split(conf, "\n") {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "[") {
+			break
+		}
+		for _, key := range []string{"name:", "hostname:"} {
+			if value, ok := strings.CutPrefix(t, key); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+)
+
+type fakeGuestRestoreUI struct {
+	pick     []int
+	node     string
+	vmids    []string
+	confirm  bool
+	messages []string
+	prompts  []string
+	confirms []string
+}
+
+func (f *fakeGuestRestoreUI) ShowMessage(ctx context.Context, title, message string) error {
+	f.messages = append(f.messages, title+"\n"+message)
+	return nil
+}
+
+func (f *fakeGuestRestoreUI) SelectGuests(ctx context.Context, guests []ArchivedGuest) ([]ArchivedGuest, error) {
+	var out []ArchivedGuest
+	for _, idx := range f.pick {
+		out = append(out, guests[idx])
+	}
+	return out, nil
+}
+
+func (f *fakeGuestRestoreUI) SelectGuestTargetNode(ctx context.Context, nodes []string, defaultNode string) (string, error) {
+	if f.node != "" {
+		return f.node, nil
+	}
+	return defaultNode, nil
+}
+
+func (f *fakeGuestRestoreUI) PromptGuestVMID(ctx context.Context, guest ArchivedGuest, reason, suggested string) (string, error) {
+	f.prompts = append(f.prompts, guest.VMID+": "+reason+" [suggested "+suggested+"]")
+	if len(f.vmids) == 0 {
+		return suggested, nil
+	}
+	answer := f.vmids[0]
+	f.vmids = f.vmids[1:]
+	return answer, nil
+}
+
+func (f *fakeGuestRestoreUI) ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error) {
+	f.confirms = append(f.confirms, title)
+	return f.confirm, nil
+}
+
+const testGuestQemuConf = `boot: order=scsi0
+digest: 0123456789abcdef
+name: web01
+scsi0: local-lvm:vm-104-disk-0,size=32G
+efidisk0: local:104/vm-104-disk-1.qcow2,size=4M
+ide2: local-lvm:vm-104-cloudinit,media=cdrom
+parent: before-upgrade
+
+[before-upgrade]
+scsi0: local-lvm:vm-104-disk-0,size=32G
+`
+
+func writeGuestTestArchive(t *testing.T) string {
+	t.Helper()
+	restoreFS = osFS{}
+	archivePath := filepath.Join(t.TempDir(), "backup.tar")
+	if err := writeTarFile(archivePath, map[string]string{
+		"./var/lib/proxsave-info/commands/pve/qemu_vms.json":       `[{"vmid":104,"name":"web01","status":"running"},{"vmid":150,"name":"gone","status":"stopped"}]`,
+		"./var/lib/proxsave-info/commands/pve/lxc_containers.json": `[{"vmid":"200","name":"dns","status":"running"}]`,
+		"./etc/pve/nodes/pve1/qemu-server/104.conf":                testGuestQemuConf,
+		"./etc/pve/nodes/pve1/lxc/200.conf":                        "hostname: dns\nostemplate: local:vztmpl/debian-12.tar.zst\nrootfs: local-lvm:subvol-200-disk-0,size=8G\n",
+		"./etc/pve/nodes/pve2/qemu-server/300.conf":                "name: db\nscsi0: ceph:vm-300-disk-0,size=64G\n",
+		"./etc/pve/nodes/pve1/qemu-server/notes.txt":               "not a guest",
+		"./etc/pve/storage.cfg":                                    "dir: local\n",
+	}); err != nil {
+		t.Fatalf("writeTarFile: %v", err)
+	}
+	return archivePath
+}
+
+func setupGuestRestoreTest(t *testing.T, outputs map[string][]byte, errs map[string]error) *fakeCommandRunner {
+	t.Helper()
+	origFS := restoreFS
+	origCmd := restoreCmd
+	t.Cleanup(func() {
+		restoreFS = origFS
+		restoreCmd = origCmd
+	})
+	runner := &fakeCommandRunner{outputs: outputs, errs: errs}
+	restoreCmd = runner
+	return runner
+}
+
+func TestRewriteGuestConfigVMID(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"block volume", "scsi0: local-lvm:vm-104-disk-0,size=32G", "scsi0: local-lvm:vm-205-disk-0,size=32G"},
+		{"file volume directory", "efidisk0: local:104/vm-104-disk-1.qcow2,size=4M", "efidisk0: local:205/vm-205-disk-1.qcow2,size=4M"},
+		{"cloudinit", "ide2: local-lvm:vm-104-cloudinit,media=cdrom", "ide2: local-lvm:vm-205-cloudinit,media=cdrom"},
+		{"container subvol", "rootfs: local-zfs:subvol-104-disk-0,size=8G", "rootfs: local-zfs:subvol-205-disk-0,size=8G"},
+		{"linked clone keeps base", "scsi0: local:900/base-900-disk-0.qcow2/104/vm-104-disk-0.qcow2", "scsi0: local:900/base-900-disk-0.qcow2/205/vm-205-disk-0.qcow2"},
+		{"other guest untouched", "scsi1: local-lvm:vm-1040-disk-0,size=1G", "scsi1: local-lvm:vm-1040-disk-0,size=1G"},
+		{"non volume value untouched", "description: moved from 104/ to new host", "description: moved from 104/ to new host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteGuestConfigVMID(tt.in, "104", "205"); got != tt.want {
+				t.Fatalf("rewrite = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadArchivedGuests(t *testing.T) {
+	setupGuestRestoreTest(t, nil, nil)
+	archivePath := writeGuestTestArchive(t)
+
+	guests, err := loadArchivedGuests(context.Background(), archivePath)
+	if err != nil {
+		t.Fatalf("loadArchivedGuests: %v", err)
+	}
+	var got []string
+	for _, g := range guests {
+		got = append(got, g.Display())
+	}
+	want := []string{
+		"104 qemu web01 (node pve1, running)",
+		"200 lxc dns (node pve1, running)",
+		"300 qemu db (node pve2)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("guests = %q, want %q", got, want)
+	}
+	if !strings.Contains(guests[0].Config, "scsi0: local-lvm:vm-104-disk-0") {
+		t.Fatalf("config not loaded: %q", guests[0].Config)
+	}
+}
+
+func TestGuestRestoreWorkflowRemapsTakenVMID(t *testing.T) {
+	runner := setupGuestRestoreTest(t, map[string][]byte{
+		"pvesh get /cluster/resources --type vm --output-format=json": []byte(`[{"vmid":104,"type":"qemu","name":"web01-new","node":"pve1"},{"vmid":105,"type":"lxc","name":"web01","node":"pve2"}]`),
+		"pvesh get /nodes --output-format=json":                       []byte(`[{"node":"pve1","status":"online"},{"node":"pve2","status":"online"}]`),
+		"pvesh get /cluster/nextid --output-format=json":              []byte(`"105"`),
+	}, map[string]error{
+		"pvesh get /nodes/pve1/qemu/106/config": errors.New("Configuration file 'nodes/pve1/qemu-server/106.conf' does not exist"),
+	})
+	archivePath := writeGuestTestArchive(t)
+	ui := &fakeGuestRestoreUI{pick: []int{0}, node: "pve1", confirm: true}
+
+	if err := runGuestRestoreWorkflowWithUI(context.Background(), &config.Config{}, newTestLogger(), ui, archivePath); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+
+	if len(ui.prompts) != 1 || !strings.Contains(ui.prompts[0], "VMID 104 is in use on node pve1") || !strings.HasSuffix(ui.prompts[0], "[suggested 106]") {
+		t.Fatalf("prompts = %q", ui.prompts)
+	}
+	plan := strings.Join(ui.messages, "\n")
+	for _, want := range []string{
+		"-> qemu 106 on node pve1 (create)",
+		"vm-104-disk-0 -> vm-106-disk-0",
+		"vm-104-cloudinit -> vm-106-cloudinit",
+		"warning: name also used by lxc 105 (web01) on node pve2",
+	} {
+		if !strings.Contains(plan, want) {
+			t.Fatalf("plan missing %q:\n%s", want, plan)
+		}
+	}
+
+	wantCalls := []string{
+		"pvesh get /nodes/pve1/qemu/106/config",
+		"pvesh create /nodes/pve1/qemu --vmid=106",
+		"pvesh set /nodes/pve1/qemu/106/config --boot=order=scsi0 --name=web01 --scsi0=local-lvm:vm-106-disk-0,size=32G --efidisk0=local:106/vm-106-disk-1.qcow2,size=4M --ide2=local-lvm:vm-106-cloudinit,media=cdrom",
+	}
+	gotCalls := runner.calls[len(runner.calls)-len(wantCalls):]
+	if !reflect.DeepEqual(gotCalls, wantCalls) {
+		t.Fatalf("pvesh calls = %q, want %q", gotCalls, wantCalls)
+	}
+}
+
+func TestGuestRestoreWorkflowKeepsFreeVMIDAndReservesRemaps(t *testing.T) {
+	setupGuestRestoreTest(t, map[string][]byte{
+		"pvesh get /cluster/resources --type vm --output-format=json": []byte(`[{"vmid":104,"type":"qemu","node":"pve1"},{"vmid":300,"type":"qemu","node":"pve2"}]`),
+		"pvesh get /nodes --output-format=json":                       []byte(`[{"node":"pve1","status":"online"}]`),
+		"pvesh get /cluster/nextid --output-format=json":              []byte(`"105"`),
+	}, nil)
+	archivePath := writeGuestTestArchive(t)
+	ui := &fakeGuestRestoreUI{pick: []int{0, 1, 2}, vmids: []string{"", "200", "301"}}
+
+	plans, err := planGuestRestores(context.Background(), newTestLogger(), ui, mustLoadArchivedGuests(t, archivePath, ui.pick), mustListLiveGuests(t), "pve1")
+	if err != nil {
+		t.Fatalf("planGuestRestores: %v", err)
+	}
+	// 104 skipped (empty answer), 200 kept, 300 remapped; "200" was refused for 300
+	// because the restore already reserved it.
+	var got []string
+	for _, p := range plans {
+		got = append(got, p.Guest.VMID+"->"+p.VMID)
+	}
+	if want := []string{"200->200", "300->301"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("plans = %q, want %q (prompts %q)", got, want, ui.prompts)
+	}
+	if len(ui.prompts) != 3 || !strings.Contains(ui.prompts[2], "already used by another guest of this restore") {
+		t.Fatalf("prompts = %q", ui.prompts)
+	}
+}
+
+func TestGuestRestoreWorkflowOverwriteRequiresConfirmation(t *testing.T) {
+	setupGuestRestoreTest(t, map[string][]byte{
+		"pvesh get /cluster/resources --type vm --output-format=json": []byte(`[{"vmid":104,"type":"qemu","name":"web01","node":"pve2"}]`),
+		"pvesh get /nodes --output-format=json":                       []byte(`[{"node":"pve1","status":"online"},{"node":"pve2","status":"online"}]`),
+	}, nil)
+	archivePath := writeGuestTestArchive(t)
+	ui := &fakeGuestRestoreUI{pick: []int{0}, node: "pve1", vmids: []string{"104"}, confirm: true}
+
+	plans, err := planGuestRestores(context.Background(), newTestLogger(), ui, mustLoadArchivedGuests(t, archivePath, ui.pick), mustListLiveGuests(t), "pve1")
+	if err != nil {
+		t.Fatalf("planGuestRestores: %v", err)
+	}
+	if len(plans) != 1 || !plans[0].Overwrite || plans[0].VMID != "104" || plans[0].Node != "pve2" {
+		t.Fatalf("plans = %+v", plans)
+	}
+	if len(ui.confirms) != 1 || !strings.Contains(ui.confirms[0], "Overwrite") {
+		t.Fatalf("confirms = %q", ui.confirms)
+	}
+}
+
+func TestGuestRestoreWorkflowDryRunAppliesNothing(t *testing.T) {
+	runner := setupGuestRestoreTest(t, map[string][]byte{
+		"pvesh get /cluster/resources --type vm --output-format=json": []byte(`[]`),
+		"pvesh get /nodes --output-format=json":                       []byte(`[{"node":"pve1","status":"online"}]`),
+	}, nil)
+	archivePath := writeGuestTestArchive(t)
+	ui := &fakeGuestRestoreUI{pick: []int{0, 1}, confirm: true}
+
+	if err := runGuestRestoreWorkflowWithUI(context.Background(), &config.Config{DryRun: true}, newTestLogger(), ui, archivePath); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+	for _, call := range runner.calls {
+		if strings.HasPrefix(call, "pvesh create") || strings.HasPrefix(call, "pvesh set") {
+			t.Fatalf("dry run ran %q", call)
+		}
+	}
+	if len(ui.confirms) != 0 {
+		t.Fatalf("dry run asked for confirmation: %q", ui.confirms)
+	}
+}
+
+func TestParseMenuSelection(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{in: "all", want: []int{0, 1, 2, 3}},
+		{in: "3,1", want: []int{0, 2}},
+		{in: "2-4 1", want: []int{0, 1, 2, 3}},
+		{in: "2,2", want: []int{1}},
+		{in: "5", wantErr: true},
+		{in: "3-2", wantErr: true},
+		{in: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMenuSelection(tt.in, 4)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseMenuSelection(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseMenuSelection(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func mustLoadArchivedGuests(t *testing.T, archivePath string, pick []int) []ArchivedGuest {
+	t.Helper()
+	guests, err := loadArchivedGuests(context.Background(), archivePath)
+	if err != nil {
+		t.Fatalf("loadArchivedGuests: %v", err)
+	}
+	var out []ArchivedGuest
+	for _, idx := range pick {
+		out = append(out, guests[idx])
+	}
+	return out
+}
+
+func mustListLiveGuests(t *testing.T) []liveGuest {
+	t.Helper()
+	live, err := listLiveGuests(context.Background(), newTestLogger())
+	if err != nil {
+		t.Fatalf("listLiveGuests: %v", err)
+	}
+	return live
+}
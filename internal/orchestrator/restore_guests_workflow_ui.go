@@ -0,0 +1,137 @@
+// Package orchestrator coordinates backup, restore, decrypt, and related workflows.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tis24dev/proxsave/internal/input"
+	"github.com/tis24dev/proxsave/internal/ui/components"
+)
+
+func (u *cliWorkflowUI) SelectGuests(ctx context.Context, guests []ArchivedGuest) ([]ArchivedGuest, error) {
+	for {
+		fmt.Fprintln(u.w(), "\nGuests found in the backup:")
+		for idx, g := range guests {
+			fmt.Fprintf(u.w(), "  [%d] %s\n", idx+1, components.SanitizeLine(g.Display()))
+		}
+		fmt.Fprintln(u.w(), "  [0] Exit")
+
+		fmt.Fprint(u.w(), "Guests to restore (e.g. 1,3 or 2-4; 'all' for every guest): ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "0" {
+			return nil, ErrRestoreAborted
+		}
+		if trimmed == "" {
+			continue
+		}
+		indexes, err := parseMenuSelection(trimmed, len(guests))
+		if err != nil {
+			fmt.Fprintln(u.w(), err)
+			continue
+		}
+		selected := make([]ArchivedGuest, 0, len(indexes))
+		for _, idx := range indexes {
+			selected = append(selected, guests[idx])
+		}
+		return selected, nil
+	}
+}
+
+func (u *cliWorkflowUI) SelectGuestTargetNode(ctx context.Context, nodes []string, defaultNode string) (string, error) {
+	if len(nodes) <= 1 {
+		return defaultNode, nil
+	}
+	for {
+		fmt.Fprintln(u.w(), "\nTarget node for the restored guests:")
+		for idx, node := range nodes {
+			marker := ""
+			if node == defaultNode {
+				marker = " (default)"
+			}
+			fmt.Fprintf(u.w(), "  [%d] %s%s\n", idx+1, components.SanitizeLine(node), marker)
+		}
+		fmt.Fprint(u.w(), "Choice (Enter for default): ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			return defaultNode, nil
+		}
+		idx, err := parseMenuIndex(trimmed, len(nodes))
+		if err != nil {
+			fmt.Fprintln(u.w(), err)
+			continue
+		}
+		return nodes[idx], nil
+	}
+}
+
+func (u *cliWorkflowUI) PromptGuestVMID(ctx context.Context, guest ArchivedGuest, reason, suggested string) (string, error) {
+	fmt.Fprintf(u.w(), "\n%s: %s\n", components.SanitizeLine(guest.Display()), components.SanitizeLine(reason))
+	if suggested != "" {
+		fmt.Fprintf(u.w(), "New VMID (Enter for %s, 'skip' to leave this guest out): ", suggested)
+	} else {
+		fmt.Fprint(u.w(), "New VMID ('skip' to leave this guest out): ")
+	}
+	line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+	if err != nil {
+		return "", err
+	}
+	trimmed := strings.TrimSpace(line)
+	switch strings.ToLower(trimmed) {
+	case "skip", "s":
+		return "", nil
+	case "":
+		if suggested == "" {
+			return "", nil
+		}
+		return suggested, nil
+	}
+	return trimmed, nil
+}
+
+// parseMenuSelection parses a 1-based list of menu entries ("1,3", "2-4", "all")
+// into sorted, de-duplicated 0-based indexes.
+func parseMenuSelection(value string, max int) ([]int, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "all") {
+		indexes := make([]int, max)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+	seen := make(map[int]bool)
+	var indexes []int
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		first, last := part, part
+		if lo, hi, ok := strings.Cut(part, "-"); ok {
+			first, last = lo, hi
+		}
+		lo, errLo := strconv.Atoi(first)
+		hi, errHi := strconv.Atoi(last)
+		if errLo != nil || errHi != nil || lo < 1 || hi > max || lo > hi {
+			return nil, fmt.Errorf("invalid selection %q: use values between 1 and %d", part, max)
+		}
+		for i := lo; i <= hi; i++ {
+			if !seen[i-1] {
+				seen[i-1] = true
+				indexes = append(indexes, i-1)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("please select at least one entry")
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
@@ -12,6 +12,10 @@ type RestorePlan struct {
 	ClusterSafeMode     bool
 	NeedsClusterRestore bool
 	NeedsPBSServices    bool
+
+	// StorageDecisions is filled by the storage validation pass before the staged
+	// apply; nil applies every storage.cfg/datastore.cfg entry as-is.
+	StorageDecisions *StorageDecisions
 }
 
 // PlanRestore computes the restore plan without performing any I/O or prompts.
@@ -0,0 +1,279 @@
+// Package orchestrator coordinates backup, restore, decrypt, and related workflows.
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// ErrNoSafetyBackups is returned by the rollback workflow when no safety backup is
+// available in the safety backup directory.
+var ErrNoSafetyBackups = errors.New("no safety backups found")
+
+// RestoreRollbackUI groups prompts used by the restore rollback workflow.
+type RestoreRollbackUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	SelectSafetyBackup(ctx context.Context, backups []SafetyBackupInfo) (SafetyBackupInfo, error)
+	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
+}
+
+// Test seams for the service handling around a rollback.
+var (
+	rollbackDestRoot           = "/"
+	rollbackStopPVEServicesFn  = stopPVEClusterServices
+	rollbackStartPVEServicesFn = startPVEClusterServices
+	rollbackUnmountEtcPVEFn    = unmountEtcPVE
+	rollbackStopPBSServicesFn  = stopPBSServices
+	rollbackStartPBSServicesFn = startPBSServices
+)
+
+// restoreRollbackServicePlan records which service groups must be stopped while a
+// safety backup is extracted, mirroring the decisions made by the restore plan.
+type restoreRollbackServicePlan struct {
+	StopPVECluster bool
+	StopPBS        bool
+}
+
+func planRestoreRollbackServices(systemType SystemType, categories []Category) restoreRollbackServicePlan {
+	return restoreRollbackServicePlan{
+		StopPVECluster: systemType.SupportsPVE() && hasCategoryID(categories, "pve_cluster"),
+		StopPBS:        systemType.SupportsPBS() && shouldStopPBSServices(categories),
+	}
+}
+
+func (p restoreRollbackServicePlan) describe() string {
+	var parts []string
+	if p.StopPVECluster {
+		parts = append(parts, "stop PVE cluster services and unmount /etc/pve")
+	}
+	if p.StopPBS {
+		parts = append(parts, "stop proxmox-backup services")
+	}
+	if len(parts) == 0 {
+		return "No services need to be stopped."
+	}
+	return "Services: " + strings.Join(parts, ", ") + "; they are restarted after the rollback."
+}
+
+// SafetyBackupRetentionFromConfig builds the retention policy for safety backups.
+func SafetyBackupRetentionFromConfig(cfg *config.Config) SafetyBackupRetention {
+	if cfg == nil {
+		return SafetyBackupRetention{}
+	}
+	return SafetyBackupRetention{
+		Keep:   cfg.SafetyBackupKeep,
+		MaxAge: time.Duration(cfg.SafetyBackupMaxAgeDays) * 24 * time.Hour,
+	}
+}
+
+// RunRestoreRollbackWorkflow lists the safety backups taken by previous restores and
+// rolls the system back to the selected one, using stdin prompts.
+func RunRestoreRollbackWorkflow(ctx context.Context, cfg *config.Config, logger *logging.Logger) (err error) {
+	if cfg == nil {
+		return fmt.Errorf("configuration not available")
+	}
+	if logger == nil {
+		logger = logging.GetDefaultLogger()
+	}
+	done := logging.DebugStart(logger, "restore rollback workflow (cli)", "dry_run=%v", cfg.DryRun)
+	defer func() { done(err) }()
+
+	ui := newCLIWorkflowUI(bufio.NewReader(os.Stdin), logger)
+	return runRestoreRollbackWorkflowWithUI(ctx, cfg, logger, ui)
+}
+
+func runRestoreRollbackWorkflowWithUI(ctx context.Context, cfg *config.Config, logger *logging.Logger, ui RestoreRollbackUI) error {
+	backups, err := ListSafetyBackups(logger)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("%w in %s", ErrNoSafetyBackups, safetyBackupDir)
+	}
+
+	selected, err := ui.SelectSafetyBackup(ctx, backups)
+	if err != nil {
+		return err
+	}
+	logger.Info("Selected safety backup: %s", selected.Path)
+
+	entries, err := ListSafetyBackupEntries(selected.Path)
+	if err != nil {
+		return fmt.Errorf("inspect safety backup: %w", err)
+	}
+	if len(selected.Categories) == 0 {
+		selected.Categories = inferSafetyBackupCategories(entries)
+	}
+
+	servicePlan := planRestoreRollbackServices(restoreSystem.DetectCurrentSystem(), selected.Categories)
+	if err := ui.ShowMessage(ctx, "Safety backup contents", describeSafetyBackupContents(selected, entries)+"\n\n"+servicePlan.describe()); err != nil {
+		return err
+	}
+
+	if cfg.DryRun {
+		logger.Info("Dry run: no files were restored from %s", selected.Path)
+		return nil
+	}
+
+	confirmed, err := ui.ConfirmAction(ctx,
+		"Roll back to this safety backup?",
+		fmt.Sprintf("%d file(s) under / will be overwritten with the content captured at %s.", countSafetyBackupFiles(entries), selected.Timestamp.Format("2006-01-02 15:04:05")),
+		"Roll back", "Cancel", 0, false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return ErrRestoreAborted
+	}
+
+	if err := createPreRollbackSnapshot(ctx, logger, ui, selected); err != nil {
+		return err
+	}
+
+	restart, err := stopRollbackServices(ctx, logger, ui, servicePlan)
+	if restart != nil {
+		defer restart()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := RestoreSafetyBackup(logger, selected.Path, rollbackDestRoot); err != nil {
+		return fmt.Errorf("restore safety backup: %w", err)
+	}
+	logger.Info("Rollback to %s completed", selected.Path)
+
+	if _, err := PruneSafetyBackups(logger, SafetyBackupRetentionFromConfig(cfg)); err != nil {
+		logger.Warning("Safety backup retention: %v", err)
+	}
+	return nil
+}
+
+// createPreRollbackSnapshot captures the current state of the paths about to be
+// overwritten, so a rollback can itself be reverted with another --restore-rollback.
+func createPreRollbackSnapshot(ctx context.Context, logger *logging.Logger, ui RestoreRollbackUI, selected SafetyBackupInfo) error {
+	if len(selected.Categories) == 0 {
+		return nil
+	}
+	snapshot, err := createSafetyBackup(logger, selected.Categories, rollbackDestRoot, safetyBackupSpec{
+		ArchivePrefix:    "pre_rollback_backup",
+		HumanDescription: "Pre-rollback snapshot",
+	})
+	if err == nil {
+		logger.Info("Pre-rollback snapshot saved to %s", snapshot.BackupPath)
+		return nil
+	}
+	logger.Warning("Failed to create pre-rollback snapshot: %v", err)
+	cont, promptErr := ui.ConfirmAction(ctx,
+		"Continue without a pre-rollback snapshot?",
+		fmt.Sprintf("The current configuration could not be saved (%v). The rollback will not be revertible.", err),
+		"Continue", "Abort", 0, false)
+	if promptErr != nil {
+		return promptErr
+	}
+	if !cont {
+		return ErrRestoreAborted
+	}
+	return nil
+}
+
+// stopRollbackServices stops the services required by the plan and returns a
+// cleanup that restarts them; the cleanup is non-nil whenever something was stopped.
+func stopRollbackServices(ctx context.Context, logger *logging.Logger, ui RestoreRollbackUI, plan restoreRollbackServicePlan) (func(), error) {
+	var restarts []func(context.Context)
+	restart := func() {
+		restartCtx, cancel := context.WithTimeout(context.Background(), 2*serviceStartTimeout+2*serviceVerifyTimeout+10*time.Second)
+		defer cancel()
+		for i := len(restarts) - 1; i >= 0; i-- {
+			restarts[i](restartCtx)
+		}
+	}
+
+	if plan.StopPVECluster {
+		logger.Info("Stopping PVE services and unmounting /etc/pve for the cluster database rollback")
+		// Registered before stopping: a partial stop must still bring the services back.
+		restarts = append(restarts, func(rctx context.Context) {
+			if err := rollbackStartPVEServicesFn(rctx, logger); err != nil {
+				logger.Warning("Failed to restart PVE services after rollback: %v", err)
+			}
+		})
+		if err := rollbackStopPVEServicesFn(ctx, logger); err != nil {
+			return restart, err
+		}
+		if err := rollbackUnmountEtcPVEFn(ctx, logger); err != nil {
+			logger.Warning("Could not unmount /etc/pve: %v", err)
+		}
+	}
+
+	if plan.StopPBS {
+		logger.Info("Stopping proxmox-backup services for the rollback")
+		if err := rollbackStopPBSServicesFn(ctx, logger); err != nil {
+			logger.Warning("Unable to stop PBS services automatically: %v", err)
+			cont, promptErr := ui.ConfirmAction(ctx,
+				"Continue with PBS services running?",
+				"The proxmox-backup services could not be stopped. Restoring their configuration while they run may leave them out of sync until restarted.",
+				"Continue", "Abort", 0, false)
+			if promptErr != nil {
+				return restart, promptErr
+			}
+			if !cont {
+				return restart, ErrRestoreAborted
+			}
+		} else {
+			restarts = append(restarts, func(rctx context.Context) {
+				if err := rollbackStartPBSServicesFn(rctx, logger); err != nil {
+					logger.Warning("Failed to restart PBS services after rollback: %v", err)
+				}
+			})
+		}
+	}
+
+	if len(restarts) == 0 {
+		return nil, nil
+	}
+	return restart, nil
+}
+
+func describeSafetyBackupContents(b SafetyBackupInfo, entries []SafetyBackupEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\nCreated: %s\nArchive: %s\n", b.Description, b.Timestamp.Format("2006-01-02 15:04:05"), b.Path)
+	if len(b.Categories) > 0 {
+		names := make([]string, 0, len(b.Categories))
+		for _, cat := range b.Categories {
+			names = append(names, cat.Name)
+		}
+		fmt.Fprintf(&sb, "Categories: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "Files: %d\n", countSafetyBackupFiles(entries))
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir:
+			continue
+		case entry.Symlink != "":
+			fmt.Fprintf(&sb, "  /%s -> %s\n", strings.TrimPrefix(entry.Name, "./"), entry.Symlink)
+		default:
+			fmt.Fprintf(&sb, "  /%s (%d bytes)\n", strings.TrimPrefix(entry.Name, "./"), entry.Size)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatSafetyBackupChoice(b SafetyBackupInfo) string {
+	var names []string
+	for _, cat := range b.Categories {
+		names = append(names, cat.Name)
+	}
+	cats := "unknown categories"
+	if len(names) > 0 {
+		cats = strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s • %s • %s • %.2f MB", b.Timestamp.Format("2006-01-02 15:04:05"), b.Description, cats, float64(b.Size)/(1024*1024))
+}
@@ -0,0 +1,219 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+type fakeRestoreRollbackUI struct {
+	selectIndex int
+	confirm     bool
+	messages    []string
+	confirms    []string
+}
+
+func (f *fakeRestoreRollbackUI) ShowMessage(ctx context.Context, title, message string) error {
+	f.messages = append(f.messages, title+"\n"+message)
+	return nil
+}
+
+func (f *fakeRestoreRollbackUI) SelectSafetyBackup(ctx context.Context, backups []SafetyBackupInfo) (SafetyBackupInfo, error) {
+	if f.selectIndex < 0 {
+		return SafetyBackupInfo{}, ErrRestoreAborted
+	}
+	return backups[f.selectIndex], nil
+}
+
+func (f *fakeRestoreRollbackUI) ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error) {
+	f.confirms = append(f.confirms, title)
+	return f.confirm, nil
+}
+
+func setupRestoreRollbackTest(t *testing.T, system SystemType) *FakeFS {
+	t.Helper()
+	fake := setupSafetyCatalogFS(t, time.Date(2024, time.March, 2, 10, 0, 0, 0, time.Local))
+	origDest := rollbackDestRoot
+	rollbackDestRoot = "/target"
+	origSystem := restoreSystem
+	restoreSystem = fakeSystemDetector{systemType: system}
+	t.Cleanup(func() {
+		rollbackDestRoot = origDest
+		restoreSystem = origSystem
+	})
+	return fake
+}
+
+func TestPlanRestoreRollbackServices(t *testing.T) {
+	all := GetAllCategories()
+	pick := func(ids ...string) []Category {
+		var out []Category
+		for _, id := range ids {
+			if cat := GetCategoryByID(id, all); cat != nil {
+				out = append(out, *cat)
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		system SystemType
+		cats   []Category
+		want   restoreRollbackServicePlan
+	}{
+		{"pve cluster db", SystemTypePVE, pick("pve_cluster"), restoreRollbackServicePlan{StopPVECluster: true}},
+		{"pve firewall only", SystemTypePVE, pick("pve_firewall"), restoreRollbackServicePlan{}},
+		{"pbs datastore config", SystemTypePBS, pick("datastore_pbs"), restoreRollbackServicePlan{StopPBS: true}},
+		{"pbs categories on pve host", SystemTypePVE, pick("datastore_pbs"), restoreRollbackServicePlan{}},
+		{"network only", SystemTypeDual, pick("network"), restoreRollbackServicePlan{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planRestoreRollbackServices(tt.system, tt.cats); got != tt.want {
+				t.Fatalf("plan = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreRollbackWorkflowRestoresSelectedBackup(t *testing.T) {
+	fake := setupRestoreRollbackTest(t, SystemTypePVE)
+	writeTestSafetyArchive(t, fake, "network_rollback_backup_20240301_150405.tar.gz", map[string]string{
+		"etc/hosts": "original\n",
+	})
+	if err := fake.AddFile("/target/etc/hosts", []byte("changed by restore\n")); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+
+	ui := &fakeRestoreRollbackUI{confirm: true}
+	logger := logging.New(types.LogLevelError, false)
+	if err := runRestoreRollbackWorkflowWithUI(context.Background(), &config.Config{}, logger, ui); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+
+	data, err := os.ReadFile(fake.onDisk("/target/etc/hosts"))
+	if err != nil {
+		t.Fatalf("read restored file: %v", err)
+	}
+	if string(data) != "original\n" {
+		t.Fatalf("restored content = %q", data)
+	}
+	if len(ui.messages) == 0 || !strings.Contains(ui.messages[0], "/etc/hosts") {
+		t.Fatalf("expected contents listing, got %v", ui.messages)
+	}
+
+	backups, err := ListSafetyBackups(logger)
+	if err != nil {
+		t.Fatalf("ListSafetyBackups: %v", err)
+	}
+	var snapshot *SafetyBackupInfo
+	for i := range backups {
+		if backups[i].Kind == "pre_rollback_backup" {
+			snapshot = &backups[i]
+		}
+	}
+	if snapshot == nil {
+		t.Fatalf("expected a pre-rollback snapshot, got %+v", backups)
+	}
+	entries, err := ListSafetyBackupEntries(snapshot.Path)
+	if err != nil {
+		t.Fatalf("ListSafetyBackupEntries: %v", err)
+	}
+	found := false
+	for _, e := range entries {
+		if filepath.Clean(e.Name) == "etc/hosts" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("pre-rollback snapshot should contain etc/hosts, got %+v", entries)
+	}
+}
+
+func TestRestoreRollbackWorkflowDryRunLeavesFilesUntouched(t *testing.T) {
+	fake := setupRestoreRollbackTest(t, SystemTypePVE)
+	writeTestSafetyArchive(t, fake, "restore_backup_20240301_150405.tar.gz", map[string]string{
+		"etc/hosts": "original\n",
+	})
+	if err := fake.AddFile("/target/etc/hosts", []byte("current\n")); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+
+	ui := &fakeRestoreRollbackUI{confirm: true}
+	if err := runRestoreRollbackWorkflowWithUI(context.Background(), &config.Config{DryRun: true}, logging.New(types.LogLevelError, false), ui); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	data, _ := os.ReadFile(fake.onDisk("/target/etc/hosts"))
+	if string(data) != "current\n" {
+		t.Fatalf("dry run modified file: %q", data)
+	}
+	if len(ui.confirms) != 0 {
+		t.Fatalf("dry run should not ask for confirmation, got %v", ui.confirms)
+	}
+}
+
+func TestRestoreRollbackWorkflowDeclined(t *testing.T) {
+	fake := setupRestoreRollbackTest(t, SystemTypePVE)
+	writeTestSafetyArchive(t, fake, "restore_backup_20240301_150405.tar.gz", map[string]string{
+		"etc/hosts": "original\n",
+	})
+
+	ui := &fakeRestoreRollbackUI{confirm: false}
+	err := runRestoreRollbackWorkflowWithUI(context.Background(), &config.Config{}, logging.New(types.LogLevelError, false), ui)
+	if !errors.Is(err, ErrRestoreAborted) {
+		t.Fatalf("err = %v, want ErrRestoreAborted", err)
+	}
+}
+
+func TestRestoreRollbackWorkflowNoBackups(t *testing.T) {
+	setupRestoreRollbackTest(t, SystemTypePVE)
+	err := runRestoreRollbackWorkflowWithUI(context.Background(), &config.Config{}, logging.New(types.LogLevelError, false), &fakeRestoreRollbackUI{})
+	if !errors.Is(err, ErrNoSafetyBackups) {
+		t.Fatalf("err = %v, want ErrNoSafetyBackups", err)
+	}
+}
+
+func TestStopRollbackServicesRestartsInReverseOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) func(context.Context, *logging.Logger) error {
+		return func(context.Context, *logging.Logger) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	origStopPVE, origStartPVE, origUnmount := rollbackStopPVEServicesFn, rollbackStartPVEServicesFn, rollbackUnmountEtcPVEFn
+	origStopPBS, origStartPBS := rollbackStopPBSServicesFn, rollbackStartPBSServicesFn
+	t.Cleanup(func() {
+		rollbackStopPVEServicesFn, rollbackStartPVEServicesFn, rollbackUnmountEtcPVEFn = origStopPVE, origStartPVE, origUnmount
+		rollbackStopPBSServicesFn, rollbackStartPBSServicesFn = origStopPBS, origStartPBS
+	})
+	rollbackStopPVEServicesFn = record("stop-pve")
+	rollbackStartPVEServicesFn = record("start-pve")
+	rollbackUnmountEtcPVEFn = record("unmount")
+	rollbackStopPBSServicesFn = record("stop-pbs")
+	rollbackStartPBSServicesFn = record("start-pbs")
+
+	restart, err := stopRollbackServices(context.Background(), logging.New(types.LogLevelError, false), &fakeRestoreRollbackUI{},
+		restoreRollbackServicePlan{StopPVECluster: true, StopPBS: true})
+	if err != nil {
+		t.Fatalf("stopRollbackServices: %v", err)
+	}
+	if restart == nil {
+		t.Fatal("expected a restart cleanup")
+	}
+	restart()
+
+	want := []string{"stop-pve", "unmount", "stop-pbs", "start-pbs", "start-pve"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
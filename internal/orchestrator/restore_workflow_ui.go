@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/tis24dev/proxsave/internal/backup"
@@ -39,9 +40,60 @@ func prepareRestoreBundleWithUI(ctx context.Context, cfg *config.Config, logger
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := enforceManifestSignaturePolicy(cfg, logger, prepared); err != nil {
+		prepared.Cleanup()
+		return nil, nil, err
+	}
 	return candidate, prepared, nil
 }
 
+// prepareRestoreBundleFromPath is the non-interactive prepareRestoreBundleWithUI
+// for a backup named by path (its archive, .metadata or bundle): the backup is
+// staged, checked and decrypted the same way and must pass the manifest
+// signature policy. An encrypted archive is only decrypted through identity, an
+// identity source (systemd-creds:, unlock-file:, plugin:); nothing is prompted.
+func prepareRestoreBundleFromPath(ctx context.Context, cfg *config.Config, logger *logging.Logger, version, path, identity string) (*preparedBundle, error) {
+	path = filepath.Clean(strings.TrimSpace(path))
+	candidates, err := discoverBackupCandidates(logger, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	var candidate *backupCandidate
+	for _, cand := range candidates {
+		if path == cand.RawArchivePath || path == cand.RawMetadataPath || path == cand.BundlePath {
+			candidate = cand
+			break
+		}
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("%s is not a backup with a readable manifest", path)
+	}
+
+	prepared, err := preparePlainBundleWithUI(ctx, candidate, version, logger, identitySourceSecret(identity), fsIoTimeoutFromConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	if err := enforceManifestSignaturePolicy(cfg, logger, prepared); err != nil {
+		prepared.Cleanup()
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// identitySourceSecret answers the decrypt prompt once with a preset identity
+// source and fails instead of asking again.
+type identitySourceSecret string
+
+func (s identitySourceSecret) PromptDecryptSecret(_ context.Context, displayName, previousError string) (string, error) {
+	if previousError != "" {
+		return "", fmt.Errorf("decrypt %s: %s", displayName, previousError)
+	}
+	if strings.TrimSpace(string(s)) == "" {
+		return "", fmt.Errorf("%s is encrypted and no decryption identity source is configured", displayName)
+	}
+	return string(s), nil
+}
+
 func runRestoreWorkflowWithUI(ctx context.Context, cfg *config.Config, logger *logging.Logger, version string, ui RestoreWorkflowUI) (err error) {
 	ClearRestoreAbortInfo()
 	if cfg == nil {
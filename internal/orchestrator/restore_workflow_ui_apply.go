@@ -10,7 +10,22 @@ import (
 )
 
 func (w *restoreUIWorkflowRun) applyAccessControlFromStage() error {
-	return maybeApplyAccessControlWithUI(w.ctx, w.ui, w.logger, w.plan, w.safetyBackup, w.accessControlRollbackBackup, w.stageRoot, w.cfg.DryRun)
+	w.accessControlApplyErr = maybeApplyAccessControlWithUI(w.ctx, w.ui, w.logger, w.plan, w.safetyBackup, w.accessControlRollbackBackup, w.stageRoot, w.cfg.DryRun)
+	return w.accessControlApplyErr
+}
+
+// reviewAccessControlAfterApply runs the token/realm/TFA review once access control
+// was applied; a failed or uncommitted apply (rollback pending) is not reviewed.
+func (w *restoreUIWorkflowRun) reviewAccessControlAfterApply() error {
+	if w.accessControlApplyErr != nil {
+		logging.DebugStep(w.logger, "access control review", "Skipped: access control apply did not complete")
+		return nil
+	}
+	recipients, err := accessControlReportRecipients(w.cfg)
+	if err != nil {
+		w.logger.Warning("Access control review: %v", err)
+	}
+	return maybeReviewAccessControlWithUI(w.ctx, w.ui, w.logger, w.plan, w.stageRoot, recipients, w.cfg.DryRun)
 }
 
 func (w *restoreUIWorkflowRun) logAccessControlNotCommitted(err error) {
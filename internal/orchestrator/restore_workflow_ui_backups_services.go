@@ -23,9 +23,18 @@ func (w *restoreUIWorkflowRun) createRollbackBackups() error {
 	w.createFirewallRollbackBackup(systemWriteCategories)
 	w.createHARollbackBackup(systemWriteCategories)
 	w.createAccessControlRollbackBackup(systemWriteCategories)
+	w.pruneSafetyBackups()
 	return nil
 }
 
+// pruneSafetyBackups applies SAFETY_BACKUP_KEEP / SAFETY_BACKUP_MAX_AGE_DAYS once the
+// snapshots for this restore exist, so the newest ones are never the ones removed.
+func (w *restoreUIWorkflowRun) pruneSafetyBackups() {
+	if _, err := PruneSafetyBackups(w.logger, SafetyBackupRetentionFromConfig(w.cfg)); err != nil {
+		w.logger.Warning("Safety backup retention: %v", err)
+	}
+}
+
 func (w *restoreUIWorkflowRun) createSafetyBackup(categories []Category) error {
 	if len(categories) == 0 {
 		return nil
@@ -300,12 +300,19 @@ func (w *restoreUIWorkflowRun) applyStagedCategories() error {
 	}
 	w.logger.Info("")
 	steps := []restoreStageApplyStep{
+		{name: "PBS datastore re-attach", run: func() error {
+			return maybeReattachPBSDatastoresWithUI(w.ctx, w.ui, w.logger, w.plan, w.stageRoot, w.cfg.DryRun)
+		}},
+		{name: "Storage validation", run: func() error {
+			return validateStagedStorageWithUI(w.ctx, w.ui, w.logger, w.plan, w.stageRoot, w.cfg.DryRun)
+		}},
 		{name: "PBS staged config apply", run: func() error { return maybeApplyPBSConfigsFromStage(w.ctx, w.logger, w.plan, w.stageRoot, w.cfg.DryRun) }},
 		{name: "PVE staged config apply", run: func() error {
 			return maybeApplyPVEConfigsFromStage(w.ctx, w.logger, w.plan, w.stageRoot, w.destRoot, w.cfg.DryRun)
 		}},
 		{name: "PVE SDN staged apply", run: func() error { return maybeApplyPVESDNFromStage(w.ctx, w.logger, w.plan, w.stageRoot, w.cfg.DryRun) }},
 		{name: "Access control staged apply", run: w.applyAccessControlFromStage},
+		{name: "Access control review", run: w.reviewAccessControlAfterApply},
 		{name: "System accounts staged apply", run: func() error {
 			return maybeApplyAccountsFromStage(w.ctx, w.logger, w.plan, w.stageRoot, w.cfg.DryRun)
 		}},
@@ -22,6 +22,9 @@ type fakeRestoreWorkflowUI struct {
 	applyDatacenterCfg  bool
 	confirmAction       bool
 	networkCommit       bool
+	firewallMode        FirewallRestoreMode
+	firewallAccept      func([]firewallChange) []firewallChange
+	regenerateTokens    func([]restoredAPIToken) []restoredAPIToken
 
 	modeErr                error
 	categoriesErr          error
@@ -133,6 +136,27 @@ func (f *fakeRestoreWorkflowUI) ConfirmApplyDatacenterCfg(ctx context.Context, d
 	return f.applyDatacenterCfg, nil
 }
 
+func (f *fakeRestoreWorkflowUI) SelectFirewallRestoreMode(ctx context.Context) (FirewallRestoreMode, error) {
+	if f.firewallMode == "" {
+		return FirewallRestoreReplace, nil
+	}
+	return f.firewallMode, nil
+}
+
+func (f *fakeRestoreWorkflowUI) ReviewFirewallChanges(ctx context.Context, changes []firewallChange) ([]firewallChange, error) {
+	if f.firewallAccept != nil {
+		return f.firewallAccept(changes), nil
+	}
+	return defaultFirewallChanges(changes), nil
+}
+
+func (f *fakeRestoreWorkflowUI) SelectAccessTokensToRegenerate(ctx context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error) {
+	if f.regenerateTokens != nil {
+		return f.regenerateTokens(tokens), nil
+	}
+	return nil, nil
+}
+
 func (f *fakeRestoreWorkflowUI) ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error) {
 	f.confirmMessages = append(f.confirmMessages, message)
 	return f.confirmAction, f.confirmActionErr
@@ -14,6 +14,9 @@ func (w *restoreUIWorkflowRun) prepareBundleAndPlan() (fallbackToFullRestore boo
 	if err := w.prepareBundle(); err != nil {
 		return false, err
 	}
+	if err := w.combineWithClusterArchive(); err != nil {
+		return false, err
+	}
 
 	fallbackToFullRestore, err = w.planPreparedBundle()
 	if err != nil {
@@ -28,6 +28,7 @@ type restoreUIWorkflowRun struct {
 	firewallRollbackBackup      *SafetyBackupResult
 	haRollbackBackup            *SafetyBackupResult
 	accessControlRollbackBackup *SafetyBackupResult
+	accessControlApplyErr       error
 	stageLogPath                string
 	stageRoot                   string
 	stageRootForNetworkApply    string
@@ -0,0 +1,320 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// safetyBackupDir is where createSafetyBackup writes its archives and the
+// catalog sidecars; ListSafetyBackups and PruneSafetyBackups scan the same place.
+var safetyBackupDir = filepath.Join("/tmp", "proxsave")
+
+const (
+	safetyBackupArchiveSuffix = ".tar.gz"
+	safetyBackupSidecarSuffix = ".json"
+	safetyBackupTimeLayout    = "20060102_150405"
+)
+
+// safetyBackupKinds lists the archive prefixes written by createSafetyBackup, in the
+// order they are presented to the user. The rollback prefix is the snapshot taken by
+// the rollback command itself, so a rollback can in turn be reverted.
+var safetyBackupKinds = []struct {
+	Prefix      string
+	Description string
+}{
+	{"restore_backup", "Safety backup"},
+	{"network_rollback_backup", "Network rollback backup"},
+	{"firewall_rollback_backup", "Firewall rollback backup"},
+	{"ha_rollback_backup", "HA rollback backup"},
+	{"pve_access_control_rollback_backup", "PVE access control rollback backup"},
+	{"cluster_rebuild_backup", "Cluster rebuild backup"},
+	{"pre_rollback_backup", "Pre-rollback snapshot"},
+}
+
+// SafetyBackupInfo describes a safety backup archive found on disk.
+type SafetyBackupInfo struct {
+	Path        string
+	Kind        string
+	Description string
+	Timestamp   time.Time
+	Size        int64
+	Files       int
+	Categories  []Category
+}
+
+// CategoryIDs returns the IDs of the categories covered by the backup.
+func (b SafetyBackupInfo) CategoryIDs() []string {
+	ids := make([]string, 0, len(b.Categories))
+	for _, cat := range b.Categories {
+		ids = append(ids, cat.ID)
+	}
+	return ids
+}
+
+// SafetyBackupRetention controls how many safety backups are kept on disk.
+// Keep is applied per kind (restore, network, firewall, ...); zero disables the
+// count limit. MaxAge removes archives older than the given age; zero disables it.
+type SafetyBackupRetention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// safetyBackupSidecar is the JSON document stored next to each safety archive so the
+// catalog can report categories without re-reading the whole tarball.
+type safetyBackupSidecar struct {
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	Categories  []string  `json:"categories"`
+	Files       int       `json:"files"`
+	TotalSize   int64     `json:"total_size"`
+}
+
+func safetyBackupSidecarPath(archivePath string) string {
+	return strings.TrimSuffix(archivePath, safetyBackupArchiveSuffix) + safetyBackupSidecarSuffix
+}
+
+func writeSafetyBackupSidecar(archivePath, kind, description string, categories []Category, result *SafetyBackupResult) error {
+	ids := make([]string, 0, len(categories))
+	for _, cat := range categories {
+		ids = append(ids, cat.ID)
+	}
+	data, err := json.MarshalIndent(safetyBackupSidecar{
+		Kind:        kind,
+		Description: description,
+		CreatedAt:   result.Timestamp,
+		Categories:  ids,
+		Files:       result.FilesBackedUp,
+		TotalSize:   result.TotalSize,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safetyFS.WriteFile(safetyBackupSidecarPath(archivePath), append(data, '\n'), 0o600)
+}
+
+// parseSafetyBackupName splits "<prefix>_<timestamp>.tar.gz" into its known kind and
+// creation time. Unknown prefixes are rejected so unrelated files in the shared
+// directory are never listed or pruned.
+func parseSafetyBackupName(name string) (kind, description string, ts time.Time, ok bool) {
+	if !strings.HasSuffix(name, safetyBackupArchiveSuffix) {
+		return "", "", time.Time{}, false
+	}
+	base := strings.TrimSuffix(name, safetyBackupArchiveSuffix)
+	for _, k := range safetyBackupKinds {
+		rest, found := strings.CutPrefix(base, k.Prefix+"_")
+		if !found {
+			continue
+		}
+		parsed, err := time.ParseInLocation(safetyBackupTimeLayout, rest, time.Local)
+		if err != nil {
+			continue
+		}
+		return k.Prefix, k.Description, parsed, true
+	}
+	return "", "", time.Time{}, false
+}
+
+// ListSafetyBackups returns the safety backups found in the safety backup directory,
+// newest first. Categories come from the sidecar when present, otherwise they are
+// inferred from the archive entries.
+func ListSafetyBackups(logger *logging.Logger) ([]SafetyBackupInfo, error) {
+	entries, err := safetyFS.ReadDir(safetyBackupDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read safety backup directory: %w", err)
+	}
+
+	var backups []SafetyBackupInfo
+	for _, entry := range entries {
+		if entry == nil || entry.IsDir() {
+			continue
+		}
+		kind, desc, ts, ok := parseSafetyBackupName(entry.Name())
+		if !ok {
+			continue
+		}
+		info := SafetyBackupInfo{
+			Path:        filepath.Join(safetyBackupDir, entry.Name()),
+			Kind:        kind,
+			Description: desc,
+			Timestamp:   ts,
+		}
+		if fi, err := entry.Info(); err == nil {
+			info.Size = fi.Size()
+		}
+		if err := loadSafetyBackupSidecar(&info); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.Debug("Ignoring unreadable sidecar for %s: %v", info.Path, err)
+			}
+			if entries, err := ListSafetyBackupEntries(info.Path); err == nil {
+				info.Files = countSafetyBackupFiles(entries)
+				info.Categories = inferSafetyBackupCategories(entries)
+			} else {
+				logger.Debug("Cannot inspect safety backup %s: %v", info.Path, err)
+			}
+		}
+		backups = append(backups, info)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].Timestamp.Equal(backups[j].Timestamp) {
+			return backups[i].Path > backups[j].Path
+		}
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+	return backups, nil
+}
+
+func loadSafetyBackupSidecar(info *SafetyBackupInfo) error {
+	data, err := safetyFS.ReadFile(safetyBackupSidecarPath(info.Path))
+	if err != nil {
+		return err
+	}
+	var sidecar safetyBackupSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return err
+	}
+	if strings.TrimSpace(sidecar.Description) != "" {
+		info.Description = sidecar.Description
+	}
+	if !sidecar.CreatedAt.IsZero() {
+		info.Timestamp = sidecar.CreatedAt
+	}
+	info.Files = sidecar.Files
+	all := GetAllCategories()
+	for _, id := range sidecar.Categories {
+		if cat := GetCategoryByID(id, all); cat != nil {
+			info.Categories = append(info.Categories, *cat)
+		} else {
+			info.Categories = append(info.Categories, Category{ID: id, Name: id})
+		}
+	}
+	return nil
+}
+
+// SafetyBackupEntry is a single member of a safety backup archive.
+type SafetyBackupEntry struct {
+	Name    string
+	Size    int64
+	IsDir   bool
+	Symlink string
+}
+
+// ListSafetyBackupEntries reads the tar members of a safety backup without extracting it.
+func ListSafetyBackupEntries(backupPath string) (entries []SafetyBackupEntry, err error) {
+	file, err := safetyFS.Open(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("open backup: %w", err)
+	}
+	defer closeIntoErr(&err, file, "close backup archive")
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer closeIntoErr(&err, gzReader, "close gzip reader")
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar entry: %w", err)
+		}
+		entry := SafetyBackupEntry{Name: header.Name, Size: header.Size}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entry.IsDir = true
+			entry.Size = 0
+		case tar.TypeSymlink:
+			entry.Symlink = header.Linkname
+			entry.Size = 0
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func countSafetyBackupFiles(entries []SafetyBackupEntry) int {
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir && entry.Symlink == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// inferSafetyBackupCategories maps archive entries back to restore categories, for
+// archives written before sidecars existed.
+func inferSafetyBackupCategories(entries []SafetyBackupEntry) []Category {
+	var out []Category
+	for _, cat := range GetAllCategories() {
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name, "/")
+			if PathMatchesCategory(name, cat) {
+				out = append(out, cat)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// PruneSafetyBackups applies the retention policy to the safety backup directory,
+// removing archives (and their sidecars) beyond the per-kind count or past MaxAge.
+// The newest archive of each kind is always kept so the last restore stays revertible.
+func PruneSafetyBackups(logger *logging.Logger, retention SafetyBackupRetention) (removed int, err error) {
+	if retention.Keep <= 0 && retention.MaxAge <= 0 {
+		return 0, nil
+	}
+	backups, err := ListSafetyBackups(logger)
+	if err != nil {
+		return 0, err
+	}
+
+	now := safetyNow()
+	seen := make(map[string]int)
+	for _, b := range backups {
+		seen[b.Kind]++
+		position := seen[b.Kind]
+		if position == 1 {
+			continue
+		}
+		expired := retention.MaxAge > 0 && now.Sub(b.Timestamp) > retention.MaxAge
+		overCount := retention.Keep > 0 && position > retention.Keep
+		if !expired && !overCount {
+			continue
+		}
+		if err := safetyFS.Remove(b.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warning("Cannot remove old safety backup %s: %v", b.Path, err)
+			continue
+		}
+		if err := safetyFS.Remove(safetyBackupSidecarPath(b.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Debug("Cannot remove safety backup sidecar for %s: %v", b.Path, err)
+		}
+		logger.Debug("Removed old safety backup: %s", b.Path)
+		removed++
+	}
+
+	if removed > 0 {
+		logger.Info("Pruned %d old safety backup(s) from %s", removed, safetyBackupDir)
+	}
+	return removed, nil
+}
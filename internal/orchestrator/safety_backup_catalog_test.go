@@ -0,0 +1,226 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func setupSafetyCatalogFS(t *testing.T, now time.Time) *FakeFS {
+	t.Helper()
+	fake := NewFakeFS()
+	t.Cleanup(func() { _ = os.RemoveAll(fake.Root) })
+	origFS := safetyFS
+	safetyFS = fake
+	t.Cleanup(func() { safetyFS = origFS })
+	origNow := safetyNow
+	safetyNow = func() time.Time { return now }
+	t.Cleanup(func() { safetyNow = origNow })
+	return fake
+}
+
+func writeTestSafetyArchive(t *testing.T, fake *FakeFS, name string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(safetyBackupDir, name)
+	if err := fake.AddDir(safetyBackupDir); err != nil {
+		t.Fatalf("add dir: %v", err)
+	}
+	f, err := os.Create(fake.onDisk(path))
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	for entry, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: entry, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close file: %v", err)
+	}
+	return path
+}
+
+func TestParseSafetyBackupName(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantKind string
+		wantOK   bool
+	}{
+		{"restore_backup_20240301_150405.tar.gz", "restore_backup", true},
+		{"network_rollback_backup_20240301_150405.tar.gz", "network_rollback_backup", true},
+		{"pve_access_control_rollback_backup_20240301_150405.tar.gz", "pve_access_control_rollback_backup", true},
+		{"pre_rollback_backup_20240301_150405.tar.gz", "pre_rollback_backup", true},
+		{"restore_backup_20240301_150405.json", "", false},
+		{"restore_backup_location.txt", "", false},
+		{"restore_backup_garbage.tar.gz", "", false},
+		{"firewall_rollback_20240301_150405.sh", "", false},
+	}
+	for _, tt := range tests {
+		kind, _, ts, ok := parseSafetyBackupName(tt.name)
+		if ok != tt.wantOK || kind != tt.wantKind {
+			t.Fatalf("parseSafetyBackupName(%q) = (%q, %v), want (%q, %v)", tt.name, kind, ok, tt.wantKind, tt.wantOK)
+		}
+		if ok && ts.Format(safetyBackupTimeLayout) != "20240301_150405" {
+			t.Fatalf("parseSafetyBackupName(%q) timestamp = %v", tt.name, ts)
+		}
+	}
+}
+
+func TestCreateSafetyBackupWritesCatalogSidecar(t *testing.T) {
+	fixed := time.Date(2024, time.March, 1, 15, 4, 5, 0, time.Local)
+	fake := setupSafetyCatalogFS(t, fixed)
+	destRoot := "/restore-target"
+	if err := fake.AddFile(filepath.Join(destRoot, "etc/hosts"), []byte("127.0.0.1 localhost\n")); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+	logger := logging.New(types.LogLevelError, false)
+
+	all := GetAllCategories()
+	network := GetCategoryByID("network", all)
+	if network == nil {
+		t.Fatal("network category not found")
+	}
+	if _, err := CreateSafetyBackup(logger, []Category{*network}, destRoot); err != nil {
+		t.Fatalf("CreateSafetyBackup: %v", err)
+	}
+
+	backups, err := ListSafetyBackups(logger)
+	if err != nil {
+		t.Fatalf("ListSafetyBackups: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(backups))
+	}
+	got := backups[0]
+	if got.Kind != "restore_backup" || got.Description != "Safety backup" {
+		t.Fatalf("unexpected kind/description: %+v", got)
+	}
+	if !got.Timestamp.Equal(fixed) {
+		t.Fatalf("timestamp = %v, want %v", got.Timestamp, fixed)
+	}
+	if ids := got.CategoryIDs(); len(ids) != 1 || ids[0] != "network" {
+		t.Fatalf("categories = %v, want [network]", ids)
+	}
+	if got.Files != 1 {
+		t.Fatalf("files = %d, want 1", got.Files)
+	}
+}
+
+func TestListSafetyBackupsInfersCategoriesWithoutSidecar(t *testing.T) {
+	fake := setupSafetyCatalogFS(t, time.Now())
+	writeTestSafetyArchive(t, fake, "restore_backup_20240301_150405.tar.gz", map[string]string{
+		"etc/hosts": "127.0.0.1 localhost\n",
+	})
+	writeTestSafetyArchive(t, fake, "firewall_rollback_backup_20240302_150405.tar.gz", map[string]string{
+		"etc/pve/firewall/cluster.fw": "[OPTIONS]\n",
+	})
+	if err := fake.AddFile(filepath.Join(safetyBackupDir, "restore_backup_location.txt"), []byte("x")); err != nil {
+		t.Fatalf("add location file: %v", err)
+	}
+
+	backups, err := ListSafetyBackups(logging.New(types.LogLevelError, false))
+	if err != nil {
+		t.Fatalf("ListSafetyBackups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %d", len(backups))
+	}
+	if backups[0].Kind != "firewall_rollback_backup" {
+		t.Fatalf("expected newest first, got %s", backups[0].Kind)
+	}
+	if GetCategoryByID("pve_firewall", backups[0].Categories) == nil {
+		t.Fatalf("expected pve_firewall to be inferred, got %v", backups[0].CategoryIDs())
+	}
+	if GetCategoryByID("network", backups[1].Categories) == nil {
+		t.Fatalf("expected network to be inferred, got %v", backups[1].CategoryIDs())
+	}
+	if backups[1].Files != 1 {
+		t.Fatalf("files = %d, want 1", backups[1].Files)
+	}
+}
+
+func TestListSafetyBackupsMissingDirectory(t *testing.T) {
+	setupSafetyCatalogFS(t, time.Now())
+	backups, err := ListSafetyBackups(logging.New(types.LogLevelError, false))
+	if err != nil {
+		t.Fatalf("ListSafetyBackups: %v", err)
+	}
+	if len(backups) != 0 {
+		t.Fatalf("expected no backups, got %d", len(backups))
+	}
+}
+
+func TestPruneSafetyBackups(t *testing.T) {
+	now := time.Date(2024, time.March, 20, 12, 0, 0, 0, time.Local)
+	fake := setupSafetyCatalogFS(t, now)
+	files := map[string]string{"etc/hosts": "x"}
+	names := []string{
+		"restore_backup_20240319_120000.tar.gz",
+		"restore_backup_20240318_120000.tar.gz",
+		"restore_backup_20240317_120000.tar.gz",
+		"restore_backup_20240101_120000.tar.gz",
+		"network_rollback_backup_20240101_120000.tar.gz",
+	}
+	for _, name := range names {
+		writeTestSafetyArchive(t, fake, name, files)
+	}
+	sidecar := filepath.Join(safetyBackupDir, "restore_backup_20240317_120000.json")
+	if err := fake.AddFile(sidecar, []byte(`{"kind":"restore_backup"}`)); err != nil {
+		t.Fatalf("add sidecar: %v", err)
+	}
+
+	logger := logging.New(types.LogLevelError, false)
+	removed, err := PruneSafetyBackups(logger, SafetyBackupRetention{Keep: 2, MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("PruneSafetyBackups: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("removed = %d, want 2", removed)
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(fake.onDisk(filepath.Join(safetyBackupDir, name)))
+		return err == nil
+	}
+	for _, keep := range []string{names[0], names[1], names[4]} {
+		if !exists(keep) {
+			t.Fatalf("%s should be kept", keep)
+		}
+	}
+	for _, gone := range []string{names[2], names[3], "restore_backup_20240317_120000.json"} {
+		if exists(gone) {
+			t.Fatalf("%s should be removed", gone)
+		}
+	}
+}
+
+func TestPruneSafetyBackupsDisabled(t *testing.T) {
+	fake := setupSafetyCatalogFS(t, time.Now())
+	writeTestSafetyArchive(t, fake, "restore_backup_20200101_120000.tar.gz", map[string]string{"etc/hosts": "x"})
+	writeTestSafetyArchive(t, fake, "restore_backup_20200102_120000.tar.gz", map[string]string{"etc/hosts": "x"})
+
+	removed, err := PruneSafetyBackups(logging.New(types.LogLevelError, false), SafetyBackupRetention{})
+	if err != nil {
+		t.Fatalf("PruneSafetyBackups: %v", err)
+	}
+	if removed != 0 {
+		t.Fatalf("removed = %d, want 0 when retention is disabled", removed)
+	}
+}
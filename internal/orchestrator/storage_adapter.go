@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/tis24dev/proxsave/internal/config"
 	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/notify"
 	"github.com/tis24dev/proxsave/internal/storage"
 	"github.com/tis24dev/proxsave/internal/types"
 )
@@ -89,7 +91,17 @@ func (s *StorageAdapter) Sync(ctx context.Context, stats *BackupStats) error {
 
 	// Step 3: Store backup
 	s.logger.Step("%s: Storing backup", s.backend.Name())
-	if err := s.backend.Store(ctx, stats.ArchivePath, metadata); err != nil {
+	deferred := false
+	resumePending := false
+	if err := s.backend.Store(ctx, stats.ArchivePath, metadata); errors.Is(err, storage.ErrUploadDeferred) {
+		s.logger.Info("%s: Backup queued for the next upload window", s.backend.Name())
+		deferred = true
+	} else if errors.Is(err, storage.ErrUploadPendingResume) {
+		// The uploaded parts are journaled and the archive is queued: this is not a
+		// lost backup, the next run (or the daemon) finishes the upload.
+		s.logger.Warning("WARNING: %s: upload interrupted, pending resume: %v", s.backend.Name(), err)
+		resumePending = true
+	} else if err != nil {
 		// Check if error is critical
 		if s.backend.IsCritical() {
 			s.setStorageStatus(stats, "error")
@@ -112,16 +124,23 @@ func (s *StorageAdapter) Sync(ctx context.Context, stats *BackupStats) error {
 		s.logger.Info("✓ %s: Backup stored successfully", s.backend.Name())
 	}
 
+	if reporter, ok := s.backend.(storage.UploadQueueReporter); ok && stats != nil {
+		stats.CloudUploadQueue = uploadQueueSummaryForNotification(reporter.UploadQueueStatus())
+	}
+
 	// Step 4: Apply retention policy
 	retentionConfig := storage.NewRetentionConfigFromConfig(s.config, s.backend.Location())
 	if retentionConfig.Policy == "gfs" {
 		// Enforce GFS-specific rules (e.g. minimum DAILY=1) once per backend.
 		retentionConfig = storage.NormalizeGFSRetentionConfig(s.logger, s.backend.Name(), retentionConfig)
 	}
-	if retentionConfig.MaxBackups > 0 || retentionConfig.Policy == "gfs" {
-		if retentionConfig.Policy == "gfs" {
+	if retentionConfig.MaxBackups > 0 || retentionConfig.IsTimeBased() {
+		switch retentionConfig.Policy {
+		case "gfs":
 			s.logger.Info("%s: Applying GFS retention policy...", s.backend.Name())
-		} else {
+		case "thinning":
+			s.logger.Info("%s: Applying thinning retention policy...", s.backend.Name())
+		default:
 			s.logger.Info("%s: Applying retention policy...", s.backend.Name())
 		}
 		s.logRetentionPolicyDetails(retentionConfig)
@@ -172,6 +191,9 @@ func (s *StorageAdapter) Sync(ctx context.Context, stats *BackupStats) error {
 
 		if stats != nil {
 			s.applyStorageStats(storageStats, retentionConfig, stats)
+			if s.recordCapacityForecast(storageStats, retentionConfig, stats) {
+				hasWarnings = true
+			}
 		}
 	}
 
@@ -181,9 +203,35 @@ func (s *StorageAdapter) Sync(ctx context.Context, stats *BackupStats) error {
 		s.logger.Info("✓ %s operations completed", s.backend.Name())
 	}
 	s.finalizeStorageStatus(stats, hasErrors, hasWarnings)
+	if deferred && !hasErrors && !hasWarnings {
+		s.setStorageStatus(stats, "queued")
+	}
+	if resumePending && !hasErrors {
+		s.setStorageStatus(stats, "pending")
+	}
 	return nil
 }
 
+func uploadQueueSummaryForNotification(status *storage.UploadQueueStatus) *notify.CloudUploadQueueSummary {
+	if status == nil {
+		return nil
+	}
+	summary := &notify.CloudUploadQueueSummary{
+		Pending:   status.Pending,
+		Window:    status.Window,
+		LastError: status.LastError,
+	}
+	if !status.Oldest.IsZero() {
+		oldest := status.Oldest
+		summary.Oldest = &oldest
+	}
+	if !status.NextWindow.IsZero() {
+		next := status.NextWindow
+		summary.NextWindow = &next
+	}
+	return summary
+}
+
 func (s *StorageAdapter) logCurrentBackupCount() {
 	listable, ok := s.backend.(interface {
 		List(context.Context) ([]*types.BackupMetadata, error)
@@ -210,9 +258,8 @@ func (s *StorageAdapter) logRetentionPolicyDetails(cfg storage.RetentionConfig)
 	if s.logger == nil {
 		return
 	}
-	if cfg.Policy == "gfs" {
-		s.logger.Debug("  Policy: GFS (daily=%d, weekly=%d, monthly=%d, yearly=%d)",
-			cfg.Daily, cfg.Weekly, cfg.Monthly, cfg.Yearly)
+	if cfg.IsTimeBased() {
+		s.logger.Debug("  Policy: %s", cfg.Describe())
 		return
 	}
 	if cfg.MaxBackups > 0 {
@@ -241,19 +288,20 @@ func (s *StorageAdapter) applyStorageStats(storageStats *storage.StorageStats, r
 		return
 	}
 
-	// Get current GFS stats if in GFS mode
-	var gfsStats map[storage.RetentionCategory]int
-	if retentionConfig.Policy == "gfs" {
-		// Get backups list for classification
+	// Classify the current backups for time-based policies (GFS or thinning)
+	var tiers []notify.RetentionTier
+	if retentionConfig.IsTimeBased() {
+		var retentionStats map[storage.RetentionCategory]int
 		if listable, ok := s.backend.(interface {
 			List(context.Context) ([]*types.BackupMetadata, error)
 		}); ok {
 			backups, err := listable.List(context.Background())
 			if err == nil && len(backups) > 0 {
-				classification := storage.ClassifyBackupsGFS(backups, retentionConfig)
-				gfsStats = storage.GetRetentionStats(classification)
+				classification := storage.ClassifyBackups(backups, retentionConfig)
+				retentionStats = storage.GetRetentionStats(classification)
 			}
 		}
+		tiers = retentionTiersForNotification(retentionConfig, retentionStats)
 	}
 
 	switch s.backend.Location() {
@@ -264,18 +312,7 @@ func (s *StorageAdapter) applyStorageStats(storageStats *storage.StorageStats, r
 		stats.LocalTotalSpace = clampInt64ToUint64(storageStats.TotalSpace)
 		// Populate retention info
 		stats.LocalRetentionPolicy = retentionConfig.Policy
-		if retentionConfig.Policy == "gfs" {
-			stats.LocalGFSDaily = retentionConfig.Daily
-			stats.LocalGFSWeekly = retentionConfig.Weekly
-			stats.LocalGFSMonthly = retentionConfig.Monthly
-			stats.LocalGFSYearly = retentionConfig.Yearly
-			if gfsStats != nil {
-				stats.LocalGFSCurrentDaily = gfsStats[storage.CategoryDaily]
-				stats.LocalGFSCurrentWeekly = gfsStats[storage.CategoryWeekly]
-				stats.LocalGFSCurrentMonthly = gfsStats[storage.CategoryMonthly]
-				stats.LocalGFSCurrentYearly = gfsStats[storage.CategoryYearly]
-			}
-		}
+		stats.LocalRetentionTiers = tiers
 	case storage.LocationSecondary:
 		if !stats.SecondaryEnabled {
 			stats.SecondaryEnabled = true
@@ -286,18 +323,7 @@ func (s *StorageAdapter) applyStorageStats(storageStats *storage.StorageStats, r
 		stats.SecondaryTotalSpace = clampInt64ToUint64(storageStats.TotalSpace)
 		// Populate retention info
 		stats.SecondaryRetentionPolicy = retentionConfig.Policy
-		if retentionConfig.Policy == "gfs" {
-			stats.SecondaryGFSDaily = retentionConfig.Daily
-			stats.SecondaryGFSWeekly = retentionConfig.Weekly
-			stats.SecondaryGFSMonthly = retentionConfig.Monthly
-			stats.SecondaryGFSYearly = retentionConfig.Yearly
-			if gfsStats != nil {
-				stats.SecondaryGFSCurrentDaily = gfsStats[storage.CategoryDaily]
-				stats.SecondaryGFSCurrentWeekly = gfsStats[storage.CategoryWeekly]
-				stats.SecondaryGFSCurrentMonthly = gfsStats[storage.CategoryMonthly]
-				stats.SecondaryGFSCurrentYearly = gfsStats[storage.CategoryYearly]
-			}
-		}
+		stats.SecondaryRetentionTiers = tiers
 	case storage.LocationCloud:
 		if !stats.CloudEnabled {
 			stats.CloudEnabled = true
@@ -305,19 +331,84 @@ func (s *StorageAdapter) applyStorageStats(storageStats *storage.StorageStats, r
 		stats.CloudBackups = storageStats.TotalBackups
 		// Populate retention info
 		stats.CloudRetentionPolicy = retentionConfig.Policy
-		if retentionConfig.Policy == "gfs" {
-			stats.CloudGFSDaily = retentionConfig.Daily
-			stats.CloudGFSWeekly = retentionConfig.Weekly
-			stats.CloudGFSMonthly = retentionConfig.Monthly
-			stats.CloudGFSYearly = retentionConfig.Yearly
-			if gfsStats != nil {
-				stats.CloudGFSCurrentDaily = gfsStats[storage.CategoryDaily]
-				stats.CloudGFSCurrentWeekly = gfsStats[storage.CategoryWeekly]
-				stats.CloudGFSCurrentMonthly = gfsStats[storage.CategoryMonthly]
-				stats.CloudGFSCurrentYearly = gfsStats[storage.CategoryYearly]
-			}
-		}
+		stats.CloudRetentionTiers = tiers
+	}
+}
+
+// retentionTiersForNotification converts the retention tiers of cfg (with the
+// kept counts from stats, nil when the backups could not be listed).
+func retentionTiersForNotification(cfg storage.RetentionConfig, stats map[storage.RetentionCategory]int) []notify.RetentionTier {
+	tiers := storage.RetentionTiers(cfg, stats)
+	out := make([]notify.RetentionTier, 0, len(tiers))
+	for _, t := range tiers {
+		out = append(out, notify.RetentionTier{Name: string(t.Category), Kept: t.Kept, Limit: t.Limit})
+	}
+	return out
+}
+
+// recordCapacityForecast projects when this target runs out of space and adds
+// the result to stats. It returns true (after logging a warning) when the target
+// is projected to fill within CAPACITY_FORECAST_WARN_DAYS. Targets that do not
+// report their capacity (cloud remotes) are skipped.
+func (s *StorageAdapter) recordCapacityForecast(storageStats *storage.StorageStats, retentionConfig storage.RetentionConfig, stats *BackupStats) bool {
+	if s.config == nil || s.config.CapacityForecastWarnDays <= 0 || storageStats.TotalSpace <= 0 {
+		return false
+	}
+	listable, ok := s.backend.(interface {
+		List(context.Context) ([]*types.BackupMetadata, error)
+	})
+	if !ok {
+		return false
+	}
+	backups, err := listable.List(context.Background())
+	if err != nil {
+		s.logger.Debug("%s: capacity forecast skipped: %v", s.backend.Name(), err)
+		return false
+	}
+
+	var reserveGB float64
+	switch s.backend.Location() {
+	case storage.LocationPrimary:
+		reserveGB = s.config.MinDiskPrimaryGB
+	case storage.LocationSecondary:
+		reserveGB = s.config.MinDiskSecondaryGB
+	case storage.LocationCloud:
+		reserveGB = s.config.MinDiskCloudGB
+	}
+	forecast, ok := storage.ForecastCapacity(backups, retentionConfig, storageStats.AvailableSpace, int64(reserveGB*(1<<30)), time.Now())
+	if !ok {
+		s.logger.Debug("%s: capacity forecast skipped: not enough backup history", s.backend.Name())
+		return false
+	}
+
+	summary := notify.CapacityForecastSummary{
+		Location:         storageLocationLabel(s.backend.Location()),
+		DaysUntilFull:    forecast.DaysUntilFull,
+		HorizonDays:      storage.ForecastHorizonDays,
+		AvailableBytes:   forecast.AvailableBytes,
+		SteadyStateBytes: forecast.SteadyStateBytes,
+		Warning:          forecast.WillFill() && forecast.DaysUntilFull <= s.config.CapacityForecastWarnDays,
+	}
+	stats.CapacityForecasts = append(stats.CapacityForecasts, summary)
+
+	s.logger.Debug("%s: capacity forecast: %.2f backups/day, next archive %s, growth %s/day, retained %s -> %s",
+		s.backend.Name(), forecast.BackupsPerDay, formatBytes(forecast.NextArchiveBytes),
+		formatBytes(int64(forecast.GrowthPerDay)), formatBytes(forecast.RetainedBytes), formatBytes(forecast.SteadyStateBytes))
+	if summary.Warning {
+		s.logger.Warning("WARNING: %s: projected to run out of space: %s", s.backend.Name(), summary.Describe())
+		return true
+	}
+	s.logger.Info("  Capacity forecast: %s", summary.Describe())
+	return false
+}
+
+// storageLocationLabel maps a backend location to the label used in
+// notifications and metrics ("local", "secondary", "cloud").
+func storageLocationLabel(location storage.BackupLocation) string {
+	if location == storage.LocationPrimary {
+		return "local"
 	}
+	return string(location)
 }
 
 func clampInt64ToUint64(value int64) uint64 {
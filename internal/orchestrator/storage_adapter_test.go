@@ -430,3 +430,44 @@ func TestStorageAdapterSync_NonCriticalRetentionErrorFinalizesWarning(t *testing
 		t.Fatalf("LocalRetentionPolicy = %q; want simple", stats.LocalRetentionPolicy)
 	}
 }
+
+func TestStorageAdapterSync_CapacityForecastWarnsBeforeFull(t *testing.T) {
+	logger := newStorageAdapterTestLogger()
+	cfg := &config.Config{
+		MinDiskPrimaryGB:         1,
+		CapacityForecastWarnDays: 14,
+	}
+
+	const gib = int64(1 << 30)
+	now := time.Now()
+	var backups []*types.BackupMetadata
+	for i := 9; i >= 0; i-- {
+		backups = append(backups, &types.BackupMetadata{Timestamp: now.AddDate(0, 0, -i), Size: gib, Verified: true})
+	}
+	backend := &fakeStorageBackend{
+		name:     "primary",
+		location: storage.LocationPrimary,
+		enabled:  true,
+		listFn:   func(context.Context) ([]*types.BackupMetadata, error) { return backups, nil },
+		getStatsFn: func(context.Context) (*storage.StorageStats, error) {
+			return &storage.StorageStats{TotalBackups: 10, AvailableSpace: 5 * gib, UsedSpace: 10 * gib, TotalSpace: 15 * gib}, nil
+		},
+	}
+
+	adapter := NewStorageAdapter(backend, logger, cfg)
+	stats := sampleAdapterStats()
+	if err := adapter.Sync(context.Background(), stats); err != nil {
+		t.Fatalf("Sync returned error: %v", err)
+	}
+	if len(stats.CapacityForecasts) != 1 {
+		t.Fatalf("CapacityForecasts = %+v, want one entry", stats.CapacityForecasts)
+	}
+	f := stats.CapacityForecasts[0]
+	// 5 GiB free, 1 GiB reserve, 1 GiB a day with no retention: full in ~5 days.
+	if f.Location != "local" || f.DaysUntilFull != 5 || !f.Warning {
+		t.Fatalf("forecast = %+v, want local full in 5 days with a warning", f)
+	}
+	if stats.LocalStatus != "warning" {
+		t.Fatalf("LocalStatus = %q; want warning", stats.LocalStatus)
+	}
+}
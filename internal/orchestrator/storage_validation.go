@@ -0,0 +1,560 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+)
+
+// storageReadiness classifies a storage or datastore definition against the live system.
+type storageReadiness string
+
+const (
+	storageReady    storageReadiness = "ready"
+	storageDegraded storageReadiness = "degraded"
+	storageMissing  storageReadiness = "missing"
+)
+
+// storageCheck is the validation result of one storage.cfg or datastore.cfg entry.
+type storageCheck struct {
+	ID     string
+	Type   string
+	Status storageReadiness
+	Detail string
+}
+
+// StorageDecisions records what the user chose for the storage.cfg/datastore.cfg
+// entries that are not ready on this host. A nil value applies every entry.
+type StorageDecisions struct {
+	SkipPVEStorage    map[string]bool
+	DisablePVEStorage map[string]bool
+	SkipPBSDatastores map[string]bool
+}
+
+func (d *StorageDecisions) skipPVEStorage(id string) bool {
+	return d != nil && d.SkipPVEStorage[id]
+}
+
+func (d *StorageDecisions) disablePVEStorage(id string) bool {
+	return d != nil && d.DisablePVEStorage[id]
+}
+
+func (d *StorageDecisions) pbsDatastoreSkips() map[string]bool {
+	if d == nil {
+		return nil
+	}
+	return d.SkipPBSDatastores
+}
+
+// storageProbe inspects the live system for the resources a storage definition
+// depends on. Tests replace it with a stand-in.
+type storageProbe interface {
+	// PathStatus reports whether path exists and whether it is a mountpoint.
+	PathStatus(path string) (exists, mounted bool)
+	// ZFSPools returns the imported pools and their health.
+	ZFSPools(ctx context.Context) (map[string]string, error)
+	// LVM returns the volume groups and the "vg/lv" logical volumes.
+	LVM(ctx context.Context) (vgs, lvs map[string]bool, err error)
+	// CephPools returns the pools of the local Ceph cluster.
+	CephPools(ctx context.Context) (map[string]bool, error)
+	// Reachable checks that a TCP connection to host:port can be opened.
+	Reachable(ctx context.Context, host string, port int) error
+	// DevicePresent reports whether the block device with the given filesystem UUID is attached.
+	DevicePresent(uuid string) bool
+}
+
+var liveStorageProbe storageProbe = systemStorageProbe{}
+
+const storageProbeDialTimeout = 3 * time.Second
+
+type systemStorageProbe struct{}
+
+func (systemStorageProbe) PathStatus(path string) (exists, mounted bool) {
+	info, err := restoreFS.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false, false
+	}
+	mounted, _ = isMounted(path)
+	return true, mounted
+}
+
+func (systemStorageProbe) ZFSPools(ctx context.Context) (map[string]string, error) {
+	out, err := restoreCmd.Run(ctx, "zpool", "list", "-H", "-o", "name,health")
+	if err != nil {
+		return nil, fmt.Errorf("zpool list: %w", err)
+	}
+	pools := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			pools[fields[0]] = fields[1]
+		}
+	}
+	return pools, nil
+}
+
+func (systemStorageProbe) LVM(ctx context.Context) (vgs, lvs map[string]bool, err error) {
+	out, err := restoreCmd.Run(ctx, "lvs", "--noheadings", "--separator", "/", "-o", "vg_name,lv_name")
+	if err != nil {
+		return nil, nil, fmt.Errorf("lvs: %w", err)
+	}
+	vgs = make(map[string]bool)
+	lvs = make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		vg, lv, ok := strings.Cut(strings.TrimSpace(line), "/")
+		if !ok || vg == "" {
+			continue
+		}
+		vgs[vg] = true
+		if lv != "" {
+			lvs[vg+"/"+lv] = true
+		}
+	}
+	// A volume group without logical volumes is not listed by lvs.
+	if out, err := restoreCmd.Run(ctx, "vgs", "--noheadings", "-o", "vg_name"); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if vg := strings.TrimSpace(line); vg != "" {
+				vgs[vg] = true
+			}
+		}
+	}
+	return vgs, lvs, nil
+}
+
+func (systemStorageProbe) CephPools(ctx context.Context) (map[string]bool, error) {
+	out, err := restoreCmd.Run(ctx, "ceph", "osd", "pool", "ls")
+	if err != nil {
+		return nil, fmt.Errorf("ceph osd pool ls: %w", err)
+	}
+	pools := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if pool := strings.TrimSpace(line); pool != "" {
+			pools[pool] = true
+		}
+	}
+	return pools, nil
+}
+
+func (systemStorageProbe) Reachable(ctx context.Context, host string, port int) error {
+	dialCtx, cancel := context.WithTimeout(ctx, storageProbeDialTimeout)
+	defer cancel()
+	conn, err := dialContextFunc(dialCtx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (systemStorageProbe) DevicePresent(uuid string) bool {
+	_, err := restoreFS.Stat(filepath.Join("/dev/disk/by-uuid", uuid))
+	return err == nil
+}
+
+// storageValidator caches the probe answers shared by several definitions.
+type storageValidator struct {
+	ctx   context.Context
+	probe storageProbe
+
+	zfsPools   map[string]string
+	zfsErr     error
+	zfsLoaded  bool
+	vgs, lvs   map[string]bool
+	lvmErr     error
+	lvmLoaded  bool
+	cephPools  map[string]bool
+	cephErr    error
+	cephLoaded bool
+}
+
+func newStorageValidator(ctx context.Context, probe storageProbe) *storageValidator {
+	return &storageValidator{ctx: ctx, probe: probe}
+}
+
+// validatePVEStorage checks one storage.cfg definition.
+func (v *storageValidator) validatePVEStorage(block storageBlock) storageCheck {
+	typ := strings.TrimSpace(block.Type)
+	if typ == "" {
+		typ = storageEntryValue(block.entries, "type")
+	}
+	check := storageCheck{ID: block.ID, Type: typ}
+	get := func(key string) string { return storageEntryValue(block.entries, key) }
+
+	switch typ {
+	case "dir", "btrfs":
+		check.Status, check.Detail = v.checkPath(get("path"), get("is_mountpoint"))
+	case "zfspool":
+		check.Status, check.Detail = v.checkZFSPool(get("pool"))
+	case "lvm":
+		check.Status, check.Detail = v.checkLVM(get("vgname"), "")
+	case "lvmthin":
+		check.Status, check.Detail = v.checkLVM(get("vgname"), get("thinpool"))
+	case "nfs":
+		check.Status, check.Detail = v.checkServer(get("server"), 2049)
+	case "cifs":
+		check.Status, check.Detail = v.checkServer(get("server"), 445)
+	case "glusterfs":
+		check.Status, check.Detail = v.checkServer(get("server"), 24007)
+	case "pbs":
+		port := 8007
+		if p, err := strconv.Atoi(get("port")); err == nil && p > 0 {
+			port = p
+		}
+		check.Status, check.Detail = v.checkServer(get("server"), port)
+	case "esxi":
+		check.Status, check.Detail = v.checkServer(get("server"), 443)
+	case "iscsi", "iscsidirect", "zfs":
+		check.Status, check.Detail = v.checkServer(get("portal"), 3260)
+	case "rbd":
+		pool := get("pool")
+		if pool == "" {
+			pool = "rbd"
+		}
+		check.Status, check.Detail = v.checkCeph(get("monhost"), pool)
+	case "cephfs":
+		check.Status, check.Detail = v.checkCeph(get("monhost"), "")
+	default:
+		check.Status, check.Detail = storageReady, "no live check for this storage type"
+	}
+	return check
+}
+
+// validatePBSDatastore checks one datastore.cfg definition.
+func (v *storageValidator) validatePBSDatastore(section proxmoxNotificationSection) storageCheck {
+	check := storageCheck{ID: strings.TrimSpace(section.Name), Type: "datastore"}
+	path := storageEntryValue(section.Entries, "path")
+	if uuid := storageEntryValue(section.Entries, "backing-device"); uuid != "" {
+		check.Type = "removable datastore"
+		if v.probe.DevicePresent(uuid) {
+			check.Status, check.Detail = storageReady, "backing device "+uuid+" attached"
+		} else {
+			check.Status, check.Detail = storageDegraded, "backing device "+uuid+" not attached"
+		}
+		return check
+	}
+	if path == "" {
+		check.Status, check.Detail = storageMissing, "no path configured"
+		return check
+	}
+	if exists, _ := v.probe.PathStatus(path); !exists {
+		check.Status, check.Detail = storageMissing, path+" does not exist"
+		return check
+	}
+	if exists, _ := v.probe.PathStatus(filepath.Join(path, ".chunks")); !exists {
+		check.Status, check.Detail = storageDegraded, path+" has no chunk store (.chunks); PBS would initialize an empty datastore there"
+		return check
+	}
+	check.Status, check.Detail = storageReady, path
+	return check
+}
+
+func (v *storageValidator) checkPath(path, isMountpoint string) (storageReadiness, string) {
+	if path == "" {
+		return storageMissing, "no path configured"
+	}
+	exists, mounted := v.probe.PathStatus(path)
+	if !exists {
+		return storageMissing, path + " does not exist"
+	}
+	mountpoint := ""
+	switch strings.ToLower(strings.TrimSpace(isMountpoint)) {
+	case "", "0", "no", "off", "false":
+	case "1", "yes", "on", "true":
+		mountpoint = path
+	default:
+		mountpoint = isMountpoint
+	}
+	if mountpoint != "" {
+		if mountpoint != path {
+			_, mounted = v.probe.PathStatus(mountpoint)
+		}
+		if !mounted {
+			return storageDegraded, mountpoint + " is not mounted"
+		}
+	}
+	return storageReady, path
+}
+
+func (v *storageValidator) checkZFSPool(dataset string) (storageReadiness, string) {
+	pool, _, _ := strings.Cut(dataset, "/")
+	if pool == "" {
+		return storageMissing, "no pool configured"
+	}
+	if !v.zfsLoaded {
+		v.zfsPools, v.zfsErr = v.probe.ZFSPools(v.ctx)
+		v.zfsLoaded = true
+	}
+	if v.zfsErr != nil {
+		return storageMissing, fmt.Sprintf("cannot list ZFS pools: %v", v.zfsErr)
+	}
+	health, ok := v.zfsPools[pool]
+	if !ok {
+		return storageMissing, "ZFS pool " + pool + " not imported"
+	}
+	if !strings.EqualFold(health, "ONLINE") {
+		return storageDegraded, fmt.Sprintf("ZFS pool %s is %s", pool, health)
+	}
+	return storageReady, "ZFS pool " + pool
+}
+
+func (v *storageValidator) checkLVM(vg, thinpool string) (storageReadiness, string) {
+	if vg == "" {
+		return storageMissing, "no volume group configured"
+	}
+	if !v.lvmLoaded {
+		v.vgs, v.lvs, v.lvmErr = v.probe.LVM(v.ctx)
+		v.lvmLoaded = true
+	}
+	if v.lvmErr != nil {
+		return storageMissing, fmt.Sprintf("cannot list LVM volumes: %v", v.lvmErr)
+	}
+	if !v.vgs[vg] {
+		return storageMissing, "volume group " + vg + " not found"
+	}
+	if thinpool != "" && !v.lvs[vg+"/"+thinpool] {
+		return storageMissing, "thin pool " + vg + "/" + thinpool + " not found"
+	}
+	if thinpool != "" {
+		return storageReady, "thin pool " + vg + "/" + thinpool
+	}
+	return storageReady, "volume group " + vg
+}
+
+func (v *storageValidator) checkServer(address string, defaultPort int) (storageReadiness, string) {
+	host, port := splitStorageServer(address, defaultPort)
+	if host == "" {
+		return storageMissing, "no server configured"
+	}
+	if err := v.probe.Reachable(v.ctx, host, port); err != nil {
+		return storageMissing, fmt.Sprintf("%s unreachable on port %d: %v", host, port, err)
+	}
+	return storageReady, fmt.Sprintf("%s reachable on port %d", host, port)
+}
+
+func (v *storageValidator) checkCeph(monhost, pool string) (storageReadiness, string) {
+	if monhost != "" {
+		// External cluster: one reachable monitor is enough (msgr2 first, then v1).
+		mons := strings.FieldsFunc(monhost, func(r rune) bool { return r == ' ' || r == ',' || r == ';' })
+		for _, mon := range mons {
+			for _, port := range []int{3300, 6789} {
+				host, p := splitStorageServer(mon, port)
+				if v.probe.Reachable(v.ctx, host, p) == nil {
+					return storageReady, fmt.Sprintf("Ceph monitor %s reachable on port %d", host, p)
+				}
+			}
+		}
+		return storageMissing, "no Ceph monitor reachable (" + monhost + ")"
+	}
+	if !v.cephLoaded {
+		v.cephPools, v.cephErr = v.probe.CephPools(v.ctx)
+		v.cephLoaded = true
+	}
+	if v.cephErr != nil {
+		return storageMissing, fmt.Sprintf("local Ceph cluster not available: %v", v.cephErr)
+	}
+	if pool != "" && !v.cephPools[pool] {
+		return storageMissing, "Ceph pool " + pool + " not found"
+	}
+	if pool != "" {
+		return storageReady, "Ceph pool " + pool
+	}
+	return storageReady, "local Ceph cluster"
+}
+
+// splitStorageServer parses "host", "host:port", "[v6]:port" and "v6" server values.
+func splitStorageServer(address string, defaultPort int) (string, int) {
+	address = strings.TrimSpace(address)
+	if host, portStr, err := net.SplitHostPort(address); err == nil {
+		if port, err := strconv.Atoi(portStr); err == nil && port > 0 {
+			return host, port
+		}
+		return host, defaultPort
+	}
+	return strings.Trim(address, "[]"), defaultPort
+}
+
+func notReadyStorageChecks(checks []storageCheck) []storageCheck {
+	var out []storageCheck
+	for _, c := range checks {
+		if c.Status != storageReady {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func formatStorageChecks(checks []storageCheck) string {
+	var b strings.Builder
+	for _, c := range checks {
+		fmt.Fprintf(&b, "  %-8s %s (%s): %s\n", strings.ToUpper(string(c.Status)), c.ID, c.Type, c.Detail)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// storageValidationUI groups the prompts used by the storage validation pass.
+type storageValidationUI interface {
+	ShowMessage(ctx context.Context, title, message string) error
+	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
+}
+
+// validateStagedStorageWithUI checks the staged storage.cfg and datastore.cfg against
+// the live system before they are applied, and records on the plan which entries
+// that are not ready must be skipped (or, for PVE storages, created disabled).
+func validateStagedStorageWithUI(ctx context.Context, ui storageValidationUI, logger *logging.Logger, plan *RestorePlan, stageRoot string, dryRun bool) (err error) {
+	if plan == nil || strings.TrimSpace(stageRoot) == "" {
+		return nil
+	}
+	validatePVE := plan.SystemType.SupportsPVE() && plan.HasCategoryID("storage_pve") && !plan.NeedsClusterRestore
+	validatePBS := plan.SystemType.SupportsPBS() && plan.HasCategoryID("datastore_pbs")
+	if !validatePVE && !validatePBS {
+		return nil
+	}
+
+	if !isRealRestoreFS(restoreFS) {
+		logging.DebugStep(logger, "storage validation", "Skipped: non-system filesystem in use")
+		return nil
+	}
+
+	done := logging.DebugStart(logger, "storage validation", "pve=%v pbs=%v dryRun=%v", validatePVE, validatePBS, dryRun)
+	defer func() { done(err) }()
+
+	validator := newStorageValidator(ctx, liveStorageProbe)
+	decisions := plan.StorageDecisions
+	if decisions == nil {
+		decisions = &StorageDecisions{}
+	}
+
+	if validatePVE {
+		checks, err := validateStagedPVEStorage(validator, stageRoot)
+		if err != nil {
+			logger.Warning("Storage validation: storage.cfg: %v", err)
+		} else if err := decidePVEStorage(ctx, ui, logger, checks, decisions, dryRun); err != nil {
+			return err
+		}
+	}
+	if validatePBS {
+		checks, err := validateStagedPBSDatastores(validator, stageRoot, decisions.pbsDatastoreSkips())
+		if err != nil {
+			logger.Warning("Storage validation: datastore.cfg: %v", err)
+		} else if err := decidePBSDatastores(ctx, ui, logger, checks, decisions, dryRun); err != nil {
+			return err
+		}
+	}
+
+	plan.StorageDecisions = decisions
+	return nil
+}
+
+func validateStagedPVEStorage(v *storageValidator, stageRoot string) ([]storageCheck, error) {
+	stagePath := filepath.Join(stageRoot, "etc/pve/storage.cfg")
+	blocks, err := parseStorageBlocks(stagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	checks := make([]storageCheck, 0, len(blocks))
+	for _, blk := range blocks {
+		checks = append(checks, v.validatePVEStorage(blk))
+	}
+	return checks, nil
+}
+
+// validateStagedPBSDatastores checks the staged datastore.cfg, leaving out the
+// datastores already handled (e.g. re-attached) and listed in skip.
+func validateStagedPBSDatastores(v *storageValidator, stageRoot string, skip map[string]bool) ([]storageCheck, error) {
+	raw, present, err := readStageFileOptional(stageRoot, "etc/proxmox-backup/datastore.cfg")
+	if err != nil || !present {
+		return nil, err
+	}
+	sections, err := parseProxmoxNotificationSections(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse staged datastore.cfg: %w", err)
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Name < sections[j].Name })
+	checks := make([]storageCheck, 0, len(sections))
+	for _, s := range sections {
+		if skip[strings.TrimSpace(s.Name)] {
+			continue
+		}
+		checks = append(checks, v.validatePBSDatastore(s))
+	}
+	return checks, nil
+}
+
+func decidePVEStorage(ctx context.Context, ui storageValidationUI, logger *logging.Logger, checks []storageCheck, decisions *StorageDecisions, dryRun bool) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	notReady := notReadyStorageChecks(checks)
+	logger.Info("Storage validation: storage.cfg %d ready, %d not ready", len(checks)-len(notReady), len(notReady))
+	if err := ui.ShowMessage(ctx, "PVE storage validation", formatStorageChecks(checks)); err != nil {
+		return err
+	}
+	if len(notReady) == 0 || dryRun {
+		return nil
+	}
+
+	disable, err := ui.ConfirmAction(ctx,
+		"Create the storages that are not ready as disabled?",
+		fmt.Sprintf("%d storage definition(s) depend on resources missing or degraded on this host.\n\n%s\n\nCreate them disabled (enable them later with pvesm set <id> --disable 0), or skip them and apply only the ready ones.",
+			len(notReady), formatStorageChecks(notReady)),
+		"Create disabled", "Skip them", 90*time.Second, true)
+	if err != nil {
+		return err
+	}
+	target := &decisions.SkipPVEStorage
+	if disable {
+		target = &decisions.DisablePVEStorage
+	}
+	*target = make(map[string]bool, len(notReady))
+	for _, c := range notReady {
+		(*target)[c.ID] = true
+	}
+	logging.DebugStep(logger, "storage validation", "pve not ready=%d disable=%v", len(notReady), disable)
+	return nil
+}
+
+func decidePBSDatastores(ctx context.Context, ui storageValidationUI, logger *logging.Logger, checks []storageCheck, decisions *StorageDecisions, dryRun bool) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	notReady := notReadyStorageChecks(checks)
+	logger.Info("Storage validation: datastore.cfg %d ready, %d not ready", len(checks)-len(notReady), len(notReady))
+	if err := ui.ShowMessage(ctx, "PBS datastore validation", formatStorageChecks(checks)); err != nil {
+		return err
+	}
+	if len(notReady) == 0 || dryRun {
+		return nil
+	}
+
+	// PBS has no disabled datastore: creating one always initializes a chunk
+	// store at its path, so the only safe alternative is to leave it out.
+	readyOnly, err := ui.ConfirmAction(ctx,
+		"Apply only the datastores that are ready?",
+		fmt.Sprintf("%d datastore(s) are missing or degraded on this host.\n\n%s\n\nCreating a datastore whose path is missing initializes a new, empty chunk store there.",
+			len(notReady), formatStorageChecks(notReady)),
+		"Ready only", "Apply all", 90*time.Second, true)
+	if err != nil {
+		return err
+	}
+	if !readyOnly {
+		return nil
+	}
+	if decisions.SkipPBSDatastores == nil {
+		decisions.SkipPBSDatastores = make(map[string]bool, len(notReady))
+	}
+	for _, c := range notReady {
+		decisions.SkipPBSDatastores[c.ID] = true
+	}
+	logging.DebugStep(logger, "storage validation", "pbs datastores skipped=%d", len(notReady))
+	return nil
+}
@@ -0,0 +1,301 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/logging"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+type fakeStorageProbe struct {
+	dirs      map[string]bool
+	mounts    map[string]bool
+	zfs       map[string]string
+	vgs, lvs  map[string]bool
+	ceph      map[string]bool
+	cephErr   error
+	reachable map[string]bool
+	devices   map[string]bool
+}
+
+func (p *fakeStorageProbe) PathStatus(path string) (bool, bool) {
+	return p.dirs[path], p.mounts[path]
+}
+
+func (p *fakeStorageProbe) ZFSPools(context.Context) (map[string]string, error) {
+	return p.zfs, nil
+}
+
+func (p *fakeStorageProbe) LVM(context.Context) (map[string]bool, map[string]bool, error) {
+	return p.vgs, p.lvs, nil
+}
+
+func (p *fakeStorageProbe) CephPools(context.Context) (map[string]bool, error) {
+	return p.ceph, p.cephErr
+}
+
+func (p *fakeStorageProbe) Reachable(_ context.Context, host string, port int) error {
+	if p.reachable[host+":"+strconv.Itoa(port)] {
+		return nil
+	}
+	return errors.New("connection refused")
+}
+
+func (p *fakeStorageProbe) DevicePresent(uuid string) bool {
+	return p.devices[uuid]
+}
+
+func TestValidatePVEStorageClassification(t *testing.T) {
+	probe := &fakeStorageProbe{
+		dirs:      map[string]bool{"/var/lib/vz": true, "/mnt/usb": true},
+		mounts:    map[string]bool{},
+		zfs:       map[string]string{"rpool": "ONLINE", "tank": "DEGRADED"},
+		vgs:       map[string]bool{"pve": true},
+		lvs:       map[string]bool{"pve/data": true},
+		reachable: map[string]bool{"10.0.0.5:2049": true, "pbs.lan:8008": true},
+		cephErr:   errors.New("ceph not installed"),
+	}
+	v := newStorageValidator(context.Background(), probe)
+
+	block := func(typ, id string, kv ...string) storageBlock {
+		blk := storageBlock{ID: id, Type: typ}
+		for i := 0; i+1 < len(kv); i += 2 {
+			blk.entries = append(blk.entries, proxmoxNotificationEntry{Key: kv[i], Value: kv[i+1]})
+		}
+		return blk
+	}
+
+	cases := []struct {
+		blk  storageBlock
+		want storageReadiness
+	}{
+		{block("dir", "local", "path", "/var/lib/vz"), storageReady},
+		{block("dir", "gone", "path", "/srv/gone"), storageMissing},
+		{block("dir", "usb", "path", "/mnt/usb", "is_mountpoint", "yes"), storageDegraded},
+		{block("zfspool", "local-zfs", "pool", "rpool/data"), storageReady},
+		{block("zfspool", "tank", "pool", "tank"), storageDegraded},
+		{block("zfspool", "fast", "pool", "fast/vm"), storageMissing},
+		{block("lvm", "vg", "vgname", "pve"), storageReady},
+		{block("lvmthin", "thin", "vgname", "pve", "thinpool", "data"), storageReady},
+		{block("lvmthin", "thin2", "vgname", "pve", "thinpool", "other"), storageMissing},
+		{block("lvm", "vg2", "vgname", "san"), storageMissing},
+		{block("nfs", "nas", "server", "10.0.0.5", "export", "/x"), storageReady},
+		{block("cifs", "smb", "server", "10.0.0.6"), storageMissing},
+		{block("pbs", "pbs", "server", "pbs.lan", "port", "8008"), storageReady},
+		{block("rbd", "ceph", "pool", "vms"), storageMissing},
+		{block("unknowntype", "x"), storageReady},
+	}
+	for _, tc := range cases {
+		got := v.validatePVEStorage(tc.blk)
+		if got.Status != tc.want {
+			t.Errorf("%s (%s): status=%s want %s (%s)", tc.blk.ID, tc.blk.Type, got.Status, tc.want, got.Detail)
+		}
+	}
+}
+
+func TestValidatePBSDatastoreClassification(t *testing.T) {
+	probe := &fakeStorageProbe{
+		dirs:    map[string]bool{"/mnt/ds1": true, "/mnt/ds1/.chunks": true, "/mnt/ds2": true},
+		devices: map[string]bool{"uuid-present": true},
+	}
+	v := newStorageValidator(context.Background(), probe)
+
+	section := func(name string, kv ...string) proxmoxNotificationSection {
+		s := proxmoxNotificationSection{Type: "datastore", Name: name}
+		for i := 0; i+1 < len(kv); i += 2 {
+			s.Entries = append(s.Entries, proxmoxNotificationEntry{Key: kv[i], Value: kv[i+1]})
+		}
+		return s
+	}
+
+	cases := []struct {
+		s    proxmoxNotificationSection
+		want storageReadiness
+	}{
+		{section("ds1", "path", "/mnt/ds1"), storageReady},
+		{section("ds2", "path", "/mnt/ds2"), storageDegraded},
+		{section("ds3", "path", "/mnt/ds3"), storageMissing},
+		{section("usb1", "path", "usb1", "backing-device", "uuid-present"), storageReady},
+		{section("usb2", "path", "usb2", "backing-device", "uuid-absent"), storageDegraded},
+	}
+	for _, tc := range cases {
+		if got := v.validatePBSDatastore(tc.s); got.Status != tc.want {
+			t.Errorf("%s: status=%s want %s (%s)", tc.s.Name, got.Status, tc.want, got.Detail)
+		}
+	}
+}
+
+func TestApplyStorageCfgWithDecisions(t *testing.T) {
+	origCmd := restoreCmd
+	t.Cleanup(func() { restoreCmd = origCmd })
+	fake := &FakeCommandRunner{}
+	restoreCmd = fake
+
+	cfgPath := filepath.Join(t.TempDir(), "storage.cfg")
+	content := "dir: local\n    path /var/lib/vz\n\n" +
+		"nfs: nas\n    server 10.0.0.5\n    export /x\n    disable 0\n\n" +
+		"zfspool: fast\n    pool fast\n"
+	if err := os.WriteFile(cfgPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	decisions := &StorageDecisions{
+		SkipPVEStorage:    map[string]bool{"fast": true},
+		DisablePVEStorage: map[string]bool{"nas": true},
+	}
+	applied, failed, err := applyStorageCfgWithDecisions(context.Background(), cfgPath, newTestLogger(), decisions)
+	if err != nil {
+		t.Fatalf("applyStorageCfgWithDecisions: %v", err)
+	}
+	if applied != 2 || failed != 0 {
+		t.Fatalf("applied=%d failed=%d, want 2/0", applied, failed)
+	}
+	want := []string{
+		"pvesh create /storage --storage=local --type=dir --path=/var/lib/vz",
+		"pvesh create /storage --storage=nas --type=nfs --server=10.0.0.5 --export=/x --disable=1",
+	}
+	if !reflect.DeepEqual(fake.Calls, want) {
+		t.Fatalf("calls=%v\nwant %v", fake.Calls, want)
+	}
+}
+
+func TestApplyPBSDatastoreCfgViaAPIWithSkipLeavesSkippedAlone(t *testing.T) {
+	stageRoot, fs, runner := setupPBSAPIApplyTestDeps(t)
+	logger := logging.New(types.LogLevelDebug, false)
+
+	writeStageFile(t, fs, stageRoot, "etc/proxmox-backup/datastore.cfg",
+		"datastore: ds1\n    path /p1\n\ndatastore: ds2\n    path /p2\n", 0o640)
+	runner.outputs = map[string][]byte{
+		"proxmox-backup-manager datastore list --output-format=json": []byte(`{"data":[{"name":"ds2","path":"/old2"}]}`),
+	}
+
+	if err := applyPBSDatastoreCfgViaAPIWithSkip(context.Background(), logger, stageRoot, true, map[string]bool{"ds2": true}); err != nil {
+		t.Fatalf("applyPBSDatastoreCfgViaAPIWithSkip: %v", err)
+	}
+	want := []string{
+		"proxmox-backup-manager datastore list --output-format=json",
+		"proxmox-backup-manager datastore create ds1 /p1",
+	}
+	if !reflect.DeepEqual(runner.calls, want) {
+		t.Fatalf("calls=%v want %v", runner.calls, want)
+	}
+}
+
+type fakeStorageValidationUI struct {
+	messages []string
+	confirm  bool
+	asked    []string
+}
+
+func (u *fakeStorageValidationUI) ShowMessage(_ context.Context, title, message string) error {
+	u.messages = append(u.messages, title+"\n"+message)
+	return nil
+}
+
+func (u *fakeStorageValidationUI) ConfirmAction(_ context.Context, title, _, _, _ string, _ time.Duration, _ bool) (bool, error) {
+	u.asked = append(u.asked, title)
+	return u.confirm, nil
+}
+
+func setupStorageValidationTest(t *testing.T, probe storageProbe) string {
+	t.Helper()
+	origFS, origProbe := restoreFS, liveStorageProbe
+	t.Cleanup(func() {
+		restoreFS = origFS
+		liveStorageProbe = origProbe
+	})
+	restoreFS = osFS{}
+	liveStorageProbe = probe
+
+	stageRoot := t.TempDir()
+	files := map[string]string{
+		"etc/pve/storage.cfg":              "dir: local\n    path /var/lib/vz\n\nnfs: nas\n    server 10.0.0.9\n    export /x\n",
+		"etc/proxmox-backup/datastore.cfg": "datastore: ds1\n    path /mnt/ds1\n\ndatastore: ds2\n    path /mnt/ds2\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(stageRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return stageRoot
+}
+
+func TestValidateStagedStorageRecordsDecisions(t *testing.T) {
+	stageRoot := setupStorageValidationTest(t, &fakeStorageProbe{
+		dirs: map[string]bool{"/var/lib/vz": true, "/mnt/ds1": true, "/mnt/ds1/.chunks": true},
+	})
+	plan := &RestorePlan{
+		SystemType:       SystemTypeDual,
+		StagedCategories: []Category{{ID: "storage_pve"}, {ID: "datastore_pbs"}},
+	}
+	ui := &fakeStorageValidationUI{confirm: true}
+
+	if err := validateStagedStorageWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, false); err != nil {
+		t.Fatalf("validateStagedStorageWithUI: %v", err)
+	}
+	if len(ui.messages) != 2 || len(ui.asked) != 2 {
+		t.Fatalf("messages=%d asked=%d, want 2/2", len(ui.messages), len(ui.asked))
+	}
+	got := plan.StorageDecisions
+	if got == nil {
+		t.Fatal("expected decisions on the plan")
+	}
+	if !reflect.DeepEqual(got.DisablePVEStorage, map[string]bool{"nas": true}) || len(got.SkipPVEStorage) != 0 {
+		t.Fatalf("pve decisions=%+v", got)
+	}
+	if !reflect.DeepEqual(got.SkipPBSDatastores, map[string]bool{"ds2": true}) {
+		t.Fatalf("pbs skips=%v", got.SkipPBSDatastores)
+	}
+}
+
+func TestValidateStagedStorageDeclineChoices(t *testing.T) {
+	stageRoot := setupStorageValidationTest(t, &fakeStorageProbe{
+		dirs: map[string]bool{"/var/lib/vz": true, "/mnt/ds1": true, "/mnt/ds1/.chunks": true},
+	})
+	plan := &RestorePlan{
+		SystemType:       SystemTypeDual,
+		StagedCategories: []Category{{ID: "storage_pve"}, {ID: "datastore_pbs"}},
+	}
+	ui := &fakeStorageValidationUI{confirm: false}
+
+	if err := validateStagedStorageWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, false); err != nil {
+		t.Fatalf("validateStagedStorageWithUI: %v", err)
+	}
+	got := plan.StorageDecisions
+	if !reflect.DeepEqual(got.SkipPVEStorage, map[string]bool{"nas": true}) || len(got.DisablePVEStorage) != 0 {
+		t.Fatalf("pve decisions=%+v", got)
+	}
+	if len(got.SkipPBSDatastores) != 0 {
+		t.Fatalf("pbs skips=%v, want none when applying all", got.SkipPBSDatastores)
+	}
+}
+
+func TestValidateStagedStorageDryRunOnlyReports(t *testing.T) {
+	stageRoot := setupStorageValidationTest(t, &fakeStorageProbe{})
+	plan := &RestorePlan{
+		SystemType:       SystemTypePVE,
+		StagedCategories: []Category{{ID: "storage_pve"}},
+	}
+	ui := &fakeStorageValidationUI{confirm: true}
+
+	if err := validateStagedStorageWithUI(context.Background(), ui, newTestLogger(), plan, stageRoot, true); err != nil {
+		t.Fatalf("validateStagedStorageWithUI: %v", err)
+	}
+	if len(ui.messages) != 1 || len(ui.asked) != 0 {
+		t.Fatalf("messages=%d asked=%d, want 1/0", len(ui.messages), len(ui.asked))
+	}
+	if plan.StorageDecisions.skipPVEStorage("local") || plan.StorageDecisions.disablePVEStorage("nas") {
+		t.Fatalf("dry run must not record decisions: %+v", plan.StorageDecisions)
+	}
+}
@@ -72,6 +72,10 @@ type RestoreWorkflowUI interface {
 	ConfirmApplyStorageCfg(ctx context.Context, storageCfgPath string) (bool, error)
 	ConfirmApplyDatacenterCfg(ctx context.Context, datacenterCfgPath string) (bool, error)
 
+	SelectFirewallRestoreMode(ctx context.Context) (FirewallRestoreMode, error)
+	ReviewFirewallChanges(ctx context.Context, changes []firewallChange) ([]firewallChange, error)
+	SelectAccessTokensToRegenerate(ctx context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error)
+
 	ConfirmAction(ctx context.Context, title, message, yesLabel, noLabel string, timeout time.Duration, defaultYes bool) (bool, error)
 	RepairNICNames(ctx context.Context, archivePath string) (*nicRepairResult, error)
 	PromptNetworkCommit(ctx context.Context, remaining time.Duration, health networkHealthReport, nicRepair *nicRepairResult, diagnosticsDir string) (bool, error)
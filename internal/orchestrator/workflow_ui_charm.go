@@ -266,7 +266,7 @@ func (u *charmWorkflowUI) PromptDecryptSecret(ctx context.Context, displayName,
 	}
 	opts := []components.InputOption{
 		components.WithSecret(),
-		components.WithNote("Enter 0 to exit."),
+		components.WithNote(ageIdentitySourceHint + "\nEnter 0 to exit."),
 		components.WithValidate(func(value string) error {
 			if strings.TrimSpace(value) == "" {
 				return fmt.Errorf("key or passphrase cannot be empty")
@@ -295,3 +295,19 @@ func (u *charmWorkflowUI) PromptDecryptSecret(ctx context.Context, displayName,
 	}
 	return secret, nil
 }
+
+func (u *charmWorkflowUI) PromptAgePluginValue(ctx context.Context, pluginName, prompt string, secret bool) (string, error) {
+	var opts []components.InputOption
+	if secret {
+		opts = append(opts, components.WithSecret())
+	}
+	value, err := shell.Ask(ctx, u.session, components.NewInput(
+		"age-plugin-"+pluginName,
+		strings.TrimSpace(prompt),
+		opts...,
+	))
+	if err != nil {
+		return "", u.mapAbort(err)
+	}
+	return value, nil
+}
@@ -124,6 +124,73 @@ func (u *charmWorkflowUI) SelectPBSRestoreBehavior(ctx context.Context) (PBSRest
 	return behavior, nil
 }
 
+func (u *charmWorkflowUI) SelectFirewallRestoreMode(ctx context.Context) (FirewallRestoreMode, error) {
+	items := []components.SelectorItem[FirewallRestoreMode]{
+		{
+			Label:       "Replace (1:1)",
+			Description: "Replace cluster.fw, guest .fw and host.fw with the files from the backup",
+			Value:       FirewallRestoreReplace,
+		},
+		{
+			Label:       "Review and merge",
+			Description: "Review added/removed/changed rules, aliases, IP sets and security groups; write only the accepted changes",
+			Value:       FirewallRestoreMerge,
+		},
+	}
+	mode, err := shell.Ask(ctx, u.session, components.NewSelector(
+		"PVE firewall restore", items,
+		components.WithSelectorPrompt[FirewallRestoreMode]("Select how the firewall configuration is applied."),
+		components.WithSelectorBack[FirewallRestoreMode](u.abortErr),
+	))
+	if err != nil {
+		return "", u.mapAbort(err)
+	}
+	return mode, nil
+}
+
+func (u *charmWorkflowUI) ReviewFirewallChanges(ctx context.Context, changes []firewallChange) ([]firewallChange, error) {
+	items := make([]components.MultiSelectItem[firewallChange], 0, len(changes))
+	for _, c := range changes {
+		items = append(items, components.MultiSelectItem[firewallChange]{
+			Label:    c.Summary(),
+			Value:    c,
+			Selected: c.Default,
+			Detail:   c.Detail(),
+		})
+	}
+	accepted, err := shell.Ask(ctx, u.session, components.NewMultiSelect(
+		"Review firewall changes", items,
+		components.WithMultiSelectPrompt[firewallChange]("Selected changes are written; unselected ones keep the live configuration. Removals are unselected by default."),
+		components.WithMultiSelectActions[firewallChange]("Select all", "Apply selected"),
+		components.WithMultiSelectDetailPane[firewallChange]("Change"),
+		components.WithMultiSelectBack[firewallChange](u.abortErr),
+	))
+	if err != nil {
+		return nil, u.mapAbort(err)
+	}
+	return accepted, nil
+}
+
+func (u *charmWorkflowUI) SelectAccessTokensToRegenerate(ctx context.Context, tokens []restoredAPIToken) ([]restoredAPIToken, error) {
+	items := make([]components.MultiSelectItem[restoredAPIToken], 0, len(tokens))
+	for _, t := range tokens {
+		items = append(items, components.MultiSelectItem[restoredAPIToken]{
+			Label: t.Summary(),
+			Value: t,
+		})
+	}
+	selected, err := shell.Ask(ctx, u.session, components.NewMultiSelect(
+		"Regenerate token secrets", items,
+		components.WithMultiSelectPrompt[restoredAPIToken]("Selected tokens get a new secret; clients using the old one stop working. ACLs are kept."),
+		components.WithMultiSelectActions[restoredAPIToken]("Select all", "Regenerate selected"),
+		components.WithMultiSelectBack[restoredAPIToken](u.abortErr),
+	))
+	if err != nil {
+		return nil, u.mapAbort(err)
+	}
+	return selected, nil
+}
+
 func (u *charmWorkflowUI) ShowRestorePlan(ctx context.Context, config *SelectiveRestoreConfig) error {
 	if config == nil {
 		return fmt.Errorf("restore configuration not available")
@@ -122,6 +122,35 @@ func (u *cliWorkflowUI) SelectBackupCandidate(ctx context.Context, candidates []
 	return promptCandidateSelection(ctx, u.reader, candidates)
 }
 
+func (u *cliWorkflowUI) SelectSafetyBackup(ctx context.Context, backups []SafetyBackupInfo) (SafetyBackupInfo, error) {
+	for {
+		fmt.Fprintln(u.w(), "\nAvailable safety backups:")
+		for idx, b := range backups {
+			fmt.Fprintf(u.w(), "  [%d] %s\n", idx+1, components.SanitizeLine(formatSafetyBackupChoice(b)))
+		}
+		fmt.Fprintln(u.w(), "  [0] Exit")
+
+		fmt.Fprint(u.w(), "Choice: ")
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return SafetyBackupInfo{}, err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "0" {
+			return SafetyBackupInfo{}, ErrRestoreAborted
+		}
+		if trimmed == "" {
+			continue
+		}
+		idx, err := parseMenuIndex(trimmed, len(backups))
+		if err != nil {
+			fmt.Fprintln(u.w(), err)
+			continue
+		}
+		return backups[idx], nil
+	}
+}
+
 func (u *cliWorkflowUI) PromptDestinationDir(ctx context.Context, defaultDir string) (string, error) {
 	defaultDir = strings.TrimSpace(defaultDir)
 	if defaultDir == "" {
@@ -187,6 +216,8 @@ func (u *cliWorkflowUI) ResolveExistingPath(ctx context.Context, path, descripti
 func (u *cliWorkflowUI) PromptDecryptSecret(ctx context.Context, displayName, previousError string) (string, error) {
 	if strings.TrimSpace(previousError) != "" {
 		fmt.Fprintln(u.w(), components.SanitizeText(strings.TrimSpace(previousError)))
+	} else {
+		fmt.Fprintln(u.w(), ageIdentitySourceHint)
 	}
 
 	displayName = strings.TrimSpace(displayName)
@@ -215,6 +246,26 @@ func (u *cliWorkflowUI) PromptDecryptSecret(ctx context.Context, displayName, pr
 	return trimmed, nil
 }
 
+func (u *cliWorkflowUI) PromptAgePluginValue(ctx context.Context, pluginName, prompt string, secret bool) (string, error) {
+	// prompt comes from the plugin binary: scrub it before printing.
+	fmt.Fprintf(u.w(), "age-plugin-%s: %s: ", components.SanitizeLine(pluginName), components.SanitizeLine(strings.TrimSpace(prompt)))
+	if !secret {
+		line, err := input.ReadLineWithIdle(ctx, u.reader, cliIdleTimeout)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+	inputBytes, err := input.ReadPasswordWithIdle(ctx, readPassword, int(os.Stdin.Fd()), cliIdleTimeout)
+	fmt.Fprintln(u.w())
+	if err != nil {
+		return "", err
+	}
+	value := string(inputBytes)
+	zeroBytes(inputBytes)
+	return value, nil
+}
+
 func (u *cliWorkflowUI) SelectRestoreMode(ctx context.Context, systemType SystemType) (RestoreMode, error) {
 	return ShowRestoreModeMenuWithReader(ctx, u.reader, u.logger, systemType)
 }
@@ -209,6 +209,9 @@ func TestHelperProcess(t *testing.T) {
 	case "cli-success":
 		_, _ = fmt.Fprint(os.Stdout, `{"data":[{"ns":"","path":"/mnt/datastore","comment":"root namespace"},{"ns":"prod","path":"/mnt/datastore/prod","parent":"","ctime":1700000000}]}`)
 		os.Exit(0)
+	case "snapshots-cli-success":
+		_, _ = fmt.Fprint(os.Stdout, `[{"backup-type":"vm","backup-id":"100","backup-time":1700000000,"verification":{"state":"ok","upid":"UPID:pbs:00001234:00005678:00000001:6553F100:verify:store1:root@pam:"}}]`)
+		os.Exit(0)
 	case "cli-error":
 		_, _ = fmt.Fprint(os.Stderr, "CLI exploded")
 		os.Exit(1)
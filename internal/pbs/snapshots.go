@@ -0,0 +1,244 @@
+package pbs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/safefs"
+)
+
+// Snapshot is a single backup snapshot as reported by the PBS datastore API.
+type Snapshot struct {
+	BackupType   string                `json:"backup-type"`
+	BackupID     string                `json:"backup-id"`
+	BackupTime   int64                 `json:"backup-time"`
+	Size         int64                 `json:"size,omitempty"`
+	Protected    bool                  `json:"protected,omitempty"`
+	Verification *SnapshotVerification `json:"verification,omitempty"`
+}
+
+// SnapshotVerification is the last verify state recorded in a snapshot manifest.
+type SnapshotVerification struct {
+	State string `json:"state"`
+	UPID  string `json:"upid"`
+}
+
+// Time returns the start time of the verification task, decoded from its UPID.
+func (v *SnapshotVerification) Time() (time.Time, bool) {
+	if v == nil {
+		return time.Time{}, false
+	}
+	// UPID:<node>:<pid>:<pstart>:<task_id>:<starttime>:<type>:<id>:<auth>:
+	fields := strings.Split(v.UPID, ":")
+	if len(fields) < 6 || fields[0] != "UPID" {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseInt(fields[5], 16, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
+// GroupKey returns the "<type>/<id>" backup group the snapshot belongs to.
+func (s Snapshot) GroupKey() string {
+	return s.BackupType + "/" + s.BackupID
+}
+
+// snapshotTypeDirs are the backup group types PBS stores below a namespace.
+var snapshotTypeDirs = []string{"vm", "ct", "host"}
+
+// snapshotDirLayout is the directory name format PBS uses for snapshot timestamps.
+const snapshotDirLayout = "2006-01-02T15:04:05Z"
+
+const manifestBlobName = "index.json.blob"
+
+// uncompressedBlobMagic identifies an unencrypted, uncompressed PBS data blob;
+// it is followed by a 4-byte CRC and the raw payload.
+var uncompressedBlobMagic = []byte{66, 171, 56, 7, 190, 131, 112, 161}
+
+type listSnapshotsResponse struct {
+	Data []Snapshot `json:"data"`
+}
+
+// NamespaceDir returns the on-disk directory of a namespace inside a datastore,
+// following the PBS "<store>/ns/<a>/ns/<b>" layout for nested namespaces.
+func NamespaceDir(datastorePath, ns string) string {
+	dir := datastorePath
+	for _, part := range strings.Split(strings.Trim(ns, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		dir = filepath.Join(dir, "ns", part)
+	}
+	return dir
+}
+
+// ListSnapshots tries the PBS API (through proxmox-backup-debug) first and, if it
+// fails, falls back to walking the namespace directory. The boolean reports whether
+// the filesystem fallback was used.
+func ListSnapshots(ctx context.Context, datastoreName, datastorePath string, ns Namespace, ioTimeout time.Duration) ([]Snapshot, bool, error) {
+	if snapshots, err := listSnapshotsViaCLI(ctx, datastoreName, ns.Ns); err == nil {
+		return snapshots, false, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	dir := strings.TrimSpace(ns.Path)
+	if dir == "" {
+		dir = NamespaceDir(datastorePath, ns.Ns)
+	}
+	snapshots, err := listSnapshotsFromFilesystem(ctx, dir, ioTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	return snapshots, true, nil
+}
+
+// ListSnapshotsFromFilesystem skips the PBS API and reads the snapshot directories
+// of a namespace directly. Verification state is only available for manifests stored
+// as uncompressed blobs.
+func ListSnapshotsFromFilesystem(ctx context.Context, namespaceDir string, ioTimeout time.Duration) ([]Snapshot, error) {
+	return listSnapshotsFromFilesystem(ctx, namespaceDir, ioTimeout)
+}
+
+func listSnapshotsViaCLI(ctx context.Context, datastore, ns string) ([]Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(datastore) == "" {
+		return nil, fmt.Errorf("datastore name is empty")
+	}
+
+	args := []string{"api", "get", fmt.Sprintf("/admin/datastore/%s/snapshots", datastore)}
+	if ns != "" {
+		args = append(args, "--ns", ns)
+	}
+	args = append(args, "--output-format", "json")
+
+	cmd, cmdErr := execCommand(ctx, "proxmox-backup-debug", args...)
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("snapshot list command failed: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return parseSnapshotList(stdout.Bytes())
+}
+
+// parseSnapshotList accepts both the bare array printed by proxmox-backup-debug and
+// the {"data": [...]} envelope returned by the REST API.
+func parseSnapshotList(data []byte) ([]Snapshot, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var snapshots []Snapshot
+		if err := json.Unmarshal(trimmed, &snapshots); err != nil {
+			return nil, fmt.Errorf("snapshot list parsing failed: %w", err)
+		}
+		return snapshots, nil
+	}
+	var parsed listSnapshotsResponse
+	if err := json.Unmarshal(trimmed, &parsed); err != nil {
+		return nil, fmt.Errorf("snapshot list parsing failed: %w", err)
+	}
+	return parsed.Data, nil
+}
+
+func listSnapshotsFromFilesystem(ctx context.Context, namespaceDir string, ioTimeout time.Duration) ([]Snapshot, error) {
+	if namespaceDir == "" {
+		return nil, fmt.Errorf("namespace path is empty")
+	}
+	if _, err := safefs.Stat(ctx, namespaceDir, ioTimeout); err != nil {
+		return nil, fmt.Errorf("cannot access namespace path %s: %w", namespaceDir, err)
+	}
+
+	var snapshots []Snapshot
+	for _, backupType := range snapshotTypeDirs {
+		typeDir := filepath.Join(namespaceDir, backupType)
+		groups, err := safefs.ReadDir(ctx, typeDir, ioTimeout)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("cannot read %s: %w", typeDir, err)
+		}
+		for _, group := range groups {
+			if !group.IsDir() {
+				continue
+			}
+			groupDir := filepath.Join(typeDir, group.Name())
+			entries, err := safefs.ReadDir(ctx, groupDir, ioTimeout)
+			if err != nil {
+				if errors.Is(err, safefs.ErrTimeout) {
+					return nil, err
+				}
+				continue
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+				ts, err := time.Parse(snapshotDirLayout, entry.Name())
+				if err != nil {
+					continue
+				}
+				snapshots = append(snapshots, Snapshot{
+					BackupType:   backupType,
+					BackupID:     group.Name(),
+					BackupTime:   ts.Unix(),
+					Verification: readManifestVerification(ctx, filepath.Join(groupDir, entry.Name(), manifestBlobName), ioTimeout),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if snapshots[i].GroupKey() != snapshots[j].GroupKey() {
+			return snapshots[i].GroupKey() < snapshots[j].GroupKey()
+		}
+		return snapshots[i].BackupTime < snapshots[j].BackupTime
+	})
+	return snapshots, nil
+}
+
+// readManifestVerification extracts unprotected.verify_state from a snapshot
+// manifest. Compressed or encrypted blobs cannot be decoded here and yield nil.
+func readManifestVerification(ctx context.Context, path string, ioTimeout time.Duration) *SnapshotVerification {
+	data, err := safefs.Run(ctx, "readfile", path, ioTimeout, func() ([]byte, error) {
+		return os.ReadFile(path)
+	})
+	if err != nil || len(data) < len(uncompressedBlobMagic)+4 {
+		return nil
+	}
+	if !bytes.Equal(data[:len(uncompressedBlobMagic)], uncompressedBlobMagic) {
+		return nil
+	}
+	// The header CRC is not validated: a corrupt manifest just yields no state.
+	payload := data[len(uncompressedBlobMagic)+4:]
+
+	var manifest struct {
+		Unprotected struct {
+			VerifyState *SnapshotVerification `json:"verify_state"`
+		} `json:"unprotected"`
+	}
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return nil
+	}
+	return manifest.Unprotected.VerifyState
+}
@@ -0,0 +1,111 @@
+package pbs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNamespaceDir(t *testing.T) {
+	tests := []struct {
+		ns   string
+		want string
+	}{
+		{"", "/mnt/store"},
+		{"prod", "/mnt/store/ns/prod"},
+		{"prod/web", "/mnt/store/ns/prod/ns/web"},
+	}
+	for _, tt := range tests {
+		if got := NamespaceDir("/mnt/store", tt.ns); got != tt.want {
+			t.Fatalf("NamespaceDir(%q) = %q, want %q", tt.ns, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshotVerificationTime(t *testing.T) {
+	v := &SnapshotVerification{State: "ok", UPID: "UPID:pbs:00001234:00005678:00000001:6553F100:verify:store1:root@pam:"}
+	got, ok := v.Time()
+	if !ok || got.Unix() != 0x6553F100 {
+		t.Fatalf("Time() = %v, %v", got, ok)
+	}
+	if _, ok := (&SnapshotVerification{UPID: "garbage"}).Time(); ok {
+		t.Fatal("expected malformed UPID to be rejected")
+	}
+	var nilVerification *SnapshotVerification
+	if _, ok := nilVerification.Time(); ok {
+		t.Fatal("expected nil verification to have no time")
+	}
+}
+
+func TestParseSnapshotListAcceptsEnvelope(t *testing.T) {
+	snapshots, err := parseSnapshotList([]byte(`{"data":[{"backup-type":"ct","backup-id":"101","backup-time":1700000000}]}`))
+	if err != nil {
+		t.Fatalf("parseSnapshotList: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].GroupKey() != "ct/101" {
+		t.Fatalf("unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestListSnapshots_CLISuccess(t *testing.T) {
+	setExecCommandStub(t, "snapshots-cli-success")
+
+	snapshots, usedFallback, err := ListSnapshots(context.Background(), "store1", t.TempDir(), Namespace{}, 0)
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if usedFallback {
+		t.Fatal("expected CLI result, got fallback")
+	}
+	if len(snapshots) != 1 || snapshots[0].Verification == nil || snapshots[0].Verification.State != "ok" {
+		t.Fatalf("unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestListSnapshots_FilesystemFallback(t *testing.T) {
+	setExecCommandStub(t, "cli-error")
+
+	root := t.TempDir()
+	nsDir := NamespaceDir(root, "prod")
+	verified := filepath.Join(nsDir, "vm", "100", "2024-03-01T02:00:00Z")
+	mustMkdirAll(t, verified)
+	manifest := append(append([]byte{}, uncompressedBlobMagic...), 0, 0, 0, 0)
+	manifest = append(manifest, []byte(`{"unprotected":{"verify_state":{"state":"failed","upid":"UPID:pbs:1:2:3:65E1B800:verify:s:root@pam:"}}}`)...)
+	mustWriteFile(t, filepath.Join(verified, manifestBlobName), manifest)
+	mustMkdirAll(t, filepath.Join(nsDir, "vm", "100", "2024-03-02T02:00:00Z"))
+	mustMkdirAll(t, filepath.Join(nsDir, "ct", "200", "2024-03-02T03:00:00Z"))
+	mustMkdirAll(t, filepath.Join(nsDir, "ct", "200", "not-a-snapshot"))
+	// Child namespaces live below "ns" and must not be counted in the parent.
+	mustMkdirAll(t, filepath.Join(nsDir, "ns", "child", "vm", "300", "2024-03-02T03:00:00Z"))
+
+	snapshots, usedFallback, err := ListSnapshots(context.Background(), "store1", root, Namespace{Ns: "prod"}, 0)
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if !usedFallback {
+		t.Fatal("expected filesystem fallback")
+	}
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots, got %+v", snapshots)
+	}
+	if snapshots[0].GroupKey() != "ct/200" || snapshots[1].GroupKey() != "vm/100" {
+		t.Fatalf("unexpected ordering: %+v", snapshots)
+	}
+	first := snapshots[1]
+	if first.Verification == nil || first.Verification.State != "failed" {
+		t.Fatalf("expected verify state from manifest, got %+v", first.Verification)
+	}
+	if want := time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC).Unix(); first.BackupTime != want {
+		t.Fatalf("backup time = %d, want %d", first.BackupTime, want)
+	}
+	if snapshots[2].Verification != nil {
+		t.Fatalf("expected no verify state without manifest, got %+v", snapshots[2].Verification)
+	}
+}
+
+func TestListSnapshotsFromFilesystem_MissingNamespace(t *testing.T) {
+	if _, err := ListSnapshotsFromFilesystem(context.Background(), filepath.Join(t.TempDir(), "missing"), 0); err == nil {
+		t.Fatal("expected error for missing namespace directory")
+	}
+}
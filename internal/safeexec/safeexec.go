@@ -97,6 +97,9 @@ var allowedCommandFactories = map[string]commandFactory{
 	"journalctl": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "journalctl"), args...)
 	},
+	"killall": func(ctx context.Context, args ...string) *exec.Cmd {
+		return withArgs(exec.CommandContext(ctx, "killall"), args...)
+	},
 	"lsblk": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "lsblk"), args...)
 	},
@@ -142,6 +145,9 @@ var allowedCommandFactories = map[string]commandFactory{
 	"ping": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "ping"), args...)
 	},
+	"pmxcfs": func(ctx context.Context, args ...string) *exec.Cmd {
+		return withArgs(exec.CommandContext(ctx, "pmxcfs"), args...)
+	},
 	"pvs": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "pvs"), args...)
 	},
@@ -205,6 +211,9 @@ var allowedCommandFactories = map[string]commandFactory{
 	"systemctl": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "systemctl"), args...)
 	},
+	"systemd-creds": func(ctx context.Context, args ...string) *exec.Cmd {
+		return withArgs(exec.CommandContext(ctx, "systemd-creds"), args...)
+	},
 	"systemd-run": func(ctx context.Context, args ...string) *exec.Cmd {
 		return withArgs(exec.CommandContext(ctx, "systemd-run"), args...)
 	},
@@ -0,0 +1,723 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/safeexec"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// HardeningSeverity weights a hardening rule in the audit score.
+type HardeningSeverity string
+
+const (
+	HardeningLow    HardeningSeverity = "low"
+	HardeningMedium HardeningSeverity = "medium"
+	HardeningHigh   HardeningSeverity = "high"
+)
+
+func (s HardeningSeverity) weight() int {
+	switch s {
+	case HardeningHigh:
+		return 3
+	case HardeningMedium:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// HardeningStatus is the outcome of a single hardening rule. Skipped rules do not
+// count towards the score (the component is not installed or could not be read).
+type HardeningStatus string
+
+const (
+	HardeningPass HardeningStatus = "pass"
+	HardeningFail HardeningStatus = "fail"
+	HardeningSkip HardeningStatus = "skip"
+)
+
+// HardeningRule is one check of the hardening audit. Check returns the status and a
+// short detail line; Remediation is reported for failed rules only. Applies limits a
+// rule to some hosts (nil: every host).
+type HardeningRule struct {
+	ID          string
+	Title       string
+	Severity    HardeningSeverity
+	Remediation string
+	Applies     func(env *HardeningEnv) bool
+	Check       func(ctx context.Context, env *HardeningEnv) (HardeningStatus, string)
+}
+
+// HardeningEnv gives the rules access to the host: files are read below Root and
+// commands go through RunCommand, so rules can be exercised against a fixture tree.
+type HardeningEnv struct {
+	root        string
+	proxmoxType types.ProxmoxType
+	now         time.Time
+	run         func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ProxmoxType returns the product the audited host runs.
+func (e *HardeningEnv) ProxmoxType() types.ProxmoxType { return e.proxmoxType }
+
+// Now returns the reference time of the audit.
+func (e *HardeningEnv) Now() time.Time { return e.now }
+
+// Path maps an absolute host path below the audit root.
+func (e *HardeningEnv) Path(path string) string {
+	if e.root == "" || e.root == "/" {
+		return path
+	}
+	return filepath.Join(e.root, path)
+}
+
+// ReadFile reads an absolute host path below the audit root.
+func (e *HardeningEnv) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(e.Path(path))
+}
+
+// Glob expands an absolute host pattern below the audit root and returns the
+// matching host paths, sorted.
+func (e *HardeningEnv) Glob(pattern string) []string {
+	matches, err := filepath.Glob(e.Path(pattern))
+	if err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if e.root != "" && e.root != "/" {
+			rel, err := filepath.Rel(e.root, m)
+			if err != nil {
+				continue
+			}
+			m = "/" + rel
+		}
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Run executes a command on the host.
+func (e *HardeningEnv) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return e.run(ctx, name, args...)
+}
+
+// HardeningAuditOptions configures RunHardeningAudit. Zero values audit the live
+// host with DefaultHardeningRules.
+type HardeningAuditOptions struct {
+	Root        string
+	ProxmoxType types.ProxmoxType
+	Now         func() time.Time
+	RunCommand  func(ctx context.Context, name string, args ...string) ([]byte, error)
+	Rules       []HardeningRule
+}
+
+// HardeningFinding is the result of one rule in the audit report.
+type HardeningFinding struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Severity    HardeningSeverity `json:"severity"`
+	Status      HardeningStatus   `json:"status"`
+	Detail      string            `json:"detail,omitempty"`
+	Remediation string            `json:"remediation,omitempty"`
+}
+
+// HardeningReport is the scored result of a hardening audit. Score is the share of
+// the applicable rule weight (high 3, medium 2, low 1) that passed, 0-100.
+type HardeningReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Score       int                `json:"score"`
+	Points      int                `json:"points"`
+	MaxPoints   int                `json:"max_points"`
+	Findings    []HardeningFinding `json:"findings"`
+}
+
+// HardeningSummary aggregates a hardening report into the counters used by
+// notifications.
+type HardeningSummary struct {
+	Score       int
+	Passed      int
+	Failed      int
+	Skipped     int
+	FailedHigh  int
+	FailedRules []string
+}
+
+// Summary returns the counters of the report; FailedRules lists the failed rule IDs,
+// high severity first.
+func (r *HardeningReport) Summary() HardeningSummary {
+	sum := HardeningSummary{Score: r.Score}
+	var failed []HardeningFinding
+	for _, f := range r.Findings {
+		switch f.Status {
+		case HardeningPass:
+			sum.Passed++
+		case HardeningSkip:
+			sum.Skipped++
+		case HardeningFail:
+			sum.Failed++
+			if f.Severity == HardeningHigh {
+				sum.FailedHigh++
+			}
+			failed = append(failed, f)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].Severity.weight() > failed[j].Severity.weight()
+	})
+	for _, f := range failed {
+		sum.FailedRules = append(sum.FailedRules, f.ID)
+	}
+	return sum
+}
+
+// Text renders the report for the archive.
+func (r *HardeningReport) Text() string {
+	sum := r.Summary()
+	var b strings.Builder
+	b.WriteString("ProxSave hardening audit\n")
+	fmt.Fprintf(&b, "Generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Score: %d/100 (%d passed, %d failed, %d skipped)\n", r.Score, sum.Passed, sum.Failed, sum.Skipped)
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "\n[%s] %-6s %s - %s\n", strings.ToUpper(string(f.Status)), f.Severity, f.ID, f.Title)
+		if f.Detail != "" {
+			fmt.Fprintf(&b, "       %s\n", f.Detail)
+		}
+		if f.Remediation != "" {
+			fmt.Fprintf(&b, "       Fix: %s\n", f.Remediation)
+		}
+	}
+	return b.String()
+}
+
+// RunHardeningAudit evaluates the hardening rules against the host and scores them.
+func RunHardeningAudit(ctx context.Context, opts HardeningAuditOptions) *HardeningReport {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	run := opts.RunCommand
+	if run == nil {
+		run = safeexec.Output
+	}
+	rules := opts.Rules
+	if rules == nil {
+		rules = DefaultHardeningRules()
+	}
+	env := &HardeningEnv{root: opts.Root, proxmoxType: opts.ProxmoxType, now: now(), run: run}
+
+	report := &HardeningReport{GeneratedAt: env.now}
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			break
+		}
+		finding := HardeningFinding{ID: rule.ID, Title: rule.Title, Severity: rule.Severity}
+		if rule.Applies != nil && !rule.Applies(env) {
+			finding.Status = HardeningSkip
+			finding.Detail = "not applicable to this host"
+		} else {
+			finding.Status, finding.Detail = rule.Check(ctx, env)
+		}
+		switch finding.Status {
+		case HardeningPass:
+			report.Points += rule.Severity.weight()
+			report.MaxPoints += rule.Severity.weight()
+		case HardeningFail:
+			report.MaxPoints += rule.Severity.weight()
+			finding.Remediation = rule.Remediation
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+	report.Score = 100
+	if report.MaxPoints > 0 {
+		report.Score = (report.Points*100 + report.MaxPoints/2) / report.MaxPoints
+	}
+	return report
+}
+
+// DefaultHardeningRules returns the built-in CIS-style rules.
+func DefaultHardeningRules() []HardeningRule {
+	return []HardeningRule{
+		{
+			ID:          "ssh_root_login",
+			Title:       "SSH root login restricted to keys",
+			Severity:    HardeningHigh,
+			Remediation: "Set 'PermitRootLogin prohibit-password' in /etc/ssh/sshd_config (cluster nodes need key-based root SSH) and run 'systemctl reload ssh'.",
+			Check:       checkSSHRootLogin,
+		},
+		{
+			ID:          "ssh_password_authentication",
+			Title:       "SSH password authentication disabled",
+			Severity:    HardeningMedium,
+			Remediation: "Install SSH keys for every administrator, set 'PasswordAuthentication no' in /etc/ssh/sshd_config and run 'systemctl reload ssh'.",
+			Check:       checkSSHPasswordAuthentication,
+		},
+		{
+			ID:          "ssh_empty_passwords",
+			Title:       "SSH empty passwords refused",
+			Severity:    HardeningHigh,
+			Remediation: "Set 'PermitEmptyPasswords no' in /etc/ssh/sshd_config and run 'systemctl reload ssh'.",
+			Check:       checkSSHEmptyPasswords,
+		},
+		{
+			ID:          "ssh_max_auth_tries",
+			Title:       "SSH authentication attempts limited",
+			Severity:    HardeningLow,
+			Remediation: "Set 'MaxAuthTries 4' (or lower) in /etc/ssh/sshd_config and run 'systemctl reload ssh'.",
+			Check:       checkSSHMaxAuthTries,
+		},
+		{
+			ID:          "pam_password_quality",
+			Title:       "PAM password quality policy",
+			Severity:    HardeningMedium,
+			Remediation: "Install libpam-pwquality and set 'minlen = 14' in /etc/security/pwquality.conf.",
+			Check:       checkPAMPasswordQuality,
+		},
+		{
+			ID:          "root_account",
+			Title:       "root is the only UID 0 account and has a password",
+			Severity:    HardeningHigh,
+			Remediation: "Remove or renumber the extra UID 0 accounts in /etc/passwd and set a root password with 'passwd root'.",
+			Check:       checkRootAccount,
+		},
+		{
+			ID:          "unattended_upgrades",
+			Title:       "Unattended security upgrades enabled",
+			Severity:    HardeningMedium,
+			Remediation: "Run 'apt install unattended-upgrades' and 'dpkg-reconfigure -plow unattended-upgrades' (keep the default security-only origins).",
+			Check:       checkUnattendedUpgrades,
+		},
+		{
+			ID:          "web_ui_exposure",
+			Title:       "Proxmox web UI not exposed on every interface",
+			Severity:    HardeningMedium,
+			Remediation: "Limit the web UI to the management network: set LISTEN_IP or ALLOW_FROM/DENY_FROM=\"all\" in /etc/default/pveproxy (PVE), or filter port 8006/8007 with the Proxmox firewall.",
+			Applies:     appliesToProxmox,
+			Check:       checkWebUIExposure,
+		},
+		{
+			ID:          "root_pam_tfa",
+			Title:       "Two-factor authentication on root@pam",
+			Severity:    HardeningHigh,
+			Remediation: "Add a TOTP or WebAuthn factor to root@pam under Datacenter > Permissions > Two Factor (PVE) or Access Control > Two Factor Authentication (PBS).",
+			Applies:     appliesToProxmox,
+			Check:       checkRootPAMTFA,
+		},
+		{
+			ID:          "expired_certificates",
+			Title:       "Proxmox node certificates not expired",
+			Severity:    HardeningHigh,
+			Remediation: "Renew the certificate with 'pvecm updatecerts --force' (self-signed) or 'pvenode acme cert renew' on PVE, 'proxmox-backup-manager cert update' on PBS, then restart the proxy.",
+			Applies:     appliesToProxmox,
+			Check:       checkExpiredCertificates,
+		},
+	}
+}
+
+func appliesToProxmox(env *HardeningEnv) bool {
+	return env.proxmoxType.SupportsPVE() || env.proxmoxType.SupportsPBS()
+}
+
+// sshdOptions returns the effective global sshd options (lowercase keys). Like sshd
+// the first value of a keyword wins; Include directives are followed in place and
+// Match blocks are ignored. ok is false when sshd_config does not exist.
+func (e *HardeningEnv) sshdOptions() (options map[string]string, ok bool) {
+	if _, err := os.Stat(e.Path("/etc/ssh/sshd_config")); err != nil {
+		return nil, false
+	}
+	options = map[string]string{}
+	e.readSSHDConfig("/etc/ssh/sshd_config", options, 0)
+	return options, true
+}
+
+func (e *HardeningEnv) readSSHDConfig(path string, options map[string]string, depth int) {
+	data, err := e.ReadFile(path)
+	if err != nil || depth > 4 {
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		if k, v, found := strings.Cut(fields[0], "="); found {
+			key = strings.ToLower(k)
+			fields = append([]string{k, v}, fields[1:]...)
+		}
+		switch key {
+		case "match":
+			return
+		case "include":
+			for _, pattern := range fields[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join("/etc/ssh", pattern)
+				}
+				for _, inc := range e.Glob(pattern) {
+					e.readSSHDConfig(inc, options, depth+1)
+				}
+			}
+			continue
+		}
+		if _, seen := options[key]; !seen && len(fields) > 1 {
+			options[key] = strings.ToLower(strings.Join(fields[1:], " "))
+		}
+	}
+}
+
+func sshdOption(options map[string]string, key, def string) string {
+	if value, ok := options[key]; ok {
+		return value
+	}
+	return def
+}
+
+func checkSSHRootLogin(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	options, ok := env.sshdOptions()
+	if !ok {
+		return HardeningSkip, "OpenSSH server not installed"
+	}
+	value := sshdOption(options, "permitrootlogin", "prohibit-password")
+	if value == "yes" {
+		return HardeningFail, "PermitRootLogin yes allows root to log in with a password"
+	}
+	return HardeningPass, "PermitRootLogin " + value
+}
+
+func checkSSHPasswordAuthentication(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	options, ok := env.sshdOptions()
+	if !ok {
+		return HardeningSkip, "OpenSSH server not installed"
+	}
+	if value := sshdOption(options, "passwordauthentication", "yes"); value != "no" {
+		return HardeningFail, "PasswordAuthentication " + value
+	}
+	return HardeningPass, "PasswordAuthentication no"
+}
+
+func checkSSHEmptyPasswords(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	options, ok := env.sshdOptions()
+	if !ok {
+		return HardeningSkip, "OpenSSH server not installed"
+	}
+	if value := sshdOption(options, "permitemptypasswords", "no"); value == "yes" {
+		return HardeningFail, "PermitEmptyPasswords yes"
+	}
+	return HardeningPass, "PermitEmptyPasswords no"
+}
+
+func checkSSHMaxAuthTries(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	options, ok := env.sshdOptions()
+	if !ok {
+		return HardeningSkip, "OpenSSH server not installed"
+	}
+	value := sshdOption(options, "maxauthtries", "6")
+	tries, err := strconv.Atoi(value)
+	if err != nil {
+		return HardeningFail, fmt.Sprintf("MaxAuthTries %q is not a number", value)
+	}
+	if tries > 4 {
+		return HardeningFail, fmt.Sprintf("MaxAuthTries %d", tries)
+	}
+	return HardeningPass, fmt.Sprintf("MaxAuthTries %d", tries)
+}
+
+const minPasswordLength = 14
+
+func checkPAMPasswordQuality(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	data, err := env.ReadFile("/etc/pam.d/common-password")
+	if err != nil {
+		return HardeningSkip, "/etc/pam.d/common-password not readable"
+	}
+	module, minlen := "", 0
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") || fields[0] != "password" {
+			continue
+		}
+		for i, field := range fields {
+			if field != "pam_pwquality.so" && field != "pam_cracklib.so" {
+				continue
+			}
+			module = field
+			for _, arg := range fields[i+1:] {
+				if v, ok := strings.CutPrefix(arg, "minlen="); ok {
+					minlen, _ = strconv.Atoi(v)
+				}
+			}
+		}
+	}
+	if module == "" {
+		return HardeningFail, "no pam_pwquality or pam_cracklib module in common-password"
+	}
+	if minlen == 0 && module == "pam_pwquality.so" {
+		files := append([]string{"/etc/security/pwquality.conf"}, env.Glob("/etc/security/pwquality.conf.d/*.conf")...)
+		for _, path := range files {
+			conf, err := env.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			for _, line := range strings.Split(string(conf), "\n") {
+				key, value, ok := strings.Cut(line, "=")
+				if ok && strings.TrimSpace(key) == "minlen" {
+					minlen, _ = strconv.Atoi(strings.TrimSpace(value))
+				}
+			}
+		}
+	}
+	if minlen == 0 {
+		minlen = 8 // pam_pwquality and pam_cracklib default
+	}
+	if minlen < minPasswordLength {
+		return HardeningFail, fmt.Sprintf("%s minimum length %d (want %d)", module, minlen, minPasswordLength)
+	}
+	return HardeningPass, fmt.Sprintf("%s minimum length %d", module, minlen)
+}
+
+func checkRootAccount(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	passwd, err := env.ReadFile("/etc/passwd")
+	if err != nil {
+		return HardeningSkip, "/etc/passwd not readable"
+	}
+	var problems []string
+	for _, line := range strings.Split(string(passwd), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 2 && fields[2] == "0" && fields[0] != "root" {
+			problems = append(problems, fmt.Sprintf("%s has UID 0", fields[0]))
+		}
+	}
+	if shadow, err := env.ReadFile("/etc/shadow"); err == nil {
+		for _, line := range strings.Split(string(shadow), "\n") {
+			fields := strings.Split(line, ":")
+			if len(fields) > 1 && fields[0] == "root" && fields[1] == "" {
+				problems = append(problems, "root has an empty password")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return HardeningFail, strings.Join(problems, "; ")
+	}
+	return HardeningPass, "no other UID 0 account, root password set"
+}
+
+func checkUnattendedUpgrades(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	status, err := env.ReadFile("/var/lib/dpkg/status")
+	if err != nil {
+		return HardeningSkip, "dpkg status not readable"
+	}
+	if !dpkgPackageInstalled(status, "unattended-upgrades") {
+		return HardeningFail, "unattended-upgrades is not installed"
+	}
+	enabled := ""
+	for _, path := range env.Glob("/etc/apt/apt.conf.d/*") {
+		data, err := env.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if rest, ok := strings.CutPrefix(line, "APT::Periodic::Unattended-Upgrade"); ok {
+				enabled = strings.Trim(strings.TrimSpace(rest), `";`)
+			}
+		}
+	}
+	if enabled == "" || enabled == "0" {
+		return HardeningFail, "APT::Periodic::Unattended-Upgrade is not enabled"
+	}
+	return HardeningPass, fmt.Sprintf("APT::Periodic::Unattended-Upgrade \"%s\"", enabled)
+}
+
+func dpkgPackageInstalled(status []byte, name string) bool {
+	for _, stanza := range strings.Split(string(status), "\n\n") {
+		pkg, installed := false, false
+		for _, line := range strings.Split(stanza, "\n") {
+			switch {
+			case line == "Package: "+name:
+				pkg = true
+			case strings.HasPrefix(line, "Status:") && strings.HasSuffix(line, " installed"):
+				installed = true
+			}
+		}
+		if pkg {
+			return installed
+		}
+	}
+	return false
+}
+
+func checkWebUIExposure(ctx context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	out, err := env.Run(ctx, "ss", "-tuln")
+	if err != nil {
+		return HardeningSkip, fmt.Sprintf("ss -tuln failed: %v", err)
+	}
+	ports := map[int]string{}
+	if env.proxmoxType.SupportsPVE() {
+		ports[8006] = "pveproxy"
+	}
+	if env.proxmoxType.SupportsPBS() {
+		ports[8007] = "proxmox-backup-proxy"
+	}
+
+	var exposed, bound []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "tcp") {
+			continue
+		}
+		entry := parseSSLine(line)
+		service, ok := ports[entry.port]
+		if !entry.valid || !ok {
+			continue
+		}
+		switch entry.address {
+		case "0.0.0.0", "::", "*":
+			exposed = append(exposed, fmt.Sprintf("%s on %s:%d", service, entry.address, entry.port))
+		default:
+			bound = append(bound, fmt.Sprintf("%s on %s:%d", service, entry.address, entry.port))
+		}
+	}
+	if len(exposed) == 0 {
+		if len(bound) == 0 {
+			return HardeningSkip, "web UI not listening"
+		}
+		return HardeningPass, strings.Join(bound, ", ")
+	}
+	if env.proxmoxType.SupportsPVE() && pveproxyRestricted(env) {
+		return HardeningPass, strings.Join(exposed, ", ") + ", restricted by ALLOW_FROM/DENY_FROM"
+	}
+	return HardeningFail, strings.Join(exposed, ", ") + " without an access restriction"
+}
+
+// pveproxyRestricted reports whether /etc/default/pveproxy denies every client not in
+// ALLOW_FROM.
+func pveproxyRestricted(env *HardeningEnv) bool {
+	data, err := env.ReadFile("/etc/default/pveproxy")
+	if err != nil {
+		return false
+	}
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && !strings.HasPrefix(key, "#") {
+			values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return values["ALLOW_FROM"] != "" && strings.EqualFold(values["DENY_FROM"], "all")
+}
+
+func checkRootPAMTFA(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	path := "/etc/pve/priv/tfa.cfg"
+	if !env.proxmoxType.SupportsPVE() {
+		path = "/etc/proxmox-backup/tfa.json"
+	}
+	data, err := env.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return HardeningFail, "no second factor configured for any user"
+	}
+	if err != nil {
+		return HardeningSkip, fmt.Sprintf("%s not readable", path)
+	}
+	methods := rootPAMTFAMethods(data)
+	if len(methods) == 0 {
+		return HardeningFail, "root@pam has no TOTP, WebAuthn, U2F or Yubico factor"
+	}
+	return HardeningPass, "root@pam factors: " + strings.Join(methods, ", ")
+}
+
+// rootPAMTFAMethods returns the second-factor kinds configured for root@pam in a
+// PVE tfa.cfg or PBS tfa.json; recovery keys alone do not count.
+func rootPAMTFAMethods(data []byte) []string {
+	var doc struct {
+		Users map[string]map[string]json.RawMessage `json:"users"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// Pre-7.2 PVE format: one "user:type:data" line per user.
+		for _, line := range strings.Split(string(data), "\n") {
+			parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+			if len(parts) >= 2 && parts[0] == "root@pam" {
+				return []string{parts[1]}
+			}
+		}
+		return nil
+	}
+	var methods []string
+	for kind, raw := range doc.Users["root@pam"] {
+		if kind == "recovery" {
+			continue
+		}
+		var entries []json.RawMessage
+		if json.Unmarshal(raw, &entries) == nil && len(entries) > 0 {
+			methods = append(methods, kind)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func checkExpiredCertificates(_ context.Context, env *HardeningEnv) (HardeningStatus, string) {
+	var files []string
+	if env.proxmoxType.SupportsPVE() {
+		files = append(files, env.Glob("/etc/pve/local/*.pem")...)
+	}
+	if env.proxmoxType.SupportsPBS() {
+		files = append(files, "/etc/proxmox-backup/proxy.pem")
+	}
+	var expired, valid []string
+	for _, path := range files {
+		data, err := env.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		cert := firstPEMCertificate(data)
+		if cert == nil {
+			continue
+		}
+		name := filepath.Base(path)
+		if env.now.After(cert.NotAfter) {
+			expired = append(expired, fmt.Sprintf("%s expired %s", name, cert.NotAfter.Format("2006-01-02")))
+		} else {
+			valid = append(valid, fmt.Sprintf("%s until %s", name, cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+	switch {
+	case len(expired) > 0:
+		return HardeningFail, strings.Join(expired, ", ")
+	case len(valid) == 0:
+		return HardeningSkip, "no node certificate found"
+	}
+	return HardeningPass, strings.Join(valid, ", ")
+}
+
+func firstPEMCertificate(data []byte) *x509.Certificate {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return cert
+	}
+}
@@ -0,0 +1,214 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+var hardeningTestNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func writeHardeningFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", path, err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return root
+}
+
+func testCertificatePEM(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pve1"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func runHardeningFixture(t *testing.T, pt types.ProxmoxType, files map[string]string, ss string) map[string]HardeningFinding {
+	t.Helper()
+	root := writeHardeningFixture(t, files)
+	report := RunHardeningAudit(context.Background(), HardeningAuditOptions{
+		Root:        root,
+		ProxmoxType: pt,
+		Now:         func() time.Time { return hardeningTestNow },
+		RunCommand: func(_ context.Context, name string, args ...string) ([]byte, error) {
+			if name == "ss" && ss != "" {
+				return []byte(ss), nil
+			}
+			return nil, errors.New("command not available")
+		},
+	})
+	out := map[string]HardeningFinding{}
+	for _, f := range report.Findings {
+		out[f.ID] = f
+	}
+	return out
+}
+
+func findingStatuses(findings map[string]HardeningFinding) map[string]HardeningStatus {
+	out := map[string]HardeningStatus{}
+	for id, f := range findings {
+		out[id] = f.Status
+	}
+	return out
+}
+
+func TestHardeningAuditHardenedPVEHost(t *testing.T) {
+	findings := runHardeningFixture(t, types.ProxmoxVE, map[string]string{
+		"/etc/ssh/sshd_config":                  "Include /etc/ssh/sshd_config.d/*.conf\nPermitRootLogin yes\nMaxAuthTries 3\n\nMatch User backup\n    PasswordAuthentication yes\n",
+		"/etc/ssh/sshd_config.d/10-harden.conf": "PermitRootLogin prohibit-password\nPasswordAuthentication=no\n",
+		"/etc/pam.d/common-password":            "password\trequisite\t\t\tpam_pwquality.so retry=3\npassword\t[success=1 default=ignore]\tpam_unix.so obscure yescrypt\n",
+		"/etc/security/pwquality.conf":          "# minlen = 8\nminlen = 14\n",
+		"/etc/passwd":                           "root:x:0:0:root:/root:/bin/bash\nbackup:x:34:34:backup:/var/backups:/usr/sbin/nologin\n",
+		"/etc/shadow":                           "root:$y$j9T$abc:19000:0:99999:7:::\n",
+		"/var/lib/dpkg/status":                  "Package: apt\nStatus: install ok installed\n\nPackage: unattended-upgrades\nStatus: install ok installed\nVersion: 2.9\n",
+		"/etc/apt/apt.conf.d/20auto-upgrades":   "APT::Periodic::Update-Package-Lists \"1\";\nAPT::Periodic::Unattended-Upgrade \"1\";\n",
+		"/etc/default/pveproxy":                 "ALLOW_FROM=\"10.0.0.0/24\"\nDENY_FROM=\"all\"\n",
+		"/etc/pve/priv/tfa.cfg":                 `{"users":{"root@pam":{"totp":[{"enable":true}],"recovery":[]}}}`,
+		"/etc/pve/local/pve-ssl.pem":            testCertificatePEM(t, hardeningTestNow.AddDate(1, 0, 0)),
+	}, "Netid State  Recv-Q Send-Q Local Address:Port Peer Address:Port\ntcp   LISTEN 0      4096   *:8006             *:*\ntcp   LISTEN 0      128    0.0.0.0:22         0.0.0.0:*\n")
+
+	for id, f := range findings {
+		if f.Status != HardeningPass {
+			t.Errorf("%s: status=%s detail=%q", id, f.Status, f.Detail)
+		}
+		if f.Remediation != "" {
+			t.Errorf("%s: passed rule must not carry a remediation", id)
+		}
+	}
+	if got := findings["root_pam_tfa"].Detail; got != "root@pam factors: totp" {
+		t.Fatalf("tfa detail=%q", got)
+	}
+}
+
+func TestHardeningAuditDefaultsFailAndScore(t *testing.T) {
+	root := writeHardeningFixture(t, map[string]string{
+		"/etc/ssh/sshd_config":          "PermitRootLogin yes\nPermitEmptyPasswords yes\n",
+		"/etc/pam.d/common-password":    "password\t[success=1 default=ignore]\tpam_unix.so obscure yescrypt\n",
+		"/etc/passwd":                   "root:x:0:0:root:/root:/bin/bash\ntoor:x:0:0::/root:/bin/sh\n",
+		"/etc/shadow":                   "root::19000:0:99999:7:::\n",
+		"/var/lib/dpkg/status":          "Package: unattended-upgrades\nStatus: deinstall ok config-files\n",
+		"/etc/proxmox-backup/tfa.json":  `{"users":{"root@pam":{"recovery":["x"]}}}`,
+		"/etc/proxmox-backup/proxy.pem": testCertificatePEM(t, hardeningTestNow.AddDate(0, 0, -3)),
+	})
+	report := RunHardeningAudit(context.Background(), HardeningAuditOptions{
+		Root:        root,
+		ProxmoxType: types.ProxmoxBS,
+		Now:         func() time.Time { return hardeningTestNow },
+		RunCommand: func(context.Context, string, ...string) ([]byte, error) {
+			return []byte("tcp LISTEN 0 4096 [::]:8007 [::]:*\n"), nil
+		},
+	})
+
+	want := map[string]HardeningStatus{
+		"ssh_root_login":              HardeningFail,
+		"ssh_password_authentication": HardeningFail,
+		"ssh_empty_passwords":         HardeningFail,
+		"ssh_max_auth_tries":          HardeningFail,
+		"pam_password_quality":        HardeningFail,
+		"root_account":                HardeningFail,
+		"unattended_upgrades":         HardeningFail,
+		"web_ui_exposure":             HardeningFail,
+		"root_pam_tfa":                HardeningFail,
+		"expired_certificates":        HardeningFail,
+	}
+	got := map[string]HardeningStatus{}
+	for _, f := range report.Findings {
+		got[f.ID] = f.Status
+		if f.Remediation == "" {
+			t.Errorf("%s: failed rule without remediation", f.ID)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("statuses=%v\nwant %v", got, want)
+	}
+	if report.Score != 0 || report.MaxPoints != 24 {
+		t.Fatalf("score=%d max=%d", report.Score, report.MaxPoints)
+	}
+	sum := report.Summary()
+	if sum.Failed != 10 || sum.FailedHigh != 5 || sum.FailedRules[0] != "ssh_root_login" || sum.FailedRules[9] != "ssh_max_auth_tries" {
+		t.Fatalf("summary=%+v", sum)
+	}
+	for _, want := range []string{
+		"Score: 0/100 (0 passed, 10 failed, 0 skipped)",
+		"[FAIL] high   root_account - root is the only UID 0 account and has a password\n       toor has UID 0; root has an empty password\n",
+		"proxy.pem expired 2026-04-28",
+	} {
+		if !strings.Contains(report.Text(), want) {
+			t.Fatalf("report text missing %q:\n%s", want, report.Text())
+		}
+	}
+}
+
+func TestHardeningAuditSkipsMissingComponents(t *testing.T) {
+	findings := runHardeningFixture(t, types.ProxmoxUnknown, map[string]string{
+		"/etc/passwd": "root:x:0:0:root:/root:/bin/bash\n",
+	}, "")
+	want := map[string]HardeningStatus{
+		"ssh_root_login":              HardeningSkip,
+		"ssh_password_authentication": HardeningSkip,
+		"ssh_empty_passwords":         HardeningSkip,
+		"ssh_max_auth_tries":          HardeningSkip,
+		"pam_password_quality":        HardeningSkip,
+		"root_account":                HardeningPass,
+		"unattended_upgrades":         HardeningSkip,
+		"web_ui_exposure":             HardeningSkip,
+		"root_pam_tfa":                HardeningSkip,
+		"expired_certificates":        HardeningSkip,
+	}
+	if got := findingStatuses(findings); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statuses=%v\nwant %v", got, want)
+	}
+}
+
+func TestHardeningAuditScoresCustomRules(t *testing.T) {
+	rule := func(id string, sev HardeningSeverity, status HardeningStatus) HardeningRule {
+		return HardeningRule{ID: id, Severity: sev, Remediation: "fix " + id, Check: func(context.Context, *HardeningEnv) (HardeningStatus, string) {
+			return status, ""
+		}}
+	}
+	report := RunHardeningAudit(context.Background(), HardeningAuditOptions{Rules: []HardeningRule{
+		rule("a", HardeningHigh, HardeningPass),
+		rule("b", HardeningMedium, HardeningFail),
+		rule("c", HardeningLow, HardeningPass),
+		rule("d", HardeningHigh, HardeningSkip),
+	}})
+	if report.Points != 4 || report.MaxPoints != 6 || report.Score != 67 {
+		t.Fatalf("points=%d max=%d score=%d", report.Points, report.MaxPoints, report.Score)
+	}
+	if report.Findings[1].Remediation != "fix b" || report.Findings[3].Remediation != "" {
+		t.Fatalf("findings=%+v", report.Findings)
+	}
+}
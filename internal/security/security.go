@@ -237,11 +237,11 @@ func (c *Checker) buildDependencyList() []dependencyEntry {
 
 	switch c.cfg.CompressionType {
 	case types.CompressionXZ:
-		deps = append(deps, c.binaryDependency("xz", []string{"xz"}, true, "compression type set to xz"))
+		deps = append(deps, c.compressorDependency("xz")...)
 	case types.CompressionZstd:
-		deps = append(deps, c.binaryDependency("zstd", []string{"zstd"}, true, "compression type set to zstd"))
+		deps = append(deps, c.compressorDependency("zstd")...)
 	case types.CompressionPigz:
-		deps = append(deps, c.binaryDependency("pigz", []string{"pigz"}, true, "compression type set to pigz"))
+		deps = append(deps, c.compressorDependency("pigz")...)
 	case types.CompressionBzip2:
 		deps = append(deps, c.binaryDependency("pbzip2/bzip2", []string{"pbzip2", "bzip2"}, true, "compression type set to bzip2"))
 	case types.CompressionLZMA:
@@ -316,6 +316,21 @@ func (c *Checker) buildDependencyList() []dependencyEntry {
 	return deps
 }
 
+// compressorDependency returns the dependency on an external compressor that
+// has a built-in Go counterpart: none with the native engine, optional with auto
+// (the built-in encoder takes over when it is missing), required otherwise.
+func (c *Checker) compressorDependency(binary string) []dependencyEntry {
+	reason := "compression type set to " + binary
+	switch c.cfg.CompressionEngine {
+	case types.CompressionEngineNative:
+		return nil
+	case types.CompressionEngineAuto:
+		return []dependencyEntry{c.binaryDependency(binary, []string{binary}, false, reason+" (built-in encoder used when missing)")}
+	default:
+		return []dependencyEntry{c.binaryDependency(binary, []string{binary}, true, reason)}
+	}
+}
+
 func (c *Checker) binaryDependency(name string, binaries []string, required bool, reason string) dependencyEntry {
 	return dependencyEntry{
 		Name:     name,
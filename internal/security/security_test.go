@@ -144,6 +144,31 @@ func TestCheckDependenciesMissingRequiredAddsError(t *testing.T) {
 	}
 }
 
+func TestCheckDependenciesCompressorWithBuiltInEngine(t *testing.T) {
+	tests := []struct {
+		engine       types.CompressionEngine
+		wantWarnings int
+	}{
+		{engine: types.CompressionEngineAuto, wantWarnings: 1},
+		{engine: types.CompressionEngineNative, wantWarnings: 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.engine), func(t *testing.T) {
+			cfg := &config.Config{CompressionType: types.CompressionZstd, CompressionEngine: tt.engine}
+			checker := newCheckerForTest(cfg, stubLookPath(map[string]bool{"tar": true}))
+
+			checker.checkDependencies()
+
+			if got := checker.result.ErrorCount(); got != 0 {
+				t.Fatalf("expected no errors with a built-in encoder, got %d issues=%+v", got, checker.result.Issues)
+			}
+			if got := checker.result.WarningCount(); got != tt.wantWarnings {
+				t.Fatalf("expected %d warnings, got %d issues=%+v", tt.wantWarnings, got, checker.result.Issues)
+			}
+		})
+	}
+}
+
 func TestCheckDependenciesMissingOptionalAddsWarning(t *testing.T) {
 	cfg := &config.Config{
 		CompressionType:       types.CompressionNone, // only tar required
@@ -1,9 +1,15 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/safefs"
 )
 
 const bundleSuffix = ".bundle.tar"
@@ -33,18 +39,93 @@ var errBackupSidecarDeleteOnly = errors.New("backup archive deleted; associated
 func isBackupSidecar(path string) bool {
 	return strings.HasSuffix(path, ".sha256") ||
 		strings.HasSuffix(path, ".metadata") ||
-		strings.HasSuffix(path, ".manifest.json")
+		strings.HasSuffix(path, ".manifest.json") ||
+		strings.HasSuffix(path, ".manifest.sig")
 }
 
 // isBackupTempArtifact reports whether a candidate path is an in-flight temp or
-// partial artifact (a .tmp-<...> temp copy, or a <name>.partial archive being
-// written before promotion) rather than a completed backup. Such files must
-// never be counted as backups by any List filter.
+// partial artifact (a .tmp-<...> temp copy, or a <name>.partial archive or one
+// of its volumes being written before promotion) rather than a completed backup.
+// Such files must never be counted as backups by any List filter.
 func isBackupTempArtifact(path string) bool {
 	base := filepath.Base(path)
+	if archive, _, ok := backup.SplitVolumePath(path); ok {
+		path = archive
+	}
 	return strings.HasPrefix(base, ".tmp-") || strings.HasSuffix(path, ".partial")
 }
 
+// isBackupVolume reports whether a candidate path is one volume of a multi-volume
+// archive (<archive>.partNNN, see ARCHIVE_VOLUME_SIZE). Volumes are backup DATA,
+// not sidecars: every List filter reports the set once, under its archive path,
+// and every delete removes all of its volumes.
+func isBackupVolume(path string) bool {
+	_, _, ok := backup.SplitVolumePath(path)
+	return ok
+}
+
+// orderBackupVolumes keeps the names that are volumes of base and returns them
+// ordered by volume index.
+func orderBackupVolumes(base string, names []string) []string {
+	type indexed struct {
+		name  string
+		index int
+	}
+	var volumes []indexed
+	for _, name := range names {
+		archive, index, ok := backup.SplitVolumePath(name)
+		if !ok || archive != base {
+			continue
+		}
+		volumes = append(volumes, indexed{name: name, index: index})
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].index < volumes[j].index })
+	ordered := make([]string, 0, len(volumes))
+	for _, v := range volumes {
+		ordered = append(ordered, v.name)
+	}
+	return ordered
+}
+
+// backupVolumesComplete reports whether ordered volumes (see orderBackupVolumes)
+// are numbered 1..n without gaps. A set with a missing volume cannot be restored
+// and is listed as unverified, so retention neither keeps nor deletes it.
+func backupVolumesComplete(volumes []string) bool {
+	for i, name := range volumes {
+		if _, index, ok := backup.SplitVolumePath(name); !ok || index != i+1 {
+			return false
+		}
+	}
+	return len(volumes) > 0
+}
+
+// listBackupVolumes returns the volumes of base present on a filesystem backend,
+// ordered by index; empty when base was not split.
+func listBackupVolumes(ctx context.Context, base string, timeout time.Duration) ([]string, error) {
+	matches, err := safefs.Run(ctx, "volume-glob", base, timeout, func() ([]string, error) {
+		return filepath.Glob(base + ".part[0-9][0-9][0-9]*")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderBackupVolumes(base, matches), nil
+}
+
+// backupDataFiles returns the data files of the backup at path on a filesystem
+// backend: path itself, or the volumes of a split archive when path does not
+// exist. It fails when neither is present.
+func backupDataFiles(ctx context.Context, path string, timeout time.Duration) ([]string, error) {
+	_, statErr := safefs.Stat(ctx, path, timeout)
+	if statErr == nil {
+		return []string{path}, nil
+	}
+	volumes, err := listBackupVolumes(ctx, path, timeout)
+	if err != nil || len(volumes) == 0 {
+		return nil, statErr
+	}
+	return volumes, nil
+}
+
 // trimBundleSuffix removes the .bundle.tar suffix from a path if present.
 // It returns the trimmed path and whether the suffix was removed.
 func trimBundleSuffix(path string) (string, bool) {
@@ -72,7 +153,9 @@ func bundlePathFor(path string) string {
 
 // buildBackupCandidatePaths returns the list of files that belong to a backup.
 // When includeBundle is true, both the bundle and the legacy single-file layout
-// are included so retention can clean up either form.
+// are included so retention can clean up either form. The volumes of a split
+// archive are not included: their count varies, so each backend adds the ones it
+// finds (listBackupVolumes, or the remote snapshot for cloud).
 func buildBackupCandidatePaths(base string, includeBundle bool) []string {
 	base = normalizeBundleBasePath(base)
 	seen := make(map[string]struct{})
@@ -91,6 +174,7 @@ func buildBackupCandidatePaths(base string, includeBundle bool) []string {
 		base,
 		base + ".sha256",
 		base + ".manifest.json",
+		base + ".manifest.sig",
 		base + ".metadata",
 		base + ".metadata.sha256",
 	}
@@ -29,6 +29,7 @@ func TestBuildBackupCandidatePathsNormalizesBundleInput(t *testing.T) {
 				"backup.tar.zst",
 				"backup.tar.zst.sha256",
 				"backup.tar.zst.manifest.json",
+				"backup.tar.zst.manifest.sig",
 				"backup.tar.zst.metadata",
 				"backup.tar.zst.metadata.sha256",
 			},
@@ -41,6 +42,7 @@ func TestBuildBackupCandidatePathsNormalizesBundleInput(t *testing.T) {
 				"backup.tar.zst",
 				"backup.tar.zst.sha256",
 				"backup.tar.zst.manifest.json",
+				"backup.tar.zst.manifest.sig",
 				"backup.tar.zst.metadata",
 				"backup.tar.zst.metadata.sha256",
 			},
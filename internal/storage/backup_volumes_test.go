@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tis24dev/proxsave/internal/config"
+)
+
+// writeVolumeSet seeds a split archive (volumes + raw sidecars) in dir and
+// returns the archive path the volumes were split from.
+func writeVolumeSet(t *testing.T, dir string, volumes ...string) string {
+	t.Helper()
+	archive := filepath.Join(dir, "host-backup-20260717-020000.tar.zst")
+	for _, suffix := range volumes {
+		writeTestFile(t, archive+suffix, "volume")
+	}
+	writeTestFile(t, archive+".sha256", "h  host-backup-20260717-020000.tar.zst\n")
+	writeTestFile(t, archive+".manifest.json", "{}")
+	return archive
+}
+
+// A split archive is one backup: List reports it once, under the archive path,
+// with the size of all volumes, and Delete removes every volume.
+func TestLocalStorageVolumeSetListAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeVolumeSet(t, dir, ".part001", ".part002", ".part003")
+
+	cfg := &config.Config{BackupPath: dir, BundleAssociatedFiles: true}
+	local, err := NewLocalStorage(cfg, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	backups, err := local.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(backups) != 1 || backups[0].BackupFile != archive {
+		t.Fatalf("List returned %v; want only %s", backupPaths(backups), filepath.Base(archive))
+	}
+	if got := backups[0]; got.Volumes != 3 || !got.Verified {
+		t.Fatalf("volume set listed with volumes=%d verified=%v; want 3, true", got.Volumes, got.Verified)
+	}
+
+	if err := local.Delete(context.Background(), archive); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "host-backup-*")); len(left) != 0 {
+		t.Fatalf("files left after Delete: %v", left)
+	}
+}
+
+// A set with a missing volume cannot be restored; it is listed as unverified so
+// retention neither keeps it as a slot nor deletes it.
+func TestLocalStorageIncompleteVolumeSetIsUnverified(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeSet(t, dir, ".part001", ".part003")
+
+	local, err := NewLocalStorage(&config.Config{BackupPath: dir}, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	backups, err := local.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Verified {
+		t.Fatalf("List returned %v (verified=%v); want one unverified backup", backupPaths(backups), len(backups) == 1 && backups[0].Verified)
+	}
+}
+
+func TestSecondaryStorageStoresVolumeSet(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	archive := writeVolumeSet(t, srcDir, ".part001", ".part002")
+	writeTestFile(t, archive+".metadata", "{}")
+
+	cfg := &config.Config{SecondaryEnabled: true, SecondaryPath: destDir, BundleAssociatedFiles: true}
+	secondary, err := NewSecondaryStorage(cfg, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewSecondaryStorage: %v", err)
+	}
+	if err := secondary.Store(context.Background(), archive, nil); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	for _, suffix := range []string{".part001", ".part002", ".sha256", ".metadata"} {
+		if _, err := os.Stat(filepath.Join(destDir, filepath.Base(archive)+suffix)); err != nil {
+			t.Fatalf("%s not copied: %v", suffix, err)
+		}
+	}
+
+	backups, err := secondary.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Volumes != 2 || backups[0].Size != int64(2*len("volume")) {
+		t.Fatalf("List returned %v; want one 2-volume backup", backupPaths(backups))
+	}
+}
+
+func TestCloudStorageListGroupsVolumeSet(t *testing.T) {
+	cs := newCloudStorageForTest(&config.Config{CloudEnabled: true, CloudRemote: "remote"})
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{{
+			name: "rclone",
+			args: []string{"lsl", "remote:"},
+			out: strings.TrimSpace(`
+1000 2026-07-17 02:10:00 host-backup-20260717.tar.zst.part001
+1000 2026-07-17 02:10:05 host-backup-20260717.tar.zst.part002
+300 2026-07-17 02:10:09 host-backup-20260717.tar.zst.part003
+64 2026-07-17 02:10:10 host-backup-20260717.tar.zst.sha256
+`),
+		}},
+	}
+	cs.execCommand = queue.exec
+
+	backups, err := cs.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("List() = %v, want one backup", backupPaths(backups))
+	}
+	got := backups[0]
+	if got.BackupFile != "host-backup-20260717.tar.zst" || got.Size != 2300 || got.Volumes != 3 || !got.Verified {
+		t.Fatalf("List() = %+v; want the 3-volume archive of 2300 bytes, verified", got)
+	}
+}
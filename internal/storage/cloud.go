@@ -15,6 +15,7 @@ import (
 
 	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/cron"
 	"github.com/tis24dev/proxsave/internal/logging"
 	"github.com/tis24dev/proxsave/internal/safeexec"
 	"github.com/tis24dev/proxsave/internal/safefs"
@@ -77,8 +78,14 @@ type CloudStorage struct {
 	lastRet        RetentionSummary
 	remoteFilesMu  sync.RWMutex
 	remoteFiles    map[string]struct{}
+	remoteChunks   map[string][]string // composite name -> part names (chunked uploads)
 	logPathMu      sync.Mutex
 	logPathMissing bool
+	// uploadWindow and uploadQueue defer uploads outside CLOUD_UPLOAD_WINDOW; a nil
+	// queue or a zero window uploads immediately.
+	uploadWindow cron.Window
+	uploadQueue  *UploadQueue
+	now          func() time.Time
 }
 
 func (c *CloudStorage) remoteLabel() string {
@@ -120,7 +127,7 @@ func validateRcloneArgs(args []string) error {
 		return fmt.Errorf("missing rclone subcommand")
 	}
 	switch args[0] {
-	case "copyto", "delete", "deletefile", "hashsum", "ls", "lsf", "lsl", "mkdir", "touch":
+	case "copyto", "delete", "deletefile", "hashsum", "ls", "lsf", "lsl", "mkdir", "moveto", "touch":
 	default:
 		return fmt.Errorf("rclone subcommand not allowed: %s", args[0])
 	}
@@ -235,6 +242,14 @@ func NewCloudStorage(cfg *config.Config, logger *logging.Logger) (*CloudStorage,
 	if parallelJobs <= 0 {
 		parallelJobs = 1
 	}
+	window, err := cron.ParseWindow(cfg.CloudUploadWindow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLOUD_UPLOAD_WINDOW: %w", err)
+	}
+	var queue *UploadQueue
+	if strings.TrimSpace(cfg.CloudUploadQueueFile) != "" {
+		queue = NewUploadQueue(cfg.CloudUploadQueueFile)
+	}
 	return &CloudStorage{
 		config:         cfg,
 		logger:         logger,
@@ -249,6 +264,9 @@ func NewCloudStorage(cfg *config.Config, logger *logging.Logger) (*CloudStorage,
 		lookPath:       exec.LookPath,
 		waitForRetry:   waitForRetryContext,
 		sleep:          time.Sleep,
+		uploadWindow:   window,
+		uploadQueue:    queue,
+		now:            time.Now,
 	}, nil
 }
 
@@ -683,8 +701,23 @@ func cleanRcloneOutput(output string) string {
 	return strings.TrimSpace(output)
 }
 
-// Store uploads a backup file to cloud storage using rclone
-func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *types.BackupMetadata) (err error) {
+// Store uploads a backup file to cloud storage using rclone. Outside
+// CLOUD_UPLOAD_WINDOW the upload is queued instead and ErrUploadDeferred is
+// returned; after a successful upload inside the window, archives queued by
+// earlier runs are uploaded too.
+func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *types.BackupMetadata) error {
+	if err := c.store(ctx, backupFile, metadata); err != nil {
+		return err
+	}
+	if c.uploadQueue != nil {
+		if _, err := c.DrainUploadQueue(ctx); err != nil {
+			c.logger.Warning("WARNING: Cloud storage: queued uploads not completed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *CloudStorage) store(ctx context.Context, backupFile string, metadata *types.BackupMetadata) (err error) {
 	done := logging.DebugStart(c.logger, "cloud store", "file=%s", filepath.Base(backupFile))
 	defer func() { done(err) }()
 	c.logger.Debug("Cloud storage: preparing to upload %s", filepath.Base(backupFile))
@@ -697,7 +730,12 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 	// Verify source file exists. Bound the stat with FS_IO_TIMEOUT: this runs
 	// before uploadCtx/rclone, so a dead/stale BACKUP_PATH mount must not wedge
 	// Store in an uninterruptible (D-state) syscall here.
-	stat, err := safefs.Stat(ctx, backupFile, c.fsIoTimeout())
+	// A split archive is uploaded as its volumes (see ARCHIVE_VOLUME_SIZE).
+	dataFiles, err := backupDataFiles(ctx, backupFile, c.fsIoTimeout())
+	var stat os.FileInfo
+	if err == nil {
+		stat, err = safefs.Stat(ctx, dataFiles[0], c.fsIoTimeout())
+	}
 	if err != nil {
 		c.logger.Debug("Cloud storage: source file %s not found", backupFile)
 		c.logger.Warning("WARNING: Cloud storage - backup file not found: %s: %v", backupFile, err)
@@ -711,9 +749,14 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 		}
 	}
 
-	primaryFile := backupFile
+	if c.deferUpload(backupFile) {
+		return ErrUploadDeferred
+	}
+
+	split := dataFiles[0] != backupFile
+	primaryFile := dataFiles[0]
 	primaryStat := stat
-	if c.config.BundleAssociatedFiles {
+	if c.config.BundleAssociatedFiles && !split {
 		// When bundling is enabled, callers may pass either the raw archive path
 		// or the bundle path itself. Only switch to the bundle when a distinct
 		// canonical bundle exists alongside the raw archive.
@@ -734,13 +777,22 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 	remoteFile := c.remotePathFor(filename)
 	logging.DebugStep(c.logger, "cloud store", "source size=%s remote=%s", utils.FormatBytes(primaryStat.Size()), c.remoteLabel())
 
-	c.logger.Info("Uploading backup to cloud storage: %s (%s) -> %s (timeout: %ds)",
-		filename,
-		utils.FormatBytes(primaryStat.Size()),
-		c.remoteLabel(),
-		c.config.RcloneTimeoutOperation)
+	if split {
+		filename = filepath.Base(backupFile)
+		c.logger.Info("Uploading backup to cloud storage: %s (%d volumes) -> %s (timeout: %ds per volume)",
+			filename,
+			len(dataFiles),
+			c.remoteLabel(),
+			c.config.RcloneTimeoutOperation)
+	} else {
+		c.logger.Info("Uploading backup to cloud storage: %s (%s) -> %s (timeout: %ds)",
+			filename,
+			utils.FormatBytes(primaryStat.Size()),
+			c.remoteLabel(),
+			c.config.RcloneTimeoutOperation)
+	}
 	c.logger.Debug("Cloud storage: upload retries=%d threads=%d bwlimit=%s",
-		c.config.RcloneRetries, c.config.RcloneTransfers, c.config.RcloneBandwidthLimit)
+		c.config.RcloneRetries, c.config.RcloneTransfers, c.bandwidthLimit())
 
 	// Do NOT pre-bound all tasks under one shared RcloneTimeoutOperation budget: that
 	// let a slow copy consume the budget and starve the post-upload verify, failing a
@@ -749,19 +801,41 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 	// shutdown (cancel-only), and RcloneTimeoutOperation=0 stays unbounded per task.
 	uploadCtx := ctx
 
-	tasks := make([]uploadTask, 0, 4)
+	tasks := make([]uploadTask, 0, len(dataFiles)+4)
 	tasks = append(tasks, uploadTask{
-		local:  primaryFile,
-		remote: remoteFile,
-		verify: true,
+		local:   primaryFile,
+		remote:  remoteFile,
+		verify:  true,
+		chunked: c.useChunkedUpload(primaryStat.Size()),
 	})
-	if !c.config.BundleAssociatedFiles {
+	for _, volume := range dataFiles[1:] {
+		volumeStat, err := safefs.Stat(ctx, volume, c.fsIoTimeout())
+		if err != nil {
+			return &StorageError{
+				Location:    LocationCloud,
+				Operation:   "store",
+				Path:        volume,
+				Err:         fmt.Errorf("source file not found: %w", err),
+				IsCritical:  false,
+				Recoverable: false,
+			}
+		}
+		tasks = append(tasks, uploadTask{
+			local:   volume,
+			remote:  c.remotePathFor(filepath.Base(volume)),
+			verify:  true,
+			chunked: c.useChunkedUpload(volumeStat.Size()),
+			primary: true,
+		})
+	}
+	if !c.config.BundleAssociatedFiles || split {
 		associatedFiles := []string{
 			backupFile + ".sha256",
 			// .manifest.json is the authoritative metadata; upload it so a raw cloud
 			// backup stays discoverable even if the legacy .metadata alias (written
 			// best-effort) is missing (PS-BH-002).
 			backupFile + ".manifest.json",
+			backupFile + ".manifest.sig",
 			backupFile + ".metadata",
 			backupFile + ".metadata.sha256",
 		}
@@ -785,14 +859,40 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 		}
 	}
 
-	logging.DebugStep(c.logger, "cloud store", "upload tasks=%d mode=%s", len(tasks), c.uploadMode)
+	// A chunked upload is queued before it starts, so a run that dies part-way
+	// leaves the archive in the upload queue and the next run resumes it from the
+	// journal instead of leaving the cloud without this backup.
+	resumable := false
+	if tasks[0].chunked && c.uploadQueue != nil {
+		if err := c.uploadQueue.Enqueue(UploadQueueEntry{Archive: backupFile, QueuedAt: c.clock()}); err != nil {
+			c.logger.Debug("Cloud storage: cannot queue %s for resume: %v", filename, err)
+		} else {
+			resumable = true
+		}
+	}
+
+	logging.DebugStep(c.logger, "cloud store", "upload tasks=%d mode=%s chunked=%v", len(tasks), c.uploadMode, tasks[0].chunked)
 	primaryFailed, err := c.uploadTasks(uploadCtx, tasks)
+	if resumable && !primaryFailed {
+		if err := c.uploadQueue.Remove(backupFile); err != nil {
+			c.logger.Debug("Cloud storage: cannot remove %s from the upload queue: %v", filename, err)
+		}
+	}
 	if err != nil {
 		op := "upload_associated"
 		target := "associated files"
-		if primaryFailed {
+		if primaryFailed && resumable {
 			op = "upload"
 			target = "primary backup"
+			c.logger.Warning("WARNING: Cloud Storage: upload of %s interrupted; uploaded parts are kept and the upload resumes on the next run", filename)
+			err = fmt.Errorf("%w: %w", ErrUploadPendingResume, err)
+		} else if primaryFailed {
+			op = "upload"
+			target = "primary backup"
+			if split {
+				// A volume set is stored whole or not at all.
+				c.removeRemoteVolumes(ctx, dataFiles)
+			}
 			c.logger.Warning("WARNING: Cloud Storage: Backup not saved to %s", c.remoteLabel())
 		}
 		c.logger.Warning("WARNING: Cloud Storage: Failed to upload %s: %v", target, err)
@@ -819,6 +919,22 @@ func (c *CloudStorage) Store(ctx context.Context, backupFile string, metadata *t
 	return nil
 }
 
+// removeRemoteVolumes best-effort deletes the remote copies of the given local
+// volumes after a failed upload of a split archive.
+func (c *CloudStorage) removeRemoteVolumes(ctx context.Context, volumes []string) {
+	ctx, cancel := c.boundManagementCtx(ctx)
+	defer cancel()
+	for _, volume := range volumes {
+		args := c.buildRcloneArgs("deletefile")
+		args = append(args, c.remotePathFor(filepath.Base(volume)))
+		if output, err := c.exec(ctx, args[0], args[1:]...); err != nil {
+			if msg := strings.TrimSpace(string(output)); !isRcloneObjectNotFound(msg) {
+				c.logger.Debug("Cloud storage: cannot remove incomplete volume %s: %v: %s", filepath.Base(volume), err, msg)
+			}
+		}
+	}
+}
+
 // sidecarStatWarrantsWarning reports whether a Stat error on an associated (sidecar) file
 // should be surfaced as a warning rather than silently skipped. A stalled-mount TIMEOUT is
 // warned (a silently dropped sidecar would otherwise look merely absent); a genuinely missing
@@ -910,9 +1026,11 @@ func (c *CloudStorage) uploadWithRetry(ctx context.Context, localFile, remoteFil
 }
 
 type uploadTask struct {
-	local  string
-	remote string
-	verify bool
+	local   string
+	remote  string
+	verify  bool
+	chunked bool // upload in CLOUD_UPLOAD_CHUNK_SIZE parts (see uploadChunked)
+	primary bool // a further volume of a split archive: backup data like the first task
 }
 
 func (c *CloudStorage) uploadTasks(ctx context.Context, tasks []uploadTask) (bool, error) {
@@ -920,12 +1038,19 @@ func (c *CloudStorage) uploadTasks(ctx context.Context, tasks []uploadTask) (boo
 		return false, nil
 	}
 
-	first := tasks[0]
-	if err := c.runUploadTask(ctx, first); err != nil {
-		return true, c.wrapUploadError(first.local, err)
+	// The first task is the backup data, and so are the volumes of a split
+	// archive that follow it; they are uploaded in order before the sidecars.
+	data := 1
+	for data < len(tasks) && tasks[data].primary {
+		data++
+	}
+	for _, task := range tasks[:data] {
+		if err := c.runUploadTask(ctx, task); err != nil {
+			return true, c.wrapUploadError(task.local, err)
+		}
 	}
 
-	remaining := tasks[1:]
+	remaining := tasks[data:]
 	if len(remaining) == 0 {
 		return false, nil
 	}
@@ -942,6 +1067,11 @@ func (c *CloudStorage) shouldUseParallelUpload() bool {
 }
 
 func (c *CloudStorage) runUploadTask(parentCtx context.Context, task uploadTask) error {
+	if task.chunked {
+		// Every part runs through runUploadTask with its own copy and verify budgets.
+		return c.uploadChunked(parentCtx, task.local, task.remote)
+	}
+
 	// Copy gets its own budget. Guard >0 so RCLONE_TIMEOUT_OPERATION=0 stays unbounded
 	// rather than collapsing to an already-expired WithTimeout(parentCtx, 0).
 	copyCtx := parentCtx
@@ -1076,9 +1206,9 @@ func (c *CloudStorage) UploadToRemotePath(ctx context.Context, localFile, remote
 func (c *CloudStorage) buildRcloneUploadArgs(localFile, remoteFile string) []string {
 	args := c.buildRcloneArgs("copyto")
 
-	// Add bandwidth limit if configured
-	if c.config.RcloneBandwidthLimit != "" {
-		args = append(args, "--bwlimit", c.config.RcloneBandwidthLimit)
+	// Add bandwidth limit (or timetable) if configured
+	if limit := c.bandwidthLimit(); limit != "" {
+		args = append(args, "--bwlimit", limit)
 	}
 
 	// Add parallel transfers
@@ -1092,6 +1222,16 @@ func (c *CloudStorage) buildRcloneUploadArgs(localFile, remoteFile string) []str
 	return args
 }
 
+// bandwidthLimit returns the --bwlimit value: the RCLONE_BANDWIDTH_SCHEDULE
+// timetable when set (rclone switches rates itself during a long upload),
+// otherwise the static RCLONE_BANDWIDTH_LIMIT.
+func (c *CloudStorage) bandwidthLimit() string {
+	if schedule := strings.TrimSpace(c.config.RcloneBandwidthSchedule); schedule != "" {
+		return schedule
+	}
+	return c.config.RcloneBandwidthLimit
+}
+
 // truncateRcloneOutput bounds captured rclone output to its last maxTail bytes
 // before it is folded into an error message, so a long headless upload cannot
 // carry a multi-megabyte blob into the returned error.
@@ -1262,8 +1402,7 @@ func (c *CloudStorage) remoteSHA256(ctx context.Context, remoteFile string, down
 		// transport error. Require the word "hash" so genuine transport/auth
 		// failures (e.g. "tls: unsupported protocol version", "operation not
 		// supported in this region") stay fatal instead of silently downgrading.
-		msg := strings.ToLower(strings.TrimSpace(string(output)))
-		if strings.Contains(msg, "hash") && (strings.Contains(msg, "not supported") || strings.Contains(msg, "unsupported")) {
+		if isRcloneHashUnsupported(string(output)) {
 			return "", false, nil
 		}
 		return "", false, fmt.Errorf("rclone hashsum failed: %w: %s", err, strings.TrimSpace(string(output)))
@@ -1299,6 +1438,13 @@ func (c *CloudStorage) remoteSHA256(ctx context.Context, remoteFile string, down
 	return "", false, nil
 }
 
+// isRcloneHashUnsupported reports whether rclone hashsum failed because the
+// backend cannot produce the requested hash.
+func isRcloneHashUnsupported(output string) bool {
+	msg := strings.ToLower(strings.TrimSpace(output))
+	return strings.Contains(msg, "hash") && (strings.Contains(msg, "not supported") || strings.Contains(msg, "unsupported"))
+}
+
 // verifyPrimary uses 'rclone lsl' to verify upload (primary method)
 func (c *CloudStorage) verifyPrimary(ctx context.Context, remoteFile string, expectedSize int64, filename string) (bool, error) {
 	args := c.buildRcloneArgs("lsl")
@@ -1429,9 +1575,10 @@ func (c *CloudStorage) List(ctx context.Context) (backups []*types.BackupMetadat
 		}
 	}
 
-	entries := parseLslEntries(string(output))
+	entries, chunks := groupChunkedEntries(parseLslEntries(string(output)))
 	snapshot := buildSnapshot(entries)
 	c.setRemoteSnapshot(snapshot)
+	c.setRemoteChunks(chunks)
 
 	logging.DebugStep(c.logger, "cloud list", "entries=%d", len(entries))
 	backups = c.buildBackupMetadata(entries, snapshot)
@@ -1485,10 +1632,44 @@ func buildSnapshot(entries []lslEntry) map[string]struct{} {
 
 func (c *CloudStorage) buildBackupMetadata(entries []lslEntry, snapshot map[string]struct{}) []*types.BackupMetadata {
 	backups := make([]*types.BackupMetadata, 0, len(entries))
+	volumeSizes := make(map[string]int64)
+	volumeNames := make([]string, 0)
+	for _, entry := range entries {
+		if !isBackupVolume(entry.filename) {
+			continue
+		}
+		if size, ok := parseEntrySize(entry.fields[0]); ok {
+			volumeSizes[entry.filename] = size
+			volumeNames = append(volumeNames, entry.filename)
+		}
+	}
 
 	for _, entry := range entries {
 		filename := entry.filename
 
+		// A split archive is listed once, under its archive name, from its first volume.
+		if archive, index, ok := backup.SplitVolumePath(filename); ok {
+			if index != 1 || !c.isBackupEntry(archive, snapshot) {
+				continue
+			}
+			timestamp, ok := parseEntryTimestamp(entry.fields[1], entry.fields[2])
+			if !ok {
+				continue
+			}
+			volumes := orderBackupVolumes(archive, volumeNames)
+			metadata := &types.BackupMetadata{
+				BackupFile: archive,
+				Timestamp:  timestamp,
+				Volumes:    len(volumes),
+				Verified:   remoteBackupHasCompletionSidecar(archive, snapshot) && backupVolumesComplete(volumes),
+			}
+			for _, volume := range volumes {
+				metadata.Size += volumeSizes[volume]
+			}
+			backups = append(backups, metadata)
+			continue
+		}
+
 		if !c.isBackupEntry(filename, snapshot) {
 			continue
 		}
@@ -1613,7 +1794,7 @@ func (c *CloudStorage) deleteBackupInternal(ctx context.Context, backupFile stri
 	// bundling was enabled, then disabled) is cleaned up. A missing remote
 	// object delete is tolerated, so this is behavior-preserving when no
 	// bundle exists on the remote.
-	candidateNames := buildBackupCandidatePaths(baseName, true)
+	candidateNames := append(buildBackupCandidatePaths(baseName, true), c.remoteVolumeNames(baseName)...)
 	logging.DebugStep(c.logger, "cloud delete", "candidates=%d", len(candidateNames))
 	relativeNames := make([]string, 0, len(candidateNames))
 	for _, name := range candidateNames {
@@ -1635,31 +1816,41 @@ func (c *CloudStorage) deleteBackupInternal(ctx context.Context, backupFile stri
 			continue
 		}
 
-		f := c.remotePathFor(rel)
-		args := c.buildRcloneArgs("deletefile")
-		args = append(args, f)
+		// A chunked object is removed part by part.
+		targets := []string{rel}
+		if parts := c.remoteChunkParts(rel); len(parts) > 0 {
+			targets = parts
+		}
+		relFailed := false
+		for _, target := range targets {
+			f := c.remotePathFor(target)
+			args := c.buildRcloneArgs("deletefile")
+			args = append(args, f)
 
-		c.logger.Debug("Running: %s", strings.Join(args, " "))
+			c.logger.Debug("Running: %s", strings.Join(args, " "))
 
-		output, err := c.exec(ctx, args[0], args[1:]...)
+			output, err := c.exec(ctx, args[0], args[1:]...)
 
-		if err != nil {
-			msg := strings.TrimSpace(string(output))
-			if isRcloneObjectNotFound(msg) {
-				c.logger.Debug("Cloud storage: file already removed %s (%s)", filepath.Base(f), msg)
-				c.removeRemoteSnapshotEntry(rel)
+			if err != nil {
+				msg := strings.TrimSpace(string(output))
+				if isRcloneObjectNotFound(msg) {
+					c.logger.Debug("Cloud storage: file already removed %s (%s)", filepath.Base(f), msg)
+					continue
+				}
+				c.logger.Warning("WARNING: Cloud storage - failed to delete %s: %v: %s",
+					filepath.Base(f), err, msg)
+				failedFiles = append(failedFiles, filepath.Base(f))
+				if !isBackupSidecar(rel) {
+					dataFailed = true
+				}
+				relFailed = true
+				// Continue with other files
 				continue
 			}
-			c.logger.Warning("WARNING: Cloud storage - failed to delete %s: %v: %s",
-				filepath.Base(f), err, msg)
-			failedFiles = append(failedFiles, filepath.Base(f))
-			if !isBackupSidecar(rel) {
-				dataFailed = true
-			}
-			// Continue with other files
-			continue
 		}
-		c.removeRemoteSnapshotEntry(rel)
+		if !relFailed {
+			c.removeRemoteSnapshotEntry(rel)
+		}
 	}
 
 	// Best-effort: delete associated cloud log file for this backup
@@ -1853,13 +2044,14 @@ func (c *CloudStorage) ApplyRetention(ctx context.Context, config RetentionConfi
 	}
 
 	// Apply appropriate retention policy
-	if config.Policy == "gfs" {
+	if config.IsTimeBased() {
 		return c.applyGFSRetention(ctx, backups, config)
 	}
 	return c.applySimpleRetention(ctx, backups, config.MaxBackups)
 }
 
-// applyGFSRetention applies GFS (Grandfather-Father-Son) retention policy
+// applyGFSRetention applies a time-based retention policy: GFS
+// (Grandfather-Father-Son) or age-based thinning rules
 func (c *CloudStorage) applyGFSRetention(ctx context.Context, backups []*types.BackupMetadata, config RetentionConfig) (int, error) {
 	eligible, inert := partitionRetentionEligible(backups)
 	for _, in := range inert {
@@ -1868,20 +2060,16 @@ func (c *CloudStorage) applyGFSRetention(ctx context.Context, backups []*types.B
 	backups = eligible
 
 	config = EffectiveGFSRetentionConfig(config)
-	c.logger.Debug("Applying GFS retention policy (daily=%d, weekly=%d, monthly=%d, yearly=%d)",
-		config.Daily, config.Weekly, config.Monthly, config.Yearly)
+	c.logger.Debug("Applying %s retention policy", config.Describe())
 
-	// Classify backups according to GFS scheme
-	classification := ClassifyBackupsGFS(backups, config)
+	// Classify backups according to the GFS scheme or the thinning rules
+	classification := ClassifyBackups(backups, config)
 
 	// Get statistics
 	stats := GetRetentionStats(classification)
 	kept := len(backups) - stats[CategoryDelete]
-	c.logger.Debug("GFS classification -> daily: %d/%d, weekly: %d/%d, monthly: %d/%d, yearly: %d/%d, kept: %d, to_delete: %d",
-		stats[CategoryDaily], config.Daily,
-		stats[CategoryWeekly], config.Weekly,
-		stats[CategoryMonthly], config.Monthly,
-		stats[CategoryYearly], config.Yearly,
+	c.logger.Debug("Retention classification -> %s, kept: %d, to_delete: %d",
+		FormatRetentionTiers(RetentionTiers(config, stats)),
 		kept,
 		stats[CategoryDelete])
 
@@ -2072,6 +2260,34 @@ func (c *CloudStorage) setRemoteSnapshot(entries map[string]struct{}) {
 	c.remoteFilesMu.Unlock()
 }
 
+func (c *CloudStorage) setRemoteChunks(chunks map[string][]string) {
+	c.remoteFilesMu.Lock()
+	c.remoteChunks = chunks
+	c.remoteFilesMu.Unlock()
+}
+
+// remoteChunkParts returns the part names of name when it is a chunked object.
+func (c *CloudStorage) remoteChunkParts(name string) []string {
+	c.remoteFilesMu.RLock()
+	defer c.remoteFilesMu.RUnlock()
+	return c.remoteChunks[normalizeRemoteRelativePath(name)]
+}
+
+// remoteVolumeNames returns the volumes of a split archive found in the remote
+// snapshot, ordered by index.
+func (c *CloudStorage) remoteVolumeNames(archive string) []string {
+	archive = normalizeRemoteRelativePath(archive)
+	c.remoteFilesMu.RLock()
+	defer c.remoteFilesMu.RUnlock()
+	names := make([]string, 0)
+	for name := range c.remoteFiles {
+		if strings.HasPrefix(name, archive) {
+			names = append(names, name)
+		}
+	}
+	return orderBackupVolumes(archive, names)
+}
+
 func (c *CloudStorage) remoteFileExists(name string) (exists bool, snapshotReady bool) {
 	normalized := normalizeRemoteRelativePath(name)
 	if normalized == "" {
@@ -0,0 +1,417 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/backup"
+	"github.com/tis24dev/proxsave/internal/safefs"
+	"github.com/tis24dev/proxsave/pkg/utils"
+)
+
+// Chunked cloud objects use rclone's chunker layout ("<name>.rclone_chunk.001",
+// ".002", ...), so any rclone chunker overlay with meta_format=none reads them back
+// as the original file.
+const chunkNameInfix = ".rclone_chunk."
+
+// ChunkedObjectName reports whether filename is a part of a chunked cloud object
+// and returns the composite name. Temporary parts of an unfinished upload
+// ("<name>.rclone_chunk.NNN_<txn>") report committed=false.
+func ChunkedObjectName(filename string) (base string, committed, ok bool) {
+	idx := strings.LastIndex(filename, chunkNameInfix)
+	if idx <= 0 {
+		return "", false, false
+	}
+	digits, txn, temporary := strings.Cut(filename[idx+len(chunkNameInfix):], "_")
+	if len(digits) < 3 || strings.Trim(digits, "0123456789") != "" {
+		return "", false, false
+	}
+	if temporary && (len(txn) < 4 || len(txn) > 9 || strings.Trim(txn, "0123456789abcdefghijklmnopqrstuvwxyz") != "") {
+		return "", false, false
+	}
+	return filename[:idx], !temporary, true
+}
+
+// ChunkedRemoteRef returns an rclone connection string that reads the chunked
+// object at ref ("remote:dir/name") through an on-the-fly chunker overlay.
+func ChunkedRemoteRef(ref string) string {
+	dir := remoteDirRef(ref)
+	return fmt.Sprintf(":chunker,remote=\"%s\",meta_format=none:%s",
+		strings.ReplaceAll(dir, `"`, `""`), remoteBaseName(ref))
+}
+
+func chunkPartName(name string, index int) string {
+	return fmt.Sprintf("%s%s%03d", name, chunkNameInfix, index)
+}
+
+func chunkTempName(name string, index int, txn string) string {
+	return chunkPartName(name, index) + "_" + txn
+}
+
+// groupChunkedEntries folds the parts of chunked objects into one entry per
+// composite (summed size, newest part time) and drops temporary parts. parts maps
+// each composite name to every remote part name, temporary ones included.
+func groupChunkedEntries(entries []lslEntry) (grouped []lslEntry, parts map[string][]string) {
+	grouped = make([]lslEntry, 0, len(entries))
+	composites := make(map[string]*lslEntry)
+	var order []string
+	for _, entry := range entries {
+		base, committed, ok := ChunkedObjectName(entry.filename)
+		if !ok {
+			grouped = append(grouped, entry)
+			continue
+		}
+		if parts == nil {
+			parts = make(map[string][]string)
+		}
+		parts[base] = append(parts[base], entry.filename)
+		if !committed || len(entry.fields) < 3 {
+			continue
+		}
+		size, ok := parseEntrySize(entry.fields[0])
+		if !ok {
+			continue
+		}
+		composite, seen := composites[base]
+		if !seen {
+			composite = &lslEntry{fields: []string{"0", entry.fields[1], entry.fields[2], base}, filename: base}
+			composites[base] = composite
+			order = append(order, base)
+		}
+		total, _ := parseEntrySize(composite.fields[0])
+		composite.fields[0] = strconv.FormatInt(total+size, 10)
+		if entry.fields[1]+" "+entry.fields[2] > composite.fields[1]+" "+composite.fields[2] {
+			composite.fields[1], composite.fields[2] = entry.fields[1], entry.fields[2]
+		}
+	}
+	for _, base := range order {
+		grouped = append(grouped, *composites[base])
+	}
+	return grouped, parts
+}
+
+// useChunkedUpload reports whether a file of size bytes is uploaded in parts.
+func (c *CloudStorage) useChunkedUpload(size int64) bool {
+	if c.config == nil || c.config.CloudUploadChunkSize <= 0 || strings.TrimSpace(c.config.CloudUploadJournalDir) == "" {
+		return false
+	}
+	return size > c.config.CloudUploadChunkSize
+}
+
+// uploadChunked uploads localFile to remoteFile part by part. Each part is copied
+// to a temporary remote name and verified on its own, and the journal records it,
+// so a retry (even from a new process) skips the parts already uploaded. Once all
+// parts are up they are renamed to their final chunk names and the journal is
+// removed.
+func (c *CloudStorage) uploadChunked(ctx context.Context, localFile, remoteFile string) (err error) {
+	stat, err := safefs.Stat(ctx, localFile, c.fsIoTimeout())
+	if err != nil {
+		return fmt.Errorf("cannot stat local file: %w", err)
+	}
+	name := remoteBaseName(remoteFile)
+	c.logger.Debug("Cloud storage: hashing %s to key its upload journal", name)
+	sum, err := backup.GenerateChecksumBounded(ctx, c.logger, localFile, c.fsIoTimeout())
+	if err != nil {
+		return fmt.Errorf("hash %s for the upload journal: %w", name, err)
+	}
+
+	journalDir := c.config.CloudUploadJournalDir
+	journalPath := uploadJournalPath(journalDir, sum)
+	unlock, err := lockFile(journalPath+".lock", syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return fmt.Errorf("%s is being uploaded by another process", name)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	journal, err := loadUploadJournal(journalPath)
+	if err != nil {
+		c.logger.Warning("WARNING: Cloud storage: %v; restarting the upload of %s", err, name)
+		journal = nil
+	}
+	if journal != nil && !journal.matches(remoteFile, stat.Size(), c.config.CloudUploadChunkSize) {
+		c.logger.Info("Cloud storage: upload journal of %s does not match the current target or chunk size; restarting", name)
+		c.discardJournalParts(ctx, journal)
+		journal = nil
+	}
+	if journal == nil {
+		journal = newUploadJournal(sum, localFile, remoteFile, stat.Size(), c.config.CloudUploadChunkSize, c.clock())
+	} else if uploaded, total := journal.progress(); uploaded > 0 {
+		c.logger.Info("Cloud storage: resuming upload of %s: %d/%d part(s) already uploaded", name, uploaded, total)
+	}
+	if err := journal.save(journalPath, c.clock()); err != nil {
+		return err
+	}
+
+	c.logger.Info("Cloud storage: uploading %s in %d part(s) of %s", name, len(journal.Parts), utils.FormatBytes(journal.ChunkSize))
+	partFile := filepath.Join(journalDir, sum+".part")
+	defer func() { _ = os.Remove(partFile) }()
+	for i := range journal.Parts {
+		part := &journal.Parts[i]
+		if part.Uploaded {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		partSum, err := copyFileRange(localFile, partFile, part.Offset, part.Size)
+		if err != nil {
+			return fmt.Errorf("part %d/%d: %w", part.Index, len(journal.Parts), err)
+		}
+		part.SHA256 = partSum
+		task := uploadTask{
+			local:  partFile,
+			remote: c.remotePathFor(chunkTempName(name, part.Index, journal.txn())),
+			verify: true,
+		}
+		if err := c.runUploadTask(ctx, task); err != nil {
+			return fmt.Errorf("part %d/%d: %w", part.Index, len(journal.Parts), err)
+		}
+		part.Uploaded = true
+		if err := journal.save(journalPath, c.clock()); err != nil {
+			return err
+		}
+		c.logger.Debug("Cloud storage: %s part %d/%d uploaded", name, part.Index, len(journal.Parts))
+	}
+
+	if err := c.commitChunks(ctx, journal, journalPath, name); err != nil {
+		return err
+	}
+	if err := c.verifyChunkedObject(ctx, journal, journalPath, name); err != nil {
+		return err
+	}
+	if err := os.Remove(journalPath); err != nil && !os.IsNotExist(err) {
+		c.logger.Debug("Cloud storage: cannot remove upload journal %s: %v", journalPath, err)
+	}
+	_ = os.Remove(journalPath + ".lock")
+	return nil
+}
+
+// commitChunks renames the uploaded parts to their final chunk names. rclone
+// performs the rename server-side on backends that support it.
+func (c *CloudStorage) commitChunks(ctx context.Context, journal *uploadJournal, journalPath, name string) error {
+	for i := range journal.Parts {
+		part := &journal.Parts[i]
+		if part.Committed {
+			continue
+		}
+		args := c.buildRcloneArgs("moveto")
+		args = append(args,
+			c.remotePathFor(chunkTempName(name, part.Index, journal.txn())),
+			c.remotePathFor(chunkPartName(name, part.Index)))
+		c.logger.Debug("Running: %s", strings.Join(args, " "))
+		if output, err := c.exec(ctx, args[0], args[1:]...); err != nil {
+			return fmt.Errorf("commit part %d/%d: %w: %s", part.Index, len(journal.Parts), err,
+				strings.TrimSpace(string(truncateRcloneOutput(output))))
+		}
+		part.Committed = true
+		if err := journal.save(journalPath, c.clock()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyChunkedObject checks that the committed parts of name add up to the
+// journal size and that each part's hash matches the bytes that were uploaded.
+// A part whose hash differs is reset in the journal, so the resumed upload
+// sends it again instead of failing on the same chunk forever.
+func (c *CloudStorage) verifyChunkedObject(ctx context.Context, journal *uploadJournal, journalPath, name string) error {
+	verifyCtx, cancel := c.boundManagementCtx(ctx)
+	defer cancel()
+	args := c.buildRcloneArgs("lsl")
+	args = append(args, "--max-depth", "1", "--include", name+chunkNameInfix+"*", c.remoteBase())
+	output, err := c.exec(verifyCtx, args[0], args[1:]...)
+	if err != nil {
+		return fmt.Errorf("verify %s: rclone lsl failed: %w", name, err)
+	}
+	var total int64
+	for _, entry := range parseLslEntries(string(output)) {
+		base, committed, ok := ChunkedObjectName(path.Base(entry.filename))
+		if !ok || !committed || base != name {
+			continue
+		}
+		if partSize, ok := parseEntrySize(entry.fields[0]); ok {
+			total += partSize
+		}
+	}
+	if total != journal.Size {
+		return fmt.Errorf("verify %s: remote parts total %d bytes, expected %d", name, total, journal.Size)
+	}
+
+	remote, ok, err := c.remoteChunkSHA256s(ctx, journal, name, false)
+	if err == nil && !ok && c.verifyDownload {
+		remote, ok, err = c.remoteChunkSHA256s(ctx, journal, name, true)
+	}
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", name, err)
+	}
+	if !ok {
+		c.logger.Debug("Cloud verify: remote backend has no SHA256 for the parts of %s; verified by size only, %s (set CLOUD_VERIFY_DOWNLOAD=true to force download-and-hash)",
+			name, utils.FormatBytes(journal.Size))
+		return nil
+	}
+	var mismatched []int
+	for i := range journal.Parts {
+		part := &journal.Parts[i]
+		want := part.SHA256
+		if want == "" {
+			// Journals written before per-part hashes were recorded.
+			if want, err = hashFileRange(journal.Archive, part.Offset, part.Size); err != nil {
+				return fmt.Errorf("verify %s: part %d/%d: %w", name, part.Index, len(journal.Parts), err)
+			}
+		}
+		if got := remote[chunkPartName(name, part.Index)]; got != want {
+			c.logger.Warning("WARNING: Cloud verify: %s part %d/%d checksum mismatch (remote %s, local %s)",
+				name, part.Index, len(journal.Parts), got, want)
+			part.Uploaded = false
+			part.Committed = false
+			mismatched = append(mismatched, part.Index)
+		}
+	}
+	if len(mismatched) > 0 {
+		if err := journal.save(journalPath, c.clock()); err != nil {
+			return err
+		}
+		return fmt.Errorf("verify %s: checksum mismatch on part(s) %v", name, mismatched)
+	}
+	c.logger.Debug("Cloud verify: chunked object %s complete (%s, %d part checksum(s) verified)",
+		name, utils.FormatBytes(journal.Size), len(journal.Parts))
+	return nil
+}
+
+// remoteChunkSHA256s asks rclone for the SHA256 of every committed part of name
+// in one listing. ok is false when the backend cannot hash one of the parts.
+func (c *CloudStorage) remoteChunkSHA256s(ctx context.Context, journal *uploadJournal, name string, download bool) (map[string]string, bool, error) {
+	if c.config.RcloneTimeoutOperation > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.config.RcloneTimeoutOperation)*time.Second)
+		defer cancel()
+	}
+	args := c.buildRcloneArgs("hashsum")
+	args = append(args, "sha256")
+	if download {
+		args = append(args, "--download")
+	}
+	args = append(args, "--max-depth", "1", "--include", name+chunkNameInfix+"*", c.remoteBase())
+	c.logger.Debug("Remote SHA256: parts of %s download=%v", name, download)
+	output, err := c.exec(ctx, args[0], args[1:]...)
+	if err != nil {
+		if isRcloneHashUnsupported(string(output)) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("rclone hashsum failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	hashes := make(map[string]string, len(journal.Parts))
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		remotePath := strings.TrimLeft(strings.TrimPrefix(line, fields[0]), " \t")
+		base, committed, ok := ChunkedObjectName(path.Base(remotePath))
+		if !ok || !committed || base != name {
+			continue
+		}
+		if norm, nErr := backup.NormalizeChecksum(fields[0]); nErr == nil {
+			hashes[path.Base(remotePath)] = norm
+		}
+	}
+	for _, part := range journal.Parts {
+		if _, found := hashes[chunkPartName(name, part.Index)]; !found {
+			return hashes, false, nil
+		}
+	}
+	return hashes, true, nil
+}
+
+// discardUploadJournals drops the journals (and their uploaded parts, best effort)
+// of a queued upload that will not be resumed.
+func (c *CloudStorage) discardUploadJournals(ctx context.Context, archive string) {
+	if c.config == nil || strings.TrimSpace(c.config.CloudUploadJournalDir) == "" {
+		return
+	}
+	for path, journal := range findUploadJournals(c.config.CloudUploadJournalDir, archive, bundlePathFor(archive)) {
+		c.discardJournalParts(ctx, journal)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			c.logger.Debug("Cloud storage: cannot remove upload journal %s: %v", path, err)
+		}
+	}
+}
+
+// discardJournalParts deletes the temporary parts an abandoned journal uploaded.
+func (c *CloudStorage) discardJournalParts(ctx context.Context, journal *uploadJournal) {
+	name := remoteBaseName(journal.Remote)
+	if name == "" || len(journal.SHA256) < 8 {
+		return
+	}
+	delCtx, cancel := c.boundManagementCtx(ctx)
+	defer cancel()
+	for _, part := range journal.Parts {
+		if !part.Uploaded || part.Committed {
+			continue
+		}
+		target := c.remotePathFor(chunkTempName(name, part.Index, journal.txn()))
+		args := c.buildRcloneArgs("deletefile")
+		args = append(args, target)
+		if output, err := c.exec(delCtx, args[0], args[1:]...); err != nil && !isRcloneObjectNotFound(string(output)) {
+			c.logger.Debug("Cloud storage: cannot delete abandoned part %s: %v", remoteBaseName(target), err)
+		}
+	}
+}
+
+// copyFileRange writes size bytes of src starting at offset to dst (0600) and
+// returns the SHA256 of the bytes written.
+func copyFileRange(src, dst string, offset, size int64) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", filepath.Base(src), err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("create part file: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), io.NewSectionReader(in, offset, size)); err != nil {
+		_ = out.Close()
+		return "", fmt.Errorf("write part file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("write part file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFileRange returns the SHA256 of size bytes of src starting at offset.
+func hashFileRange(src string, offset, size int64) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", filepath.Base(src), err)
+	}
+	defer in.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(in, offset, size)); err != nil {
+		return "", fmt.Errorf("read %s: %w", filepath.Base(src), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
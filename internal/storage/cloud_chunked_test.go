@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+)
+
+func TestChunkedObjectName(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		committed bool
+		ok        bool
+	}{
+		{"pve1-backup.tar.zst.rclone_chunk.001", "pve1-backup.tar.zst", true, true},
+		{"pve1-backup.tar.zst.rclone_chunk.1234", "pve1-backup.tar.zst", true, true},
+		{"pve1-backup.tar.zst.rclone_chunk.002_ab12cd34", "pve1-backup.tar.zst", false, true},
+		{"pve1-backup.tar.zst.rclone_chunk.01", "", false, false},
+		{"pve1-backup.tar.zst.rclone_chunk.002_AB", "", false, false},
+		{"pve1-backup.tar.zst", "", false, false},
+		{"pve1-backup.tar.zst.sha256", "", false, false},
+	}
+	for _, tc := range tests {
+		base, committed, ok := ChunkedObjectName(tc.name)
+		if base != tc.base || committed != tc.committed || ok != tc.ok {
+			t.Errorf("ChunkedObjectName(%q) = (%q, %v, %v), want (%q, %v, %v)",
+				tc.name, base, committed, ok, tc.base, tc.committed, tc.ok)
+		}
+	}
+}
+
+func TestChunkedRemoteRef(t *testing.T) {
+	got := ChunkedRemoteRef("gdrive:proxsave/backup/pve1-backup.tar.zst")
+	want := `:chunker,remote="gdrive:proxsave/backup",meta_format=none:pve1-backup.tar.zst`
+	if got != want {
+		t.Fatalf("ChunkedRemoteRef() = %s, want %s", got, want)
+	}
+}
+
+func TestCloudStorageListGroupsChunkedObjects(t *testing.T) {
+	cs := newCloudStorageForTest(&config.Config{CloudEnabled: true, CloudRemote: "remote"})
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{
+			{name: "rclone", args: []string{"lsl", "remote:"}, out: "" +
+				"4 2026-07-06 10:00:00 pve1-backup-20260706.tar.zst.rclone_chunk.001\n" +
+				"2 2026-07-06 10:05:00 pve1-backup-20260706.tar.zst.rclone_chunk.002\n" +
+				"64 2026-07-06 10:06:00 pve1-backup-20260706.tar.zst.sha256\n" +
+				"4 2026-07-07 10:00:00 pve1-backup-20260707.tar.zst.rclone_chunk.001_ab12cd34\n"},
+		},
+	}
+	cs.execCommand = queue.exec
+
+	backups, err := cs.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("List() = %d backups, want 1 (temporary parts hidden)", len(backups))
+	}
+	got := backups[0]
+	if got.BackupFile != "pve1-backup-20260706.tar.zst" || got.Size != 6 || !got.Verified {
+		t.Fatalf("List()[0] = %+v, want the 6-byte composite, verified", got)
+	}
+	if want := time.Date(2026, 7, 6, 10, 5, 0, 0, time.UTC); !got.Timestamp.Equal(want) {
+		t.Fatalf("Timestamp = %s, want %s", got.Timestamp, want)
+	}
+	if parts := cs.remoteChunkParts("pve1-backup-20260707.tar.zst"); len(parts) != 1 {
+		t.Fatalf("temporary parts of the unfinished upload = %v, want 1", parts)
+	}
+}
+
+func TestCloudStorageDeleteRemovesChunkedParts(t *testing.T) {
+	cs := newCloudStorageForTest(&config.Config{CloudEnabled: true, CloudRemote: "remote"})
+	const name = "pve1-backup-20260706.tar.zst"
+	cs.setRemoteSnapshot(map[string]struct{}{name: {}})
+	cs.setRemoteChunks(map[string][]string{name: {name + ".rclone_chunk.001", name + ".rclone_chunk.002"}})
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{
+			{name: "rclone", args: []string{"deletefile", "remote:" + name + ".rclone_chunk.001"}},
+			{name: "rclone", args: []string{"deletefile", "remote:" + name + ".rclone_chunk.002"}},
+		},
+	}
+	cs.execCommand = queue.exec
+
+	if err := cs.Delete(context.Background(), name); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(queue.queue) != 0 {
+		t.Fatalf("expected both parts deleted, %d call(s) left", len(queue.queue))
+	}
+}
+
+func newChunkedCloudStorageForTest(t *testing.T) (*CloudStorage, string, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	backupFile := filepath.Join(tmpDir, "pve1-backup.tar.zst")
+	writeTestFile(t, backupFile, "abcdefghij")
+	cfg := &config.Config{
+		CloudEnabled:           true,
+		CloudRemote:            "remote",
+		RcloneRetries:          1,
+		RcloneTimeoutOperation: 10,
+		CloudUploadQueueFile:   filepath.Join(tmpDir, "upload-queue.json"),
+		CloudUploadChunkSize:   4,
+		CloudUploadJournalDir:  filepath.Join(tmpDir, "upload-journal"),
+	}
+	cs := newCloudStorageForTest(cfg)
+	cs.sleep = func(time.Duration) {}
+	sum := sha256.Sum256([]byte("abcdefghij"))
+	return cs, backupFile, hex.EncodeToString(sum[:])
+}
+
+func TestCloudStorageStoreResumesChunkedUpload(t *testing.T) {
+	cs, backupFile, sum := newChunkedCloudStorageForTest(t)
+	journalDir := cs.config.CloudUploadJournalDir
+	journal := newUploadJournal(sum, backupFile, "remote:pve1-backup.tar.zst", 10, 4, time.Now())
+	journal.Parts[0].Uploaded = true
+	if err := journal.save(uploadJournalPath(journalDir, sum), time.Now()); err != nil {
+		t.Fatalf("save journal: %v", err)
+	}
+
+	part := filepath.Join(journalDir, sum+".part")
+	remote := "remote:pve1-backup.tar.zst.rclone_chunk."
+	txn := "_" + sum[:8]
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{
+			{name: "rclone", args: []string{"copyto", part, remote + "002" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "002" + txn}, out: "4 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"copyto", part, remote + "003" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "003" + txn}, out: "2 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"moveto", remote + "001" + txn, remote + "001"}},
+			{name: "rclone", args: []string{"moveto", remote + "002" + txn, remote + "002"}},
+			{name: "rclone", args: []string{"moveto", remote + "003" + txn, remote + "003"}},
+			{name: "rclone", args: []string{"lsl", "--max-depth", "1", "--include", "pve1-backup.tar.zst.rclone_chunk.*", "remote:"}, out: "" +
+				"4 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.001\n" +
+				"4 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.002\n" +
+				"2 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.003\n"},
+			{name: "rclone", args: []string{"hashsum", "sha256", "--max-depth", "1", "--include", "pve1-backup.tar.zst.rclone_chunk.*", "remote:"}, out: "" +
+				sha256Hex("abcd") + "  pve1-backup.tar.zst.rclone_chunk.001\n" +
+				sha256Hex("efgh") + "  pve1-backup.tar.zst.rclone_chunk.002\n" +
+				sha256Hex("ij") + "  pve1-backup.tar.zst.rclone_chunk.003\n"},
+			{name: "rclone", args: []string{"lsl", "remote:"}},
+		},
+	}
+	cs.execCommand = queue.exec
+
+	if err := cs.Store(context.Background(), backupFile, nil); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if len(queue.queue) != 0 {
+		t.Fatalf("%d expected rclone call(s) not made", len(queue.queue))
+	}
+	if _, err := os.Stat(uploadJournalPath(journalDir, sum)); !os.IsNotExist(err) {
+		t.Fatalf("journal should be removed after a complete upload, stat err = %v", err)
+	}
+	if entries, _ := cs.uploadQueue.Load(); len(entries) != 0 {
+		t.Fatalf("upload queue = %+v, want empty", entries)
+	}
+}
+
+func TestCloudStorageStoreChunkedFailureIsPendingResume(t *testing.T) {
+	cs, backupFile, sum := newChunkedCloudStorageForTest(t)
+	part := filepath.Join(cs.config.CloudUploadJournalDir, sum+".part")
+	remote := "remote:pve1-backup.tar.zst.rclone_chunk."
+	txn := "_" + sum[:8]
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{
+			{name: "rclone", args: []string{"copyto", part, remote + "001" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "001" + txn}, out: "4 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"copyto", part, remote + "002" + txn}, err: errors.New("connection reset")},
+		},
+	}
+	cs.execCommand = queue.exec
+
+	err := cs.Store(context.Background(), backupFile, nil)
+	if !errors.Is(err, ErrUploadPendingResume) {
+		t.Fatalf("Store() error = %v, want ErrUploadPendingResume", err)
+	}
+	entries, _ := cs.uploadQueue.Load()
+	if len(entries) != 1 || entries[0].Archive != backupFile {
+		t.Fatalf("upload queue = %+v, want the interrupted archive", entries)
+	}
+	journal, err := loadUploadJournal(uploadJournalPath(cs.config.CloudUploadJournalDir, sum))
+	if err != nil || journal == nil {
+		t.Fatalf("journal missing after interruption: %v", err)
+	}
+	if uploaded, total := journal.progress(); uploaded != 1 || total != 3 {
+		t.Fatalf("journal progress = %d/%d, want 1/3", uploaded, total)
+	}
+}
+
+func TestCloudStorageStoreChunkedChecksumMismatchResetsPart(t *testing.T) {
+	cs, backupFile, sum := newChunkedCloudStorageForTest(t)
+	journalDir := cs.config.CloudUploadJournalDir
+	part := filepath.Join(journalDir, sum+".part")
+	remote := "remote:pve1-backup.tar.zst.rclone_chunk."
+	txn := "_" + sum[:8]
+	queue := &commandQueue{
+		t: t,
+		queue: []queuedResponse{
+			{name: "rclone", args: []string{"copyto", part, remote + "001" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "001" + txn}, out: "4 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"copyto", part, remote + "002" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "002" + txn}, out: "4 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"copyto", part, remote + "003" + txn}},
+			{name: "rclone", args: []string{"lsl", remote + "003" + txn}, out: "2 2026-07-06 10:00:00 x"},
+			{name: "rclone", args: []string{"moveto", remote + "001" + txn, remote + "001"}},
+			{name: "rclone", args: []string{"moveto", remote + "002" + txn, remote + "002"}},
+			{name: "rclone", args: []string{"moveto", remote + "003" + txn, remote + "003"}},
+			{name: "rclone", args: []string{"lsl", "--max-depth", "1", "--include", "pve1-backup.tar.zst.rclone_chunk.*", "remote:"}, out: "" +
+				"4 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.001\n" +
+				"4 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.002\n" +
+				"2 2026-07-06 10:00:00 pve1-backup.tar.zst.rclone_chunk.003\n"},
+			{name: "rclone", args: []string{"hashsum", "sha256", "--max-depth", "1", "--include", "pve1-backup.tar.zst.rclone_chunk.*", "remote:"}, out: "" +
+				sha256Hex("abcd") + "  pve1-backup.tar.zst.rclone_chunk.001\n" +
+				sha256Hex("eXgh") + "  pve1-backup.tar.zst.rclone_chunk.002\n" +
+				sha256Hex("ij") + "  pve1-backup.tar.zst.rclone_chunk.003\n"},
+		},
+	}
+	cs.execCommand = queue.exec
+
+	if err := cs.Store(context.Background(), backupFile, nil); !errors.Is(err, ErrUploadPendingResume) {
+		t.Fatalf("Store() error = %v, want ErrUploadPendingResume for a corrupt remote part", err)
+	}
+	if len(queue.queue) != 0 {
+		t.Fatalf("%d expected rclone call(s) not made", len(queue.queue))
+	}
+	journal, err := loadUploadJournal(uploadJournalPath(journalDir, sum))
+	if err != nil || journal == nil {
+		t.Fatalf("journal must be kept after a checksum mismatch: %v", err)
+	}
+	for _, p := range journal.Parts {
+		corrupt := p.Index == 2
+		if p.Uploaded == corrupt || p.Committed == corrupt {
+			t.Fatalf("part %d = %+v, want only part 2 reset for re-upload", p.Index, p)
+		}
+		if want := sha256Hex("abcdefghij"[p.Offset : p.Offset+p.Size]); p.SHA256 != want {
+			t.Fatalf("part %d SHA256 = %s, want %s", p.Index, p.SHA256, want)
+		}
+	}
+}
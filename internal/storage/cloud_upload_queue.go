@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/safefs"
+)
+
+func (c *CloudStorage) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// deferUpload queues backupFile when the run finished outside the upload window.
+// A queue that cannot be written never loses the upload: it goes out immediately.
+func (c *CloudStorage) deferUpload(backupFile string) bool {
+	if c.uploadQueue == nil || c.uploadWindow.IsZero() {
+		return false
+	}
+	now := c.clock()
+	if c.uploadWindow.Contains(now) {
+		return false
+	}
+	if err := c.uploadQueue.Enqueue(UploadQueueEntry{Archive: backupFile, QueuedAt: now}); err != nil {
+		c.logger.Warning("WARNING: Cloud storage: outside the upload window (%s) but the upload queue is not writable (%v); uploading now", c.uploadWindow, err)
+		return false
+	}
+	c.logger.Info("Cloud storage: outside the upload window (%s); %s queued for upload at %s",
+		c.uploadWindow, filepath.Base(backupFile), c.uploadWindow.NextOpen(now).Format("2006-01-02 15:04"))
+	return true
+}
+
+// DrainUploadQueue uploads the queued archives, oldest first, while the upload
+// window stays open. Entries whose archive is gone (removed by local retention)
+// or older than CLOUD_UPLOAD_QUEUE_MAX_AGE are dropped. The first failed upload
+// stops the drain and stays queued for the next attempt. Only one process drains
+// at a time; a concurrent call returns immediately.
+func (c *CloudStorage) DrainUploadQueue(ctx context.Context) (uploaded int, err error) {
+	if c.uploadQueue == nil {
+		return 0, nil
+	}
+	if pending, err := c.uploadQueue.Load(); err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	unlock, err := c.uploadQueue.tryDrainLock()
+	if errors.Is(err, errUploadQueueBusy) {
+		c.logger.Debug("Cloud storage: %v", err)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	entries, err := c.uploadQueue.Load()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	c.logger.Info("Cloud storage: %d queued upload(s) pending", len(entries))
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return uploaded, err
+		}
+		now := c.clock()
+		if !c.uploadWindow.Contains(now) {
+			c.logger.Info("Cloud storage: upload window (%s) closed; %d queued upload(s) wait for %s",
+				c.uploadWindow, len(entries)-i, c.uploadWindow.NextOpen(now).Format("2006-01-02 15:04"))
+			return uploaded, nil
+		}
+		name := filepath.Base(entry.Archive)
+		if maxAge := c.config.CloudUploadQueueMaxAge; maxAge > 0 && now.Sub(entry.QueuedAt) > maxAge {
+			c.logger.Warning("WARNING: Cloud storage: dropping queued upload %s: queued %s ago (CLOUD_UPLOAD_QUEUE_MAX_AGE=%s)",
+				name, now.Sub(entry.QueuedAt).Round(time.Minute), maxAge)
+			c.discardUploadJournals(ctx, entry.Archive)
+			if err := c.uploadQueue.Remove(entry.Archive); err != nil {
+				return uploaded, err
+			}
+			continue
+		}
+		if !c.queuedArchiveExists(ctx, entry.Archive) {
+			c.logger.Warning("WARNING: Cloud storage: dropping queued upload %s: the archive no longer exists locally", name)
+			c.discardUploadJournals(ctx, entry.Archive)
+			if err := c.uploadQueue.Remove(entry.Archive); err != nil {
+				return uploaded, err
+			}
+			continue
+		}
+
+		c.logger.Info("Cloud storage: uploading queued backup %s (queued %s)", name, entry.QueuedAt.Format("2006-01-02 15:04"))
+		storeErr := c.store(ctx, entry.Archive, nil)
+		if errors.Is(storeErr, ErrUploadDeferred) {
+			return uploaded, nil
+		}
+		if storeErr != nil {
+			if recErr := c.uploadQueue.RecordAttempt(entry.Archive, now, storeErr); recErr != nil {
+				c.logger.Debug("Cloud storage: cannot record queued upload attempt: %v", recErr)
+			}
+			return uploaded, fmt.Errorf("%s: %w", name, storeErr)
+		}
+		if err := c.uploadQueue.Remove(entry.Archive); err != nil {
+			return uploaded, err
+		}
+		uploaded++
+	}
+	return uploaded, nil
+}
+
+func (c *CloudStorage) queuedArchiveExists(ctx context.Context, archive string) bool {
+	_, err := safefs.Stat(ctx, archive, c.fsIoTimeout())
+	return err == nil
+}
+
+// UploadQueueStatus implements UploadQueueReporter. It returns nil when uploads
+// are never deferred and nothing is queued.
+func (c *CloudStorage) UploadQueueStatus() *UploadQueueStatus {
+	if c.uploadQueue == nil {
+		return nil
+	}
+	var next time.Time
+	if now := c.clock(); !c.uploadWindow.Contains(now) {
+		next = c.uploadWindow.NextOpen(now)
+	}
+	status, err := c.uploadQueue.Status(c.uploadWindow.String(), next)
+	if err != nil {
+		c.logger.Debug("Cloud storage: cannot read upload queue: %v", err)
+		return nil
+	}
+	if status.Pending == 0 && c.uploadWindow.IsZero() {
+		return nil
+	}
+	return status
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+const (
+	// ForecastHorizonDays is how far ahead the capacity forecast replays backups
+	// and retention. A target that does not fill within it is reported as not filling.
+	ForecastHorizonDays = 365
+
+	// forecastSampleSize is the number of most recent archives used to estimate
+	// the backup cadence and the archive size trend.
+	forecastSampleSize = 30
+
+	// forecastMaxSteps bounds the replay for very frequent schedules.
+	forecastMaxSteps = 20000
+)
+
+// CapacityForecast is the projected fill-up of one storage target.
+type CapacityForecast struct {
+	Location BackupLocation
+
+	// Samples is the number of archives the cadence and size trend are based on.
+	Samples int
+	// BackupsPerDay is the observed backup cadence.
+	BackupsPerDay float64
+	// GrowthPerDay is the archive size trend in bytes/day (never negative).
+	GrowthPerDay float64
+	// NextArchiveBytes is the projected size of the next archive.
+	NextArchiveBytes int64
+
+	// AvailableBytes is the free space now; ReserveBytes the minimum that must
+	// stay free (MIN_DISK_SPACE_*_GB).
+	AvailableBytes int64
+	ReserveBytes   int64
+	// RetainedBytes is the size of the retained backups now; SteadyStateBytes the
+	// projected size the retention policy settles at (at the end of the horizon
+	// when the policy never saturates, e.g. unlimited yearly GFS slots).
+	RetainedBytes    int64
+	SteadyStateBytes int64
+
+	// DaysUntilFull is the number of days until the next backup would no longer
+	// fit above the reserve, or -1 when it does not happen within the horizon.
+	DaysUntilFull int
+}
+
+// WillFill reports whether the target is projected to fill within the horizon.
+func (f *CapacityForecast) WillFill() bool {
+	return f != nil && f.DaysUntilFull >= 0
+}
+
+// ForecastCapacity projects when a storage target runs out of space. It
+// estimates the backup cadence and the archive size trend from the history of
+// backups (as returned by List), then replays future backups followed by the
+// retention policy (count-based, or GFS via the same classification used by
+// ApplyRetention) and tracks the space they take relative to today.
+//
+// The peak after each store, before retention runs, is what has to fit, so a
+// target is "full" once that peak would leave less than reserveBytes free.
+// ok is false when there is not enough history (two timestamped, verified
+// backups) to estimate a cadence.
+func ForecastCapacity(backups []*types.BackupMetadata, retention RetentionConfig, availableBytes, reserveBytes int64, now time.Time) (forecast CapacityForecast, ok bool) {
+	eligible, _ := partitionRetentionEligible(backups)
+	if len(eligible) < 2 {
+		return forecast, false
+	}
+	retained := append([]*types.BackupMetadata(nil), eligible...)
+	sort.Slice(retained, func(i, j int) bool {
+		return retained[i].Timestamp.Before(retained[j].Timestamp)
+	})
+
+	samples := retained
+	if len(samples) > forecastSampleSize {
+		samples = samples[len(samples)-forecastSampleSize:]
+	}
+	first, last := samples[0].Timestamp, samples[len(samples)-1].Timestamp
+	span := last.Sub(first)
+	if span <= 0 {
+		return forecast, false
+	}
+	interval := span / time.Duration(len(samples)-1)
+	if interval <= 0 {
+		return forecast, false
+	}
+
+	sizeAt := archiveSizeTrend(samples)
+
+	forecast = CapacityForecast{
+		Samples:        len(samples),
+		BackupsPerDay:  float64(24*time.Hour) / float64(interval),
+		AvailableBytes: availableBytes,
+		ReserveBytes:   reserveBytes,
+		DaysUntilFull:  -1,
+	}
+	forecast.GrowthPerDay = float64(sizeAt(now.Add(24*time.Hour)) - sizeAt(now))
+
+	var current int64
+	for _, b := range retained {
+		current += b.Size
+	}
+	forecast.RetainedBytes = current
+
+	horizon := now.Add(ForecastHorizonDays * 24 * time.Hour)
+	next := last.Add(interval)
+	if next.Before(now) {
+		next = now
+	}
+	forecast.NextArchiveBytes = sizeAt(next)
+
+	retainedBytes := current
+	for step := 0; !next.After(horizon) && step < forecastMaxSteps; step++ {
+		size := sizeAt(next)
+		peak := retainedBytes + size - current
+		if forecast.DaysUntilFull < 0 && availableBytes-peak < reserveBytes {
+			forecast.DaysUntilFull = int(math.Ceil(next.Sub(now).Hours() / 24))
+		}
+
+		retained = append(retained, &types.BackupMetadata{Timestamp: next, Size: size, Verified: true})
+		retained = applyForecastRetention(retained, retention, next)
+		retainedBytes = 0
+		for _, b := range retained {
+			retainedBytes += b.Size
+		}
+		next = next.Add(interval)
+	}
+	forecast.SteadyStateBytes = retainedBytes
+	return forecast, true
+}
+
+// archiveSizeTrend fits a least-squares line through the archive sizes over
+// time and returns the projected size at a given instant. A shrinking trend is
+// flattened to the mean size so the forecast never counts on archives getting
+// smaller.
+func archiveSizeTrend(samples []*types.BackupMetadata) func(time.Time) int64 {
+	origin := samples[0].Timestamp
+	var sumX, sumY float64
+	for _, b := range samples {
+		sumX += b.Timestamp.Sub(origin).Hours() / 24
+		sumY += float64(b.Size)
+	}
+	n := float64(len(samples))
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX float64
+	for _, b := range samples {
+		dx := b.Timestamp.Sub(origin).Hours()/24 - meanX
+		cov += dx * (float64(b.Size) - meanY)
+		varX += dx * dx
+	}
+	slope := 0.0
+	if varX > 0 {
+		slope = cov / varX
+	}
+	if slope < 0 {
+		slope = 0
+	}
+
+	return func(t time.Time) int64 {
+		x := t.Sub(origin).Hours() / 24
+		size := meanY + slope*(x-meanX)
+		if size < meanY {
+			size = meanY
+		}
+		return int64(math.Round(size))
+	}
+}
+
+// applyForecastRetention returns the backups retention would keep at now.
+// backups is ordered oldest first and the result keeps that order.
+func applyForecastRetention(backups []*types.BackupMetadata, retention RetentionConfig, now time.Time) []*types.BackupMetadata {
+	if retention.IsTimeBased() {
+		classification := classifyBackupsAt(append([]*types.BackupMetadata(nil), backups...), retention, now)
+		kept := backups[:0]
+		for _, b := range backups {
+			if classification[b] != CategoryDelete {
+				kept = append(kept, b)
+			}
+		}
+		return kept
+	}
+	if retention.MaxBackups > 0 && len(backups) > retention.MaxBackups {
+		return backups[len(backups)-retention.MaxBackups:]
+	}
+	return backups
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+const (
+	forecastMiB = int64(1 << 20)
+	forecastGiB = int64(1 << 30)
+)
+
+// dailyBackups returns n verified backups, one per day, the newest at now.
+func dailyBackups(now time.Time, n int, size func(i int) int64) []*types.BackupMetadata {
+	backups := make([]*types.BackupMetadata, 0, n)
+	for i := 0; i < n; i++ {
+		backups = append(backups, &types.BackupMetadata{
+			BackupFile: "backup-" + now.AddDate(0, 0, i-n+1).Format("20060102") + ".tar.zst",
+			Timestamp:  now.AddDate(0, 0, i-n+1),
+			Size:       size(i),
+			Verified:   true,
+		})
+	}
+	return backups
+}
+
+func constantSize(size int64) func(int) int64 {
+	return func(int) int64 { return size }
+}
+
+func TestForecastCapacityNeedsHistory(t *testing.T) {
+	now := time.Date(2026, 7, 6, 2, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now, 1, constantSize(forecastGiB))
+	backups = append(backups, &types.BackupMetadata{Timestamp: now.Add(-time.Hour), Size: forecastGiB})
+
+	if _, ok := ForecastCapacity(backups, RetentionConfig{Policy: "simple", MaxBackups: 7}, 100*forecastGiB, 0, now); ok {
+		t.Fatal("ForecastCapacity() ok with a single verified backup, want not enough history")
+	}
+}
+
+func TestForecastCapacitySimpleRetentionSettles(t *testing.T) {
+	now := time.Date(2026, 7, 6, 2, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now, 10, constantSize(forecastGiB))
+
+	f, ok := ForecastCapacity(backups, RetentionConfig{Policy: "simple", MaxBackups: 10}, 5*forecastGiB, forecastGiB, now)
+	if !ok {
+		t.Fatal("ForecastCapacity() not ok")
+	}
+	if f.WillFill() {
+		t.Fatalf("DaysUntilFull = %d, want -1 (retention caps usage)", f.DaysUntilFull)
+	}
+	if f.SteadyStateBytes != 10*forecastGiB || f.RetainedBytes != 10*forecastGiB {
+		t.Fatalf("retained = %d -> %d, want 10 GiB steady", f.RetainedBytes, f.SteadyStateBytes)
+	}
+	if f.BackupsPerDay < 0.99 || f.BackupsPerDay > 1.01 {
+		t.Fatalf("BackupsPerDay = %.3f, want 1", f.BackupsPerDay)
+	}
+}
+
+func TestForecastCapacityUnlimitedRetentionFills(t *testing.T) {
+	now := time.Date(2026, 7, 6, 2, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now, 10, constantSize(forecastGiB))
+
+	// 10 GiB free, 1 GiB reserve: the 10th new daily archive no longer fits.
+	f, ok := ForecastCapacity(backups, RetentionConfig{Policy: "simple"}, 10*forecastGiB, forecastGiB, now)
+	if !ok {
+		t.Fatal("ForecastCapacity() not ok")
+	}
+	if f.DaysUntilFull != 10 {
+		t.Fatalf("DaysUntilFull = %d, want 10", f.DaysUntilFull)
+	}
+}
+
+func TestForecastCapacityGrowingArchives(t *testing.T) {
+	now := time.Date(2026, 7, 6, 2, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now, 10, func(i int) int64 { return 100*forecastMiB + int64(i)*10*forecastMiB })
+
+	f, ok := ForecastCapacity(backups, RetentionConfig{Policy: "simple", MaxBackups: 10}, 100*forecastGiB, 0, now)
+	if !ok {
+		t.Fatal("ForecastCapacity() not ok")
+	}
+	if got := int64(f.GrowthPerDay); got < 9*forecastMiB || got > 11*forecastMiB {
+		t.Fatalf("GrowthPerDay = %d, want ~10 MiB", got)
+	}
+	if f.NextArchiveBytes != 200*forecastMiB {
+		t.Fatalf("NextArchiveBytes = %d, want 200 MiB", f.NextArchiveBytes)
+	}
+	if f.SteadyStateBytes <= f.RetainedBytes {
+		t.Fatalf("SteadyStateBytes = %d, want above the current %d for growing archives", f.SteadyStateBytes, f.RetainedBytes)
+	}
+}
+
+func TestForecastCapacityGFSSteadyState(t *testing.T) {
+	now := time.Date(2026, 7, 6, 2, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now, 7, constantSize(forecastMiB))
+	retention := RetentionConfig{Policy: "gfs", Daily: 7, Weekly: 4, Monthly: 3, Yearly: 1}
+
+	f, ok := ForecastCapacity(backups, retention, 100*forecastGiB, 0, now)
+	if !ok {
+		t.Fatal("ForecastCapacity() not ok")
+	}
+	// Once saturated the policy keeps 7 daily + 4 weekly + 3 monthly + 1 yearly.
+	if f.SteadyStateBytes != 15*forecastMiB {
+		t.Fatalf("SteadyStateBytes = %d MiB, want 15 MiB", f.SteadyStateBytes/forecastMiB)
+	}
+	if f.WillFill() {
+		t.Fatalf("DaysUntilFull = %d, want -1", f.DaysUntilFull)
+	}
+
+	// The same history with unlimited yearly slots grows by one archive a year.
+	retention.Yearly = 0
+	f, _ = ForecastCapacity(backups, retention, 100*forecastGiB, 0, now)
+	if f.SteadyStateBytes < 15*forecastMiB {
+		t.Fatalf("SteadyStateBytes with unlimited yearly = %d MiB, want at least 15 MiB", f.SteadyStateBytes/forecastMiB)
+	}
+}
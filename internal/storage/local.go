@@ -102,8 +102,10 @@ func (l *LocalStorage) Store(ctx context.Context, backupFile string, metadata *t
 		return err
 	}
 
-	// Verify file exists (bounded against a dead/stale mount).
-	if _, err := safefs.Stat(ctx, backupFile, fsIoTimeout(l.config)); err != nil {
+	// A split archive is stored as its volumes; verify they (or the single
+	// file) exist, bounded against a dead/stale mount.
+	files, err := backupDataFiles(ctx, backupFile, fsIoTimeout(l.config))
+	if err != nil {
 		l.logger.Debug("Local storage: source file %s not found", backupFile)
 		return &StorageError{
 			Location:   LocationPrimary,
@@ -114,11 +116,13 @@ func (l *LocalStorage) Store(ctx context.Context, backupFile string, metadata *t
 		}
 	}
 
-	// Set proper permissions on the backup file
-	l.logger.Debug("Local storage: setting ownership/permissions on %s", filepath.Base(backupFile))
-	if err := l.fsDetector.SetPermissions(ctx, backupFile, 0, 0, 0600, l.fsInfo); err != nil {
-		l.logger.Warning("Failed to set permissions on %s: %v", backupFile, err)
-		// Not critical - continue
+	// Set proper permissions on the backup file(s)
+	for _, file := range files {
+		l.logger.Debug("Local storage: setting ownership/permissions on %s", filepath.Base(file))
+		if err := l.fsDetector.SetPermissions(ctx, file, 0, 0, 0600, l.fsInfo); err != nil {
+			l.logger.Warning("Failed to set permissions on %s: %v", file, err)
+			// Not critical - continue
+		}
 	}
 
 	l.logger.Debug("Backup stored successfully in local storage: %s", backupFile)
@@ -192,6 +196,17 @@ func (l *LocalStorage) List(ctx context.Context) (backups []*types.BackupMetadat
 		if isBackupTempArtifact(match) {
 			continue
 		}
+		// A split archive is listed once, under its archive path, from its first volume.
+		var volumes []string
+		if archive, index, ok := backup.SplitVolumePath(match); ok {
+			if index != 1 {
+				continue
+			}
+			match = archive
+			if volumes, err = listBackupVolumes(ctx, archive, timeout); err != nil {
+				l.logger.Debug("Local storage: cannot list volumes of %s: %v", filepath.Base(archive), err)
+			}
+		}
 
 		// When bundling is enabled, skip standalone files that have a corresponding bundle
 		if l.config != nil && l.config.BundleAssociatedFiles {
@@ -218,15 +233,23 @@ func (l *LocalStorage) List(ctx context.Context) (backups []*types.BackupMetadat
 			metadata = &types.BackupMetadata{
 				BackupFile: match,
 			}
-			if stat, statErr := safefs.Stat(ctx, match, timeout); statErr == nil {
+			statPath := match
+			if len(volumes) > 0 {
+				statPath = volumes[0]
+			}
+			if stat, statErr := safefs.Stat(ctx, statPath, timeout); statErr == nil {
 				metadata.Timestamp = stat.ModTime()
 				metadata.Size = stat.Size()
 			} else {
-				l.logger.Debug("Failed to stat %s after metadata load failure: %v", match, statErr)
+				l.logger.Debug("Failed to stat %s after metadata load failure: %v", statPath, statErr)
 			}
 		}
 
 		metadata.Verified = backupHasCompletionSidecar(ctx, match, timeout)
+		if len(volumes) > 0 {
+			metadata.Volumes = len(volumes)
+			metadata.Verified = metadata.Verified && backupVolumesComplete(volumes)
+		}
 		backups = append(backups, metadata)
 	}
 
@@ -371,12 +394,17 @@ func (l *LocalStorage) deleteBackupInternal(ctx context.Context, backupFile stri
 	// bundling was enabled, then disabled) is cleaned up. Remove of an absent
 	// bundle is a no-op, so this is behavior-preserving when no bundle exists.
 	filesToDelete := buildBackupCandidatePaths(basePath, true)
+	timeout := fsIoTimeout(l.config)
+	volumes, err := listBackupVolumes(ctx, basePath, timeout)
+	if err != nil {
+		return false, fmt.Errorf("list volumes of %s: %w", filepath.Base(basePath), err)
+	}
+	filesToDelete = append(filesToDelete, volumes...)
 
 	// Delete all files; collect real removal failures (not "already gone") and
 	// track whether the data archive itself (not just a sidecar) failed, so the
 	// caller never counts a backup whose archive remains on disk as deleted
 	// (PS-BH-001), while a sidecar-only failure still counts (the archive IS gone).
-	timeout := fsIoTimeout(l.config)
 	var failedFiles []string
 	dataFailed := false
 	for _, f := range filesToDelete {
@@ -491,13 +519,14 @@ func (l *LocalStorage) ApplyRetention(ctx context.Context, config RetentionConfi
 	}
 
 	// Apply appropriate retention policy
-	if config.Policy == "gfs" {
+	if config.IsTimeBased() {
 		return l.applyGFSRetention(ctx, backups, config)
 	}
 	return l.applySimpleRetention(ctx, backups, config.MaxBackups)
 }
 
-// applyGFSRetention applies GFS (Grandfather-Father-Son) retention policy
+// applyGFSRetention applies a time-based retention policy: GFS
+// (Grandfather-Father-Son) or age-based thinning rules
 func (l *LocalStorage) applyGFSRetention(ctx context.Context, backups []*types.BackupMetadata, config RetentionConfig) (int, error) {
 	eligible, inert := partitionRetentionEligible(backups)
 	for _, in := range inert {
@@ -506,23 +535,19 @@ func (l *LocalStorage) applyGFSRetention(ctx context.Context, backups []*types.B
 	backups = eligible
 
 	config = EffectiveGFSRetentionConfig(config)
-	l.logger.Debug("Applying GFS retention policy (daily=%d, weekly=%d, monthly=%d, yearly=%d)",
-		config.Daily, config.Weekly, config.Monthly, config.Yearly)
+	l.logger.Debug("Applying %s retention policy", config.Describe())
 
 	initialLogs := l.countLogFiles(ctx)
 	logsDeleted := 0
 
-	// Classify backups according to GFS scheme
-	classification := ClassifyBackupsGFS(backups, config)
+	// Classify backups according to the GFS scheme or the thinning rules
+	classification := ClassifyBackups(backups, config)
 
 	// Get statistics
 	stats := GetRetentionStats(classification)
 	kept := len(backups) - stats[CategoryDelete]
-	l.logger.Debug("GFS classification -> daily: %d/%d, weekly: %d/%d, monthly: %d/%d, yearly: %d/%d, kept: %d, to_delete: %d",
-		stats[CategoryDaily], config.Daily,
-		stats[CategoryWeekly], config.Weekly,
-		stats[CategoryMonthly], config.Monthly,
-		stats[CategoryYearly], config.Yearly,
+	l.logger.Debug("Retention classification -> %s, kept: %d, to_delete: %d",
+		FormatRetentionTiers(RetentionTiers(config, stats)),
 		kept,
 		stats[CategoryDelete])
 
@@ -60,7 +60,8 @@ func backupHasCompletionSidecar(ctx context.Context, archivePath string, timeout
 
 // RetentionConfig defines the retention policy configuration
 type RetentionConfig struct {
-	// Policy type: "simple" (count-based) or "gfs" (time-distributed)
+	// Policy type: "simple" (count-based), "gfs" (time-distributed) or
+	// "thinning" (age-based rules)
 	Policy string
 
 	// Simple retention: total number of backups to keep
@@ -72,19 +73,62 @@ type RetentionConfig struct {
 	Weekly  int // Keep N weekly backups (one per week)
 	Monthly int // Keep N monthly backups (one per month)
 	Yearly  int // Keep N yearly backups (one per year, 0 = keep all)
+	Hourly  int // Keep N hourly backups (one per clock hour, before the daily tier, 0 = disabled)
+
+	// Thinning retention: age-based rules, youngest first (RETENTION_RULES)
+	Rules []config.ThinningRule
+}
+
+// IsTimeBased reports whether the policy classifies backups by their age (gfs
+// or thinning) rather than keeping a fixed number of them.
+func (c RetentionConfig) IsTimeBased() bool {
+	return c.Policy == "gfs" || c.Policy == "thinning"
+}
+
+// Describe renders the policy as one line, e.g. "GFS (daily=7, weekly=4,
+// monthly=12, yearly=0)" or "thinning (48h:all, 7d:6h, forever:1M)".
+func (c RetentionConfig) Describe() string {
+	switch c.Policy {
+	case "gfs":
+		hourly := ""
+		if c.Hourly > 0 {
+			hourly = fmt.Sprintf("hourly=%d, ", c.Hourly)
+		}
+		return fmt.Sprintf("GFS (%sdaily=%d, weekly=%d, monthly=%d, yearly=%d)", hourly, c.Daily, c.Weekly, c.Monthly, c.Yearly)
+	case "thinning":
+		rules := make([]string, 0, len(c.Rules))
+		for _, r := range c.Rules {
+			rules = append(rules, r.String())
+		}
+		return fmt.Sprintf("thinning (%s)", strings.Join(rules, ", "))
+	}
+	if c.MaxBackups > 0 {
+		return fmt.Sprintf("simple (keep %d newest)", c.MaxBackups)
+	}
+	return "simple (disabled, everything is kept)"
 }
 
 // RetentionCategory represents the classification of a backup
 type RetentionCategory string
 
 const (
+	CategoryHourly  RetentionCategory = "hourly"
 	CategoryDaily   RetentionCategory = "daily"
 	CategoryWeekly  RetentionCategory = "weekly"
 	CategoryMonthly RetentionCategory = "monthly"
 	CategoryYearly  RetentionCategory = "yearly"
 	CategoryDelete  RetentionCategory = "delete"
+	// CategoryKeep marks a backup kept by simple (count-based) retention, or the
+	// newest backup thinning retention keeps whatever its age.
+	CategoryKeep RetentionCategory = "keep"
 )
 
+// ThinningCategory is the category of the backups kept by a thinning rule: the
+// rule itself (e.g. "7d:6h").
+func ThinningCategory(rule config.ThinningRule) RetentionCategory {
+	return RetentionCategory(rule.String())
+}
+
 // NewRetentionConfigFromConfig creates a RetentionConfig from main Config
 // Auto-detects whether to use simple or GFS policy based on configuration
 func NewRetentionConfigFromConfig(cfg *config.Config, location BackupLocation) RetentionConfig {
@@ -93,11 +137,15 @@ func NewRetentionConfigFromConfig(cfg *config.Config, location BackupLocation) R
 		Weekly:  cfg.RetentionWeekly,
 		Monthly: cfg.RetentionMonthly,
 		Yearly:  cfg.RetentionYearly,
+		Hourly:  cfg.RetentionHourly,
 	}
 
 	// Auto-detect policy: if any GFS parameter is set, use GFS
 	if cfg.IsGFSRetentionEnabled() {
 		rc.Policy = "gfs"
+	} else if cfg.IsThinningRetentionEnabled() {
+		rc.Policy = "thinning"
+		rc.Rules = append([]config.ThinningRule(nil), cfg.RetentionRules...)
 	} else {
 		rc.Policy = "simple"
 		// Use location-specific max backups for simple policy
@@ -116,9 +164,44 @@ func NewRetentionConfigFromConfig(cfg *config.Config, location BackupLocation) R
 	return rc
 }
 
+// ClassifyBackups classifies backups according to the configured time-based
+// policy (GFS or thinning), or keeps the newest MaxBackups for simple retention
+// (MaxBackups <= 0 keeps everything).
+func ClassifyBackups(backups []*types.BackupMetadata, config RetentionConfig) map[*types.BackupMetadata]RetentionCategory {
+	return classifyBackupsAt(backups, config, time.Now())
+}
+
+// classifyBackupsAt is ClassifyBackups evaluated at now.
+func classifyBackupsAt(backups []*types.BackupMetadata, config RetentionConfig, now time.Time) map[*types.BackupMetadata]RetentionCategory {
+	switch config.Policy {
+	case "gfs":
+		return classifyBackupsGFSAt(backups, config, now)
+	case "thinning":
+		return classifyBackupsThinningAt(backups, config.Rules, now)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+	classification := make(map[*types.BackupMetadata]RetentionCategory, len(backups))
+	for i, b := range backups {
+		if config.MaxBackups > 0 && i >= config.MaxBackups {
+			classification[b] = CategoryDelete
+			continue
+		}
+		classification[b] = CategoryKeep
+	}
+	return classification
+}
+
 // ClassifyBackupsGFS classifies backups according to GFS (Grandfather-Father-Son) scheme
 // Returns a map of backup -> category, allowing intelligent time-distributed retention
 func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig) map[*types.BackupMetadata]RetentionCategory {
+	return classifyBackupsGFSAt(backups, config, time.Now())
+}
+
+// classifyBackupsGFSAt is ClassifyBackupsGFS evaluated at now (used by the
+// capacity forecast to replay retention on future dates).
+func classifyBackupsGFSAt(backups []*types.BackupMetadata, config RetentionConfig, now time.Time) map[*types.BackupMetadata]RetentionCategory {
 	if len(backups) == 0 {
 		return make(map[*types.BackupMetadata]RetentionCategory)
 	}
@@ -130,28 +213,61 @@ func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig)
 	})
 
 	classification := make(map[*types.BackupMetadata]RetentionCategory)
-	now := time.Now()
 	currentYear, currentWeek := now.ISOWeek()
 	currentYearInt := now.Year()
 	currentMonth := int(now.Month())
 
-	// 1. DAILY: Keep the last N backups (newest first)
+	// 1. HOURLY: Keep the newest backup of each of the last N clock hours
+	// (sub-daily schedules). It runs before the daily tier so that frequent
+	// backups do not use up the daily slots within a few hours.
+	hourlyCutIndex := 0
+	hourlyDays := make(map[int64]bool)
+	if config.Hourly > 0 {
+		hoursSeen := make(map[int64]bool)
+		hourlyCutIndex = len(backups)
+		for i, b := range backups {
+			hourKey := timeBucket(b.Timestamp, 1, 'h')
+			if hoursSeen[hourKey] {
+				continue
+			}
+			if len(hoursSeen) >= config.Hourly {
+				hourlyCutIndex = i
+				break
+			}
+			classification[b] = CategoryHourly
+			hoursSeen[hourKey] = true
+			hourlyDays[timeBucket(b.Timestamp, 1, 'd')] = true
+		}
+	}
+
+	// 2. DAILY: Keep the last N backups (newest first). With the hourly tier
+	// enabled, keep one backup per calendar day instead, for the days older
+	// than the hourly window that it does not already cover.
 	dailyLimit := config.Daily
 	dailyCount := 0
 	dailyCutIndex := len(backups)
-	for i, b := range backups {
-		if dailyCount >= dailyLimit {
+	daysSeen := make(map[int64]bool)
+	for i := hourlyCutIndex; i < len(backups); i++ {
+		b := backups[i]
+		if config.Hourly > 0 {
+			dayKey := timeBucket(b.Timestamp, 1, 'd')
+			if hourlyDays[dayKey] || daysSeen[dayKey] {
+				continue
+			}
+			if dailyCount >= dailyLimit {
+				dailyCutIndex = i
+				break
+			}
+			daysSeen[dayKey] = true
+		} else if dailyCount >= dailyLimit {
 			dailyCutIndex = i
 			break
 		}
 		classification[b] = CategoryDaily
 		dailyCount++
 	}
-	if dailyCount < dailyLimit {
-		dailyCutIndex = len(backups)
-	}
 
-	// 2. WEEKLY: Keep one backup per week (ISO week number)
+	// 3. WEEKLY: Keep one backup per week (ISO week number)
 	// Only consider backups older than the oldest daily and not already classified.
 	// Only weeks strictly before the current ISO week are eligible.
 	if config.Weekly > 0 {
@@ -177,7 +293,7 @@ func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig)
 		}
 	}
 
-	// 3. MONTHLY: Keep one backup per month
+	// 4. MONTHLY: Keep one backup per month
 	// Only consider backups older than the oldest daily and not already classified.
 	// Only months strictly before the current month are eligible.
 	if config.Monthly > 0 {
@@ -203,7 +319,7 @@ func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig)
 		}
 	}
 
-	// 4. YEARLY: Keep one backup per year
+	// 5. YEARLY: Keep one backup per year
 	// If Yearly == 0, keep all yearly backups (infinite retention)
 	if config.Yearly >= 0 {
 		yearsSeen := make(map[string]bool)
@@ -230,7 +346,7 @@ func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig)
 		}
 	}
 
-	// 5. Mark remaining backups for deletion
+	// 6. Mark remaining backups for deletion
 	for _, b := range backups {
 		if classification[b] == "" {
 			classification[b] = CategoryDelete
@@ -240,6 +356,57 @@ func ClassifyBackupsGFS(backups []*types.BackupMetadata, config RetentionConfig)
 	return classification
 }
 
+// RetentionTierStat is one retention tier (a GFS category, a thinning rule or
+// the simple keep count) with the backups it keeps and its limit (0 = no fixed
+// limit).
+type RetentionTierStat struct {
+	Category RetentionCategory
+	Kept     int
+	Limit    int
+}
+
+// RetentionTiers lists the tiers of config in display order with the kept
+// counts from stats (GetRetentionStats of a classification, nil for none).
+func RetentionTiers(config RetentionConfig, stats map[RetentionCategory]int) []RetentionTierStat {
+	var tiers []RetentionTierStat
+	switch config.Policy {
+	case "gfs":
+		if config.Hourly > 0 {
+			tiers = append(tiers, RetentionTierStat{Category: CategoryHourly, Kept: stats[CategoryHourly], Limit: config.Hourly})
+		}
+		tiers = append(tiers,
+			RetentionTierStat{Category: CategoryDaily, Kept: stats[CategoryDaily], Limit: config.Daily},
+			RetentionTierStat{Category: CategoryWeekly, Kept: stats[CategoryWeekly], Limit: config.Weekly},
+			RetentionTierStat{Category: CategoryMonthly, Kept: stats[CategoryMonthly], Limit: config.Monthly},
+			RetentionTierStat{Category: CategoryYearly, Kept: stats[CategoryYearly], Limit: config.Yearly},
+		)
+	case "thinning":
+		for _, rule := range config.Rules {
+			cat := ThinningCategory(rule)
+			tiers = append(tiers, RetentionTierStat{Category: cat, Kept: stats[cat]})
+		}
+		if stats[CategoryKeep] > 0 {
+			tiers = append(tiers, RetentionTierStat{Category: CategoryKeep, Kept: stats[CategoryKeep]})
+		}
+	default:
+		tiers = append(tiers, RetentionTierStat{Category: CategoryKeep, Kept: stats[CategoryKeep], Limit: config.MaxBackups})
+	}
+	return tiers
+}
+
+// FormatRetentionTiers renders tiers as "daily: 3/7, weekly: 1/4, 7d:6h: 12".
+func FormatRetentionTiers(tiers []RetentionTierStat) string {
+	parts := make([]string, 0, len(tiers))
+	for _, t := range tiers {
+		if t.Limit > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d/%d", t.Category, t.Kept, t.Limit))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: %d", t.Category, t.Kept))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // GetRetentionStats returns statistics about classification results
 func GetRetentionStats(classification map[*types.BackupMetadata]RetentionCategory) map[RetentionCategory]int {
 	stats := make(map[RetentionCategory]int)
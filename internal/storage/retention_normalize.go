@@ -6,9 +6,13 @@ import (
 
 // EffectiveGFSRetentionConfig returns the effective GFS configuration without side effects.
 // It applies the same value normalization used by GFS retention execution paths, but does not log.
-// Callers are responsible for invoking it only for configurations that should use GFS semantics.
+// Callers are responsible for invoking it only for configurations that should use GFS semantics;
+// thinning configurations are returned unchanged.
 func EffectiveGFSRetentionConfig(cfg RetentionConfig) RetentionConfig {
 	effective := cfg
+	if effective.Policy == "thinning" {
+		return effective
+	}
 	if effective.Daily <= 0 {
 		effective.Daily = 1
 	}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// RetentionPlanEntry is one backup of a retention plan.
+type RetentionPlanEntry struct {
+	Backup *types.BackupMetadata
+	// Category is what retention does with the backup: a GFS category, a
+	// thinning rule, CategoryKeep for simple (count-based) retention, or
+	// CategoryDelete.
+	Category RetentionCategory
+	// Simulated marks a backup produced by a simulated future run.
+	Simulated bool
+	// DeletedByRun is the simulated run (1-based) whose retention deletes the
+	// backup, 0 when it is deleted (or kept) at the plan time.
+	DeletedByRun int
+}
+
+// RetentionPlan is the outcome of a retention policy on a set of backups,
+// computed without deleting anything.
+type RetentionPlan struct {
+	Config RetentionConfig
+	// At is when the plan is evaluated: now, or the last simulated run.
+	At            time.Time
+	SimulatedRuns int
+	// Entries lists the backups retention classifies, newest first.
+	Entries []RetentionPlanEntry
+	// Inert lists the backups retention ignores (never kept as a slot, never deleted).
+	Inert []RetentionPlanInert
+}
+
+// RetentionPlanInert is a backup excluded from retention, with the reason.
+type RetentionPlanInert struct {
+	Backup *types.BackupMetadata
+	Reason string
+}
+
+// Counts returns the number of entries per category.
+func (p *RetentionPlan) Counts() map[RetentionCategory]int {
+	counts := make(map[RetentionCategory]int)
+	for _, e := range p.Entries {
+		counts[e.Category]++
+	}
+	return counts
+}
+
+// PlanRetention classifies backups the way ApplyRetention would at now, without
+// deleting anything. With runs > 0 it first simulates that many future daily
+// runs, each adding a backup the size of the newest one at the newest backup's
+// time of day and then applying retention: the plan then shows the set as it
+// is after the last run, and the backups deleted along the way with the run
+// that deletes them.
+func PlanRetention(backups []*types.BackupMetadata, config RetentionConfig, now time.Time, runs int) RetentionPlan {
+	if config.IsTimeBased() {
+		config = EffectiveGFSRetentionConfig(config)
+	}
+	plan := RetentionPlan{Config: config, At: now}
+
+	eligible, inert := partitionRetentionEligible(backups)
+	for _, in := range inert {
+		plan.Inert = append(plan.Inert, RetentionPlanInert{Backup: in.Backup, Reason: in.Reason})
+	}
+
+	current := append([]*types.BackupMetadata(nil), eligible...)
+	sort.Slice(current, func(i, j int) bool {
+		return current[i].Timestamp.Before(current[j].Timestamp)
+	})
+
+	simulated := make(map[*types.BackupMetadata]bool)
+	deletedBy := make(map[*types.BackupMetadata]int)
+	var deleted []*types.BackupMetadata
+	if runs > 0 {
+		plan.SimulatedRuns = runs
+		run := firstSimulatedRun(current, now)
+		var size int64
+		if len(current) > 0 {
+			size = current[len(current)-1].Size
+		}
+		for i := 1; i <= runs; i++ {
+			b := &types.BackupMetadata{Timestamp: run, Size: size, Verified: true}
+			simulated[b] = true
+			before := append([]*types.BackupMetadata(nil), current...)
+			before = append(before, b)
+			current = applyForecastRetention(append([]*types.BackupMetadata(nil), before...), config, run)
+			for _, gone := range removedBackups(before, current) {
+				deletedBy[gone] = i
+				deleted = append(deleted, gone)
+			}
+			plan.At = run
+			run = run.AddDate(0, 0, 1)
+		}
+	}
+
+	classification := classifyBackupsAt(append([]*types.BackupMetadata(nil), current...), config, plan.At)
+	for _, b := range current {
+		plan.Entries = append(plan.Entries, RetentionPlanEntry{Backup: b, Category: classification[b], Simulated: simulated[b]})
+	}
+	for _, b := range deleted {
+		plan.Entries = append(plan.Entries, RetentionPlanEntry{Backup: b, Category: CategoryDelete, Simulated: simulated[b], DeletedByRun: deletedBy[b]})
+	}
+	sort.SliceStable(plan.Entries, func(i, j int) bool {
+		return plan.Entries[i].Backup.Timestamp.After(plan.Entries[j].Backup.Timestamp)
+	})
+	return plan
+}
+
+// firstSimulatedRun is the first daily run after now, at the time of day of the
+// newest backup (now's time of day without backups).
+func firstSimulatedRun(backups []*types.BackupMetadata, now time.Time) time.Time {
+	clock := now
+	if len(backups) > 0 {
+		clock = backups[len(backups)-1].Timestamp.In(now.Location())
+	}
+	run := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+	if !run.After(now) {
+		run = run.AddDate(0, 0, 1)
+	}
+	return run
+}
+
+func removedBackups(before, after []*types.BackupMetadata) []*types.BackupMetadata {
+	kept := make(map[*types.BackupMetadata]bool, len(after))
+	for _, b := range after {
+		kept[b] = true
+	}
+	var removed []*types.BackupMetadata
+	for _, b := range before {
+		if !kept[b] {
+			removed = append(removed, b)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+func TestPlanRetentionGFSMatchesClassification(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now.Add(-10*time.Hour), 20, constantSize(forecastMiB))
+	unverified := &types.BackupMetadata{BackupFile: "partial.tar.zst", Timestamp: now.Add(-time.Hour)}
+	retention := RetentionConfig{Policy: "gfs", Daily: 3, Weekly: 2, Monthly: 1, Yearly: 1}
+
+	plan := PlanRetention(append(backups, unverified), retention, now, 0)
+	if len(plan.Inert) != 1 || plan.Inert[0].Backup != unverified {
+		t.Fatalf("Inert = %+v, want the unverified backup", plan.Inert)
+	}
+	if len(plan.Entries) != 20 {
+		t.Fatalf("Entries = %d, want 20", len(plan.Entries))
+	}
+	for i := 1; i < len(plan.Entries); i++ {
+		if plan.Entries[i].Backup.Timestamp.After(plan.Entries[i-1].Backup.Timestamp) {
+			t.Fatal("entries are not ordered newest first")
+		}
+	}
+
+	want := GetRetentionStats(classifyBackupsGFSAt(append([]*types.BackupMetadata(nil), backups...), retention, now))
+	got := plan.Counts()
+	for _, cat := range []RetentionCategory{CategoryDaily, CategoryWeekly, CategoryMonthly, CategoryYearly, CategoryDelete} {
+		if got[cat] != want[cat] {
+			t.Fatalf("%s = %d, want %d (plan %v, classification %v)", cat, got[cat], want[cat], got, want)
+		}
+	}
+	if got[CategoryDaily] != 3 || got[CategoryDelete] == 0 {
+		t.Fatalf("counts = %v, want 3 daily and some deletions", got)
+	}
+}
+
+func TestPlanRetentionSimple(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now.Add(-10*time.Hour), 5, constantSize(forecastMiB))
+
+	plan := PlanRetention(backups, RetentionConfig{Policy: "simple", MaxBackups: 3}, now, 0)
+	counts := plan.Counts()
+	if counts[CategoryKeep] != 3 || counts[CategoryDelete] != 2 {
+		t.Fatalf("counts = %v, want keep 3 delete 2", counts)
+	}
+	if plan.Entries[0].Category != CategoryKeep || plan.Entries[4].Category != CategoryDelete {
+		t.Fatalf("newest must be kept and oldest deleted: %+v", plan.Entries)
+	}
+
+	plan = PlanRetention(backups, RetentionConfig{Policy: "simple"}, now, 0)
+	if counts := plan.Counts(); counts[CategoryKeep] != 5 {
+		t.Fatalf("disabled simple retention counts = %v, want everything kept", counts)
+	}
+}
+
+func TestPlanRetentionSimulatesFutureRuns(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 0, 0, 0, time.UTC)
+	backups := dailyBackups(now.Add(-10*time.Hour), 3, constantSize(forecastMiB))
+
+	plan := PlanRetention(backups, RetentionConfig{Policy: "simple", MaxBackups: 3}, now, 4)
+	if plan.SimulatedRuns != 4 {
+		t.Fatalf("SimulatedRuns = %d, want 4", plan.SimulatedRuns)
+	}
+	if want := time.Date(2026, 7, 10, 2, 0, 0, 0, time.UTC); !plan.At.Equal(want) {
+		t.Fatalf("At = %s, want the 4th run at %s", plan.At, want)
+	}
+
+	counts := plan.Counts()
+	if counts[CategoryKeep] != 3 || counts[CategoryDelete] != 4 {
+		t.Fatalf("counts = %v, want 3 kept and 4 deleted along the way", counts)
+	}
+	simulatedKept := 0
+	for _, e := range plan.Entries {
+		if e.Category == CategoryKeep && e.Simulated {
+			simulatedKept++
+		}
+		if e.Category == CategoryDelete && e.DeletedByRun == 0 {
+			t.Fatalf("deleted entry without its run: %+v", e)
+		}
+	}
+	if simulatedKept != 3 {
+		t.Fatalf("simulated backups kept = %d, want 3", simulatedKept)
+	}
+	// The oldest real backup goes with the first simulated run.
+	oldest := plan.Entries[len(plan.Entries)-1]
+	if oldest.Simulated || oldest.DeletedByRun != 1 {
+		t.Fatalf("oldest entry = %+v, want the oldest real backup deleted by run 1", oldest)
+	}
+}
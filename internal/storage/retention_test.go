@@ -90,6 +90,16 @@ func TestNewRetentionConfigFromConfig(t *testing.T) {
 			expectedPolicy: "simple",
 			expectedMax:    7,
 		},
+		{
+			name: "Thinning policy - rules set",
+			cfg: &config.Config{
+				RetentionPolicy: "thinning",
+				RetentionRules:  []config.ThinningRule{{MaxAge: 48 * time.Hour}},
+			},
+			location:       LocationPrimary,
+			expectedPolicy: "thinning",
+			expectedMax:    0,
+		},
 	}
 
 	for _, tt := range tests {
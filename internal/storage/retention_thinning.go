@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// classifyBackupsThinningAt classifies backups according to age-based thinning
+// rules evaluated at now. Each backup falls under the first rule whose age limit
+// it does not exceed: "all" rules keep it, the others keep only the newest backup
+// of each spacing bucket (6 hours, 1 day, ...). Backups older than every rule are
+// deleted, except the newest backup, which is always kept so that a stalled
+// schedule never empties the storage.
+func classifyBackupsThinningAt(backups []*types.BackupMetadata, rules []config.ThinningRule, now time.Time) map[*types.BackupMetadata]RetentionCategory {
+	classification := make(map[*types.BackupMetadata]RetentionCategory, len(backups))
+	if len(backups) == 0 {
+		return classification
+	}
+
+	// Sort by timestamp descending (newest first)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+
+	type bucketKey struct {
+		rule   int
+		bucket int64
+	}
+	seen := make(map[bucketKey]bool)
+	for _, b := range backups {
+		age := now.Sub(b.Timestamp)
+		ruleIndex := -1
+		for i, rule := range rules {
+			if rule.MaxAge == 0 || age <= rule.MaxAge {
+				ruleIndex = i
+				break
+			}
+		}
+		if ruleIndex < 0 {
+			classification[b] = CategoryDelete
+			continue
+		}
+
+		rule := rules[ruleIndex]
+		if rule.Every > 0 {
+			key := bucketKey{rule: ruleIndex, bucket: timeBucket(b.Timestamp, rule.Every, rule.Unit)}
+			if seen[key] {
+				classification[b] = CategoryDelete
+				continue
+			}
+			seen[key] = true
+		}
+		classification[b] = ThinningCategory(rule)
+	}
+
+	if newest := backups[0]; classification[newest] == CategoryDelete {
+		classification[newest] = CategoryKeep
+	}
+	return classification
+}
+
+// timeBucket returns the index of the every-unit calendar bucket holding t, in
+// t's local time: hours and days since the epoch, ISO weeks (Monday-based),
+// months and years.
+func timeBucket(t time.Time, every int, unit byte) int64 {
+	if every <= 0 {
+		every = 1
+	}
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+	var n int64
+	switch unit {
+	case 'h':
+		n = days*24 + int64(t.Hour())
+	case 'd':
+		n = days
+	case 'w':
+		// 1970-01-01 was a Thursday: shift so that weeks start on Monday.
+		n = (days + 3) / 7
+	case 'M':
+		n = int64(y)*12 + int64(m) - 1
+	default:
+		n = int64(y)
+	}
+	return n / int64(every)
+}
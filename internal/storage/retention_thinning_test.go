@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+	"github.com/tis24dev/proxsave/internal/types"
+)
+
+// hourlyBackups returns n verified backups every interval, the newest at now.
+func hourlyBackups(now time.Time, n int, interval time.Duration) []*types.BackupMetadata {
+	backups := make([]*types.BackupMetadata, 0, n)
+	for i := 0; i < n; i++ {
+		ts := now.Add(-time.Duration(i) * interval)
+		backups = append(backups, &types.BackupMetadata{
+			BackupFile: "backup-" + ts.Format("20060102-1504") + ".tar.zst",
+			Timestamp:  ts,
+			Verified:   true,
+		})
+	}
+	return backups
+}
+
+func TestClassifyBackupsThinning(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 30, 0, 0, time.UTC)
+	rules, err := config.ParseThinningRules("48h:all,7d:6h,30d:1d")
+	if err != nil {
+		t.Fatalf("ParseThinningRules() error = %v", err)
+	}
+	// One backup per hour for 40 days.
+	backups := hourlyBackups(now, 40*24, time.Hour)
+	retention := RetentionConfig{Policy: "thinning", Rules: rules}
+
+	classification := classifyBackupsAt(backups, retention, now)
+	stats := GetRetentionStats(classification)
+
+	if got := stats[ThinningCategory(rules[0])]; got != 49 {
+		t.Fatalf("48h:all kept %d, want 49 (every backup up to 48h old)", got)
+	}
+	// Backups 49h..168h old span 120 hours: 20 six-hour buckets, plus the
+	// partial bucket at each end.
+	if got := stats[ThinningCategory(rules[1])]; got < 20 || got > 22 {
+		t.Fatalf("7d:6h kept %d, want ~20", got)
+	}
+	// 7..30 days old: one per calendar day.
+	if got := stats[ThinningCategory(rules[2])]; got < 23 || got > 24 {
+		t.Fatalf("30d:1d kept %d, want ~23", got)
+	}
+	for b, cat := range classification {
+		if now.Sub(b.Timestamp) > 30*24*time.Hour && cat != CategoryDelete {
+			t.Fatalf("backup %s older than every rule kept as %s", b.BackupFile, cat)
+		}
+	}
+
+	// Within a bucket the newest backup is the one kept.
+	seenDays := make(map[string]*types.BackupMetadata)
+	for b, cat := range classification {
+		if cat == ThinningCategory(rules[2]) {
+			day := b.Timestamp.Format("2006-01-02")
+			if prev := seenDays[day]; prev != nil {
+				t.Fatalf("two backups kept for %s: %s and %s", day, prev.BackupFile, b.BackupFile)
+			}
+			seenDays[day] = b
+		}
+	}
+}
+
+func TestClassifyBackupsThinningKeepsNewest(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 0, 0, 0, time.UTC)
+	rules, _ := config.ParseThinningRules("7d:1d")
+	// The schedule stopped a month ago: every backup is older than the rules.
+	backups := dailyBackups(now.AddDate(0, -1, 0), 5, constantSize(forecastMiB))
+
+	classification := ClassifyBackups(backups, RetentionConfig{Policy: "thinning", Rules: rules})
+	stats := GetRetentionStats(classification)
+	if stats[CategoryKeep] != 1 || stats[CategoryDelete] != 4 {
+		t.Fatalf("stats = %v, want the newest kept and 4 deleted", stats)
+	}
+	if classification[backups[0]] != CategoryKeep {
+		t.Fatalf("newest backup classified %s, want keep", classification[backups[0]])
+	}
+}
+
+func TestClassifyBackupsGFSHourlyTier(t *testing.T) {
+	now := time.Date(2026, 7, 6, 12, 30, 0, 0, time.UTC)
+	// Every 15 minutes for three days.
+	backups := hourlyBackups(now, 3*24*4, 15*time.Minute)
+	retention := RetentionConfig{Policy: "gfs", Daily: 2, Hourly: 6}
+
+	classification := classifyBackupsGFSAt(backups, retention, now)
+	stats := GetRetentionStats(classification)
+	if stats[CategoryDaily] != 2 || stats[CategoryHourly] != 6 {
+		t.Fatalf("stats = %v, want 2 daily and 6 hourly", stats)
+	}
+
+	var kept []string
+	for _, b := range backups {
+		if cat := classification[b]; cat != CategoryDelete {
+			kept = append(kept, string(cat)+" "+b.Timestamp.Format("01-02 15:04"))
+		}
+	}
+	// The newest backup of each of the last six hours, then the newest backup
+	// of each of the two previous calendar days.
+	want := []string{
+		"hourly 07-06 12:30",
+		"hourly 07-06 11:45",
+		"hourly 07-06 10:45",
+		"hourly 07-06 09:45",
+		"hourly 07-06 08:45",
+		"hourly 07-06 07:45",
+		"daily 07-05 23:45",
+		"daily 07-04 23:45",
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept = %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Fatalf("kept = %v, want %v", kept, want)
+		}
+	}
+
+	tiers := RetentionTiers(retention, stats)
+	if len(tiers) != 5 || tiers[0].Category != CategoryHourly || tiers[0].Kept != 6 || tiers[0].Limit != 6 {
+		t.Fatalf("RetentionTiers() = %+v, want hourly first", tiers)
+	}
+	if got := FormatRetentionTiers(tiers); got != "hourly: 6/6, daily: 2/2, weekly: 0, monthly: 0, yearly: 0" {
+		t.Fatalf("FormatRetentionTiers() = %q", got)
+	}
+}
+
+func TestRetentionConfigDescribe(t *testing.T) {
+	rules, _ := config.ParseThinningRules("48h:all,forever:1M")
+	for _, tc := range []struct {
+		cfg  RetentionConfig
+		want string
+	}{
+		{RetentionConfig{Policy: "gfs", Daily: 7, Weekly: 4}, "GFS (daily=7, weekly=4, monthly=0, yearly=0)"},
+		{RetentionConfig{Policy: "gfs", Hourly: 24, Daily: 7}, "GFS (hourly=24, daily=7, weekly=0, monthly=0, yearly=0)"},
+		{RetentionConfig{Policy: "thinning", Rules: rules}, "thinning (48h:all, forever:1M)"},
+		{RetentionConfig{Policy: "simple", MaxBackups: 5}, "simple (keep 5 newest)"},
+	} {
+		if got := tc.cfg.Describe(); got != tc.want {
+			t.Errorf("Describe() = %q, want %q", got, tc.want)
+		}
+	}
+}
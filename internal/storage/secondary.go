@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tis24dev/proxsave/internal/backup"
 	"github.com/tis24dev/proxsave/internal/config"
 	"github.com/tis24dev/proxsave/internal/logging"
 	"github.com/tis24dev/proxsave/internal/safefs"
@@ -107,23 +108,35 @@ func (s *SecondaryStorage) Store(ctx context.Context, backupFile string, metadat
 		return err
 	}
 
-	bundleEnabled := s.config != nil && s.config.BundleAssociatedFiles
+	// A split archive is never bundled: its volumes are copied as one set
+	// together with the raw sidecars.
+	volumes, err := listBackupVolumes(ctx, backupFile, fsIoTimeout(s.config))
+	if err != nil {
+		s.logger.Debug("Secondary storage: cannot list volumes of %s: %v", filepath.Base(backupFile), err)
+	}
+	bundleEnabled := s.config != nil && s.config.BundleAssociatedFiles && len(volumes) == 0
 	sourceFile := backupFile
 	if bundleEnabled {
 		sourceFile = bundlePathFor(sourceFile)
 	}
+	sourceFiles := []string{sourceFile}
+	if len(volumes) > 0 {
+		sourceFiles = volumes
+	}
 
-	// Verify source file exists (bounded against a dead/stale mount).
-	if _, err := safefs.Stat(ctx, sourceFile, fsIoTimeout(s.config)); err != nil {
-		s.logger.Debug("Secondary storage: source file %s not found", sourceFile)
-		s.logger.Warning("WARNING: Secondary storage - backup file not found: %s: %v", sourceFile, err)
-		return &StorageError{
-			Location:    LocationSecondary,
-			Operation:   "store",
-			Path:        sourceFile,
-			Err:         fmt.Errorf("source file not found: %w", err),
-			IsCritical:  false,
-			Recoverable: false,
+	// Verify source files exist (bounded against a dead/stale mount).
+	for _, sourceFile := range sourceFiles {
+		if _, err := safefs.Stat(ctx, sourceFile, fsIoTimeout(s.config)); err != nil {
+			s.logger.Debug("Secondary storage: source file %s not found", sourceFile)
+			s.logger.Warning("WARNING: Secondary storage - backup file not found: %s: %v", sourceFile, err)
+			return &StorageError{
+				Location:    LocationSecondary,
+				Operation:   "store",
+				Path:        sourceFile,
+				Err:         fmt.Errorf("source file not found: %w", err),
+				IsCritical:  false,
+				Recoverable: false,
+			}
 		}
 	}
 
@@ -141,29 +154,36 @@ func (s *SecondaryStorage) Store(ctx context.Context, backupFile string, metadat
 		}
 	}
 
-	// Determine destination filename
-	destFile := filepath.Join(s.basePath, filepath.Base(sourceFile))
-
 	s.logger.Debug("Secondary Storage: Start copy...")
-	s.logger.Debug("Copying backup to secondary storage: %s -> %s", filepath.Base(sourceFile), s.basePath)
-
-	if err := s.copyFile(ctx, sourceFile, destFile); err != nil {
-		s.logger.Warning("WARNING: Secondary Storage: File copy failed for %s: %v", filepath.Base(sourceFile), err)
-		s.logger.Warning("WARNING: Secondary Storage: Backup not saved to %s", s.basePath)
-		return &StorageError{
-			Location:    LocationSecondary,
-			Operation:   "store",
-			Path:        sourceFile,
-			Err:         fmt.Errorf("copy failed: %w", err),
-			IsCritical:  false,
-			Recoverable: true,
+	destFiles := make([]string, 0, len(sourceFiles))
+	for _, sourceFile := range sourceFiles {
+		// Determine destination filename
+		destFile := filepath.Join(s.basePath, filepath.Base(sourceFile))
+		s.logger.Debug("Copying backup to secondary storage: %s -> %s", filepath.Base(sourceFile), s.basePath)
+
+		if err := s.copyFile(ctx, sourceFile, destFile); err != nil {
+			s.logger.Warning("WARNING: Secondary Storage: File copy failed for %s: %v", filepath.Base(sourceFile), err)
+			s.logger.Warning("WARNING: Secondary Storage: Backup not saved to %s", s.basePath)
+			// A volume set is stored whole or not at all: drop the volumes
+			// already copied so no incomplete set is left behind.
+			s.removeCopiedFiles(ctx, destFiles)
+			return &StorageError{
+				Location:    LocationSecondary,
+				Operation:   "store",
+				Path:        sourceFile,
+				Err:         fmt.Errorf("copy failed: %w", err),
+				IsCritical:  false,
+				Recoverable: true,
+			}
 		}
+		destFiles = append(destFiles, destFile)
 	}
 
 	// Copy associated files if not bundled
 	if !bundleEnabled {
 		associatedFiles := []string{
 			backupFile + ".sha256",
+			backupFile + ".manifest.sig",
 			backupFile + ".metadata",
 			backupFile + ".metadata.sha256",
 		}
@@ -191,10 +211,12 @@ func (s *SecondaryStorage) Store(ctx context.Context, backupFile string, metadat
 
 	// Set permissions on destination (best effort)
 	if s.fsInfo != nil && s.fsInfo.SupportsOwnership {
-		if err := s.fsDetector.SetPermissions(ctx, destFile, 0, 0, 0600, s.fsInfo); err != nil {
-			s.logger.Warning("WARNING: Secondary storage - failed to set permissions on %s: %v",
-				filepath.Base(destFile), err)
-			// Not critical - continue
+		for _, destFile := range destFiles {
+			if err := s.fsDetector.SetPermissions(ctx, destFile, 0, 0, 0600, s.fsInfo); err != nil {
+				s.logger.Warning("WARNING: Secondary storage - failed to set permissions on %s: %v",
+					filepath.Base(destFile), err)
+				// Not critical - continue
+			}
 		}
 	}
 
@@ -209,6 +231,15 @@ func (s *SecondaryStorage) Store(ctx context.Context, backupFile string, metadat
 	return nil
 }
 
+// removeCopiedFiles best-effort removes files copied by an interrupted Store.
+func (s *SecondaryStorage) removeCopiedFiles(ctx context.Context, files []string) {
+	for _, file := range files {
+		if err := safefs.Remove(ctx, file, fsIoTimeout(s.config)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warning("WARNING: Secondary storage - failed to remove incomplete copy %s: %v", filepath.Base(file), err)
+		}
+	}
+}
+
 // countBackups lists current backups on secondary storage for logging/diagnostic purposes.
 func (s *SecondaryStorage) countBackups(ctx context.Context) int {
 	backups, err := s.List(ctx)
@@ -398,6 +429,15 @@ func (s *SecondaryStorage) List(ctx context.Context) (backups []*types.BackupMet
 		if isBackupTempArtifact(match) {
 			continue
 		}
+		// A split archive is listed once, under its archive path, from its first volume.
+		if archive, index, ok := backup.SplitVolumePath(match); ok {
+			if index == 1 {
+				if metadata := s.volumeSetMetadata(ctx, archive, timeout); metadata != nil {
+					backups = append(backups, metadata)
+				}
+			}
+			continue
+		}
 
 		// When bundling is enabled, skip standalone files that have a corresponding bundle
 		if s.config != nil && s.config.BundleAssociatedFiles {
@@ -435,6 +475,31 @@ func (s *SecondaryStorage) List(ctx context.Context) (backups []*types.BackupMet
 	return backups, nil
 }
 
+// volumeSetMetadata describes the split archive whose volumes sit next to
+// archive: its size is the sum of the volumes, its timestamp the first one's.
+func (s *SecondaryStorage) volumeSetMetadata(ctx context.Context, archive string, timeout time.Duration) *types.BackupMetadata {
+	volumes, err := listBackupVolumes(ctx, archive, timeout)
+	if err != nil || len(volumes) == 0 {
+		return nil
+	}
+	metadata := &types.BackupMetadata{
+		BackupFile: archive,
+		Volumes:    len(volumes),
+		Verified:   backupHasCompletionSidecar(ctx, archive, timeout) && backupVolumesComplete(volumes),
+	}
+	for i, volume := range volumes {
+		stat, err := safefs.Stat(ctx, volume, timeout)
+		if err != nil {
+			return nil
+		}
+		if i == 0 {
+			metadata.Timestamp = stat.ModTime()
+		}
+		metadata.Size += stat.Size()
+	}
+	return metadata
+}
+
 // Delete removes a backup file and its associated files
 func (s *SecondaryStorage) Delete(ctx context.Context, backupFile string) (err error) {
 	done := logging.DebugStart(s.logger, "secondary delete", "file=%s", backupFile)
@@ -455,12 +520,17 @@ func (s *SecondaryStorage) deleteBackupInternal(ctx context.Context, backupFile
 	// bundling was enabled, then disabled) is cleaned up. Remove of an absent
 	// bundle is a no-op, so this is behavior-preserving when no bundle exists.
 	filesToDelete := buildBackupCandidatePaths(basePath, true)
+	timeout := fsIoTimeout(s.config)
+	volumes, err := listBackupVolumes(ctx, basePath, timeout)
+	if err != nil {
+		return false, fmt.Errorf("list volumes of %s: %w", filepath.Base(basePath), err)
+	}
+	filesToDelete = append(filesToDelete, volumes...)
 
 	// Delete all files; collect real removal failures (not "already gone") and
 	// track whether the data archive itself (not just a sidecar) failed, so the
 	// caller never counts a backup whose archive remains on disk as deleted
 	// (PS-BH-001), while a sidecar-only failure still counts (the archive IS gone).
-	timeout := fsIoTimeout(s.config)
 	var failedFiles []string
 	dataFailed := false
 	for _, f := range filesToDelete {
@@ -578,13 +648,14 @@ func (s *SecondaryStorage) ApplyRetention(ctx context.Context, config RetentionC
 	}
 
 	// Apply appropriate retention policy
-	if config.Policy == "gfs" {
+	if config.IsTimeBased() {
 		return s.applyGFSRetention(ctx, backups, config)
 	}
 	return s.applySimpleRetention(ctx, backups, config.MaxBackups)
 }
 
-// applyGFSRetention applies GFS (Grandfather-Father-Son) retention policy
+// applyGFSRetention applies a time-based retention policy: GFS
+// (Grandfather-Father-Son) or age-based thinning rules
 func (s *SecondaryStorage) applyGFSRetention(ctx context.Context, backups []*types.BackupMetadata, config RetentionConfig) (int, error) {
 	eligible, inert := partitionRetentionEligible(backups)
 	for _, in := range inert {
@@ -593,22 +664,19 @@ func (s *SecondaryStorage) applyGFSRetention(ctx context.Context, backups []*typ
 	backups = eligible
 
 	config = EffectiveGFSRetentionConfig(config)
-	s.logger.Debug("Applying GFS retention policy (daily=%d, weekly=%d, monthly=%d, yearly=%d)",
-		config.Daily, config.Weekly, config.Monthly, config.Yearly)
+	s.logger.Debug("Applying %s retention policy", config.Describe())
 
 	initialLogs := s.countLogFiles(ctx)
 	logsDeleted := 0
 
-	// Classify backups according to GFS scheme
-	classification := ClassifyBackupsGFS(backups, config)
+	// Classify backups according to the GFS scheme or the thinning rules
+	classification := ClassifyBackups(backups, config)
 
 	// Get statistics
 	stats := GetRetentionStats(classification)
-	s.logger.Debug("GFS classification -> daily: %d/%d, weekly: %d/%d, monthly: %d/%d, yearly: %d/%d, to_delete: %d",
-		stats[CategoryDaily], config.Daily,
-		stats[CategoryWeekly], config.Weekly,
-		stats[CategoryMonthly], config.Monthly,
-		stats[CategoryYearly], config.Yearly,
+	s.logger.Debug("Retention classification -> %s, kept: %d, to_delete: %d",
+		FormatRetentionTiers(RetentionTiers(config, stats)),
+		len(backups)-stats[CategoryDelete],
 		stats[CategoryDelete])
 
 	// Delete backups marked for deletion
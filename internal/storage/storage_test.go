@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -62,7 +63,7 @@ func TestNormalizeGFSRetentionConfigLeavesNonGFSUnchanged(t *testing.T) {
 
 	effective := NormalizeGFSRetentionConfig(logger, "Test Storage", cfg)
 
-	if effective != cfg {
+	if !reflect.DeepEqual(effective, cfg) {
 		t.Fatalf("NormalizeGFSRetentionConfig() = %+v; want %+v", effective, cfg)
 	}
 	if buf.Len() != 0 {
@@ -83,7 +84,7 @@ func TestNormalizeGFSRetentionConfigDoesNotLogWhenDailyAlreadyValid(t *testing.T
 
 	effective := NormalizeGFSRetentionConfig(logger, "Test Storage", cfg)
 
-	if effective != cfg {
+	if !reflect.DeepEqual(effective, cfg) {
 		t.Fatalf("NormalizeGFSRetentionConfig() = %+v; want %+v", effective, cfg)
 	}
 	if buf.Len() != 0 {
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUploadPendingResume marks a chunked cloud upload that stopped part-way: the
+// uploaded parts are recorded in the journal and the archive stays in the upload
+// queue, so the next run (or the daemon) resumes it instead of starting over.
+var ErrUploadPendingResume = errors.New("cloud upload interrupted; pending resume")
+
+// uploadJournal is the on-disk record of one chunked upload, keyed by the SHA-256
+// of the uploaded file so a resumed upload never mixes parts of different content.
+type uploadJournal struct {
+	SHA256    string              `json:"sha256"`
+	Archive   string              `json:"archive"`
+	Remote    string              `json:"remote"`
+	Size      int64               `json:"size"`
+	ChunkSize int64               `json:"chunk_size"`
+	Parts     []uploadJournalPart `json:"parts"`
+	StartedAt time.Time           `json:"started_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// uploadJournalPart tracks one part: Uploaded once it is on the remote under its
+// temporary name and verified, Committed once renamed to its final chunk name.
+// SHA256 is the hash of the part's bytes, checked against the committed chunk.
+type uploadJournalPart struct {
+	Index     int    `json:"index"`
+	Offset    int64  `json:"offset"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256,omitempty"`
+	Uploaded  bool   `json:"uploaded,omitempty"`
+	Committed bool   `json:"committed,omitempty"`
+}
+
+func newUploadJournal(sha, archive, remote string, size, chunkSize int64, now time.Time) *uploadJournal {
+	j := &uploadJournal{
+		SHA256:    sha,
+		Archive:   archive,
+		Remote:    remote,
+		Size:      size,
+		ChunkSize: chunkSize,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	for offset, index := int64(0), 1; offset < size; offset, index = offset+chunkSize, index+1 {
+		partSize := chunkSize
+		if remaining := size - offset; remaining < partSize {
+			partSize = remaining
+		}
+		j.Parts = append(j.Parts, uploadJournalPart{Index: index, Offset: offset, Size: partSize})
+	}
+	return j
+}
+
+// matches reports whether j describes the same upload (same target and layout).
+func (j *uploadJournal) matches(remote string, size, chunkSize int64) bool {
+	return j.Remote == remote && j.Size == size && j.ChunkSize == chunkSize && len(j.Parts) > 0
+}
+
+// progress returns the number of parts already on the remote.
+func (j *uploadJournal) progress() (uploaded, total int) {
+	for _, part := range j.Parts {
+		if part.Uploaded {
+			uploaded++
+		}
+	}
+	return uploaded, len(j.Parts)
+}
+
+// txn is the chunker transaction id used for temporary part names: rclone's
+// chunker overlay ignores "<name>.rclone_chunk.NNN_<txn>" objects, so a partial
+// upload never shows up as a (truncated) backup.
+func (j *uploadJournal) txn() string {
+	return strings.ToLower(j.SHA256[:8])
+}
+
+func uploadJournalPath(dir, sha string) string {
+	return filepath.Join(dir, sha+".json")
+}
+
+// loadUploadJournal returns nil (no error) when there is no journal at path.
+func loadUploadJournal(path string) (*uploadJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read upload journal: %w", err)
+	}
+	var j uploadJournal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("parse upload journal %s: %w", path, err)
+	}
+	return &j, nil
+}
+
+func (j *uploadJournal) save(path string, now time.Time) error {
+	j.UpdatedAt = now
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal upload journal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create upload journal dir: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("write upload journal: %w", err)
+	}
+	return nil
+}
+
+// findUploadJournals returns the journals in dir whose archive is one of archives
+// (with their paths). Unreadable journals are skipped.
+func findUploadJournals(dir string, archives ...string) map[string]*uploadJournal {
+	found := make(map[string]*uploadJournal)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return found
+	}
+	for _, path := range paths {
+		j, err := loadUploadJournal(path)
+		if err != nil || j == nil {
+			continue
+		}
+		for _, archive := range archives {
+			if j.Archive == archive {
+				found[path] = j
+				break
+			}
+		}
+	}
+	return found
+}
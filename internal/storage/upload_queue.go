@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrUploadDeferred is returned by CloudStorage.Store when the upload was queued
+// because the run finished outside CLOUD_UPLOAD_WINDOW.
+var ErrUploadDeferred = errors.New("cloud upload deferred to the next upload window")
+
+// errUploadQueueBusy reports that another process is draining the queue.
+var errUploadQueueBusy = errors.New("upload queue is being drained by another process")
+
+// UploadQueueEntry is one deferred cloud upload. Archive is the path passed to
+// Store (the raw archive; the bundle is resolved again when the entry is drained).
+type UploadQueueEntry struct {
+	Archive     string    `json:"archive"`
+	QueuedAt    time.Time `json:"queued_at"`
+	Attempts    int       `json:"attempts,omitempty"`
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// UploadQueueStatus summarizes the queue for notifications and --daemon-status.
+type UploadQueueStatus struct {
+	Pending    int
+	Oldest     time.Time // queue time of the oldest entry (zero when empty)
+	NextWindow time.Time // next opening of the upload window (zero when open or unset)
+	Window     string
+	LastError  string // last drain error of the oldest entry
+}
+
+// UploadQueueReporter can be implemented by storage backends that defer uploads.
+type UploadQueueReporter interface {
+	UploadQueueStatus() *UploadQueueStatus
+}
+
+// UploadQueue is the persistent FIFO of deferred cloud uploads: a JSON file
+// rewritten atomically under an flock so a backup run and the daemon can
+// update it concurrently.
+type UploadQueue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewUploadQueue returns the queue stored at path.
+func NewUploadQueue(path string) *UploadQueue {
+	return &UploadQueue{path: path}
+}
+
+// Path returns the queue file path.
+func (q *UploadQueue) Path() string {
+	return q.path
+}
+
+// Load returns the queued entries, oldest first. A missing file is an empty queue.
+func (q *UploadQueue) Load() ([]UploadQueueEntry, error) {
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read upload queue: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var entries []UploadQueueEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse upload queue %s: %w", q.path, err)
+	}
+	return entries, nil
+}
+
+// Enqueue appends entry unless its archive is already queued.
+func (q *UploadQueue) Enqueue(entry UploadQueueEntry) error {
+	return q.update(func(entries []UploadQueueEntry) []UploadQueueEntry {
+		for _, existing := range entries {
+			if existing.Archive == entry.Archive {
+				return entries
+			}
+		}
+		return append(entries, entry)
+	})
+}
+
+// Remove drops the entry of archive.
+func (q *UploadQueue) Remove(archive string) error {
+	return q.update(func(entries []UploadQueueEntry) []UploadQueueEntry {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.Archive != archive {
+				kept = append(kept, entry)
+			}
+		}
+		return kept
+	})
+}
+
+// RecordAttempt stores the outcome of a failed drain attempt for archive.
+func (q *UploadQueue) RecordAttempt(archive string, at time.Time, attemptErr error) error {
+	return q.update(func(entries []UploadQueueEntry) []UploadQueueEntry {
+		for i := range entries {
+			if entries[i].Archive == archive {
+				entries[i].Attempts++
+				entries[i].LastAttempt = at
+				entries[i].LastError = attemptErr.Error()
+			}
+		}
+		return entries
+	})
+}
+
+func (q *UploadQueue) update(mutate func([]UploadQueueEntry) []UploadQueueEntry) (err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := lockFile(q.path+".lock", syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	entries, err := q.Load()
+	if err != nil {
+		return err
+	}
+	return q.save(mutate(entries))
+}
+
+// tryDrainLock takes the drain lock without waiting; errUploadQueueBusy means
+// another process is already draining.
+func (q *UploadQueue) tryDrainLock() (func() error, error) {
+	unlock, err := lockFile(q.path+".drain.lock", syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return nil, errUploadQueueBusy
+	}
+	return unlock, err
+}
+
+// lockFile takes an flock on path (created if missing) and returns its release
+// func. It backs both the upload queue and the chunked upload journal.
+func lockFile(path string, how int) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create lock dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock %s: %w", filepath.Base(path), err)
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("lock %s: %w", filepath.Base(path), err)
+	}
+	return func() error {
+		unlockErr := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		if closeErr := f.Close(); unlockErr == nil {
+			unlockErr = closeErr
+		}
+		if unlockErr != nil {
+			return fmt.Errorf("unlock %s: %w", filepath.Base(path), unlockErr)
+		}
+		return nil
+	}, nil
+}
+
+func (q *UploadQueue) save(entries []UploadQueueEntry) error {
+	if entries == nil {
+		entries = []UploadQueueEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal upload queue: %w", err)
+	}
+	if err := writeFileAtomic(q.path, data); err != nil {
+		return fmt.Errorf("write upload queue: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data (0600) via a temp file and rename.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Status summarizes the queue; nextWindow is reported as is.
+func (q *UploadQueue) Status(window string, nextWindow time.Time) (*UploadQueueStatus, error) {
+	entries, err := q.Load()
+	if err != nil {
+		return nil, err
+	}
+	status := &UploadQueueStatus{Pending: len(entries), Window: window, NextWindow: nextWindow}
+	if len(entries) > 0 {
+		status.Oldest = entries[0].QueuedAt
+		status.LastError = entries[0].LastError
+	}
+	return status, nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tis24dev/proxsave/internal/config"
+)
+
+func TestUploadQueueEnqueueRemove(t *testing.T) {
+	q := NewUploadQueue(filepath.Join(t.TempDir(), "upload-queue.json"))
+	queuedAt := time.Date(2026, 7, 6, 14, 0, 0, 0, time.UTC)
+
+	for _, archive := range []string{"/b/a.tar.zst", "/b/b.tar.zst", "/b/a.tar.zst"} {
+		if err := q.Enqueue(UploadQueueEntry{Archive: archive, QueuedAt: queuedAt}); err != nil {
+			t.Fatalf("Enqueue(%s): %v", archive, err)
+		}
+	}
+	entries, err := q.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Archive != "/b/a.tar.zst" || entries[1].Archive != "/b/b.tar.zst" {
+		t.Fatalf("entries = %+v, want a then b without duplicates", entries)
+	}
+
+	if err := q.RecordAttempt("/b/a.tar.zst", queuedAt.Add(time.Hour), errors.New("rclone failed")); err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+	status, err := q.Status("01:00-06:00", time.Time{})
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Pending != 2 || !status.Oldest.Equal(queuedAt) || status.LastError != "rclone failed" {
+		t.Fatalf("status = %+v", status)
+	}
+
+	if err := q.Remove("/b/a.tar.zst"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	entries, _ = q.Load()
+	if len(entries) != 1 || entries[0].Archive != "/b/b.tar.zst" {
+		t.Fatalf("entries after Remove = %+v", entries)
+	}
+}
+
+func newWindowedCloudStorageForTest(t *testing.T, now time.Time) (*CloudStorage, *UploadQueue) {
+	t.Helper()
+	cfg := &config.Config{
+		CloudEnabled:           true,
+		CloudRemote:            "remote",
+		RcloneRetries:          1,
+		RcloneTimeoutOperation: 10,
+		CloudUploadWindow:      "01:00-06:00",
+		CloudUploadQueueFile:   filepath.Join(t.TempDir(), "upload-queue.json"),
+		CloudUploadQueueMaxAge: 48 * time.Hour,
+	}
+	cs := newCloudStorageForTest(cfg)
+	cs.sleep = func(time.Duration) {}
+	cs.now = func() time.Time { return now }
+	return cs, cs.uploadQueue
+}
+
+func TestCloudStorageStoreDefersOutsideUploadWindow(t *testing.T) {
+	backupFile := filepath.Join(t.TempDir(), "pve1-backup.tar.zst")
+	writeTestFile(t, backupFile, "primary")
+
+	cs, q := newWindowedCloudStorageForTest(t, time.Date(2026, 7, 6, 14, 0, 0, 0, time.UTC))
+	queue := &commandQueue{t: t}
+	cs.execCommand = queue.exec
+
+	if err := cs.Store(context.Background(), backupFile, nil); !errors.Is(err, ErrUploadDeferred) {
+		t.Fatalf("Store() error = %v, want ErrUploadDeferred", err)
+	}
+	if len(queue.calls) != 0 {
+		t.Fatalf("expected no rclone calls outside the window, got %v", queue.calls)
+	}
+	entries, err := q.Load()
+	if err != nil || len(entries) != 1 || entries[0].Archive != backupFile {
+		t.Fatalf("queue = %+v (err %v), want %s", entries, err, backupFile)
+	}
+	status := cs.UploadQueueStatus()
+	if status == nil || status.Pending != 1 || !status.NextWindow.Equal(time.Date(2026, 7, 7, 1, 0, 0, 0, time.UTC)) {
+		t.Fatalf("UploadQueueStatus() = %+v", status)
+	}
+}
+
+func TestCloudStorageDrainUploadQueueDropsStaleEntries(t *testing.T) {
+	now := time.Date(2026, 7, 7, 2, 0, 0, 0, time.UTC)
+	cs, q := newWindowedCloudStorageForTest(t, now)
+	queue := &commandQueue{t: t}
+	cs.execCommand = queue.exec
+
+	existing := filepath.Join(t.TempDir(), "old-backup.tar.zst")
+	writeTestFile(t, existing, "primary")
+	for _, entry := range []UploadQueueEntry{
+		{Archive: existing, QueuedAt: now.Add(-72 * time.Hour)},
+		{Archive: filepath.Join(t.TempDir(), "missing.tar.zst"), QueuedAt: now.Add(-time.Hour)},
+	} {
+		if err := q.Enqueue(entry); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	uploaded, err := cs.DrainUploadQueue(context.Background())
+	if err != nil {
+		t.Fatalf("DrainUploadQueue() error = %v", err)
+	}
+	if uploaded != 0 || len(queue.calls) != 0 {
+		t.Fatalf("uploaded = %d, calls = %v; want nothing uploaded", uploaded, queue.calls)
+	}
+	if entries, _ := q.Load(); len(entries) != 0 {
+		t.Fatalf("expected expired and missing entries dropped, got %+v", entries)
+	}
+}
+
+func TestCloudStorageBandwidthScheduleOverridesLimit(t *testing.T) {
+	cs := &CloudStorage{config: &config.Config{
+		RcloneBandwidthLimit:    "10M",
+		RcloneBandwidthSchedule: "08:00,512k 23:00,off",
+	}}
+	if got := cs.bandwidthLimit(); got != "08:00,512k 23:00,off" {
+		t.Fatalf("bandwidthLimit() = %q, want the schedule", got)
+	}
+	cs.config.RcloneBandwidthSchedule = ""
+	if got := cs.bandwidthLimit(); got != "10M" {
+		t.Fatalf("bandwidthLimit() = %q, want 10M", got)
+	}
+}
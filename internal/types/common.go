@@ -77,6 +77,26 @@ func (c CompressionType) String() string {
 	return string(c)
 }
 
+// CompressionEngine selects what runs the compressor: the external tools
+// (xz, zstd, pigz) or the built-in Go codecs.
+type CompressionEngine string
+
+const (
+	// CompressionEngineAuto - external tool when installed, Go codec otherwise
+	CompressionEngineAuto CompressionEngine = "auto"
+
+	// CompressionEngineNative - always the Go codecs for gzip/xz/zstd
+	CompressionEngineNative CompressionEngine = "native"
+
+	// CompressionEngineExternal - always the external tools (gzip fallback when missing)
+	CompressionEngineExternal CompressionEngine = "external"
+)
+
+// String returns the string representation of the compression engine.
+func (e CompressionEngine) String() string {
+	return string(e)
+}
+
 // BackupInfo contains information about a backup.
 type BackupInfo struct {
 	// Backup timestamp
@@ -129,6 +149,10 @@ type BackupMetadata struct {
 	// or .sha256) exists for this backup. An unverified entry (a partial or a
 	// mystery file) is inert for retention: never kept as a slot, never deleted.
 	Verified bool
+
+	// Volumes is the number of .partNNN volumes of a split archive (0 = single
+	// file). BackupFile then names the archive the volumes were split from.
+	Volumes int
 }
 
 // StorageLocation represents a storage destination.
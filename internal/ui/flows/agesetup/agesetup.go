@@ -53,6 +53,9 @@ func (u *UI) CollectRecipientDraft(ctx context.Context, recipientPath string) (*
 		kindExisting kind = iota
 		kindPassphrase
 		kindPrivateKey
+		kindSystemdCreds
+		kindHardwareToken
+		kindUnlockFile
 	)
 	items := []components.SelectorItem[kind]{
 		{
@@ -70,6 +73,21 @@ func (u *UI) CollectRecipientDraft(ctx context.Context, recipientPath string) (*
 			Description: "Derives the public key from an AGE-SECRET-KEY; not stored on the server",
 			Value:       kindPrivateKey,
 		},
+		{
+			Label:       "Generate a key sealed with systemd-creds",
+			Description: "Key stays on this host, TPM2-bound when available; decrypt with systemd-creds:",
+			Value:       kindSystemdCreds,
+		},
+		{
+			Label:       "Use a hardware token",
+			Description: "Paste an age plugin recipient (e.g. age1yubikey1...)",
+			Value:       kindHardwareToken,
+		},
+		{
+			Label:       "Generate an unlock file on removable media",
+			Description: "Key written to a USB stick or similar; decrypt with unlock-file:",
+			Value:       kindUnlockFile,
+		},
 	}
 
 	for {
@@ -178,6 +196,65 @@ func (u *UI) CollectRecipientDraft(ctx context.Context, recipientPath string) (*
 				Kind:       orchestrator.AgeRecipientInputPrivateKey,
 				PrivateKey: strings.TrimSpace(privateKey),
 			}, nil
+
+		case kindSystemdCreds:
+			name, err := shell.Ask(ctx, u.session, components.NewInput(
+				"Credential name",
+				"Name of the credential stored in /etc/credstore.encrypted.",
+				components.WithInitialValue(orchestrator.DefaultAgeCredentialName),
+				components.WithNote("A new key is generated and sealed with systemd-creds; it never leaves this host."),
+				components.WithValidate(orchestrator.ValidateAgeCredentialName),
+				components.WithInputBack(errBackToKind),
+			))
+			if errors.Is(err, errBackToKind) {
+				continue
+			}
+			if err != nil {
+				return nil, u.mapAbort(err)
+			}
+			return &orchestrator.AgeRecipientDraft{
+				Kind:           orchestrator.AgeRecipientInputSystemdCreds,
+				CredentialName: strings.TrimSpace(name),
+			}, nil
+
+		case kindHardwareToken:
+			value, err := shell.Ask(ctx, u.session, components.NewInput(
+				"Hardware token recipient",
+				"Enter the age plugin recipient of your token (e.g. from age-plugin-yubikey --list).",
+				components.WithNote("The matching age-plugin binary must be installed on this host."),
+				components.WithValidate(orchestrator.ValidateHardwareTokenRecipient),
+				components.WithInputBack(errBackToKind),
+			))
+			if errors.Is(err, errBackToKind) {
+				continue
+			}
+			if err != nil {
+				return nil, u.mapAbort(err)
+			}
+			return &orchestrator.AgeRecipientDraft{
+				Kind:      orchestrator.AgeRecipientInputHardwareToken,
+				PublicKey: strings.TrimSpace(value),
+			}, nil
+
+		case kindUnlockFile:
+			path, err := shell.Ask(ctx, u.session, components.NewInput(
+				"Unlock file",
+				"Enter the mount point of the removable media or the full path of the unlock file.",
+				components.WithPlaceholder("/media/usb"),
+				components.WithNote(fmt.Sprintf("A new key is written as %s. Keep the media offline.", orchestrator.AgeUnlockFileName)),
+				components.WithValidate(orchestrator.ValidateAgeUnlockFilePath),
+				components.WithInputBack(errBackToKind),
+			))
+			if errors.Is(err, errBackToKind) {
+				continue
+			}
+			if err != nil {
+				return nil, u.mapAbort(err)
+			}
+			return &orchestrator.AgeRecipientDraft{
+				Kind:           orchestrator.AgeRecipientInputUnlockFile,
+				UnlockFilePath: strings.TrimSpace(path),
+			}, nil
 		}
 	}
 }
@@ -187,6 +187,51 @@ func TestCollectRecipientDraftPrivateKey(t *testing.T) {
 	}
 }
 
+func TestCollectRecipientDraftSystemdCreds(t *testing.T) {
+	d, ui := newDriver(t)
+
+	ch := collect(ui, context.Background())
+	d.waitScreen("AGE encryption setup")
+	d.keys("down down down enter") // systemd-creds option
+	d.waitScreen("Credential name")
+	d.waitOutput(orchestrator.DefaultAgeCredentialName)
+	d.keys("enter") // accept the default name
+
+	res := <-ch
+	if res.err != nil {
+		t.Fatalf("unexpected error: %v", res.err)
+	}
+	if res.draft.Kind != orchestrator.AgeRecipientInputSystemdCreds || res.draft.CredentialName != orchestrator.DefaultAgeCredentialName {
+		t.Fatalf("unexpected draft: %+v", res.draft)
+	}
+}
+
+func TestCollectRecipientDraftUnlockFile(t *testing.T) {
+	d, ui := newDriver(t)
+
+	ch := collect(ui, context.Background())
+	d.waitScreen("AGE encryption setup")
+	d.keys("down down down down down enter") // unlock file option
+	d.waitScreen("Unlock file")
+	// Relative paths are rejected inline.
+	d.typeText("usb")
+	d.keys("enter")
+	d.waitOutput("must be absolute")
+	for range "usb" {
+		d.keys("backspace")
+	}
+	d.typeText("/media/usb")
+	d.keys("enter")
+
+	res := <-ch
+	if res.err != nil {
+		t.Fatalf("unexpected error: %v", res.err)
+	}
+	if res.draft.Kind != orchestrator.AgeRecipientInputUnlockFile || res.draft.UnlockFilePath != "/media/usb" {
+		t.Fatalf("unexpected draft: %+v", res.draft)
+	}
+}
+
 func TestCollectRecipientDraftEscAtSelectorAborts(t *testing.T) {
 	d, ui := newDriver(t)
 	ch := collect(ui, context.Background())